@@ -0,0 +1,42 @@
+// Command tmps runs the tools of every lab as subcommands of one binary,
+// with the same diagnostic flags, help and shell completion:
+//
+//	tmps search -q world -p test.txt
+//	tmps -resources convert run pipeline.json
+//	source <(tmps completion bash)
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/lab1/searchcmd"
+	"tmps-go-labs/lab2/convertcmd"
+)
+
+func main() {
+	os.Exit(newRoot().Execute(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func newRoot() *cli.Command {
+	root := &cli.Command{
+		Name:  "tmps",
+		About: "Runs the lab tools: the text search of lab1 and the converters of lab2.",
+		Examples: []string{
+			"tmps search -e regex -q '^[A-Z].*ing' -p test.txt",
+			"tmps convert run pipeline.json",
+			"tmps -cpuprofile cpu.out convert diff people.csv people.yaml",
+			"tmps help convert diff",
+			"source <(tmps completion bash)",
+		},
+	}
+	root.Setup = func(flags *flag.FlagSet) cli.Run {
+		diagnose := root.DiagnosticFlags(flags)
+		return func(args []string, stdout, stderr io.Writer) int {
+			return diagnose(stderr, func() int { return root.Dispatch(args, stdout, stderr) })
+		}
+	}
+	return root.Add(searchcmd.New(), convertcmd.New(), cli.Completion(root))
+}
@@ -0,0 +1,227 @@
+// Package cli is the command tree shared by the lab command-line tools. A
+// Command declares its flags, arguments, examples and subcommands; Execute
+// parses flags level by level, prints help on demand and answers the shell
+// completion requests of the scripts written by the completion command.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Exit codes are the same for every command, so scripts can rely on them:
+// 0 for success, 1 for a negative answer such as no matches or differences
+// found, and 2 for usage and runtime errors, as in grep(1) and diff(1).
+const (
+	ExitOK       = 0
+	ExitNegative = 1
+	ExitError    = 2
+)
+
+// Run runs a command with the arguments left after its flags.
+type Run func(args []string, stdout, stderr io.Writer) int
+
+// Command is a node of a command tree.
+type Command struct {
+	Name    string
+	Summary string
+	// Usage shows what follows the command's flags, e.g. "<file> <file>".
+	Usage    string
+	About    string
+	Examples []string
+	// Setup defines the command's flags and returns the function running it
+	// with them. A command with subcommands may return nil to dispatch to
+	// them directly, or a Run that wraps its own Dispatch call.
+	Setup func(flags *flag.FlagSet) Run
+	// Values lists the accepted values of flags, by flag name without the
+	// dash. They are shown in help and offered as completions.
+	Values map[string]func() []string
+	// Args lists completions for positional arguments; without it the
+	// shell completes file names.
+	Args     func() []string
+	Commands []*Command
+	Hidden   bool
+
+	parent *Command
+}
+
+// Add attaches subcommands to c and returns c.
+func (c *Command) Add(commands ...*Command) *Command {
+	for _, command := range commands {
+		command.parent = c
+		c.Commands = append(c.Commands, command)
+	}
+	return c
+}
+
+// Path is the command's name prefixed with its parents', as typed on the
+// command line.
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Find returns the visible or hidden subcommand called name.
+func (c *Command) Find(name string) (*Command, bool) {
+	for _, command := range c.Commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+	return nil, false
+}
+
+// Execute parses c's flags from args and runs it.
+func (c *Command) Execute(args []string, stdout, stderr io.Writer) int {
+	flags, run := c.flagSet(stderr)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitError
+	}
+	if run == nil {
+		return c.Dispatch(flags.Args(), stdout, stderr)
+	}
+	return run(flags.Args(), stdout, stderr)
+}
+
+// Dispatch runs the subcommand named by the first argument. It also answers
+// "help [command]" and the hidden completion requests.
+func (c *Command) Dispatch(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		c.PrintUsage(stderr)
+		return ExitError
+	}
+
+	switch args[0] {
+	case "help":
+		return c.help(args[1:], stdout, stderr)
+	case completeCommand:
+		c.Complete(args[1:], stdout)
+		return ExitOK
+	}
+
+	command, ok := c.Find(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "%s: unknown command %q\n\n", c.Path(), args[0])
+		c.PrintUsage(stderr)
+		return ExitError
+	}
+	return command.Execute(args[1:], stdout, stderr)
+}
+
+func (c *Command) help(args []string, stdout, stderr io.Writer) int {
+	command := c
+	for _, name := range args {
+		sub, ok := command.Find(name)
+		if !ok {
+			fmt.Fprintf(stderr, "%s: unknown command %q\n", command.Path(), name)
+			return ExitError
+		}
+		command = sub
+	}
+	command.PrintUsage(stdout)
+	return ExitOK
+}
+
+// flagSet builds c's flags. The returned Run is nil when c only dispatches.
+func (c *Command) flagSet(stderr io.Writer) (*flag.FlagSet, Run) {
+	flags := flag.NewFlagSet(c.Path(), flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var run Run
+	if c.Setup != nil {
+		run = c.Setup(flags)
+	}
+	flags.Usage = func() { c.printUsage(stderr, flags) }
+	return flags, run
+}
+
+// PrintUsage writes c's help: usage line, description, flags with their
+// accepted values, subcommands and examples.
+func (c *Command) PrintUsage(w io.Writer) {
+	flags, _ := c.flagSet(io.Discard)
+	c.printUsage(w, flags)
+}
+
+func (c *Command) printUsage(w io.Writer, flags *flag.FlagSet) {
+	usage := c.Path()
+	if hasFlags(flags) {
+		usage += " [flags]"
+	}
+	switch {
+	case c.Usage != "":
+		usage += " " + c.Usage
+	case len(c.Commands) > 0:
+		usage += " <command>"
+	}
+	fmt.Fprintf(w, "Usage: %s\n", usage)
+
+	if c.About != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(c.About))
+	}
+
+	if hasFlags(flags) {
+		fmt.Fprintln(w, "\nFlags:")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+
+	if len(c.Values) > 0 {
+		fmt.Fprintln(w, "\nValues:")
+		for _, name := range sortedKeys(c.Values) {
+			fmt.Fprintf(w, "  -%s: %s\n", name, strings.Join(c.Values[name](), ", "))
+		}
+	}
+
+	if visible := c.visibleCommands(); len(visible) > 0 {
+		fmt.Fprintln(w, "\nCommands:")
+		width := 0
+		for _, command := range visible {
+			width = max(width, len(command.Name))
+		}
+		for _, command := range visible {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, command.Name, command.Summary)
+		}
+		fmt.Fprintf(w, "\nRun '%s help <command>' for details.\n", c.Path())
+	}
+
+	if len(c.Examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, example := range c.Examples {
+			fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+}
+
+func (c *Command) visibleCommands() []*Command {
+	var visible []*Command
+	for _, command := range c.Commands {
+		if !command.Hidden {
+			visible = append(visible, command)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool { return visible[i].Name < visible[j].Name })
+	return visible
+}
+
+func hasFlags(flags *flag.FlagSet) bool {
+	found := false
+	flags.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
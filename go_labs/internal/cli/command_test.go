@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTree() (*Command, *[]string) {
+	var calls []string
+	root := &Command{Name: "tool"}
+	root.Setup = func(flags *flag.FlagSet) Run {
+		flags.Bool("v", false, "verbose")
+		flags.String("config", "", "config file")
+		return nil
+	}
+	convert := &Command{
+		Name:     "convert",
+		Summary:  "convert a file",
+		Usage:    "<file>",
+		Examples: []string{"tool convert -to yaml people.csv"},
+		Values: map[string]func() []string{
+			"to": func() []string { return []string{"json", "xml", "yaml"} },
+		},
+		Setup: func(flags *flag.FlagSet) Run {
+			to := flags.String("to", "json", "output format")
+			flags.Bool("pretty", false, "indent the output")
+			return func(args []string, stdout, stderr io.Writer) int {
+				calls = append(calls, "convert "+*to+" "+strings.Join(args, " "))
+				return ExitOK
+			}
+		},
+	}
+	hidden := &Command{Name: "debug", Hidden: true, Setup: func(*flag.FlagSet) Run {
+		return func([]string, io.Writer, io.Writer) int { return 1 }
+	}}
+	return root.Add(convert, hidden, Completion(root)), &calls
+}
+
+func complete(root *Command, words ...string) []string {
+	var out bytes.Buffer
+	root.Complete(words, &out)
+	return strings.Fields(out.String())
+}
+
+func TestExecuteDispatchesThroughTheTree(t *testing.T) {
+	root, calls := testTree()
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, ExitOK, root.Execute([]string{"-v", "convert", "-to", "yaml", "people.csv"}, &stdout, &stderr))
+	assert.Equal(t, []string{"convert yaml people.csv"}, *calls)
+	assert.Equal(t, 1, root.Execute([]string{"debug"}, &stdout, &stderr))
+
+	assert.Equal(t, ExitError, root.Execute([]string{"nope"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `tool: unknown command "nope"`)
+	assert.Equal(t, ExitError, root.Execute(nil, &stdout, &stderr))
+	assert.Equal(t, ExitError, root.Execute([]string{"convert", "-bogus"}, &stdout, &stderr))
+	assert.Equal(t, ExitOK, root.Execute([]string{"convert", "-h"}, &stdout, &stderr))
+}
+
+func TestHelpListsFlagsValuesCommandsAndExamples(t *testing.T) {
+	root, _ := testTree()
+	var stdout, stderr bytes.Buffer
+
+	assert.Equal(t, ExitOK, root.Execute([]string{"help"}, &stdout, &stderr))
+	help := stdout.String()
+	assert.Contains(t, help, "Usage: tool [flags] <command>")
+	assert.Contains(t, help, "-config string")
+	assert.Contains(t, help, "convert     convert a file")
+	assert.NotContains(t, help, "debug")
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, root.Execute([]string{"help", "convert"}, &stdout, &stderr))
+	help = stdout.String()
+	assert.Contains(t, help, "Usage: tool convert [flags] <file>")
+	assert.Contains(t, help, "-to: json, xml, yaml")
+	assert.Contains(t, help, "Examples:\n  tool convert -to yaml people.csv")
+
+	assert.Equal(t, ExitError, root.Execute([]string{"help", "nope"}, &stdout, &stderr))
+}
+
+func TestCompleteCommandsFlagsAndValues(t *testing.T) {
+	root, _ := testTree()
+
+	assert.Equal(t, []string{"completion", "convert", "help"}, complete(root, ""))
+	assert.Equal(t, []string{"completion", "convert"}, complete(root, "co"))
+	assert.Equal(t, []string{"convert"}, complete(root, "-config", "tool.json", "help", "con"))
+	assert.Equal(t, []string{"-config", "-v"}, complete(root, "-"))
+	assert.Equal(t, []string{"-pretty", "-to"}, complete(root, "-v", "convert", "-"))
+	assert.Equal(t, []string{"json", "xml", "yaml"}, complete(root, "convert", "-to", ""))
+	assert.Equal(t, []string{"xml"}, complete(root, "convert", "--to", "x"))
+	assert.Empty(t, complete(root, "convert", "-pretty", ""), "positional arguments fall back to files")
+	assert.Empty(t, complete(root, "-config", ""), "flags without values fall back to files")
+	assert.Equal(t, []string{"bash", "fish", "zsh"}, complete(root, "completion", ""))
+}
+
+func TestCompletionScripts(t *testing.T) {
+	root, _ := testTree()
+	for _, shell := range Shells {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, ExitOK, root.Execute([]string{"completion", shell}, &stdout, &stderr))
+		assert.Contains(t, stdout.String(), "tool __complete", shell)
+		assert.NotContains(t, stdout.String(), "PROGRAM", shell)
+	}
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, ExitError, root.Execute([]string{"completion", "tcsh"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `unsupported shell "tcsh"`)
+}
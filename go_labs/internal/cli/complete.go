@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// completeCommand is the hidden command the completion scripts call with the
+// words typed so far, the last one being the word under the cursor.
+const completeCommand = "__complete"
+
+// Shells lists the shells the completion command writes scripts for.
+var Shells = []string{"bash", "fish", "zsh"}
+
+// Complete writes the completions of the last of words, one per line. An
+// empty answer makes the scripts fall back to file names.
+func (c *Command) Complete(words []string, w io.Writer) {
+	current := ""
+	if len(words) > 0 {
+		current, words = words[len(words)-1], words[:len(words)-1]
+	}
+
+	command := c
+	flags, _ := command.flagSet(io.Discard)
+	positional := false
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if strings.HasPrefix(word, "-") && word != "-" {
+			if f := lookupFlag(flags, word); f != nil && takesValue(f) && !strings.Contains(word, "=") {
+				i++
+			}
+			continue
+		}
+		if word == "help" && len(command.Commands) > 0 && !positional {
+			continue
+		}
+		if sub, ok := command.Find(word); ok && !positional {
+			command = sub
+			flags, _ = command.flagSet(io.Discard)
+			continue
+		}
+		positional = true
+	}
+
+	var candidates []string
+	switch {
+	case len(words) > 0 && isValueOf(flags, words[len(words)-1]):
+		if values, ok := command.Values[flagName(words[len(words)-1])]; ok {
+			candidates = values()
+		}
+	case strings.HasPrefix(current, "-"):
+		flags.VisitAll(func(f *flag.Flag) { candidates = append(candidates, "-"+f.Name) })
+	case len(command.Commands) > 0 && !positional:
+		for _, sub := range command.visibleCommands() {
+			candidates = append(candidates, sub.Name)
+		}
+		candidates = append(candidates, "help")
+	case command.Args != nil:
+		candidates = command.Args()
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Fprintln(w, candidate)
+		}
+	}
+}
+
+func flagName(word string) string {
+	name := strings.TrimLeft(word, "-")
+	name, _, _ = strings.Cut(name, "=")
+	return name
+}
+
+func lookupFlag(flags *flag.FlagSet, word string) *flag.Flag {
+	return flags.Lookup(flagName(word))
+}
+
+func takesValue(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !boolFlag.IsBoolFlag()
+}
+
+// isValueOf reports whether the word after previous is a flag value.
+func isValueOf(flags *flag.FlagSet, previous string) bool {
+	if !strings.HasPrefix(previous, "-") || strings.Contains(previous, "=") {
+		return false
+	}
+	f := lookupFlag(flags, previous)
+	return f != nil && takesValue(f)
+}
+
+// Completion returns the command writing the completion script of root for
+// a shell:
+//
+//	source <(convert completion bash)
+//	convert completion fish > ~/.config/fish/completions/convert.fish
+func Completion(root *Command) *Command {
+	return &Command{
+		Name:    "completion",
+		Summary: "print a shell completion script (" + strings.Join(Shells, ", ") + ")",
+		Usage:   "<shell>",
+		About: "Prints the completion script of " + root.Name + " for a shell. The script asks\n" +
+			root.Name + " itself for completions, so it stays in sync with its commands and flags.",
+		Examples: []string{
+			"source <(" + root.Name + " completion bash)",
+			root.Name + " completion zsh > \"${fpath[1]}/_" + root.Name + "\"",
+			root.Name + " completion fish > ~/.config/fish/completions/" + root.Name + ".fish",
+		},
+		Args: func() []string { return Shells },
+		Setup: func(flags *flag.FlagSet) Run {
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) != 1 {
+					flags.Usage()
+					return ExitError
+				}
+				script, ok := completionScripts[args[0]]
+				if !ok {
+					fmt.Fprintf(stderr, "%s completion: unsupported shell %q (want one of %s)\n",
+						root.Name, args[0], strings.Join(Shells, ", "))
+					return ExitError
+				}
+				fmt.Fprint(stdout, strings.ReplaceAll(script, "PROGRAM", root.Name))
+				return ExitOK
+			}
+		},
+	}
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for PROGRAM
+_PROGRAM_complete() {
+	local IFS=$'\n'
+	COMPREPLY=($(PROGRAM ` + completeCommand + ` "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _PROGRAM_complete PROGRAM
+`,
+	"zsh": `#compdef PROGRAM
+# zsh completion for PROGRAM
+_PROGRAM_complete() {
+	local -a candidates
+	candidates=(${(f)"$(PROGRAM ` + completeCommand + ` "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+	if (( ${#candidates} )); then
+		compadd -a candidates
+	else
+		_files
+	fi
+}
+compdef _PROGRAM_complete PROGRAM
+`,
+	"fish": `# fish completion for PROGRAM
+function __PROGRAM_complete
+	PROGRAM ` + completeCommand + ` (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null
+end
+complete -c PROGRAM -f -n 'test (count (__PROGRAM_complete)) -gt 0' -a '(__PROGRAM_complete)'
+complete -c PROGRAM -f -n 'test (count (__PROGRAM_complete)) -eq 0' -a '(__fish_complete_path (commandline -ct))'
+`,
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"tmps-go-labs/internal/logging"
+	"tmps-go-labs/lab2/domain/diagnostics"
+)
+
+// Diagnose runs a command's work under the diagnostic flags, returning its
+// exit code.
+type Diagnose func(stderr io.Writer, run func() int) int
+
+// DiagnosticFlags adds the -cpuprofile, -memprofile and -resources flags
+// and the -verbose, -quiet and -log-format logging flags every tool takes
+// to c's flags, so they read the same in each of them, and returns the
+// function applying them around c's work. The logging flags set the
+// default slog logger, which logs to stderr; a subcommand taking them too
+// only replaces its parent's logger when they are given to it.
+func (c *Command) DiagnosticFlags(flags *flag.FlagSet) Diagnose {
+	logFlags := logging.AddFlags(flags)
+	cpuProfile := flags.String("cpuprofile", "", "write a CPU profile of the command to this file")
+	memProfile := flags.String("memprofile", "", "write a heap profile to this file when the command ends")
+	resources := flags.Bool("resources", false, "print peak memory, goroutines, allocations and GC pauses to stderr when the command ends")
+	return func(stderr io.Writer, run func() int) int {
+		if c.parent == nil || logFlags.Set() {
+			logger, err := logFlags.Logger(stderr)
+			if err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", c.Path(), err)
+				return ExitError
+			}
+			slog.SetDefault(logger)
+		}
+		if *resources {
+			monitor := diagnostics.StartResourceMonitor(diagnostics.DefaultSampleInterval)
+			defer func() { fmt.Fprintln(stderr, monitor.Stop()) }()
+		}
+		if *cpuProfile != "" {
+			stop, err := diagnostics.StartCPUProfile(*cpuProfile)
+			if err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", c.Path(), err)
+				return ExitError
+			}
+			defer func() {
+				if err := stop(); err != nil {
+					fmt.Fprintf(stderr, "%s: %v\n", c.Path(), err)
+				}
+			}()
+		}
+
+		code := run()
+		if *memProfile != "" {
+			if err := diagnostics.WriteHeapProfile(*memProfile); err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", c.Path(), err)
+				return ExitError
+			}
+		}
+		return code
+	}
+}
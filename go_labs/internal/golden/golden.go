@@ -0,0 +1,122 @@
+// Package golden runs command line tools end to end against fixture
+// directories and compares their exit codes, stdout and stderr to golden
+// files, so a change to the output of a command shows up in its tests.
+//
+// Each directory under the cases directory is a case: its args file holds
+// the arguments, one per line, and the other files are the inputs. The
+// command runs in a copy of the directory and its output is compared to
+// output.golden there. Run the tests with -update to rewrite the golden
+// files after an intended change:
+//
+//	go test ./lab1/searchcmd -run Golden -update
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// Run is a command run with args, such as (*cli.Command).Execute,
+// returning its exit code.
+type Run func(args []string, stdout, stderr io.Writer) int
+
+// Scrub rewrites output that changes between runs, such as durations,
+// before it is compared.
+type Scrub func(output string) string
+
+// Replace returns a Scrub replacing the matches of pattern with
+// replacement.
+func Replace(pattern, replacement string) Scrub {
+	compiled := regexp.MustCompile(pattern)
+	return func(output string) string {
+		return compiled.ReplaceAllString(output, replacement)
+	}
+}
+
+// Durations replaces Go durations such as 1.5ms with <duration>.
+var Durations = Replace(`\b\d+(\.\d+)?(ns|µs|ms|s|m|h)\b`, "<duration>")
+
+// Test runs every case under dir as a subtest named after it.
+func Test(t *testing.T, dir string, run Run, scrubs ...Scrub) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		caseDir, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(entry.Name(), func(t *testing.T) {
+			testCase(t, caseDir, run, scrubs)
+		})
+	}
+}
+
+func testCase(t *testing.T, dir string, run Run, scrubs []Scrub) {
+	data, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		args = nil
+	}
+
+	work := t.TempDir()
+	if err := os.CopyFS(work, os.DirFS(dir)); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(work)
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	got := Format(code, stdout.String(), stderr.String())
+	for _, scrub := range scrubs {
+		got = scrub(got)
+	}
+
+	path := filepath.Join(dir, "output.golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run the test with -update to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("output of %s differs from %s; run the test with -update if the change is intended\n--- want\n%s--- got\n%s",
+			strings.Join(args, " "), path, want, got)
+	}
+}
+
+// Format renders a run as a golden file: the exit code, stdout and
+// stderr, each after a header line. Output not ending in a newline is
+// marked, so a lost trailing newline changes the file.
+func Format(code int, stdout, stderr string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- exit --\n%d\n", code)
+	for _, section := range []struct{ name, output string }{{"stdout", stdout}, {"stderr", stderr}} {
+		fmt.Fprintf(&b, "-- %s --\n%s", section.name, section.output)
+		if section.output != "" && !strings.HasSuffix(section.output, "\n") {
+			b.WriteString("\n-- no newline at end --\n")
+		}
+	}
+	return b.String()
+}
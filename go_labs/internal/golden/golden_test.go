@@ -0,0 +1,52 @@
+package golden
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "-- exit --\n0\n-- stdout --\nhello\n-- stderr --\n", Format(0, "hello\n", ""))
+	assert.Equal(t, "-- exit --\n2\n-- stdout --\n-- stderr --\nfailed\n-- no newline at end --\n", Format(2, "", "failed"))
+}
+
+func TestScrubs(t *testing.T) {
+	assert.Equal(t, "took <duration>, then <duration>; 3 records", Durations("took 1.5ms, then 2s; 3 records"))
+	assert.Equal(t, "id <id>", Replace(`[0-9a-f]{16}`, "<id>")("id 0123456789abcdef"))
+}
+
+func TestRunsCasesInACopyOfTheirDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeCase := func(name, args, golden string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "args"), []byte(args), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "input.txt"), []byte("hello\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "output.golden"), []byte(golden), 0644))
+	}
+	writeCase("cat", "input.txt\n", "-- exit --\n0\n-- stdout --\nhello\n-- stderr --\n")
+	writeCase("missing", "gone.txt\n", "-- exit --\n2\n-- stdout --\n-- stderr --\nopen gone.txt: no such file or directory\n")
+
+	var ran []string
+	cat := func(args []string, stdout, stderr io.Writer) int {
+		ran = append(ran, strings.Join(args, " "))
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		os.WriteFile("written.txt", data, 0644)
+		stdout.Write(data)
+		return 0
+	}
+	Test(t, dir, cat)
+
+	assert.Equal(t, []string{"input.txt", "gone.txt"}, ran)
+	assert.NoFileExists(t, filepath.Join(dir, "cat", "written.txt"), "cases run in a copy")
+}
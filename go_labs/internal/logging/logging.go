@@ -0,0 +1,177 @@
+// Package logging builds the slog loggers of the lab tools: leveled, with a
+// compact console handler for people and a JSON handler for log
+// collectors, and the -verbose, -quiet and -log-format flags choosing
+// between them, so every tool's diagnostics read and are controlled the
+// same way.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats of the handlers.
+const (
+	Console = "console"
+	JSON    = "json"
+)
+
+// Options choose a logger's level and format. The zero value logs info
+// and above to the console handler.
+type Options struct {
+	Level  slog.Leveler
+	Format string
+}
+
+// New returns a logger writing to w.
+func New(w io.Writer, options Options) (*slog.Logger, error) {
+	level := options.Level
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	switch options.Format {
+	case "", Console:
+		return slog.New(NewConsoleHandler(w, level)), nil
+	case JSON:
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q; use %s or %s", options.Format, Console, JSON)
+}
+
+// Flags are the logging flags of a command.
+type Flags struct {
+	flags   *flag.FlagSet
+	verbose *bool
+	quiet   *bool
+	format  *string
+}
+
+// AddFlags adds -verbose, -quiet and -log-format to flags.
+func AddFlags(flags *flag.FlagSet) *Flags {
+	return &Flags{
+		flags:   flags,
+		verbose: flags.Bool("verbose", false, "log debug messages too"),
+		quiet:   flags.Bool("quiet", false, "log errors only"),
+		format:  flags.String("log-format", Console, "format of the log on stderr: "+Console+" or "+JSON),
+	}
+}
+
+// Set reports whether any of the logging flags was given.
+func (f *Flags) Set() bool {
+	set := false
+	f.flags.Visit(func(flag *flag.Flag) {
+		switch flag.Name {
+		case "verbose", "quiet", "log-format":
+			set = true
+		}
+	})
+	return set
+}
+
+// Logger returns the logger the flags ask for, writing to w.
+func (f *Flags) Logger(w io.Writer) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	switch {
+	case *f.verbose && *f.quiet:
+		return nil, fmt.Errorf("-verbose and -quiet cannot be combined")
+	case *f.verbose:
+		level = slog.LevelDebug
+	case *f.quiet:
+		level = slog.LevelError
+	}
+	return New(w, Options{Level: level, Format: *f.format})
+}
+
+// ConsoleHandler writes one line per record: the level, the message and
+// the attributes as key=value, quoting values with spaces. It leaves out
+// the time, which the terminal does not need.
+type ConsoleHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	prefix string
+	attrs  string
+}
+
+// NewConsoleHandler returns a console handler writing records at level or
+// above to w.
+func NewConsoleHandler(w io.Writer, level slog.Leveler) *ConsoleHandler {
+	return &ConsoleHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *ConsoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *ConsoleHandler) Handle(_ context.Context, record slog.Record) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%-5s %s", record.Level, record.Message)
+	buf.WriteString(h.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		appendAttr(&buf, h.prefix, attr)
+		return true
+	})
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *ConsoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var buf bytes.Buffer
+	for _, attr := range attrs {
+		appendAttr(&buf, h.prefix, attr)
+	}
+	copied := *h
+	copied.attrs += buf.String()
+	return &copied
+}
+
+func (h *ConsoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	copied := *h
+	copied.prefix += name + "."
+	return &copied
+}
+
+func appendAttr(buf *bytes.Buffer, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			appendAttr(buf, prefix, member)
+		}
+		return
+	}
+
+	var value string
+	switch attr.Value.Kind() {
+	case slog.KindDuration:
+		value = attr.Value.Duration().Round(time.Microsecond).String()
+	case slog.KindTime:
+		value = attr.Value.Time().Format(time.RFC3339)
+	default:
+		value = attr.Value.String()
+	}
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(buf, " %s%s=%s", prefix, attr.Key, value)
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: slog.LevelDebug})
+	require.NoError(t, err)
+
+	logger.With("run", 7).WithGroup("step").Debug("completed", "index", 2, "duration", 1500*time.Microsecond, "path", "my file.csv")
+	logger.Warn("step failed", "error", errors.New("bad input"), slog.Group("size", "in", 10, "out", 0), "note", "")
+	assert.Equal(t, `DEBUG completed run=7 step.index=2 step.duration=1.5ms step.path="my file.csv"
+WARN  step failed error="bad input" size.in=10 size.out=0 note=""
+`, buf.String())
+}
+
+func TestFlags(t *testing.T) {
+	logger := func(args ...string) (*slog.Logger, *Flags, error) {
+		flags := flag.NewFlagSet("tool", flag.ContinueOnError)
+		logFlags := AddFlags(flags)
+		require.NoError(t, flags.Parse(args))
+		logger, err := logFlags.Logger(&bytes.Buffer{})
+		return logger, logFlags, err
+	}
+
+	quiet, flags, err := logger()
+	require.NoError(t, err)
+	assert.False(t, flags.Set())
+	assert.True(t, quiet.Enabled(context.Background(), slog.LevelInfo))
+	assert.False(t, quiet.Enabled(context.Background(), slog.LevelDebug))
+
+	verbose, flags, err := logger("-verbose", "-log-format", "json")
+	require.NoError(t, err)
+	assert.True(t, flags.Set())
+	assert.True(t, verbose.Enabled(context.Background(), slog.LevelDebug))
+	assert.IsType(t, &slog.JSONHandler{}, verbose.Handler())
+
+	errorsOnly, _, err := logger("-quiet")
+	require.NoError(t, err)
+	assert.False(t, errorsOnly.Enabled(context.Background(), slog.LevelWarn))
+
+	_, _, err = logger("-verbose", "-quiet")
+	assert.EqualError(t, err, "-verbose and -quiet cannot be combined")
+	_, _, err = logger("-log-format", "xml")
+	assert.EqualError(t, err, `unknown log format "xml"; use console or json`)
+}
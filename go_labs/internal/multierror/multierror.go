@@ -0,0 +1,95 @@
+// Package multierror collects the failures of batch operations, such as
+// searching several files or running several pipelines, so they can be
+// reported together instead of stopping at the first.
+package multierror
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Error is a list of errors. errors.Is and errors.As look through all of
+// them, as with errors.Join.
+type Error struct {
+	Errors []error
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+func (e *Error) Unwrap() []error {
+	return e.Errors
+}
+
+// Append returns err with errs added, flattening *Error values on either
+// side and dropping nils. It returns nil when there are no errors left, and
+// the only error itself when there is one.
+func Append(err error, errs ...error) error {
+	var all []error
+	for _, e := range append([]error{err}, errs...) {
+		switch typed := e.(type) {
+		case nil:
+		case *Error:
+			all = append(all, typed.Errors...)
+		default:
+			all = append(all, e)
+		}
+	}
+	switch len(all) {
+	case 0:
+		return nil
+	case 1:
+		return all[0]
+	}
+	return &Error{Errors: all}
+}
+
+// Collector gathers errors from concurrent goroutines. The zero value is
+// ready to use.
+type Collector struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Add records err unless it is nil.
+func (c *Collector) Add(err error) {
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = Append(c.err, err)
+}
+
+// Err returns the errors added so far, as Append does.
+func (c *Collector) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Errors lists the errors in err: none for nil, those of an *Error and err
+// itself otherwise.
+func Errors(err error) []error {
+	switch typed := err.(type) {
+	case nil:
+		return nil
+	case *Error:
+		return typed.Errors
+	}
+	return []error{err}
+}
+
+// Len returns the number of errors in err.
+func Len(err error) int {
+	return len(Errors(err))
+}
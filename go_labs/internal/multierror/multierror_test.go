@@ -0,0 +1,47 @@
+package multierror
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppend(t *testing.T) {
+	assert.NoError(t, Append(nil, nil))
+
+	single := errors.New("a failed")
+	assert.Same(t, single, Append(nil, single, nil))
+
+	err := Append(Append(single, errors.New("b failed")), &os.PathError{Op: "open", Path: "c.txt", Err: fs.ErrNotExist})
+	assert.EqualError(t, err, "3 errors: a failed; b failed; open c.txt: file does not exist")
+	assert.Equal(t, 3, Len(err))
+	assert.Equal(t, []error{single}, Errors(single))
+	assert.Empty(t, Errors(nil))
+	assert.ErrorIs(t, err, single)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	var pathErr *os.PathError
+	assert.ErrorAs(t, err, &pathErr)
+	assert.Equal(t, "c.txt", pathErr.Path)
+}
+
+func TestCollector(t *testing.T) {
+	var collector Collector
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				collector.Add(errors.New("failed"))
+			} else {
+				collector.Add(nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 5, Len(collector.Err()))
+}
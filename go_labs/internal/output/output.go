@@ -0,0 +1,236 @@
+// Package output renders the results of the lab tools, such as search
+// matches and diff changes, as plain text, JSON, CSV, YAML or the
+// tab-separated porcelain format, so every tool writes them the same way.
+// Results are Rows: named fields in display order.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats the writers support.
+const (
+	Plain     = "plain"
+	JSON      = "json"
+	CSV       = "csv"
+	YAML      = "yaml"
+	Porcelain = "porcelain"
+)
+
+// Field is a named value of a row. A json.RawMessage value is written as
+// the JSON it holds, as text in text formats.
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Row is one result. Text is its plain-text line; without it the plain
+// format joins the field values with ": ".
+type Row struct {
+	Fields []Field
+	Text   string
+}
+
+// Writer writes rows in one format.
+type Writer interface {
+	Write(rows []Row) error
+}
+
+var writers = map[string]func(w io.Writer) Writer{
+	Plain:     func(w io.Writer) Writer { return &plainWriter{w: w} },
+	JSON:      func(w io.Writer) Writer { return &jsonWriter{w: w} },
+	CSV:       func(w io.Writer) Writer { return &csvWriter{w: csv.NewWriter(w)} },
+	YAML:      func(w io.Writer) Writer { return &yamlWriter{w: w} },
+	Porcelain: func(w io.Writer) Writer { return &porcelainWriter{w: w} },
+}
+
+// New returns the writer of format writing to w.
+func New(format string, w io.Writer) (Writer, error) {
+	newWriter, ok := writers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q; use %s", format, strings.Join(Formats(), ", "))
+	}
+	return newWriter(w), nil
+}
+
+// Formats lists the supported formats in order.
+func Formats() []string {
+	formats := make([]string, 0, len(writers))
+	for format := range writers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// text returns a value as a cell of the text formats: strings as they
+// are, JSON as written, nothing for nil and anything else as JSON.
+func text(v interface{}) string {
+	switch typed := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case json.RawMessage:
+		return string(typed)
+	case fmt.Stringer:
+		return typed.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p *plainWriter) Write(rows []Row) error {
+	for _, row := range rows {
+		line := row.Text
+		if line == "" {
+			cells := make([]string, len(row.Fields))
+			for i, field := range row.Fields {
+				cells[i] = text(field.Value)
+			}
+			line = strings.Join(cells, ": ")
+		}
+		if _, err := fmt.Fprintln(p.w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// porcelainWriter writes one row per line with its values separated by
+// tabs and no other decoration. The format is stable for scripts.
+type porcelainWriter struct {
+	w io.Writer
+}
+
+func (p *porcelainWriter) Write(rows []Row) error {
+	for _, row := range rows {
+		cells := make([]string, len(row.Fields))
+		for i, field := range row.Fields {
+			cells[i] = text(field.Value)
+		}
+		if _, err := fmt.Fprintln(p.w, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonWriter writes each call's rows as an array of objects on one line,
+// with the fields in order.
+type jsonWriter struct {
+	w io.Writer
+}
+
+func (j *jsonWriter) Write(rows []Row) error {
+	var buf strings.Builder
+	buf.WriteByte('[')
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for k, field := range row.Fields {
+			if k > 0 {
+				buf.WriteByte(',')
+			}
+			name, err := json.Marshal(field.Name)
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(field.Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(name)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteString("]\n")
+	_, err := io.WriteString(j.w, buf.String())
+	return err
+}
+
+// csvWriter writes a header of the first row's field names, then a record
+// per row.
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvWriter) Write(rows []Row) error {
+	for _, row := range rows {
+		if !c.header {
+			names := make([]string, len(row.Fields))
+			for i, field := range row.Fields {
+				names[i] = field.Name
+			}
+			if err := c.w.Write(names); err != nil {
+				return err
+			}
+			c.header = true
+		}
+		cells := make([]string, len(row.Fields))
+		for i, field := range row.Fields {
+			cells[i] = text(field.Value)
+		}
+		if err := c.w.Write(cells); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// yamlWriter writes each call's rows as a sequence of mappings, with the
+// fields in order.
+type yamlWriter struct {
+	w io.Writer
+}
+
+func (y *yamlWriter) Write(rows []Row) error {
+	sequence := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range rows {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		for _, field := range row.Fields {
+			value := field.Value
+			if raw, ok := value.(json.RawMessage); ok {
+				if err := json.Unmarshal(raw, &value); err != nil {
+					return err
+				}
+			}
+			var node yaml.Node
+			if err := node.Encode(value); err != nil {
+				return err
+			}
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Name}, &node)
+		}
+		sequence.Content = append(sequence.Content, mapping)
+	}
+	if len(rows) == 0 {
+		sequence.Style = yaml.FlowStyle
+	}
+
+	encoder := yaml.NewEncoder(y.w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(sequence); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rows = []Row{
+	{Fields: []Field{{Name: "name", Value: "Ann"}, {Name: "age", Value: 34}, {Name: "tags", Value: json.RawMessage(`["a","b"]`)}}},
+	{Fields: []Field{{Name: "name", Value: "Bob, Jr."}, {Name: "age", Value: nil}, {Name: "tags", Value: json.RawMessage(`[]`)}}, Text: "Bob is special"},
+}
+
+func render(t *testing.T, format string) string {
+	var buf bytes.Buffer
+	writer, err := New(format, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(rows))
+	return buf.String()
+}
+
+func TestWriters(t *testing.T) {
+	assert.Equal(t, "Ann: 34: [\"a\",\"b\"]\nBob is special\n", render(t, Plain))
+	assert.Equal(t, "Ann\t34\t[\"a\",\"b\"]\nBob, Jr.\t\t[]\n", render(t, Porcelain))
+	assert.Equal(t, `[{"name":"Ann","age":34,"tags":["a","b"]},{"name":"Bob, Jr.","age":null,"tags":[]}]`+"\n", render(t, JSON))
+	assert.Equal(t, "name,age,tags\nAnn,34,\"[\"\"a\"\",\"\"b\"\"]\"\n\"Bob, Jr.\",,[]\n", render(t, CSV))
+	assert.Equal(t, `- name: Ann
+  age: 34
+  tags:
+    - a
+    - b
+- name: Bob, Jr.
+  age: null
+  tags: []
+`, render(t, YAML))
+}
+
+func TestEmptyAndUnknown(t *testing.T) {
+	for format, want := range map[string]string{Plain: "", Porcelain: "", CSV: "", JSON: "[]\n", YAML: "[]\n"} {
+		var buf bytes.Buffer
+		writer, err := New(format, &buf)
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(nil))
+		assert.Equal(t, want, buf.String(), format)
+	}
+
+	_, err := New("xml", &bytes.Buffer{})
+	assert.EqualError(t, err, `unknown output format "xml"; use csv, json, plain, porcelain, yaml`)
+}
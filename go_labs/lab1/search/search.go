@@ -0,0 +1,105 @@
+// Package search implements the line search engines of the search tool:
+// literal substrings, regular expressions and fuzzy subsequences. They are
+// shared with the converter's search stage, which filters records by
+// their serialized form.
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Engine reports whether text matches query.
+type Engine interface {
+	Search(text, query string) bool
+}
+
+// engines maps engine names to their constructors, guarded by enginesMu.
+var engines = map[string]func() Engine{
+	"literal": func() Engine { return &Literal{} },
+	"regex":   func() Engine { return &Regex{} },
+	"fuzzy":   func() Engine { return &Fuzzy{} },
+}
+
+var enginesMu sync.RWMutex
+
+// Register makes the engines newEngine returns available as name, to the
+// search tool and the converter's search stage, replacing any engine of
+// the same name.
+func Register(name string, newEngine func() Engine) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	engines[name] = newEngine
+}
+
+// New returns the engine called name.
+func New(name string) (Engine, bool) {
+	enginesMu.RLock()
+	newEngine, ok := engines[name]
+	enginesMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return newEngine(), true
+}
+
+// Names returns the engine names in order.
+func Names() []string {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type Literal struct{}
+
+func (l *Literal) Search(text, query string) bool {
+	return strings.Contains(text, query)
+}
+
+// Regex matches query as a regular expression. Compiled queries are kept,
+// so searching many texts for the same query compiles it once.
+type Regex struct {
+	query   string
+	pattern *regexp.Regexp
+}
+
+func (r *Regex) Search(text, query string) bool {
+	if r.pattern == nil || r.query != query {
+		pattern, err := regexp.Compile(query)
+		if err != nil {
+			return false
+		}
+		r.query, r.pattern = query, pattern
+	}
+	return r.pattern.MatchString(text)
+}
+
+type Fuzzy struct{}
+
+func (f *Fuzzy) Search(text, query string) bool {
+	textLower := strings.ToLower(text)
+	queryLower := strings.ToLower(query)
+
+	if len(queryLower) == 0 {
+		return true
+	}
+
+	textIdx := 0
+	queryIdx := 0
+
+	for textIdx < len(textLower) && queryIdx < len(queryLower) {
+		if textLower[textIdx] == queryLower[queryIdx] {
+			queryIdx++
+		}
+		textIdx++
+	}
+
+	return queryIdx == len(queryLower)
+}
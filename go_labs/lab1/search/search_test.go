@@ -0,0 +1,54 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiteralSearch(t *testing.T) {
+	engine := &Literal{}
+
+	assert.True(t, engine.Search("hello world", "world"))
+	assert.False(t, engine.Search("hello world", "xyz"))
+	assert.True(t, engine.Search("test", ""))
+}
+
+func TestRegexSearch(t *testing.T) {
+	engine := &Regex{}
+
+	assert.True(t, engine.Search("hello123", "\\d+"))
+	assert.False(t, engine.Search("hello", "\\d+"))
+	assert.False(t, engine.Search("hello", "["))
+	assert.True(t, engine.Search("hello", "^h"))
+}
+
+func TestFuzzySearch(t *testing.T) {
+	engine := &Fuzzy{}
+
+	assert.True(t, engine.Search("hello world", "hlowrd"))
+	assert.False(t, engine.Search("hello", "xyz"))
+	assert.True(t, engine.Search("test", ""))
+}
+
+func TestNew(t *testing.T) {
+	assert.Equal(t, []string{"fuzzy", "literal", "regex"}, Names())
+	engine, ok := New("fuzzy")
+	assert.True(t, ok)
+	assert.IsType(t, &Fuzzy{}, engine)
+	_, ok = New("soundex")
+	assert.False(t, ok)
+}
+
+func TestRegister(t *testing.T) {
+	Register("always", func() Engine { return alwaysEngine{} })
+
+	engine, ok := New("always")
+	assert.True(t, ok)
+	assert.True(t, engine.Search("anything", "else"))
+	assert.Contains(t, Names(), "always")
+}
+
+type alwaysEngine struct{}
+
+func (alwaysEngine) Search(text, query string) bool { return true }
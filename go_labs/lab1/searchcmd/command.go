@@ -0,0 +1,228 @@
+// Package searchcmd is the command of the lab1 search tool, run by the
+// search binary and as the search command of tmps.
+package searchcmd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/internal/multierror"
+	"tmps-go-labs/internal/output"
+	"tmps-go-labs/lab1/search"
+	"tmps-go-labs/lab2/pkg/convert"
+	"tmps-go-labs/lab3/vfs"
+)
+
+// binaryFormats are converted to lines before searching even without -from.
+var binaryFormats = map[convert.FileFormat]bool{convert.XLSX: true}
+
+// New returns the search command, reading files from the local disk and
+// http:// and https:// URLs. The search and tmps binaries add the
+// completion command for their own name.
+func New() *cli.Command {
+	return newCommand(vfs.Default())
+}
+
+// newCommand returns the search command reading files from files.
+func newCommand(files vfs.FileSystem) *cli.Command {
+	root := &cli.Command{
+		Name:    "search",
+		Summary: "print the lines of a file matching a query",
+		Usage:   "[command]",
+		About: "Prints the lines of a file matching a query. Exits with 0 when lines\n" +
+			"matched, 1 when none did and 2 on errors.",
+		Examples: []string{
+			"search -e literal -q world -p test.txt",
+			"search -e regex -q '^[A-Z].*ing' -f json -p test.txt",
+			"search -e fuzzy -q hlwrd -p test.txt",
+			"search -q TODO -p main.go -p runner.go",
+			"search -porcelain -q TODO -p main.go | cut -f1",
+			"search -q Lee -p people.xlsx",
+			"search -q TODO -p https://example.com/notes.txt",
+			"search -from xml -e regex -q '\"age\":4[0-9]' -p people.xml",
+			"source <(search completion bash)",
+		},
+		Values: map[string]func() []string{
+			"e":    search.Names,
+			"f":    output.Formats,
+			"from": sourceFormats,
+		},
+	}
+	root.Setup = func(flags *flag.FlagSet) cli.Run {
+		engine := flags.String("e", "literal", "search engine")
+		query := flags.String("q", "", "search query")
+		format := flags.String("f", "plain", "output format")
+		var paths pathList
+		flags.Var(&paths, "p", "file path or URL to search in; repeat to search several files")
+		from := flags.String("from", "", "convert the input from this format to one JSON record per line before searching (default: only for .xlsx)")
+		porcelain := flags.Bool("porcelain", false, "print <line number>\\t<line> per match, led by <path>\\t with several files, a format stable for scripts")
+		diagnose := root.DiagnosticFlags(flags)
+		return func(args []string, stdout, stderr io.Writer) int {
+			if len(args) > 0 {
+				return root.Dispatch(args, stdout, stderr)
+			}
+			if *query == "" || len(paths) == 0 {
+				flags.Usage()
+				return cli.ExitError
+			}
+			if *porcelain {
+				if isSet(flags, "f") {
+					fmt.Fprintf(stderr, "%s: -porcelain and -f cannot be combined\n", root.Path())
+					return cli.ExitError
+				}
+				*format = "porcelain"
+			}
+
+			return diagnose(stderr, func() int {
+				matches, err := run(files, *engine, *query, *format, paths, *from, stdout)
+				switch {
+				case err != nil:
+					for _, failure := range multierror.Errors(err) {
+						fmt.Fprintf(stderr, "%s: %v\n", root.Path(), failure)
+					}
+					return cli.ExitError
+				case matches == 0:
+					return cli.ExitNegative
+				}
+				return cli.ExitOK
+			})
+		}
+	}
+	return root
+}
+
+// run searches the files at paths in files, converted from the from format first
+// when set, writes the matches of all of them and returns their number.
+// A file that fails does not stop the others; the failures are returned
+// together, after the matches of the rest are written.
+func run(files vfs.FileSystem, engine, query, format string, paths []string, from string, stdout io.Writer) (int, error) {
+	searchEngine, ok := search.New(engine)
+	if !ok {
+		return 0, fmt.Errorf("unknown engine %q", engine)
+	}
+	resultWriter, err := NewResultWriter(format, stdout)
+	if err != nil {
+		return 0, err
+	}
+
+	var results []SearchResult
+	var errs error
+	for _, path := range paths {
+		found := &collector{}
+		if len(paths) > 1 {
+			found.path = path
+		}
+		start := time.Now()
+		if err := searchFile(files, searchEngine, query, path, from, found); err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		slog.Debug("search finished", "path", path, "engine", engine, "matches", len(found.results), "duration", time.Since(start))
+		results = append(results, found.results...)
+	}
+
+	if err := resultWriter.Write(results); err != nil {
+		return len(results), multierror.Append(errs, err)
+	}
+	return len(results), errs
+}
+
+func searchFile(files vfs.FileSystem, engine SearchEngine, query, path, from string, writer ResultWriter) error {
+	input, err := open(files, path, from)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	if err := NewRunner(engine, input, writer).Run(query); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// open opens the file at path in files as lines to search. Input in the from
+// format, or in a binary format such as XLSX when from is empty, is
+// converted to NDJSON, one record per line.
+func open(files vfs.FileSystem, path, from string) (io.ReadCloser, error) {
+	format := convert.FileFormat(from)
+	if from == "" {
+		detected, ok := convert.FormatFromPath(path)
+		if !ok || !binaryFormats[detected] {
+			return files.Open(path)
+		}
+		format = detected
+	}
+
+	slog.Debug("converting input to lines", "path", path, "from", format)
+	data, err := vfs.ReadFile(files, path)
+	if err != nil {
+		return nil, err
+	}
+	if format == convert.NDJSON {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	lines, err := convert.Bytes(data, format, convert.NDJSON)
+	if err != nil {
+		return nil, fmt.Errorf("converting %s from %s: %w", path, format, err)
+	}
+	return io.NopCloser(bytes.NewReader(lines)), nil
+}
+
+// collector keeps the results of one file, marked with its path when
+// several files are searched.
+type collector struct {
+	path    string
+	results []SearchResult
+}
+
+func (c *collector) Write(results []SearchResult) error {
+	for _, result := range results {
+		result.Path = c.path
+		c.results = append(c.results, result)
+	}
+	return nil
+}
+
+// pathList is a flag collecting every value it is given.
+type pathList []string
+
+func (p *pathList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pathList) Set(path string) error {
+	*p = append(*p, path)
+	return nil
+}
+
+// sourceFormats lists the formats the converter reads.
+func sourceFormats() []string {
+	formats := make(map[string]bool)
+	for _, conversion := range convert.Conversions() {
+		from, _, _ := strings.Cut(conversion, "-")
+		formats[from] = true
+	}
+	return names(formats)
+}
+
+func isSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
+
+func names[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package searchcmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab3/vfs"
+)
+
+func TestExitCodesAndPorcelainOutput(t *testing.T) {
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := New().Execute(args, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, stdout, _ := run("-porcelain", "-q", "world", "-p", "../test.txt")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "1\tHello world\n6\tAnother line with world\n", stdout)
+
+	code, stdout, _ = run("-q", "no such text", "-p", "../test.txt")
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout)
+
+	code, _, stderr := run("-e", "soundex", "-q", "world", "-p", "../test.txt")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown engine "soundex"`)
+
+	code, _, _ = run("-q", "world", "-p", "missing.txt")
+	assert.Equal(t, 2, code)
+
+	code, _, _ = run("-porcelain", "-f", "json", "-q", "world", "-p", "../test.txt")
+	assert.Equal(t, 2, code)
+}
+
+func TestSearchConvertsInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "people.csv")
+	require.NoError(t, os.WriteFile(path, []byte("name,city\nAnn,Paris\nBob,Oslo\n"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	code := New().Execute([]string{"-porcelain", "-from", "csv", "-q", `"city":"Oslo"`, "-p", path}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "2\t{\"city\":\"Oslo\",\"name\":\"Bob\"}\n", stdout.String())
+
+	stderr.Reset()
+	code = New().Execute([]string{"-from", "vcard", "-q", "x", "-p", path}, &stdout, &stderr)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr.String(), "converting "+path+" from vcard")
+}
+
+func TestSearchReportsEveryFailedFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := New().Execute([]string{"-porcelain", "-q", "world", "-p", "missing.txt", "-p", "../test.txt", "-p", "gone.txt"}, &stdout, &stderr)
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "../test.txt\t1\tHello world\n../test.txt\t6\tAnother line with world\n", stdout.String())
+	assert.Equal(t, "search: open missing.txt: no such file or directory\nsearch: open gone.txt: no such file or directory\n", stderr.String())
+}
+
+func TestSearchReadsThroughTheFileSystem(t *testing.T) {
+	files := vfs.NewMem(map[string][]byte{
+		"notes.txt":  []byte("buy milk\nTODO: call Ann\n"),
+		"people.csv": []byte("name,city\nAnn,Paris\n"),
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := newCommand(files).Execute([]string{"-porcelain", "-q", "Ann", "-p", "notes.txt", "-p", "people.csv", "-p", "gone.txt"}, &stdout, &stderr)
+	assert.Equal(t, 2, code)
+	assert.Equal(t, "notes.txt\t2\tTODO: call Ann\npeople.csv\t2\tAnn,Paris\n", stdout.String())
+	assert.Equal(t, "search: open gone.txt: file does not exist\n", stderr.String())
+}
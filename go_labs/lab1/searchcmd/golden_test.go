@@ -0,0 +1,11 @@
+package searchcmd
+
+import (
+	"testing"
+
+	"tmps-go-labs/internal/golden"
+)
+
+func TestGolden(t *testing.T) {
+	golden.Test(t, "testdata/golden", New().Execute)
+}
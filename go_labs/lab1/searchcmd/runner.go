@@ -1,10 +1,14 @@
-package main
+package searchcmd
 
 import (
 	"bufio"
 	"io"
 )
 
+type SearchEngine interface {
+	Search(text, query string) bool
+}
+
 type Runner struct {
 	engine SearchEngine
 	reader io.Reader
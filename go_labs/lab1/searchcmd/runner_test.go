@@ -1,4 +1,4 @@
-package main
+package searchcmd
 
 import (
 	"bytes"
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab1/search"
 )
 
 func TestRunner(t *testing.T) {
@@ -13,11 +15,12 @@ func TestRunner(t *testing.T) {
 	reader := strings.NewReader(input)
 
 	var output bytes.Buffer
-	engine := &LiteralSearch{}
-	writer := &PlainWriter{output: &output}
+	engine := &search.Literal{}
+	writer, err := NewResultWriter("plain", &output)
+	assert.NoError(t, err)
 
 	runner := NewRunner(engine, reader, writer)
-	err := runner.Run("world")
+	err = runner.Run("world")
 
 	assert.NoError(t, err)
 	assert.Contains(t, output.String(), "1: hello world")
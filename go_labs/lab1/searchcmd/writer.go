@@ -0,0 +1,56 @@
+package searchcmd
+
+import (
+	"io"
+
+	"tmps-go-labs/internal/output"
+)
+
+// SearchResult is a matching line. Path is set when several files are
+// searched.
+type SearchResult struct {
+	Path       string `json:"path,omitempty"`
+	LineNumber int    `json:"line_number"`
+	Line       string `json:"line"`
+}
+
+// Row returns the result as a row of the shared output writers, led by
+// the path when it is set.
+func (s SearchResult) Row() output.Row {
+	fields := []output.Field{
+		{Name: "line_number", Value: s.LineNumber},
+		{Name: "line", Value: s.Line},
+	}
+	if s.Path != "" {
+		fields = append([]output.Field{{Name: "path", Value: s.Path}}, fields...)
+	}
+	return output.Row{Fields: fields}
+}
+
+type ResultWriter interface {
+	Write(results []SearchResult) error
+}
+
+// NewResultWriter returns the writer of an output format: plain, json,
+// csv, yaml or porcelain, one match per line as the line number, a tab and
+// the line, a format stable for scripts.
+func NewResultWriter(format string, w io.Writer) (ResultWriter, error) {
+	writer, err := output.New(format, w)
+	if err != nil {
+		return nil, err
+	}
+	return &rowWriter{writer: writer}, nil
+}
+
+// rowWriter writes results as rows through a shared output writer.
+type rowWriter struct {
+	writer output.Writer
+}
+
+func (r *rowWriter) Write(results []SearchResult) error {
+	rows := make([]output.Row, len(results))
+	for i, result := range results {
+		rows[i] = result.Row()
+	}
+	return r.writer.Write(rows)
+}
@@ -0,0 +1,56 @@
+package searchcmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func write(t *testing.T, format string, results []SearchResult) string {
+	var buf bytes.Buffer
+	writer, err := NewResultWriter(format, &buf)
+	require.NoError(t, err)
+	assert.NoError(t, writer.Write(results))
+	return buf.String()
+}
+
+func TestPlainWriter(t *testing.T) {
+	results := []SearchResult{
+		{LineNumber: 1, Line: "hello"},
+		{LineNumber: 3, Line: "world"},
+	}
+
+	assert.Equal(t, "1: hello\n3: world\n", write(t, "plain", results))
+}
+
+func TestJSONWriter(t *testing.T) {
+	results := []SearchResult{
+		{LineNumber: 1, Line: "hello"},
+	}
+
+	out := write(t, "json", results)
+	assert.Contains(t, out, `"line_number":1`)
+	assert.Contains(t, out, `"line":"hello"`)
+}
+
+func TestPorcelainWriter(t *testing.T) {
+	results := []SearchResult{
+		{LineNumber: 2, Line: "key:\tvalue"},
+		{LineNumber: 10, Line: ""},
+	}
+
+	assert.Equal(t, "2\tkey:\tvalue\n10\t\n", write(t, "porcelain", results))
+}
+
+func TestCSVWriter(t *testing.T) {
+	results := []SearchResult{
+		{LineNumber: 4, Line: "a, b"},
+	}
+
+	assert.Equal(t, "line_number,line\n4,\"a, b\"\n", write(t, "csv", results))
+
+	_, err := NewResultWriter("xml", &bytes.Buffer{})
+	assert.EqualError(t, err, `unknown output format "xml"; use csv, json, plain, porcelain, yaml`)
+}
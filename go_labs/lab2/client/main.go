@@ -8,30 +8,27 @@ import (
 	"log"
 	"os"
 
-	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/pkg/convert"
 )
 
 func main() {
 	fmt.Println("Creational Design Patterns Demo: CSV → JSON → XML → YAML")
 
-	converterFactory := factory.NewConverterFactory()
-	pool := factory.NewConverterPool(5, converterFactory)
-
-	pipeline, err := factory.NewPipelineBuilder().
+	pipeline, err := convert.NewBuilder().
 		WithInputPath("input_sample.csv").
 		WithOutputPath("output_final.yaml").
 		WithSaveIntermediarySteps().
-		WithIndent().
-		WithPrettyPrint().
-		AddCSVToJSON().
-		AddJSONToXML().
-		AddXMLToYAML().
+		Apply(convert.WithIndentWidth(2)).
+		WithProfiling().
+		AddConversionStep(convert.CSV, convert.JSON).
+		AddConversionStep(convert.JSON, convert.XML).
+		AddConversionStep(convert.XML, convert.YAML).
 		Build()
 	if err != nil {
 		log.Fatalf("Pipeline build failed: %v", err)
 	}
 
-	executor := factory.NewPipelineExecutor(pool)
+	executor := convert.NewExecutor(5)
 	result := executor.Execute(pipeline)
 
 	if !result.Success {
@@ -51,6 +48,10 @@ func main() {
 					float64(len(stepResult.Data))/1024)
 			}
 		}
+
+		if pipeline.Options.Profile {
+			fmt.Printf("  Profile: %s\n", convert.ProfilePath(pipeline.OutputPath))
+		}
 	} else {
 		log.Fatalf("Output file not created: %v", err)
 	}
@@ -0,0 +1,15 @@
+// Command convert is the command-line front end of the converters. It is
+// also available as the convert command of tmps.
+package main
+
+import (
+	"os"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/lab2/convertcmd"
+)
+
+func main() {
+	root := convertcmd.New()
+	os.Exit(root.Add(cli.Completion(root)).Execute(os.Args[1:], os.Stdout, os.Stderr))
+}
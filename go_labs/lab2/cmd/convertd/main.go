@@ -0,0 +1,184 @@
+// Package main runs the conversion pipeline as an HTTP service. Tenants and
+// their quotas (input size, timeout, allowed formats, concurrency) are read
+// from a JSON file; without one the service runs open with default quotas.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"tmps-go-labs/lab2/domain/catalog"
+	"tmps-go-labs/lab2/domain/diagnostics"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/jobs"
+	"tmps-go-labs/lab2/domain/service"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	tenantsPath := flag.String("tenants", "", "path to tenants JSON file")
+	poolSize := flag.Int("pool-size", 5, "maximum pooled converters per type")
+	poolSizes := flag.String("pool-sizes", "", "per-type overrides of -pool-size, e.g. json-xml=2,xml-yaml=2")
+	poolWait := flag.Duration("pool-wait", 0, "how long a conversion waits for a busy converter before failing with 503 (0: until the tenant timeout)")
+	poolOverflow := flag.Int("pool-overflow", 0, "unpooled converters per type allowed on top of -pool-size")
+	drainDelay := flag.Duration("drain-delay", 5*time.Second, "time /readyz reports not ready before shutdown")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "time in-flight conversions get to finish on shutdown")
+	redisAddr := flag.String("redis", "", "Redis address for the /jobs queue, consumed by convertworker processes")
+	workers := flag.Int("workers", 0, "in-process workers for the /jobs queue when -redis is not set")
+	catalogPath := flag.String("catalog", "", "path to a JSON catalog of converter plugins and pipeline presets, reloaded on SIGHUP")
+	adminKey := flag.String("admin-key", "", "key for the /admin endpoints (default $CONVERTD_ADMIN_KEY; unset disables them)")
+	jwtSecret := flag.String("jwt-secret", "", "HS256 secret for bearer JWTs naming a tenant (default $CONVERTD_JWT_SECRET; unset accepts API keys only)")
+	jwtTenantClaim := flag.String("jwt-tenant-claim", "tenant", "JWT claim holding the tenant name")
+	uploadDir := flag.String("upload-dir", "", "directory for resumable /uploads; unset disables them")
+	pprofAddr := flag.String("pprof-addr", "", "serve /debug/pprof on this private address, e.g. localhost:6060")
+	flag.Parse()
+	// Secrets fall back to the environment only after parsing, so -h and
+	// flag errors never print them as defaults.
+	if *adminKey == "" {
+		*adminKey = os.Getenv("CONVERTD_ADMIN_KEY")
+	}
+	if *jwtSecret == "" {
+		*jwtSecret = os.Getenv("CONVERTD_JWT_SECRET")
+	}
+
+	var tenants []service.Tenant
+	if *tenantsPath != "" {
+		loaded, err := service.LoadTenants(*tenantsPath)
+		if err != nil {
+			log.Fatalf("Loading tenants failed: %v", err)
+		}
+		tenants = loaded
+	}
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	sizes, err := parsePoolSizes(*poolSizes)
+	if err != nil {
+		log.Fatalf("Invalid -pool-sizes: %v", err)
+	}
+	pool := factory.NewConverterPool(*poolSize, factory.NewConverterFactory(),
+		factory.WithTypeSizes(sizes), factory.WithWaitTimeout(*poolWait), factory.WithOverflow(*poolOverflow))
+	executor := factory.NewPipelineExecutor(pool)
+	server := service.NewServer(executor, tenants)
+	if *jwtSecret != "" {
+		server.SetAuthenticators(service.APIKeys(tenants), service.JWT([]byte(*jwtSecret), *jwtTenantClaim))
+	}
+
+	var reload func() error
+	if *catalogPath != "" {
+		plugins := catalog.New(*catalogPath, pool)
+		if err := plugins.Reload(); err != nil {
+			log.Fatalf("Loading catalog failed: %v", err)
+		}
+		server.EnablePresets(plugins)
+		reload = plugins.Reload
+		go reloadOnHangup(plugins)
+	}
+
+	switch {
+	case *redisAddr != "":
+		client := redis.NewClient(&redis.Options{Addr: *redisAddr})
+		defer client.Close()
+		server.EnableJobs(jobs.NewRedisQueue(client, jobs.DefaultRedisPrefix))
+	case *workers > 0:
+		queue := jobs.NewMemoryQueue(1024)
+		server.EnableJobs(queue)
+		worker := jobs.NewWorker(queue, executor)
+		for i := 0; i < *workers; i++ {
+			go worker.Run(context.Background())
+		}
+	}
+
+	if *uploadDir != "" {
+		if err := server.EnableUploads(*uploadDir); err != nil {
+			log.Fatalf("Enabling uploads failed: %v", err)
+		}
+	}
+
+	if *adminKey != "" {
+		server.EnableAdmin(*adminKey, reload)
+	}
+
+	if *pprofAddr != "" {
+		go diagnostics.Serve(*pprofAddr)
+	}
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// ListenAndServe returns as soon as Shutdown closes the listener; main
+	// waits on stopped so in-flight conversions can finish draining.
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+
+		log.Printf("Draining for %s before shutdown", *drainDelay)
+		server.Drain()
+		time.Sleep(*drainDelay)
+
+		ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Aborted in-flight conversions: %v", err)
+		}
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Printf("Shutdown failed: %v", err)
+		}
+	}()
+
+	log.Printf("Conversion service listening on %s (%d tenants)", *addr, len(tenants))
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Server failed: %v", err)
+	}
+	<-stopped
+}
+
+// reloadOnHangup reloads the catalog on every SIGHUP. A failed reload keeps
+// the previous plugins and presets.
+func reloadOnHangup(plugins *catalog.Catalog) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if err := plugins.Reload(); err != nil {
+			log.Printf("Reloading catalog failed: %v", err)
+			continue
+		}
+		log.Printf("Reloaded catalog: %d plugins, %d presets", len(plugins.Plugins()), len(plugins.Presets()))
+	}
+}
+
+// parsePoolSizes reads "type=size" pairs separated by commas.
+func parsePoolSizes(text string) (map[string]int, error) {
+	sizes := make(map[string]int)
+	for _, pair := range strings.Split(text, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		converterType, value, ok := strings.Cut(pair, "=")
+		size, err := strconv.Atoi(value)
+		if !ok || err != nil || size < 1 {
+			return nil, fmt.Errorf("%q is not type=size with a positive size", pair)
+		}
+		sizes[converterType] = size
+	}
+	return sizes, nil
+}
@@ -0,0 +1,77 @@
+// Package main runs conversion workers that take jobs queued by the
+// conversion service from Redis. Start as many worker processes as needed;
+// they share the queue and scale conversions horizontally.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/redis/go-redis/v9"
+
+	"tmps-go-labs/internal/logging"
+	"tmps-go-labs/lab2/domain/diagnostics"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/jobs"
+)
+
+func main() {
+	redisAddr := flag.String("redis", "localhost:6379", "Redis address")
+	prefix := flag.String("prefix", jobs.DefaultRedisPrefix, "Redis key prefix shared with the service")
+	concurrency := flag.Int("concurrency", 4, "jobs processed in parallel")
+	poolSize := flag.Int("pool-size", 5, "maximum pooled converters per type")
+	requeue := flag.Bool("requeue", false, "move jobs left unfinished by stopped workers back onto the queue; only while no other worker runs")
+	drainTimeout := flag.Duration("drain-timeout", jobs.DefaultDrainTimeout, "time running jobs get to finish on shutdown before they are left for -requeue")
+	pprofAddr := flag.String("pprof-addr", "", "serve /debug/pprof on this private address, e.g. localhost:6060")
+	logFlags := logging.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	logger, err := logFlags.Logger(os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	if *pprofAddr != "" {
+		go diagnostics.Serve(*pprofAddr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client := redis.NewClient(&redis.Options{Addr: *redisAddr})
+	defer client.Close()
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Connecting to Redis failed: %v", err)
+	}
+
+	queue := jobs.NewRedisQueue(client, *prefix)
+	if *requeue {
+		moved, err := queue.Requeue(ctx)
+		if err != nil {
+			log.Fatalf("Requeueing unfinished jobs failed: %v", err)
+		}
+		log.Printf("Requeued %d unfinished jobs", moved)
+	}
+	pool := factory.NewConverterPool(*poolSize, factory.NewConverterFactory())
+	worker := jobs.NewWorker(queue, factory.NewPipelineExecutor(pool)).WithLogger(logger)
+	worker.DrainTimeout = *drainTimeout
+
+	log.Printf("Worker consuming %s with %d goroutines", *redisAddr, *concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker.Run(ctx)
+		}()
+	}
+	wg.Wait()
+}
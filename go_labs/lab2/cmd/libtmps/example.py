@@ -0,0 +1,35 @@
+"""Calls the lab2 converters through libtmps.
+
+    go build -buildmode=c-shared -o libtmps.so ./cmd/libtmps
+    python3 cmd/libtmps/example.py ./libtmps.so
+"""
+import ctypes
+import json
+import sys
+
+lib = ctypes.CDLL(sys.argv[1] if len(sys.argv) > 1 else "./libtmps.so")
+lib.Convert.argtypes = [
+    ctypes.c_char_p, ctypes.c_char_p, ctypes.c_char_p, ctypes.c_size_t, ctypes.c_char_p,
+    ctypes.POINTER(ctypes.c_void_p), ctypes.POINTER(ctypes.c_size_t), ctypes.POINTER(ctypes.c_void_p),
+]
+lib.Convert.restype = ctypes.c_int
+lib.FreeBuffer.argtypes = [ctypes.c_void_p]
+
+
+def convert(source, target, data, options=None):
+    output, length, error = ctypes.c_void_p(), ctypes.c_size_t(), ctypes.c_void_p()
+    encoded = json.dumps(options).encode() if options else None
+    status = lib.Convert(source.encode(), target.encode(), data, len(data), encoded,
+                         ctypes.byref(output), ctypes.byref(length), ctypes.byref(error))
+    if status != 0:
+        message = ctypes.string_at(error).decode()
+        lib.FreeBuffer(error)
+        raise RuntimeError(message)
+    try:
+        return ctypes.string_at(output, length.value)
+    finally:
+        lib.FreeBuffer(output)
+
+
+if __name__ == "__main__":
+    print(convert("csv", "json", b"name,city\nAnn,Oslo\n", {"IndentWidth": 2}).decode())
@@ -0,0 +1,91 @@
+// Package main exports the conversion API as a C shared library so ETL
+// scripts in Python, Ruby or any language with a C FFI can call the
+// converters in process. Build with -buildmode=c-shared.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"unsafe"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+var executor = factory.NewPipelineExecutor(factory.NewConverterPool(4, factory.NewConverterFactory()))
+
+// Convert converts length bytes of data and returns 0 on success with the
+// result in output/outputLength, or 1 with a message in errorMessage. Both
+// buffers are allocated with malloc and must be released with FreeBuffer.
+// options may be NULL or a JSON-encoded ConversionOptions. Lengths are
+// size_t, so inputs and outputs past 2 GiB are not truncated.
+//
+//export Convert
+func Convert(from, to *C.char, data *C.char, length C.size_t, options *C.char, output **C.char, outputLength *C.size_t, errorMessage **C.char) C.int {
+	*output = nil
+	*outputLength = 0
+	*errorMessage = nil
+
+	input, err := inputBytes(data, length)
+	var result []byte
+	if err == nil {
+		result, err = convert(C.GoString(from), C.GoString(to), input, options)
+	}
+	if err != nil {
+		*errorMessage = C.CString(err.Error())
+		return 1
+	}
+
+	*output = (*C.char)(C.CBytes(result))
+	*outputLength = C.size_t(len(result))
+	return 0
+}
+
+// inputBytes copies the caller's buffer, rejecting lengths a Go slice
+// cannot hold and a NULL buffer with a non-zero length.
+func inputBytes(data *C.char, length C.size_t) ([]byte, error) {
+	if uint64(length) > math.MaxInt {
+		return nil, fmt.Errorf("input length %d is too large", uint64(length))
+	}
+	if length == 0 {
+		return nil, nil
+	}
+	if data == nil {
+		return nil, fmt.Errorf("input is NULL but its length is %d", uint64(length))
+	}
+	return bytes.Clone(unsafe.Slice((*byte)(unsafe.Pointer(data)), int(length))), nil
+}
+
+//export FreeBuffer
+func FreeBuffer(buffer unsafe.Pointer) {
+	C.free(buffer)
+}
+
+func convert(from, to string, input []byte, rawOptions *C.char) ([]byte, error) {
+	var options models.ConversionOptions
+	if rawOptions != nil {
+		if err := json.Unmarshal([]byte(C.GoString(rawOptions)), &options); err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+	}
+
+	pipeline := &models.Pipeline{
+		Steps:   []models.ConversionStep{{From: models.FileFormat(from), To: models.FileFormat(to)}},
+		Options: options,
+	}
+	output, result := executor.ConvertData(context.Background(), pipeline, input)
+	if !result.Success {
+		return nil, result.Error
+	}
+	return output, nil
+}
+
+func main() {}
@@ -0,0 +1,33 @@
+// JS bindings for the WebAssembly build of the lab2 converters.
+//
+//   const tmps = await loadConverter("tmps.wasm");
+//   const json = await tmps.ConvertBytes("csv", "json", csvBytes, { IndentWidth: 2 });
+//
+// wasm_exec.js from the Go distribution must be loaded first; it defines the
+// global Go class used below.
+
+export async function loadConverter(url) {
+  const go = new Go();
+  const source = typeof url === "string" ? fetch(url) : url;
+  const { instance } = await WebAssembly.instantiateStreaming(source, go.importObject);
+  go.run(instance);
+
+  return {
+    // ConvertBytes converts data (Uint8Array or string) and resolves to the
+    // converted bytes. It rejects with the converter's error message.
+    ConvertBytes(from, to, data, options) {
+      const bytes = typeof data === "string" ? new TextEncoder().encode(data) : data;
+      return globalThis.tmpsConvert(from, to, bytes, options ? JSON.stringify(options) : "");
+    },
+
+    // ConvertText is ConvertBytes for text formats, resolving to a string.
+    async ConvertText(from, to, text, options) {
+      const output = await this.ConvertBytes(from, to, text, options);
+      return new TextDecoder().decode(output);
+    },
+
+    formats() {
+      return globalThis.tmpsFormats();
+    },
+  };
+}
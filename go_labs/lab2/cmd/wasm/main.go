@@ -0,0 +1,87 @@
+//go:build js && wasm
+
+// Package main builds the converters to WebAssembly. It registers a global
+// tmpsConvert(from, to, data, options) function that convert.js wraps as
+// ConvertBytes, so browsers and Electron apps convert data client-side.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+var executor = factory.NewPipelineExecutor(factory.NewConverterPool(2, factory.NewConverterFactory()))
+
+func main() {
+	js.Global().Set("tmpsConvert", js.FuncOf(convert))
+	js.Global().Set("tmpsFormats", js.FuncOf(formats))
+	select {}
+}
+
+// convert returns a Promise so the conversion runs off the JS event loop
+// callback; blocking inside a js.Func would stall the page. The Promise
+// calls its executor before New returns, so the executor is released then.
+func convert(this js.Value, args []js.Value) interface{} {
+	start := js.FuncOf(func(_ js.Value, handlers []js.Value) interface{} {
+		resolve, reject := handlers[0], handlers[1]
+		go func() {
+			output, err := run(args)
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			array := js.Global().Get("Uint8Array").New(len(output))
+			js.CopyBytesToJS(array, output)
+			resolve.Invoke(array)
+		}()
+		return nil
+	})
+	defer start.Release()
+	return js.Global().Get("Promise").New(start)
+}
+
+func run(args []js.Value) ([]byte, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("usage: tmpsConvert(from, to, data, [optionsJSON])")
+	}
+
+	from := models.FileFormat(args[0].String())
+	to := models.FileFormat(args[1].String())
+
+	if !args[2].InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, fmt.Errorf("data must be a Uint8Array")
+	}
+	input := make([]byte, args[2].Get("length").Int())
+	js.CopyBytesToGo(input, args[2])
+
+	var options models.ConversionOptions
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &options); err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+	}
+
+	pipeline := &models.Pipeline{
+		Steps:   []models.ConversionStep{{From: from, To: to}},
+		Options: options,
+	}
+	output, result := executor.ConvertData(context.Background(), pipeline, input)
+	if !result.Success {
+		return nil, result.Error
+	}
+	return output, nil
+}
+
+func formats(this js.Value, args []js.Value) interface{} {
+	conversions := factory.RegisteredConversions()
+	list := make([]interface{}, len(conversions))
+	for i, conversion := range conversions {
+		list[i] = conversion
+	}
+	return js.ValueOf(list)
+}
@@ -0,0 +1,174 @@
+package convertcmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/lab2/domain/bundle"
+	"tmps-go-labs/lab2/domain/catalog"
+	"tmps-go-labs/lab2/domain/config"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func exportCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "export",
+		Summary: "pack a pipeline and the files it reads into a shareable bundle",
+		Usage:   "<pipeline.json>",
+		About: "Writes a zip bundle holding the pipeline config, its templates and\n" +
+			"validation schemas, the presets of a catalog and the first records of the\n" +
+			"input. Key files are not bundled; the bundle lists them as missing.",
+		Examples: []string{
+			"convert export -o people.zip pipeline.json",
+			"convert export -catalog catalog.json -presets people,orders -records 50 pipeline.json",
+		},
+		Setup: func(flags *flag.FlagSet) cli.Run {
+			var options exportOptions
+			flags.StringVar(&options.output, "o", "bundle.zip", "path of the bundle to write")
+			flags.StringVar(&options.name, "name", "", "bundle name; defaults to the config file name")
+			flags.StringVar(&options.catalog, "catalog", "", "catalog file whose presets are bundled")
+			flags.StringVar(&options.presets, "presets", "", "comma-separated presets to bundle; all when empty")
+			flags.IntVar(&options.records, "records", 100, "input records to bundle as sample data; 0 leaves the input out")
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) != 1 {
+					flags.Usage()
+					return exitError
+				}
+				if err := runExport(args[0], &options, stdout); err != nil {
+					fmt.Fprintf(stderr, "convert export: %v\n", err)
+					return exitError
+				}
+				return exitOK
+			}
+		},
+	}
+}
+
+type exportOptions struct {
+	output  string
+	name    string
+	catalog string
+	presets string
+	records int
+}
+
+func runExport(path string, options *exportOptions, stdout io.Writer) error {
+	pipeline, err := config.LoadPipeline(path)
+	if err != nil {
+		return err
+	}
+	name := options.name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	presets, err := loadPresets(options.catalog, options.presets)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(options.output)
+	if err != nil {
+		return err
+	}
+	manifest, err := bundle.Export(file, pipeline, bundle.ExportOptions{Name: name, Presets: presets, SampleRecords: options.records})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(options.output)
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Wrote %s:\n", options.output)
+	printFiles(stdout, manifest)
+	return nil
+}
+
+// loadPresets reads the named presets, or all of them, from a catalog file.
+func loadPresets(path, names string) (map[string]*models.Pipeline, error) {
+	if path == "" {
+		if names != "" {
+			return nil, fmt.Errorf("-presets needs -catalog")
+		}
+		return nil, nil
+	}
+	file, err := catalog.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if names == "" {
+		return file.Presets, nil
+	}
+	presets := make(map[string]*models.Pipeline)
+	for _, name := range strings.Split(names, ",") {
+		preset, ok := file.Presets[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("catalog %s has no preset %q", path, name)
+		}
+		presets[strings.TrimSpace(name)] = preset
+	}
+	return presets, nil
+}
+
+func importCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "import",
+		Summary: "unpack a bundle written by 'convert export'",
+		Usage:   "<bundle.zip>",
+		About: "Extracts the bundle into -dir after checking every file against the\n" +
+			"bundle's checksums, and points the paths in the pipeline config and\n" +
+			"presets at the extracted files. Existing files are never overwritten.",
+		Examples: []string{
+			"convert import -dir people people.zip && convert run people/pipeline.json",
+		},
+		Setup: func(flags *flag.FlagSet) cli.Run {
+			dir := flags.String("dir", ".", "directory to extract the bundle into")
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) != 1 {
+					flags.Usage()
+					return exitError
+				}
+				if err := runImport(args[0], *dir, stdout); err != nil {
+					fmt.Fprintf(stderr, "convert import: %v\n", err)
+					return exitError
+				}
+				return exitOK
+			}
+		},
+	}
+}
+
+func runImport(path, dir string, stdout io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	manifest, err := bundle.Import(file, info.Size(), dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Imported %s into %s:\n", manifest.Name, dir)
+	printFiles(stdout, manifest)
+	fmt.Fprintf(stdout, "\nRun it with: convert run %s\n", filepath.Join(dir, bundle.PipelineName))
+	return nil
+}
+
+func printFiles(w io.Writer, manifest *bundle.Manifest) {
+	for _, file := range manifest.Files {
+		fmt.Fprintf(w, "  %-9s %s (%d bytes)\n", file.Role, file.Path, file.Size)
+	}
+	for _, missing := range manifest.Missing {
+		fmt.Fprintf(w, "  %-9s %s (not bundled; provide it separately)\n", "missing", missing)
+	}
+}
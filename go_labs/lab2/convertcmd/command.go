@@ -0,0 +1,69 @@
+// Package convertcmd is the command tree of the converters, run by the
+// convert binary and as the convert command of tmps. Each subcommand
+// lives in its own file and is attached to the tree in New:
+//
+//	convert diff people.csv people.yaml
+//	convert -cpuprofile cpu.out run pipeline.json
+//	source <(convert completion bash)
+package convertcmd
+
+import (
+	"flag"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/lab2/domain/factory"
+)
+
+// Exit codes are the stable cli ones: 0 for success, 1 when differences or
+// leaks were found, 2 for usage and runtime errors.
+const (
+	exitOK       = cli.ExitOK
+	exitNegative = cli.ExitNegative
+	exitError    = cli.ExitError
+)
+
+// New returns the convert command tree. The convert and tmps binaries add
+// the completion command for their own name.
+func New() *cli.Command {
+	root := &cli.Command{
+		Name:    "convert",
+		Summary: "convert, compare and pipeline files between formats",
+		About: "Converts, compares and pipelines files between the supported formats:\n" +
+			strings.Join(conversionFormats(), ", ") + ".",
+		Examples: []string{
+			"convert init people.csv",
+			"convert run pipeline.json",
+			"convert diff people.csv people.yaml",
+			"convert -resources run pipeline.json",
+			"convert help diff",
+		},
+	}
+	root.Setup = func(flags *flag.FlagSet) cli.Run {
+		diagnose := root.DiagnosticFlags(flags)
+		return func(args []string, stdout, stderr io.Writer) int {
+			return diagnose(stderr, func() int { return root.Dispatch(args, stdout, stderr) })
+		}
+	}
+	return root.Add(diffCommand(), exportCommand(), graphCommand(), importCommand(), initCommand(os.Stdin), runCommand(), schemaCommand(), soakCommand())
+}
+
+// conversionFormats lists the formats of the registered conversions.
+func conversionFormats() []string {
+	seen := make(map[string]bool)
+	var formats []string
+	for _, key := range factory.RegisteredConversions() {
+		from, to, _ := strings.Cut(key, "-")
+		for _, format := range []string{from, to} {
+			if !seen[format] {
+				seen[format] = true
+				formats = append(formats, format)
+			}
+		}
+	}
+	sort.Strings(formats)
+	return formats
+}
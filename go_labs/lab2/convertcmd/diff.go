@@ -0,0 +1,188 @@
+package convertcmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/internal/output"
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func diffCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "diff",
+		Summary: "compare two files of any supported formats structurally",
+		Usage:   "<file> <file>",
+		About: "Loads both files into the document model and prints their structural\n" +
+			"differences: + added, - removed, ~ changed. Exits with 0 when the files\n" +
+			"match, 1 when they differ and 2 on errors.",
+		Examples: []string{
+			"convert diff people.csv people.yaml",
+			"convert diff -strict -right-path doc.root people.json people.xml",
+			"convert diff -left-format json -q export.txt people.json",
+			"convert diff -f csv people.csv people.yaml > changes.csv",
+		},
+		Values: map[string]func() []string{
+			"left-format":  document.ParserFormats,
+			"right-format": document.ParserFormats,
+			"f":            output.Formats,
+		},
+		Setup: func(flags *flag.FlagSet) cli.Run {
+			var options diffOptions
+			flags.StringVar(&options.leftFormat, "left-format", "", "format of the first file (default: from its extension)")
+			flags.StringVar(&options.rightFormat, "right-format", "", "format of the second file (default: from its extension)")
+			flags.StringVar(&options.leftPath, "left-path", "", "compare only this dotted path of the first file, e.g. doc.root")
+			flags.StringVar(&options.rightPath, "right-path", "", "compare only this dotted path of the second file")
+			flags.BoolVar(&options.strict, "strict", false, "compare scalar types too, so \"34\" differs from 34")
+			flags.BoolVar(&options.quiet, "q", false, "only report whether the files differ")
+			flags.StringVar(&options.format, "f", output.Plain, "output format of the changes, with the kind, path, old and new value of each")
+			porcelain := flags.Bool("porcelain", false, "print <kind>\\t<path>\\t<old>\\t<new> per change with JSON values, a format stable for scripts")
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) != 2 {
+					flags.Usage()
+					return exitError
+				}
+				if *porcelain {
+					if options.format != output.Plain {
+						fmt.Fprintln(stderr, "convert diff: -porcelain and -f cannot be combined")
+						return exitError
+					}
+					options.format = output.Porcelain
+				}
+				return runDiff(&options, args, stdout, stderr)
+			}
+		},
+	}
+}
+
+type diffOptions struct {
+	leftFormat, rightFormat string
+	leftPath, rightPath     string
+	strict, quiet           bool
+	format                  string
+}
+
+func runDiff(options *diffOptions, args []string, stdout, stderr io.Writer) int {
+	writer, err := output.New(options.format, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert diff: %v\n", err)
+		return exitError
+	}
+	left, err := load(args[0], options.leftFormat, options.leftPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert diff: %v\n", err)
+		return exitError
+	}
+	right, err := load(args[1], options.rightFormat, options.rightPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert diff: %v\n", err)
+		return exitError
+	}
+
+	changes := document.Diff(left, right, document.DiffOptions{Strict: options.strict})
+	if len(changes) == 0 {
+		return exitOK
+	}
+
+	switch {
+	case options.quiet && options.format == output.Porcelain:
+	case options.quiet:
+		fmt.Fprintf(stdout, "Files %s and %s differ\n", args[0], args[1])
+	default:
+		rows := make([]output.Row, len(changes))
+		for i, change := range changes {
+			rows[i] = changeRow(change)
+		}
+		if err := writer.Write(rows); err != nil {
+			fmt.Fprintf(stderr, "convert diff: %v\n", err)
+			return exitError
+		}
+	}
+	return exitNegative
+}
+
+// changeRow returns a change as its kind (added, removed or changed),
+// dotted path, and old and new values as JSON, which porcelain output
+// separates by tabs. The value a change lacks is left empty.
+func changeRow(change document.Change) output.Row {
+	value := func(v interface{}, present bool) interface{} {
+		if !present {
+			return nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			data, _ = json.Marshal(fmt.Sprint(v))
+		}
+		return json.RawMessage(data)
+	}
+	return output.Row{
+		Text: change.String(),
+		Fields: []output.Field{
+			{Name: "kind", Value: string(change.Kind)},
+			{Name: "path", Value: change.Path.String()},
+			{Name: "old", Value: value(change.Old, change.Kind != document.Added)},
+			{Name: "new", Value: value(change.New, change.Kind != document.Removed)},
+		},
+	}
+}
+
+// load parses path with the parser for format, or for its extension when
+// format is empty, and narrows the document to the value at subtree.
+func load(path, format, subtree string) (*document.Document, error) {
+	fileFormat := models.FileFormat(format)
+	if format == "" {
+		var ok bool
+		if fileFormat, ok = models.FormatFromPath(path); !ok {
+			return nil, fmt.Errorf("cannot tell the format of %s; pass it with -left-format or -right-format", path)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	doc, err := document.Parse(file, fileFormat, models.ConversionOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if subtree != "" {
+		value, ok := lookup(doc.Root, strings.Split(subtree, "."))
+		if !ok {
+			return nil, fmt.Errorf("%s has no value at %s", path, subtree)
+		}
+		doc.Root = value
+	}
+	return doc, nil
+}
+
+func lookup(value interface{}, path []string) (interface{}, bool) {
+	for _, part := range path {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			child, ok := typed[part]
+			if !ok {
+				return nil, false
+			}
+			value = child
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(typed) {
+				return nil, false
+			}
+			value = typed[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,12 @@
+package convertcmd
+
+import (
+	"testing"
+
+	"tmps-go-labs/internal/golden"
+)
+
+func TestGolden(t *testing.T) {
+	golden.Test(t, "testdata/golden", New().Execute,
+		golden.Durations, golden.Replace(`"nanoseconds":\d+`, `"nanoseconds":<n>`))
+}
@@ -0,0 +1,94 @@
+package convertcmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/signal"
+	"syscall"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/lab2/domain/dag"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/incremental"
+	"tmps-go-labs/lab3/vfs"
+)
+
+func graphCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "graph",
+		Summary: "run pipelines that depend on each other as one job",
+		Usage:   "<jobs.json>",
+		About: "Runs the job graph defined in a config file of the form\n" +
+			"{\"jobs\": [{\"name\": ..., \"pipeline\": ..., \"depends_on\": [...]}]}.\n" +
+			"A job runs once the jobs it depends on succeeded, explicitly or because\n" +
+			"it reads their output file; when a job fails, the jobs downstream of it\n" +
+			"are skipped. Prints the status of every job in topological order.",
+		Examples: []string{
+			"convert graph jobs.json",
+			"convert graph -parallel 8 jobs.json",
+			"convert graph -state convert.state.json jobs.json",
+		},
+		Setup: func(flags *flag.FlagSet) cli.Run {
+			var options graphOptions
+			flags.IntVar(&options.parallel, "parallel", 4, "maximum pipelines running at once")
+			flags.IntVar(&options.poolSize, "pool-size", 5, "maximum pooled converters per type")
+			flags.StringVar(&options.state, "state", "", "skip jobs unchanged since their last successful run recorded in the JSON file `path`")
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) != 1 {
+					flags.Usage()
+					return exitError
+				}
+				return runGraph(args[0], &options, stdout, stderr)
+			}
+		},
+	}
+}
+
+type graphOptions struct {
+	parallel int
+	poolSize int
+	state    string
+}
+
+// runGraph runs the job graph at path and prints "<name>\t<status>" per
+// job. Failed and skipped jobs are explained on stderr and make the exit
+// code 2.
+func runGraph(path string, options *graphOptions, stdout, stderr io.Writer) int {
+	graph, err := dag.LoadGraph(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert graph: %v\n", err)
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var runner dag.Runner = factory.NewPipelineExecutor(factory.NewConverterPool(options.poolSize, factory.NewConverterFactory())).
+		WithLogger(slog.Default()).
+		WithFileSystem(vfs.Default())
+	if options.state != "" {
+		state, err := incremental.LoadState(options.state)
+		if err != nil {
+			fmt.Fprintf(stderr, "convert graph: %v\n", err)
+			return exitError
+		}
+		runner = incremental.NewExecutor(runner, state)
+	}
+
+	report := graph.Run(ctx, runner, options.parallel)
+	for _, job := range report.Jobs {
+		fmt.Fprintf(stdout, "%s\t%s\n", job.Name, job.Status)
+	}
+	if report.Success {
+		return exitOK
+	}
+	for _, job := range report.Jobs {
+		if job.Status != dag.StatusSucceeded {
+			fmt.Fprintf(stderr, "convert graph: %s %s: %v\n", job.Name, job.Status, job.Err)
+		}
+	}
+	return exitError
+}
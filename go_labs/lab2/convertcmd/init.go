@@ -0,0 +1,324 @@
+package convertcmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+// previewLines caps how much of the sample output the wizard prints.
+const previewLines = 20
+
+func initCommand(stdin io.Reader) *cli.Command {
+	return &cli.Command{
+		Name:    "init",
+		Summary: "build a pipeline config file interactively",
+		Usage:   "[input]",
+		About: "Asks for the input and output formats and their options, previews the\n" +
+			"conversion of the first records of the input and writes a pipeline config\n" +
+			"file for 'convert run'. Press enter to accept the default in brackets.",
+		Examples: []string{
+			"convert init people.csv",
+			"convert init -o exports/people.json -records 3 people.csv",
+		},
+		Setup: func(flags *flag.FlagSet) cli.Run {
+			output := flags.String("o", "pipeline.json", "path of the pipeline config file to write")
+			records := flags.Int("records", 5, "number of input records to preview")
+			force := flags.Bool("f", false, "overwrite an existing config file without asking")
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) > 1 {
+					flags.Usage()
+					return exitError
+				}
+				w := &wizard{in: bufio.NewReader(stdin), out: stdout}
+				if len(args) == 1 {
+					w.input = args[0]
+				}
+				if err := w.run(*output, *records, *force); err != nil {
+					fmt.Fprintf(stderr, "convert init: %v\n", err)
+					return exitError
+				}
+				return exitOK
+			}
+		},
+	}
+}
+
+// wizard asks its questions on out and reads one answer per line from in.
+type wizard struct {
+	in    *bufio.Reader
+	out   io.Writer
+	input string
+}
+
+// errAborted ends the wizard when the user declines to write the file.
+var errAborted = errors.New("aborted; nothing written")
+
+func (w *wizard) run(configPath string, records int, force bool) error {
+	var err error
+	if w.input == "" {
+		if w.input, err = w.ask("Input file", ""); err != nil {
+			return err
+		}
+	}
+	data, err := os.ReadFile(w.input)
+	if err != nil {
+		return err
+	}
+
+	detected, _ := models.FormatFromPath(w.input)
+	from, err := w.choose("Input format", inputFormats(), string(detected))
+	if err != nil {
+		return err
+	}
+	to, err := w.choose("Output format", outputFormats(models.FileFormat(from)), "")
+	if err != nil {
+		return err
+	}
+	steps, err := factory.PlanConversion(models.FileFormat(from), models.FileFormat(to))
+	if err != nil {
+		return err
+	}
+
+	outputPath := strings.TrimSuffix(w.input, filepath.Ext(w.input))
+	if extension, ok := models.Extension(models.FileFormat(to)); ok {
+		outputPath += extension
+	}
+	if outputPath, err = w.ask("Output file", outputPath); err != nil {
+		return err
+	}
+
+	options, err := w.options(models.FileFormat(from), models.FileFormat(to))
+	if err != nil {
+		return err
+	}
+
+	pipeline := &models.Pipeline{InputPath: w.input, OutputPath: outputPath, Steps: steps}
+	for name, value := range options {
+		applyOption(&pipeline.Options, name, value)
+	}
+	w.preview(pipeline, data, records)
+
+	question, fallback := "Write "+configPath+"?", true
+	if _, err := os.Stat(configPath); err == nil && !force {
+		question, fallback = configPath+" exists; overwrite it?", false
+	}
+	if ok, err := w.confirm(question, fallback); err != nil {
+		return err
+	} else if !ok {
+		return errAborted
+	}
+
+	config, err := json.MarshalIndent(pipelineConfig{
+		InputPath:  pipeline.InputPath,
+		OutputPath: pipeline.OutputPath,
+		Steps:      pipeline.Steps,
+		Options:    options,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath, append(config, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(w.out, "Wrote %s. Run it with: convert run %s\n", configPath, configPath)
+	return nil
+}
+
+// pipelineConfig is the JSON form of models.Pipeline with only the options
+// the user chose, so the file stays short enough to edit by hand.
+type pipelineConfig struct {
+	InputPath  string
+	OutputPath string
+	Steps      []models.ConversionStep
+	Options    map[string]interface{} `json:",omitempty"`
+}
+
+// options asks for the options that matter for the chosen formats, keyed by
+// their ConversionOptions field name. Defaults are left out.
+func (w *wizard) options(from, to models.FileFormat) (map[string]interface{}, error) {
+	options := make(map[string]interface{})
+	if from == models.FormatCSV || to == models.FormatCSV {
+		delimiter, err := w.ask("CSV delimiter", string(models.DefaultCSVDelimiter))
+		if err != nil {
+			return nil, err
+		}
+		if delimiter == `\t` {
+			delimiter = "\t"
+		}
+		if r := []rune(delimiter); len(r) != 1 {
+			return nil, fmt.Errorf("the CSV delimiter must be one character, got %q", delimiter)
+		} else if r[0] != models.DefaultCSVDelimiter {
+			options["CSVDelimiter"] = r[0]
+		}
+	}
+	if to == models.FormatJSON || to == models.FormatXML || to == models.FormatYAML {
+		answer, err := w.ask("Indent width (0 for compact output)", strconv.Itoa(models.DefaultIndentWidth))
+		if err != nil {
+			return nil, err
+		}
+		width, err := strconv.Atoi(answer)
+		if err != nil || width < 0 {
+			return nil, fmt.Errorf("the indent width must be a number of spaces, got %q", answer)
+		}
+		if width != models.DefaultIndentWidth {
+			options["IndentWidth"] = width
+		}
+	}
+	if to == models.FormatXML {
+		root, err := w.ask("XML root element", models.DefaultXMLRoot)
+		if err != nil {
+			return nil, err
+		}
+		if root != models.DefaultXMLRoot {
+			options["XMLRoot"] = root
+		}
+	}
+	return options, nil
+}
+
+func applyOption(options *models.ConversionOptions, name string, value interface{}) {
+	switch name {
+	case "CSVDelimiter":
+		options.Apply(models.WithCSVDelimiter(value.(rune)))
+	case "IndentWidth":
+		options.Apply(models.WithIndentWidth(value.(int)))
+	case "XMLRoot":
+		options.Apply(models.WithXMLRoot(value.(string)))
+	}
+}
+
+// preview converts the first records of data and prints the start of the
+// result. A failure is reported but does not stop the wizard, since the
+// sample may not be representative.
+func (w *wizard) preview(pipeline *models.Pipeline, data []byte, n int) {
+	input := records.Sample(data, pipeline.Steps[0].From, n)
+	copied := *pipeline
+	built, err := factory.NewPipelineBuilderFrom(&copied).Build()
+	if err != nil {
+		fmt.Fprintf(w.out, "\nPreview failed: %v\n\n", err)
+		return
+	}
+	executor := factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory()))
+	output, result := executor.ConvertData(context.Background(), built, input)
+	if result.Error != nil {
+		fmt.Fprintf(w.out, "\nPreview failed: %v\n\n", result.Error)
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	fmt.Fprintf(w.out, "\nPreview of the first %d records:\n\n", n)
+	for _, line := range lines[:min(len(lines), previewLines)] {
+		fmt.Fprintf(w.out, "  %s\n", line)
+	}
+	if len(lines) > previewLines {
+		fmt.Fprint(w.out, "  ...\n")
+	}
+	fmt.Fprintln(w.out)
+}
+
+// inputFormats lists the formats with at least one conversion from them.
+func inputFormats() []string {
+	var formats []string
+	for _, format := range conversionFormats() {
+		if len(outputFormats(models.FileFormat(format))) > 0 {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// outputFormats lists the formats from converts to, except templates,
+// which need a template the wizard does not ask for.
+func outputFormats(from models.FileFormat) []string {
+	var formats []string
+	for _, format := range conversionFormats() {
+		if format == string(from) || format == string(models.FormatTemplate) {
+			continue
+		}
+		if _, err := factory.PlanConversion(from, models.FileFormat(format)); err == nil {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// ask prints a question with its default and returns the trimmed answer,
+// or the default for an empty one.
+func (w *wizard) ask(question, fallback string) (string, error) {
+	for {
+		if fallback != "" {
+			fmt.Fprintf(w.out, "%s [%s]: ", question, fallback)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", question)
+		}
+		line, err := w.in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = fallback
+		}
+		if answer != "" {
+			return answer, nil
+		}
+		if err != nil {
+			if err == io.EOF {
+				return "", fmt.Errorf("no answer for %q", question)
+			}
+			return "", err
+		}
+	}
+}
+
+// choose asks until the answer is one of choices.
+func (w *wizard) choose(question string, choices []string, fallback string) (string, error) {
+	if len(choices) == 0 {
+		return "", fmt.Errorf("%s: nothing to choose from", strings.ToLower(question))
+	}
+	if !slices.Contains(choices, fallback) {
+		fallback = ""
+	}
+	for {
+		answer, err := w.ask(question+" ("+strings.Join(choices, ", ")+")", fallback)
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(choices, answer) {
+			return answer, nil
+		}
+		fmt.Fprintf(w.out, "%q is not one of the choices.\n", answer)
+	}
+}
+
+func (w *wizard) confirm(question string, fallback bool) (bool, error) {
+	def := "y/N"
+	if fallback {
+		def = "Y/n"
+	}
+	fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	line, err := w.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "":
+		return fallback, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,245 @@
+package convertcmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/internal/multierror"
+	"tmps-go-labs/internal/output"
+	"tmps-go-labs/lab2/domain/batch"
+	"tmps-go-labs/lab2/domain/config"
+	"tmps-go-labs/lab2/domain/diskspace"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/incremental"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab3/vfs"
+)
+
+func runCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "run",
+		Summary: "run pipelines defined in config files",
+		Usage:   "<pipeline.json>...",
+		About: "Runs the pipelines defined in config files, the JSON form of\n" +
+			"models.Pipeline with ${NAME} references expanded. Steps convert\n" +
+			"between " + strings.Join(conversionFormats(), ", ") + ". A failed\n" +
+			"pipeline does not stop the others; every failure is reported at the end.\n" +
+			"With -budget, the smallest inputs run first and the pipelines not\n" +
+			"started when the budget expires are reported as not run. A pipeline\n" +
+			"whose estimated output does not fit on the disk fails before it runs.\n" +
+			"With -state, pipelines whose input and definition have not changed\n" +
+			"since their last successful run are skipped.",
+		Examples: []string{
+			"convert run pipeline.json",
+			"convert -cpuprofile cpu.out run -pool-size 2 pipeline.json",
+			"convert run -f json pipeline.json",
+			"convert run pipelines/*.json",
+			"convert run -seed 42 pipeline.json",
+			"convert run -budget 10m pipelines/*.json",
+			"convert run -state convert.state.json pipelines/*.json",
+		},
+		Values: map[string]func() []string{
+			"f": output.Formats,
+		},
+		Setup: func(flags *flag.FlagSet) cli.Run {
+			var options runOptions
+			flags.IntVar(&options.poolSize, "pool-size", 5, "maximum pooled converters per type")
+			flags.StringVar(&options.format, "f", output.Plain, "output format of the status, input, output and nanoseconds of each output file")
+			flags.Func("seed", "draw the run IDs and samples of every pipeline from the seed `n`, to repeat a run exactly", func(value string) error {
+				seed, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return fmt.Errorf("seed must be an integer")
+				}
+				options.seed = &seed
+				return nil
+			})
+			flags.DurationVar(&options.budget, "budget", 0, "run the smallest inputs first and skip the pipelines not started within `duration`")
+			flags.StringVar(&options.history, "history", "", "learn the expansion of each conversion in the JSON file `path`, to estimate the disk space runs need")
+			flags.StringVar(&options.state, "state", "", "skip pipelines unchanged since their last successful run recorded in the JSON file `path`")
+			porcelain := flags.Bool("porcelain", false, "print <status>\\t<input>\\t<output>\\t<nanoseconds> per output file, a format stable for scripts")
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) == 0 {
+					flags.Usage()
+					return exitError
+				}
+				if *porcelain {
+					if options.format != output.Plain {
+						fmt.Fprintln(stderr, "convert run: -porcelain and -f cannot be combined")
+						return exitError
+					}
+					options.format = output.Porcelain
+				}
+				return runPipelines(args, &options, stdout, stderr)
+			}
+		},
+	}
+}
+
+type runOptions struct {
+	poolSize int
+	format   string
+	seed     *int64
+	budget   time.Duration
+	history  string
+	state    string
+}
+
+// runPipelines runs the pipelines at paths one after another with a
+// shared converter pool, smallest input first when they have a time budget.
+// Failures and the pipelines the budget left out are collected, printed one
+// per line in the order of paths and make the exit code 2, but do not stop
+// the remaining pipelines.
+func runPipelines(paths []string, options *runOptions, stdout, stderr io.Writer) int {
+	writer, err := output.New(options.format, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert run: %v\n", err)
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	history, err := diskspace.LoadHistory(options.history)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert run: %v\n", err)
+		return exitError
+	}
+
+	failures := make([]error, len(paths))
+	index := make(map[*models.Pipeline]int, len(paths))
+	var pipelines []*models.Pipeline
+	for i, path := range paths {
+		pipeline, err := loadPipeline(path, options.seed)
+		if err != nil {
+			failures[i] = err
+			continue
+		}
+		index[pipeline] = i
+		pipelines = append(pipelines, pipeline)
+	}
+
+	var runner batch.Runner = factory.NewPipelineExecutor(factory.NewConverterPool(options.poolSize, factory.NewConverterFactory())).
+		WithLogger(slog.Default()).
+		WithFileSystem(vfs.Default()).
+		WithPreflight(history)
+	if options.state != "" {
+		state, err := incremental.LoadState(options.state)
+		if err != nil {
+			fmt.Fprintf(stderr, "convert run: %v\n", err)
+			return exitError
+		}
+		runner = incremental.NewExecutor(runner, state)
+	}
+	report := batch.NewExecutor(runner, options.budget).Execute(ctx, pipelines)
+	if err := history.Save(); err != nil {
+		fmt.Fprintf(stderr, "convert run: %v\n", err)
+	}
+
+	var rows []output.Row
+	for _, run := range report.Runs {
+		if run.Result.Error != nil {
+			failures[index[run.Pipeline]] = run.Result.Error
+			continue
+		}
+		if options.format != output.Plain {
+			rows = append(rows, resultRows(run.Pipeline, run.Result)...)
+			continue
+		}
+		printResult(stdout, run.Pipeline, run.Result)
+	}
+	for _, pipeline := range report.Skipped {
+		failures[index[pipeline]] = fmt.Errorf("not run: %w", report.Cause)
+	}
+
+	var errs error
+	for i, err := range failures {
+		if err == nil {
+			continue
+		}
+		if len(paths) > 1 {
+			err = fmt.Errorf("%s: %w", paths[i], err)
+		}
+		errs = multierror.Append(errs, err)
+	}
+	if options.format != output.Plain {
+		errs = multierror.Append(errs, writer.Write(rows))
+	}
+	if errs == nil {
+		return exitOK
+	}
+	for _, err := range multierror.Errors(errs) {
+		fmt.Fprintf(stderr, "convert run: %v\n", err)
+	}
+	if len(paths) > 1 {
+		fmt.Fprintf(stderr, "convert run: %d of %d pipelines failed\n", multierror.Len(errs), len(paths))
+	}
+	return exitError
+}
+
+// loadPipeline builds the pipeline defined at path, seeded with seed when
+// it is set, in place of any seed of the config.
+func loadPipeline(path string, seed *int64) (*models.Pipeline, error) {
+	loaded, err := config.LoadPipeline(path)
+	if err != nil {
+		return nil, err
+	}
+	builder := factory.NewPipelineBuilderFrom(loaded)
+	if seed != nil {
+		builder.WithSeed(*seed)
+	}
+	return builder.Build()
+}
+
+// printResult tells what a run converted, or that it skipped an unchanged
+// input.
+func printResult(stdout io.Writer, pipeline *models.Pipeline, result *models.PipelineResult) {
+	switch {
+	case result.Skipped:
+		fmt.Fprintf(stdout, "Skipped %s: unchanged since the last run\n", pipeline.InputPath)
+	case len(result.Outputs) > 1:
+		fmt.Fprintf(stdout, "Converted %s to %d parts, %s to %s, in %s\n", pipeline.InputPath, len(result.Outputs),
+			result.Outputs[0], result.Outputs[len(result.Outputs)-1], time.Duration(result.Duration))
+	default:
+		fmt.Fprintf(stdout, "Converted %s to %s in %s\n", pipeline.InputPath, pipeline.OutputPath, time.Duration(result.Duration))
+	}
+}
+
+// resultRows returns a row per output file of a run: its status, converted
+// or skipped, the input and output paths and the run's duration in
+// nanoseconds.
+func resultRows(pipeline *models.Pipeline, result *models.PipelineResult) []output.Row {
+	row := func(status, outputPath string) output.Row {
+		return output.Row{Fields: []output.Field{
+			{Name: "status", Value: status},
+			{Name: "input", Value: pipeline.InputPath},
+			{Name: "output", Value: outputPath},
+			{Name: "nanoseconds", Value: result.Duration},
+		}}
+	}
+	if result.Skipped {
+		return []output.Row{row("skipped", pipeline.OutputPath)}
+	}
+	var rows []output.Row
+	for _, outputPath := range outputsOf(pipeline, result) {
+		rows = append(rows, row("converted", outputPath))
+	}
+	return rows
+}
+
+// outputsOf lists the files a run wrote; archive runs report the output
+// path.
+func outputsOf(pipeline *models.Pipeline, result *models.PipelineResult) []string {
+	if len(result.Outputs) == 0 {
+		return []string{pipeline.OutputPath}
+	}
+	return result.Outputs
+}
@@ -0,0 +1,143 @@
+package convertcmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/jsonschema"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/validation"
+)
+
+// Schema kinds written by 'convert schema infer'.
+const (
+	schemaJSON  = "jsonschema"
+	schemaAvro  = "avro"
+	schemaRules = "rules"
+)
+
+func schemaCommand() *cli.Command {
+	return (&cli.Command{
+		Name:    "schema",
+		Summary: "work with record schemas",
+		About:   "Generates schemas for validation and for other tools from sample data.",
+	}).Add(inferCommand())
+}
+
+func inferCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "infer",
+		Summary: "generate a schema from sample input",
+		Usage:   "<input>",
+		About: "Reads the records of the input and writes a schema they all match: a\n" +
+			"JSON Schema, an Avro schema or validation rules. Edit it and pass it to\n" +
+			"a pipeline's validation stage as its SchemaPath; JSON Schemas are read\n" +
+			"there as rules on the top-level fields. Text formats such as CSV and XML\n" +
+			"have their numbers and booleans recognised in strings.",
+		Examples: []string{
+			"convert schema infer people.csv > people.schema.json",
+			"convert schema infer -kind avro -name Person -o person.avsc people.json",
+			"convert schema infer -kind rules -enum-limit 5 -bounds -records 1000 orders.ndjson",
+		},
+		Values: map[string]func() []string{
+			"format": document.ParserFormats,
+			"kind":   func() []string { return []string{schemaJSON, schemaAvro, schemaRules} },
+		},
+		Setup: func(flags *flag.FlagSet) cli.Run {
+			var options inferOptions
+			flags.StringVar(&options.format, "format", "", "format of the input (default: from its extension)")
+			flags.StringVar(&options.kind, "kind", schemaJSON, "schema to write: jsonschema, avro or rules")
+			flags.StringVar(&options.output, "o", "", "file to write the schema to (default: stdout)")
+			flags.StringVar(&options.name, "name", "", "schema title, or Avro record name (default: from the input file name)")
+			flags.IntVar(&options.records, "records", 0, "infer from only the first records; 0 reads them all")
+			flags.IntVar(&options.enumLimit, "enum-limit", 0, "make string fields with at most this many repeated values enums")
+			flags.BoolVar(&options.bounds, "bounds", false, "bound numbers by the lowest and highest value seen")
+			flags.BoolVar(&options.strict, "strict", false, "forbid properties the samples did not have")
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) != 1 {
+					flags.Usage()
+					return exitError
+				}
+				if err := runInfer(args[0], &options, stdout); err != nil {
+					fmt.Fprintf(stderr, "convert schema infer: %v\n", err)
+					return exitError
+				}
+				return exitOK
+			}
+		},
+	}
+}
+
+type inferOptions struct {
+	format, kind   string
+	output, name   string
+	records        int
+	enumLimit      int
+	bounds, strict bool
+}
+
+func runInfer(path string, options *inferOptions, stdout io.Writer) error {
+	format := models.FileFormat(options.format)
+	if format == "" {
+		var ok bool
+		if format, ok = models.FormatFromPath(path); !ok {
+			return fmt.Errorf("cannot tell the format of %s; pass it with -format", path)
+		}
+	}
+	name := options.name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	doc, err := document.Parse(file, format, models.NewOptions(models.WithLosslessTypes()))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	rows := records.Find(doc.Root)
+	if options.records > 0 && len(rows) > options.records {
+		rows = rows[:options.records]
+	}
+
+	schema := jsonschema.Infer(rows, jsonschema.InferOptions{
+		Title:        name,
+		TypedStrings: format != models.FormatJSON && format != models.FormatNDJSON && format != models.FormatYAML,
+		EnumLimit:    options.enumLimit,
+		Bounds:       options.bounds,
+		Strict:       options.strict,
+	})
+	var out interface{}
+	switch options.kind {
+	case schemaJSON:
+		out = schema
+	case schemaAvro:
+		out = jsonschema.Avro(schema, name)
+	case schemaRules:
+		out = validation.FromJSONSchema(schema)
+	default:
+		return fmt.Errorf("unknown schema kind %q; use %s, %s or %s", options.kind, schemaJSON, schemaAvro, schemaRules)
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if options.output == "" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(options.output, data, 0644)
+}
@@ -0,0 +1,114 @@
+package convertcmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"tmps-go-labs/internal/cli"
+	"tmps-go-labs/lab2/domain/config"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/soak"
+	"tmps-go-labs/lab3/vfs"
+)
+
+func soakCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "soak",
+		Summary: "run a pipeline repeatedly for a while and check for leaks",
+		Usage:   "<pipeline.json>",
+		About: "Converts the pipeline's input in memory over and over for -duration,\n" +
+			"printing a sample every -interval, then checks that the live heap did not\n" +
+			"keep growing, that no goroutines outlived their runs and that every pooled\n" +
+			"converter was returned. The output file is not written. Exits with 0 when\n" +
+			"the run passed, 1 when it found a problem and 2 on errors.",
+		Examples: []string{
+			"convert soak -duration 30m -concurrency 8 pipeline.json",
+			"convert soak -duration 2m -interval 5s -max-heap-growth 0.2 pipeline.json",
+		},
+		Setup: func(flags *flag.FlagSet) cli.Run {
+			var options soakOptions
+			flags.DurationVar(&options.Duration, "duration", time.Minute, "how long to keep running the pipeline")
+			flags.IntVar(&options.Concurrency, "concurrency", 1, "pipelines running at once")
+			flags.DurationVar(&options.Interval, "interval", soak.DefaultInterval, "time between samples; the first one is the baseline")
+			flags.Float64Var(&options.MaxHeapGrowth, "max-heap-growth", soak.DefaultMaxHeapGrowth, "tolerated live heap growth over the baseline, as a fraction")
+			flags.IntVar(&options.poolSize, "pool-size", 5, "maximum pooled converters per type")
+			flags.BoolVar(&options.porcelain, "porcelain", false, "print samples and the verdict tab-separated, a format stable for scripts")
+			return func(args []string, stdout, stderr io.Writer) int {
+				if len(args) != 1 {
+					flags.Usage()
+					return exitError
+				}
+				return runSoak(args[0], &options, stdout, stderr)
+			}
+		},
+	}
+}
+
+type soakOptions struct {
+	soak.Options
+	poolSize  int
+	porcelain bool
+}
+
+func runSoak(path string, options *soakOptions, stdout, stderr io.Writer) int {
+	loaded, err := config.LoadPipeline(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert soak: %v\n", err)
+		return exitError
+	}
+	pipeline, err := factory.NewPipelineBuilderFrom(loaded).Build()
+	if err != nil {
+		fmt.Fprintf(stderr, "convert soak: %v\n", err)
+		return exitError
+	}
+	input, err := vfs.ReadFile(vfs.Default(), pipeline.InputPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert soak: %v\n", err)
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	options.OnSample = func(s soak.Sample) {
+		if options.porcelain {
+			fmt.Fprintf(stdout, "sample\t%d\t%d\t%d\t%d\t%d\t%d\n", s.Elapsed, s.Runs, s.Failures, s.HeapAlloc, s.Goroutines, s.CheckedOut)
+			return
+		}
+		fmt.Fprintf(stdout, "%8s  %d runs, %d failed, live heap %.1f MiB, %d goroutines, %d converters checked out\n",
+			s.Elapsed.Round(time.Second), s.Runs, s.Failures, float64(s.HeapAlloc)/(1<<20), s.Goroutines, s.CheckedOut)
+	}
+
+	pool := factory.NewConverterPool(options.poolSize, factory.NewConverterFactory())
+	report, err := soak.Run(ctx, factory.NewPipelineExecutor(pool).WithLogger(slog.Default()), pool, pipeline, input, options.Options)
+	if err != nil {
+		fmt.Fprintf(stderr, "convert soak: %v\n", err)
+		return exitError
+	}
+
+	switch {
+	case options.porcelain && report.Passed():
+		fmt.Fprintln(stdout, "passed")
+	case options.porcelain:
+		for _, problem := range report.Problems {
+			fmt.Fprintf(stdout, "failed\t%s\n", problem)
+		}
+	case report.Passed():
+		fmt.Fprintf(stdout, "Passed: %d runs, live heap changed %+.0f%%, no leaks\n", report.Runs, report.HeapGrowth*100)
+	default:
+		fmt.Fprintf(stdout, "Failed after %d runs:\n", report.Runs)
+		for _, problem := range report.Problems {
+			fmt.Fprintf(stdout, "  %s\n", problem)
+		}
+	}
+	if !report.Passed() {
+		return exitNegative
+	}
+	return exitOK
+}
@@ -0,0 +1,161 @@
+// Package adapters plugs external conversion tools such as pandoc, jq and yq
+// into the converter registry. Each conversion runs the tool as a subprocess
+// that reads the input on stdin and writes the result to stdout.
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const (
+	DefaultTimeout     = 30 * time.Second
+	DefaultMaxOutput   = 256 << 20
+	maxStderrBytes     = 4 << 10
+	processWaitTimeout = 2 * time.Second
+)
+
+type Command struct {
+	From    models.FileFormat
+	To      models.FileFormat
+	Path    string
+	Args    []string
+	Env     []string
+	Timeout time.Duration
+	// MaxOutput is the most bytes the tool may write to stdout before it
+	// is killed; DefaultMaxOutput when zero.
+	MaxOutput int64
+}
+
+type CommandConverter struct {
+	command Command
+}
+
+func NewCommandConverter(command Command) *CommandConverter {
+	if command.Timeout <= 0 {
+		command.Timeout = DefaultTimeout
+	}
+	if command.MaxOutput <= 0 {
+		command.MaxOutput = DefaultMaxOutput
+	}
+	return &CommandConverter{command: command}
+}
+
+// Register makes the command available as the command.From-command.To
+// converter. It fails if the tool is not installed, so a missing binary is
+// reported at startup rather than on the first conversion.
+func Register(command Command) error {
+	if _, err := exec.LookPath(command.Path); err != nil {
+		return fmt.Errorf("%s-%s adapter: %w", command.From, command.To, err)
+	}
+	factory.RegisterConverter(string(command.From)+"-"+string(command.To), func() models.Converter {
+		return NewCommandConverter(command)
+	})
+	return nil
+}
+
+func (c *CommandConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	return c.ConvertContext(context.Background(), input, from, to)
+}
+
+// ConvertContext runs the tool until it exits, its Timeout passes or ctx is
+// done, killing it in the last two cases and once it writes more than
+// MaxOutput bytes.
+func (c *CommandConverter) ConvertContext(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from != c.command.From || to != c.command.To {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	runCtx, cancel := context.WithTimeoutCause(ctx, c.command.Timeout, errTimedOut)
+	defer cancel()
+	kill, stop := context.WithCancelCause(runCtx)
+	defer stop(nil)
+
+	stdout := &cappedBuffer{limit: c.command.MaxOutput, overflow: func() { stop(errTooMuchOutput) }}
+	stderr := &limitedBuffer{limit: maxStderrBytes}
+
+	cmd := exec.CommandContext(kill, c.command.Path, c.command.Args...)
+	cmd.Stdin = input
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(), c.command.Env...)
+	// Kill the tool on timeout and stop waiting for pipes held open by any
+	// children it spawned.
+	cmd.WaitDelay = processWaitTimeout
+
+	if err := cmd.Run(); err != nil {
+		switch cause := context.Cause(kill); {
+		case ctx.Err() != nil:
+			return &models.ConversionResult{Error: fmt.Errorf("%s stopped: %w", c.command.Path, context.Cause(ctx))}
+		case errors.Is(cause, errTimedOut):
+			return &models.ConversionResult{Error: fmt.Errorf("%s timed out after %s", c.command.Path, c.command.Timeout)}
+		case errors.Is(cause, errTooMuchOutput):
+			return &models.ConversionResult{Error: fmt.Errorf("%s wrote more than %d bytes of output", c.command.Path, c.command.MaxOutput)}
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return &models.ConversionResult{Error: fmt.Errorf("%s failed: %w", c.command.Path, err)}
+		}
+		return &models.ConversionResult{Error: fmt.Errorf("%s failed: %w: %s", c.command.Path, err, message)}
+	}
+
+	return &models.ConversionResult{
+		Data:   stdout.data.Bytes(),
+		Format: to,
+	}
+}
+
+func (c *CommandConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == c.command.From || format == c.command.To
+}
+
+var (
+	errTimedOut      = errors.New("timed out")
+	errTooMuchOutput = errors.New("too much output")
+)
+
+// cappedBuffer holds a tool's stdout and calls overflow, which kills the
+// tool, instead of growing past limit bytes. It does not embed the buffer,
+// whose ReadFrom would let io.Copy bypass the limit.
+type cappedBuffer struct {
+	data     bytes.Buffer
+	limit    int64
+	overflow func()
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if int64(b.data.Len())+int64(len(p)) > b.limit {
+		b.overflow()
+		return 0, errTooMuchOutput
+	}
+	return b.data.Write(p)
+}
+
+// limitedBuffer keeps the first limit bytes of a tool's stderr for error
+// messages and discards the rest. Like cappedBuffer, it does not embed the
+// buffer, so io.Copy cannot bypass Write.
+type limitedBuffer struct {
+	data  bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.data.Len(); room > 0 {
+		b.data.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.data.String()
+}
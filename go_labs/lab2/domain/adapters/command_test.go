@@ -0,0 +1,52 @@
+package adapters
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestCommandConverterRunsTool(t *testing.T) {
+	assert.NoError(t, Register(Command{From: "text", To: "shout", Path: "tr", Args: []string{"a-z", "A-Z"}}))
+	assert.True(t, factory.IsRegistered("text-shout"))
+
+	converter, err := factory.NewConverterFactory().CreateConverter("text-shout")
+	assert.NoError(t, err)
+
+	result := converter.Convert(strings.NewReader("hello"), "text", "shout")
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "HELLO", string(result.Data))
+}
+
+func TestCommandConverterReportsFailures(t *testing.T) {
+	assert.Error(t, Register(Command{From: "a", To: "b", Path: "no-such-tool-tmps"}))
+
+	failing := NewCommandConverter(Command{From: "a", To: "b", Path: "sh", Args: []string{"-c", "echo broken input >&2; exit 3"}})
+	result := failing.Convert(strings.NewReader(""), "a", "b")
+	assert.ErrorContains(t, result.Error, "broken input")
+
+	slow := NewCommandConverter(Command{From: "a", To: "b", Path: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	result = slow.Convert(strings.NewReader(""), "a", "b")
+	assert.ErrorContains(t, result.Error, "timed out")
+	assert.Less(t, time.Since(start), 3*time.Second)
+
+	flood := NewCommandConverter(Command{From: "a", To: "b", Path: "yes", MaxOutput: 1 << 10})
+	result = flood.Convert(strings.NewReader(""), "a", "b")
+	assert.EqualError(t, result.Error, "yes wrote more than 1024 bytes of output")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	result = NewCommandConverter(Command{From: "a", To: "b", Path: "sleep", Args: []string{"5"}}).ConvertContext(ctx, strings.NewReader(""), "a", "b")
+	assert.ErrorIs(t, result.Error, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 3*time.Second)
+
+	assert.Equal(t, []string{"--from", "markdown", "--to", "html"}, Pandoc(models.FormatMarkdown, "html").Args)
+}
@@ -0,0 +1,53 @@
+// Package adapters plugs external conversion tools such as pandoc, jq and yq
+// into the converter registry. Each conversion runs the tool as a subprocess
+// that reads the input on stdin and writes the result to stdout.
+package adapters
+
+import (
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// pandocFormats maps registry formats to pandoc's reader/writer names.
+var pandocFormats = map[models.FileFormat]string{
+	models.FormatMarkdown: "markdown",
+	"html":                "html",
+	"rst":                 "rst",
+	"docx":                "docx",
+	"latex":               "latex",
+	"org":                 "org",
+}
+
+func Pandoc(from, to models.FileFormat, extraArgs ...string) Command {
+	args := []string{"--from", pandocName(from), "--to", pandocName(to)}
+	if to == "docx" {
+		args = append(args, "--output", "-")
+	}
+	return Command{From: from, To: to, Path: "pandoc", Args: append(args, extraArgs...)}
+}
+
+// JQ filters JSON through a jq program, e.g. JQ("active", "map(select(.active))").
+// The custom target format keeps it apart from the native JSON converters.
+func JQ(to models.FileFormat, filter string) Command {
+	return Command{From: models.FormatJSON, To: to, Path: "jq", Args: []string{filter}}
+}
+
+// YQ converts between YAML, JSON and XML with mikefarah/yq, optionally
+// applying an expression.
+func YQ(from, to models.FileFormat, expression string) Command {
+	if expression == "" {
+		expression = "."
+	}
+	return Command{
+		From: from,
+		To:   to,
+		Path: "yq",
+		Args: []string{"--input-format", string(from), "--output-format", string(to), expression},
+	}
+}
+
+func pandocName(format models.FileFormat) string {
+	if name, ok := pandocFormats[format]; ok {
+		return name
+	}
+	return string(format)
+}
@@ -0,0 +1,111 @@
+// Package batch runs many pipelines within a total time budget. When a
+// budget is set, the smallest inputs run first, so as many inputs as
+// possible are converted before it expires, and the inputs never started
+// are reported as skipped.
+package batch
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// ErrBudgetExpired is the cause of runs cut short by the batch's budget.
+var ErrBudgetExpired = errors.New("time budget expired")
+
+type Runner interface {
+	ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult
+}
+
+// Run is the result of one pipeline of a batch.
+type Run struct {
+	Pipeline *models.Pipeline
+	Result   *models.PipelineResult
+}
+
+// Report lists the runs of a batch in the order they ran, and the pipelines
+// skipped because the budget expired, or the batch was cancelled, before
+// they could start. Cause tells which.
+type Report struct {
+	Runs    []Run
+	Skipped []*models.Pipeline
+	Cause   error
+}
+
+// Executor runs the pipelines of a batch one after another with a runner.
+type Executor struct {
+	runner Runner
+	budget time.Duration
+	size   func(path string) (int64, error)
+}
+
+// NewExecutor returns an executor giving a batch budget to run. A zero
+// budget sets no deadline and runs the pipelines in the order given.
+func NewExecutor(runner Runner, budget time.Duration) *Executor {
+	return &Executor{runner: runner, budget: budget, size: fileSize}
+}
+
+// WithSizes makes the executor measure inputs with size instead of
+// reading their size from the local disk.
+func (e *Executor) WithSizes(size func(path string) (int64, error)) *Executor {
+	e.size = size
+	return e
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Execute runs pipelines. With a budget, they run smallest input first,
+// inputs whose size cannot be read, such as URLs, last, and the run in
+// progress when the budget expires is cancelled with ErrBudgetExpired as
+// its cause.
+func (e *Executor) Execute(ctx context.Context, pipelines []*models.Pipeline) Report {
+	if e.budget > 0 {
+		pipelines = e.schedule(pipelines)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, e.budget, fmt.Errorf("%w after %s", ErrBudgetExpired, e.budget))
+		defer cancel()
+	}
+
+	var report Report
+	for i, pipeline := range pipelines {
+		if ctx.Err() != nil {
+			report.Skipped = pipelines[i:]
+			report.Cause = context.Cause(ctx)
+			break
+		}
+		report.Runs = append(report.Runs, Run{Pipeline: pipeline, Result: e.runner.ExecuteContext(ctx, pipeline)})
+	}
+	return report
+}
+
+// schedule orders pipelines by the size of their input, keeping the given
+// order between inputs of the same size.
+func (e *Executor) schedule(pipelines []*models.Pipeline) []*models.Pipeline {
+	sizes := make(map[*models.Pipeline]int64, len(pipelines))
+	for _, pipeline := range pipelines {
+		size, err := e.size(pipeline.InputPath)
+		if err != nil {
+			size = math.MaxInt64
+		}
+		sizes[pipeline] = size
+	}
+
+	ordered := slices.Clone(pipelines)
+	slices.SortStableFunc(ordered, func(a, b *models.Pipeline) int {
+		return cmp.Compare(sizes[a], sizes[b])
+	})
+	return ordered
+}
@@ -0,0 +1,61 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// sleepingRunner takes each run a fixed time, or until it is cancelled.
+type sleepingRunner struct {
+	sleep time.Duration
+	ran   []string
+}
+
+func (r *sleepingRunner) ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	r.ran = append(r.ran, pipeline.InputPath)
+	select {
+	case <-time.After(r.sleep):
+		return &models.PipelineResult{Success: true}
+	case <-ctx.Done():
+		return &models.PipelineResult{Error: context.Cause(ctx)}
+	}
+}
+
+func TestExecutorRunsSmallestInputsWithinBudget(t *testing.T) {
+	sizes := map[string]int64{"a.csv": 300, "b.csv": 100, "c.csv": 200}
+	pipelines := []*models.Pipeline{{InputPath: "a.csv"}, {InputPath: "https://example.com/d.csv"}, {InputPath: "b.csv"}, {InputPath: "c.csv"}}
+	runner := &sleepingRunner{sleep: 40 * time.Millisecond}
+
+	report := NewExecutor(runner, 60*time.Millisecond).
+		WithSizes(func(path string) (int64, error) {
+			if size, ok := sizes[path]; ok {
+				return size, nil
+			}
+			return 0, fmt.Errorf("stat %s: %w", path, os.ErrNotExist)
+		}).
+		Execute(context.Background(), pipelines)
+
+	assert.Equal(t, []string{"b.csv", "c.csv"}, runner.ran)
+	assert.True(t, report.Runs[0].Result.Success)
+	assert.ErrorIs(t, report.Runs[1].Result.Error, ErrBudgetExpired)
+	assert.Equal(t, []*models.Pipeline{pipelines[0], pipelines[1]}, report.Skipped)
+	assert.EqualError(t, report.Cause, "time budget expired after 60ms")
+}
+
+func TestExecutorWithoutBudgetKeepsOrder(t *testing.T) {
+	pipelines := []*models.Pipeline{{InputPath: "big.csv"}, {InputPath: "small.csv"}}
+	runner := &sleepingRunner{}
+
+	report := NewExecutor(runner, 0).Execute(context.Background(), pipelines)
+
+	assert.Equal(t, []string{"big.csv", "small.csv"}, runner.ran)
+	assert.Len(t, report.Runs, 2)
+	assert.Empty(t, report.Skipped)
+}
@@ -0,0 +1,46 @@
+// Package buffers pools the byte buffers converters only need while a
+// conversion runs, so batch jobs reuse them instead of allocating fresh
+// ones for every step.
+package buffers
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// MaxRetained is the largest buffer capacity returned to the pool. Bigger
+// buffers are left to the garbage collector so one huge input does not pin
+// its memory for the life of the process.
+const MaxRetained = 4 << 20
+
+var pool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// Get returns an empty buffer from the pool.
+func Get() *bytes.Buffer {
+	return pool.Get().(*bytes.Buffer)
+}
+
+// Put resets the buffer and returns it to the pool. The buffer's bytes
+// must not be used afterwards.
+func Put(buffer *bytes.Buffer) {
+	if buffer == nil || buffer.Cap() > MaxRetained {
+		return
+	}
+	buffer.Reset()
+	pool.Put(buffer)
+}
+
+// ReadAll reads input into a pooled buffer. Callers that only parse the
+// data should Put the buffer back once parsing is done; data that outlives
+// the call must be copied out first.
+func ReadAll(input io.Reader) (*bytes.Buffer, error) {
+	buffer := Get()
+	if _, err := buffer.ReadFrom(input); err != nil {
+		Put(buffer)
+		return nil, err
+	}
+	return buffer, nil
+}
@@ -0,0 +1,50 @@
+package buffers
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAll(t *testing.T) {
+	buffer, err := ReadAll(strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", buffer.String())
+	Put(buffer)
+
+	reused := Get()
+	assert.Zero(t, reused.Len())
+	Put(reused)
+}
+
+func TestPutDropsLargeBuffers(t *testing.T) {
+	buffer := bytes.NewBuffer(make([]byte, 0, MaxRetained+1))
+	Put(buffer)
+	assert.Equal(t, MaxRetained+1, buffer.Cap())
+	Put(nil)
+}
+
+var sample = strings.Repeat(`{"name":"ann","city":"oslo"},`, 4096)
+
+func BenchmarkReadAll(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		buffer, err := ReadAll(strings.NewReader(sample))
+		if err != nil {
+			b.Fatal(err)
+		}
+		Put(buffer)
+	}
+}
+
+func BenchmarkIOReadAll(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := io.ReadAll(strings.NewReader(sample)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+// Package bundle packs a pipeline together with the files it reads into a
+// single zip archive that can be shared and imported elsewhere: the
+// pipeline config, catalog presets, templates, validation schemas, lookup
+// tables and a sample of the input. Key files are secrets and are never bundled.
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+const (
+	Version = 1
+
+	ManifestName = "bundle.json"
+	PipelineName = "pipeline.json"
+	PresetsName  = "presets.json"
+
+	// MaxFileSize bounds every file read from a bundle on import, so a
+	// small archive cannot decompress into an unbounded amount of memory.
+	MaxFileSize = 256 << 20
+)
+
+// Roles say what a bundled file is for.
+const (
+	RolePipeline = "pipeline"
+	RolePresets  = "presets"
+	RoleTemplate = "template"
+	RoleSchema   = "schema"
+	RoleLookup   = "lookup"
+	RoleSample   = "sample"
+)
+
+type File struct {
+	Path   string `json:"path"`
+	Role   string `json:"role"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Manifest describes a bundle. Missing lists files the pipeline refers to
+// that were left out, such as key files, which have to be provided where
+// the bundle is imported.
+type Manifest struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []File    `json:"files"`
+	Missing   []string  `json:"missing,omitempty"`
+}
+
+type ExportOptions struct {
+	Name string
+	// Presets are bundled along with the pipeline, by name.
+	Presets map[string]*models.Pipeline
+	// SampleRecords is how many records of the input are bundled; with 0
+	// the input is left out.
+	SampleRecords int
+}
+
+// Export writes a bundle of pipeline to w. The bundled config points at
+// the copies in the bundle: the input at data/, templates at templates/,
+// schemas at schemas/, lookup tables at lookups/, and the output at
+// output/.
+func Export(w io.Writer, pipeline *models.Pipeline, options ExportOptions) (*Manifest, error) {
+	e := &exporter{
+		zip:      zip.NewWriter(w),
+		manifest: &Manifest{Version: Version, Name: options.Name, CreatedAt: time.Now().UTC()},
+		paths:    make(map[string]string),
+		used:     make(map[string]bool),
+	}
+
+	bundled, err := clone(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.rewrite(bundled); err != nil {
+		return nil, err
+	}
+	if err := e.input(bundled, options.SampleRecords); err != nil {
+		return nil, err
+	}
+	if bundled.OutputPath != "" {
+		bundled.OutputPath = "output/" + filepath.Base(bundled.OutputPath)
+	}
+	if err := e.addJSON(PipelineName, RolePipeline, bundled); err != nil {
+		return nil, err
+	}
+
+	if len(options.Presets) > 0 {
+		presets := make(map[string]*models.Pipeline, len(options.Presets))
+		for _, name := range sortedNames(options.Presets) {
+			preset, err := clone(options.Presets[name])
+			if err != nil {
+				return nil, err
+			}
+			if err := e.rewrite(preset); err != nil {
+				return nil, fmt.Errorf("preset %s: %w", name, err)
+			}
+			presets[name] = preset
+		}
+		if err := e.addJSON(PresetsName, RolePresets, presets); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(e.manifest.Missing)
+	data, err := json.MarshalIndent(e.manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := e.write(ManifestName, data); err != nil {
+		return nil, err
+	}
+	if err := e.zip.Close(); err != nil {
+		return nil, err
+	}
+	return e.manifest, nil
+}
+
+type exporter struct {
+	zip      *zip.Writer
+	manifest *Manifest
+	// paths maps source files to their path in the bundle, so files shared
+	// by several pipelines are bundled once.
+	paths map[string]string
+	used  map[string]bool
+}
+
+// rewrite bundles the templates, schemas and lookup tables of p and its
+// sub-pipelines and points them at the copies. Key files are never bundled;
+// they are reported as missing.
+func (e *exporter) rewrite(p *models.Pipeline) error {
+	var err error
+	if p.Options.TemplatePath, err = e.include(p.Options.TemplatePath, "templates", RoleTemplate); err != nil {
+		return err
+	}
+	if p.Options.Validation.SchemaPath, err = e.include(p.Options.Validation.SchemaPath, "schemas", RoleSchema); err != nil {
+		return err
+	}
+	if p.Options.OutputSchema.Path, err = e.include(p.Options.OutputSchema.Path, "schemas", RoleSchema); err != nil {
+		return err
+	}
+	e.missing(p.Options.Encryption.KeyFile)
+	e.missing(p.Options.Manifest.SigningKeyFile)
+	for _, step := range p.Steps {
+		if step.Encrypt != nil {
+			e.missing(step.Encrypt.KeyFile)
+		}
+		if step.Lookup != nil {
+			if step.Lookup.Path, err = e.include(step.Lookup.Path, "lookups", RoleLookup); err != nil {
+				return err
+			}
+		}
+		if step.Pipeline != nil {
+			if err := e.rewrite(step.Pipeline); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// input bundles the first records of the input as a sample, or records the
+// input as missing when no records are asked for.
+func (e *exporter) input(p *models.Pipeline, n int) error {
+	if p.InputPath == "" {
+		return nil
+	}
+	if n <= 0 || len(p.Steps) == 0 {
+		e.missing(p.InputPath)
+		p.InputPath = "data/" + filepath.Base(p.InputPath)
+		return nil
+	}
+	data, err := os.ReadFile(p.InputPath)
+	if err != nil {
+		return err
+	}
+	name := e.unique("data", filepath.Base(p.InputPath))
+	p.InputPath = name
+	return e.add(name, RoleSample, records.Sample(data, p.Steps[0].From, n))
+}
+
+func (e *exporter) include(source, dir, role string) (string, error) {
+	if source == "" {
+		return "", nil
+	}
+	if name, ok := e.paths[source]; ok {
+		return name, nil
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return "", err
+	}
+	name := e.unique(dir, filepath.Base(source))
+	e.paths[source] = name
+	return name, e.add(name, role, data)
+}
+
+func (e *exporter) missing(source string) {
+	if source != "" && !slices.Contains(e.manifest.Missing, source) {
+		e.manifest.Missing = append(e.manifest.Missing, source)
+	}
+}
+
+// unique names a file in dir after base, numbering it when another file
+// already took the name.
+func (e *exporter) unique(dir, base string) string {
+	ext := path.Ext(base)
+	name := dir + "/" + base
+	for i := 2; e.used[name]; i++ {
+		name = fmt.Sprintf("%s/%s-%d%s", dir, strings.TrimSuffix(base, ext), i, ext)
+	}
+	e.used[name] = true
+	return name
+}
+
+func (e *exporter) addJSON(name, role string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return e.add(name, role, data)
+}
+
+func (e *exporter) add(name, role string, data []byte) error {
+	sum := sha256.Sum256(data)
+	e.manifest.Files = append(e.manifest.Files, File{Path: name, Role: role, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data))})
+	return e.write(name, data)
+}
+
+func (e *exporter) write(name string, data []byte) error {
+	w, err := e.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Import extracts the bundle in r into dir, checking every file against
+// the manifest, and rewrites the paths in the extracted pipeline and
+// presets to point into dir. Existing files are never overwritten.
+func Import(r io.ReaderAt, size int64, dir string) (*Manifest, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	entries := make(map[string]*zip.File, len(archive.File))
+	for _, entry := range archive.File {
+		entries[entry.Name] = entry
+	}
+
+	var manifest Manifest
+	data, err := readEntry(entries, ManifestName)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("read %s: %w", ManifestName, err)
+	}
+	if manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+	}
+
+	contents := make(map[string][]byte, len(manifest.Files))
+	for _, file := range manifest.Files {
+		if !filepath.IsLocal(file.Path) {
+			return nil, fmt.Errorf("bundle file %q is outside the bundle", file.Path)
+		}
+		data, err := readEntry(entries, file.Path)
+		if err != nil {
+			return nil, err
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != file.SHA256 {
+			return nil, fmt.Errorf("bundle file %s does not match its checksum", file.Path)
+		}
+		contents[file.Path] = data
+	}
+	if _, ok := contents[PipelineName]; !ok {
+		return nil, fmt.Errorf("bundle has no %s", PipelineName)
+	}
+	for _, file := range manifest.Files {
+		target := filepath.Join(dir, filepath.FromSlash(file.Path))
+		if _, err := os.Stat(target); err == nil {
+			return nil, fmt.Errorf("%s already exists", target)
+		}
+	}
+
+	for _, file := range manifest.Files {
+		data := contents[file.Path]
+		switch file.Role {
+		case RolePipeline:
+			var pipeline models.Pipeline
+			if data, err = relocated(data, &pipeline, func() error { return relocate(&pipeline, dir) }); err != nil {
+				return nil, err
+			}
+			if pipeline.OutputPath != "" {
+				if err := os.MkdirAll(filepath.Dir(pipeline.OutputPath), 0755); err != nil {
+					return nil, err
+				}
+			}
+		case RolePresets:
+			var presets map[string]*models.Pipeline
+			if data, err = relocated(data, &presets, func() error {
+				for name, preset := range presets {
+					if err := relocate(preset, dir); err != nil {
+						return fmt.Errorf("preset %s: %w", name, err)
+					}
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+		if err := create(filepath.Join(dir, filepath.FromSlash(file.Path)), data); err != nil {
+			return nil, err
+		}
+	}
+	return &manifest, nil
+}
+
+func readEntry(entries map[string]*zip.File, name string) ([]byte, error) {
+	entry, ok := entries[name]
+	if !ok {
+		return nil, fmt.Errorf("bundle has no %s", name)
+	}
+	if entry.UncompressedSize64 > MaxFileSize {
+		return nil, fmt.Errorf("bundle file %s is larger than %d bytes", name, MaxFileSize)
+	}
+	file, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("bundle file %s is larger than %d bytes", name, MaxFileSize)
+	}
+	return data, nil
+}
+
+// relocated decodes data into target, applies fix and encodes it again.
+func relocated(data []byte, target interface{}, fix func() error) ([]byte, error) {
+	if err := json.Unmarshal(data, target); err != nil {
+		return nil, err
+	}
+	if err := fix(); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(target, "", "  ")
+}
+
+// relocate makes the bundle-relative paths of p relative to dir. Every one
+// of them has to stay inside the bundle: an absolute or ../ path would let
+// an imported pipeline read or write anywhere. Paths of files left out of
+// the bundle, such as key files, are kept as they were.
+func relocate(p *models.Pipeline, dir string) error {
+	join := func(name *string) error {
+		if *name == "" {
+			return nil
+		}
+		if !filepath.IsLocal(filepath.FromSlash(*name)) {
+			return fmt.Errorf("pipeline path %q is outside the bundle", *name)
+		}
+		*name = filepath.Join(dir, filepath.FromSlash(*name))
+		return nil
+	}
+	for _, name := range []*string{
+		&p.InputPath,
+		&p.OutputPath,
+		&p.Options.TemplatePath,
+		&p.Options.Validation.SchemaPath,
+		&p.Options.OutputSchema.Path,
+	} {
+		if err := join(name); err != nil {
+			return err
+		}
+	}
+	for _, step := range p.Steps {
+		if step.Lookup != nil {
+			if err := join(&step.Lookup.Path); err != nil {
+				return err
+			}
+		}
+		if step.Pipeline != nil {
+			if err := relocate(step.Pipeline, dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func create(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists", name)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// clone deep-copies a pipeline so rewriting its paths leaves the caller's
+// untouched.
+func clone(p *models.Pipeline) (*models.Pipeline, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var copied models.Pipeline
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+func sortedNames(presets map[string]*models.Pipeline) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
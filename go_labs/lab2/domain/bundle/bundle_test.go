@@ -0,0 +1,146 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestExportAndImport(t *testing.T) {
+	source := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(source, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	pipeline := &models.Pipeline{
+		Steps: []models.ConversionStep{
+			{From: models.FormatCSV, To: models.FormatCSV, Lookup: &models.Lookup{Path: write("countries.csv", "code,name\n"), Key: "code"}},
+			{From: models.FormatCSV, To: models.FormatJSON},
+		},
+		InputPath:  write("people.csv", "name,age\nann,31\nbob,42\ncid,27\n"),
+		OutputPath: "/srv/exports/people.json",
+	}
+	pipeline.Options.Validation.SchemaPath = write("people.schema.json", `{"fields":{}}`)
+	pipeline.Options.Encryption.KeyFile = "/etc/convert/key"
+	preset := &models.Pipeline{Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}}}
+	preset.Options.Validation.SchemaPath = pipeline.Options.Validation.SchemaPath
+
+	var archive bytes.Buffer
+	exported, err := Export(&archive, pipeline, ExportOptions{
+		Name:          "people",
+		Presets:       map[string]*models.Pipeline{"people": preset},
+		SampleRecords: 2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/etc/convert/key"}, exported.Missing)
+	assert.Equal(t, filepath.Join(source, "people.csv"), pipeline.InputPath, "the exported pipeline is not modified")
+
+	var paths []string
+	for _, file := range exported.Files {
+		paths = append(paths, file.Path)
+	}
+	assert.Equal(t, []string{"schemas/people.schema.json", "lookups/countries.csv", "data/people.csv", PipelineName, PresetsName}, paths, "the shared schema is bundled once")
+
+	dir := filepath.Join(t.TempDir(), "imported")
+	imported, err := Import(bytes.NewReader(archive.Bytes()), int64(archive.Len()), dir)
+	require.NoError(t, err)
+	assert.Equal(t, "people", imported.Name)
+
+	sample, err := os.ReadFile(filepath.Join(dir, "data", "people.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "name,age\nann,31\nbob,42\n", string(sample))
+
+	var restored models.Pipeline
+	data, err := os.ReadFile(filepath.Join(dir, PipelineName))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &restored))
+	assert.Equal(t, filepath.Join(dir, "data", "people.csv"), restored.InputPath)
+	assert.Equal(t, filepath.Join(dir, "output", "people.json"), restored.OutputPath)
+	assert.Equal(t, filepath.Join(dir, "schemas", "people.schema.json"), restored.Options.Validation.SchemaPath)
+	assert.Equal(t, filepath.Join(dir, "lookups", "countries.csv"), restored.Steps[0].Lookup.Path)
+	assert.Equal(t, "/etc/convert/key", restored.Options.Encryption.KeyFile)
+	assert.DirExists(t, filepath.Join(dir, "output"))
+
+	var presets map[string]*models.Pipeline
+	data, err = os.ReadFile(filepath.Join(dir, PresetsName))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &presets))
+	assert.Equal(t, filepath.Join(dir, "schemas", "people.schema.json"), presets["people"].Options.Validation.SchemaPath)
+
+	_, err = Import(bytes.NewReader(archive.Bytes()), int64(archive.Len()), dir)
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestExportReportsEncryptStepKeysMissing(t *testing.T) {
+	pipeline := &models.Pipeline{
+		Steps: []models.ConversionStep{
+			{From: models.FormatCSV, To: models.FormatJSON},
+			{From: models.FormatJSON, To: models.FormatJSON, Pipeline: &models.Pipeline{Steps: []models.ConversionStep{
+				{From: models.FormatJSON, To: models.FormatJSON, Encrypt: &models.FieldEncryption{Fields: []string{"email"}, KeyFile: "/etc/convert/fields.key"}},
+			}}},
+			{From: models.FormatJSON, To: models.FormatJSON, Encrypt: &models.FieldEncryption{Fields: []string{"name"}, KeyEnv: "FIELD_KEY"}},
+		},
+	}
+	pipeline.Options.Encryption.KeyFile = "/etc/convert/key"
+
+	var archive bytes.Buffer
+	exported, err := Export(&archive, pipeline, ExportOptions{Name: "people"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/etc/convert/fields.key", "/etc/convert/key"}, exported.Missing)
+}
+
+func TestImportRejectsTamperedBundles(t *testing.T) {
+	var archive bytes.Buffer
+	w := zip.NewWriter(&archive)
+	entry, err := w.Create(PipelineName)
+	require.NoError(t, err)
+	entry.Write([]byte(`{"Steps":[]}`))
+	entry, err = w.Create(ManifestName)
+	require.NoError(t, err)
+	entry.Write([]byte(`{"version":1,"files":[{"path":"pipeline.json","role":"pipeline","sha256":"00"}]}`))
+	require.NoError(t, w.Close())
+
+	dir := t.TempDir()
+	_, err = Import(bytes.NewReader(archive.Bytes()), int64(archive.Len()), dir)
+	assert.ErrorContains(t, err, "does not match its checksum")
+	assert.NoFileExists(t, filepath.Join(dir, PipelineName))
+}
+
+func TestImportRejectsPathsOutsideTheBundle(t *testing.T) {
+	pipelines := map[string]string{
+		"absolute output":  `{"OutputPath":"/etc/cron.d/job","Steps":[]}`,
+		"escaping input":   `{"InputPath":"../../secrets.csv","Steps":[]}`,
+		"escaping lookup":  `{"Steps":[{"From":"csv","To":"csv","Lookup":{"Path":"lookups/../../x.csv"}}]}`,
+		"nested sub-pipe":  `{"Steps":[{"From":"csv","To":"csv","Pipeline":{"Options":{"TemplatePath":"/tmp/t"},"Steps":[]}}]}`,
+		"escaping schema":  `{"Options":{"OutputSchema":{"Path":"../schema.json"}},"Steps":[]}`,
+		"escaping options": `{"Options":{"Validation":{"SchemaPath":"/schema.json"}},"Steps":[]}`,
+	}
+	for name, pipeline := range pipelines {
+		var archive bytes.Buffer
+		w := zip.NewWriter(&archive)
+		entry, err := w.Create(PipelineName)
+		require.NoError(t, err)
+		entry.Write([]byte(pipeline))
+		sum := sha256.Sum256([]byte(pipeline))
+		entry, err = w.Create(ManifestName)
+		require.NoError(t, err)
+		entry.Write([]byte(`{"version":1,"files":[{"path":"pipeline.json","role":"pipeline","sha256":"` + hex.EncodeToString(sum[:]) + `"}]}`))
+		require.NoError(t, w.Close())
+
+		dir := t.TempDir()
+		_, err = Import(bytes.NewReader(archive.Bytes()), int64(archive.Len()), dir)
+		assert.ErrorContains(t, err, "is outside the bundle", name)
+		assert.NoFileExists(t, filepath.Join(dir, PipelineName), name)
+	}
+}
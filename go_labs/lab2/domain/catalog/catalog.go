@@ -0,0 +1,182 @@
+// Package catalog holds the part of a convertd deployment that can change
+// without a restart: converter plugins, conversions served by remote
+// convertd instances, and named pipeline presets. Reload re-reads the file
+// and swaps both in while requests keep being served.
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"tmps-go-labs/lab2/domain/config"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/remote"
+)
+
+// Plugin registers the From-To conversion of the convertd at URL, replacing
+// any local converter for the pair until it is removed from the file.
+type Plugin struct {
+	From   models.FileFormat `json:"from"`
+	To     models.FileFormat `json:"to"`
+	URL    string            `json:"url"`
+	APIKey string            `json:"api_key"`
+}
+
+func (p Plugin) key() string {
+	return string(p.From) + "-" + string(p.To)
+}
+
+// File is the JSON form of a catalog. Presets are pipelines without input
+// or output paths, referenced by name.
+type File struct {
+	Plugins []Plugin                    `json:"plugins"`
+	Presets map[string]*models.Pipeline `json:"presets"`
+}
+
+type Catalog struct {
+	path string
+	pool *factory.ConverterPool
+
+	mu      sync.RWMutex
+	plugins map[string]Plugin
+	// replaced keeps the converter versions plugins displaced, so removing
+	// a plugin restores the local converters as they were.
+	replaced map[string]factory.Registrations
+	presets  map[string]*models.Pipeline
+}
+
+// New returns an empty catalog for the file at path; call Reload to load
+// it. Pooled converters of pairs that change on reload are flushed from
+// pool.
+func New(path string, pool *factory.ConverterPool) *Catalog {
+	return &Catalog{
+		path:     path,
+		pool:     pool,
+		plugins:  make(map[string]Plugin),
+		replaced: make(map[string]factory.Registrations),
+		presets:  make(map[string]*models.Pipeline),
+	}
+}
+
+// Load reads and validates a catalog file, expanding references such as
+// "${PLUGIN_API_KEY}".
+func Load(path string) (*File, error) {
+	var file File
+	if err := config.Load(path, &file); err != nil {
+		return nil, fmt.Errorf("failed to load catalog %s: %w", path, err)
+	}
+
+	plugins := make(map[string]bool)
+	for _, plugin := range file.Plugins {
+		if plugin.From == "" || plugin.To == "" || plugin.URL == "" {
+			return nil, fmt.Errorf("plugin %s needs from, to and url", plugin.key())
+		}
+		if plugins[plugin.key()] {
+			return nil, fmt.Errorf("plugin %s is listed twice", plugin.key())
+		}
+		plugins[plugin.key()] = true
+	}
+
+	for name, preset := range file.Presets {
+		if preset == nil {
+			return nil, fmt.Errorf("preset %q is empty", name)
+		}
+		if _, err := factory.NewPipelineBuilderFrom(preset).BuildSubPipeline(); err != nil {
+			return nil, fmt.Errorf("preset %q: %w", name, err)
+		}
+		for i, step := range preset.Steps {
+			key := string(step.From) + "-" + string(step.To)
+			if step.UsesConverter() && !plugins[key] && !factory.IsRegistered(key) {
+				return nil, fmt.Errorf("preset %q: step %d: unsupported conversion: %s to %s", name, i+1, step.From, step.To)
+			}
+		}
+	}
+	return &file, nil
+}
+
+// Reload loads the file and swaps in its plugins and presets. An invalid
+// file changes nothing, so a bad edit cannot take the running service down.
+// Conversions already running finish with the converters they hold.
+func (c *Catalog) Reload() error {
+	file, err := Load(c.path)
+	if err != nil {
+		return err
+	}
+
+	next := make(map[string]Plugin, len(file.Plugins))
+	for _, plugin := range file.Plugins {
+		next[plugin.key()] = plugin
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.plugins {
+		if _, kept := next[key]; kept {
+			continue
+		}
+		factory.RestoreConverter(key, c.replaced[key])
+		delete(c.replaced, key)
+		c.pool.Flush(key)
+	}
+
+	for key, plugin := range next {
+		current, installed := c.plugins[key]
+		if installed && current == plugin {
+			continue
+		}
+		if !installed {
+			c.replaced[key] = factory.SaveConverter(key)
+		}
+		remote.Register(remote.Remote{URL: plugin.URL, APIKey: plugin.APIKey, From: plugin.From, To: plugin.To})
+		c.pool.Flush(key)
+	}
+
+	c.plugins = next
+	c.presets = file.Presets
+	if c.presets == nil {
+		c.presets = make(map[string]*models.Pipeline)
+	}
+	return nil
+}
+
+// Preset returns a copy of the named preset's pipeline.
+func (c *Catalog) Preset(name string) (*models.Pipeline, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	preset, ok := c.presets[name]
+	if !ok {
+		return nil, false
+	}
+	return &models.Pipeline{Steps: slices.Clone(preset.Steps), Options: preset.Options}, true
+}
+
+// Presets returns the preset names in order.
+func (c *Catalog) Presets() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.presets))
+	for name := range c.presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Plugins returns the installed plugins ordered by conversion.
+func (c *Catalog) Plugins() []Plugin {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	plugins := make([]Plugin, 0, len(c.plugins))
+	for _, plugin := range c.plugins {
+		plugins = append(plugins, plugin)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].key() < plugins[j].key() })
+	return plugins
+}
@@ -0,0 +1,79 @@
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestReloadSwapsPluginsAndPresets(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("from the plugin"))
+	}))
+	defer remote.Close()
+
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	write := func(content string) {
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	pool := factory.NewConverterPool(1, factory.NewConverterFactory())
+	catalog := New(path, pool)
+	local := factory.ConverterVersions("csv-json")
+
+	write(`{
+		"plugins": [{"from": "csv", "to": "json", "url": "` + remote.URL + `"}],
+		"presets": {"people": {"Steps": [{"From": "csv", "To": "json"}]}}
+	}`)
+	assert.NoError(t, catalog.Reload())
+	assert.Equal(t, []string{"people"}, catalog.Presets())
+	assert.Equal(t, "from the plugin", string(convert(t, pool)))
+
+	write(`{"plugins": [{"from": "csv", "to": "json"}]}`)
+	assert.ErrorContains(t, catalog.Reload(), "needs from, to and url")
+	assert.Equal(t, []string{"people"}, catalog.Presets(), "a failed reload keeps the old catalog")
+
+	write(`{}`)
+	assert.NoError(t, catalog.Reload())
+	assert.Empty(t, catalog.Presets())
+	_, ok := catalog.Preset("people")
+	assert.False(t, ok)
+	assert.JSONEq(t, `[{"name":"ann"}]`, string(convert(t, pool)), "removing the plugin restores the local converter")
+	assert.Equal(t, local, factory.ConverterVersions("csv-json"), "with its versions and capabilities")
+}
+
+func TestPresetReturnsItsOwnSteps(t *testing.T) {
+	catalog := &Catalog{presets: map[string]*models.Pipeline{
+		"people": {Steps: []models.ConversionStep{{From: "csv", To: "json"}}},
+	}}
+
+	preset, _ := catalog.Preset("people")
+	preset.Steps[0].To = "xml"
+	preset, _ = catalog.Preset("people")
+	assert.Equal(t, models.FileFormat("json"), preset.Steps[0].To)
+}
+
+func TestLoadRejectsPresetsWithUnknownConversions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"presets": {"bad": {"Steps": [{"From": "csv", "To": "pdf"}]}}}`), 0644))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, `preset "bad": step 1: unsupported conversion`)
+}
+
+func convert(t *testing.T, pool *factory.ConverterPool) []byte {
+	converter, err := pool.Get("csv-json")
+	assert.NoError(t, err)
+	defer pool.Return("csv-json", converter)
+
+	result := converter.Convert(strings.NewReader("name\nann\n"), models.FormatCSV, models.FormatJSON)
+	assert.NoError(t, result.Error)
+	return result.Data
+}
@@ -0,0 +1,139 @@
+// Package config loads JSON configuration files with ${...} references
+// expanded, so credentials come from the environment or a secrets provider
+// at load time and never have to be written into the file itself.
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SecretProvider resolves references of the form ${scheme:name} for the
+// scheme it is registered under, e.g. a Vault or cloud secret manager.
+type SecretProvider interface {
+	Secret(name string) (string, error)
+}
+
+// SecretFunc adapts a function to SecretProvider.
+type SecretFunc func(name string) (string, error)
+
+func (f SecretFunc) Secret(name string) (string, error) {
+	return f(name)
+}
+
+var (
+	providers = map[string]SecretProvider{
+		"env":  SecretFunc(lookupEnv),
+		"file": SecretFunc(readSecretFile),
+	}
+	providersMu sync.RWMutex
+)
+
+// RegisterSecretProvider makes ${scheme:name} resolve through provider,
+// replacing any provider registered for the same scheme.
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+func providerFor(scheme string) (SecretProvider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	provider, ok := providers[scheme]
+	return provider, ok
+}
+
+func lookupEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// readSecretFile reads mounted secrets such as /run/secrets/db_password,
+// dropping the trailing newline most tools write.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Expand replaces every reference in text:
+//
+//	${NAME}            the environment variable NAME, which must be set
+//	${NAME:-fallback}  NAME, or fallback when it is unset or empty
+//	${scheme:name}     name resolved by the provider registered for scheme
+//	$$                 a literal $
+//
+// All unresolved references are reported together.
+func Expand(text string) (string, error) {
+	var out strings.Builder
+	var failures []string
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != '$' || i+1 == len(text) {
+			out.WriteByte(text[i])
+			continue
+		}
+
+		switch text[i+1] {
+		case '$':
+			out.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			out.WriteByte('$')
+			continue
+		}
+
+		end := strings.IndexByte(text[i+2:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference at offset %d", i)
+		}
+		reference := text[i+2 : i+2+end]
+		i += end + 2
+
+		value, err := resolve(reference)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("${%s}: %v", reference, err))
+			continue
+		}
+		out.WriteString(value)
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return "", fmt.Errorf("unresolved references: %s", strings.Join(failures, "; "))
+	}
+	return out.String(), nil
+}
+
+func resolve(reference string) (string, error) {
+	if name, fallback, ok := strings.Cut(reference, ":-"); ok {
+		if value := os.Getenv(name); value != "" {
+			return value, nil
+		}
+		return fallback, nil
+	}
+
+	if scheme, name, ok := strings.Cut(reference, ":"); ok {
+		provider, found := providerFor(scheme)
+		if !found {
+			return "", fmt.Errorf("no secret provider for %q", scheme)
+		}
+		return provider.Secret(name)
+	}
+
+	if reference == "" {
+		return "", fmt.Errorf("empty reference")
+	}
+	return lookupEnv(reference)
+}
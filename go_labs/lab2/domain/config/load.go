@@ -0,0 +1,89 @@
+// Package config loads JSON configuration files with ${...} references
+// expanded, so credentials come from the environment or a secrets provider
+// at load time and never have to be written into the file itself.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Load reads the JSON file at path into target, expanding references in
+// string values only, so a secret containing quotes cannot break the JSON.
+// The file is checked against target first (see Validate), so typos in
+// keys and values of the wrong type are reported with their line instead
+// of being ignored.
+func Load(path string, target interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return Decode(data, target)
+}
+
+// Decode is Load for configuration that is already in memory.
+func Decode(data []byte, target interface{}) error {
+	if err := Validate(data, target); err != nil {
+		return err
+	}
+
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return err
+	}
+
+	tree, err := expandTree(tree)
+	if err != nil {
+		return err
+	}
+
+	expanded, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(expanded, target)
+}
+
+func expandTree(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return Expand(typed)
+	case map[string]interface{}:
+		for key, child := range typed {
+			expanded, err := expandTree(child)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			typed[key] = expanded
+		}
+		return typed, nil
+	case []interface{}:
+		for i, child := range typed {
+			expanded, err := expandTree(child)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			typed[i] = expanded
+		}
+		return typed, nil
+	default:
+		return value, nil
+	}
+}
+
+// LoadPipeline reads a pipeline definition, the JSON form of
+// models.Pipeline, with references expanded. The pipeline is not validated;
+// pass it to factory.NewPipelineBuilderFrom and Build it before running.
+func LoadPipeline(path string) (*models.Pipeline, error) {
+	var pipeline models.Pipeline
+	if err := Load(path, &pipeline); err != nil {
+		return nil, fmt.Errorf("failed to load pipeline config %s: %w", path, err)
+	}
+	return &pipeline, nil
+}
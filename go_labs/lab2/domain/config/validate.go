@@ -0,0 +1,324 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Problem is one mismatch between a configuration file and the type it is
+// loaded into, at a 1-based line and column of the file.
+type Problem struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("line %d, column %d: %s", p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", p.Line, p.Column, p.Path, p.Message)
+}
+
+// ValidationError lists every problem found in a configuration file, in
+// the order they appear.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.String()
+	}
+	return "invalid config: " + strings.Join(messages, "; ")
+}
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Validate checks JSON configuration against the Go type of target, which
+// acts as its schema: keys must name fields (matched like encoding/json
+// does, ignoring case) and values must have the kind the field expects.
+// Unknown keys come with the closest field name as a suggestion. Values of
+// types with their own UnmarshalJSON or UnmarshalText are not inspected.
+func Validate(data []byte, target interface{}) error {
+	w := &walker{data: data, decoder: json.NewDecoder(bytes.NewReader(data))}
+	w.decoder.UseNumber()
+	if err := w.value(reflect.TypeOf(target), ""); err != nil {
+		return err
+	}
+	if len(w.problems) > 0 {
+		return &ValidationError{Problems: w.problems}
+	}
+	return nil
+}
+
+type walker struct {
+	data     []byte
+	decoder  *json.Decoder
+	problems []Problem
+}
+
+// start returns the offset of the next token, skipping the whitespace and
+// separators the decoder has not consumed yet.
+func (w *walker) start() int {
+	offset := int(w.decoder.InputOffset())
+	for offset < len(w.data) && strings.IndexByte(" \t\r\n,:", w.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func (w *walker) report(offset int, path, format string, args ...interface{}) {
+	line := 1 + bytes.Count(w.data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(w.data[:offset], '\n')
+	w.problems = append(w.problems, Problem{Line: line, Column: column, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// value reads the next value and checks it against t. A nil t accepts
+// anything.
+func (w *walker) value(t reflect.Type, path string) error {
+	t = schemaType(t)
+	offset := w.start()
+	token, err := w.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '{' {
+			return w.object(t, path, offset)
+		}
+		return w.array(t, path, offset)
+	case nil:
+		return nil
+	}
+
+	if t == nil {
+		return nil
+	}
+	var got, want string
+	switch token := token.(type) {
+	case string:
+		got = "a string"
+		if t.Kind() == reflect.String || isBytes(t) {
+			return nil
+		}
+	case bool:
+		got = "a boolean"
+		if t.Kind() == reflect.Bool {
+			return nil
+		}
+	case json.Number:
+		got = "a number"
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if _, err := token.Int64(); err == nil {
+				return nil
+			}
+			got, want = token.String(), "an integer"
+		}
+	}
+	if want == "" {
+		want = describe(t)
+	}
+	w.report(offset, path, "expected %s, got %s", want, got)
+	return nil
+}
+
+func (w *walker) object(t reflect.Type, path string, offset int) error {
+	if t != nil && t.Kind() != reflect.Struct && t.Kind() != reflect.Map {
+		w.report(offset, path, "expected %s, got an object", describe(t))
+		t = nil
+	}
+	for w.decoder.More() {
+		keyOffset := w.start()
+		token, err := w.decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+
+		var child reflect.Type
+		switch {
+		case t == nil:
+		case t.Kind() == reflect.Map:
+			child = t.Elem()
+		default:
+			fields := fieldsOf(t)
+			field, ok := lookupField(fields, key)
+			if !ok {
+				if suggestion := closest(fields, key); suggestion != "" {
+					w.report(keyOffset, childPath, "unknown key; did you mean %q?", suggestion)
+				} else {
+					w.report(keyOffset, childPath, "unknown key")
+				}
+			}
+			child = field.typ
+		}
+		if err := w.value(child, childPath); err != nil {
+			return err
+		}
+	}
+	_, err := w.decoder.Token()
+	return err
+}
+
+func (w *walker) array(t reflect.Type, path string, offset int) error {
+	if t != nil && t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		w.report(offset, path, "expected %s, got an array", describe(t))
+		t = nil
+	}
+	var elem reflect.Type
+	if t != nil {
+		elem = t.Elem()
+	}
+	for i := 0; w.decoder.More(); i++ {
+		if err := w.value(elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	_, err := w.decoder.Token()
+	return err
+}
+
+// schemaType dereferences pointers and returns nil for types that accept
+// any JSON value or decode it themselves.
+func schemaType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface {
+		return nil
+	}
+	pointer := reflect.PointerTo(t)
+	if pointer.Implements(jsonUnmarshaler) || pointer.Implements(textUnmarshaler) {
+		return nil
+	}
+	return t
+}
+
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func describe(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		if isBytes(t) {
+			return "a base64 string"
+		}
+		return "an array"
+	default:
+		return "an integer"
+	}
+}
+
+type field struct {
+	name string
+	typ  reflect.Type
+}
+
+// fieldsOf lists the JSON fields of a struct as encoding/json sees them,
+// including those promoted from embedded structs.
+func fieldsOf(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, fieldsOf(embedded)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, field{name: name, typ: f.Type})
+	}
+	return fields
+}
+
+// lookupField matches a key exactly first and then ignoring case, like
+// encoding/json.
+func lookupField(fields []field, key string) (field, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// closest returns the field name nearest to key, or "" when none is close
+// enough to be a plausible typo.
+func closest(fields []field, key string) string {
+	best, bestDistance := "", len(key)/3+2
+	for _, f := range fields {
+		if distance := editDistance(strings.ToLower(f.name), strings.ToLower(key)); distance < bestDistance {
+			best, bestDistance = f.name, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
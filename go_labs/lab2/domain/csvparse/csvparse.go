@@ -0,0 +1,114 @@
+// Package csvparse reads whole CSV tables with the parser chosen in the
+// conversion options: encoding/csv, or a chunked parser that splits large
+// inputs at record boundaries and parses the chunks in parallel.
+package csvparse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"runtime"
+	"sync"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// MinParallelSize is the smallest input the parallel parser splits. Smaller
+// inputs are not worth the goroutines and go to encoding/csv.
+const MinParallelSize = 1 << 20
+
+// ReadAll reads every record of input, like csv.Reader.ReadAll with the
+// options' delimiter. Both parsers return the same records and errors.
+func ReadAll(input io.Reader, options models.ConversionOptions) ([][]string, error) {
+	if options.CSVParser != models.CSVParserParallel {
+		return newReader(input, options.Delimiter()).ReadAll()
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < MinParallelSize {
+		return newReader(bytes.NewReader(data), options.Delimiter()).ReadAll()
+	}
+	return parseParallel(data, options.Delimiter(), runtime.GOMAXPROCS(0))
+}
+
+func newReader(input io.Reader, delimiter rune) *csv.Reader {
+	reader := csv.NewReader(input)
+	reader.Comma = delimiter
+	return reader
+}
+
+// parseParallel parses up to workers chunks concurrently. A chunk that
+// fails, or records whose field counts disagree, send the whole input back
+// through encoding/csv so the error (and its line number) is exactly the
+// standard parser's.
+func parseParallel(data []byte, delimiter rune, workers int) ([][]string, error) {
+	chunks := split(data, workers)
+	if len(chunks) < 2 {
+		return newReader(bytes.NewReader(data), delimiter).ReadAll()
+	}
+
+	tables := make([][][]string, len(chunks))
+	failed := make([]bool, len(chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reader := newReader(bytes.NewReader(chunk), delimiter)
+			reader.FieldsPerRecord = -1
+			table, err := reader.ReadAll()
+			tables[i], failed[i] = table, err != nil
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i, table := range tables {
+		if failed[i] {
+			return newReader(bytes.NewReader(data), delimiter).ReadAll()
+		}
+		total += len(table)
+	}
+
+	records := make([][]string, 0, total)
+	for _, table := range tables {
+		records = append(records, table...)
+	}
+	for _, record := range records {
+		if len(record) != len(records[0]) {
+			return newReader(bytes.NewReader(data), delimiter).ReadAll()
+		}
+	}
+	return records, nil
+}
+
+// split cuts data into at most n chunks of about equal size. Cuts are made
+// only after a newline outside quotes, which for well-formed CSV is always
+// the end of a record: every quote either opens or closes a quoted field or
+// is half of an escaped "" pair, so counting them tells whether a newline
+// sits inside a field.
+func split(data []byte, n int) [][]byte {
+	if n < 2 {
+		return [][]byte{data}
+	}
+
+	size := len(data)/n + 1
+	chunks := make([][]byte, 0, n)
+	start, quoted := 0, false
+	for i, c := range data {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\n' && !quoted && i+1-start >= size:
+			chunks = append(chunks, data[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(data) {
+		chunks = append(chunks, data[start:])
+	}
+	return chunks
+}
@@ -0,0 +1,113 @@
+package csvparse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func standard(data string, delimiter rune) ([][]string, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.Comma = delimiter
+	return reader.ReadAll()
+}
+
+func TestParallelMatchesEncodingCSV(t *testing.T) {
+	inputs := map[string]string{
+		"plain":          "name,city\nann,oslo\nbob,rome\ncid,lima\ndan,kyiv\n",
+		"no trailing nl": "name,city\nann,oslo\nbob,rome",
+		"quoted newline": "name,note\nann,\"line one\nline two\"\nbob,\"a\n\nb\"\ncid,plain\n",
+		"escaped quotes": "name,quote\nann,\"she said \"\"hi\"\"\"\nbob,\"\"\"\"\ncid,x\n",
+		"crlf":           "name,city\r\nann,oslo\r\nbob,\"ro\r\nme\"\r\ncid,lima\r\n",
+		"blank lines":    "name,city\n\nann,oslo\n\n\nbob,rome\n\n",
+		"unicode":        "名前,都市\nアン,オスロ\nボブ,ローマ\n",
+		"empty":          "",
+		"bare quote":     "name,city\nann,oslo\nbob,ro\"me\ncid,lima\n",
+		"unterminated":   "name,city\nann,oslo\nbob,\"rome\ncid,lima\n",
+		"field count":    "name,city\nann,oslo\nbob\ncid,lima\n",
+	}
+
+	for name, input := range inputs {
+		expected, expectedErr := standard(input, ',')
+		for workers := 1; workers <= 8; workers++ {
+			t.Run(fmt.Sprintf("%s/%d", name, workers), func(t *testing.T) {
+				records, err := parseParallel([]byte(input), ',', workers)
+				assert.Equal(t, expected, records)
+				if expectedErr == nil {
+					assert.NoError(t, err)
+				} else {
+					assert.EqualError(t, err, expectedErr.Error())
+				}
+			})
+		}
+	}
+}
+
+func TestParallelUsesDelimiter(t *testing.T) {
+	input := "name;city\nann;oslo\nbob;\"ro;me\"\ncid;lima\n"
+	expected, err := standard(input, ';')
+	assert.NoError(t, err)
+
+	records, err := parseParallel([]byte(input), ';', 3)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, records)
+}
+
+func TestSplitCutsOnlyBetweenRecords(t *testing.T) {
+	data := []byte("a,b\n1,\"x\ny\"\n2,z\n3,w\n")
+	chunks := split(data, 4)
+	assert.Greater(t, len(chunks), 1)
+	assert.Equal(t, data, bytes.Join(chunks, nil))
+	for _, chunk := range chunks {
+		assert.Equal(t, 0, bytes.Count(chunk, []byte(`"`))%2, string(chunk))
+	}
+}
+
+func largeCSV(rows int) string {
+	var input strings.Builder
+	input.WriteString("id,name,note\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&input, "%d,person%d,\"note with, comma and\nnewline %d\"\n", i, i, i)
+	}
+	return input.String()
+}
+
+func TestReadAllSelectsParser(t *testing.T) {
+	input := largeCSV(40000)
+	assert.GreaterOrEqual(t, len(input), MinParallelSize)
+	expected, err := standard(input, ',')
+	assert.NoError(t, err)
+
+	for _, parser := range []models.CSVParser{"", models.CSVParserStandard, models.CSVParserParallel} {
+		records, err := ReadAll(strings.NewReader(input), models.ConversionOptions{CSVParser: parser})
+		assert.NoError(t, err, parser)
+		assert.Equal(t, expected, records, parser)
+	}
+}
+
+func BenchmarkStandard(b *testing.B) {
+	input := largeCSV(100000)
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		if _, err := ReadAll(strings.NewReader(input), models.ConversionOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	input := largeCSV(100000)
+	options := models.NewOptions(models.WithCSVParser(models.CSVParserParallel))
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		if _, err := ReadAll(strings.NewReader(input), options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func pipeline(input, output string) *models.Pipeline {
+	return &models.Pipeline{
+		InputPath:  input,
+		OutputPath: output,
+		Steps:      []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}},
+	}
+}
+
+type fakeRunner struct {
+	fail    map[string]bool
+	running atomic.Int32
+	peak    atomic.Int32
+	mu      sync.Mutex
+	ran     []string
+}
+
+func (r *fakeRunner) ExecuteContext(ctx context.Context, p *models.Pipeline) *models.PipelineResult {
+	now := r.running.Add(1)
+	defer r.running.Add(-1)
+	for {
+		peak := r.peak.Load()
+		if now <= peak || r.peak.CompareAndSwap(peak, now) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	r.mu.Lock()
+	r.ran = append(r.ran, p.OutputPath)
+	r.mu.Unlock()
+
+	if r.fail[p.OutputPath] {
+		return &models.PipelineResult{Error: errors.New("boom")}
+	}
+	return &models.PipelineResult{Success: true}
+}
+
+func TestGraphInfersDependenciesFromPaths(t *testing.T) {
+	g, err := NewGraph(
+		Job{Name: "report", Pipeline: pipeline("joined.json", "report.yaml")},
+		Job{Name: "orders", Pipeline: pipeline("orders.csv", "orders.json")},
+		Job{Name: "join", Pipeline: pipeline("customers.json", "joined.json"), DependsOn: []string{"orders", "customers"}},
+		Job{Name: "customers", Pipeline: pipeline("customers.csv", "customers.json")},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"orders", "customers", "join", "report"}, g.Order())
+	assert.Equal(t, []string{"orders", "customers"}, g.Dependencies("join"))
+	assert.Equal(t, []string{"join"}, g.Dependencies("report"))
+}
+
+func TestGraphRejectsCyclesAndUnknownJobs(t *testing.T) {
+	_, err := NewGraph(
+		Job{Name: "a", Pipeline: pipeline("b.json", "a.json")},
+		Job{Name: "b", Pipeline: pipeline("a.json", "b.json")},
+		Job{Name: "c", Pipeline: pipeline("c.csv", "c.json")},
+	)
+	assert.EqualError(t, err, "dependency cycle among jobs: a, b")
+
+	_, err = NewGraph(Job{Name: "a", Pipeline: pipeline("a.csv", "a.json"), DependsOn: []string{"z"}})
+	assert.EqualError(t, err, `job "a" depends on unknown job "z"`)
+}
+
+func TestRunParallelAndSkipsDependentsOfFailures(t *testing.T) {
+	g, err := NewGraph(
+		Job{Name: "orders", Pipeline: pipeline("orders.csv", "orders.json")},
+		Job{Name: "customers", Pipeline: pipeline("customers.csv", "customers.json")},
+		Job{Name: "products", Pipeline: pipeline("products.csv", "products.json")},
+		Job{Name: "join", Pipeline: pipeline("orders.json", "joined.json"), DependsOn: []string{"customers"}},
+		Job{Name: "report", Pipeline: pipeline("joined.json", "report.yaml")},
+		Job{Name: "catalog", Pipeline: pipeline("products.json", "catalog.yaml")},
+	)
+	require.NoError(t, err)
+
+	runner := &fakeRunner{fail: map[string]bool{"customers.json": true}}
+	report := g.Run(context.Background(), runner, 3)
+
+	assert.False(t, report.Success)
+	assert.Equal(t, int32(3), runner.peak.Load())
+	assert.Equal(t, StatusSucceeded, report.Job("orders").Status)
+	assert.Equal(t, StatusFailed, report.Job("customers").Status)
+	assert.Equal(t, StatusSkipped, report.Job("join").Status)
+	assert.Equal(t, StatusSkipped, report.Job("report").Status)
+	assert.EqualError(t, report.Job("report").Err, `dependency "customers" did not succeed`)
+	assert.Equal(t, StatusSucceeded, report.Job("catalog").Status)
+	assert.NotContains(t, runner.ran, "joined.json")
+	assert.Len(t, report.Jobs, 6)
+}
+
+func TestLoadGraphBuildsPipelines(t *testing.T) {
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "jobs.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	graph, err := LoadGraph(write(`{"jobs": [
+		{"name": "orders", "pipeline": {"InputPath": "orders.csv", "OutputPath": "orders.json", "Steps": [{"From": "csv", "To": "json"}]}},
+		{"name": "report", "pipeline": {"InputPath": "orders.json", "OutputPath": "orders.yaml", "Steps": [{"From": "json", "To": "yaml"}]}}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders"}, graph.Dependencies("report"))
+
+	_, err = LoadGraph(write(`{"jobs": [
+		{"name": "orders", "pipeline": {"InputPath": "orders.csv", "Steps": [{"From": "csv", "To": "json"}]}}
+	]}`))
+	assert.ErrorContains(t, err, `job "orders": output path is required`)
+}
@@ -0,0 +1,156 @@
+// Package dag runs several pipelines as one job. Pipelines depend on each
+// other explicitly or by reading another pipeline's output file, and run in
+// topological order with independent pipelines in parallel.
+package dag
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/config"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Job is one pipeline in the graph. DependsOn names jobs that must succeed
+// first; a job reading another job's output depends on it implicitly.
+type Job struct {
+	Name      string           `json:"name"`
+	Pipeline  *models.Pipeline `json:"pipeline"`
+	DependsOn []string         `json:"depends_on,omitempty"`
+}
+
+type Graph struct {
+	jobs       []Job
+	index      map[string]int
+	deps       map[string][]string
+	dependents map[string][]string
+	order      []string
+}
+
+// NewGraph checks that job names are unique, dependencies exist and there
+// is no cycle, and infers dependencies from input and output paths.
+func NewGraph(jobs ...Job) (*Graph, error) {
+	g := &Graph{
+		jobs:       jobs,
+		index:      make(map[string]int, len(jobs)),
+		deps:       make(map[string][]string, len(jobs)),
+		dependents: make(map[string][]string, len(jobs)),
+	}
+
+	producers := make(map[string]string)
+	for i, job := range jobs {
+		if job.Name == "" {
+			return nil, fmt.Errorf("job %d has no name", i+1)
+		}
+		if _, exists := g.index[job.Name]; exists {
+			return nil, fmt.Errorf("duplicate job %q", job.Name)
+		}
+		if job.Pipeline == nil {
+			return nil, fmt.Errorf("job %q has no pipeline", job.Name)
+		}
+		g.index[job.Name] = i
+		if job.Pipeline.OutputPath != "" {
+			producers[filepath.Clean(job.Pipeline.OutputPath)] = job.Name
+		}
+	}
+
+	for _, job := range jobs {
+		seen := make(map[string]bool)
+		for _, dep := range job.DependsOn {
+			if _, ok := g.index[dep]; !ok {
+				return nil, fmt.Errorf("job %q depends on unknown job %q", job.Name, dep)
+			}
+			g.addDependency(job.Name, dep, seen)
+		}
+		if producer, ok := producers[filepath.Clean(job.Pipeline.InputPath)]; ok && producer != job.Name {
+			g.addDependency(job.Name, producer, seen)
+		}
+	}
+
+	order, err := g.sort()
+	if err != nil {
+		return nil, err
+	}
+	g.order = order
+	return g, nil
+}
+
+func (g *Graph) addDependency(job, dep string, seen map[string]bool) {
+	if seen[dep] {
+		return
+	}
+	seen[dep] = true
+	g.deps[job] = append(g.deps[job], dep)
+	g.dependents[dep] = append(g.dependents[dep], job)
+}
+
+// sort orders jobs so every job follows its dependencies, keeping the
+// declaration order among jobs that are ready at the same time.
+func (g *Graph) sort() ([]string, error) {
+	remaining := make(map[string]int, len(g.jobs))
+	for _, job := range g.jobs {
+		remaining[job.Name] = len(g.deps[job.Name])
+	}
+
+	order := make([]string, 0, len(g.jobs))
+	done := make(map[string]bool, len(g.jobs))
+	for len(order) < len(g.jobs) {
+		progressed := false
+		for _, job := range g.jobs {
+			if done[job.Name] || remaining[job.Name] > 0 {
+				continue
+			}
+			done[job.Name] = true
+			order = append(order, job.Name)
+			for _, dependent := range g.dependents[job.Name] {
+				remaining[dependent]--
+			}
+			progressed = true
+		}
+		if !progressed {
+			var cycle []string
+			for _, job := range g.jobs {
+				if !done[job.Name] {
+					cycle = append(cycle, job.Name)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle among jobs: %s", strings.Join(cycle, ", "))
+		}
+	}
+	return order, nil
+}
+
+// Order returns the job names in an order that respects every dependency.
+func (g *Graph) Order() []string {
+	return append([]string(nil), g.order...)
+}
+
+// Dependencies returns the jobs name waits for, explicit and inferred.
+func (g *Graph) Dependencies(name string) []string {
+	return append([]string(nil), g.deps[name]...)
+}
+
+// LoadGraph reads {"jobs": [...]} from a config file, with references
+// expanded as by config.Load, and builds the pipeline of every job as
+// factory.NewPipelineBuilderFrom would.
+func LoadGraph(path string) (*Graph, error) {
+	var file struct {
+		Jobs []Job `json:"jobs"`
+	}
+	if err := config.Load(path, &file); err != nil {
+		return nil, fmt.Errorf("failed to load job graph %s: %w", path, err)
+	}
+	for i, job := range file.Jobs {
+		if job.Pipeline == nil {
+			continue
+		}
+		pipeline, err := factory.NewPipelineBuilderFrom(job.Pipeline).Build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load job graph %s: job %q: %w", path, job.Name, err)
+		}
+		file.Jobs[i].Pipeline = pipeline
+	}
+	return NewGraph(file.Jobs...)
+}
@@ -0,0 +1,154 @@
+// Package dag runs several pipelines as one job. Pipelines depend on each
+// other explicitly or by reading another pipeline's output file, and run in
+// topological order with independent pipelines in parallel.
+package dag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+)
+
+// Runner is the part of the pipeline executor the graph needs;
+// factory.PipelineExecutor implements it.
+type Runner interface {
+	ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult
+}
+
+type JobResult struct {
+	Name   string
+	Status Status
+	Result *models.PipelineResult
+	Err    error
+}
+
+type Report struct {
+	Success bool
+	Jobs    []*JobResult
+}
+
+// Job returns the result for name, or nil when there is no such job.
+func (r *Report) Job(name string) *JobResult {
+	for _, job := range r.Jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+// Run executes the graph with up to parallelism pipelines at once. A job
+// starts as soon as all its dependencies succeeded; when one fails, every job
+// depending on it, directly or not, is skipped while unrelated jobs carry
+// on. Results are reported in topological order.
+func (g *Graph) Run(ctx context.Context, runner Runner, parallelism int) *Report {
+	slots := make(chan struct{}, max(parallelism, 1))
+	results := make(map[string]*JobResult, len(g.jobs))
+	waiting := make(map[string]int, len(g.jobs))
+	for _, job := range g.jobs {
+		waiting[job.Name] = len(g.deps[job.Name])
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		start func(name string)
+	)
+
+	// skip marks every job downstream of name as skipped.
+	var skip func(name, cause string)
+	skip = func(name, cause string) {
+		for _, dependent := range g.dependents[name] {
+			if _, decided := results[dependent]; decided {
+				continue
+			}
+			results[dependent] = &JobResult{
+				Name:   dependent,
+				Status: StatusSkipped,
+				Err:    fmt.Errorf("dependency %q did not succeed", cause),
+			}
+			skip(dependent, cause)
+		}
+	}
+
+	finish := func(result *JobResult) {
+		mu.Lock()
+		results[result.Name] = result
+		var ready []string
+		if result.Status == StatusSucceeded {
+			for _, dependent := range g.dependents[result.Name] {
+				waiting[dependent]--
+				if _, decided := results[dependent]; !decided && waiting[dependent] == 0 {
+					ready = append(ready, dependent)
+				}
+			}
+		} else {
+			skip(result.Name, result.Name)
+		}
+		mu.Unlock()
+
+		for _, name := range ready {
+			start(name)
+		}
+	}
+
+	start = func(name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job := g.jobs[g.index[name]]
+
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				finish(&JobResult{Name: name, Status: StatusSkipped, Err: context.Cause(ctx)})
+				return
+			}
+			defer func() { <-slots }()
+
+			if ctx.Err() != nil {
+				finish(&JobResult{Name: name, Status: StatusSkipped, Err: context.Cause(ctx)})
+				return
+			}
+
+			result := runner.ExecuteContext(ctx, job.Pipeline)
+			jobResult := &JobResult{Name: name, Status: StatusSucceeded, Result: result}
+			if result.Error != nil || !result.Success {
+				jobResult.Status = StatusFailed
+				jobResult.Err = result.Error
+			}
+			finish(jobResult)
+		}()
+	}
+
+	var roots []string
+	for _, job := range g.jobs {
+		if waiting[job.Name] == 0 {
+			roots = append(roots, job.Name)
+		}
+	}
+	for _, name := range roots {
+		start(name)
+	}
+	wg.Wait()
+
+	report := &Report{Success: true}
+	for _, name := range g.order {
+		result := results[name]
+		if result.Status != StatusSucceeded {
+			report.Success = false
+		}
+		report.Jobs = append(report.Jobs, result)
+	}
+	return report
+}
@@ -0,0 +1,184 @@
+// Package delta reduces a repeatedly converted dataset to the records that
+// changed since the previous run, keyed by a primary field, with a periodic
+// full snapshot so downstream sync systems can resynchronize.
+package delta
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+// OpField is added to every emitted record to say what happened to it.
+const OpField = "_op"
+
+const (
+	OpAdded    = "added"
+	OpChanged  = "changed"
+	OpRemoved  = "removed"
+	OpSnapshot = "snapshot"
+)
+
+// Base is the full dataset of the previous run, which the next run is
+// compared against.
+type Base struct {
+	Key               string                    `json:"key"`
+	RunsSinceSnapshot int                       `json:"runs_since_snapshot"`
+	Records           map[string]records.Record `json:"records"`
+}
+
+// BasePath is where the base for outputPath is kept, e.g. people.json ->
+// people.delta-base.json.
+func BasePath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".delta-base.json"
+}
+
+// LoadBase reads the base at path, returning nil when there is none yet.
+func LoadBase(path string) (*Base, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delta base: %w", err)
+	}
+
+	var base Base
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&base); err != nil {
+		return nil, fmt.Errorf("failed to parse delta base %s: %w", path, err)
+	}
+	for _, record := range base.Records {
+		values.Numbers(map[string]interface{}(record))
+	}
+	return &base, nil
+}
+
+// Save writes the base through a temporary file, so an interrupted save
+// never leaves a truncated base for the next run to diff against.
+func (b *Base) Save(path string) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write delta base: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write delta base: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write delta base: %w", err)
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// Index keys current by the key field, normalizing values the way the base
+// stores them so unchanged records compare equal. Records that already have
+// an OpField are rejected, since the marker would overwrite their value.
+func Index(current []records.Record, key string) (map[string]records.Record, []string, error) {
+	index := make(map[string]records.Record, len(current))
+	order := make([]string, 0, len(current))
+	for i, record := range current {
+		if _, ok := record[OpField]; ok {
+			return nil, nil, fmt.Errorf("record %d has a %q field, which delta output reserves for its change marker", i+1, OpField)
+		}
+		value, ok := record[key]
+		if !ok || value == nil || value == "" {
+			return nil, nil, fmt.Errorf("record %d has no %q key", i+1, key)
+		}
+		id := fmt.Sprint(value)
+		if _, duplicate := index[id]; duplicate {
+			return nil, nil, fmt.Errorf("record %d repeats key %s=%s", i+1, key, id)
+		}
+
+		normalized, err := normalize(record)
+		if err != nil {
+			return nil, nil, err
+		}
+		index[id] = normalized
+		order = append(order, id)
+	}
+	return index, order, nil
+}
+
+// Compute compares current with base and returns the records to emit and
+// the base for the next run. Without a base, after a key change, or every
+// fullEvery runs it emits a snapshot of all records instead; fullEvery <= 0
+// disables periodic snapshots.
+func Compute(base *Base, current []records.Record, key string, fullEvery int) ([]interface{}, *Base, error) {
+	index, order, err := Index(current, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next := &Base{Key: key, Records: index}
+	if base == nil || base.Key != key || (fullEvery > 0 && base.RunsSinceSnapshot+1 >= fullEvery) {
+		out := make([]interface{}, 0, len(order))
+		for _, id := range order {
+			out = append(out, tagged(index[id], OpSnapshot))
+		}
+		return out, next, nil
+	}
+	next.RunsSinceSnapshot = base.RunsSinceSnapshot + 1
+
+	out := make([]interface{}, 0)
+	for _, id := range order {
+		previous, existed := base.Records[id]
+		switch {
+		case !existed:
+			out = append(out, tagged(index[id], OpAdded))
+		case !reflect.DeepEqual(previous, index[id]):
+			out = append(out, tagged(index[id], OpChanged))
+		}
+	}
+
+	var removed []string
+	for id := range base.Records {
+		if _, ok := index[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+	for _, id := range removed {
+		out = append(out, map[string]interface{}{key: base.Records[id][key], OpField: OpRemoved})
+	}
+	return out, next, nil
+}
+
+func tagged(record records.Record, op string) map[string]interface{} {
+	out := make(map[string]interface{}, len(record)+1)
+	for field, value := range record {
+		out[field] = value
+	}
+	out[OpField] = op
+	return out
+}
+
+func normalize(record records.Record) (records.Record, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	out, err := values.UnmarshalJSON(data, true)
+	if err != nil {
+		return nil, err
+	}
+	return records.Record(out.(map[string]interface{})), nil
+}
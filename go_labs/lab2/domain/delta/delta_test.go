@@ -0,0 +1,74 @@
+package delta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func TestComputeEmitsChangesBetweenSnapshots(t *testing.T) {
+	first := []records.Record{{"id": "1", "name": "ann"}, {"id": "2", "name": "bob"}}
+	out, base, err := Compute(nil, first, "id", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "1", "name": "ann", OpField: OpSnapshot},
+		map[string]interface{}{"id": "2", "name": "bob", OpField: OpSnapshot},
+	}, out)
+
+	path := filepath.Join(t.TempDir(), "base.json")
+	require.NoError(t, base.Save(path))
+	base, err = LoadBase(path)
+	require.NoError(t, err)
+
+	second := []records.Record{{"id": "2", "name": "bobby"}, {"id": "3", "name": "cid"}, {"id": "1", "name": "ann"}}
+	out, base, err = Compute(base, second, "id", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "2", "name": "bobby", OpField: OpChanged},
+		map[string]interface{}{"id": "3", "name": "cid", OpField: OpAdded},
+	}, out)
+
+	out, base, err = Compute(base, []records.Record{{"id": "3", "name": "cid"}}, "id", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "1", OpField: OpRemoved},
+		map[string]interface{}{"id": "2", OpField: OpRemoved},
+	}, out)
+	assert.Equal(t, 2, base.RunsSinceSnapshot)
+
+	out, base, err = Compute(base, []records.Record{{"id": "3", "name": "cid"}}, "id", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "3", "name": "cid", OpField: OpSnapshot}}, out)
+	assert.Equal(t, 0, base.RunsSinceSnapshot)
+}
+
+func TestComputeRequiresUniqueKeys(t *testing.T) {
+	_, _, err := Compute(nil, []records.Record{{"id": "1"}, {"name": "x"}}, "id", 0)
+	assert.EqualError(t, err, `record 2 has no "id" key`)
+
+	_, _, err = Compute(nil, []records.Record{{"id": 1.0}, {"id": 1.0}}, "id", 0)
+	assert.EqualError(t, err, "record 2 repeats key id=1")
+
+	_, _, err = Compute(nil, []records.Record{{"id": "1", "_op": "insert"}}, "id", 0)
+	assert.EqualError(t, err, `record 1 has a "_op" field, which delta output reserves for its change marker`)
+}
+
+func TestSaveLeavesNoTemporaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "base.json")
+	_, base, err := Compute(nil, []records.Record{{"id": "1"}}, "id", 0)
+	require.NoError(t, err)
+	require.NoError(t, base.Save(path))
+	require.NoError(t, base.Save(path))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Error(t, base.Save(filepath.Join(dir, "missing", "base.json")))
+}
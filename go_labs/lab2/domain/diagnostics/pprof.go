@@ -0,0 +1,73 @@
+// Package diagnostics exposes Go's profilers to users of the service and
+// the command line, so slow conversions can be diagnosed without a
+// debugger. Pipeline steps carry pprof labels (step, conversion), so
+// profiles can be narrowed to one step with go tool pprof -tagfocus.
+package diagnostics
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"time"
+)
+
+// Handler serves the net/http/pprof endpoints under /debug/pprof/. It is
+// meant for a separate, private listener: profiles expose input data held
+// in memory and the command line the process was started with.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// Serve serves Handler on its own listener at addr, so the endpoints are
+// never reachable through a service's tenant-facing address, and logs
+// where they are and why the listener stopped. It blocks; services run it
+// in a goroutine.
+func Serve(addr string) {
+	slog.Info("profiling endpoints listening", "url", "http://"+addr+"/debug/pprof/")
+	server := &http.Server{Addr: addr, Handler: Handler(), ReadHeaderTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		slog.Error("profiling server failed", "error", err)
+	}
+}
+
+// StartCPUProfile writes a CPU profile to path until the returned function
+// is called.
+func StartCPUProfile(path string) (func() error, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	if err := runtimepprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	return func() error {
+		runtimepprof.StopCPUProfile()
+		return file.Close()
+	}, nil
+}
+
+// WriteHeapProfile writes the live heap to path, after a garbage collection
+// so the profile is up to date.
+func WriteHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile: %w", err)
+	}
+	runtime.GC()
+	if err := runtimepprof.WriteHeapProfile(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,57 @@
+package diagnostics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerServesProfiles(t *testing.T) {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/goroutine?debug=1"} {
+		response, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode, path)
+	}
+}
+
+func TestProfilesAreWritten(t *testing.T) {
+	dir := t.TempDir()
+	cpu := filepath.Join(dir, "cpu.out")
+	heap := filepath.Join(dir, "mem.out")
+
+	stop, err := StartCPUProfile(cpu)
+	assert.NoError(t, err)
+	assert.NoError(t, stop())
+	assert.NoError(t, WriteHeapProfile(heap))
+
+	for _, path := range []string{cpu, heap} {
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.NotZero(t, info.Size(), path)
+	}
+
+	_, err = StartCPUProfile(filepath.Join(dir, "missing", "cpu.out"))
+	assert.Error(t, err)
+}
+
+func TestServeReturnsWhenTheListenerFails(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		Serve("localhost:-1")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve kept running without a listener")
+	}
+}
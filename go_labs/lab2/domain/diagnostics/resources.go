@@ -0,0 +1,158 @@
+package diagnostics
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSampleInterval is how often a ResourceMonitor samples memory and
+// goroutines between the start and the end of a run.
+const DefaultSampleInterval = 100 * time.Millisecond
+
+// ResourceReport summarizes what a run cost the process, to help size the
+// memory and CPU of batch jobs. Peaks are sampled, so short spikes between
+// samples can be missed. On Linux PeakRSS is raised to the kernel's
+// high-water mark, which covers the whole process rather than the run.
+type ResourceReport struct {
+	Duration       time.Duration `json:"duration"`
+	PeakRSS        uint64        `json:"peak_rss_bytes"`
+	PeakHeap       uint64        `json:"peak_heap_bytes"`
+	PeakGoroutines int           `json:"peak_goroutines"`
+	TotalAlloc     uint64        `json:"total_alloc_bytes"`
+	Mallocs        uint64        `json:"mallocs"`
+	GCCycles       uint32        `json:"gc_cycles"`
+	GCPauseTotal   time.Duration `json:"gc_pause_total"`
+	GCPauseMax     time.Duration `json:"gc_pause_max"`
+}
+
+func (r ResourceReport) String() string {
+	return fmt.Sprintf("Resources: %s elapsed, peak RSS ~%s, peak heap %s, peak goroutines %d\n"+
+		"           allocated %s in %d objects, %d GC cycles, GC pauses %s total (max %s)",
+		r.Duration.Round(time.Microsecond), formatBytes(r.PeakRSS), formatBytes(r.PeakHeap), r.PeakGoroutines,
+		formatBytes(r.TotalAlloc), r.Mallocs, r.GCCycles, r.GCPauseTotal, r.GCPauseMax)
+}
+
+// ResourceMonitor tracks resource usage from StartResourceMonitor until
+// Stop.
+type ResourceMonitor struct {
+	start time.Time
+	first runtime.MemStats
+	stop  chan struct{}
+	done  chan struct{}
+
+	mu             sync.Mutex
+	peakRSS        uint64
+	peakHeap       uint64
+	peakGoroutines int
+}
+
+// StartResourceMonitor starts sampling every interval, or every
+// DefaultSampleInterval when interval is not positive.
+func StartResourceMonitor(interval time.Duration) *ResourceMonitor {
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
+	m := &ResourceMonitor{start: time.Now(), stop: make(chan struct{}), done: make(chan struct{})}
+	runtime.ReadMemStats(&m.first)
+	m.observe(&m.first)
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				var stats runtime.MemStats
+				runtime.ReadMemStats(&stats)
+				m.observe(&stats)
+			}
+		}
+	}()
+	return m
+}
+
+func (m *ResourceMonitor) observe(stats *runtime.MemStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Memory obtained from the OS and not yet returned approximates RSS.
+	m.peakRSS = max(m.peakRSS, stats.Sys-stats.HeapReleased)
+	m.peakHeap = max(m.peakHeap, stats.HeapInuse)
+	m.peakGoroutines = max(m.peakGoroutines, runtime.NumGoroutine())
+}
+
+// Stop ends sampling and returns the report. It must be called once.
+func (m *ResourceMonitor) Stop() ResourceReport {
+	close(m.stop)
+	<-m.done
+
+	var last runtime.MemStats
+	runtime.ReadMemStats(&last)
+	m.observe(&last)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := ResourceReport{
+		Duration:       time.Since(m.start),
+		PeakRSS:        max(m.peakRSS, highWaterRSS()),
+		PeakHeap:       m.peakHeap,
+		PeakGoroutines: m.peakGoroutines,
+		TotalAlloc:     last.TotalAlloc - m.first.TotalAlloc,
+		Mallocs:        last.Mallocs - m.first.Mallocs,
+		GCCycles:       last.NumGC - m.first.NumGC,
+		GCPauseTotal:   time.Duration(last.PauseTotalNs - m.first.PauseTotalNs),
+	}
+	// PauseNs is a ring of the last 256 pauses, indexed by cycle.
+	for cycle := max(m.first.NumGC, last.NumGC-min(last.NumGC, uint32(len(last.PauseNs)))); cycle < last.NumGC; cycle++ {
+		pause := time.Duration(last.PauseNs[cycle%uint32(len(last.PauseNs))])
+		report.GCPauseMax = max(report.GCPauseMax, pause)
+	}
+	return report
+}
+
+// highWaterRSS reads the process's peak resident set size from
+// /proc/self/status, and returns 0 where that is not available.
+func highWaterRSS() uint64 {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "VmHWM:")
+		if !ok {
+			continue
+		}
+		kilobytes, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kilobytes * 1024
+	}
+	return 0
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,52 @@
+package diagnostics
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sink [][]byte
+
+func TestResourceMonitorReportsTheRun(t *testing.T) {
+	monitor := StartResourceMonitor(time.Millisecond)
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-release
+		}()
+	}
+	for i := 0; i < 16; i++ {
+		sink = append(sink, make([]byte, 1<<20))
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	runtime.GC()
+	sink = nil
+
+	report := monitor.Stop()
+	assert.GreaterOrEqual(t, report.TotalAlloc, uint64(16<<20))
+	assert.GreaterOrEqual(t, report.Mallocs, uint64(16))
+	assert.GreaterOrEqual(t, report.PeakGoroutines, 21)
+	assert.GreaterOrEqual(t, report.PeakHeap, uint64(16<<20))
+	assert.GreaterOrEqual(t, report.PeakRSS, report.PeakHeap)
+	assert.GreaterOrEqual(t, report.GCCycles, uint32(1))
+	assert.GreaterOrEqual(t, report.GCPauseTotal, report.GCPauseMax)
+	assert.Positive(t, report.Duration)
+	assert.Contains(t, report.String(), "GC cycles")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "16.0 MiB", formatBytes(16<<20))
+	assert.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
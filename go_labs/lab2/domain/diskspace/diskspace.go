@@ -0,0 +1,165 @@
+// Package diskspace checks before a run that the target file system has
+// room for its output. It estimates the output from the input size and the
+// expansion ratios of earlier runs, kept in a History.
+package diskspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultRatio is the expansion assumed for a conversion without history.
+// JSON and XML spell out every field name on every record, so converting
+// from CSV often triples the size.
+const DefaultRatio = 3.0
+
+// ErrInsufficientSpace is returned by Check when a run would not fit.
+var ErrInsufficientSpace = errors.New("not enough disk space")
+
+// Ratio is the mean output-to-input size ratio of a step over Runs runs.
+type Ratio struct {
+	Mean float64 `json:"mean"`
+	Runs int     `json:"runs"`
+}
+
+// History keeps the expansion ratios of earlier runs by step key, such as
+// "csv-json". A history loaded from an empty path lives in memory only.
+type History struct {
+	path   string
+	mu     sync.Mutex
+	ratios map[string]Ratio
+}
+
+// NewHistory returns an empty history kept in memory.
+func NewHistory() *History {
+	return &History{ratios: make(map[string]Ratio)}
+}
+
+// LoadHistory reads the history file at path; a missing file is an empty
+// history.
+func LoadHistory(path string) (*History, error) {
+	history := NewHistory()
+	history.path = path
+	if path == "" {
+		return history, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	if err := json.Unmarshal(data, &history.ratios); err != nil {
+		return nil, fmt.Errorf("failed to parse history file %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// Save writes the history back to the file it was loaded from through a
+// temporary file, like incremental state. In-memory histories are not
+// saved.
+func (h *History) Save() error {
+	if h.path == "" {
+		return nil
+	}
+	h.mu.Lock()
+	data, err := json.MarshalIndent(h.ratios, "", "  ")
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return os.Rename(temp.Name(), h.path)
+}
+
+// Ratio returns the mean expansion recorded for key, or fallback when no
+// run of it was recorded.
+func (h *History) Ratio(key string, fallback float64) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ratio, ok := h.ratios[key]; ok && ratio.Runs > 0 {
+		return ratio.Mean
+	}
+	return fallback
+}
+
+// Record adds a run of key that turned input bytes into output bytes.
+// Empty inputs say nothing about expansion and are ignored.
+func (h *History) Record(key string, input, output int) {
+	if input <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ratio := h.ratios[key]
+	ratio.Runs++
+	ratio.Mean += (float64(output)/float64(input) - ratio.Mean) / float64(ratio.Runs)
+	h.ratios[key] = ratio
+}
+
+// Check fails with ErrInsufficientSpace when the file system holding dir
+// has fewer than needed bytes free. Directories that do not exist yet are
+// measured through their nearest existing parent.
+func Check(dir string, needed int64) error {
+	free, err := Free(dir)
+	if err != nil {
+		return err
+	}
+	if needed > 0 && uint64(needed) > free {
+		return fmt.Errorf("%w in %s: the run needs about %s, %s is free", ErrInsufficientSpace, dir, formatBytes(uint64(needed)), formatBytes(free))
+	}
+	return nil
+}
+
+// Free returns the bytes available to unprivileged users on the file
+// system holding path or, when path does not exist, its nearest existing
+// parent. It fails with errors.ErrUnsupported where free space cannot be
+// measured.
+func Free(path string) (uint64, error) {
+	for {
+		free, err := freeSpace(path)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return free, err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return 0, err
+		}
+		path = parent
+	}
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,40 @@
+package diskspace
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryAveragesRatios(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	history, err := LoadHistory(path)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRatio, history.Ratio("csv-json", DefaultRatio))
+
+	history.Record("csv-json", 100, 200)
+	history.Record("csv-json", 100, 400)
+	history.Record("csv-json", 0, 50)
+	assert.Equal(t, 3.0, history.Ratio("csv-json", 1))
+	require.NoError(t, history.Save())
+
+	loaded, err := LoadHistory(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, loaded.Ratio("csv-json", 1))
+	assert.Equal(t, 1.0, loaded.Ratio("json-yaml", 1))
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	free, err := Free(filepath.Join(dir, "not", "created"))
+	require.NoError(t, err)
+	assert.Positive(t, free)
+
+	assert.NoError(t, Check(dir, 1))
+	err = Check(dir, math.MaxInt64)
+	assert.ErrorIs(t, err, ErrInsufficientSpace)
+	assert.ErrorContains(t, err, "the run needs about 8388608.0 TiB")
+}
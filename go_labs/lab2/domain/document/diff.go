@@ -0,0 +1,144 @@
+// Package document is the format-neutral model between parsing and rendering.
+// A Parser turns one format into a Document and a Renderer turns a Document
+// into another format, so N parsers and M renderers cover N×M conversions.
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is one difference between two documents. Old is unset for added
+// values and New for removed ones.
+type Change struct {
+	Path Path
+	Kind ChangeKind
+	Old  interface{}
+	New  interface{}
+}
+
+func (c Change) String() string {
+	path := c.Path.String()
+	if path == "" {
+		path = "(root)"
+	}
+
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", path, display(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s: %s", path, display(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, display(c.Old), display(c.New))
+	}
+}
+
+// DiffOptions tune how values are compared. Unless Strict is set, scalars
+// are equal when they print the same, so "34" read from CSV matches 34 read
+// from JSON, and an empty CSV cell matches null. That lets a converted file
+// be checked against its source.
+type DiffOptions struct {
+	Strict bool
+}
+
+// Diff lists the structural differences from a to b: map keys in sorted
+// order, array elements by position.
+func Diff(a, b *Document, options DiffOptions) []Change {
+	var changes []Change
+	diff(nil, a.Root, b.Root, options, &changes)
+	return changes
+}
+
+func diff(path Path, a, b interface{}, options DiffOptions, changes *[]Change) {
+	switch left := a.(type) {
+	case map[string]interface{}:
+		right, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(left)+len(right))
+		for key := range left {
+			keys = append(keys, key)
+		}
+		for key := range right {
+			if _, ok := left[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := append(path[:len(path):len(path)], key)
+			leftValue, inLeft := left[key]
+			rightValue, inRight := right[key]
+			switch {
+			case !inRight:
+				*changes = append(*changes, Change{Path: child, Kind: Removed, Old: leftValue})
+			case !inLeft:
+				*changes = append(*changes, Change{Path: child, Kind: Added, New: rightValue})
+			default:
+				diff(child, leftValue, rightValue, options, changes)
+			}
+		}
+		return
+	case []interface{}:
+		right, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+
+		for i := 0; i < max(len(left), len(right)); i++ {
+			child := append(path[:len(path):len(path)], strconv.Itoa(i))
+			switch {
+			case i >= len(right):
+				*changes = append(*changes, Change{Path: child, Kind: Removed, Old: left[i]})
+			case i >= len(left):
+				*changes = append(*changes, Change{Path: child, Kind: Added, New: right[i]})
+			default:
+				diff(child, left[i], right[i], options, changes)
+			}
+		}
+		return
+	default:
+		if !isContainer(b) && scalarsEqual(a, b, options) {
+			return
+		}
+	}
+
+	*changes = append(*changes, Change{Path: path, Kind: Changed, Old: a, New: b})
+}
+
+func isContainer(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func scalarsEqual(a, b interface{}, options DiffOptions) bool {
+	if options.Strict {
+		return a == b
+	}
+	return cell(a) == cell(b)
+}
+
+func display(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
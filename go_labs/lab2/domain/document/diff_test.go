@@ -0,0 +1,40 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestDiffAcrossFormats(t *testing.T) {
+	source, err := Parse(strings.NewReader("name,age,email\nann,34,\nbob,41,bob@example.com\n"), models.FormatCSV, models.ConversionOptions{})
+	require.NoError(t, err)
+	converted, err := Parse(strings.NewReader("- name: ann\n  age: 34\n  email: null\n- name: bob\n  age: 42\n"), models.FormatYAML, models.ConversionOptions{})
+	require.NoError(t, err)
+
+	var lines []string
+	for _, change := range Diff(source, converted, DiffOptions{}) {
+		lines = append(lines, change.String())
+	}
+	assert.Equal(t, []string{
+		`~ 1.age: "41" -> 42`,
+		`- 1.email: "bob@example.com"`,
+	}, lines)
+
+	strict := Diff(source, converted, DiffOptions{Strict: true})
+	assert.Len(t, strict, 4)
+}
+
+func TestDiffReportsTypeChanges(t *testing.T) {
+	changes := Diff(&Document{Root: map[string]interface{}{"tags": []interface{}{"a"}}},
+		&Document{Root: map[string]interface{}{"tags": "a", "extra": true}}, DiffOptions{})
+
+	assert.Equal(t, []Change{
+		{Path: Path{"extra"}, Kind: Added, New: true},
+		{Path: Path{"tags"}, Kind: Changed, Old: []interface{}{"a"}, New: "a"},
+	}, changes)
+}
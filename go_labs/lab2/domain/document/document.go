@@ -0,0 +1,108 @@
+// Package document is the format-neutral model between parsing and rendering.
+// A Parser turns one format into a Document and a Renderer turns a Document
+// into another format, so N parsers and M renderers cover N×M conversions.
+package document
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Document holds a tree of map[string]interface{} and []interface{} with
+// string, bool and nil leaves and numbers read losslessly: int64 and uint64
+// for integers and values.Decimal otherwise, plus values.Timestamp for
+// YAML timestamps. With FloatNumbers and without LosslessTypes, numbers are
+// float64 as encoding/json produces them and timestamps time.Time.
+type Document struct {
+	Root interface{}
+}
+
+type Parser interface {
+	Parse(input io.Reader, options models.ConversionOptions) (*Document, error)
+}
+
+type Renderer interface {
+	Render(doc *Document, options models.ConversionOptions) ([]byte, error)
+}
+
+var (
+	parsers    = make(map[models.FileFormat]Parser)
+	renderers  = make(map[models.FileFormat]Renderer)
+	registryMu sync.RWMutex
+)
+
+func RegisterParser(format models.FileFormat, parser Parser) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	parsers[format] = parser
+}
+
+func RegisterRenderer(format models.FileFormat, renderer Renderer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	renderers[format] = renderer
+}
+
+func ParserFor(format models.FileFormat) (Parser, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	parser, ok := parsers[format]
+	return parser, ok
+}
+
+func RendererFor(format models.FileFormat) (Renderer, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	renderer, ok := renderers[format]
+	return renderer, ok
+}
+
+// Pairs lists every parser/renderer combination as "from-to" keys.
+func Pairs() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var pairs []string
+	for from := range parsers {
+		for to := range renderers {
+			if from != to {
+				pairs = append(pairs, string(from)+"-"+string(to))
+			}
+		}
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// ParserFormats lists the formats with a registered parser, in sorted order.
+func ParserFormats() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	formats := make([]string, 0, len(parsers))
+	for format := range parsers {
+		formats = append(formats, string(format))
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func Parse(input io.Reader, format models.FileFormat, options models.ConversionOptions) (*Document, error) {
+	parser, ok := ParserFor(format)
+	if !ok {
+		return nil, fmt.Errorf("no parser for format: %s", format)
+	}
+	return parser.Parse(input, options)
+}
+
+func Render(doc *Document, format models.FileFormat, options models.ConversionOptions) ([]byte, error) {
+	renderer, ok := RendererFor(format)
+	if !ok {
+		return nil, fmt.Errorf("no renderer for format: %s", format)
+	}
+	return renderer.Render(doc, options)
+}
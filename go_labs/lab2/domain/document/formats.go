@@ -0,0 +1,313 @@
+// Package document is the format-neutral model between parsing and rendering.
+// A Parser turns one format into a Document and a Renderer turns a Document
+// into another format, so N parsers and M renderers cover N×M conversions.
+package document
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/clbanning/mxj/v2"
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/buffers"
+	"tmps-go-labs/lab2/domain/csvparse"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/values"
+	"tmps-go-labs/lab2/domain/xmlmap"
+	"tmps-go-labs/lab2/domain/yamlalias"
+)
+
+func init() {
+	RegisterParser(models.FormatJSON, JSON{})
+	RegisterRenderer(models.FormatJSON, JSON{})
+	RegisterParser(models.FormatYAML, YAML{})
+	RegisterRenderer(models.FormatYAML, YAML{})
+	RegisterParser(models.FormatXML, XML{})
+	RegisterRenderer(models.FormatXML, XML{})
+	RegisterParser(models.FormatCSV, CSV{})
+	RegisterRenderer(models.FormatCSV, CSV{})
+	RegisterParser(models.FormatNDJSON, NDJSON{})
+	RegisterRenderer(models.FormatNDJSON, NDJSON{})
+	RegisterParser(models.FormatXLSX, XLSX{})
+}
+
+type JSON struct{}
+
+func (JSON) Parse(input io.Reader, options models.ConversionOptions) (*Document, error) {
+	root, err := DecodeJSON(input, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return &Document{Root: root}, nil
+}
+
+func (JSON) Render(doc *Document, options models.ConversionOptions) ([]byte, error) {
+	return MarshalJSON(doc.Root, options)
+}
+
+type YAML struct{}
+
+func (YAML) Parse(input io.Reader, options models.ConversionOptions) (*Document, error) {
+	decode := yamlalias.Decode
+	if options.Lossless() {
+		decode = yamlalias.DecodeLossless
+	}
+	root, err := decode(input, options.YAML)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return &Document{Root: normalize(root)}, nil
+}
+
+func (YAML) Render(doc *Document, options models.ConversionOptions) ([]byte, error) {
+	return MarshalYAML(doc.Root, options)
+}
+
+// XML unwraps the document element and, when it only holds repeated root
+// elements, the root elements too, mirroring how XML renders lists.
+type XML struct{}
+
+func (XML) Parse(input io.Reader, options models.ConversionOptions) (*Document, error) {
+	data, err := buffers.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XML: %w", err)
+	}
+	defer buffers.Put(data)
+	m, err := xmlmap.Parse(data.Bytes(), options.XML)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	var root interface{} = m
+	if len(m) == 1 {
+		for _, child := range m {
+			root = child
+		}
+	}
+	if m, ok := root.(map[string]interface{}); ok && len(m) == 1 {
+		if list, ok := m[options.RootElement()].([]interface{}); ok {
+			root = list
+		}
+	}
+	return &Document{Root: root}, nil
+}
+
+func (XML) Render(doc *Document, options models.ConversionOptions) ([]byte, error) {
+	return MarshalXML(doc.Root, options)
+}
+
+// CSV reads the header row as field names and interns repeated values per
+// column. Rendering needs a list of flat records; nested values are written
+// as JSON. Columns are the Headers option, or every field sorted by
+// KeyOrder.
+type CSV struct{}
+
+func (CSV) Parse(input io.Reader, options models.ConversionOptions) (*Document, error) {
+	table, err := csvparse.ReadAll(input, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	rows := make([]interface{}, 0, len(table))
+	interner := records.NewInterner(records.DefaultInternLimit)
+	if len(table) > 0 {
+		headers := table[0]
+		for _, record := range table[1:] {
+			row := make(map[string]interface{}, len(headers))
+			for i, value := range record {
+				if i < len(headers) {
+					row[headers[i]] = interner.Intern(headers[i], value)
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return &Document{Root: rows}, nil
+}
+
+func (CSV) Render(doc *Document, options models.ConversionOptions) ([]byte, error) {
+	rows := records.Find(doc.Root)
+	columns := options.Headers
+	if len(columns) == 0 {
+		columns = records.Columns(rows)
+		if !options.KeyOrder.IsZero() {
+			less := KeyLess(options.KeyOrder)
+			sort.SliceStable(columns, func(i, j int) bool { return less(columns[i], columns[j]) })
+		}
+	}
+
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	writer.Comma = options.Delimiter()
+	writer.Write(columns)
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = cell(row[column])
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return out.Bytes(), writer.Error()
+}
+
+// NDJSON holds one JSON object per line. Rendering writes every record of
+// the document on its own line, with its keys in KeyOrder.
+type NDJSON struct{}
+
+func (NDJSON) Parse(input io.Reader, options models.ConversionOptions) (*Document, error) {
+	iterator, err := records.NewIterator(input, models.FormatNDJSON, options)
+	if err != nil {
+		return nil, err
+	}
+	return collect(iterator)
+}
+
+func (NDJSON) Render(doc *Document, options models.ConversionOptions) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	for _, row := range records.Find(doc.Root) {
+		if err := encoder.Encode(Ordered(map[string]interface{}(row), options.KeyOrder)); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// XLSX reads the first worksheet of an Excel workbook, using its first row
+// as field names. There is no XLSX renderer.
+type XLSX struct{}
+
+func (XLSX) Parse(input io.Reader, options models.ConversionOptions) (*Document, error) {
+	iterator, err := records.NewIterator(input, models.FormatXLSX, options)
+	if err != nil {
+		return nil, err
+	}
+	return collect(iterator)
+}
+
+func collect(iterator records.RecordIterator) (*Document, error) {
+	rows, err := records.Collect(iterator)
+	if err != nil {
+		return nil, err
+	}
+	records.NewInterner(records.DefaultInternLimit).InternRecords(rows)
+
+	root := make([]interface{}, len(rows))
+	for i, row := range rows {
+		root[i] = map[string]interface{}(row)
+	}
+	return &Document{Root: root}, nil
+}
+
+func cell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		if text, ok := values.Text(v); ok {
+			return text
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+// normalize converts YAML's map[interface{}]interface{} into the string-keyed
+// maps the rest of the model uses.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = normalize(child)
+		}
+		return v
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			out[fmt.Sprint(key)] = normalize(child)
+		}
+		return out
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normalize(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// DecodeJSON reads one JSON value, keeping integers and decimals exact
+// when options.Lossless() is set. Anything but whitespace after the value
+// is an error.
+func DecodeJSON(input io.Reader, options models.ConversionOptions) (interface{}, error) {
+	decoder := json.NewDecoder(input)
+	if options.Lossless() {
+		decoder.UseNumber()
+	}
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, err
+	}
+	end := decoder.InputOffset()
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON value ending at offset %d", end)
+	}
+	return values.Numbers(root), nil
+}
+
+func MarshalJSON(v interface{}, options models.ConversionOptions) ([]byte, error) {
+	v = Ordered(v, options.KeyOrder)
+	indent, _ := options.Indentation()
+	if indent == "" {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", indent)
+}
+
+func MarshalXML(v interface{}, options models.ConversionOptions) ([]byte, error) {
+	mv := mxj.Map{options.RootElement(): values.Textual(v)}
+	indent, _ := options.Indentation()
+	if indent == "" {
+		return mv.Xml()
+	}
+	return mv.XmlIndent("", indent)
+}
+
+func MarshalYAML(v interface{}, options models.ConversionOptions) ([]byte, error) {
+	v = Ordered(v, options.KeyOrder)
+	if options.YAML.Anchors {
+		var node yaml.Node
+		if err := node.Encode(v); err != nil {
+			return nil, err
+		}
+		yamlalias.AddAnchors(&node)
+		v = &node
+	}
+	indent, set := options.Indentation()
+	if !set {
+		return yaml.Marshal(v)
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(max(len(indent), 2))
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
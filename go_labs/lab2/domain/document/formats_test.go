@@ -0,0 +1,60 @@
+package document
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func convert(t *testing.T, data string, from, to models.FileFormat, options models.ConversionOptions) string {
+	t.Helper()
+	doc, err := Parse(bytes.NewReader([]byte(data)), from, options)
+	require.NoError(t, err)
+	out, err := Render(doc, to, options)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestLosslessTypesSurviveConversions(t *testing.T) {
+	input := `[{"id": 9007199254740993, "price": 19.990, "day": "2024-01-02"}]`
+	lossless := models.NewOptions(models.WithIndentWidth(0))
+	plain := models.NewOptions(models.WithIndentWidth(0), models.WithFloatNumbers())
+
+	assert.Equal(t, `[{"day":"2024-01-02","id":9007199254740992,"price":19.99}]`, convert(t, input, models.FormatJSON, models.FormatJSON, plain))
+	assert.Equal(t, `[{"day":"2024-01-02","id":9007199254740993,"price":19.990}]`, convert(t, input, models.FormatJSON, models.FormatJSON, lossless))
+
+	yaml := convert(t, input, models.FormatJSON, models.FormatYAML, lossless)
+	assert.Equal(t, "- day: \"2024-01-02\"\n  id: 9007199254740993\n  price: 19.990\n", yaml)
+	assert.Equal(t, `[{"day":"2024-01-02","id":9007199254740993,"price":19.990}]`, convert(t, yaml, models.FormatYAML, models.FormatJSON, lossless))
+
+	assert.Equal(t, "day,id,price\n2024-01-02,9007199254740993,19.990\n", convert(t, input, models.FormatJSON, models.FormatCSV, lossless))
+	assert.Equal(t, "id,price\n1e+21,19.99\n", convert(t, `[{"id": 1e21, "price": 19.99}]`, models.FormatJSON, models.FormatCSV, plain))
+}
+
+func TestDecodeJSONRejectsTrailingData(t *testing.T) {
+	for _, options := range []models.ConversionOptions{models.NewOptions(), models.NewOptions(models.WithFloatNumbers())} {
+		root, err := DecodeJSON(strings.NewReader("{\"a\":1}\n\t "), options)
+		require.NoError(t, err)
+		assert.Len(t, root, 1)
+
+		_, err = DecodeJSON(strings.NewReader(`{"a":1} garbage`), options)
+		assert.EqualError(t, err, "unexpected data after the JSON value ending at offset 7")
+		_, err = DecodeJSON(strings.NewReader(`{"a":1}{"b":2}`), options)
+		assert.EqualError(t, err, "unexpected data after the JSON value ending at offset 7")
+	}
+}
+
+func TestTimestampsEncodePerFormat(t *testing.T) {
+	input := "- at: 2024-01-02T03:04:05+02:00\n  day: 2024-01-02\n"
+	lossless := models.NewOptions(models.WithIndentWidth(0))
+
+	assert.Equal(t, `[{"at":"2024-01-02T03:04:05+02:00","day":"2024-01-02"}]`, convert(t, input, models.FormatYAML, models.FormatJSON, lossless))
+	assert.Equal(t, input, convert(t, input, models.FormatYAML, models.FormatYAML, models.ConversionOptions{}))
+	assert.Equal(t, "at,day\n2024-01-02T03:04:05+02:00,2024-01-02T00:00:00Z\n", convert(t, input, models.FormatYAML, models.FormatCSV, models.NewOptions(models.WithFloatNumbers())), "time.Time is written in RFC 3339")
+	assert.Contains(t, convert(t, input, models.FormatYAML, models.FormatXML, lossless), "<day>2024-01-02</day>")
+}
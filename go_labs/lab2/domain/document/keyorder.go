@@ -0,0 +1,154 @@
+// Package document is the format-neutral model between parsing and rendering.
+// A Parser turns one format into a Document and a Renderer turns a Document
+// into another format, so N parsers and M renderers cover N×M conversions.
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Ordered wraps the objects in v so that JSON and YAML marshal their keys
+// in the given order instead of the lexical order of Go maps. v is
+// returned as it is for the zero KeyOrder.
+func Ordered(v interface{}, order models.KeyOrder) interface{} {
+	if order.IsZero() {
+		return v
+	}
+	return ordered(v, KeyLess(order))
+}
+
+func ordered(v interface{}, less func(a, b string) bool) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		object := orderedMap{keys: make([]string, 0, len(typed)), values: make(map[string]interface{}, len(typed))}
+		for key, value := range typed {
+			object.keys = append(object.keys, key)
+			object.values[key] = ordered(value, less)
+		}
+		sort.Slice(object.keys, func(i, j int) bool {
+			return less(object.keys[i], object.keys[j])
+		})
+		return object
+	case []interface{}:
+		elements := make([]interface{}, len(typed))
+		for i, element := range typed {
+			elements[i] = ordered(element, less)
+		}
+		return elements
+	}
+	return v
+}
+
+// KeyLess reports whether key a goes before key b in order.
+func KeyLess(order models.KeyOrder) func(a, b string) bool {
+	rank := rankOf(order)
+	natural := order.Collation == models.CollationNatural
+	return func(a, b string) bool {
+		if rankA, rankB := rank(a), rank(b); rankA != rankB {
+			return rankA < rankB
+		}
+		if natural {
+			return naturalLess(a, b)
+		}
+		return a < b
+	}
+}
+
+// rankOf places First keys in their listed order before all others and
+// Last keys in their listed order after them; other keys share one rank.
+func rankOf(order models.KeyOrder) func(string) int {
+	ranks := make(map[string]int, len(order.First)+len(order.Last))
+	for i, key := range order.First {
+		ranks[key] = i - len(order.First)
+	}
+	for i, key := range order.Last {
+		ranks[key] = i + 1
+	}
+	return func(key string) int {
+		return ranks[key]
+	}
+}
+
+// naturalLess compares runs of digits by their numeric value, so "item2"
+// sorts before "item10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		chunkA, restA := nextChunk(a)
+		chunkB, restB := nextChunk(b)
+		if chunkA != chunkB {
+			if isDigit(chunkA[0]) && isDigit(chunkB[0]) {
+				trimmedA, trimmedB := strings.TrimLeft(chunkA, "0"), strings.TrimLeft(chunkB, "0")
+				if len(trimmedA) != len(trimmedB) {
+					return len(trimmedA) < len(trimmedB)
+				}
+				if trimmedA != trimmedB {
+					return trimmedA < trimmedB
+				}
+			}
+			return chunkA < chunkB
+		}
+		a, b = restA, restB
+	}
+	return len(a) < len(b)
+}
+
+func nextChunk(s string) (chunk, rest string) {
+	digits := isDigit(s[0])
+	end := 1
+	for end < len(s) && isDigit(s[end]) == digits {
+		end++
+	}
+	return s[:end], s[end:]
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// orderedMap is an object that marshals its keys in order.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+func (m orderedMap) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, key := range m.keys {
+		value := &yaml.Node{}
+		if err := value.Encode(m.values[key]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+	return node, nil
+}
@@ -0,0 +1,45 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestMarshalWithKeyOrder(t *testing.T) {
+	record := map[string]interface{}{
+		"metadata": map[string]interface{}{"b": 1, "a": 2},
+		"item10":   true,
+		"item2":    false,
+		"id":       7,
+		"tags":     []interface{}{map[string]interface{}{"z": 1, "id": 2}},
+	}
+	options := models.NewOptions(models.WithIndentWidth(0), models.WithKeyOrder(models.KeyOrder{
+		Collation: models.CollationNatural,
+		First:     []string{"id"},
+		Last:      []string{"metadata"},
+	}))
+
+	data, err := MarshalJSON(record, options)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":7,"item2":false,"item10":true,"tags":[{"id":2,"z":1}],"metadata":{"a":2,"b":1}}`, string(data))
+
+	data, err = MarshalYAML(map[string]interface{}{"123": "x", "id": 1, "name": "ann"}, options)
+	require.NoError(t, err)
+	assert.Equal(t, "id: 1\n\"123\": x\nname: ann\n", string(data))
+
+	data, err = MarshalJSON(record, models.NewOptions(models.WithIndentWidth(0)))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":7,"item10":true,"item2":false,"metadata":{"a":2,"b":1},"tags":[{"id":2,"z":1}]}`, string(data), "without options keys keep the encoding/json order")
+}
+
+func TestNaturalLess(t *testing.T) {
+	assert.True(t, naturalLess("item2", "item10"))
+	assert.True(t, naturalLess("a", "a1"))
+	assert.True(t, naturalLess("v1.9", "v1.10"))
+	assert.False(t, naturalLess("item10", "item10"))
+	assert.True(t, naturalLess("x007", "x8"))
+}
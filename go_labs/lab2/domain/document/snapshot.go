@@ -0,0 +1,26 @@
+// Package document is the format-neutral model between parsing and rendering.
+// A Parser turns one format into a Document and a Renderer turns a Document
+// into another format, so N parsers and M renderers cover N×M conversions.
+package document
+
+import (
+	"bytes"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// FromSnapshot parses the data held by a snapshot so it can be inspected or
+// edited before the pipeline resumes from it.
+func FromSnapshot(snapshot *models.Snapshot, options models.ConversionOptions) (*Document, error) {
+	return Parse(bytes.NewReader(snapshot.Data()), snapshot.Format(), options)
+}
+
+// ReplaceSnapshot renders an edited document back into a snapshot for the
+// same step and format, ready to be resumed.
+func ReplaceSnapshot(snapshot *models.Snapshot, doc *Document, options models.ConversionOptions) (*models.Snapshot, error) {
+	data, err := Render(doc, snapshot.Format(), options)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewSnapshot(snapshot.Step(), snapshot.Format(), data), nil
+}
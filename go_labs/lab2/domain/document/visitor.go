@@ -0,0 +1,187 @@
+// Package document is the format-neutral model between parsing and rendering.
+// A Parser turns one format into a Document and a Renderer turns a Document
+// into another format, so N parsers and M renderers cover N×M conversions.
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/values"
+)
+
+// Path locates a value in a document: map keys, and array indexes written
+// in decimal.
+type Path []string
+
+func (p Path) String() string {
+	return strings.Join(p, ".")
+}
+
+// Key returns the last element of the path, or "" at the root.
+func (p Path) Key() string {
+	if len(p) == 0 {
+		return ""
+	}
+	return p[len(p)-1]
+}
+
+// Visitor is called for every value of a document, children before their
+// parent. Each method returns the value that replaces the visited one, so
+// a visitor can transform the document as well as inspect it.
+type Visitor interface {
+	VisitScalar(path Path, value interface{}) (interface{}, error)
+	VisitMap(path Path, value map[string]interface{}) (interface{}, error)
+	VisitArray(path Path, value []interface{}) (interface{}, error)
+}
+
+// BaseVisitor leaves every value unchanged; embed it to implement only the
+// methods a visitor needs.
+type BaseVisitor struct{}
+
+func (BaseVisitor) VisitScalar(_ Path, value interface{}) (interface{}, error) {
+	return value, nil
+}
+
+func (BaseVisitor) VisitMap(_ Path, value map[string]interface{}) (interface{}, error) {
+	return value, nil
+}
+
+func (BaseVisitor) VisitArray(_ Path, value []interface{}) (interface{}, error) {
+	return value, nil
+}
+
+// Walk visits every value of the document and stores the result back into
+// doc.Root. Map keys are visited in sorted order so visitors see a stable
+// sequence.
+func Walk(doc *Document, visitor Visitor) error {
+	root, err := walk(nil, doc.Root, visitor)
+	if err != nil {
+		return err
+	}
+	doc.Root = root
+	return nil
+}
+
+func walk(path Path, value interface{}, visitor Visitor) (interface{}, error) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child, err := walk(append(path[:len(path):len(path)], key), typed[key], visitor)
+			if err != nil {
+				return nil, err
+			}
+			typed[key] = child
+		}
+		return visitor.VisitMap(path, typed)
+	case []interface{}:
+		for i, item := range typed {
+			child, err := walk(append(path[:len(path):len(path)], strconv.Itoa(i)), item, visitor)
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = child
+		}
+		return visitor.VisitArray(path, typed)
+	default:
+		replaced, err := visitor.VisitScalar(path, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return replaced, nil
+	}
+}
+
+// Mask replaces the scalar value of every field whose key is listed with
+// "***", wherever it occurs in the document.
+type Mask struct {
+	BaseVisitor
+	Fields []string
+}
+
+func (m Mask) VisitScalar(path Path, value interface{}) (interface{}, error) {
+	for _, field := range m.Fields {
+		if path.Key() == field && value != nil {
+			return "***", nil
+		}
+	}
+	return value, nil
+}
+
+// CoerceTypes turns strings that hold numbers or booleans, as produced by
+// CSV and XML parsers, into number and bool values. Only numbers written
+// in JSON syntax are coerced, so "007", "NaN" or "+1" stay strings, and
+// they become an int64 or uint64 when they are integers that fit and a
+// values.Decimal otherwise, so long IDs keep every digit.
+type CoerceTypes struct {
+	BaseVisitor
+}
+
+func (CoerceTypes) VisitScalar(_ Path, value interface{}) (interface{}, error) {
+	text, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	if number, ok := values.ParseDecimal(text); ok && string(number) == text {
+		return values.Number(json.Number(text)), nil
+	}
+	if flag, err := strconv.ParseBool(text); err == nil && (text == "true" || text == "false") {
+		return flag, nil
+	}
+	return value, nil
+}
+
+// Stats counts the values of a document by kind and records how deeply it
+// nests.
+type Stats struct {
+	BaseVisitor
+	Maps     int
+	Arrays   int
+	Scalars  map[string]int
+	MaxDepth int
+}
+
+func (s *Stats) VisitScalar(path Path, value interface{}) (interface{}, error) {
+	if s.Scalars == nil {
+		s.Scalars = make(map[string]int)
+	}
+	s.Scalars[scalarKind(value)]++
+	s.MaxDepth = max(s.MaxDepth, len(path))
+	return value, nil
+}
+
+func (s *Stats) VisitMap(path Path, value map[string]interface{}) (interface{}, error) {
+	s.Maps++
+	s.MaxDepth = max(s.MaxDepth, len(path))
+	return value, nil
+}
+
+func (s *Stats) VisitArray(path Path, value []interface{}) (interface{}, error) {
+	s.Arrays++
+	s.MaxDepth = max(s.MaxDepth, len(path))
+	return value, nil
+}
+
+func scalarKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64, int, int64, uint64, values.Decimal:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
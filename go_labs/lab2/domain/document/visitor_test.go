@@ -0,0 +1,87 @@
+package document
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/values"
+)
+
+func people() *Document {
+	return &Document{Root: []interface{}{
+		map[string]interface{}{"name": "ann", "age": "28", "email": "ann@example.com", "active": "true"},
+		map[string]interface{}{"name": "bob", "age": "35", "email": nil, "tags": []interface{}{"x"}},
+	}}
+}
+
+func TestMaskAndCoerce(t *testing.T) {
+	doc := people()
+	assert.NoError(t, Walk(doc, Mask{Fields: []string{"email"}}))
+	assert.NoError(t, Walk(doc, CoerceTypes{}))
+
+	first := doc.Root.([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "***", first["email"])
+	assert.Equal(t, int64(28), first["age"])
+	assert.Equal(t, true, first["active"])
+	assert.Equal(t, "ann", first["name"])
+	assert.Nil(t, doc.Root.([]interface{})[1].(map[string]interface{})["email"])
+}
+
+func TestCoerceKeepsNumbersExact(t *testing.T) {
+	doc := &Document{Root: map[string]interface{}{
+		"zip":   "007",
+		"nan":   "NaN",
+		"inf":   "Inf",
+		"long":  "infinity",
+		"plus":  "+1",
+		"space": " 1",
+		"id":    "123456789012345678",
+		"big":   "18446744073709551615",
+		"huge":  "123456789012345678901234567890",
+		"price": "0.10",
+		"minus": "-4",
+	}}
+	assert.NoError(t, Walk(doc, CoerceTypes{}))
+
+	assert.Equal(t, map[string]interface{}{
+		"zip":   "007",
+		"nan":   "NaN",
+		"inf":   "Inf",
+		"long":  "infinity",
+		"plus":  "+1",
+		"space": " 1",
+		"id":    int64(123456789012345678),
+		"big":   uint64(18446744073709551615),
+		"huge":  values.Decimal("123456789012345678901234567890"),
+		"price": values.Decimal("0.10"),
+		"minus": int64(-4),
+	}, doc.Root)
+}
+
+func TestStats(t *testing.T) {
+	stats := &Stats{}
+	assert.NoError(t, Walk(people(), stats))
+
+	assert.Equal(t, 2, stats.Maps)
+	assert.Equal(t, 2, stats.Arrays)
+	assert.Equal(t, map[string]int{"string": 7, "null": 1}, stats.Scalars)
+	assert.Equal(t, 3, stats.MaxDepth)
+}
+
+type rejectBob struct {
+	BaseVisitor
+}
+
+func (rejectBob) VisitScalar(_ Path, value interface{}) (interface{}, error) {
+	if value == "bob" {
+		return nil, errors.New("no bobs")
+	}
+	return value, nil
+}
+
+func TestWalkReportsErrorPath(t *testing.T) {
+	err := Walk(people(), rejectBob{})
+	assert.EqualError(t, err, "1.name: no bobs")
+}
@@ -0,0 +1,139 @@
+// Package encryption protects pipeline inputs and outputs at rest with
+// AES-256-GCM. Key material is read from an environment variable or a file
+// so that secrets never have to be embedded in pipeline definitions.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const KeySize = 32
+
+// magic prefixes every ciphertext so corrupted or foreign input is rejected
+// early with a clear error instead of an authentication failure.
+var magic = []byte("TMPSENC1")
+
+var ErrNotEncrypted = errors.New("data is not encrypted with a supported format")
+
+// LoadKey reads a 256-bit key from the named environment variable or, if
+// that is empty, from the file. Keys may be hex, base64 or 32 raw bytes;
+// see ParseKey.
+func LoadKey(env, file string) ([]byte, error) {
+	material, err := ReadKeyMaterial(env, file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKey(material)
+}
+
+// ReadKeyMaterial returns the raw contents of the configured key source.
+func ReadKeyMaterial(env, file string) ([]byte, error) {
+	switch {
+	case env != "":
+		value, ok := os.LookupEnv(env)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", env)
+		}
+		return []byte(value), nil
+	case file != "":
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		return content, nil
+	default:
+		return nil, fmt.Errorf("no key source configured")
+	}
+}
+
+// ParseKey decodes key material. Hex and base64 are tried first; material
+// that is neither is used as a raw key only when it is exactly KeySize bytes
+// and not all printable text, since a 32-character string is far more
+// likely a passphrase or a mistyped encoded key than random bytes.
+func ParseKey(material []byte) ([]byte, error) {
+	text := strings.TrimSpace(string(material))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if len(material) == KeySize && !printable(material) {
+		return material, nil
+	}
+	return nil, fmt.Errorf("key must be %d bytes (raw, hex or base64 encoded)", KeySize)
+}
+
+func printable(material []byte) bool {
+	for _, b := range material {
+		if b < 0x20 || b > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(magic)+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, magic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, magic), nil
+}
+
+func Decrypt(key, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrNotEncrypted
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	body := data[len(magic):]
+	if len(body) < gcm.NonceSize() {
+		return nil, ErrNotEncrypted
+	}
+
+	nonce, ciphertext := body[:gcm.NonceSize()], body[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, magic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: wrong key or tampered data")
+	}
+	return plaintext, nil
+}
+
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes", KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
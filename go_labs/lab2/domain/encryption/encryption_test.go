@@ -0,0 +1,88 @@
+package encryption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+
+	ciphertext, err := Encrypt(key, []byte("secret,data"))
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(ciphertext))
+	assert.NotContains(t, string(ciphertext), "secret")
+
+	plaintext, err := Decrypt(key, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret,data", string(plaintext))
+}
+
+func TestDecryptRejectsWrongKeyAndPlaintext(t *testing.T) {
+	ciphertext, _ := Encrypt(bytes.Repeat([]byte{1}, KeySize), []byte("x"))
+
+	_, err := Decrypt(bytes.Repeat([]byte{2}, KeySize), ciphertext)
+	assert.Error(t, err)
+
+	_, err = Decrypt(bytes.Repeat([]byte{1}, KeySize), []byte("plain"))
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+}
+
+func TestLoadKeyFromEnv(t *testing.T) {
+	t.Setenv("TEST_PIPELINE_KEY", "0001020304050607080910111213141516171819202122232425262728293031")
+
+	key, err := LoadKey("TEST_PIPELINE_KEY", "")
+	assert.NoError(t, err)
+	assert.Len(t, key, KeySize)
+
+	_, err = LoadKey("MISSING_PIPELINE_KEY", "")
+	assert.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	raw := bytes.Repeat([]byte{0xfe}, KeySize)
+	for name, material := range map[string]string{
+		"raw":    string(raw),
+		"hex":    strings.Repeat("fe", KeySize) + "\n",
+		"base64": "/v7+/v7+/v7+/v7+/v7+/v7+/v7+/v7+/v7+/v7+/v4=",
+	} {
+		key, err := ParseKey([]byte(material))
+		assert.NoError(t, err, name)
+		assert.Equal(t, raw, key, name)
+	}
+
+	_, err := ParseKey([]byte("correct-horse-battery-staple-123"))
+	assert.ErrorContains(t, err, "key must be 32 bytes")
+}
+
+func TestFieldCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	deterministic, err := NewFieldCipher(key, true)
+	assert.NoError(t, err)
+	randomized, err := NewFieldCipher(key, false)
+	assert.NoError(t, err)
+
+	a, _ := deterministic.Encrypt("email", "ann@example.com")
+	b, _ := deterministic.Encrypt("email", "ann@example.com")
+	other, _ := deterministic.Encrypt("backup_email", "ann@example.com")
+	assert.Equal(t, a, b, "deterministic encryption keeps equal values equal")
+	assert.NotEqual(t, a, other, "ciphertexts depend on the field")
+	assert.True(t, strings.HasPrefix(a, FieldPrefix))
+
+	c, _ := randomized.Encrypt("email", "ann@example.com")
+	d, _ := randomized.Encrypt("email", "ann@example.com")
+	assert.NotEqual(t, c, d)
+
+	for _, ciphertext := range []string{a, c} {
+		plaintext, err := randomized.Decrypt("email", ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, "ann@example.com", plaintext)
+	}
+	_, err = deterministic.Decrypt("backup_email", a)
+	assert.ErrorContains(t, err, "wrong key or tampered value")
+	_, err = deterministic.Decrypt("email", "ann@example.com")
+	assert.ErrorIs(t, err, ErrFieldNotEncrypted)
+}
@@ -0,0 +1,70 @@
+// Package events publishes pipeline lifecycle events to subscribed observers.
+// It lets progress bars, metrics and webhooks follow a pipeline run without
+// the executor knowing about any of them.
+package events
+
+import "sync"
+
+type Observer interface {
+	Notify(event Event)
+}
+
+type ObserverFunc func(event Event)
+
+func (f ObserverFunc) Notify(event Event) {
+	f(event)
+}
+
+// Bus delivers every published event to the current observers, synchronously
+// and in subscription order. A nil *Bus drops events.
+type Bus struct {
+	mu        sync.RWMutex
+	nextID    int
+	observers map[int]Observer
+	order     []int
+}
+
+func NewBus() *Bus {
+	return &Bus{observers: make(map[int]Observer)}
+}
+
+// Subscribe registers the observer and returns a function removing it again.
+func (b *Bus) Subscribe(observer Observer) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.observers[id] = observer
+	b.order = append(b.order, id)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.observers, id)
+		for i, existing := range b.order {
+			if existing == id {
+				b.order = append(b.order[:i], b.order[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	observers := make([]Observer, 0, len(b.order))
+	for _, id := range b.order {
+		observers = append(observers, b.observers[id])
+	}
+	b.mu.RUnlock()
+
+	for _, observer := range observers {
+		observer.Notify(event)
+	}
+}
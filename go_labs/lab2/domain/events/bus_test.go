@@ -0,0 +1,34 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusDeliversInSubscriptionOrderUntilUnsubscribed(t *testing.T) {
+	bus := NewBus()
+	var received []string
+
+	unsubscribeFirst := bus.Subscribe(ObserverFunc(func(event Event) {
+		received = append(received, "first:"+event.Name())
+	}))
+	bus.Subscribe(ObserverFunc(func(event Event) {
+		received = append(received, "second:"+event.Name())
+	}))
+
+	bus.Publish(PipelineStarted{})
+	unsubscribeFirst()
+	bus.Publish(PipelineFinished{})
+
+	assert.Equal(t, []string{
+		"first:pipeline.started",
+		"second:pipeline.started",
+		"second:pipeline.finished",
+	}, received)
+}
+
+func TestNilBusDropsEvents(t *testing.T) {
+	var bus *Bus
+	assert.NotPanics(t, func() { bus.Publish(StepFailed{}) })
+}
@@ -0,0 +1,90 @@
+// Package events publishes pipeline lifecycle events to subscribed observers.
+// It lets progress bars, metrics and webhooks follow a pipeline run without
+// the executor knowing about any of them.
+package events
+
+import (
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+type Event interface {
+	Name() string
+}
+
+type PipelineStarted struct {
+	Pipeline *models.Pipeline
+	Time     time.Time
+}
+
+type StepStarted struct {
+	Pipeline *models.Pipeline
+	Index    int
+	Step     models.ConversionStep
+}
+
+type StepCompleted struct {
+	Pipeline   *models.Pipeline
+	Index      int
+	Step       models.ConversionStep
+	Duration   time.Duration
+	OutputSize int
+}
+
+// StepProgress is published while a step reads its input, every 64 KiB and
+// at its end, with the bytes it has read so far.
+type StepProgress struct {
+	Pipeline  *models.Pipeline
+	Index     int
+	Step      models.ConversionStep
+	BytesRead int64
+}
+
+type StepFailed struct {
+	Pipeline *models.Pipeline
+	Index    int
+	Step     models.ConversionStep
+	Err      error
+}
+
+// StepTimedOut is published every time a step runs past its timeout,
+// before its policy skips, retries or fails it. Attempt counts from 1.
+type StepTimedOut struct {
+	Pipeline *models.Pipeline
+	Index    int
+	Step     models.ConversionStep
+	After    time.Duration
+	Attempt  int
+	Policy   models.TimeoutPolicy
+}
+
+// RuleViolated is published for every record failing a rule of a quality
+// step, after the step completes.
+type RuleViolated struct {
+	Pipeline  *models.Pipeline
+	Index     int
+	Violation models.QualityViolation
+}
+
+// QualityChecked is published after a quality step with its report.
+type QualityChecked struct {
+	Pipeline *models.Pipeline
+	Index    int
+	Report   models.QualityReport
+}
+
+type PipelineFinished struct {
+	Pipeline *models.Pipeline
+	Result   *models.PipelineResult
+}
+
+func (PipelineStarted) Name() string  { return "pipeline.started" }
+func (StepStarted) Name() string      { return "step.started" }
+func (StepCompleted) Name() string    { return "step.completed" }
+func (StepProgress) Name() string     { return "step.progress" }
+func (StepFailed) Name() string       { return "step.failed" }
+func (StepTimedOut) Name() string     { return "step.timed_out" }
+func (RuleViolated) Name() string     { return "quality.violated" }
+func (QualityChecked) Name() string   { return "quality.checked" }
+func (PipelineFinished) Name() string { return "pipeline.finished" }
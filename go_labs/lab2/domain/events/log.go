@@ -0,0 +1,43 @@
+// Package events publishes pipeline lifecycle events to subscribed observers.
+// It lets progress bars, metrics and webhooks follow a pipeline run without
+// the executor knowing about any of them.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Log returns an observer logging events to logger: runs and steps at
+// debug level, with their formats, durations and sizes, and failed or timed
+// out steps and quality steps finding violations as warnings.
+func Log(logger *slog.Logger) Observer {
+	return ObserverFunc(func(event Event) {
+		switch e := event.(type) {
+		case PipelineStarted:
+			logger.Debug("pipeline started", "input", e.Pipeline.InputPath, "output", e.Pipeline.OutputPath, "steps", len(e.Pipeline.Steps))
+		case StepStarted:
+			logger.Debug("step started", "step", e.Index+1, "from", e.Step.From, "to", e.Step.To)
+		case StepCompleted:
+			logger.Debug("step completed", "step", e.Index+1, "duration", e.Duration, "output_size", e.OutputSize)
+		case StepFailed:
+			logger.Warn("step failed", "step", e.Index+1, "from", e.Step.From, "to", e.Step.To, "error", e.Err)
+		case StepTimedOut:
+			logger.Warn("step timed out", "step", e.Index+1, "after", e.After, "attempt", e.Attempt, "policy", e.Policy)
+		case RuleViolated:
+			logger.Debug("quality rule violated", "step", e.Index+1, "record", e.Violation.Record,
+				"rule", e.Violation.Rule, "action", e.Violation.Action, "message", e.Violation.Message)
+		case QualityChecked:
+			level := slog.LevelDebug
+			if len(e.Report.Violations) > 0 {
+				level = slog.LevelWarn
+			}
+			logger.Log(context.Background(), level, "quality checked", "step", e.Index+1, "records", e.Report.Checked,
+				"dropped", e.Report.Dropped, "violations", len(e.Report.Violations))
+		case PipelineFinished:
+			logger.Debug("pipeline finished", "input", e.Pipeline.InputPath, "success", e.Result.Success,
+				"skipped", e.Result.Skipped, "duration", time.Duration(e.Result.Duration))
+		}
+	})
+}
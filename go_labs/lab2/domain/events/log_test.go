@@ -0,0 +1,38 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestLogObserver(t *testing.T) {
+	var buf bytes.Buffer
+	bus := NewBus()
+	bus.Subscribe(Log(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))))
+
+	step := models.ConversionStep{From: models.FormatCSV, To: models.FormatJSON}
+	bus.Publish(StepStarted{Index: 0, Step: step})
+	bus.Publish(StepFailed{Index: 0, Step: step, Err: errors.New("bad quote")})
+	assert.Equal(t, "level=WARN msg=\"step failed\" step=1 from=csv to=json error=\"bad quote\"\n", buf.String())
+
+	buf.Reset()
+	violation := models.QualityViolation{Record: 2, Rule: "required id", Message: "id is missing", Action: "drop"}
+	bus.Publish(QualityChecked{Index: 1, Report: models.QualityReport{Checked: 3}})
+	bus.Publish(RuleViolated{Index: 1, Violation: violation})
+	bus.Publish(QualityChecked{Index: 1, Report: models.QualityReport{Checked: 3, Dropped: 1, Violations: []models.QualityViolation{violation}}})
+	assert.Equal(t, "level=WARN msg=\"quality checked\" step=2 records=3 dropped=1 violations=1\n", buf.String())
+}
@@ -0,0 +1,294 @@
+// Package expr interprets the small expression language used by filter and
+// map steps, such as `age > 30 && country == "MD"` or `upper(name)`. Source
+// is parsed into a tree of nodes that each know how to evaluate themselves.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/values"
+)
+
+type literal struct {
+	value interface{}
+}
+
+func (l *literal) Eval(map[string]interface{}) (interface{}, error) {
+	return l.value, nil
+}
+
+// field looks a name up in the record. Missing fields evaluate to null, so
+// records with optional columns can still be filtered.
+type field struct {
+	name string
+}
+
+func (f *field) Eval(env map[string]interface{}) (interface{}, error) {
+	if value, ok := env[f.name]; ok {
+		return value, nil
+	}
+
+	var current interface{} = env
+	for _, part := range strings.Split(f.name, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		current = object[part]
+	}
+	return current, nil
+}
+
+type unary struct {
+	operator string
+	operand  Expr
+	pos      int
+}
+
+func (u *unary) Eval(env map[string]interface{}) (interface{}, error) {
+	value, err := u.operand.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.operator == "!" {
+		return !Truthy(value), nil
+	}
+	number, ok := toNumber(value)
+	if !ok {
+		return nil, errorAt(u.pos, "cannot negate %s", describe(value))
+	}
+	return -number, nil
+}
+
+type binary struct {
+	operator    string
+	left, right Expr
+	pos         int
+}
+
+func (b *binary) Eval(env map[string]interface{}) (interface{}, error) {
+	left, err := b.left.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.operator {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+		right, err := b.right.Eval(env)
+		return Truthy(right), err
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+		right, err := b.right.Eval(env)
+		return Truthy(right), err
+	}
+
+	right, err := b.right.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.operator {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return b.compare(left, right)
+	case "+":
+		if l, r, ok := numbers(left, right); ok {
+			return l + r, nil
+		}
+		return toString(left) + toString(right), nil
+	default:
+		return b.arithmetic(left, right)
+	}
+}
+
+func (b *binary) compare(left, right interface{}) (interface{}, error) {
+	var order int
+	if l, r, ok := numbers(left, right); ok {
+		order = compareFloats(l, r)
+	} else if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		if !ok {
+			return nil, errorAt(b.pos, "cannot compare %s with %s", describe(left), describe(right))
+		}
+		order = strings.Compare(l, r)
+	} else {
+		return nil, errorAt(b.pos, "cannot compare %s with %s", describe(left), describe(right))
+	}
+
+	switch b.operator {
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	default:
+		return order >= 0, nil
+	}
+}
+
+func (b *binary) arithmetic(left, right interface{}) (interface{}, error) {
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, errorAt(b.pos, "%q needs numbers, got %s and %s", b.operator, describe(left), describe(right))
+	}
+
+	switch b.operator {
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, errorAt(b.pos, "division by zero")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, errorAt(b.pos, "division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+type call struct {
+	name     string
+	function function
+	args     []Expr
+	pos      int
+}
+
+func (c *call) Eval(env map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(c.args))
+	for i, arg := range c.args {
+		value, err := arg.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	value, err := c.function.fn(args)
+	if err != nil {
+		return nil, errorAt(c.pos, "%s: %v", c.name, err)
+	}
+	return value, nil
+}
+
+// Truthy reports whether a value counts as true in a filter: false, null,
+// zero of any number type and the empty string do not.
+func Truthy(value interface{}) bool {
+	switch typed := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return typed
+	case string:
+		return typed != ""
+	case values.Decimal:
+		// Compared as written, so a tiny decimal that rounds to a zero
+		// float64 still counts as true.
+		mantissa, _, _ := strings.Cut(strings.ToLower(string(typed)), "e")
+		return strings.ContainsAny(mantissa, "123456789")
+	}
+	if number, ok := values.Float(value); ok {
+		return number != 0
+	}
+	return true
+}
+
+// toNumber accepts numbers and strings holding numbers, since CSV and XML
+// fields arrive as strings.
+func toNumber(value interface{}) (float64, bool) {
+	if number, ok := values.Float(value); ok {
+		return number, true
+	}
+	switch typed := value.(type) {
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// numbers converts both operands when at least one is already a number and
+// the other holds one, so "28" > 30 compares numerically but "a" + "b"
+// concatenates.
+func numbers(left, right interface{}) (float64, float64, bool) {
+	_, leftString := left.(string)
+	_, rightString := right.(string)
+	if leftString && rightString {
+		return 0, 0, false
+	}
+
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	return l, r, lok && rok
+}
+
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if l, r, ok := numbers(left, right); ok {
+		return l == r
+	}
+	if l, ok := left.(bool); ok {
+		r, ok := right.(bool)
+		return ok && l == r
+	}
+	return toString(left) == toString(right)
+}
+
+func compareFloats(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toString(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", value)
+	case float64, int, int64, uint64, values.Decimal:
+		return fmt.Sprintf("number %s", toString(value))
+	case bool:
+		return fmt.Sprintf("bool %v", value)
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
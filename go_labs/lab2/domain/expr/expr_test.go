@@ -0,0 +1,65 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/values"
+)
+
+func eval(t *testing.T, source string, env map[string]interface{}) interface{} {
+	expression, err := Parse(source)
+	assert.NoError(t, err)
+	value, err := expression.Eval(env)
+	assert.NoError(t, err)
+	return value
+}
+
+func TestEvaluate(t *testing.T) {
+	record := map[string]interface{}{
+		"name":    "ann",
+		"age":     "28",
+		"country": "MD",
+		"address": map[string]interface{}{"city": "Chisinau"},
+	}
+
+	assert.Equal(t, false, eval(t, `age > 30 && country == "MD"`, record))
+	assert.Equal(t, true, eval(t, `age >= 28 || missing`, record))
+	assert.Equal(t, "ANN", eval(t, `upper(name)`, record))
+	assert.Equal(t, "ann (28)", eval(t, `name + " (" + age + ")"`, record))
+	assert.Equal(t, 29.0, eval(t, `age + 1`, record))
+	assert.Equal(t, true, eval(t, `address.city == 'Chisinau' && !(len(name) != 3)`, record))
+	assert.Equal(t, 7.0, eval(t, `1 + 2 * 3`, record))
+	assert.Equal(t, "n/a", eval(t, `coalesce(phone, "n/a")`, record))
+}
+
+func TestZeroOfEveryNumberTypeIsFalsy(t *testing.T) {
+	for _, zero := range []interface{}{0.0, int64(0), uint64(0), values.Decimal("0"), values.Decimal("-0.00e5")} {
+		assert.False(t, Truthy(zero), "%#v", zero)
+		assert.Equal(t, true, eval(t, `!count`, map[string]interface{}{"count": zero}), "%#v", zero)
+	}
+	for _, nonzero := range []interface{}{1.5, int64(-1), uint64(7), values.Decimal("0.001"), values.Decimal("1e-400")} {
+		assert.True(t, Truthy(nonzero), "%#v", nonzero)
+	}
+}
+
+func TestErrorsCarryColumns(t *testing.T) {
+	for source, message := range map[string]string{
+		`age > `:           "column 7: unexpected end of expression",
+		`age > 30 &&& x`:   `column 12: unexpected character '&'`,
+		`shout(name)`:      `column 1: unknown function "shout"`,
+		`upper(name, age)`: "column 1: upper takes 1 argument(s), got 2",
+		`name == "ann`:     "column 9: unterminated string",
+		`(age > 30`:        `column 10: expected ")"`,
+		`name # 1`:         `column 6: unexpected character '#'`,
+	} {
+		_, err := Parse(source)
+		assert.EqualError(t, err, message, source)
+	}
+
+	expression, err := Parse(`age / 0`)
+	assert.NoError(t, err)
+	_, err = expression.Eval(map[string]interface{}{"age": "28"})
+	assert.EqualError(t, err, "column 5: division by zero")
+}
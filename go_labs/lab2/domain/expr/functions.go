@@ -0,0 +1,74 @@
+// Package expr interprets the small expression language used by filter and
+// map steps, such as `age > 30 && country == "MD"` or `upper(name)`. Source
+// is parsed into a tree of nodes that each know how to evaluate themselves.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// function is a built-in callable from expressions; arity -1 accepts any
+// number of arguments.
+type function struct {
+	arity int
+	fn    func(args []interface{}) (interface{}, error)
+}
+
+var functions = map[string]function{
+	"upper": {1, func(args []interface{}) (interface{}, error) {
+		return strings.ToUpper(toString(args[0])), nil
+	}},
+	"lower": {1, func(args []interface{}) (interface{}, error) {
+		return strings.ToLower(toString(args[0])), nil
+	}},
+	"trim": {1, func(args []interface{}) (interface{}, error) {
+		return strings.TrimSpace(toString(args[0])), nil
+	}},
+	"len": {1, func(args []interface{}) (interface{}, error) {
+		switch typed := args[0].(type) {
+		case []interface{}:
+			return float64(len(typed)), nil
+		case map[string]interface{}:
+			return float64(len(typed)), nil
+		default:
+			return float64(utf8.RuneCountInString(toString(typed))), nil
+		}
+	}},
+	"contains": {2, func(args []interface{}) (interface{}, error) {
+		return strings.Contains(toString(args[0]), toString(args[1])), nil
+	}},
+	"startsWith": {2, func(args []interface{}) (interface{}, error) {
+		return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+	}},
+	"endsWith": {2, func(args []interface{}) (interface{}, error) {
+		return strings.HasSuffix(toString(args[0]), toString(args[1])), nil
+	}},
+	"number": {1, func(args []interface{}) (interface{}, error) {
+		number, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("%s is not a number", describe(args[0]))
+		}
+		return number, nil
+	}},
+	"string": {1, func(args []interface{}) (interface{}, error) {
+		return toString(args[0]), nil
+	}},
+	"round": {1, func(args []interface{}) (interface{}, error) {
+		number, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("%s is not a number", describe(args[0]))
+		}
+		return math.Round(number), nil
+	}},
+	"coalesce": {-1, func(args []interface{}) (interface{}, error) {
+		for _, arg := range args {
+			if arg != nil && arg != "" {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	}},
+}
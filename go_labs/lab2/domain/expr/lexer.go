@@ -0,0 +1,134 @@
+// Package expr interprets the small expression language used by filter and
+// map steps, such as `age > 30 && country == "MD"` or `upper(name)`. Source
+// is parsed into a tree of nodes that each know how to evaluate themselves.
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value string
+}
+
+// Error reports a problem at a 1-based column of the expression source.
+type Error struct {
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Message)
+}
+
+func errorAt(pos int, format string, args ...interface{}) *Error {
+	return &Error{Column: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%"}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case unicode.IsDigit(r):
+			start := pos
+			for pos < len(runes) && (unicode.IsDigit(runes[pos]) || runes[pos] == '.') {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:pos]), pos: start})
+		case r == '"' || r == '\'':
+			start := pos
+			value, end, err := readString(runes, pos)
+			if err != nil {
+				return nil, err
+			}
+			pos = end
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[start:pos]), pos: start, value: value})
+		case unicode.IsLetter(r) || r == '_':
+			start := pos
+			for pos < len(runes) && (unicode.IsLetter(runes[pos]) || unicode.IsDigit(runes[pos]) || runes[pos] == '_' || runes[pos] == '.') {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:pos]), pos: start})
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			pos++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: pos})
+			pos++
+		default:
+			operator := matchOperator(string(runes[pos:]))
+			if operator == "" {
+				return nil, errorAt(pos, "unexpected character %q", r)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: pos})
+			pos += len(operator)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+func matchOperator(rest string) string {
+	for _, operator := range operators {
+		if strings.HasPrefix(rest, operator) {
+			return operator
+		}
+	}
+	return ""
+}
+
+func readString(runes []rune, start int) (string, int, error) {
+	quote := runes[start]
+	var value strings.Builder
+
+	for pos := start + 1; pos < len(runes); pos++ {
+		switch runes[pos] {
+		case quote:
+			return value.String(), pos + 1, nil
+		case '\\':
+			pos++
+			if pos == len(runes) {
+				break
+			}
+			switch runes[pos] {
+			case 'n':
+				value.WriteRune('\n')
+			case 't':
+				value.WriteRune('\t')
+			default:
+				value.WriteRune(runes[pos])
+			}
+		default:
+			value.WriteRune(runes[pos])
+		}
+	}
+	return "", 0, errorAt(start, "unterminated string")
+}
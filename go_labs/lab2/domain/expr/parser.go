@@ -0,0 +1,185 @@
+// Package expr interprets the small expression language used by filter and
+// map steps, such as `age > 30 && country == "MD"` or `upper(name)`. Source
+// is parsed into a tree of nodes that each know how to evaluate themselves.
+package expr
+
+import "strconv"
+
+// Expr is a parsed expression. Env maps field names to the values of the
+// record being evaluated; dotted names reach into nested maps.
+type Expr interface {
+	Eval(env map[string]interface{}) (interface{}, error)
+}
+
+// Parse compiles source into an expression tree. Grammar, loosest first:
+//
+//	or         = and { "||" and }
+//	and        = equality { "&&" equality }
+//	equality   = comparison { ("==" | "!=") comparison }
+//	comparison = additive { ("<" | "<=" | ">" | ">=") additive }
+//	additive   = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/" | "%") unary }
+//	unary      = ("!" | "-") unary | primary
+//	primary    = number | string | true | false | null | name | name "(" args ")" | "(" or ")"
+func Parse(source string) (Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expression, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, errorAt(next.pos, "unexpected %q", next.text)
+	}
+	return expression, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// precedence lists binary operators from loosest to tightest binding.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseBinary(level int) (Expr, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok.kind != tokenOperator || !contains(precedence[level], tok.text) {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{operator: tok.text, left: left, right: right, pos: tok.pos}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.peek()
+	if tok.kind == tokenOperator && (tok.text == "!" || tok.text == "-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{operator: tok.text, operand: operand, pos: tok.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		number, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, errorAt(tok.pos, "invalid number %q", tok.text)
+		}
+		return &literal{value: number}, nil
+	case tokenString:
+		return &literal{value: tok.value}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		}
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(tok)
+		}
+		return &field{name: tok.text}, nil
+	case tokenLParen:
+		inner, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, errorAt(closing.pos, "expected \")\"")
+		}
+		return inner, nil
+	case tokenEOF:
+		return nil, errorAt(tok.pos, "unexpected end of expression")
+	default:
+		return nil, errorAt(tok.pos, "unexpected %q", tok.text)
+	}
+}
+
+func (p *parser) parseCall(name token) (Expr, error) {
+	function, ok := functions[name.text]
+	if !ok {
+		return nil, errorAt(name.pos, "unknown function %q", name.text)
+	}
+	p.next()
+
+	var args []Expr
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if closing := p.next(); closing.kind != tokenRParen {
+		return nil, errorAt(closing.pos, "expected \")\" to close call to %s", name.text)
+	}
+	if function.arity >= 0 && len(args) != function.arity {
+		return nil, errorAt(name.pos, "%s takes %d argument(s), got %d", name.text, function.arity, len(args))
+	}
+	return &call{name: name.text, function: function, args: args, pos: name.pos}, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
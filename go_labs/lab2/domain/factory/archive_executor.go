@@ -0,0 +1,494 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Every matching entry is held in memory while the pipeline runs, so
+// maxArchiveEntryBytes bounds the uncompressed size of one entry and
+// maxArchiveBytes that of all of them together, whatever the archive
+// claims. A zip bomb fails instead of exhausting memory.
+var (
+	maxArchiveEntryBytes int64 = 256 << 20
+	maxArchiveBytes      int64 = 1 << 30
+)
+
+type archiveEntry struct {
+	name string
+	data []byte
+}
+
+// archiveWriter collects the converted entries. Close finishes the output
+// and Abort gives it up after a failure.
+type archiveWriter interface {
+	Add(name string, data []byte) error
+	Close() error
+	Abort()
+}
+
+func IsArchive(filePath string) bool {
+	return archiveKind(filePath) != ""
+}
+
+func archiveKind(filePath string) string {
+	lower := strings.ToLower(filePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	default:
+		return ""
+	}
+}
+
+// executeArchive runs the pipeline once per matching entry. Outputs go into
+// an archive when OutputPath has an archive extension, otherwise into the
+// OutputPath directory.
+func (e *PipelineExecutor) executeArchive(ctx context.Context, pipeline *models.Pipeline, result *models.PipelineResult, key []byte) {
+	glob := pipeline.Options.ArchiveEntryGlob
+	if glob == "" {
+		glob = "*"
+	}
+
+	entries, err := readArchive(pipeline.InputPath, glob)
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("failed to read input archive: %w", err)
+		return
+	}
+
+	if len(entries) == 0 {
+		result.Success = false
+		result.Error = fmt.Errorf("no archive entries match %q", glob)
+		return
+	}
+
+	writer, err := newArchiveWriter(pipeline.OutputPath)
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("failed to create output: %w", err)
+		return
+	}
+
+	workers := 1
+	if pipeline.Options.Strategy == models.StrategyConcurrent {
+		workers = max(e.pool.maxSize, 1)
+	}
+
+	for _, converted := range e.convertEntries(ctx, pipeline, key, entries, workers, result.RunID) {
+		entryResult := converted.result
+		result.Entries = append(result.Entries, entryResult)
+		result.Results = append(result.Results, entryResult.Results...)
+
+		if entryResult.Error != nil {
+			result.Success = false
+			result.Error = fmt.Errorf("entry %s: %w", entryResult.Name, entryResult.Error)
+			writer.Abort()
+			return
+		}
+
+		if err := writer.Add(entryResult.Output, converted.output); err != nil {
+			entryResult.Error = err
+			result.Success = false
+			result.Error = fmt.Errorf("failed to write output for entry %s: %w", entryResult.Name, err)
+			writer.Abort()
+			return
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("failed to finalize output: %w", err)
+	}
+}
+
+type convertedEntry struct {
+	result *models.EntryResult
+	output []byte
+}
+
+// convertEntries runs the pipeline over up to workers entries at a time.
+// Entries start in archive order and none start after a failure, so every
+// entry before the first failed one has been converted.
+func (e *PipelineExecutor) convertEntries(ctx context.Context, pipeline *models.Pipeline, key []byte, entries []archiveEntry, workers int, runID string) []convertedEntry {
+	converted := make([]convertedEntry, len(entries))
+	finalFormat := pipeline.Steps[len(pipeline.Steps)-1].To
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	slots := make(chan struct{}, workers)
+
+	for i, entry := range entries {
+		slots <- struct{}{}
+
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop {
+			<-slots
+			converted = converted[:i]
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, entry archiveEntry) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			entryResult := &models.EntryResult{
+				Name:   entry.name,
+				Output: strings.TrimSuffix(entry.name, path.Ext(entry.name)) + "." + string(finalFormat),
+			}
+
+			stepsDir := ""
+			if pipeline.Options.SaveIntermediarySteps {
+				stepsDir = filepath.Join("steps", filepath.FromSlash(strings.TrimSuffix(entry.name, path.Ext(entry.name))))
+			}
+
+			output, err := openInput(pipeline, key, entry.data)
+			if err == nil && pipeline.Options.Provenance {
+				output, err = addProvenance(pipeline, output, entry.name, runID)
+			}
+			if err == nil {
+				entryResult.Results, output, err = e.runSteps(ctx, pipeline, output, stepsDir)
+			}
+			if err == nil {
+				output, err = sealOutput(pipeline, key, output)
+			}
+
+			entryResult.Error = err
+			converted[i] = convertedEntry{result: entryResult, output: output}
+			if err != nil {
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+			}
+		}(i, entry)
+	}
+
+	wg.Wait()
+	return converted
+}
+
+// archiveOutputs lists the files produced by an archive run: the output
+// archive itself, or every entry written into the output directory.
+func archiveOutputs(pipeline *models.Pipeline, result *models.PipelineResult) []string {
+	if IsArchive(pipeline.OutputPath) {
+		return []string{pipeline.OutputPath}
+	}
+
+	outputs := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		outputs = append(outputs, filepath.Join(pipeline.OutputPath, filepath.FromSlash(entry.Output)))
+	}
+	return outputs
+}
+
+func readArchive(archivePath, glob string) ([]archiveEntry, error) {
+	switch archiveKind(archivePath) {
+	case "zip":
+		return readZip(archivePath, glob)
+	case "tar", "tar.gz":
+		return readTar(archivePath, glob)
+	default:
+		return nil, fmt.Errorf("unsupported archive: %s", archivePath)
+	}
+}
+
+func readZip(archivePath, glob string) ([]archiveEntry, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var entries []archiveEntry
+	var total int64
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		matched, err := matchEntry(file.Name, glob)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		size := int64(file.UncompressedSize64)
+		if file.UncompressedSize64 > uint64(maxArchiveEntryBytes) {
+			size = maxArchiveEntryBytes + 1
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := readEntry(rc, file.Name, size, &total)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{name: file.Name, data: data})
+	}
+	return entries, nil
+}
+
+func readTar(archivePath, glob string) ([]archiveEntry, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var source io.Reader = file
+	if archiveKind(archivePath) == "tar.gz" {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		source = gz
+	}
+
+	var entries []archiveEntry
+	var total int64
+	reader := tar.NewReader(source)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		matched, err := matchEntry(header.Name, glob)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		data, err := readEntry(reader, header.Name, header.Size, &total)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{name: header.Name, data: data})
+	}
+	return entries, nil
+}
+
+// readEntry reads an entry of the declared uncompressed size, failing
+// before reading when the size is over the limits and while reading when
+// the entry turns out longer than declared. total sums the entries read so
+// far.
+func readEntry(r io.Reader, name string, declared int64, total *int64) ([]byte, error) {
+	limit := min(maxArchiveEntryBytes, maxArchiveBytes-*total)
+	tooLarge := func() error {
+		if limit == maxArchiveEntryBytes {
+			return fmt.Errorf("archive entry %s is larger than %d bytes", name, maxArchiveEntryBytes)
+		}
+		return fmt.Errorf("archive entries are larger than %d bytes in total", maxArchiveBytes)
+	}
+	if declared > limit {
+		return nil, tooLarge()
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, tooLarge()
+	}
+	*total += int64(len(data))
+	return data, nil
+}
+
+// matchEntry matches the glob against the full entry path or its base name
+// and rejects names that would escape the output directory.
+func matchEntry(name, glob string) (bool, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return false, fmt.Errorf("unsafe archive entry name: %s", name)
+	}
+
+	matched, err := path.Match(glob, clean)
+	if err != nil || matched {
+		return matched, err
+	}
+	return path.Match(glob, path.Base(clean))
+}
+
+func newArchiveWriter(outputPath string) (archiveWriter, error) {
+	kind := archiveKind(outputPath)
+	if kind == "" {
+		if err := os.MkdirAll(outputPath, 0755); err != nil {
+			return nil, err
+		}
+		return &directoryWriter{root: outputPath}, nil
+	}
+
+	out, err := createPending(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == "zip" {
+		return &zipWriter{out: out, writer: zip.NewWriter(out.file)}, nil
+	}
+
+	w := &tarWriter{out: out}
+	if kind == "tar.gz" {
+		w.gz = gzip.NewWriter(out.file)
+		w.writer = tar.NewWriter(w.gz)
+	} else {
+		w.writer = tar.NewWriter(out.file)
+	}
+	return w, nil
+}
+
+type directoryWriter struct {
+	root string
+}
+
+func (d *directoryWriter) Add(name string, data []byte) error {
+	target := filepath.Join(d.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(target, data)
+}
+
+func (d *directoryWriter) Close() error {
+	return nil
+}
+
+// Abort keeps the entries already written, each of which is complete.
+func (d *directoryWriter) Abort() {}
+
+// pendingFile is an output archive written to a temporary file next to its
+// target and renamed into place by commit, so a failed run never leaves a
+// partial archive behind.
+type pendingFile struct {
+	file   *os.File
+	target string
+}
+
+func createPending(target string) (*pendingFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &pendingFile{file: file, target: target}, nil
+}
+
+func (p *pendingFile) commit() error {
+	err := p.file.Chmod(0644)
+	if closeErr := p.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(p.file.Name(), p.target)
+	}
+	if err != nil {
+		os.Remove(p.file.Name())
+	}
+	return err
+}
+
+func (p *pendingFile) abort() {
+	p.file.Close()
+	os.Remove(p.file.Name())
+}
+
+type zipWriter struct {
+	out    *pendingFile
+	writer *zip.Writer
+}
+
+func (z *zipWriter) Add(name string, data []byte) error {
+	w, err := z.writer.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, bytes.NewReader(data))
+	return err
+}
+
+func (z *zipWriter) Close() error {
+	if err := z.writer.Close(); err != nil {
+		z.out.abort()
+		return err
+	}
+	return z.out.commit()
+}
+
+func (z *zipWriter) Abort() {
+	z.out.abort()
+}
+
+type tarWriter struct {
+	out    *pendingFile
+	gz     *gzip.Writer
+	writer *tar.Writer
+}
+
+func (t *tarWriter) Add(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := t.writer.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := t.writer.Write(data)
+	return err
+}
+
+func (t *tarWriter) Close() error {
+	err := t.writer.Close()
+	if t.gz != nil {
+		if gzErr := t.gz.Close(); err == nil {
+			err = gzErr
+		}
+	}
+	if err != nil {
+		t.out.abort()
+		return err
+	}
+	return t.out.commit()
+}
+
+func (t *tarWriter) Abort() {
+	t.out.abort()
+}
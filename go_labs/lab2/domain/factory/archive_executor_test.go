@@ -0,0 +1,124 @@
+package factory
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteArchivePerEntry(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.zip")
+	output := filepath.Join(dir, "output.zip")
+
+	file, err := os.Create(input)
+	assert.NoError(t, err)
+	writer := zip.NewWriter(file)
+	for name, content := range map[string]string{
+		"data/a.csv": "name\nann\n",
+		"data/b.csv": "name\nbob\n",
+		"notes.txt":  "skip me",
+	} {
+		w, _ := writer.Create(name)
+		w.Write([]byte(content))
+	}
+	assert.NoError(t, writer.Close())
+	assert.NoError(t, file.Close())
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(output).
+		WithArchiveEntries("*.csv").
+		AddCSVToJSON().
+		Build()
+	assert.NoError(t, err)
+
+	result := NewPipelineExecutor(NewConverterPool(2, NewConverterFactory())).Execute(pipeline)
+
+	assert.NoError(t, result.Error)
+	assert.Len(t, result.Entries, 2)
+
+	reader, err := zip.OpenReader(output)
+	assert.NoError(t, err)
+	defer reader.Close()
+
+	contents := make(map[string]string)
+	for _, f := range reader.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[f.Name] = string(data)
+	}
+	assert.Len(t, contents, 2)
+	assert.JSONEq(t, `[{"name":"bob"}]`, contents["data/b.json"])
+}
+
+func writeZip(t *testing.T, path string, entries map[string]string) {
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	writer := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		w.Write([]byte(content))
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, file.Close())
+}
+
+func TestArchiveEntriesAreBounded(t *testing.T) {
+	entryLimit, totalLimit := maxArchiveEntryBytes, maxArchiveBytes
+	maxArchiveEntryBytes, maxArchiveBytes = 64, 100
+	t.Cleanup(func() { maxArchiveEntryBytes, maxArchiveBytes = entryLimit, totalLimit })
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.zip")
+	writeZip(t, input, map[string]string{"big.csv": "name\n" + strings.Repeat("a", 100) + "\n"})
+	_, err := readArchive(input, "*")
+	assert.EqualError(t, err, "archive entry big.csv is larger than 64 bytes")
+
+	writeZip(t, input, map[string]string{"a.csv": strings.Repeat("a", 60), "b.csv": strings.Repeat("b", 60)})
+	_, err = readArchive(input, "*")
+	assert.EqualError(t, err, "archive entries are larger than 100 bytes in total")
+
+	var data bytes.Buffer
+	tw := tar.NewWriter(&data)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "big.csv", Mode: 0644, Size: 65, Typeflag: tar.TypeReg}))
+	tw.Write(bytes.Repeat([]byte("a"), 65))
+	require.NoError(t, tw.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "input.tar"), data.Bytes(), 0644))
+	_, err = readArchive(filepath.Join(dir, "input.tar"), "*")
+	assert.EqualError(t, err, "archive entry big.csv is larger than 64 bytes")
+}
+
+func TestFailedArchiveRunLeavesNoOutput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.zip")
+	writeZip(t, input, map[string]string{"a.json": `[{"name":"ann"}]`, "b.json": `not json`})
+
+	for _, name := range []string{"output.zip", "output.tar.gz"} {
+		output := filepath.Join(dir, name)
+		pipeline, err := NewPipelineBuilder().WithInputPath(input).WithOutputPath(output).AddJSONToXML().Build()
+		require.NoError(t, err)
+
+		result := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).Execute(pipeline)
+		assert.ErrorContains(t, result.Error, "entry b.json")
+		assert.NoFileExists(t, output)
+	}
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "no temporary files are left behind")
+}
+
+func TestMatchEntryRejectsTraversal(t *testing.T) {
+	_, err := matchEntry("../../etc/passwd", "*")
+	assert.Error(t, err)
+}
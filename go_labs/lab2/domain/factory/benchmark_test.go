@@ -0,0 +1,56 @@
+package factory
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func benchmarkCSV(rows int) string {
+	var input strings.Builder
+	input.WriteString("id,name,city,status\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&input, "%d,person%d,city%d,active\n", i, i, i%50)
+	}
+	return input.String()
+}
+
+func benchmarkConvert(b *testing.B, converter models.Converter, input string, from, to models.FileFormat) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		if result := converter.Convert(strings.NewReader(input), from, to); result.Error != nil {
+			b.Fatal(result.Error)
+		}
+	}
+}
+
+func BenchmarkCSVToJSON(b *testing.B) {
+	benchmarkConvert(b, &CSVToJSONConverter{}, benchmarkCSV(10000), models.FormatCSV, models.FormatJSON)
+}
+
+func BenchmarkCSVToJSONStream(b *testing.B) {
+	input := benchmarkCSV(10000)
+	converter := &CSVToJSONConverter{}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		if err := converter.ConvertStream(strings.NewReader(input), io.Discard, models.FormatCSV, models.FormatJSON); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSONToXML(b *testing.B) {
+	json := (&CSVToJSONConverter{}).Convert(strings.NewReader(benchmarkCSV(2000)), models.FormatCSV, models.FormatJSON)
+	benchmarkConvert(b, &JSONToXMLConverter{}, string(json.Data), models.FormatJSON, models.FormatXML)
+}
+
+func BenchmarkXMLToYAML(b *testing.B) {
+	json := (&CSVToJSONConverter{}).Convert(strings.NewReader(benchmarkCSV(2000)), models.FormatCSV, models.FormatJSON)
+	xml := (&JSONToXMLConverter{}).Convert(strings.NewReader(string(json.Data)), models.FormatJSON, models.FormatXML)
+	benchmarkConvert(b, &XMLToYAMLConverter{}, string(xml.Data), models.FormatXML, models.FormatYAML)
+}
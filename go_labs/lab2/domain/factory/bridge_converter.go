@@ -0,0 +1,59 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// BridgeConverter pairs any document parser with any renderer. The factory
+// falls back to it for conversions without a dedicated converter.
+type BridgeConverter struct {
+	configured
+	parser   document.Parser
+	renderer document.Renderer
+	from     models.FileFormat
+	to       models.FileFormat
+}
+
+func newBridgeConverter(from, to models.FileFormat) (*BridgeConverter, bool) {
+	parser, ok := document.ParserFor(from)
+	if !ok {
+		return nil, false
+	}
+	renderer, ok := document.RendererFor(to)
+	if !ok {
+		return nil, false
+	}
+	return &BridgeConverter{parser: parser, renderer: renderer, from: from, to: to}, true
+}
+
+func (b *BridgeConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from != b.from || to != b.to {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	doc, err := b.parser.Parse(input, b.options)
+	if err != nil {
+		return &models.ConversionResult{Error: err}
+	}
+
+	data, err := b.renderer.Render(doc, b.options)
+	if err != nil {
+		return &models.ConversionResult{Error: err}
+	}
+
+	return &models.ConversionResult{
+		Data:   data,
+		Format: to,
+	}
+}
+
+func (b *BridgeConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == b.from || format == b.to
+}
@@ -0,0 +1,35 @@
+package factory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestBridgePairsParsersWithRenderers(t *testing.T) {
+	assert.True(t, IsRegistered("yaml-json"))
+	assert.Contains(t, RegisteredConversions(), "xml-csv")
+
+	converterFactory := NewConverterFactory()
+
+	native, _ := converterFactory.CreateConverter("csv-json")
+	assert.IsType(t, &CSVToJSONConverter{}, native)
+
+	converter, err := converterFactory.CreateConverter("xml-csv")
+	assert.NoError(t, err)
+	converter.(models.Configurable).Configure(models.ConversionOptions{})
+
+	xml := "<doc><root><name>ann</name><city>oslo</city></root><root><name>bob</name><city>rome</city></root></doc>"
+	result := converter.Convert(strings.NewReader(xml), models.FormatXML, models.FormatCSV)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "city,name\noslo,ann\nrome,bob\n", string(result.Data))
+
+	yamlToJSON, _ := converterFactory.CreateConverter("yaml-json")
+	yamlToJSON.(models.Configurable).Configure(models.NewOptions(models.WithIndentWidth(0)))
+	result = yamlToJSON.Convert(strings.NewReader("items:\n  - 1\n  - two\n"), models.FormatYAML, models.FormatJSON)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, `{"items":[1,"two"]}`, string(result.Data))
+}
@@ -0,0 +1,49 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/yamlcomments"
+)
+
+func checkCommentPreservation(pipeline *models.Pipeline) error {
+	if pipeline.Steps[0].From != models.FormatYAML || pipeline.Steps[len(pipeline.Steps)-1].To != models.FormatYAML {
+		return fmt.Errorf("comment preservation needs YAML input and YAML output")
+	}
+	return nil
+}
+
+// extractComments records the comments of YAML input when the pipeline
+// preserves them, or returns nil.
+func extractComments(pipeline *models.Pipeline, data []byte) (*yamlcomments.Comments, error) {
+	if !pipeline.Options.PreserveComments || pipeline.Steps[0].From != models.FormatYAML {
+		return nil, nil
+	}
+	comments, err := yamlcomments.Extract(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input comments: %w", err)
+	}
+	return comments, nil
+}
+
+// restoreComments puts the input's comments back on YAML output and applies
+// the post-processors, which keep them.
+func restoreComments(pipeline *models.Pipeline, comments *yamlcomments.Comments, data []byte) ([]byte, error) {
+	if comments != nil && pipeline.Steps[len(pipeline.Steps)-1].To == models.FormatYAML {
+		// yaml.Marshal indents by 4 unless the pipeline sets a width.
+		indent := 4
+		if width, set := pipeline.Options.Indentation(); set {
+			indent = max(len(width), 2)
+		}
+		restored, err := yamlcomments.Apply(data, comments, indent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore comments: %w", err)
+		}
+		data = restored
+	}
+	return postProcess(pipeline, data)
+}
@@ -0,0 +1,42 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestCommentPreservationThroughJSON(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("# deploy settings\nreplicas: 3 # keep odd\nimage: api\n"), 0644))
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "config.out.yaml")).
+		AddConversionStep(models.FormatYAML, models.FormatJSON).
+		AddConversionStep(models.FormatJSON, models.FormatYAML).
+		WithCommentPreservation().
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(pipeline).Error)
+	data, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "image: api\n# deploy settings\nreplicas: 3 # keep odd\n", string(data))
+}
+
+func TestBuildRejectsCommentPreservationWithoutYAML(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.json").
+		AddCSVToJSON().
+		WithCommentPreservation().
+		Build()
+	assert.EqualError(t, err, "comment preservation needs YAML input and YAML output")
+}
@@ -0,0 +1,66 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestSubPipelineRunsWithItsOwnOptions(t *testing.T) {
+	sanitize, err := NewPipelineBuilder().
+		Apply(models.WithCSVDelimiter(';'), models.WithIndentWidth(0)).
+		AddCSVToJSON().
+		BuildSubPipeline()
+	assert.NoError(t, err)
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.xml").
+		Apply(models.WithXMLRoot("people")).
+		AddPipeline(sanitize).
+		AddJSONToXML().
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, models.FormatCSV, pipeline.Steps[0].From)
+	assert.Equal(t, models.FormatJSON, pipeline.Steps[0].To)
+
+	executor := NewPipelineExecutor(NewConverterPool(2, NewConverterFactory()))
+	output, result := executor.ConvertData(context.Background(), pipeline, []byte("name;city\nann;oslo\n"))
+	assert.NoError(t, result.Error)
+	assert.Len(t, result.Results, 2)
+	assert.Equal(t, `[{"city":"oslo","name":"ann"}]`, string(result.Results[0].Data))
+	assert.Contains(t, string(output), "<people>")
+}
+
+func TestEmptySubPipelineIsRejected(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.xml").
+		AddPipeline(&models.Pipeline{}).
+		Build()
+	assert.EqualError(t, err, "sub-pipeline at step 1: pipeline must have at least one conversion step")
+
+	_, err = NewPipelineBuilder().AddPipeline(nil).BuildSubPipeline()
+	assert.EqualError(t, err, "sub-pipeline at step 1: pipeline must have at least one conversion step")
+}
+
+func TestInvalidNestedSubPipelinesAreRejected(t *testing.T) {
+	inner := &models.Pipeline{Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}}}
+	inner.Options.CSVParser = "quantum"
+	outer := &models.Pipeline{Steps: []models.ConversionStep{{Pipeline: inner, From: models.FormatCSV, To: models.FormatJSON}}}
+	_, err := NewPipelineBuilder().AddPipeline(outer).BuildSubPipeline()
+	assert.EqualError(t, err, `sub-pipeline at step 1: sub-pipeline at step 1: unknown CSV parser "quantum"`)
+
+	loop := &models.Pipeline{}
+	loop.Steps = []models.ConversionStep{{Pipeline: loop, From: models.FormatCSV, To: models.FormatCSV}}
+	_, err = NewPipelineBuilder().AddPipeline(loop).BuildSubPipeline()
+	assert.EqualError(t, err, "sub-pipeline at step 1: sub-pipeline at step 1 contains itself")
+
+	shared, err := NewPipelineBuilder().AddCSVToJSON().BuildSubPipeline()
+	assert.NoError(t, err)
+	_, err = NewPipelineBuilder().AddPipeline(shared).AddConversionStep(models.FormatJSON, models.FormatCSV).AddPipeline(shared).BuildSubPipeline()
+	assert.NoError(t, err, "the same sub-pipeline may be used twice side by side")
+}
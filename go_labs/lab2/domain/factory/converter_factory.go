@@ -4,23 +4,212 @@
 package factory
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
+	"tmps-go-labs/lab2/domain/document"
 	"tmps-go-labs/lab2/domain/models"
 )
 
 type ConverterCreator func() models.Converter
 
+// BaseVersion is the version RegisterConverter gives the converters it
+// registers without one.
+const BaseVersion = "1.0.0"
+
+// CapabilityStreaming marks converters that implement models.StreamConverter.
+const CapabilityStreaming = "streaming"
+
+// ConverterInfo describes one implementation of a conversion: its semantic
+// version, "major.minor.patch", and the capabilities it offers, such as
+// CapabilityStreaming.
+type ConverterInfo struct {
+	Version      string
+	Capabilities []string
+}
+
+// Has reports whether the converter offers capability.
+func (i ConverterInfo) Has(capability string) bool {
+	return slices.Contains(i.Capabilities, capability)
+}
+
+func (i ConverterInfo) String() string {
+	if len(i.Capabilities) == 0 {
+		return i.Version
+	}
+	return i.Version + " (" + strings.Join(i.Capabilities, ", ") + ")"
+}
+
+type registration struct {
+	info    ConverterInfo
+	creator ConverterCreator
+}
+
 var (
-	converterRegistry = make(map[string]ConverterCreator)
+	// converterRegistry keeps the versions of each conversion newest first.
+	converterRegistry = make(map[string][]registration)
 	registryMutex     sync.RWMutex
 )
 
+// RegisterConverter registers creator as the only implementation of
+// formatType, at BaseVersion, replacing every version registered before.
 func RegisterConverter(formatType string, creator ConverterCreator) {
 	registryMutex.Lock()
 	defer registryMutex.Unlock()
-	converterRegistry[formatType] = creator
+	converterRegistry[formatType] = []registration{{info: ConverterInfo{Version: BaseVersion}, creator: creator}}
+}
+
+// RegisterConverterVersion registers creator as the info.Version
+// implementation of formatType next to the other versions, replacing only
+// a creator registered for the same version. It panics on a malformed
+// version, like regexp.MustCompile, since registrations are fixed in code.
+func RegisterConverterVersion(formatType string, info ConverterInfo, creator ConverterCreator) {
+	if _, err := parseVersion(info.Version); err != nil {
+		panic(fmt.Sprintf("factory: converter %s: %v", formatType, err))
+	}
+	info.Capabilities = slices.Clone(info.Capabilities)
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	versions := slices.DeleteFunc(converterRegistry[formatType], func(r registration) bool {
+		return r.info.Version == info.Version
+	})
+	versions = append(versions, registration{info: info, creator: creator})
+	slices.SortStableFunc(versions, func(a, b registration) int {
+		return compareVersions(b.info.Version, a.info.Version)
+	})
+	converterRegistry[formatType] = versions
+}
+
+// LookupConverter returns the creator of the newest version registered for
+// formatType.
+func LookupConverter(formatType string) (ConverterCreator, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	versions := converterRegistry[formatType]
+	if len(versions) == 0 {
+		return nil, false
+	}
+	return versions[0].creator, true
+}
+
+// Registrations is every version registered for a conversion, with its
+// capabilities, as saved by SaveConverter.
+type Registrations struct {
+	versions []registration
+}
+
+// SaveConverter returns the versions registered for formatType, so a
+// caller replacing them can put them back with RestoreConverter.
+func SaveConverter(formatType string) Registrations {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return Registrations{versions: slices.Clone(converterRegistry[formatType])}
+}
+
+// RestoreConverter replaces the versions of formatType with saved. Saved
+// registrations without versions unregister formatType.
+func RestoreConverter(formatType string, saved Registrations) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if len(saved.versions) == 0 {
+		delete(converterRegistry, formatType)
+		return
+	}
+	converterRegistry[formatType] = slices.Clone(saved.versions)
+}
+
+// UnregisterConverter removes every version of formatType. Pairs the
+// bridge can serve stay available through it.
+func UnregisterConverter(formatType string) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	delete(converterRegistry, formatType)
+}
+
+// ConverterVersions lists the implementations of formatType newest first,
+// or the bridge's when only the bridge serves the pair.
+func ConverterVersions(formatType string) []ConverterInfo {
+	registryMutex.RLock()
+	versions := converterRegistry[formatType]
+	infos := make([]ConverterInfo, 0, len(versions))
+	for _, r := range versions {
+		infos = append(infos, r.info)
+	}
+	registryMutex.RUnlock()
+
+	if len(infos) == 0 {
+		if _, ok := bridgeFor(formatType); ok {
+			infos = append(infos, bridgeInfo)
+		}
+	}
+	return infos
+}
+
+// bridgeInfo describes the BridgeConverter serving parser/renderer pairs.
+var bridgeInfo = ConverterInfo{Version: BaseVersion, Capabilities: []string{CapabilityStreaming}}
+
+// ResolveConverter negotiates the implementation of formatType to run: the
+// newest version matching requirement.Version, a version or a prefix of one
+// such as "2" or "2.1", that offers every capability the requirement lists.
+// A nil requirement takes the newest version.
+func ResolveConverter(formatType string, requirement *models.ConverterRequirement) (ConverterInfo, error) {
+	versions := ConverterVersions(formatType)
+	if len(versions) == 0 {
+		return ConverterInfo{}, fmt.Errorf("unsupported converter type: %s", formatType)
+	}
+	if requirement == nil {
+		return versions[0], nil
+	}
+
+	for _, info := range versions {
+		if !versionMatches(info.Version, requirement.Version) {
+			continue
+		}
+		if !slices.ContainsFunc(requirement.Capabilities, func(capability string) bool { return !info.Has(capability) }) {
+			return info, nil
+		}
+	}
+
+	available := make([]string, len(versions))
+	for i, info := range versions {
+		available[i] = info.String()
+	}
+	return ConverterInfo{}, fmt.Errorf("no %s converter matches %s; available: %s", formatType, describeRequirement(requirement), strings.Join(available, ", "))
+}
+
+func describeRequirement(requirement *models.ConverterRequirement) string {
+	var parts []string
+	if requirement.Version != "" {
+		parts = append(parts, "version "+requirement.Version)
+	}
+	if len(requirement.Capabilities) > 0 {
+		parts = append(parts, "capabilities "+strings.Join(requirement.Capabilities, ", "))
+	}
+	if len(parts) == 0 {
+		return "any version"
+	}
+	return strings.Join(parts, " with ")
+}
+
+// converterKey names the converter a step runs: its "from-to" pair, or
+// "from-to@version" when the step asks for a particular implementation, so
+// the pool keeps pinned converters apart from the newest ones.
+func converterKey(step models.ConversionStep) (string, error) {
+	key := string(step.From) + "-" + string(step.To)
+	if step.Converter == nil {
+		return key, nil
+	}
+	info, err := ResolveConverter(key, step.Converter)
+	if err != nil {
+		return "", err
+	}
+	return key + "@" + info.Version, nil
 }
 
 type ConverterFactory interface {
@@ -33,14 +222,137 @@ func NewConverterFactory() ConverterFactory {
 	return &DefaultConverterFactory{}
 }
 
+// CreateConverter creates the newest implementation of formatType, or the
+// one of the given version for "from-to@version".
 func (f *DefaultConverterFactory) CreateConverter(formatType string) (models.Converter, error) {
+	key, version, pinned := strings.Cut(formatType, "@")
+
 	registryMutex.RLock()
-	creator, exists := converterRegistry[formatType]
+	versions := converterRegistry[key]
 	registryMutex.RUnlock()
 
-	if !exists {
+	if len(versions) == 0 {
+		if bridge, ok := bridgeFor(key); ok && (!pinned || version == bridgeInfo.Version) {
+			return bridge, nil
+		}
 		return nil, fmt.Errorf("unsupported converter type: %s", formatType)
 	}
+	if !pinned {
+		return versions[0].creator(), nil
+	}
+	for _, r := range versions {
+		if r.info.Version == version {
+			return r.creator(), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported converter type: %s", formatType)
+}
+
+func IsRegistered(formatType string) bool {
+	registryMutex.RLock()
+	_, exists := converterRegistry[formatType]
+	registryMutex.RUnlock()
+	if exists {
+		return true
+	}
+	_, ok := bridgeFor(formatType)
+	return ok
+}
+
+func bridgeFor(formatType string) (*BridgeConverter, bool) {
+	from, to, ok := strings.Cut(formatType, "-")
+	if !ok || from == to {
+		return nil, false
+	}
+	return newBridgeConverter(models.FileFormat(from), models.FileFormat(to))
+}
+
+// RegisteredConversions lists the registered "from-to" keys, including the
+// parser/renderer pairs served by BridgeConverter, in sorted order.
+func RegisteredConversions() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	keys := make([]string, 0, len(converterRegistry))
+	for key := range converterRegistry {
+		keys = append(keys, key)
+	}
+	for _, key := range document.Pairs() {
+		if _, exists := converterRegistry[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseVersion splits a "major.minor.patch" version, or a prefix of one,
+// into its numbers.
+func parseVersion(version string) ([]int, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, fmt.Errorf("invalid version %q", version)
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+// compareVersions orders versions numerically, treating missing parts as
+// zero and malformed versions as older than any other.
+func compareVersions(a, b string) int {
+	x, errA := parseVersion(a)
+	y, errB := parseVersion(b)
+	if errA != nil || errB != nil {
+		return cmp.Compare(boolInt(errA == nil), boolInt(errB == nil))
+	}
+	for i := range 3 {
+		if c := cmp.Compare(versionPart(x, i), versionPart(y, i)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// versionMatches reports whether version starts with the parts of
+// constraint, so "2" matches "2.4.1" but not "12.0.0". An empty constraint
+// matches every version.
+func versionMatches(version, constraint string) bool {
+	if constraint == "" {
+		return true
+	}
+	have, err := parseVersion(version)
+	if err != nil {
+		return false
+	}
+	want, err := parseVersion(constraint)
+	if err != nil {
+		return false
+	}
+	for i, part := range want {
+		if versionPart(have, i) != part {
+			return false
+		}
+	}
+	return true
+}
+
+func versionPart(parts []int, i int) int {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return 0
+}
 
-	return creator(), nil
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
 }
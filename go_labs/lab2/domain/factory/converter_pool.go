@@ -4,33 +4,149 @@
 package factory
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"tmps-go-labs/lab2/domain/models"
 )
 
+// ErrPoolExhausted is returned by Get when every converter of a type is in
+// use and the pool was told not to wait, or waited longer than its timeout.
+var ErrPoolExhausted = errors.New("converter pool exhausted")
+
+// ConverterPool keeps up to maxSize converters per type, or the size set
+// for the type with WithTypeSizes. Once that many
+// exist, Get waits for one to be returned instead of creating more, unless
+// a PoolOption picks another behavior.
 type ConverterPool struct {
-	pools   map[string]chan models.Converter
-	factory ConverterFactory
-	mu      sync.Mutex
-	created map[string]int
-	maxSize int
+	pools       map[string]chan models.Converter
+	factory     ConverterFactory
+	mu          sync.Mutex
+	created     map[string]int
+	overflow    map[string]int
+	maxSize     int
+	sizes       map[string]int
+	maxOverflow int
+	failFast    bool
+	waitTimeout time.Duration
+	generation  map[string]int
+	born        map[models.Converter]birth
+}
+
+// birth records the type and generation a pooled converter was created in.
+type birth struct {
+	converterType string
+	generation    int
+}
+
+// PoolOption selects what Get does when every pooled converter of a type is
+// in use.
+type PoolOption func(*ConverterPool)
+
+// WithWaitTimeout makes Get give up with ErrPoolExhausted after waiting
+// timeout for a converter to be returned.
+func WithWaitTimeout(timeout time.Duration) PoolOption {
+	return func(p *ConverterPool) {
+		p.waitTimeout = timeout
+	}
 }
 
-func NewConverterPool(maxSize int, factory ConverterFactory) *ConverterPool {
-	return &ConverterPool{
-		pools:   make(map[string]chan models.Converter),
-		factory: factory,
-		created: make(map[string]int),
-		maxSize: maxSize,
+// FailWhenExhausted makes Get return ErrPoolExhausted at once instead of
+// waiting.
+func FailWhenExhausted() PoolOption {
+	return func(p *ConverterPool) {
+		p.failFast = true
 	}
 }
 
+// WithTypeSizes overrides maxSize for the given converter types, such as a
+// smaller cap for memory-heavy XML converters. Sizes below 1 are raised to
+// 1; types not listed keep maxSize.
+func WithTypeSizes(sizes map[string]int) PoolOption {
+	return func(p *ConverterPool) {
+		for converterType, size := range sizes {
+			p.sizes[converterType] = max(size, 1)
+		}
+	}
+}
+
+// WithOverflow lets up to n unpooled converters per type exist on top of
+// maxSize. They are dropped when returned to a full pool. Once the overflow
+// is used up, Get waits or fails as the other options say.
+func WithOverflow(n int) PoolOption {
+	return func(p *ConverterPool) {
+		p.maxOverflow = n
+	}
+}
+
+// NewConverterPool creates a pool of at most maxSize converters per type;
+// sizes below 1 are raised to 1.
+func NewConverterPool(maxSize int, factory ConverterFactory, options ...PoolOption) *ConverterPool {
+	p := &ConverterPool{
+		pools:      make(map[string]chan models.Converter),
+		factory:    factory,
+		created:    make(map[string]int),
+		overflow:   make(map[string]int),
+		maxSize:    max(maxSize, 1),
+		sizes:      make(map[string]int),
+		generation: make(map[string]int),
+		born:       make(map[models.Converter]birth),
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// Limit returns the most converters of converterType the pool keeps. A
+// pinned "from-to@version" type takes the size of its pair unless it has
+// a size of its own.
+func (p *ConverterPool) Limit(converterType string) int {
+	if size, ok := p.sizes[converterType]; ok {
+		return size
+	}
+	pair, _, _ := strings.Cut(converterType, "@")
+	if size, ok := p.sizes[pair]; ok {
+		return size
+	}
+	return p.maxSize
+}
+
+// Get returns an idle converter, creates one while fewer than Limit exist,
+// or else waits until one is returned with Return (see PoolOption for the
+// alternatives).
 func (p *ConverterPool) Get(converterType string) (models.Converter, error) {
+	converter, _, err := p.get(context.Background(), converterType)
+	return converter, err
+}
+
+// GetContext is Get traced as a child span of ctx, recording whether the
+// converter was reused from the pool or newly created. It stops waiting for
+// a converter once ctx is done.
+func (p *ConverterPool) GetContext(ctx context.Context, converterType string) (models.Converter, error) {
+	ctx, span := tracer.Start(ctx, "converter_pool.get", trace.WithAttributes(
+		attribute.String("converter.key", converterType),
+	))
+	converter, reused, err := p.get(ctx, converterType)
+	span.SetAttributes(attribute.Bool("converter_pool.reused", reused))
+	endSpan(span, err)
+	return converter, err
+}
+
+func (p *ConverterPool) get(ctx context.Context, converterType string) (models.Converter, bool, error) {
 	p.mu.Lock()
 
 	if _, exists := p.pools[converterType]; !exists {
-		p.pools[converterType] = make(chan models.Converter, p.maxSize)
+		p.pools[converterType] = make(chan models.Converter, p.Limit(converterType))
 		p.created[converterType] = 0
 	}
 
@@ -39,44 +155,194 @@ func (p *ConverterPool) Get(converterType string) (models.Converter, error) {
 
 	select {
 	case converter := <-pool:
-		return converter, nil
+		return converter, true, nil
 	default:
 		p.mu.Lock()
-		if p.created[converterType] < p.maxSize {
-			converter, err := p.factory.CreateConverter(converterType)
-			if err != nil {
+		counter := p.created
+		if p.created[converterType] >= p.Limit(converterType) {
+			counter = p.overflow
+			if p.overflow[converterType] >= p.maxOverflow {
 				p.mu.Unlock()
-				return nil, err
+				return p.wait(ctx, converterType, pool)
 			}
-			p.created[converterType]++
+		}
+		converter, err := p.factory.CreateConverter(converterType)
+		if err != nil {
 			p.mu.Unlock()
-			return converter, nil
+			return nil, false, err
 		}
+		counter[converterType]++
+		p.tag(converterType, converter)
+		p.mu.Unlock()
+		return converter, false, nil
+	}
+}
+
+func (p *ConverterPool) wait(ctx context.Context, converterType string, pool chan models.Converter) (models.Converter, bool, error) {
+	if p.failFast {
+		return nil, false, fmt.Errorf("%w: all %d %s converters are in use", ErrPoolExhausted, p.Limit(converterType)+p.maxOverflow, converterType)
+	}
+
+	var timeout <-chan time.Time
+	if p.waitTimeout > 0 {
+		timer := time.NewTimer(p.waitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case converter := <-pool:
+		return converter, true, nil
+	case <-timeout:
+		return nil, false, fmt.Errorf("%w: no %s converter returned within %s", ErrPoolExhausted, converterType, p.waitTimeout)
+	case <-ctx.Done():
+		return nil, false, fmt.Errorf("waiting for a %s converter: %w", converterType, context.Cause(ctx))
+	}
+}
+
+// Put returns a converter to the pool of the type it was created for.
+// Converters the pool cannot trace back to a type, such as ones whose type
+// cannot be a map key, are dropped; prefer Return, which is told the type.
+func (p *ConverterPool) Put(converter models.Converter) {
+	p.mu.Lock()
+	var converterType string
+	if reflect.TypeOf(converter).Comparable() {
+		converterType = p.born[converter].converterType
+	}
+	p.mu.Unlock()
+	if converterType != "" {
+		p.Return(converterType, converter)
+	}
+}
+
+// Return gives back a converter of converterType got from the pool,
+// resetting it first when it implements models.Resettable.
+func (p *ConverterPool) Return(converterType string, converter models.Converter) {
+	if resettable, ok := converter.(models.Resettable); ok {
+		resettable.Reset()
+	}
+
+	p.mu.Lock()
+	pool, exists := p.pools[converterType]
+	if p.retired(converterType, converter) {
+		// Created before a Flush: swap it for a fresh converter so anyone
+		// waiting in Get is still served.
 		p.mu.Unlock()
+		p.Discard(converterType, converter)
+		return
+	}
+	p.mu.Unlock()
 
+	if !exists {
+		return
+	}
+
+	select {
+	case pool <- converter:
+	default:
+		// A full pool means an overflow converter is out; drop one.
+		p.mu.Lock()
+		p.untag(converter)
+		if p.overflow[converterType] > 0 {
+			p.overflow[converterType]--
+		}
+		p.mu.Unlock()
+	}
+}
+
+// Discard gives up a converter of converterType that will not be returned,
+// such as one still stuck in a timed-out step, and pools a fresh one in its
+// place so anyone waiting in Get is served.
+func (p *ConverterPool) Discard(converterType string, converter models.Converter) {
+	p.mu.Lock()
+	p.untag(converter)
+	if p.overflow[converterType] > 0 {
+		p.overflow[converterType]--
+		p.mu.Unlock()
+		return
+	}
+	if p.created[converterType] > 0 {
+		p.created[converterType]--
+	}
+	pool, exists := p.pools[converterType]
+	fresh := p.replace(converterType)
+	p.mu.Unlock()
+
+	if exists && fresh != nil {
 		select {
-		case converter := <-pool:
-			return converter, nil
+		case pool <- fresh:
 		default:
-			return p.factory.CreateConverter(converterType)
 		}
 	}
 }
 
-func (p *ConverterPool) Put(converter models.Converter) {
+// Flush drops the idle converters of converterType and retires the ones in
+// use, which are replaced when they are returned and count against the
+// limit until then. After a hot reload swaps the registered creator, it
+// makes every later Get use the new one.
+// Converters of types that cannot be map keys are not tracked and survive
+// a Flush if they are in use.
+func (p *ConverterPool) Flush(converterType string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	for _, pool := range p.pools {
+	p.generation[converterType]++
+
+	pool, exists := p.pools[converterType]
+	if !exists {
+		return
+	}
+	for {
 		select {
-		case pool <- converter:
-			return
+		case converter := <-pool:
+			p.untag(converter)
+			if p.created[converterType] > 0 {
+				p.created[converterType]--
+			}
 		default:
-			continue
+			return
 		}
 	}
 }
 
+// tag records the generation a converter was created in. Callers hold mu.
+func (p *ConverterPool) tag(converterType string, converter models.Converter) {
+	if reflect.TypeOf(converter).Comparable() {
+		p.born[converter] = birth{converterType, p.generation[converterType]}
+	}
+}
+
+func (p *ConverterPool) untag(converter models.Converter) {
+	if reflect.TypeOf(converter).Comparable() {
+		delete(p.born, converter)
+	}
+}
+
+// retired reports whether converter was created before the last Flush of
+// converterType. Callers hold mu.
+func (p *ConverterPool) retired(converterType string, converter models.Converter) bool {
+	if !reflect.TypeOf(converter).Comparable() {
+		return false
+	}
+	born, tagged := p.born[converter]
+	return tagged && born.generation != p.generation[converterType]
+}
+
+// replace creates a converter in place of a retired one, or returns nil
+// when the type is already at its limit. Callers hold mu.
+func (p *ConverterPool) replace(converterType string) models.Converter {
+	if p.created[converterType] >= p.Limit(converterType) {
+		return nil
+	}
+	converter, err := p.factory.CreateConverter(converterType)
+	if err != nil {
+		return nil
+	}
+	p.created[converterType]++
+	p.tag(converterType, converter)
+	return converter
+}
+
 func (p *ConverterPool) Size() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -98,3 +364,40 @@ func (p *ConverterPool) Created() int {
 	}
 	return total
 }
+
+type PoolStats struct {
+	MaxSize  int            `json:"max_size"`
+	Limits   map[string]int `json:"limits,omitempty"`
+	Idle     map[string]int `json:"idle"`
+	Created  map[string]int `json:"created"`
+	Overflow map[string]int `json:"overflow,omitempty"`
+}
+
+func (p *ConverterPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := PoolStats{
+		MaxSize: p.maxSize,
+		Idle:    make(map[string]int, len(p.pools)),
+		Created: make(map[string]int, len(p.created)),
+	}
+	for converterType, pool := range p.pools {
+		stats.Idle[converterType] = len(pool)
+	}
+	if len(p.sizes) > 0 {
+		stats.Limits = maps.Clone(p.sizes)
+	}
+	for converterType, count := range p.created {
+		stats.Created[converterType] = count
+	}
+	for converterType, count := range p.overflow {
+		if count > 0 {
+			if stats.Overflow == nil {
+				stats.Overflow = make(map[string]int)
+			}
+			stats.Overflow[converterType] = count
+		}
+	}
+	return stats
+}
@@ -0,0 +1,461 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// keyedConverter remembers the type it was created for, so tests can tell
+// when the pool hands a converter out under the wrong type.
+type keyedConverter struct {
+	key     string
+	release chan struct{}
+}
+
+func (c *keyedConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if c.release != nil {
+		<-c.release
+	}
+	data, err := io.ReadAll(input)
+	return &models.ConversionResult{Data: data, Format: to, Error: err}
+}
+
+func (c *keyedConverter) SupportsFormat(format models.FileFormat) bool { return true }
+
+// countingFactory creates keyedConverters and counts them per type.
+type countingFactory struct {
+	mu      sync.Mutex
+	created map[string]int
+	release chan struct{}
+}
+
+func (f *countingFactory) CreateConverter(formatType string) (models.Converter, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.created == nil {
+		f.created = make(map[string]int)
+	}
+	f.created[formatType]++
+	return &keyedConverter{key: formatType, release: f.release}, nil
+}
+
+func TestPoolStressKeepsInvariants(t *testing.T) {
+	const (
+		maxSize    = 3
+		goroutines = 32
+		iterations = 200
+	)
+	types := []string{"csv-json", "json-xml", "xml-yaml"}
+	converterFactory := &countingFactory{}
+	pool := NewConverterPool(maxSize, converterFactory)
+
+	var inUse [3]atomic.Int32
+	var mixed, overLimit atomic.Int32
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				n := (g + i) % len(types)
+				converter, err := pool.Get(types[n])
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if converter.(*keyedConverter).key != types[n] {
+					mixed.Add(1)
+				}
+				if inUse[n].Add(1) > maxSize {
+					overLimit.Add(1)
+				}
+				inUse[n].Add(-1)
+				pool.Return(types[n], converter)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Zero(t, mixed.Load(), "converters handed out under the wrong type")
+	assert.Zero(t, overLimit.Load(), "more than maxSize converters of a type in use")
+	stats := pool.Stats()
+	for _, converterType := range types {
+		assert.LessOrEqual(t, converterFactory.created[converterType], maxSize, converterType)
+		assert.Equal(t, converterFactory.created[converterType], stats.Created[converterType], converterType)
+		assert.Equal(t, stats.Created[converterType], stats.Idle[converterType], converterType)
+	}
+}
+
+func TestPoolWaitsForReturnedConverter(t *testing.T) {
+	converterFactory := &countingFactory{}
+	pool := NewConverterPool(1, converterFactory)
+	held, err := pool.Get("csv-json")
+	assert.NoError(t, err)
+
+	got := make(chan models.Converter)
+	go func() {
+		converter, _ := pool.Get("csv-json")
+		got <- converter
+	}()
+
+	select {
+	case <-got:
+		t.Fatal("Get created a converter beyond maxSize")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	pool.Return("csv-json", held)
+	assert.Same(t, held, <-got)
+	assert.Equal(t, 1, converterFactory.created["csv-json"])
+}
+
+func TestGetContextStopsWaiting(t *testing.T) {
+	pool := NewConverterPool(1, &countingFactory{})
+	_, err := pool.Get("csv-json")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.GetContext(ctx, "csv-json")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAbortedConversionReturnsConverter(t *testing.T) {
+	converterFactory := &countingFactory{release: make(chan struct{})}
+	pool := NewConverterPool(1, converterFactory)
+	executor := NewPipelineExecutor(pool)
+	pipeline := &models.Pipeline{Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	aborted := make(chan *models.PipelineResult)
+	go func() {
+		_, result := executor.ConvertData(ctx, pipeline, []byte("a\n1\n"))
+		aborted <- result
+	}()
+	assert.Eventually(t, func() bool { return pool.Stats().Created["csv-json"] == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.Error(t, (<-aborted).Error)
+	assert.Zero(t, pool.Stats().Idle["csv-json"])
+
+	close(converterFactory.release)
+	assert.Eventually(t, func() bool { return pool.Stats().Idle["csv-json"] == 1 }, time.Second, time.Millisecond)
+
+	output, result := executor.ConvertData(context.Background(), pipeline, []byte("a\n1\n"))
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "a\n1\n", string(output))
+	assert.Equal(t, 1, converterFactory.created["csv-json"])
+}
+
+func TestTrackConvertersOutlivesAbortedRun(t *testing.T) {
+	converterFactory := &countingFactory{release: make(chan struct{})}
+	executor := NewPipelineExecutor(NewConverterPool(1, converterFactory))
+	pipeline := &models.Pipeline{Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}}}
+
+	ctx, settled := TrackConverters(context.Background())
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, result := executor.ConvertData(ctx, pipeline, []byte("a\n1\n"))
+	assert.ErrorIs(t, result.Error, context.DeadlineExceeded)
+
+	var released atomic.Bool
+	settled(func() { released.Store(true) })
+	assert.False(t, released.Load(), "the converter is still running")
+
+	close(converterFactory.release)
+	assert.Eventually(t, released.Load, time.Second, time.Millisecond)
+}
+
+type panickingConverter struct{}
+
+func (panickingConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	panic("boom")
+}
+
+func (panickingConverter) SupportsFormat(format models.FileFormat) bool { return true }
+
+type panickingFactory struct{}
+
+func (panickingFactory) CreateConverter(formatType string) (models.Converter, error) {
+	return panickingConverter{}, nil
+}
+
+func TestPanickingConverterFailsStep(t *testing.T) {
+	pool := NewConverterPool(1, panickingFactory{})
+	executor := NewPipelineExecutor(pool)
+	pipeline := &models.Pipeline{Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}}}
+
+	_, result := executor.ConvertData(context.Background(), pipeline, []byte("a\n1\n"))
+	assert.EqualError(t, result.Error, "step 1 failed (csv→json): converter panicked: boom")
+	assert.Equal(t, 1, pool.Stats().Idle["csv-json"], "the converter is returned to the pool")
+}
+
+func TestPutReturnsConverterToItsType(t *testing.T) {
+	pool := NewConverterPool(1, &countingFactory{})
+	converter, err := pool.Get("json-xml")
+	assert.NoError(t, err)
+	_, err = pool.Get("csv-json")
+	assert.NoError(t, err)
+
+	pool.Put(converter)
+	assert.Equal(t, map[string]int{"csv-json": 0, "json-xml": 1}, pool.Stats().Idle)
+}
+
+// funcConverter cannot be a map key, so the pool cannot tell its type.
+type funcConverter func(io.Reader, models.FileFormat, models.FileFormat) *models.ConversionResult
+
+func (f funcConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	return f(input, from, to)
+}
+
+func (f funcConverter) SupportsFormat(models.FileFormat) bool { return true }
+
+func TestPutDropsConvertersItCannotTrace(t *testing.T) {
+	pool := NewConverterPool(1, &countingFactory{})
+	_, err := pool.Get("csv-json")
+	assert.NoError(t, err)
+
+	pool.Put(funcConverter(func(io.Reader, models.FileFormat, models.FileFormat) *models.ConversionResult { return nil }))
+	pool.Put(&keyedConverter{key: "json-xml"})
+	assert.Equal(t, map[string]int{"csv-json": 0}, pool.Stats().Idle, "untraced converters are not pooled under another type")
+}
+
+func TestConcurrentExecutorsShareBoundedPool(t *testing.T) {
+	const runs = 24
+	pool := NewConverterPool(2, NewConverterFactory())
+	executor := NewPipelineExecutor(pool)
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.yaml").
+		AddCSVToJSON().
+		AddJSONToXML().
+		AddXMLToYAML().
+		Build()
+	assert.NoError(t, err)
+
+	outputs := make([]string, runs)
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, result := executor.ConvertData(context.Background(), pipeline, []byte(fmt.Sprintf("name,n\nann,%d\n", i%3)))
+			assert.NoError(t, result.Error)
+			outputs[i] = string(output)
+		}()
+	}
+	wg.Wait()
+
+	for i := 3; i < runs; i++ {
+		assert.Equal(t, outputs[i%3], outputs[i])
+	}
+	for converterType, created := range pool.Stats().Created {
+		assert.LessOrEqual(t, created, 2, converterType)
+	}
+}
+
+func TestStreamingRejectsPipelineLargerThanPool(t *testing.T) {
+	pipeline := &models.Pipeline{
+		Options: models.ConversionOptions{Strategy: models.StrategyStreaming},
+		Steps: []models.ConversionStep{
+			{From: models.FormatCSV, To: models.FormatJSON},
+			{From: models.FormatJSON, To: models.FormatCSV},
+			{From: models.FormatCSV, To: models.FormatJSON},
+		},
+	}
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	_, result := executor.ConvertData(context.Background(), pipeline, []byte("a\n1\n"))
+	assert.ErrorContains(t, result.Error, "streaming needs 2 csv-json converters")
+
+	executor = NewPipelineExecutor(NewConverterPool(2, NewConverterFactory()))
+	_, result = executor.ConvertData(context.Background(), pipeline, []byte("a\n1\n"))
+	assert.NoError(t, result.Error)
+}
+
+func TestExhaustedPoolPolicies(t *testing.T) {
+	t.Run("fail", func(t *testing.T) {
+		pool := NewConverterPool(1, &countingFactory{}, FailWhenExhausted())
+		_, err := pool.Get("csv-json")
+		assert.NoError(t, err)
+		_, err = pool.Get("csv-json")
+		assert.ErrorIs(t, err, ErrPoolExhausted)
+	})
+
+	t.Run("wait timeout", func(t *testing.T) {
+		pool := NewConverterPool(1, &countingFactory{}, WithWaitTimeout(10*time.Millisecond))
+		_, err := pool.Get("csv-json")
+		assert.NoError(t, err)
+		_, err = pool.Get("csv-json")
+		assert.ErrorIs(t, err, ErrPoolExhausted)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		converterFactory := &countingFactory{}
+		pool := NewConverterPool(1, converterFactory, WithOverflow(2), FailWhenExhausted())
+		var held []models.Converter
+		for i := 0; i < 3; i++ {
+			converter, err := pool.Get("csv-json")
+			assert.NoError(t, err)
+			held = append(held, converter)
+		}
+		_, err := pool.Get("csv-json")
+		assert.ErrorIs(t, err, ErrPoolExhausted)
+		assert.Equal(t, map[string]int{"csv-json": 2}, pool.Stats().Overflow)
+
+		for _, converter := range held {
+			pool.Return("csv-json", converter)
+		}
+		stats := pool.Stats()
+		assert.Equal(t, 1, stats.Idle["csv-json"])
+		assert.Empty(t, stats.Overflow)
+
+		_, err = pool.Get("csv-json")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, converterFactory.created["csv-json"])
+	})
+}
+
+func TestOverflowStressStaysBounded(t *testing.T) {
+	const maxSize, maxOverflow = 2, 3
+	pool := NewConverterPool(maxSize, &countingFactory{}, WithOverflow(maxOverflow))
+
+	var inUse, peak atomic.Int32
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				converter, err := pool.Get("csv-json")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := inUse.Add(1)
+				for {
+					old := peak.Load()
+					if n <= old || peak.CompareAndSwap(old, n) {
+						break
+					}
+				}
+				inUse.Add(-1)
+				pool.Return("csv-json", converter)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, int(peak.Load()), maxSize+maxOverflow)
+	stats := pool.Stats()
+	assert.LessOrEqual(t, stats.Created["csv-json"], maxSize)
+	assert.Equal(t, stats.Created["csv-json"], stats.Idle["csv-json"])
+	assert.Empty(t, stats.Overflow)
+}
+
+func TestPoolTypeSizes(t *testing.T) {
+	converterFactory := &countingFactory{}
+	pool := NewConverterPool(3, converterFactory, WithTypeSizes(map[string]int{"json-xml": 1, "xml-yaml": 0}), FailWhenExhausted())
+	assert.Equal(t, 1, pool.Limit("json-xml"))
+	assert.Equal(t, 1, pool.Limit("xml-yaml"))
+	assert.Equal(t, 3, pool.Limit("csv-json"))
+	assert.Equal(t, 1, pool.Limit("json-xml@1.0.0"), "pinned converters share their pair's size")
+
+	_, err := pool.Get("json-xml")
+	assert.NoError(t, err)
+	_, err = pool.Get("json-xml")
+	assert.ErrorIs(t, err, ErrPoolExhausted)
+
+	for i := 0; i < 3; i++ {
+		_, err := pool.Get("csv-json")
+		assert.NoError(t, err)
+	}
+	_, err = pool.Get("csv-json")
+	assert.ErrorIs(t, err, ErrPoolExhausted)
+
+	stats := pool.Stats()
+	assert.Equal(t, map[string]int{"json-xml": 1, "xml-yaml": 1}, stats.Limits)
+	assert.Equal(t, 3, stats.Created["csv-json"])
+}
+
+func TestPutResetsConverters(t *testing.T) {
+	pool := NewConverterPool(1, WithMiddleware(NewConverterFactory(), Cache(4)))
+	for _, converterType := range []string{"csv-json", "fixedwidth-json", "json-template"} {
+		converter, err := pool.Get(converterType)
+		assert.NoError(t, err)
+		converter.(models.Configurable).Configure(models.NewOptions(
+			models.WithCSVDelimiter(';'),
+			models.WithTemplate("{{.}}"),
+			models.WithFixedWidthColumns(models.FixedWidthColumn{Name: "id", Start: 1, Length: 3}),
+		))
+		pool.Return(converterType, converter)
+
+		reused, err := pool.Get(converterType)
+		assert.NoError(t, err)
+		assert.Same(t, converter, reused)
+
+		cached := reused.(*cachingConverter)
+		assert.Nil(t, cached.options, converterType)
+		switch inner := cached.Next.(type) {
+		case *CSVToJSONConverter:
+			assert.Zero(t, inner.options)
+		case *FixedWidthToJSONConverter:
+			assert.Nil(t, inner.columns)
+			assert.Zero(t, inner.options)
+		case *OutputTemplateConverter:
+			assert.Empty(t, inner.text)
+			assert.Zero(t, inner.options)
+		default:
+			t.Fatalf("unexpected converter %T", inner)
+		}
+	}
+}
+
+func TestFlushRetiresConverters(t *testing.T) {
+	pool := NewConverterPool(1, &countingFactory{})
+
+	inUse, err := pool.Get("csv-json")
+	assert.NoError(t, err)
+	waiting := make(chan models.Converter, 1)
+	go func() {
+		converter, _ := pool.Get("csv-json")
+		waiting <- converter
+	}()
+
+	pool.Flush("csv-json")
+	assert.Equal(t, 1, pool.Created(), "the retired converter still counts while it is out")
+
+	// The retired converter is swapped for a new one, which the waiting Get
+	// receives.
+	pool.Return("csv-json", inUse)
+	select {
+	case converter := <-waiting:
+		assert.NotSame(t, inUse, converter)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get kept waiting after the flushed converter was returned")
+	}
+	assert.Equal(t, 1, pool.Created())
+}
+
+func TestFlushKeepsTheLimit(t *testing.T) {
+	pool := NewConverterPool(1, &countingFactory{}, FailWhenExhausted())
+
+	inUse, err := pool.Get("csv-json")
+	assert.NoError(t, err)
+	pool.Flush("csv-json")
+	_, err = pool.Get("csv-json")
+	assert.ErrorIs(t, err, ErrPoolExhausted)
+
+	pool.Return("csv-json", inUse)
+	fresh, err := pool.Get("csv-json")
+	assert.NoError(t, err)
+	assert.NotSame(t, inUse, fresh)
+}
@@ -0,0 +1,126 @@
+package factory
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/manifest"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// versionedConverter outputs its version, so tests can tell which
+// implementation ran.
+type versionedConverter struct{ version string }
+
+func (c versionedConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	return &models.ConversionResult{Data: []byte(`{"version":"` + c.version + `"}`)}
+}
+
+func (c versionedConverter) SupportsFormat(format models.FileFormat) bool { return true }
+
+func registerVersions(t *testing.T, key string, versions ...ConverterInfo) {
+	t.Helper()
+	saved := SaveConverter(key)
+	t.Cleanup(func() { RestoreConverter(key, saved) })
+
+	for _, info := range versions {
+		RegisterConverterVersion(key, info, func() models.Converter { return versionedConverter{info.Version} })
+	}
+}
+
+func TestResolveConverterNegotiatesVersion(t *testing.T) {
+	UnregisterConverter("alpha-beta")
+	registerVersions(t, "alpha-beta",
+		ConverterInfo{Version: "1.4.0", Capabilities: []string{CapabilityStreaming}},
+		ConverterInfo{Version: "2.0.0"},
+		ConverterInfo{Version: "1.10.2"},
+	)
+
+	assert.Equal(t, []string{"2.0.0", "1.10.2", "1.4.0"}, versionsOf(ConverterVersions("alpha-beta")))
+
+	newest, err := ResolveConverter("alpha-beta", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", newest.Version)
+
+	minor, err := ResolveConverter("alpha-beta", &models.ConverterRequirement{Version: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.10.2", minor.Version)
+
+	streaming, err := ResolveConverter("alpha-beta", &models.ConverterRequirement{Capabilities: []string{CapabilityStreaming}})
+	require.NoError(t, err)
+	assert.Equal(t, "1.4.0", streaming.Version)
+
+	_, err = ResolveConverter("alpha-beta", &models.ConverterRequirement{Version: "2", Capabilities: []string{CapabilityStreaming}})
+	assert.EqualError(t, err, "no alpha-beta converter matches version 2 with capabilities streaming; available: 2.0.0, 1.10.2, 1.4.0 (streaming)")
+
+	pinned, err := NewConverterFactory().CreateConverter("alpha-beta@1.4.0")
+	require.NoError(t, err)
+	assert.Equal(t, versionedConverter{"1.4.0"}, pinned)
+
+	assert.Panics(t, func() { RegisterConverterVersion("alpha-beta", ConverterInfo{Version: "two"}, nil) })
+}
+
+func TestRegisterConverterReplacesEveryVersion(t *testing.T) {
+	registerVersions(t, "alpha-beta", ConverterInfo{Version: "3.0.0"})
+	RegisterConverter("alpha-beta", func() models.Converter { return versionedConverter{"plain"} })
+
+	assert.Equal(t, []string{BaseVersion}, versionsOf(ConverterVersions("alpha-beta")))
+}
+
+func TestPlanConversionDoesNotRankPairsByVersion(t *testing.T) {
+	for _, key := range []string{"pa-pb", "pb-pd", "pc-pd"} {
+		registerVersions(t, key, ConverterInfo{Version: "1.0.0"})
+	}
+	registerVersions(t, "pa-pc", ConverterInfo{Version: "2.0.0"})
+
+	steps, err := PlanConversion("pa", "pd")
+	require.NoError(t, err)
+	assert.Equal(t, []models.ConversionStep{{From: "pa", To: "pb"}, {From: "pb", To: "pd"}}, steps)
+}
+
+func TestManifestRecordsConverterVersions(t *testing.T) {
+	registerVersions(t, "csv-json", ConverterInfo{Version: "2.0.0"})
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	output := filepath.Join(dir, "people.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("id\n1\n"), 0644))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(output).
+		AddPinnedConversion(models.FormatCSV, models.FormatJSON, models.ConverterRequirement{Version: "1"}).
+		AddConversionStep(models.FormatJSON, models.FormatYAML).
+		WithManifest().
+		Build()
+	require.NoError(t, err)
+
+	result := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).Execute(pipeline)
+	require.NoError(t, result.Error)
+
+	m, _, err := manifest.Read(manifest.ManifestPath(output))
+	require.NoError(t, err)
+	assert.Equal(t, []manifest.Step{
+		{From: models.FormatCSV, To: models.FormatJSON, ConverterVersion: BaseVersion},
+		{From: models.FormatJSON, To: models.FormatYAML, ConverterVersion: BaseVersion},
+	}, m.Steps)
+
+	_, err = NewPipelineBuilder().
+		WithInputPath(input).
+		AddPinnedConversion(models.FormatCSV, models.FormatJSON, models.ConverterRequirement{Version: "3"}).
+		Build()
+	assert.ErrorContains(t, err, "step 1: no csv-json converter matches version 3")
+}
+
+func versionsOf(infos []ConverterInfo) []string {
+	versions := make([]string, len(infos))
+	for i, info := range infos {
+		versions[i] = info.Version
+	}
+	return versions
+}
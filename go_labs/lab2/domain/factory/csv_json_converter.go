@@ -4,18 +4,21 @@
 package factory
 
 import (
-	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 
+	"tmps-go-labs/lab2/domain/csvparse"
 	"tmps-go-labs/lab2/domain/models"
 )
 
-type CSVToJSONConverter struct{}
+type CSVToJSONConverter struct {
+	configured
+}
 
 func init() {
-	RegisterConverter("csv-json", func() models.Converter {
+	RegisterConverterVersion("csv-json", ConverterInfo{Version: BaseVersion, Capabilities: []string{CapabilityStreaming}}, func() models.Converter {
 		return &CSVToJSONConverter{}
 	})
 }
@@ -25,41 +28,75 @@ func (c *CSVToJSONConverter) Convert(input io.Reader, from, to models.FileFormat
 		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
 	}
 
-	reader := csv.NewReader(input)
-	records, err := reader.ReadAll()
+	read := c.readRows
+	if c.options.CSVParser == models.CSVParserParallel {
+		read = c.readTable
+	}
+	jsonData, err := read(input)
 	if err != nil {
 		return &models.ConversionResult{Error: fmt.Errorf("failed to read CSV: %w", err)}
 	}
 
-	if len(records) == 0 {
-		return &models.ConversionResult{
-			Data:   []byte("[]"),
-			Format: models.FormatJSON,
-		}
+	data, err := marshalJSON(jsonData, c.options)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to marshal JSON: %w", err)}
 	}
 
-	headers := records[0]
-	var jsonData []map[string]string
+	return &models.ConversionResult{
+		Data:   data,
+		Format: models.FormatJSON,
+	}
+}
 
-	for _, record := range records[1:] {
-		row := make(map[string]string)
-		for i, value := range record {
-			if i < len(headers) {
-				row[headers[i]] = value
-			}
+// readRows reads one record at a time. Values are copied into the rows, so
+// the reader can reuse one record slice for the whole input.
+func (c *CSVToJSONConverter) readRows(input io.Reader) ([]map[string]string, error) {
+	rows := []map[string]string{}
+	reader := newCSVReader(input, c.options)
+	headers, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return rows, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	headers = slices.Clone(headers)
+
+	reader.ReuseRecord = true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
 		}
-		jsonData = append(jsonData, row)
+		rows = append(rows, csvRow(headers, record))
 	}
+}
 
-	data, err := json.MarshalIndent(jsonData, "", "  ")
-	if err != nil {
-		return &models.ConversionResult{Error: fmt.Errorf("failed to marshal JSON: %w", err)}
+// readTable reads the whole table with the parser chosen in the options.
+func (c *CSVToJSONConverter) readTable(input io.Reader) ([]map[string]string, error) {
+	table, err := csvparse.ReadAll(input, c.options)
+	if err != nil || len(table) == 0 {
+		return []map[string]string{}, err
 	}
 
-	return &models.ConversionResult{
-		Data:   data,
-		Format: models.FormatJSON,
+	rows := make([]map[string]string, 0, len(table)-1)
+	for _, record := range table[1:] {
+		rows = append(rows, csvRow(table[0], record))
+	}
+	return rows, nil
+}
+
+func csvRow(headers, record []string) map[string]string {
+	row := make(map[string]string, len(headers))
+	for i, value := range record {
+		if i < len(headers) {
+			row[headers[i]] = value
+		}
 	}
+	return row
 }
 
 func (c *CSVToJSONConverter) SupportsFormat(format models.FileFormat) bool {
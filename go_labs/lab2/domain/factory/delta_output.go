@@ -0,0 +1,59 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bytes"
+	"fmt"
+
+	"tmps-go-labs/lab2/domain/delta"
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func checkDeltaOutput(pipeline *models.Pipeline) error {
+	if IsArchive(pipeline.InputPath) {
+		return fmt.Errorf("delta output is not supported for archive input")
+	}
+
+	format := pipeline.Steps[len(pipeline.Steps)-1].To
+	if _, ok := document.ParserFor(format); !ok {
+		return fmt.Errorf("delta output needs a parser for %s output", format)
+	}
+	if _, ok := document.RendererFor(format); !ok {
+		return fmt.Errorf("delta output needs a renderer for %s output", format)
+	}
+	return nil
+}
+
+// deltaOutput replaces the full output with the records that changed since
+// the base kept next to the output, and returns the base for the next run.
+// The caller saves it once the output is written.
+func deltaOutput(pipeline *models.Pipeline, data []byte) ([]byte, *delta.Base, error) {
+	format := pipeline.Steps[len(pipeline.Steps)-1].To
+	options := pipeline.Options.Delta
+
+	doc, err := document.Parse(bytes.NewReader(data), format, pipeline.Options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read output for delta: %w", err)
+	}
+
+	base, err := delta.LoadBase(delta.BasePath(pipeline.OutputPath))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes, next, err := delta.Compute(base, records.Find(doc.Root), options.Key, options.FullEvery)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute delta: %w", err)
+	}
+
+	doc.Root = changes
+	output, err := document.Render(doc, format, pipeline.Options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render delta: %w", err)
+	}
+	return output, next, nil
+}
@@ -0,0 +1,51 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaOutputAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	output := filepath.Join(dir, "people.ndjson")
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(output).
+		AddConversionStep("csv", "ndjson").
+		WithDeltaOutput("id", 0).
+		Build()
+	require.NoError(t, err)
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	run := func(csv string) string {
+		require.NoError(t, os.WriteFile(input, []byte(csv), 0644))
+		result := executor.Execute(pipeline)
+		require.NoError(t, result.Error)
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "{\"_op\":\"snapshot\",\"id\":\"1\",\"name\":\"ann\"}\n{\"_op\":\"snapshot\",\"id\":\"2\",\"name\":\"bob\"}\n",
+		run("id,name\n1,ann\n2,bob\n"))
+	assert.Equal(t, "{\"_op\":\"changed\",\"id\":\"2\",\"name\":\"rob\"}\n{\"_op\":\"removed\",\"id\":\"1\"}\n",
+		run("id,name\n2,rob\n"))
+	assert.Equal(t, "", run("id,name\n2,rob\n"))
+}
+
+func TestBuildRejectsDeltaWithoutParser(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.md").
+		AddConversionStep("csv", "json").
+		AddConversionStep("json", "markdown").
+		WithDeltaOutput("id", 0).
+		Build()
+	assert.EqualError(t, err, "delta output needs a parser for markdown output")
+}
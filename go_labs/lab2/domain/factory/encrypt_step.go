@@ -0,0 +1,62 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/encryption"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func checkFieldEncryption(step models.ConversionStep) error {
+	if len(step.Encrypt.Fields) == 0 {
+		return fmt.Errorf("field encryption needs at least one field")
+	}
+	if step.Encrypt.KeyEnv == "" && step.Encrypt.KeyFile == "" {
+		return fmt.Errorf("field encryption requires a key from an environment variable or file")
+	}
+	return nil
+}
+
+// applyFieldEncryption parses the input, encrypts or decrypts the selected
+// fields of every record and renders it back in the same format. Values
+// are encrypted as their text, so numbers and booleans come back from
+// decryption as strings; missing and null fields are left alone.
+func applyFieldEncryption(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	key, err := encryption.LoadKey(step.Encrypt.KeyEnv, step.Encrypt.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load field encryption key: %w", err)
+	}
+	fieldCipher, err := encryption.NewFieldCipher(key, step.Encrypt.Deterministic)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		for i, record := range records.Find(doc.Root) {
+			for _, field := range step.Encrypt.Fields {
+				value, ok := record[field]
+				if !ok || value == nil {
+					continue
+				}
+				text := fmt.Sprint(value)
+				var err error
+				if step.Encrypt.Decrypt {
+					text, err = fieldCipher.Decrypt(field, text)
+				} else {
+					text, err = fieldCipher.Encrypt(field, text)
+				}
+				if err != nil {
+					return fmt.Errorf("record %d, field %s: %w", i+1, field, err)
+				}
+				record[field] = text
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,62 @@
+package factory
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestFieldEncryptionStep(t *testing.T) {
+	t.Setenv("FIELD_KEY", hex.EncodeToString([]byte(strings.Repeat("k", 32))))
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	require.NoError(t, os.WriteFile(input, []byte("name,email\nann,a@example.com\nbob,a@example.com\n"), 0644))
+	encrypt := models.FieldEncryption{Fields: []string{"email"}, Deterministic: true, KeyEnv: "FIELD_KEY"}
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "encrypted.csv")).
+		AddFieldEncryption(models.FormatCSV, encrypt).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(pipeline).Error)
+
+	data, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	ann, bob := strings.Split(lines[1], ","), strings.Split(lines[2], ",")
+	assert.NotContains(t, string(data), "a@example.com")
+	assert.Equal(t, ann[0], bob[0], "equal values stay equal")
+	assert.Equal(t, []string{"ann", "bob"}, []string{ann[1], bob[1]}, "other fields stay readable")
+
+	encrypt.Decrypt = true
+	pipeline, err = NewPipelineBuilder().
+		WithInputPath(pipeline.OutputPath).
+		WithOutputPath(filepath.Join(dir, "decrypted.json")).
+		AddFieldEncryption(models.FormatCSV, encrypt).
+		AddConversionStep("csv", "json").
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(pipeline).Error)
+	data, err = os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name":"ann","email":"a@example.com"},{"name":"bob","email":"a@example.com"}]`, string(data))
+}
+
+func TestBuildRejectsFieldEncryptionWithoutKey(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.csv").
+		AddFieldEncryption(models.FormatCSV, models.FieldEncryption{Fields: []string{"email"}}).
+		Build()
+	assert.EqualError(t, err, "step 1: field encryption requires a key from an environment variable or file")
+}
@@ -0,0 +1,43 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestExecutorPublishesLifecycleEvents(t *testing.T) {
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.yaml").
+		AddCSVToJSON().
+		AddConversionStep(models.FormatJSON, models.FormatMarkdown).
+		AddConversionStep(models.FormatMarkdown, models.FormatYAML).
+		Build()
+	assert.NoError(t, err)
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	var names []string
+	var failed events.StepFailed
+	executor.Events().Subscribe(events.ObserverFunc(func(event events.Event) {
+		names = append(names, event.Name())
+		if event, ok := event.(events.StepFailed); ok {
+			failed = event
+		}
+	}))
+
+	_, result := executor.ConvertData(context.Background(), pipeline, []byte("name\nann\n"))
+	assert.Error(t, result.Error)
+	assert.Equal(t, []string{
+		"pipeline.started",
+		"step.started", "step.progress", "step.completed",
+		"step.started", "step.progress", "step.completed",
+		"step.started", "step.failed",
+		"pipeline.finished",
+	}, names)
+	assert.Equal(t, 2, failed.Index)
+}
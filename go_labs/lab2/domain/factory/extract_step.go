@@ -0,0 +1,69 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func compileExtract(extract *models.Extract) (*regexp.Regexp, error) {
+	if extract.Field == "" {
+		return nil, fmt.Errorf("extract needs a field")
+	}
+	pattern, err := regexp.Compile(extract.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("extract pattern: %w", err)
+	}
+	for _, name := range pattern.SubexpNames() {
+		if name != "" {
+			return pattern, nil
+		}
+	}
+	return nil, fmt.Errorf("extract pattern %q has no named groups, e.g. (?P<name>...)", extract.Pattern)
+}
+
+// applyExtract parses the input, expands the named groups of the pattern
+// matched against the field into fields of every record and renders the
+// records back in the same format. Groups that did not take part in the
+// match are set to an empty string, so every matched record gets the same
+// fields.
+func applyExtract(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	extract := step.Extract
+	pattern, err := compileExtract(extract)
+	if err != nil {
+		return nil, err
+	}
+
+	names := pattern.SubexpNames()
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		for i, record := range records.Find(doc.Root) {
+			value, ok := record[extract.Field]
+			var match []string
+			if ok && value != nil {
+				match = pattern.FindStringSubmatch(fmt.Sprint(value))
+			}
+			if match == nil {
+				if extract.Required {
+					return fmt.Errorf("record %d: %s %q does not match %s", i+1, extract.Field, fmt.Sprint(value), extract.Pattern)
+				}
+				continue
+			}
+			if extract.Remove {
+				delete(record, extract.Field)
+			}
+			for group, name := range names {
+				if name != "" {
+					record[extract.Prefix+name] = match[group]
+				}
+			}
+		}
+		return nil
+	})
+}
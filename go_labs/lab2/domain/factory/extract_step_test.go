@@ -0,0 +1,51 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestExtractStep(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "log.ndjson")
+	require.NoError(t, os.WriteFile(input, []byte(
+		`{"line":"GET /people 200"}`+"\n"+
+			`{"line":"POST /people"}`+"\n"+
+			`{"line":"garbage"}`+"\n"), 0644))
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	extract := models.Extract{Field: "line", Pattern: `^(?P<method>[A-Z]+) (?P<path>\S+)(?: (?P<status>\d+))?$`, Prefix: "http_", Remove: true}
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "requests.ndjson")).
+		AddExtract(models.FormatNDJSON, extract).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(pipeline).Error)
+	data, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"http_method":"GET","http_path":"/people","http_status":"200"}`+"\n"+
+			`{"http_method":"POST","http_path":"/people","http_status":""}`+"\n"+
+			`{"line":"garbage"}`+"\n",
+		string(data))
+
+	extract.Required = true
+	pipeline.Steps[0].Extract = &extract
+	assert.ErrorContains(t, executor.Execute(pipeline).Error, `step 1 extract failed (ndjson): record 3: line "garbage" does not match`)
+}
+
+func TestBuildRejectsExtractWithoutNamedGroups(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.csv").
+		AddExtract(models.FormatCSV, models.Extract{Field: "email", Pattern: `(\w+)@(.+)`}).
+		Build()
+	assert.EqualError(t, err, `step 1: extract pattern "(\\w+)@(.+)" has no named groups, e.g. (?P<name>...)`)
+}
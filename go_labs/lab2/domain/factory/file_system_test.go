@@ -0,0 +1,36 @@
+package factory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab3/vfs"
+)
+
+func TestExecuteInMemory(t *testing.T) {
+	files := vfs.NewMem(map[string][]byte{
+		"in/people.csv":          []byte("id\n1\n2\n3\n"),
+		"out/people-0003.ndjson": []byte("stale"),
+	})
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).WithFileSystem(files)
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in/people.csv").
+		WithOutputPath("out/people.ndjson").
+		AddConversionStep("csv", "ndjson").
+		WithSplit(2, 0).
+		Build()
+	require.NoError(t, err)
+
+	result := executor.Execute(pipeline)
+	require.NoError(t, result.Error)
+	assert.Equal(t, []string{"in/people.csv", "out/people-0001.ndjson", "out/people-0002.ndjson"}, files.Names())
+	data, err := vfs.ReadFile(files, "out/people-0002.ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, "{\"id\":\"3\"}\n", string(data))
+
+	pipeline.InputPath = "in/missing.csv"
+	assert.ErrorContains(t, executor.Execute(pipeline).Error, "failed to read input file: open in/missing.csv: file does not exist")
+}
@@ -0,0 +1,229 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+type FixedWidthToJSONConverter struct {
+	configured
+	columns []models.FixedWidthColumn
+}
+
+type ToFixedWidthConverter struct {
+	configured
+	columns []models.FixedWidthColumn
+}
+
+func init() {
+	RegisterConverter("fixedwidth-json", func() models.Converter {
+		return &FixedWidthToJSONConverter{}
+	})
+	RegisterConverter("json-fixedwidth", func() models.Converter {
+		return &ToFixedWidthConverter{}
+	})
+	RegisterConverter("csv-fixedwidth", func() models.Converter {
+		return &ToFixedWidthConverter{}
+	})
+}
+
+func (f *FixedWidthToJSONConverter) Configure(options models.ConversionOptions) {
+	f.configured.Configure(options)
+	f.columns = options.FixedWidthColumns
+}
+
+func (f *FixedWidthToJSONConverter) Reset() {
+	f.configured.Reset()
+	f.columns = nil
+}
+
+func (f *FixedWidthToJSONConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from != models.FormatFixedWidth || to != models.FormatJSON {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	if err := validateFixedWidthColumns(f.columns); err != nil {
+		return &models.ConversionResult{Error: err}
+	}
+
+	var jsonData []map[string]interface{}
+	scanner := bufio.NewScanner(input)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := []rune(strings.TrimRight(scanner.Text(), "\r"))
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		row := make(map[string]interface{})
+		for _, column := range f.columns {
+			start := min(column.Start-1, len(line))
+			end := min(start+column.Length, len(line))
+			value, err := parseFixedWidthValue(strings.TrimSpace(string(line[start:end])), column.Type)
+			if err != nil {
+				return &models.ConversionResult{
+					Error: fmt.Errorf("line %d, column %s: %w", lineNumber, column.Name, err),
+				}
+			}
+			row[column.Name] = value
+		}
+		jsonData = append(jsonData, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to read fixed-width data: %w", err)}
+	}
+
+	if jsonData == nil {
+		jsonData = make([]map[string]interface{}, 0)
+	}
+
+	data, err := marshalJSON(jsonData, f.options)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to marshal JSON: %w", err)}
+	}
+
+	return &models.ConversionResult{
+		Data:   data,
+		Format: models.FormatJSON,
+	}
+}
+
+func (f *FixedWidthToJSONConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == models.FormatFixedWidth || format == models.FormatJSON
+}
+
+func (f *ToFixedWidthConverter) Configure(options models.ConversionOptions) {
+	f.configured.Configure(options)
+	f.columns = options.FixedWidthColumns
+}
+
+func (f *ToFixedWidthConverter) Reset() {
+	f.configured.Reset()
+	f.columns = nil
+}
+
+func (f *ToFixedWidthConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if (from != models.FormatJSON && from != models.FormatCSV) || to != models.FormatFixedWidth {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	if err := validateFixedWidthColumns(f.columns); err != nil {
+		return &models.ConversionResult{Error: err}
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to read %s: %w", from, err)}
+	}
+
+	rows, err := records.DecodeWithOptions(data, from, f.options)
+	if err != nil {
+		return &models.ConversionResult{Error: err}
+	}
+
+	width := 0
+	for _, column := range f.columns {
+		width = max(width, column.Start-1+column.Length)
+	}
+
+	var out bytes.Buffer
+	for _, record := range rows {
+		line := []rune(strings.Repeat(" ", width))
+		for _, column := range f.columns {
+			copy(line[column.Start-1:], formatFixedWidthValue(record[column.Name], column))
+		}
+		out.WriteString(string(line))
+		out.WriteString("\n")
+	}
+
+	return &models.ConversionResult{
+		Data:   out.Bytes(),
+		Format: models.FormatFixedWidth,
+	}
+}
+
+func (f *ToFixedWidthConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == models.FormatJSON || format == models.FormatCSV || format == models.FormatFixedWidth
+}
+
+// MaxFixedWidthLine bounds where fixed-width columns may end, so a column
+// specification cannot make every output line allocate without limit.
+const MaxFixedWidthLine = 64 << 10
+
+func validateFixedWidthColumns(columns []models.FixedWidthColumn) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("fixed-width conversion requires a column specification")
+	}
+
+	sorted := append([]models.FixedWidthColumn(nil), columns...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	for i, column := range sorted {
+		if column.Name == "" || column.Start < 1 || column.Length < 1 {
+			return fmt.Errorf("invalid fixed-width column %q: start must be >= 1 and length >= 1", column.Name)
+		}
+		if column.Start > MaxFixedWidthLine || column.Length > MaxFixedWidthLine-column.Start+1 {
+			return fmt.Errorf("invalid fixed-width column %q: it ends past position %d", column.Name, MaxFixedWidthLine)
+		}
+		switch column.Type {
+		case "", "string", "integer", "float", "boolean":
+		default:
+			return fmt.Errorf("invalid fixed-width column %q: unknown type %q", column.Name, column.Type)
+		}
+		if i > 0 && sorted[i-1].Start+sorted[i-1].Length > column.Start {
+			return fmt.Errorf("fixed-width columns %q and %q overlap", sorted[i-1].Name, column.Name)
+		}
+	}
+	return nil
+}
+
+func parseFixedWidthValue(text, columnType string) (interface{}, error) {
+	if text == "" && columnType != "" && columnType != "string" {
+		return nil, nil
+	}
+
+	switch columnType {
+	case "integer":
+		return strconv.ParseInt(text, 10, 64)
+	case "float":
+		return strconv.ParseFloat(text, 64)
+	case "boolean":
+		return strconv.ParseBool(text)
+	default:
+		return text, nil
+	}
+}
+
+// formatFixedWidthValue pads numbers on the left and text on the right, and
+// truncates anything that does not fit the column.
+func formatFixedWidthValue(value interface{}, column models.FixedWidthColumn) []rune {
+	text := ""
+	if value != nil {
+		text = fmt.Sprint(value)
+	}
+
+	padding := column.Length - utf8.RuneCountInString(text)
+	if padding < 0 {
+		return []rune(text)[:column.Length]
+	}
+
+	if column.Type == "integer" || column.Type == "float" {
+		return []rune(strings.Repeat(" ", padding) + text)
+	}
+	return []rune(text + strings.Repeat(" ", padding))
+}
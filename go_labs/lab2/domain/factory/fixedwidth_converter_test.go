@@ -0,0 +1,46 @@
+package factory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+var fixedWidthColumns = []models.FixedWidthColumn{
+	{Name: "id", Start: 1, Length: 4, Type: "integer"},
+	{Name: "name", Start: 6, Length: 8},
+	{Name: "amount", Start: 14, Length: 7, Type: "float"},
+}
+
+func TestFixedWidthToJSON(t *testing.T) {
+	converter := &FixedWidthToJSONConverter{}
+	converter.Configure(models.ConversionOptions{FixedWidthColumns: fixedWidthColumns})
+	input := "0001 Alice     12.50\n0002 Bob      100.00\n"
+
+	result := converter.Convert(strings.NewReader(input), models.FormatFixedWidth, models.FormatJSON)
+
+	assert.NoError(t, result.Error)
+	assert.JSONEq(t, `[{"id":1,"name":"Alice","amount":12.5},{"id":2,"name":"Bob","amount":100}]`, string(result.Data))
+}
+
+func TestJSONToFixedWidth(t *testing.T) {
+	converter := &ToFixedWidthConverter{}
+	converter.Configure(models.ConversionOptions{FixedWidthColumns: fixedWidthColumns})
+	input := `[{"id":1,"name":"Alexandria","amount":12.5}]`
+
+	result := converter.Convert(strings.NewReader(input), models.FormatJSON, models.FormatFixedWidth)
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "   1 Alexandr   12.5\n", string(result.Data))
+}
+
+func TestFixedWidthRejectsOverlappingColumns(t *testing.T) {
+	err := validateFixedWidthColumns([]models.FixedWidthColumn{
+		{Name: "a", Start: 1, Length: 5},
+		{Name: "b", Start: 3, Length: 2},
+	})
+
+	assert.ErrorContains(t, err, "overlap")
+}
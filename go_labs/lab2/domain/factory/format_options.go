@@ -0,0 +1,50 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"encoding/csv"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// configured gives converters the pipeline options through Configurable so
+// every converter reads delimiters, indentation and roots the same way.
+type configured struct {
+	options models.ConversionOptions
+}
+
+func (c *configured) Configure(options models.ConversionOptions) {
+	c.options = options
+}
+
+func (c *configured) Reset() {
+	c.options = models.ConversionOptions{}
+}
+
+func newCSVReader(input io.Reader, options models.ConversionOptions) *csv.Reader {
+	reader := csv.NewReader(input)
+	reader.Comma = options.Delimiter()
+	return reader
+}
+
+func newCSVWriter(output io.Writer, options models.ConversionOptions) *csv.Writer {
+	writer := csv.NewWriter(output)
+	writer.Comma = options.Delimiter()
+	return writer
+}
+
+func marshalJSON(v interface{}, options models.ConversionOptions) ([]byte, error) {
+	return document.MarshalJSON(v, options)
+}
+
+func marshalXML(v interface{}, options models.ConversionOptions) ([]byte, error) {
+	return document.MarshalXML(v, options)
+}
+
+func marshalYAML(v interface{}, options models.ConversionOptions) ([]byte, error) {
+	return document.MarshalYAML(v, options)
+}
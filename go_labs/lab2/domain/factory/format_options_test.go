@@ -0,0 +1,49 @@
+package factory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func convertWith(t *testing.T, converter models.Converter, input string, from, to models.FileFormat, options ...models.Option) string {
+	converter.(models.Configurable).Configure(models.NewOptions(options...))
+	result := converter.Convert(strings.NewReader(input), from, to)
+	assert.NoError(t, result.Error)
+	return string(result.Data)
+}
+
+func TestFunctionalOptionsReachConverters(t *testing.T) {
+	output := convertWith(t, &CSVToJSONConverter{}, "name;city\nann;oslo\n", models.FormatCSV, models.FormatJSON,
+		models.WithCSVDelimiter(';'), models.WithIndentWidth(0))
+	assert.Equal(t, `[{"city":"oslo","name":"ann"}]`, output)
+
+	output = convertWith(t, &JSONToXMLConverter{}, `{"name":"ann"}`, models.FormatJSON, models.FormatXML,
+		models.WithXMLRoot("items"), models.WithIndentWidth(4))
+	assert.Equal(t, "<items>\n    <name>ann</name>\n</items>", output)
+
+	output = convertWith(t, &CSVToJSONConverter{}, "name\nann\n", models.FormatCSV, models.FormatJSON)
+	assert.Equal(t, "[\n  {\n    \"name\": \"ann\"\n  }\n]", output)
+}
+
+func TestIndentWidthIsBounded(t *testing.T) {
+	build := func(width int) error {
+		_, err := NewPipelineBuilder().WithInputPath("in.csv").WithOutputPath("out.json").
+			AddCSVToJSON().Apply(models.WithIndentWidth(width)).Build()
+		return err
+	}
+	assert.NoError(t, build(16))
+	assert.EqualError(t, build(-1), "indent width -1 is outside 0..16")
+	assert.EqualError(t, build(17), "indent width 17 is outside 0..16")
+
+	pipeline, err := NewPipelineBuilder().WithInputPath("in.csv").WithOutputPath("out.json").AddCSVToJSON().WithIndent().Build()
+	assert.NoError(t, err)
+	indent, set := pipeline.Options.Indentation()
+	assert.Equal(t, "  ", indent)
+	assert.True(t, set, "WithIndent chooses the default width")
+	assert.Equal(t, models.DefaultIndentWidth, *pipeline.Options.IndentWidth)
+	assert.False(t, pipeline.Options.Indent)
+}
@@ -0,0 +1,314 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/csvparse"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+var (
+	latitudeNames  = []string{"lat", "latitude", "y"}
+	longitudeNames = []string{"lon", "lng", "long", "longitude", "x"}
+)
+
+type GeoJSONToCSVConverter struct {
+	configured
+	geo models.GeoOptions
+}
+
+type CSVToGeoJSONConverter struct {
+	configured
+	geo models.GeoOptions
+}
+
+type geoFeatureCollection struct {
+	Type     string       `json:"type"`
+	Features []geoFeature `json:"features"`
+}
+
+type geoFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *geoGeometry           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func init() {
+	RegisterConverter("geojson-csv", func() models.Converter {
+		return &GeoJSONToCSVConverter{}
+	})
+	RegisterConverter("csv-geojson", func() models.Converter {
+		return &CSVToGeoJSONConverter{}
+	})
+}
+
+func (g *GeoJSONToCSVConverter) Configure(options models.ConversionOptions) {
+	g.configured.Configure(options)
+	g.geo = options.Geo
+}
+
+func (g *GeoJSONToCSVConverter) Reset() {
+	g.configured.Reset()
+	g.geo = models.GeoOptions{}
+}
+
+func (g *GeoJSONToCSVConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from != models.FormatGeoJSON || to != models.FormatCSV {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	var collection geoFeatureCollection
+	decoder := json.NewDecoder(input)
+	decoder.UseNumber()
+	if err := decoder.Decode(&collection); err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to parse GeoJSON: %w", err)}
+	}
+	for _, feature := range collection.Features {
+		values.Numbers(feature.Properties)
+	}
+	if collection.Type != "FeatureCollection" {
+		return &models.ConversionResult{Error: fmt.Errorf("expected FeatureCollection, got %q", collection.Type)}
+	}
+
+	latColumn := valueOr(g.geo.LatColumn, "lat")
+	lonColumn := valueOr(g.geo.LonColumn, "lon")
+
+	propertySet := make(map[string]bool)
+	for _, feature := range collection.Features {
+		for key := range feature.Properties {
+			if key != latColumn && key != lonColumn {
+				propertySet[key] = true
+			}
+		}
+	}
+	properties := make([]string, 0, len(propertySet))
+	for key := range propertySet {
+		properties = append(properties, key)
+	}
+	sort.Strings(properties)
+
+	var out bytes.Buffer
+	writer := newCSVWriter(&out, g.options)
+	if err := writer.Write(append([]string{latColumn, lonColumn}, properties...)); err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to write CSV: %w", err)}
+	}
+
+	for i, feature := range collection.Features {
+		lat, lon, err := g.pointOf(feature)
+		if err != nil {
+			if g.geo.SkipInvalid {
+				continue
+			}
+			return &models.ConversionResult{Error: fmt.Errorf("feature %d: %w", i, err)}
+		}
+
+		row := []string{formatCoordinate(lat), formatCoordinate(lon)}
+		for _, key := range properties {
+			row = append(row, stringifyProperty(feature.Properties[key]))
+		}
+		if err := writer.Write(row); err != nil {
+			return &models.ConversionResult{Error: fmt.Errorf("failed to write CSV: %w", err)}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to write CSV: %w", err)}
+	}
+
+	return &models.ConversionResult{
+		Data:   out.Bytes(),
+		Format: models.FormatCSV,
+	}
+}
+
+func (g *GeoJSONToCSVConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == models.FormatGeoJSON || format == models.FormatCSV
+}
+
+func (g *GeoJSONToCSVConverter) pointOf(feature geoFeature) (float64, float64, error) {
+	if feature.Type != "Feature" {
+		return 0, 0, fmt.Errorf("expected Feature, got %q", feature.Type)
+	}
+	if feature.Geometry == nil {
+		return 0, 0, fmt.Errorf("missing geometry")
+	}
+	if feature.Geometry.Type != "Point" {
+		return 0, 0, fmt.Errorf("unsupported geometry type %q, only Point maps to lat/lon columns", feature.Geometry.Type)
+	}
+
+	var position []float64
+	if err := json.Unmarshal(feature.Geometry.Coordinates, &position); err != nil || len(position) < 2 {
+		return 0, 0, fmt.Errorf("invalid Point coordinates")
+	}
+
+	// GeoJSON positions are [longitude, latitude]
+	lon, lat := position[0], position[1]
+	if err := validatePosition(lat, lon, g.geo.Validate); err != nil {
+		return 0, 0, err
+	}
+	return lat, lon, nil
+}
+
+func (c *CSVToGeoJSONConverter) Configure(options models.ConversionOptions) {
+	c.configured.Configure(options)
+	c.geo = options.Geo
+}
+
+func (c *CSVToGeoJSONConverter) Reset() {
+	c.configured.Reset()
+	c.geo = models.GeoOptions{}
+}
+
+func (c *CSVToGeoJSONConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from != models.FormatCSV || to != models.FormatGeoJSON {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	table, err := csvparse.ReadAll(input, c.options)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to read CSV: %w", err)}
+	}
+
+	collection := geoFeatureCollection{Type: "FeatureCollection", Features: make([]geoFeature, 0)}
+
+	if len(table) > 0 {
+		headers := table[0]
+		latIndex := findColumn(headers, c.geo.LatColumn, latitudeNames)
+		lonIndex := findColumn(headers, c.geo.LonColumn, longitudeNames)
+		if latIndex < 0 || lonIndex < 0 {
+			return &models.ConversionResult{Error: fmt.Errorf("CSV must contain latitude and longitude columns")}
+		}
+
+		for i, record := range table[1:] {
+			feature, err := c.featureOf(headers, record, latIndex, lonIndex)
+			if err != nil {
+				if c.geo.SkipInvalid {
+					continue
+				}
+				return &models.ConversionResult{Error: fmt.Errorf("row %d: %w", i+2, err)}
+			}
+			collection.Features = append(collection.Features, feature)
+		}
+	}
+
+	data, err := marshalJSON(collection, c.options)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to marshal GeoJSON: %w", err)}
+	}
+
+	return &models.ConversionResult{
+		Data:   data,
+		Format: models.FormatGeoJSON,
+	}
+}
+
+func (c *CSVToGeoJSONConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == models.FormatCSV || format == models.FormatGeoJSON
+}
+
+func (c *CSVToGeoJSONConverter) featureOf(headers, record []string, latIndex, lonIndex int) (geoFeature, error) {
+	if latIndex >= len(record) || lonIndex >= len(record) {
+		return geoFeature{}, fmt.Errorf("missing coordinates")
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(record[latIndex]), 64)
+	if err != nil {
+		return geoFeature{}, fmt.Errorf("invalid latitude %q", record[latIndex])
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(record[lonIndex]), 64)
+	if err != nil {
+		return geoFeature{}, fmt.Errorf("invalid longitude %q", record[lonIndex])
+	}
+	if err := validatePosition(lat, lon, c.geo.Validate); err != nil {
+		return geoFeature{}, err
+	}
+
+	coordinates, err := json.Marshal([]float64{lon, lat})
+	if err != nil {
+		return geoFeature{}, err
+	}
+
+	properties := make(map[string]interface{})
+	for i, value := range record {
+		if i != latIndex && i != lonIndex && i < len(headers) {
+			properties[headers[i]] = value
+		}
+	}
+
+	return geoFeature{
+		Type:       "Feature",
+		Geometry:   &geoGeometry{Type: "Point", Coordinates: coordinates},
+		Properties: properties,
+	}, nil
+}
+
+func validatePosition(lat, lon float64, strict bool) error {
+	if math.IsNaN(lat) || math.IsNaN(lon) || math.IsInf(lat, 0) || math.IsInf(lon, 0) {
+		return fmt.Errorf("coordinates must be finite numbers")
+	}
+	if strict && (lat < -90 || lat > 90) {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", lat)
+	}
+	if strict && (lon < -180 || lon > 180) {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", lon)
+	}
+	return nil
+}
+
+func findColumn(headers []string, configured string, candidates []string) int {
+	if configured != "" {
+		candidates = []string{configured}
+	}
+	for _, candidate := range candidates {
+		for i, header := range headers {
+			if strings.EqualFold(strings.TrimSpace(header), candidate) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func stringifyProperty(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return formatCoordinate(v)
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
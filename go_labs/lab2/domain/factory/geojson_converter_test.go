@@ -0,0 +1,39 @@
+package factory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestCSVToGeoJSON(t *testing.T) {
+	converter := &CSVToGeoJSONConverter{}
+	input := "name,Latitude,Longitude\nChisinau,47.01,28.86\n"
+
+	result := converter.Convert(strings.NewReader(input), models.FormatCSV, models.FormatGeoJSON)
+
+	assert.NoError(t, result.Error)
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[{"type":"Feature",
+		"geometry":{"type":"Point","coordinates":[28.86,47.01]},
+		"properties":{"name":"Chisinau"}}]}`, string(result.Data))
+}
+
+func TestGeoJSONToCSVValidation(t *testing.T) {
+	input := `{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[28.86,47.01]},"properties":{"name":"Chisinau","pop":640000}},
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[200,47]},"properties":{"name":"Nowhere"}},
+		{"type":"Feature","geometry":{"type":"LineString","coordinates":[[0,0],[1,1]]},"properties":{}}]}`
+
+	strict := &GeoJSONToCSVConverter{}
+	strict.Configure(models.ConversionOptions{Geo: models.GeoOptions{Validate: true}})
+	result := strict.Convert(strings.NewReader(input), models.FormatGeoJSON, models.FormatCSV)
+	assert.ErrorContains(t, result.Error, "longitude 200 out of range")
+
+	lenient := &GeoJSONToCSVConverter{}
+	lenient.Configure(models.ConversionOptions{Geo: models.GeoOptions{Validate: true, SkipInvalid: true}})
+	result = lenient.Convert(strings.NewReader(input), models.FormatGeoJSON, models.FormatCSV)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "lat,lon,name,pop\n47.01,28.86,Chisinau,640000\n", string(result.Data))
+}
@@ -4,15 +4,17 @@
 package factory
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 
-	"github.com/clbanning/mxj/v2"
+	"tmps-go-labs/lab2/domain/buffers"
 	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/values"
 )
 
-type JSONToXMLConverter struct{}
+type JSONToXMLConverter struct {
+	configured
+}
 
 func init() {
 	RegisterConverter("json-xml", func() models.Converter {
@@ -26,20 +28,20 @@ func (j *JSONToXMLConverter) Convert(input io.Reader, from, to models.FileFormat
 	}
 
 	// Read JSON data
-	jsonData, err := io.ReadAll(input)
+	jsonData, err := buffers.ReadAll(input)
 	if err != nil {
 		return &models.ConversionResult{Error: fmt.Errorf("failed to read JSON: %w", err)}
 	}
+	defer buffers.Put(jsonData)
 
 	// Parse JSON into generic interface
-	var data interface{}
-	if err := json.Unmarshal(jsonData, &data); err != nil {
+	data, err := values.UnmarshalJSON(jsonData.Bytes(), j.options.Lossless())
+	if err != nil {
 		return &models.ConversionResult{Error: fmt.Errorf("failed to parse JSON: %w", err)}
 	}
 
 	// Convert to XML using mxj library
-	mv := mxj.Map{"root": data}
-	xmlData, err := mv.XmlIndent("", "  ")
+	xmlData, err := marshalXML(data, j.options)
 	if err != nil {
 		return &models.ConversionResult{Error: fmt.Errorf("failed to convert to XML: %w", err)}
 	}
@@ -0,0 +1,52 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/lookup"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func checkLookup(step models.ConversionStep) error {
+	if step.Lookup.Path == "" || step.Lookup.Key == "" {
+		return fmt.Errorf("lookup needs a table path and a key column")
+	}
+	format, err := lookup.Format(step.Lookup.Path, step.Lookup.Format)
+	if err != nil {
+		return err
+	}
+	if _, ok := document.ParserFor(format); !ok {
+		return fmt.Errorf("lookup needs a parser for %s tables", format)
+	}
+	return nil
+}
+
+// applyLookup loads the lookup table, adds the columns of the matching row
+// to every record and renders the records back in the same format. The
+// table is read on every run, so edits to it apply to the next run.
+func applyLookup(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	spec := step.Lookup
+	table, err := lookup.Load(spec.Path, spec.Format, spec.Key, options)
+	if err != nil {
+		return nil, err
+	}
+	field := spec.Field
+	if field == "" {
+		field = spec.Key
+	}
+
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		for i, record := range records.Find(doc.Root) {
+			if !table.Enrich(record, field, spec.Columns, spec.Prefix) && spec.Required {
+				return fmt.Errorf("record %d: no row in %s for %s %v", i+1, spec.Path, field, record[field])
+			}
+		}
+		return nil
+	})
+}
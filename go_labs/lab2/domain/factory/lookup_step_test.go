@@ -0,0 +1,41 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestLookupStep(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	countries := filepath.Join(dir, "countries.csv")
+	require.NoError(t, os.WriteFile(input, []byte("name,country\nann,MD\nbob,FR\n"), 0644))
+	require.NoError(t, os.WriteFile(countries, []byte("code,name\nMD,Moldova\nRO,Romania\n"), 0644))
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	lookup := models.Lookup{Path: countries, Key: "code", Field: "country", Columns: []string{"name"}, Prefix: "country_"}
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "people.json")).
+		AddLookup(models.FormatCSV, lookup).
+		AddConversionStep("csv", "json").
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(pipeline).Error)
+	data, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name": "ann", "country": "MD", "country_name": "Moldova"},
+		{"name": "bob", "country": "FR", "country_name": ""}
+	]`, string(data), "CSV gives unmatched records an empty column")
+
+	lookup.Required = true
+	pipeline.Steps[0].Lookup = &lookup
+	assert.ErrorContains(t, executor.Execute(pipeline).Error, "record 2: no row in "+countries+" for country FR")
+}
@@ -0,0 +1,246 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"tmps-go-labs/lab2/domain/csvparse"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+type TableToMarkdownConverter struct {
+	configured
+}
+
+type MarkdownToJSONConverter struct {
+	configured
+}
+
+func init() {
+	RegisterConverter("csv-markdown", func() models.Converter {
+		return &TableToMarkdownConverter{}
+	})
+	RegisterConverter("json-markdown", func() models.Converter {
+		return &TableToMarkdownConverter{}
+	})
+	RegisterConverter("markdown-json", func() models.Converter {
+		return &MarkdownToJSONConverter{}
+	})
+}
+
+func (m *TableToMarkdownConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if (from != models.FormatCSV && from != models.FormatJSON) || to != models.FormatMarkdown {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to read %s: %w", from, err)}
+	}
+
+	var headers []string
+	var rows [][]string
+
+	if from == models.FormatCSV {
+		// Read CSV directly so the column order of the header is preserved
+		table, err := csvparse.ReadAll(bytes.NewReader(data), m.options)
+		if err != nil {
+			return &models.ConversionResult{Error: fmt.Errorf("failed to read CSV: %w", err)}
+		}
+		if len(table) > 0 {
+			headers, rows = table[0], table[1:]
+		}
+	} else {
+		decoded, err := records.DecodeWithOptions(data, from, m.options)
+		if err != nil {
+			return &models.ConversionResult{Error: err}
+		}
+		headers = records.Columns(decoded)
+		for _, record := range decoded {
+			row := make([]string, len(headers))
+			for i, name := range headers {
+				if value, ok := record[name]; ok && value != nil {
+					row[i] = fmt.Sprint(value)
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return &models.ConversionResult{
+		Data:   renderMarkdownTable(headers, rows),
+		Format: models.FormatMarkdown,
+	}
+}
+
+func (m *TableToMarkdownConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == models.FormatCSV || format == models.FormatJSON || format == models.FormatMarkdown
+}
+
+func (m *MarkdownToJSONConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from != models.FormatMarkdown || to != models.FormatJSON {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	headers, rows, err := parseMarkdownTable(input)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to parse Markdown table: %w", err)}
+	}
+
+	jsonData := make([]map[string]string, 0, len(rows))
+	for _, cells := range rows {
+		row := make(map[string]string)
+		for i, value := range cells {
+			if i < len(headers) {
+				row[headers[i]] = value
+			}
+		}
+		jsonData = append(jsonData, row)
+	}
+
+	data, err := marshalJSON(jsonData, m.options)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to marshal JSON: %w", err)}
+	}
+
+	return &models.ConversionResult{
+		Data:   data,
+		Format: models.FormatJSON,
+	}
+}
+
+func (m *MarkdownToJSONConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == models.FormatMarkdown || format == models.FormatJSON
+}
+
+func renderMarkdownTable(headers []string, rows [][]string) []byte {
+	if len(headers) == 0 {
+		return []byte{}
+	}
+
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = max(3, utf8.RuneCountInString(escapeMarkdownCell(header)))
+	}
+	for _, row := range rows {
+		for i := range headers {
+			if i < len(row) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(escapeMarkdownCell(row[i])))
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	writeMarkdownRow(&out, headers, widths)
+
+	separator := make([]string, len(headers))
+	for i, width := range widths {
+		separator[i] = strings.Repeat("-", width)
+	}
+	writeMarkdownRow(&out, separator, widths)
+
+	for _, row := range rows {
+		writeMarkdownRow(&out, row, widths)
+	}
+	return out.Bytes()
+}
+
+func writeMarkdownRow(out *bytes.Buffer, cells []string, widths []int) {
+	out.WriteString("|")
+	for i, width := range widths {
+		cell := ""
+		if i < len(cells) {
+			cell = escapeMarkdownCell(cells[i])
+		}
+		fmt.Fprintf(out, " %s%s |", cell, strings.Repeat(" ", width-utf8.RuneCountInString(cell)))
+	}
+	out.WriteString("\n")
+}
+
+func escapeMarkdownCell(cell string) string {
+	cell = strings.ReplaceAll(cell, "|", `\|`)
+	return strings.ReplaceAll(cell, "\n", "<br>")
+}
+
+// parseMarkdownTable is best-effort: it reads the first pipe table in the
+// document, skipping surrounding prose and the alignment row.
+func parseMarkdownTable(input io.Reader) ([]string, [][]string, error) {
+	scanner := bufio.NewScanner(input)
+	var headers []string
+	var rows [][]string
+	inTable := false
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "|") {
+			if inTable {
+				break
+			}
+			continue
+		}
+
+		cells := splitMarkdownRow(line)
+		switch {
+		case !inTable:
+			headers = cells
+			inTable = true
+		case isMarkdownSeparator(cells):
+			continue
+		default:
+			rows = append(rows, cells)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if headers == nil {
+		return nil, nil, fmt.Errorf("no table found")
+	}
+	return headers, rows, nil
+}
+
+func splitMarkdownRow(line string) []string {
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	cells = append(cells, strings.TrimSpace(cell.String()))
+
+	for i, c := range cells {
+		cells[i] = strings.ReplaceAll(c, "<br>", "\n")
+	}
+	return cells
+}
+
+func isMarkdownSeparator(cells []string) bool {
+	for _, cell := range cells {
+		if strings.Trim(cell, ":-") != "" || !strings.Contains(cell, "-") {
+			return false
+		}
+	}
+	return true
+}
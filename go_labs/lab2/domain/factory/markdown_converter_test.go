@@ -0,0 +1,28 @@
+package factory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestCSVToMarkdown(t *testing.T) {
+	converter := &TableToMarkdownConverter{}
+
+	result := converter.Convert(strings.NewReader("name,note\nann,a|b\n"), models.FormatCSV, models.FormatMarkdown)
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "| name | note |\n| ---- | ---- |\n| ann  | a\\|b |\n", string(result.Data))
+}
+
+func TestMarkdownToJSON(t *testing.T) {
+	converter := &MarkdownToJSONConverter{}
+	input := "Intro text\n\n| name | note |\n|:-----|-----:|\n| ann | a\\|b |\n\nAfter"
+
+	result := converter.Convert(strings.NewReader(input), models.FormatMarkdown, models.FormatJSON)
+
+	assert.NoError(t, result.Error)
+	assert.JSONEq(t, `[{"name":"ann","note":"a|b"}]`, string(result.Data))
+}
@@ -0,0 +1,357 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Middleware wraps a converter created for the given "from-to" key. The
+// returned converter must delegate to next for the actual conversion.
+type Middleware func(key string, next models.Converter) models.Converter
+
+type middlewareFactory struct {
+	ConverterFactory
+	middleware []Middleware
+}
+
+// WithMiddleware decorates every converter the factory creates. The first
+// middleware is the outermost, so it sees the call first and the result last.
+// A pool built on the returned factory hands out already-wrapped converters.
+func WithMiddleware(factory ConverterFactory, middleware ...Middleware) ConverterFactory {
+	return &middlewareFactory{ConverterFactory: factory, middleware: middleware}
+}
+
+func (f *middlewareFactory) CreateConverter(formatType string) (models.Converter, error) {
+	converter, err := f.ConverterFactory.CreateConverter(formatType)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(f.middleware) - 1; i >= 0; i-- {
+		converter = f.middleware[i](formatType, converter)
+	}
+	return converter, nil
+}
+
+// ConverterFunc adapts a function to a wrapping converter. Decorated
+// converters keep receiving pipeline options through Configure, and the
+// step's context through ConvertContext; Convert runs Func under
+// context.Background().
+type ConverterFunc struct {
+	Next models.Converter
+	Func func(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult
+}
+
+func (c *ConverterFunc) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	return c.Func(context.Background(), input, from, to)
+}
+
+func (c *ConverterFunc) ConvertContext(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	return c.Func(ctx, input, from, to)
+}
+
+func (c *ConverterFunc) SupportsFormat(format models.FileFormat) bool {
+	return c.Next.SupportsFormat(format)
+}
+
+func (c *ConverterFunc) Configure(options models.ConversionOptions) {
+	if configurable, ok := c.Next.(models.Configurable); ok {
+		configurable.Configure(options)
+	}
+}
+
+func (c *ConverterFunc) Reset() {
+	if resettable, ok := c.Next.(models.Resettable); ok {
+		resettable.Reset()
+	}
+}
+
+// StreamConverterFunc is a ConverterFunc around a streaming converter. It
+// keeps streaming through Stream, so the streaming strategy does not fall
+// back to buffering the step.
+type StreamConverterFunc struct {
+	ConverterFunc
+	Stream func(input io.Reader, output io.Writer, from, to models.FileFormat) error
+}
+
+func (c *StreamConverterFunc) ConvertStream(input io.Reader, output io.Writer, from, to models.FileFormat) error {
+	return c.Stream(input, output, from, to)
+}
+
+// streamFunc builds the Stream of a middleware around a streaming next.
+type streamFunc func(next models.StreamConverter) func(input io.Reader, output io.Writer, from, to models.FileFormat) error
+
+// decorate wraps next in convert, and in stream too when next streams. A
+// nil stream makes the wrapper buffer, for middleware that needs the whole
+// output.
+func decorate(next models.Converter, convert func(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult, stream streamFunc) models.Converter {
+	wrapped := ConverterFunc{Next: next, Func: convert}
+	if streamer, ok := next.(models.StreamConverter); ok && stream != nil {
+		return &StreamConverterFunc{ConverterFunc: wrapped, Stream: stream(streamer)}
+	}
+	return &wrapped
+}
+
+// convertNext runs next under ctx when it takes a context.
+func convertNext(ctx context.Context, next models.Converter, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if contextual, ok := next.(models.ContextConverter); ok {
+		return contextual.ConvertContext(ctx, input, from, to)
+	}
+	return next.Convert(input, from, to)
+}
+
+// Logging logs every conversion with its duration and sizes, at info
+// level, and failed ones as warnings.
+func Logging(logger *slog.Logger) Middleware {
+	return func(key string, next models.Converter) models.Converter {
+		report := func(start time.Time, in, out int64, err error) {
+			if err != nil {
+				logger.Warn("conversion failed", "converter", key, "duration", time.Since(start), "error", err)
+				return
+			}
+			logger.Info("conversion finished", "converter", key, "input_bytes", in, "output_bytes", out, "duration", time.Since(start))
+		}
+		return decorate(next, func(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+			counted := &countingReader{reader: input}
+			start := time.Now()
+			result := convertNext(ctx, next, counted, from, to)
+			report(start, counted.n, int64(len(result.Data)), result.Error)
+			return result
+		}, func(next models.StreamConverter) func(io.Reader, io.Writer, models.FileFormat, models.FileFormat) error {
+			return func(input io.Reader, output io.Writer, from, to models.FileFormat) error {
+				counted, written := &countingReader{reader: input}, &countingWriter{w: output}
+				start := time.Now()
+				err := next.ConvertStream(counted, written, from, to)
+				report(start, counted.n, int64(written.n), err)
+				return err
+			}
+		})
+	}
+}
+
+// Timing reports the duration of every conversion to record, e.g. to feed a
+// metrics histogram.
+func Timing(record func(key string, duration time.Duration, err error)) Middleware {
+	return func(key string, next models.Converter) models.Converter {
+		return decorate(next, func(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+			start := time.Now()
+			result := convertNext(ctx, next, input, from, to)
+			record(key, time.Since(start), result.Error)
+			return result
+		}, func(next models.StreamConverter) func(io.Reader, io.Writer, models.FileFormat, models.FileFormat) error {
+			return func(input io.Reader, output io.Writer, from, to models.FileFormat) error {
+				start := time.Now()
+				err := next.ConvertStream(input, output, from, to)
+				record(key, time.Since(start), err)
+				return err
+			}
+		})
+	}
+}
+
+var ErrInputTooLarge = errors.New("input exceeds size limit")
+
+// SizeLimit rejects inputs larger than maxBytes. It reads at most one byte
+// past the limit, and a streaming converter fails once it reads that far.
+func SizeLimit(maxBytes int64) Middleware {
+	return func(key string, next models.Converter) models.Converter {
+		return decorate(next, func(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+			data, err := io.ReadAll(io.LimitReader(input, maxBytes+1))
+			if err != nil {
+				return &models.ConversionResult{Error: fmt.Errorf("failed to read %s: %w", from, err)}
+			}
+			if int64(len(data)) > maxBytes {
+				return &models.ConversionResult{Error: fmt.Errorf("%w: more than %d bytes", ErrInputTooLarge, maxBytes)}
+			}
+			return convertNext(ctx, next, bytes.NewReader(data), from, to)
+		}, func(next models.StreamConverter) func(io.Reader, io.Writer, models.FileFormat, models.FileFormat) error {
+			return func(input io.Reader, output io.Writer, from, to models.FileFormat) error {
+				return next.ConvertStream(&limitedReader{reader: input, left: maxBytes}, output, from, to)
+			}
+		})
+	}
+}
+
+// limitedReader fails with ErrInputTooLarge once more than left bytes are
+// read.
+type limitedReader struct {
+	reader io.Reader
+	left   int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.left < 0 {
+		return 0, fmt.Errorf("%w: input too long", ErrInputTooLarge)
+	}
+	if int64(len(p)) > r.left+1 {
+		p = p[:r.left+1]
+	}
+	n, err := r.reader.Read(p)
+	r.left -= int64(n)
+	if r.left < 0 {
+		return n, fmt.Errorf("%w: input too long", ErrInputTooLarge)
+	}
+	return n, err
+}
+
+// ValidateOutput checks that JSON, XML and YAML output is well-formed, so a
+// faulty converter fails its step instead of corrupting the next one. It
+// needs the whole output, so wrapped converters no longer stream.
+func ValidateOutput() Middleware {
+	return func(key string, next models.Converter) models.Converter {
+		return decorate(next, func(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+			result := convertNext(ctx, next, input, from, to)
+			if result.Error == nil {
+				if err := validateDocument(result.Data, to); err != nil {
+					result.Error = fmt.Errorf("%s produced invalid %s: %w", key, to, err)
+				}
+			}
+			return result
+		}, nil)
+	}
+}
+
+func validateDocument(data []byte, format models.FileFormat) error {
+	switch format {
+	case models.FormatJSON, models.FormatGeoJSON:
+		if !json.Valid(data) {
+			return errors.New("malformed JSON")
+		}
+	case models.FormatXML:
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+		}
+	case models.FormatYAML:
+		var doc interface{}
+		return yaml.Unmarshal(data, &doc)
+	}
+	return nil
+}
+
+// Cache remembers up to size successful results, keyed by conversion,
+// options and input hash. The cache is shared by every converter the
+// factory creates.
+func Cache(size int) Middleware {
+	cache := &resultCache{size: size, entries: make(map[[32]byte]*list.Element), order: list.New()}
+	return func(key string, next models.Converter) models.Converter {
+		cached := &cachingConverter{cache: cache, key: key}
+		cached.ConverterFunc = ConverterFunc{Next: next, Func: cached.convert}
+		return cached
+	}
+}
+
+type cachingConverter struct {
+	ConverterFunc
+	cache   *resultCache
+	key     string
+	options []byte
+}
+
+func (c *cachingConverter) Configure(options models.ConversionOptions) {
+	c.options, _ = json.Marshal(options)
+	c.ConverterFunc.Configure(options)
+}
+
+func (c *cachingConverter) Reset() {
+	c.options = nil
+	c.ConverterFunc.Reset()
+}
+
+func (c *cachingConverter) convert(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to read %s: %w", from, err)}
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00", c.key, c.options)
+	hash.Write(data)
+	var id [32]byte
+	copy(id[:], hash.Sum(nil))
+
+	if result, ok := c.cache.get(id); ok {
+		return result
+	}
+
+	result := convertNext(ctx, c.Next, bytes.NewReader(data), from, to)
+	if result.Error == nil {
+		c.cache.put(id, result)
+	}
+	return result
+}
+
+type cacheEntry struct {
+	id     [32]byte
+	result models.ConversionResult
+}
+
+type resultCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[[32]byte]*list.Element
+	order   *list.List
+}
+
+func (c *resultCache) get(id [32]byte) (*models.ConversionResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	result := element.Value.(*cacheEntry).result
+	result.Data = bytes.Clone(result.Data)
+	return &result, true
+}
+
+func (c *resultCache) put(id [32]byte, result *models.ConversionResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[id]; ok || c.size <= 0 {
+		return
+	}
+	entry := &cacheEntry{id: id, result: *result}
+	entry.result.Data = bytes.Clone(result.Data)
+	c.entries[id] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+	}
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,75 @@
+package factory
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestMiddlewareWrapsPooledConverters(t *testing.T) {
+	var timed []string
+	converterFactory := WithMiddleware(NewConverterFactory(),
+		Timing(func(key string, duration time.Duration, err error) { timed = append(timed, key) }),
+		SizeLimit(64),
+		Cache(8),
+		ValidateOutput(),
+	)
+	pool := NewConverterPool(1, converterFactory)
+
+	converter, err := pool.Get("csv-json")
+	assert.NoError(t, err)
+	converter.(models.Configurable).Configure(models.NewOptions(models.WithCSVDelimiter(';')))
+
+	first := converter.Convert(strings.NewReader("a;b\n1;2\n"), models.FormatCSV, models.FormatJSON)
+	second := converter.Convert(strings.NewReader("a;b\n1;2\n"), models.FormatCSV, models.FormatJSON)
+	assert.NoError(t, first.Error)
+	assert.Equal(t, first.Data, second.Data)
+	assert.Equal(t, []string{"csv-json", "csv-json"}, timed)
+
+	tooLarge := converter.Convert(strings.NewReader(strings.Repeat("x", 100)), models.FormatCSV, models.FormatJSON)
+	assert.ErrorIs(t, tooLarge.Error, ErrInputTooLarge)
+
+	pool.Return("csv-json", converter)
+	reused, _ := pool.Get("csv-json")
+	assert.Same(t, converter, reused)
+}
+
+func TestMiddlewareKeepsContextAndStreaming(t *testing.T) {
+	var logged bytes.Buffer
+	converterFactory := WithMiddleware(NewConverterFactory(),
+		Logging(slog.New(slog.NewTextHandler(&logged, nil))),
+		Timing(func(string, time.Duration, error) {}),
+		SizeLimit(16),
+	)
+
+	converter, err := converterFactory.CreateConverter("csv-json")
+	assert.NoError(t, err)
+	streamer, ok := converter.(models.StreamConverter)
+	assert.True(t, ok, "wrapped streaming converters keep streaming")
+	var out bytes.Buffer
+	assert.NoError(t, streamer.ConvertStream(strings.NewReader("a\n1\n"), &out, models.FormatCSV, models.FormatJSON))
+	assert.JSONEq(t, `[{"a":"1"}]`, out.String())
+	assert.Contains(t, logged.String(), "conversion finished")
+	assert.ErrorIs(t, streamer.ConvertStream(strings.NewReader(strings.Repeat("a", 64)), &out, models.FormatCSV, models.FormatJSON), ErrInputTooLarge)
+
+	RegisterConverter("ctx-ctx", func() models.Converter { return contextConverter{returned: make(chan struct{})} })
+	defer UnregisterConverter("ctx-ctx")
+	converter, err = converterFactory.CreateConverter("ctx-ctx")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrStepTimeout)
+	result := converter.(models.ContextConverter).ConvertContext(ctx, strings.NewReader("x"), "ctx", "ctx")
+	assert.ErrorIs(t, result.Error, ErrStepTimeout, "the step's context reaches the wrapped converter")
+
+	converter, err = WithMiddleware(NewConverterFactory(), ValidateOutput()).CreateConverter("csv-json")
+	assert.NoError(t, err)
+	_, ok = converter.(models.StreamConverter)
+	assert.False(t, ok, "output validation needs the whole output")
+}
@@ -0,0 +1,36 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/migrate"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func checkMigration(step models.ConversionStep) (*migrate.Migration, error) {
+	return migrate.Compile(*step.Migrate)
+}
+
+// applyMigration parses the input, migrates every record to the target
+// schema version and renders them back in the same format.
+func applyMigration(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	migration, err := checkMigration(step)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		for i, record := range records.Find(doc.Root) {
+			if _, err := migration.Apply(record); err != nil {
+				return fmt.Errorf("record %d: %w", i+1, err)
+			}
+		}
+		return nil
+	})
+}
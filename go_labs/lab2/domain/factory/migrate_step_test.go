@@ -0,0 +1,47 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestMigrationStep(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "users.ndjson")
+	require.NoError(t, os.WriteFile(input, []byte(`{"schema":"1","mail":"ann@example.com","first":"Ann","last":"Lee"}
+{"schema":"2","email":"bob@example.com","name":"Bob Ng","role":"admin"}
+`), 0644))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "users.v2.ndjson")).
+		AddMigration(models.FormatNDJSON, models.Migration{
+			VersionField: "schema",
+			From:         "1",
+			To:           "2",
+			Rename:       map[string]string{"mail": "email"},
+			Set:          map[string]string{"name": `first + " " + last`},
+			Defaults:     map[string]interface{}{"role": "user"},
+			Drop:         []string{"first", "last"},
+		}).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).Execute(pipeline).Error)
+
+	data, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"email":"ann@example.com","name":"Ann Lee","role":"user","schema":"2"}
+{"email":"bob@example.com","name":"Bob Ng","role":"admin","schema":"2"}
+`, string(data))
+
+	_, err = NewPipelineBuilder().
+		AddMigration(models.FormatNDJSON, models.Migration{Rename: map[string]string{"a": "b"}, Drop: []string{"b"}}).
+		Build()
+	assert.EqualError(t, err, "step 1: a is renamed to b and also dropped")
+}
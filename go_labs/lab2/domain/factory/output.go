@@ -0,0 +1,82 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"tmps-go-labs/lab2/domain/inject"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/random"
+	"tmps-go-labs/lab2/domain/split"
+	"tmps-go-labs/lab3/vfs"
+)
+
+// newRunID returns a random identifier for a pipeline run, the same for
+// every run of a pipeline with a seed.
+func newRunID(pipeline *models.Pipeline) string {
+	if seed := pipeline.Options.Seed; seed != nil {
+		return random.ID(random.New(random.Derive(*seed, "run")), 8)
+	}
+	return random.ID(random.Default(), 8)
+}
+
+// writeOutput writes data to the pipeline's output path in files, or as numbered
+// parts next to it when splitting is enabled, and returns the files
+// written. Each file gets its injected header and footer and is then
+// encrypted when configured. Parts left over from an earlier run that had
+// more of them are removed, so the parts on disk are always those of the
+// last run.
+func writeOutput(files vfs.FileSystem, pipeline *models.Pipeline, key, data []byte, metadata inject.Metadata) ([]string, error) {
+	splitting := pipeline.Options.Split != (models.SplitOptions{})
+	parts, outputs := [][]byte{data}, []string{pipeline.OutputPath}
+	if splitting {
+		var err error
+		if parts, err = split.Split(data, metadata.To, pipeline.Options.Split); err != nil {
+			return nil, err
+		}
+		outputs = make([]string, len(parts))
+		for i := range parts {
+			outputs[i] = split.PartPath(pipeline.OutputPath, i+1)
+		}
+	}
+
+	for i, part := range parts {
+		metadata.Output, metadata.Part, metadata.Parts = outputs[i], i+1, len(parts)
+		part, err := finishOutput(pipeline, key, part, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if err := files.WriteFile(outputs[i], part); err != nil {
+			return nil, fmt.Errorf("failed to write output file: %w", err)
+		}
+	}
+	if !splitting {
+		return outputs, nil
+	}
+
+	for i := len(parts) + 1; ; i++ {
+		err := files.Remove(split.PartPath(pipeline.OutputPath, i))
+		if errors.Is(err, fs.ErrNotExist) {
+			return outputs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove stale output part: %w", err)
+		}
+	}
+}
+
+// finishOutput injects the header and footer into one output file and
+// seals it.
+func finishOutput(pipeline *models.Pipeline, key, data []byte, metadata inject.Metadata) ([]byte, error) {
+	if options := pipeline.Options.Inject; options.Header != "" || options.Footer != "" {
+		var err error
+		if data, err = inject.Apply(data, metadata.To, options, metadata); err != nil {
+			return nil, err
+		}
+	}
+	return sealOutput(pipeline, key, data)
+}
@@ -0,0 +1,57 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bytes"
+	"fmt"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/jsonschema"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func checkOutputSchema(pipeline *models.Pipeline) (*jsonschema.Schema, error) {
+	schema, err := jsonschema.Load(pipeline.Options.OutputSchema.Path)
+	if err != nil {
+		return nil, fmt.Errorf("output schema: %w", err)
+	}
+	format := pipeline.Steps[len(pipeline.Steps)-1].To
+	if _, ok := document.ParserFor(format); !ok {
+		return nil, fmt.Errorf("output schema needs a parser for %s", format)
+	}
+	if _, ok := document.RendererFor(format); !ok {
+		return nil, fmt.Errorf("output schema needs a renderer for %s", format)
+	}
+	return schema, nil
+}
+
+// coerceOutput parses the final output, makes it match the output schema
+// and renders it again, or fails listing what still does not match.
+func coerceOutput(pipeline *models.Pipeline, data []byte) ([]byte, error) {
+	schema, err := checkOutputSchema(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	format := pipeline.Steps[len(pipeline.Steps)-1].To
+	// The output is re-parsed losslessly, so the numbers it holds are
+	// written back exactly as they were.
+	options := pipeline.Options
+	options.LosslessTypes = true
+	doc, err := document.Parse(bytes.NewReader(data), format, options)
+	if err != nil {
+		return nil, fmt.Errorf("output schema: %w", err)
+	}
+	root, violations := jsonschema.Coerce(doc.Root, schema, pipeline.Options.OutputSchema.ValidateOnly)
+	if err := jsonschema.Error(violations); err != nil {
+		return nil, err
+	}
+	if pipeline.Options.OutputSchema.ValidateOnly {
+		return data, nil
+	}
+
+	doc.Root = root
+	return document.Render(doc, format, pipeline.Options)
+}
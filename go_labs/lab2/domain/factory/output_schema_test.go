@@ -0,0 +1,78 @@
+package factory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/jsonschema"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestOutputSchema(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "orders.csv")
+	require.NoError(t, os.WriteFile(input, []byte("id,total,paid,debug\n1,9.5,true,x\n2,12,false,y\n"), 0644))
+	schema := filepath.Join(dir, "orders.schema.json")
+	require.NoError(t, os.WriteFile(schema, []byte(`{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["id", "currency"],
+		"properties": {
+			"id": {"type": "integer"},
+			"total": {"type": "number"},
+			"paid": {"type": "boolean"},
+			"currency": {"type": "string", "default": "EUR"}
+		}
+	}`), 0644))
+
+	build := func(validateOnly bool) *models.Pipeline {
+		pipeline, err := NewPipelineBuilder().
+			WithInputPath(input).
+			WithOutputPath(filepath.Join(dir, "orders.json")).
+			WithOutputSchema(models.OutputSchemaOptions{Path: schema, ValidateOnly: validateOnly}).
+			AddCSVToJSON().
+			Build()
+		require.NoError(t, err)
+		return pipeline
+	}
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	pipeline := build(false)
+	require.NoError(t, executor.Execute(pipeline).Error)
+	data, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"id": 1, "total": 9.5, "paid": true, "currency": "EUR"},
+		{"id": 2, "total": 12, "paid": false, "currency": "EUR"}
+	]`, string(data))
+
+	err = executor.Execute(build(true)).Error
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, jsonschema.ErrMismatch))
+
+	_, err = NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "orders.json")).
+		WithOutputSchema(models.OutputSchemaOptions{Path: filepath.Join(dir, "missing.json")}).
+		AddCSVToJSON().
+		Build()
+	assert.ErrorContains(t, err, "output schema: failed to read JSON schema")
+}
+
+func TestOutputSchemaKeepsLargeIntegers(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "ids.schema.json")
+	require.NoError(t, os.WriteFile(schema, []byte(`{"type":"array","items":{"type":"object","properties":{"id":{"type":"integer"}}}}`), 0644))
+
+	pipeline := &models.Pipeline{Steps: []models.ConversionStep{{From: models.FormatJSON, To: models.FormatJSON}}}
+	pipeline.Options = models.NewOptions(models.WithIndentWidth(0), models.WithFloatNumbers())
+	pipeline.Options.OutputSchema.Path = schema
+
+	out, err := coerceOutput(pipeline, []byte(`[{"id":9007199254740993}]`))
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id":9007199254740993}]`, string(out))
+}
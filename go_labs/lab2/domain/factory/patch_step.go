@@ -0,0 +1,69 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/patch"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+type compiledPatch struct {
+	// hasMerge tells a top-level null merge patch, which clears the
+	// document, from no merge patch at all.
+	hasMerge   bool
+	merge      interface{}
+	operations []patch.Operation
+}
+
+func compilePatch(p *models.Patch) (*compiledPatch, error) {
+	if len(p.Merge) == 0 && len(p.JSONPatch) == 0 {
+		return nil, fmt.Errorf("patch step needs a merge patch or a JSON Patch")
+	}
+
+	compiled := &compiledPatch{}
+	if len(p.Merge) > 0 {
+		merge, err := values.UnmarshalJSON(p.Merge, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merge patch: %w", err)
+		}
+		compiled.hasMerge = true
+		compiled.merge = merge
+	}
+	if len(p.JSONPatch) > 0 {
+		operations, err := patch.ParseJSONPatch(p.JSONPatch)
+		if err != nil {
+			return nil, err
+		}
+		compiled.operations = operations
+	}
+	return compiled, nil
+}
+
+// applyPatch parses the input, applies the merge patch and then the JSON
+// Patch to the whole document, and renders it back in the same format.
+func applyPatch(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	compiled, err := compilePatch(step.Patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		if compiled.hasMerge {
+			doc.Root = patch.MergePatch(doc.Root, compiled.merge)
+		}
+		if compiled.operations != nil {
+			root, err := patch.ApplyJSONPatch(doc.Root, compiled.operations)
+			if err != nil {
+				return err
+			}
+			doc.Root = root
+		}
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestPatchStepsOverrideValues(t *testing.T) {
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("config.json").
+		WithOutputPath("config.yaml").
+		AddMergePatch(models.FormatJSON, []byte(`{"database": {"host": "db.prod", "debug": null}}`)).
+		AddJSONPatch(models.FormatJSON, []byte(`[{"op": "add", "path": "/replicas", "value": 3}]`)).
+		AddConversionStep(models.FormatJSON, models.FormatYAML).
+		Build()
+	require.NoError(t, err)
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	output, result := executor.ConvertData(context.Background(), pipeline,
+		[]byte(`{"database": {"host": "localhost", "port": 5432, "debug": true}}`))
+	require.NoError(t, result.Error)
+	assert.Equal(t, "database:\n    host: db.prod\n    port: 5432\nreplicas: 3\n", string(output))
+}
+
+func TestBuildRejectsInvalidPatch(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("config.json").
+		WithOutputPath("out.json").
+		AddJSONPatch(models.FormatJSON, []byte(`[{"op": "add", "path": "replicas", "value": 3}]`)).
+		Build()
+	assert.EqualError(t, err, `step 1: operation 0: JSON Pointer "replicas" must start with /`)
+}
+
+func TestNullMergePatchClearsTheDocument(t *testing.T) {
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("config.json").
+		WithOutputPath("out.json").
+		AddMergePatch(models.FormatJSON, []byte(`null`)).
+		Build()
+	require.NoError(t, err)
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	output, result := executor.ConvertData(context.Background(), pipeline, []byte(`{"database": {"host": "localhost"}}`))
+	require.NoError(t, result.Error)
+	assert.JSONEq(t, `null`, string(output))
+}
@@ -4,13 +4,37 @@
 package factory
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"tmps-go-labs/lab2/domain/delta"
+	"tmps-go-labs/lab2/domain/diskspace"
+	"tmps-go-labs/lab2/domain/encryption"
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/inject"
+	"tmps-go-labs/lab2/domain/limits"
+	"tmps-go-labs/lab2/domain/manifest"
 	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/postprocess"
+	"tmps-go-labs/lab2/domain/profiling"
+	"tmps-go-labs/lab2/domain/provenance"
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/sanitize"
+	"tmps-go-labs/lab2/domain/validation"
+	"tmps-go-labs/lab3/vfs"
 )
 
 type PipelineBuilder struct {
@@ -28,6 +52,14 @@ func NewPipelineBuilder() *PipelineBuilder {
 	}
 }
 
+// NewPipelineBuilderFrom continues building a pipeline defined elsewhere,
+// such as one loaded from a config file, so Build can validate it.
+func NewPipelineBuilderFrom(pipeline *models.Pipeline) *PipelineBuilder {
+	builder := NewPipelineBuilder()
+	builder.pipeline = pipeline
+	return builder
+}
+
 func (b *PipelineBuilder) WithInputPath(path string) *PipelineBuilder {
 	b.pipeline.InputPath = path
 	return b
@@ -43,14 +75,29 @@ func (b *PipelineBuilder) WithOptions(options models.ConversionOptions) *Pipelin
 	return b
 }
 
+// Apply sets functional options such as models.WithCSVDelimiter(';') on
+// top of any options set so far.
+func (b *PipelineBuilder) Apply(options ...models.Option) *PipelineBuilder {
+	b.pipeline.Options.Apply(options...)
+	return b
+}
+
+// WithIndent indents JSON, XML and YAML output by DefaultIndentWidth
+// spaces, unless an indent width is set.
+//
+// Deprecated: use Apply(models.WithIndentWidth(width)).
 func (b *PipelineBuilder) WithIndent() *PipelineBuilder {
-	b.pipeline.Options.Indent = true
+	if b.pipeline.Options.IndentWidth == nil {
+		b.pipeline.Options.Apply(models.WithIndentWidth(models.DefaultIndentWidth))
+	}
 	return b
 }
 
+// WithPrettyPrint is WithIndent.
+//
+// Deprecated: use Apply(models.WithIndentWidth(width)).
 func (b *PipelineBuilder) WithPrettyPrint() *PipelineBuilder {
-	b.pipeline.Options.PrettyPrint = true
-	return b
+	return b.WithIndent()
 }
 
 func (b *PipelineBuilder) WithHeaders(headers []string) *PipelineBuilder {
@@ -63,6 +110,263 @@ func (b *PipelineBuilder) WithSaveIntermediarySteps() *PipelineBuilder {
 	return b
 }
 
+func (b *PipelineBuilder) WithProfiling() *PipelineBuilder {
+	b.pipeline.Options.Profile = true
+	return b
+}
+
+func (b *PipelineBuilder) WithTemplate(text string) *PipelineBuilder {
+	b.pipeline.Options.Template = text
+	return b
+}
+
+func (b *PipelineBuilder) WithTemplateFile(path string) *PipelineBuilder {
+	b.pipeline.Options.TemplatePath = path
+	return b
+}
+
+func (b *PipelineBuilder) WithFixedWidthColumns(columns ...models.FixedWidthColumn) *PipelineBuilder {
+	b.pipeline.Options.FixedWidthColumns = columns
+	return b
+}
+
+func (b *PipelineBuilder) WithGeoColumns(latColumn, lonColumn string) *PipelineBuilder {
+	b.pipeline.Options.Geo.LatColumn = latColumn
+	b.pipeline.Options.Geo.LonColumn = lonColumn
+	return b
+}
+
+func (b *PipelineBuilder) WithGeometryValidation(skipInvalid bool) *PipelineBuilder {
+	b.pipeline.Options.Geo.Validate = true
+	b.pipeline.Options.Geo.SkipInvalid = skipInvalid
+	return b
+}
+
+// WithStrategy selects how the executor runs the pipeline; the default is
+// models.StrategySequential.
+func (b *PipelineBuilder) WithStrategy(strategy models.ExecutionStrategy) *PipelineBuilder {
+	b.pipeline.Options.Strategy = strategy
+	return b
+}
+
+// WithSanitizers cleans the input before the first step, running every
+// sanitizer except those named in skip.
+func (b *PipelineBuilder) WithSanitizers(skip ...string) *PipelineBuilder {
+	b.pipeline.Options.Sanitize.Enabled = true
+	b.pipeline.Options.Sanitize.Skip = skip
+	return b
+}
+
+func (b *PipelineBuilder) WithTrimTrailingSpace() *PipelineBuilder {
+	b.pipeline.Options.Sanitize.TrimTrailingSpace = true
+	return b
+}
+
+// WithValidation checks the input against the schema at schemaPath before
+// converting; the run fails when an error-severity rule is violated.
+func (b *PipelineBuilder) WithValidation(schemaPath string) *PipelineBuilder {
+	b.pipeline.Options.Validation.SchemaPath = schemaPath
+	return b
+}
+
+// WithKeyOrder sorts the keys of JSON, NDJSON and YAML output objects and
+// the columns of CSV output, e.g. with "id" first and "metadata" last.
+func (b *PipelineBuilder) WithKeyOrder(order models.KeyOrder) *PipelineBuilder {
+	b.pipeline.Options.KeyOrder = order
+	return b
+}
+
+// WithValidationRules checks the input against rules declared in code or
+// in the pipeline config, in addition to any schema file.
+func (b *PipelineBuilder) WithValidationRules(rules ...models.ValidationRule) *PipelineBuilder {
+	b.pipeline.Options.Validation.Rules = append(b.pipeline.Options.Validation.Rules, rules...)
+	return b
+}
+
+// WithValidationFailOn sets the lowest severity that fails the run,
+// validation.SeverityError, validation.SeverityWarning or
+// validation.FailNever.
+func (b *PipelineBuilder) WithValidationFailOn(severity string) *PipelineBuilder {
+	b.pipeline.Options.Validation.FailOn = severity
+	return b
+}
+
+// WithValidationReport selects the report format, validation.ReportJSON or
+// validation.ReportJUnit.
+func (b *PipelineBuilder) WithValidationReport(format string) *PipelineBuilder {
+	b.pipeline.Options.Validation.ReportFormat = format
+	return b
+}
+
+// WithDeltaOutput writes only the records added, changed or removed since
+// the previous run, matched by key, and a full snapshot every fullEvery runs.
+func (b *PipelineBuilder) WithDeltaOutput(key string, fullEvery int) *PipelineBuilder {
+	b.pipeline.Options.Delta = models.DeltaOptions{Key: key, FullEvery: fullEvery}
+	return b
+}
+
+// WithSplit writes the output as numbered parts of at most maxRows records
+// and maxBytes bytes each, either of which may be 0 for no limit.
+func (b *PipelineBuilder) WithSplit(maxRows int, maxBytes int64) *PipelineBuilder {
+	b.pipeline.Options.Split = models.SplitOptions{MaxRows: maxRows, MaxBytes: maxBytes}
+	return b
+}
+
+// WithHeader adds text before the output and WithFooter after it. Both are
+// templates over inject.Metadata, e.g. "Generated by run {{.RunID}}".
+func (b *PipelineBuilder) WithHeader(text string) *PipelineBuilder {
+	b.pipeline.Options.Inject.Header = text
+	return b
+}
+
+func (b *PipelineBuilder) WithFooter(text string) *PipelineBuilder {
+	b.pipeline.Options.Inject.Footer = text
+	return b
+}
+
+// WithCommentedInjection writes the header and footer as comments of the
+// output format.
+func (b *PipelineBuilder) WithCommentedInjection() *PipelineBuilder {
+	b.pipeline.Options.Inject.Comment = true
+	return b
+}
+
+// WithProvenance tags every input record with its source file, line and
+// run ID (see package provenance) before the steps run.
+func (b *PipelineBuilder) WithProvenance() *PipelineBuilder {
+	b.pipeline.Options.Provenance = true
+	return b
+}
+
+// WithCommentPreservation keeps the comments of YAML input on YAML output,
+// which conversions otherwise drop, even through steps in other formats.
+func (b *PipelineBuilder) WithCommentPreservation() *PipelineBuilder {
+	b.pipeline.Options.PreserveComments = true
+	return b
+}
+
+// WithYAMLOptions sets how YAML input aliases are expanded and whether
+// YAML output uses anchors for repeated objects and lists.
+func (b *PipelineBuilder) WithYAMLOptions(options models.YAMLOptions) *PipelineBuilder {
+	b.pipeline.Options.YAML = options
+	return b
+}
+
+// WithXMLOptions sets how CDATA sections and mixed content of XML input
+// are read.
+func (b *PipelineBuilder) WithXMLOptions(options models.XMLOptions) *PipelineBuilder {
+	b.pipeline.Options.XML = options
+	return b
+}
+
+// WithDocumentLimits bounds the nesting depth and node count of JSON, XML
+// and YAML input, which is rejected before parsing when over either.
+func (b *PipelineBuilder) WithDocumentLimits(limits models.DocumentLimits) *PipelineBuilder {
+	b.pipeline.Options.Limits = limits
+	return b
+}
+
+// WithLosslessTypes keeps JSON and YAML integers, decimals and timestamps
+// exact from input to output instead of reading them as float64. This is
+// the default, so it only overrides WithFloatNumbers.
+func (b *PipelineBuilder) WithLosslessTypes() *PipelineBuilder {
+	b.pipeline.Options.LosslessTypes = true
+	return b
+}
+
+// WithFloatNumbers reads JSON and YAML numbers as float64, as older
+// versions did, at the cost of integers past 2^53.
+func (b *PipelineBuilder) WithFloatNumbers() *PipelineBuilder {
+	b.pipeline.Options.FloatNumbers = true
+	return b
+}
+
+// WithOutputSchema makes the final output match a JSON Schema, coercing
+// values, filling in defaults and pruning properties it does not allow.
+func (b *PipelineBuilder) WithOutputSchema(schema models.OutputSchemaOptions) *PipelineBuilder {
+	b.pipeline.Options.OutputSchema = schema
+	return b
+}
+
+// WithSeed makes runs of the pipeline reproducible: the run ID and the
+// samples of sample steps without their own seed are drawn from seed.
+func (b *PipelineBuilder) WithSeed(seed int64) *PipelineBuilder {
+	b.pipeline.Options.Seed = &seed
+	return b
+}
+
+// WithStepTimeout bounds how long every step may run, and decides what
+// happens to the steps running longer.
+func (b *PipelineBuilder) WithStepTimeout(timeout models.StepTimeout) *PipelineBuilder {
+	b.pipeline.Options.StepTimeout = timeout
+	return b
+}
+
+// WithLastStepTimeout bounds the step added last, in place of the
+// pipeline's step timeout.
+func (b *PipelineBuilder) WithLastStepTimeout(timeout models.StepTimeout) *PipelineBuilder {
+	if len(b.pipeline.Steps) > 0 {
+		b.pipeline.Steps[len(b.pipeline.Steps)-1].Timeout = &timeout
+	}
+	return b
+}
+
+// WithPostProcessors reshapes the final output with the named
+// post-processors, applied in order.
+func (b *PipelineBuilder) WithPostProcessors(names ...string) *PipelineBuilder {
+	b.pipeline.Options.PostProcess = names
+	return b
+}
+
+// WithSnapshots keeps the output of the given 1-based steps in the result,
+// so the pipeline can later be resumed from them with Resume.
+func (b *PipelineBuilder) WithSnapshots(steps ...int) *PipelineBuilder {
+	b.pipeline.Options.SnapshotSteps = append(b.pipeline.Options.SnapshotSteps, steps...)
+	return b
+}
+
+func (b *PipelineBuilder) WithArchiveEntries(glob string) *PipelineBuilder {
+	b.pipeline.Options.ArchiveEntryGlob = glob
+	return b
+}
+
+func (b *PipelineBuilder) WithEncryptionKeyFromEnv(name string) *PipelineBuilder {
+	b.pipeline.Options.Encryption.KeyEnv = name
+	return b
+}
+
+func (b *PipelineBuilder) WithEncryptionKeyFromFile(path string) *PipelineBuilder {
+	b.pipeline.Options.Encryption.KeyFile = path
+	return b
+}
+
+func (b *PipelineBuilder) WithEncryptedInput() *PipelineBuilder {
+	b.pipeline.Options.Encryption.DecryptInput = true
+	return b
+}
+
+func (b *PipelineBuilder) WithEncryptedOutput() *PipelineBuilder {
+	b.pipeline.Options.Encryption.EncryptOutput = true
+	return b
+}
+
+func (b *PipelineBuilder) WithManifest() *PipelineBuilder {
+	b.pipeline.Options.Manifest.Enabled = true
+	return b
+}
+
+func (b *PipelineBuilder) WithSigningKeyFromEnv(name string) *PipelineBuilder {
+	b.pipeline.Options.Manifest.Enabled = true
+	b.pipeline.Options.Manifest.SigningKeyEnv = name
+	return b
+}
+
+func (b *PipelineBuilder) WithSigningKeyFromFile(path string) *PipelineBuilder {
+	b.pipeline.Options.Manifest.Enabled = true
+	b.pipeline.Options.Manifest.SigningKeyFile = path
+	return b
+}
+
 func (b *PipelineBuilder) AddConversionStep(from, to models.FileFormat) *PipelineBuilder {
 	step := models.ConversionStep{
 		From: from,
@@ -73,6 +377,135 @@ func (b *PipelineBuilder) AddConversionStep(from, to models.FileFormat) *Pipelin
 	return b
 }
 
+// AddPipeline adds a sub-pipeline as one step. It runs with its own options,
+// so a reusable stage such as "sanitize" is unaffected by the outer flow.
+// A nil sub-pipeline is added as an empty one, which Build rejects.
+func (b *PipelineBuilder) AddPipeline(sub *models.Pipeline) *PipelineBuilder {
+	if sub == nil {
+		sub = &models.Pipeline{}
+	}
+	step := models.ConversionStep{Pipeline: sub}
+	if len(sub.Steps) > 0 {
+		step.From = sub.Steps[0].From
+		step.To = sub.Steps[len(sub.Steps)-1].To
+	}
+
+	b.pipeline.Steps = append(b.pipeline.Steps, step)
+	return b
+}
+
+// AddFilter keeps only the records of format for which expression, such as
+// `age > 30 && country == "MD"`, is true.
+func (b *PipelineBuilder) AddFilter(format models.FileFormat, expression string) *PipelineBuilder {
+	return b.AddTransform(format, models.Transform{Filter: expression})
+}
+
+// AddMap sets each field of every record to the value of its expression,
+// such as "name": "upper(name)".
+func (b *PipelineBuilder) AddMap(format models.FileFormat, assignments map[string]string) *PipelineBuilder {
+	return b.AddTransform(format, models.Transform{Set: assignments})
+}
+
+func (b *PipelineBuilder) AddTransform(format models.FileFormat, transform models.Transform) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Transform: &transform})
+	return b
+}
+
+// AddMergePatch overlays an RFC 7386 merge patch, such as environment
+// specific values, on the document of format.
+func (b *PipelineBuilder) AddMergePatch(format models.FileFormat, patch []byte) *PipelineBuilder {
+	return b.AddPatch(format, models.Patch{Merge: patch})
+}
+
+// AddJSONPatch applies an RFC 6902 JSON Patch to the document of format.
+func (b *PipelineBuilder) AddJSONPatch(format models.FileFormat, patch []byte) *PipelineBuilder {
+	return b.AddPatch(format, models.Patch{JSONPatch: patch})
+}
+
+func (b *PipelineBuilder) AddPatch(format models.FileFormat, patch models.Patch) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Patch: &patch})
+	return b
+}
+
+// AddFieldEncryption encrypts or decrypts fields of the records of format
+// and keeps the rest of each record as it is.
+func (b *PipelineBuilder) AddFieldEncryption(format models.FileFormat, encrypt models.FieldEncryption) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Encrypt: &encrypt})
+	return b
+}
+
+// AddLookup enriches the records of format with columns of a lookup
+// table, such as the country name for a country code.
+func (b *PipelineBuilder) AddLookup(format models.FileFormat, lookup models.Lookup) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Lookup: &lookup})
+	return b
+}
+
+// AddExtract splits a field of the records of format into new fields, one
+// per named group of a regular expression.
+func (b *PipelineBuilder) AddExtract(format models.FileFormat, extract models.Extract) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Extract: &extract})
+	return b
+}
+
+// AddUnitConversion converts numeric fields of the records of format from
+// one unit to another.
+func (b *PipelineBuilder) AddUnitConversion(format models.FileFormat, conversion models.UnitConversion) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Units: &conversion})
+	return b
+}
+
+// AddUnpivot turns wide records of format into long ones, a record per
+// column; reshape.Mode is set for the caller.
+func (b *PipelineBuilder) AddUnpivot(format models.FileFormat, reshape models.Reshape) *PipelineBuilder {
+	reshape.Mode = models.ReshapeUnpivot
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Reshape: &reshape})
+	return b
+}
+
+// AddPivot turns long records of format back into wide ones, a record per
+// distinct set of ids; reshape.Mode is set for the caller.
+func (b *PipelineBuilder) AddPivot(format models.FileFormat, reshape models.Reshape) *PipelineBuilder {
+	reshape.Mode = models.ReshapePivot
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Reshape: &reshape})
+	return b
+}
+
+// AddSample passes a random or systematic sample of the records of format
+// through to the next step.
+func (b *PipelineBuilder) AddSample(format models.FileFormat, sampling models.Sampling) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Sample: &sampling})
+	return b
+}
+
+// AddMigration moves the records of format from one schema version to
+// another.
+func (b *PipelineBuilder) AddMigration(format models.FileFormat, migration models.Migration) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Migrate: &migration})
+	return b
+}
+
+// AddSearch keeps the records of format matching a query of the search
+// tool's engines.
+func (b *PipelineBuilder) AddSearch(format models.FileFormat, search models.Search) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Search: &search})
+	return b
+}
+
+// AddQuality checks the records of format against data-quality rules,
+// dropping or failing on the records violating them as the rules say.
+func (b *PipelineBuilder) AddQuality(format models.FileFormat, quality models.Quality) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: format, To: format, Quality: &quality})
+	return b
+}
+
+// AddPinnedConversion converts from to to with the newest registered
+// converter meeting requirement, e.g. a major version or a capability.
+func (b *PipelineBuilder) AddPinnedConversion(from, to models.FileFormat, requirement models.ConverterRequirement) *PipelineBuilder {
+	b.pipeline.Steps = append(b.pipeline.Steps, models.ConversionStep{From: from, To: to, Converter: &requirement})
+	return b
+}
+
 func (b *PipelineBuilder) AddCSVToJSON() *PipelineBuilder {
 	return b.AddConversionStep(models.FormatCSV, models.FormatJSON)
 }
@@ -85,9 +518,29 @@ func (b *PipelineBuilder) AddXMLToYAML() *PipelineBuilder {
 	return b.AddConversionStep(models.FormatXML, models.FormatYAML)
 }
 
+func (b *PipelineBuilder) AddCSVToMarkdown() *PipelineBuilder {
+	return b.AddConversionStep(models.FormatCSV, models.FormatMarkdown)
+}
+
+func (b *PipelineBuilder) AddJSONToMarkdown() *PipelineBuilder {
+	return b.AddConversionStep(models.FormatJSON, models.FormatMarkdown)
+}
+
+func (b *PipelineBuilder) AddCSVToGeoJSON() *PipelineBuilder {
+	return b.AddConversionStep(models.FormatCSV, models.FormatGeoJSON)
+}
+
+func (b *PipelineBuilder) AddGeoJSONToCSV() *PipelineBuilder {
+	return b.AddConversionStep(models.FormatGeoJSON, models.FormatCSV)
+}
+
+func (b *PipelineBuilder) AddTemplateOutput(from models.FileFormat) *PipelineBuilder {
+	return b.AddConversionStep(from, models.FormatTemplate)
+}
+
 func (b *PipelineBuilder) Build() (*models.Pipeline, error) {
-	if len(b.pipeline.Steps) == 0 {
-		return nil, fmt.Errorf("pipeline must have at least one conversion step")
+	if _, err := b.BuildSubPipeline(); err != nil {
+		return nil, err
 	}
 
 	if b.pipeline.InputPath == "" {
@@ -98,91 +551,967 @@ func (b *PipelineBuilder) Build() (*models.Pipeline, error) {
 		return nil, fmt.Errorf("output path is required")
 	}
 
-	return b.pipeline, nil
-}
+	encryption := b.pipeline.Options.Encryption
+	if encryption.DecryptInput || encryption.EncryptOutput {
+		if encryption.KeyEnv == "" && encryption.KeyFile == "" {
+			return nil, fmt.Errorf("encryption requires a key from an environment variable or file")
+		}
+	}
+	if encryption.EncryptOutput && (b.pipeline.Options.SaveIntermediarySteps || b.pipeline.Options.Profile ||
+		b.pipeline.Options.Validation.Enabled() || b.pipeline.Options.Delta.Key != "") {
+		return nil, fmt.Errorf("encrypted output cannot be combined with intermediary steps, profiling, validation or delta output, which are written in plaintext")
+	}
 
-type PipelineExecutor struct {
-	pool *ConverterPool
-}
+	if b.pipeline.Options.Delta.Key != "" {
+		if err := checkDeltaOutput(b.pipeline); err != nil {
+			return nil, err
+		}
+	}
 
-func NewPipelineExecutor(pool *ConverterPool) *PipelineExecutor {
-	return &PipelineExecutor{pool: pool}
-}
+	if options := b.pipeline.Options.Inject; options.Header != "" || options.Footer != "" {
+		if IsArchive(b.pipeline.InputPath) {
+			return nil, fmt.Errorf("headers and footers are not supported for archive input")
+		}
+		if err := inject.Check(options, b.pipeline.Steps[len(b.pipeline.Steps)-1].To); err != nil {
+			return nil, err
+		}
+	}
 
-func (e *PipelineExecutor) Execute(pipeline *models.Pipeline) *models.PipelineResult {
-	start := time.Now()
-	result := &models.PipelineResult{
-		Success: true,
-		Results: make([]*models.ConversionResult, 0),
+	if b.pipeline.Options.Profile {
+		if err := profiling.Check(b.pipeline.Steps[len(b.pipeline.Steps)-1].To); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(pipeline.Steps) == 0 {
-		result.Success = false
-		result.Error = fmt.Errorf("no conversion steps in pipeline")
-		return result
+	if b.pipeline.Options.Provenance {
+		if err := provenance.Check(b.pipeline.Steps[0].From); err != nil {
+			return nil, err
+		}
+		b.pipeline.Options.KeyOrder = provenance.Trailing(b.pipeline.Options.KeyOrder)
 	}
 
-	inputData, err := os.ReadFile(pipeline.InputPath)
-	if err != nil {
-		result.Success = false
-		result.Error = fmt.Errorf("failed to read input file: %w", err)
-		return result
+	if b.pipeline.Options.OutputSchema.Path != "" {
+		if _, err := checkOutputSchema(b.pipeline); err != nil {
+			return nil, err
+		}
 	}
 
-	if pipeline.Options.SaveIntermediarySteps {
-		if err := os.MkdirAll("steps", 0755); err != nil {
-			result.Success = false
-			result.Error = fmt.Errorf("failed to create steps directory: %w", err)
-			return result
+	if b.pipeline.Options.PreserveComments {
+		if err := checkCommentPreservation(b.pipeline); err != nil {
+			return nil, err
 		}
 	}
 
-	currentData := inputData
-	for i, step := range pipeline.Steps {
-		converterType := string(step.From) + "-" + string(step.To)
-		converter, err := e.pool.Get(converterType)
-		if err != nil {
-			result.Success = false
-			result.Error = fmt.Errorf("failed to get converter from pool for step %d: %w", i+1, err)
-			return result
+	if b.pipeline.Options.Split != (models.SplitOptions{}) {
+		if err := checkSplitOutput(b.pipeline); err != nil {
+			return nil, err
 		}
+	}
 
-		conversionResult := converter.Convert(
-			strings.NewReader(string(currentData)),
-			step.From,
-			step.To,
-		)
+	if validationOptions := b.pipeline.Options.Validation; validationOptions.Enabled() {
+		switch validationOptions.ReportFormat {
+		case "", validation.ReportJSON, validation.ReportJUnit:
+		default:
+			return nil, fmt.Errorf("unknown validation report format %q", validationOptions.ReportFormat)
+		}
+		if _, err := validation.FailOn(validationOptions.FailOn); err != nil {
+			return nil, err
+		}
+		if _, err := validation.NewSchema(validationOptions.Rules); err != nil {
+			return nil, fmt.Errorf("validation rules: %w", err)
+		}
+		if IsArchive(b.pipeline.InputPath) {
+			return nil, fmt.Errorf("validation is not supported for archive input")
+		}
+	}
 
-		e.pool.Put(converter)
+	return b.pipeline, nil
+}
 
-		result.Results = append(result.Results, conversionResult)
+// BuildSubPipeline validates the steps and options of a pipeline meant to be
+// used with AddPipeline, which has no input or output path of its own, and
+// of the sub-pipelines it nests.
+func (b *PipelineBuilder) BuildSubPipeline() (*models.Pipeline, error) {
+	return b.buildSubPipeline(map[*models.Pipeline]bool{b.pipeline: true})
+}
 
-		if conversionResult.Error != nil {
-			result.Success = false
-			result.Error = fmt.Errorf("step %d failed (%s→%s): %w",
-				i+1, step.From, step.To, conversionResult.Error)
-			return result
-		}
+// buildSubPipeline is BuildSubPipeline with the pipelines being validated
+// further up, so a pipeline that nests itself is rejected instead of
+// running forever.
+func (b *PipelineBuilder) buildSubPipeline(visiting map[*models.Pipeline]bool) (*models.Pipeline, error) {
+	if len(b.pipeline.Steps) == 0 {
+		return nil, fmt.Errorf("pipeline must have at least one conversion step")
+	}
 
-		currentData = conversionResult.Data
+	if _, err := sanitize.New(b.pipeline.Options.Sanitize, b.pipeline.Steps[0].From); err != nil {
+		return nil, err
+	}
 
-		if pipeline.Options.SaveIntermediarySteps {
-			stepFileName := filepath.Join("steps", fmt.Sprintf("step_%d_%s_to_%s.%s",
-				i+1, step.From, step.To, step.To))
-			if err := os.WriteFile(stepFileName, currentData, 0644); err != nil {
-				result.Success = false
-				result.Error = fmt.Errorf("failed to save intermediary step %d to file: %w", i+1, err)
-				return result
-			}
-		}
+	switch b.pipeline.Options.CSVParser {
+	case "", models.CSVParserStandard, models.CSVParserParallel:
+	default:
+		return nil, fmt.Errorf("unknown CSV parser %q", b.pipeline.Options.CSVParser)
 	}
 
-	if err := os.WriteFile(pipeline.OutputPath, currentData, 0644); err != nil {
-		result.Success = false
-		result.Error = fmt.Errorf("failed to write output file: %w", err)
-		return result
+	switch b.pipeline.Options.KeyOrder.Collation {
+	case "", models.CollationLexical, models.CollationNatural:
+	default:
+		return nil, fmt.Errorf("unknown key collation %q; use %s or %s", b.pipeline.Options.KeyOrder.Collation, models.CollationLexical, models.CollationNatural)
+	}
+
+	if err := b.pipeline.Options.CheckIndentWidth(); err != nil {
+		return nil, err
+	}
+
+	if b.pipeline.Options.YAML.MaxAliasDepth < 0 {
+		return nil, fmt.Errorf("YAML max alias depth must not be negative")
+	}
+
+	if b.pipeline.Options.Limits.MaxDepth < 0 || b.pipeline.Options.Limits.MaxNodeCount < 0 {
+		return nil, fmt.Errorf("document limits must not be negative")
+	}
+
+	switch b.pipeline.Options.XML.CDATA {
+	case "", models.XMLText, models.XMLPreserve, models.XMLError:
+	default:
+		return nil, fmt.Errorf("unknown XML CDATA handling %q; use %s, %s or %s", b.pipeline.Options.XML.CDATA, models.XMLText, models.XMLPreserve, models.XMLError)
+	}
+	switch b.pipeline.Options.XML.MixedContent {
+	case "", models.XMLConcatenate, models.XMLPreserve, models.XMLError:
+	default:
+		return nil, fmt.Errorf("unknown XML mixed content handling %q; use %s, %s or %s", b.pipeline.Options.XML.MixedContent, models.XMLConcatenate, models.XMLPreserve, models.XMLError)
+	}
+
+	switch b.pipeline.Options.Strategy {
+	case "", models.StrategySequential, models.StrategyConcurrent:
+	case models.StrategyStreaming:
+		if b.pipeline.Options.SaveIntermediarySteps {
+			return nil, fmt.Errorf("streaming strategy cannot save intermediary steps, which it never materializes")
+		}
+	default:
+		return nil, fmt.Errorf("unknown execution strategy %q", b.pipeline.Options.Strategy)
+	}
+
+	if text := b.pipeline.Options.Template; text != "" {
+		if _, err := parseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
+	last := b.pipeline.Steps[len(b.pipeline.Steps)-1]
+	if err := postprocess.Validate(b.pipeline.Options.PostProcess, last.To); err != nil {
+		return nil, err
+	}
+
+	for _, step := range b.pipeline.Options.SnapshotSteps {
+		if step < 1 || step > len(b.pipeline.Steps) {
+			return nil, fmt.Errorf("snapshot step %d is outside the pipeline's %d steps", step, len(b.pipeline.Steps))
+		}
+	}
+
+	for i, step := range b.pipeline.Steps {
+		if err := checkStepTimeout(step, stepTimeout(b.pipeline, step)); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if err := checkStage(step); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if step.Pipeline != nil {
+			if visiting[step.Pipeline] {
+				return nil, fmt.Errorf("sub-pipeline at step %d contains itself", i+1)
+			}
+			visiting[step.Pipeline] = true
+			_, err := NewPipelineBuilderFrom(step.Pipeline).buildSubPipeline(visiting)
+			delete(visiting, step.Pipeline)
+			if err != nil {
+				return nil, fmt.Errorf("sub-pipeline at step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Transform != nil {
+			if _, err := compileTransform(step.Transform); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Patch != nil {
+			if _, err := compilePatch(step.Patch); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Encrypt != nil {
+			if err := checkFieldEncryption(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Lookup != nil {
+			if err := checkLookup(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Extract != nil {
+			if _, err := compileExtract(step.Extract); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Units != nil {
+			if _, err := checkUnits(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Reshape != nil {
+			if err := checkReshape(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Sample != nil {
+			if err := checkSample(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Migrate != nil {
+			if _, err := checkMigration(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Search != nil {
+			if _, err := checkSearch(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Quality != nil {
+			if _, err := checkQuality(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			continue
+		}
+		if step.Converter != nil {
+			if _, err := converterKey(step); err != nil {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+		if step.To == models.FormatTemplate &&
+			b.pipeline.Options.Template == "" && b.pipeline.Options.TemplatePath == "" {
+			return nil, fmt.Errorf("template output requires a template")
+		}
+		if step.From == models.FormatFixedWidth || step.To == models.FormatFixedWidth {
+			if len(b.pipeline.Options.FixedWidthColumns) == 0 {
+				return nil, fmt.Errorf("fixed-width conversion requires column specifications")
+			}
+			if err := validateFixedWidthColumns(b.pipeline.Options.FixedWidthColumns); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return b.pipeline, nil
+}
+
+type PipelineExecutor struct {
+	pool      *ConverterPool
+	mu        sync.Mutex
+	closing   bool
+	inFlight  sync.WaitGroup
+	abort     context.Context
+	abortRuns context.CancelFunc
+	events    *events.Bus
+	files     vfs.FileSystem
+	history   *diskspace.History
+}
+
+func NewPipelineExecutor(pool *ConverterPool) *PipelineExecutor {
+	abort, abortRuns := context.WithCancel(context.Background())
+	return &PipelineExecutor{pool: pool, abort: abort, abortRuns: abortRuns, events: events.NewBus(), files: vfs.OS{}}
+}
+
+func (e *PipelineExecutor) Pool() *ConverterPool {
+	return e.pool
+}
+
+// Events returns the bus on which the executor publishes lifecycle events.
+func (e *PipelineExecutor) Events() *events.Bus {
+	return e.events
+}
+
+// WithLogger logs the executor's runs and steps to logger, through an
+// observer on its event bus: progress at debug level and failed steps as
+// warnings. Without it the executor does not log.
+func (e *PipelineExecutor) WithLogger(logger *slog.Logger) *PipelineExecutor {
+	e.events.Subscribe(events.Log(logger))
+	return e
+}
+
+// WithFileSystem reads pipeline inputs from and writes their outputs to
+// files instead of the local disk, for example a vfs.Mem in tests or the
+// vfs.Default mux to read inputs from URLs. Archive inputs and the files
+// written next to the output, such as manifests and delta bases, stay on
+// the local disk.
+func (e *PipelineExecutor) WithFileSystem(files vfs.FileSystem) *PipelineExecutor {
+	e.files = files
+	return e
+}
+
+func (e *PipelineExecutor) Execute(pipeline *models.Pipeline) *models.PipelineResult {
+	return e.ExecuteContext(context.Background(), pipeline)
+}
+
+func (e *PipelineExecutor) ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	ctx, done, err := e.admit(ctx)
+	if err != nil {
+		return &models.PipelineResult{Error: err}
+	}
+	defer done()
+
+	ctx, span := tracer.Start(ctx, "pipeline.execute", trace.WithAttributes(pipelineAttributes(pipeline)...))
+	e.events.Publish(events.PipelineStarted{Pipeline: pipeline, Time: time.Now()})
+	result := e.execute(ctx, pipeline)
+	e.events.Publish(events.PipelineFinished{Pipeline: pipeline, Result: result})
+	endSpan(span, result.Error)
+	return result
+}
+
+func (e *PipelineExecutor) execute(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	start := time.Now()
+	result := &models.PipelineResult{
+		RunID:   newRunID(pipeline),
+		Success: true,
+		Results: make([]*models.ConversionResult, 0),
+	}
+
+	if len(pipeline.Steps) == 0 {
+		result.Success = false
+		result.Error = fmt.Errorf("no conversion steps in pipeline")
+		return result
+	}
+
+	key, err := loadEncryptionKey(pipeline)
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("failed to load encryption key: %w", err)
+		return result
+	}
+
+	if IsArchive(pipeline.InputPath) {
+		e.executeArchive(ctx, pipeline, result, key)
+		if result.Success && pipeline.Options.Manifest.Enabled {
+			if err := writeManifest(pipeline, archiveOutputs(pipeline, result)); err != nil {
+				result.Success = false
+				result.Error = fmt.Errorf("failed to write manifest: %w", err)
+			}
+		}
+		result.Duration = time.Since(start).Nanoseconds()
+		return result
+	}
+
+	inputData, err := vfs.ReadFile(e.files, pipeline.InputPath)
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("failed to read input file: %w", err)
+		return result
+	}
+
+	inputData, err = openInput(pipeline, key, inputData)
+	if err != nil {
+		result.Success = false
+		result.Error = err
+		return result
+	}
+
+	if pipeline.Options.Validation.Enabled() {
+		if err := validateInput(pipeline, inputData); err != nil {
+			result.Success = false
+			result.Error = err
+			return result
+		}
+	}
+
+	if pipeline.Options.Provenance {
+		if inputData, err = addProvenance(pipeline, inputData, filepath.Base(pipeline.InputPath), result.RunID); err != nil {
+			result.Success = false
+			result.Error = err
+			return result
+		}
+	}
+
+	if err := e.preflight(pipeline, len(inputData)); err != nil {
+		result.Success = false
+		result.Error = err
+		return result
+	}
+
+	stepsDir := ""
+	if pipeline.Options.SaveIntermediarySteps {
+		stepsDir = "steps"
+	}
+
+	stepResults, currentData, err := e.runSteps(ctx, pipeline, inputData, stepsDir)
+	result.Results = append(result.Results, stepResults...)
+	result.Snapshots = takeSnapshots(pipeline, stepResults)
+	if err != nil {
+		result.Success = false
+		result.Error = err
+		return result
+	}
+	e.recordExpansion(pipeline, inputData, stepResults)
+
+	var nextBase *delta.Base
+	if pipeline.Options.Delta.Key != "" {
+		currentData, nextBase, err = deltaOutput(pipeline, currentData)
+		if err != nil {
+			result.Success = false
+			result.Error = err
+			return result
+		}
+	}
+
+	outputs, err := writeOutput(e.files, pipeline, key, currentData, inject.Metadata{
+		RunID: result.RunID,
+		Time:  start.UTC(),
+		Input: pipeline.InputPath,
+		From:  pipeline.Steps[0].From,
+		To:    pipeline.Steps[len(pipeline.Steps)-1].To,
+	})
+	if err != nil {
+		result.Success = false
+		result.Error = err
+		return result
+	}
+	result.Outputs = outputs
+
+	// The base only moves on once the delta computed against it is written.
+	if nextBase != nil {
+		if err := nextBase.Save(delta.BasePath(pipeline.OutputPath)); err != nil {
+			result.Success = false
+			result.Error = fmt.Errorf("failed to save delta base: %w", err)
+			return result
+		}
+	}
+
+	if pipeline.Options.Profile {
+		if err := writeProfile(pipeline, currentData); err != nil {
+			result.Success = false
+			result.Error = fmt.Errorf("failed to write profile report: %w", err)
+			return result
+		}
+	}
+
+	if pipeline.Options.Manifest.Enabled {
+		if err := writeManifest(pipeline, outputs); err != nil {
+			result.Success = false
+			result.Error = fmt.Errorf("failed to write manifest: %w", err)
+			return result
+		}
 	}
 
 	result.Duration = time.Since(start).Nanoseconds()
 	return result
 }
+
+// ConvertData runs the pipeline steps over in-memory data without touching
+// the filesystem, for callers such as the conversion service.
+func (e *PipelineExecutor) ConvertData(ctx context.Context, pipeline *models.Pipeline, input []byte) ([]byte, *models.PipelineResult) {
+	ctx, done, err := e.admit(ctx)
+	if err != nil {
+		return nil, &models.PipelineResult{Error: err}
+	}
+	defer done()
+
+	ctx, span := tracer.Start(ctx, "pipeline.convert_data", trace.WithAttributes(pipelineAttributes(pipeline)...))
+	span.SetAttributes(attribute.Int("input.size", len(input)))
+
+	start := time.Now()
+	result := &models.PipelineResult{RunID: newRunID(pipeline), Success: true}
+	e.events.Publish(events.PipelineStarted{Pipeline: pipeline, Time: start})
+
+	stepResults, output, err := e.runSteps(ctx, pipeline, input, "")
+	result.Results = stepResults
+	result.Snapshots = takeSnapshots(pipeline, stepResults)
+	result.Duration = time.Since(start).Nanoseconds()
+	span.SetAttributes(attribute.Int("output.size", len(output)))
+	endSpan(span, err)
+	if err != nil {
+		result.Success = false
+		result.Error = err
+		output = nil
+	}
+
+	e.events.Publish(events.PipelineFinished{Pipeline: pipeline, Result: result})
+	return output, result
+}
+
+// runSteps feeds data through every conversion step of the pipeline and
+// returns the per-step results together with the final output.
+func (e *PipelineExecutor) runSteps(ctx context.Context, pipeline *models.Pipeline, data []byte, stepsDir string) ([]*models.ConversionResult, []byte, error) {
+	var from models.FileFormat
+	if len(pipeline.Steps) > 0 {
+		from = pipeline.Steps[0].From
+	}
+	data, err := sanitize.Apply(data, pipeline.Options.Sanitize, from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sanitize input: %w", err)
+	}
+	if len(pipeline.Steps) > 0 {
+		if err := limits.Check(data, from, pipeline.Options.Limits); err != nil {
+			return nil, nil, fmt.Errorf("input rejected: %w", err)
+		}
+	}
+	comments, err := extractComments(pipeline, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pipeline.Options.Strategy == models.StrategyStreaming && stepsDir == "" {
+		results, output, err := e.streamSteps(ctx, pipeline, data)
+		if err != nil {
+			return results, nil, err
+		}
+		output, err = restoreComments(pipeline, comments, output)
+		return results, output, err
+	}
+
+	results := make([]*models.ConversionResult, 0, len(pipeline.Steps))
+
+	if stepsDir != "" {
+		if err := os.MkdirAll(stepsDir, 0755); err != nil {
+			return results, nil, fmt.Errorf("failed to create steps directory: %w", err)
+		}
+	}
+
+	currentData := data
+	for i, step := range pipeline.Steps {
+		if ctx.Err() != nil {
+			return results, nil, fmt.Errorf("step %d not started: %w", i+1, context.Cause(ctx))
+		}
+
+		e.events.Publish(events.StepStarted{Pipeline: pipeline, Index: i, Step: step})
+		stepStart := time.Now()
+		conversionResult, err := e.runStep(ctx, pipeline, i, step, bytes.NewReader(currentData))
+		if conversionResult != nil {
+			results = append(results, conversionResult)
+		}
+		if err != nil {
+			e.events.Publish(events.StepFailed{Pipeline: pipeline, Index: i, Step: step, Err: err})
+			return results, nil, err
+		}
+
+		e.publishQuality(pipeline, i, conversionResult)
+		e.events.Publish(events.StepCompleted{
+			Pipeline:   pipeline,
+			Index:      i,
+			Step:       step,
+			Duration:   time.Since(stepStart),
+			OutputSize: len(conversionResult.Data),
+		})
+
+		currentData = conversionResult.Data
+
+		if stepsDir != "" {
+			stepFileName := filepath.Join(stepsDir, fmt.Sprintf("step_%d_%s_to_%s.%s",
+				i+1, step.From, step.To, step.To))
+			if err := os.WriteFile(stepFileName, currentData, 0644); err != nil {
+				return results, nil, fmt.Errorf("failed to save intermediary step %d to file: %w", i+1, err)
+			}
+		}
+	}
+
+	currentData, err = restoreComments(pipeline, comments, currentData)
+	return results, currentData, err
+}
+
+// stage runs a step that keeps the format and rewrites the document.
+type stage func(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error)
+
+// stageOf returns the stage running step and the name its errors use, or
+// nil for converter steps and sub-pipelines.
+func stageOf(step models.ConversionStep) (string, stage) {
+	switch {
+	case step.Transform != nil:
+		return "transform", applyTransform
+	case step.Patch != nil:
+		return "patch", applyPatch
+	case step.Encrypt != nil:
+		return "field encryption", applyFieldEncryption
+	case step.Lookup != nil:
+		return "lookup", applyLookup
+	case step.Extract != nil:
+		return "extract", applyExtract
+	case step.Units != nil:
+		return "unit conversion", applyUnits
+	case step.Reshape != nil:
+		return string(step.Reshape.Mode), applyReshape
+	case step.Sample != nil:
+		return "sample", applySample
+	case step.Migrate != nil:
+		return "migration", applyMigration
+	case step.Search != nil:
+		return "search", applySearch
+	case step.Quality != nil:
+		return "quality", applyQuality
+	}
+	return "", nil
+}
+
+// postProcess makes the pipeline's final output match its output schema and
+// applies its post-processors.
+func postProcess(pipeline *models.Pipeline, data []byte) ([]byte, error) {
+	if pipeline.Options.OutputSchema.Path != "" {
+		coerced, err := coerceOutput(pipeline, data)
+		if err != nil {
+			return nil, err
+		}
+		data = coerced
+	}
+	if len(pipeline.Options.PostProcess) == 0 {
+		return data, nil
+	}
+
+	format := pipeline.Steps[len(pipeline.Steps)-1].To
+	data, err := postprocess.Apply(data, format, pipeline.Options.PostProcess, pipeline.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post-process output: %w", err)
+	}
+	return data, nil
+}
+
+// runStep runs a step, under its timeout when it has one.
+func (e *PipelineExecutor) runStep(ctx context.Context, pipeline *models.Pipeline, i int, step models.ConversionStep, input io.Reader) (*models.ConversionResult, error) {
+	if timeout := stepTimeout(pipeline, step); timeout.After > 0 {
+		return e.runTimedStep(ctx, pipeline, i, step, input, timeout)
+	}
+	return e.execStep(ctx, pipeline, i, step, input)
+}
+
+// execStep converts data with a pooled converter inside a span carrying the
+// step's formats and sizes.
+func (e *PipelineExecutor) execStep(ctx context.Context, pipeline *models.Pipeline, i int, step models.ConversionStep, input io.Reader) (*models.ConversionResult, error) {
+	ctx, unlabel := labelStep(ctx, i+1, step)
+	defer unlabel()
+	ctx, span := tracer.Start(ctx, "pipeline.step", trace.WithAttributes(stepAttributes(i+1, step)...))
+	if sized, ok := input.(interface{ Len() int }); ok {
+		span.SetAttributes(attribute.Int("input.size", sized.Len()))
+	}
+	input = e.trackProgress(pipeline, i, step, input)
+
+	if step.Pipeline != nil {
+		data, err := io.ReadAll(input)
+		if err != nil {
+			err = fmt.Errorf("step %d failed to read input: %w", i+1, err)
+			endSpan(span, err)
+			return nil, err
+		}
+
+		_, output, err := e.runSteps(ctx, step.Pipeline, data, "")
+		if err != nil {
+			err = fmt.Errorf("step %d sub-pipeline failed (%s→%s): %w", i+1, step.From, step.To, err)
+			endSpan(span, err)
+			return nil, err
+		}
+
+		span.SetAttributes(attribute.Int("output.size", len(output)))
+		endSpan(span, nil)
+		return &models.ConversionResult{Data: output, Format: step.To}, nil
+	}
+
+	if name, apply := stageOf(step); apply != nil {
+		conversionResult, err := apply(seedSample(pipeline, i, step), input, pipeline.Options)
+		if err != nil {
+			err = fmt.Errorf("step %d %s failed (%s): %w", i+1, name, step.From, err)
+			endSpan(span, err)
+			return nil, err
+		}
+
+		span.SetAttributes(attribute.Int("output.size", len(conversionResult.Data)))
+		endSpan(span, nil)
+		return conversionResult, nil
+	}
+
+	converterType, err := converterKey(step)
+	if err != nil {
+		err = fmt.Errorf("step %d: %w", i+1, err)
+		endSpan(span, err)
+		return nil, err
+	}
+	converter, err := e.pool.GetContext(ctx, converterType)
+	if err != nil {
+		err = fmt.Errorf("failed to get converter from pool for step %d: %w", i+1, err)
+		endSpan(span, err)
+		return nil, err
+	}
+
+	if configurable, ok := converter.(models.Configurable); ok {
+		configurable.Configure(pipeline.Options)
+	}
+
+	conversionResult, err := convertWithContext(ctx, converter, input, step, func() {
+		e.pool.Return(converterType, converter)
+	}, func() {
+		e.pool.Discard(converterType, converter)
+	})
+	if err != nil {
+		err = fmt.Errorf("step %d aborted (%s→%s): %w", i+1, step.From, step.To, err)
+		endSpan(span, err)
+		return nil, err
+	}
+
+	if conversionResult.Error != nil {
+		err = fmt.Errorf("step %d failed (%s→%s): %w", i+1, step.From, step.To, conversionResult.Error)
+		endSpan(span, err)
+		return conversionResult, err
+	}
+
+	span.SetAttributes(attribute.Int("output.size", len(conversionResult.Data)))
+	endSpan(span, nil)
+	return conversionResult, nil
+}
+
+func loadEncryptionKey(pipeline *models.Pipeline) ([]byte, error) {
+	options := pipeline.Options.Encryption
+	if !options.DecryptInput && !options.EncryptOutput {
+		return nil, nil
+	}
+	return encryption.LoadKey(options.KeyEnv, options.KeyFile)
+}
+
+func openInput(pipeline *models.Pipeline, key, data []byte) ([]byte, error) {
+	if !pipeline.Options.Encryption.DecryptInput {
+		return data, nil
+	}
+
+	plaintext, err := encryption.Decrypt(key, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt input: %w", err)
+	}
+	return plaintext, nil
+}
+
+func sealOutput(pipeline *models.Pipeline, key, data []byte) ([]byte, error) {
+	if !pipeline.Options.Encryption.EncryptOutput {
+		return data, nil
+	}
+
+	ciphertext, err := encryption.Encrypt(key, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt output: %w", err)
+	}
+	return ciphertext, nil
+}
+
+// convertWithContext stops waiting for the converter once ctx is done. The
+// converter itself cannot be interrupted, so it is left to finish in the
+// background. release is called once the converter is done either way, so
+// an aborted conversion still gives its converter back to the pool rather
+// than shrinking it for good. A step that timed out may be retried at once,
+// so its converter is given up with discard instead, making room for a
+// fresh one. A panicking converter fails the step instead of the process.
+func convertWithContext(ctx context.Context, converter models.Converter, input io.Reader, step models.ConversionStep, release, discard func()) (*models.ConversionResult, error) {
+	spanCtx, span := tracer.Start(ctx, "converter.convert", trace.WithAttributes(
+		attribute.String("converter.type", fmt.Sprintf("%T", converter)),
+		attribute.String("conversion.from", string(step.From)),
+		attribute.String("conversion.to", string(step.To)),
+	))
+	defer span.End()
+
+	finished := startConverter(ctx)
+	// settle makes sure the converter is either released or discarded,
+	// never both.
+	var settle sync.Once
+	done := make(chan *models.ConversionResult, 1)
+	go func() {
+		result := &models.ConversionResult{Format: step.To}
+		defer func() {
+			if r := recover(); r != nil {
+				result = &models.ConversionResult{Format: step.To, Error: fmt.Errorf("converter panicked: %v", r)}
+			}
+			settle.Do(release)
+			finished()
+			done <- result
+		}()
+		if contextual, ok := converter.(models.ContextConverter); ok {
+			result = contextual.ConvertContext(spanCtx, input, step.From, step.To)
+			return
+		}
+		result = converter.Convert(input, step.From, step.To)
+	}()
+
+	select {
+	case result := <-done:
+		return result, nil
+	case <-ctx.Done():
+		if errors.Is(context.Cause(ctx), ErrStepTimeout) {
+			settle.Do(discard)
+		}
+		return nil, context.Cause(ctx)
+	}
+}
+
+type convertersKey struct{}
+
+// runningConverters counts the converter goroutines started under a
+// context from TrackConverters.
+type runningConverters struct {
+	mu      sync.Mutex
+	running int
+	then    []func()
+}
+
+// TrackConverters returns a context under which the executor counts the
+// converters it runs, and a function that calls then once every one of them
+// has returned, at once if none is running. A run that times out returns
+// while its converter goroutine is still working; callers that budget
+// resources per run, such as the service's tenant slots, hold them until
+// then.
+func TrackConverters(ctx context.Context) (context.Context, func(then func())) {
+	tracked := &runningConverters{}
+	return context.WithValue(ctx, convertersKey{}, tracked), func(then func()) {
+		tracked.mu.Lock()
+		if tracked.running > 0 {
+			tracked.then = append(tracked.then, then)
+			tracked.mu.Unlock()
+			return
+		}
+		tracked.mu.Unlock()
+		then()
+	}
+}
+
+// startConverter counts a converter goroutine against ctx's tracker, if
+// any, and returns the function to call when it returns.
+func startConverter(ctx context.Context) func() {
+	tracked, ok := ctx.Value(convertersKey{}).(*runningConverters)
+	if !ok {
+		return func() {}
+	}
+	tracked.mu.Lock()
+	tracked.running++
+	tracked.mu.Unlock()
+
+	return func() {
+		tracked.mu.Lock()
+		tracked.running--
+		var then []func()
+		if tracked.running == 0 {
+			then, tracked.then = tracked.then, nil
+		}
+		tracked.mu.Unlock()
+		for _, fn := range then {
+			fn()
+		}
+	}
+}
+
+// writeManifest records the run next to the output and, when a signing key
+// is configured, writes a detached ed25519 signature over the manifest.
+func writeManifest(pipeline *models.Pipeline, outputs []string) error {
+	m, err := manifest.New(pipeline, outputs)
+	if err != nil {
+		return err
+	}
+	for i, step := range pipeline.Steps {
+		if !step.UsesConverter() {
+			continue
+		}
+		if info, err := ResolveConverter(string(step.From)+"-"+string(step.To), step.Converter); err == nil {
+			m.Steps[i].ConverterVersion = info.Version
+		}
+	}
+
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(manifest.ManifestPath(pipeline.OutputPath), data, 0644); err != nil {
+		return err
+	}
+
+	options := pipeline.Options.Manifest
+	if options.SigningKeyEnv == "" && options.SigningKeyFile == "" {
+		return nil
+	}
+
+	privateKey, err := manifest.LoadPrivateKey(options.SigningKeyEnv, options.SigningKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load signing key: %w", err)
+	}
+
+	return os.WriteFile(manifest.SignaturePath(pipeline.OutputPath), manifest.Sign(privateKey, data), 0644)
+}
+
+// writeProfile stores column statistics of the output next to it, e.g.
+// output_final.yaml -> output_final.profile.json.
+func writeProfile(pipeline *models.Pipeline, outputData []byte) error {
+	to := pipeline.Steps[len(pipeline.Steps)-1].To
+	report, err := profiling.NewProfiler().ProfileWithOptions(outputData, to, pipeline.Options)
+	if err != nil {
+		return err
+	}
+	report.Source = pipeline.OutputPath
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(ProfilePath(pipeline.OutputPath), data, 0644)
+}
+
+// validateInput checks the input records against the pipeline's schema
+// file and rules and writes the report next to the output, failing when a
+// rule of the FailOn severity or above was violated.
+func validateInput(pipeline *models.Pipeline, inputData []byte) (err error) {
+	options := pipeline.Options.Validation
+	schema := &validation.Schema{}
+	if options.SchemaPath != "" {
+		if schema, err = validation.LoadSchema(options.SchemaPath); err != nil {
+			return err
+		}
+	}
+	// Rules from the config are numbered after the file's, so their default
+	// IDs do not clash.
+	schema.Rules = append(schema.Rules, options.Rules...)
+	if err := schema.Compile(); err != nil {
+		return fmt.Errorf("validation rules: %w", err)
+	}
+	failOn, err := validation.FailOn(options.FailOn)
+	if err != nil {
+		return err
+	}
+
+	iterator, err := records.NewIterator(bytes.NewReader(inputData), pipeline.Steps[0].From, pipeline.Options)
+	if err != nil {
+		return fmt.Errorf("failed to validate input: %w", err)
+	}
+	report, err := validation.Validate(iterator, schema)
+	if err != nil {
+		return fmt.Errorf("failed to validate input: %w", err)
+	}
+	report.Source = pipeline.InputPath
+
+	format := pipeline.Options.Validation.ReportFormat
+	var out bytes.Buffer
+	if err := report.Write(&out, format); err != nil {
+		return err
+	}
+	reportPath := validation.ReportPath(pipeline.OutputPath, format)
+	if err := os.WriteFile(reportPath, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write validation report: %w", err)
+	}
+
+	if report.FailedAt(failOn) {
+		return fmt.Errorf("%w: %d errors and %d warnings in %d records, see %s", validation.ErrFailed, report.Errors, report.Warnings, report.Records, reportPath)
+	}
+	return nil
+}
+
+func ProfilePath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".profile.json"
+}
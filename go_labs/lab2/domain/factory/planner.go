@@ -0,0 +1,59 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// PlanConversion finds the shortest chain of registered converters from one
+// format to another, breadth-first over the registry. Among chains of the
+// same length it takes the first in the sorted order of the formats.
+// Versions only rank the implementations of one pair, and every step runs
+// its pair's newest, so they play no part in choosing between pairs.
+func PlanConversion(from, to models.FileFormat) ([]models.ConversionStep, error) {
+	if from == to {
+		return nil, nil
+	}
+
+	edges := make(map[models.FileFormat][]models.FileFormat)
+	for _, key := range RegisteredConversions() {
+		source, target, ok := strings.Cut(key, "-")
+		if !ok {
+			continue
+		}
+		edges[models.FileFormat(source)] = append(edges[models.FileFormat(source)], models.FileFormat(target))
+	}
+
+	previous := map[models.FileFormat]models.FileFormat{from: ""}
+	queue := []models.FileFormat{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range edges[current] {
+			if _, seen := previous[next]; seen {
+				continue
+			}
+			previous[next] = current
+			if next == to {
+				return tracePlan(previous, from, to), nil
+			}
+			queue = append(queue, next)
+		}
+	}
+
+	return nil, fmt.Errorf("no conversion path from %s to %s", from, to)
+}
+
+func tracePlan(previous map[models.FileFormat]models.FileFormat, from, to models.FileFormat) []models.ConversionStep {
+	var steps []models.ConversionStep
+	for format := to; format != from; format = previous[format] {
+		steps = append([]models.ConversionStep{{From: previous[format], To: format}}, steps...)
+	}
+	return steps
+}
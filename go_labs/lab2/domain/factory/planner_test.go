@@ -0,0 +1,21 @@
+package factory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestPlanConversionFindsShortestChain(t *testing.T) {
+	steps, err := PlanConversion(models.FormatVCard, models.FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ConversionStep{
+		{From: models.FormatVCard, To: models.FormatJSON},
+		{From: models.FormatJSON, To: models.FormatYAML},
+	}, steps)
+
+	_, err = PlanConversion(models.FormatYAML, "pdf")
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/postprocess"
+)
+
+func TestPostProcessorsShapeFinalOutput(t *testing.T) {
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	for _, strategy := range []models.ExecutionStrategy{models.StrategySequential, models.StrategyStreaming} {
+		pipeline, err := NewPipelineBuilder().
+			WithInputPath("in.csv").
+			WithOutputPath("out.json").
+			AddCSVToJSON().
+			WithStrategy(strategy).
+			WithPostProcessors(postprocess.DropEmpty, postprocess.Minify).
+			Build()
+		require.NoError(t, err)
+
+		output, result := executor.ConvertData(context.Background(), pipeline, []byte("name,email\nann,\nbob,bob@example.com\n"))
+		require.NoError(t, result.Error, strategy)
+		assert.Equal(t, `[{"name":"ann"},{"email":"bob@example.com","name":"bob"}]`, string(output), strategy)
+	}
+}
+
+func TestBuildRejectsPostProcessorForOutputFormat(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.xml").
+		AddCSVToJSON().
+		AddJSONToXML().
+		WithPostProcessors(postprocess.Minify).
+		Build()
+	assert.EqualError(t, err, `post-processor "minify" does not support xml output`)
+}
@@ -0,0 +1,94 @@
+package factory
+
+import (
+	"errors"
+	"path/filepath"
+
+	"tmps-go-labs/lab2/domain/diskspace"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// checkSpace is the disk-space check of preflight, swapped out in tests.
+var checkSpace = diskspace.Check
+
+// WithPreflight makes the executor check before every run that the output
+// directory, and the steps directory when intermediary steps are saved,
+// have room for what the run will write, failing fast with
+// diskspace.ErrInsufficientSpace otherwise. The estimate scales the input
+// size by the expansion ratios in history, which learns from every
+// successful run; saving it is up to the caller. Where free space cannot be
+// measured, runs go ahead unchecked.
+func (e *PipelineExecutor) WithPreflight(history *diskspace.History) *PipelineExecutor {
+	e.history = history
+	return e
+}
+
+// preflight checks that the directories the run writes to have room for
+// its estimated output.
+func (e *PipelineExecutor) preflight(pipeline *models.Pipeline, inputSize int) error {
+	if e.history == nil {
+		return nil
+	}
+
+	needs := make(map[string]int64)
+	var order []string
+	need := func(dir string, size int64) {
+		if _, seen := needs[dir]; !seen {
+			order = append(order, dir)
+		}
+		needs[dir] += size
+	}
+
+	size := float64(inputSize)
+	for _, step := range pipeline.Steps {
+		size *= e.history.Ratio(stepKey(step), defaultRatio(step))
+		if pipeline.Options.SaveIntermediarySteps {
+			need("steps", int64(size))
+		}
+	}
+	need(filepath.Dir(pipeline.OutputPath), int64(size))
+
+	for _, dir := range order {
+		if err := checkSpace(dir, needs[dir]); err != nil && !isUnmeasured(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordExpansion adds the expansion of every step of a successful run to
+// the history. Streamed steps keep no output to measure, so streaming runs
+// are not recorded.
+func (e *PipelineExecutor) recordExpansion(pipeline *models.Pipeline, input []byte, results []*models.ConversionResult) {
+	if e.history == nil || pipeline.Options.Strategy == models.StrategyStreaming || len(results) != len(pipeline.Steps) {
+		return
+	}
+	size := len(input)
+	for i, step := range pipeline.Steps {
+		e.history.Record(stepKey(step), size, len(results[i].Data))
+		size = len(results[i].Data)
+	}
+}
+
+// isUnmeasured reports whether err only says the free space is unknown.
+func isUnmeasured(err error) bool {
+	return !errors.Is(err, diskspace.ErrInsufficientSpace)
+}
+
+// stepKey names a step in the expansion history: "from-to" for
+// conversions and sub-pipelines, "format:stage" for stages.
+func stepKey(step models.ConversionStep) string {
+	if name, apply := stageOf(step); apply != nil {
+		return string(step.From) + ":" + name
+	}
+	return string(step.From) + "-" + string(step.To)
+}
+
+// defaultRatio is the expansion assumed for a step without history: stages
+// keep the format and roughly the size, conversions may grow it.
+func defaultRatio(step models.ConversionStep) float64 {
+	if step.From == step.To {
+		return 1
+	}
+	return diskspace.DefaultRatio
+}
@@ -0,0 +1,57 @@
+package factory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/diskspace"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab3/vfs"
+)
+
+func TestPreflightEstimatesFromHistory(t *testing.T) {
+	needs := make(map[string]int64)
+	free := int64(1 << 20)
+	original := checkSpace
+	checkSpace = func(dir string, needed int64) error {
+		needs[dir] = needed
+		if needed > free {
+			return fmt.Errorf("%w in %s", diskspace.ErrInsufficientSpace, dir)
+		}
+		return nil
+	}
+	t.Cleanup(func() { checkSpace = original })
+
+	files := vfs.NewMem(map[string][]byte{"people.csv": []byte("name,city\nann,riga\nbob,oslo\n")})
+	history := diskspace.NewHistory()
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).WithFileSystem(files).WithPreflight(history)
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("people.csv").
+		WithOutputPath("out/people.json").
+		AddConversionStep(models.FormatCSV, models.FormatNDJSON).
+		AddSample(models.FormatNDJSON, models.Sampling{Percent: 100}).
+		Build()
+	require.NoError(t, err)
+
+	require.NoError(t, executor.Execute(pipeline).Error)
+	assert.Equal(t, map[string]int64{"out": 28 * 3}, needs)
+
+	output, err := vfs.ReadFile(files, "out/people.json")
+	require.NoError(t, err)
+	ratio := float64(len(output)) / 28
+	assert.Equal(t, ratio, history.Ratio("csv-ndjson", 0))
+	assert.Equal(t, 1.0, history.Ratio("ndjson:sample", 0))
+
+	require.NoError(t, executor.Execute(pipeline).Error)
+	assert.Equal(t, int64(28*ratio), needs["out"])
+
+	free = 10
+	require.NoError(t, files.Remove("out/people.json"))
+	result := executor.Execute(pipeline)
+	assert.ErrorIs(t, result.Error, diskspace.ErrInsufficientSpace)
+	_, err = vfs.ReadFile(files, "out/people.json")
+	assert.Error(t, err)
+}
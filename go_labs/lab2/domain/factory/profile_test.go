@@ -0,0 +1,48 @@
+package factory
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tmps-go-labs/lab2/domain/profiling"
+)
+
+func TestProfilingReportsTheOutput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	output := filepath.Join(dir, "people.json")
+	require.NoError(t, os.WriteFile(input, []byte("name,age\nAnn,25\nBob,41\nCid,35\n"), 0644))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(output).
+		AddConversionStep("csv", "json").
+		AddFilter("json", "age > 30").
+		WithProfiling().
+		Build()
+	require.NoError(t, err)
+
+	result := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).Execute(pipeline)
+	require.NoError(t, result.Error)
+	data, err := os.ReadFile(ProfilePath(output))
+	require.NoError(t, err)
+	var report profiling.Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, output, report.Source)
+	assert.Equal(t, 2, report.Records)
+}
+
+func TestProfilingRejectsUnreadableOutput(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("people.csv").
+		WithOutputPath("people.md").
+		AddConversionStep("csv", "markdown").
+		WithProfiling().
+		Build()
+
+	assert.ErrorContains(t, err, "profiling not supported for format: markdown")
+}
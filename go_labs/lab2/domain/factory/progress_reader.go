@@ -0,0 +1,37 @@
+package factory
+
+import (
+	"io"
+
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// progressEvery is how many bytes a step reads between StepProgress events.
+const progressEvery = 64 << 10
+
+// progressReader publishes StepProgress as a step reads its input, so a run
+// with one long step still shows it moving.
+type progressReader struct {
+	r        io.Reader
+	bus      *events.Bus
+	pipeline *models.Pipeline
+	index    int
+	step     models.ConversionStep
+	read     int64
+	reported int64
+}
+
+func (e *PipelineExecutor) trackProgress(pipeline *models.Pipeline, i int, step models.ConversionStep, input io.Reader) io.Reader {
+	return &progressReader{r: input, bus: e.events, pipeline: pipeline, index: i, step: step}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.read-p.reported >= progressEvery || (err == io.EOF && p.read > p.reported) {
+		p.reported = p.read
+		p.bus.Publish(events.StepProgress{Pipeline: p.pipeline, Index: p.index, Step: p.step, BytesRead: p.read})
+	}
+	return n, err
+}
@@ -0,0 +1,21 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/provenance"
+	"tmps-go-labs/lab2/domain/sanitize"
+)
+
+// addProvenance tags the input records with source and runID before the
+// steps run. The input is sanitized first so the parser sees what the
+// steps would.
+func addProvenance(pipeline *models.Pipeline, data []byte, source, runID string) ([]byte, error) {
+	data, err := sanitize.Apply(data, pipeline.Options.Sanitize, pipeline.Steps[0].From)
+	if err != nil {
+		return nil, err
+	}
+	return provenance.Annotate(data, pipeline.Steps[0].From, pipeline.Options, source, runID)
+}
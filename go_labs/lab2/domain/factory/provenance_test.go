@@ -0,0 +1,55 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenanceTagsInputRecords(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	output := filepath.Join(dir, "people.ndjson")
+	require.NoError(t, os.WriteFile(input, []byte("id\n1\n2\n"), 0644))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(output).
+		AddConversionStep("csv", "ndjson").
+		WithProvenance().
+		Build()
+	require.NoError(t, err)
+
+	result := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).Execute(pipeline)
+	require.NoError(t, result.Error)
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"id":"1","_source":"people.csv","_line":"2","_run_id":"`+result.RunID+`"}`+"\n"+
+			`{"id":"2","_source":"people.csv","_line":"3","_run_id":"`+result.RunID+`"}`+"\n",
+		string(data))
+}
+
+func TestProvenanceColumnsFollowDataColumns(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	output := filepath.Join(dir, "adults.csv")
+	require.NoError(t, os.WriteFile(input, []byte("name,age\nann,40\nbob,12\n"), 0644))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(output).
+		AddFilter("csv", "age > 18").
+		WithProvenance().
+		Build()
+	require.NoError(t, err)
+
+	result := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).Execute(pipeline)
+	require.NoError(t, result.Error)
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "age,name,_source,_line,_run_id\n40,ann,people.csv,2,"+result.RunID+"\n", string(data))
+}
@@ -0,0 +1,122 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab4/rules"
+)
+
+// checkQuality returns a rules engine running the step's rules. Unique
+// rules remember the values they saw, so every run needs a new engine.
+func checkQuality(step models.ConversionStep) (*rules.Engine, error) {
+	if len(step.Quality.Rules) == 0 {
+		return nil, fmt.Errorf("quality needs rules")
+	}
+	engine := rules.New()
+	for i, spec := range step.Quality.Rules {
+		rule, err := qualityRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("quality rule %d: %w", i+1, err)
+		}
+		action, err := rules.ParseAction(spec.Action)
+		if err != nil {
+			return nil, fmt.Errorf("quality rule %d: %w", i+1, err)
+		}
+		name := spec.Name
+		if name == "" {
+			name = spec.Check + " " + spec.Field
+		}
+		engine.Add(name, rule, action)
+	}
+	return engine, nil
+}
+
+func qualityRule(spec models.QualityRule) (rules.Rule, error) {
+	if spec.Field == "" {
+		return nil, fmt.Errorf("%s needs a field", spec.Check)
+	}
+	switch spec.Check {
+	case "required":
+		return rules.Required(spec.Field), nil
+	case "pattern":
+		if spec.Pattern == "" {
+			return nil, fmt.Errorf("pattern needs a pattern")
+		}
+		return rules.Pattern(spec.Field, spec.Pattern)
+	case "range":
+		if spec.Min == nil && spec.Max == nil {
+			return nil, fmt.Errorf("range needs a min or a max")
+		}
+		return rules.Range(spec.Field, spec.Min, spec.Max), nil
+	case "one_of":
+		if len(spec.Values) == 0 {
+			return nil, fmt.Errorf("one_of needs values")
+		}
+		return rules.OneOf(spec.Field, spec.Values...), nil
+	case "unique":
+		return rules.Unique(spec.Field), nil
+	}
+	return nil, fmt.Errorf("unknown check %q; use required, pattern, range, one_of or unique", spec.Check)
+}
+
+// applyQuality parses the input, runs its records through the rules and
+// renders the records kept back in the same format. The result carries
+// the report of the violations found, which the executor publishes.
+func applyQuality(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	engine, err := checkQuality(step)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.QualityReport{Violations: make([]models.QualityViolation, 0)}
+	engine.Subscribe(rules.ObserverFunc(func(v rules.Violation) {
+		report.Violations = append(report.Violations, models.QualityViolation{
+			Record: v.Record, Rule: v.Rule, Message: v.Message, Action: string(v.Action),
+		})
+	}))
+
+	result, err := recordStage(step, input, options, func(doc *document.Document) error {
+		found := records.Find(doc.Root)
+		checked := make([]rules.Record, len(found))
+		for i, record := range found {
+			checked[i] = record
+		}
+		kept, counts, err := engine.Run(checked)
+		if err != nil {
+			return err
+		}
+		report.Checked, report.Dropped = counts.Checked, counts.Dropped
+
+		root := make([]interface{}, len(kept))
+		for i, record := range kept {
+			root[i] = record
+		}
+		doc.Root = root
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Quality = report
+	return result, nil
+}
+
+// publishQuality publishes the violations and the report of a quality
+// step once it completed.
+func (e *PipelineExecutor) publishQuality(pipeline *models.Pipeline, i int, result *models.ConversionResult) {
+	if result == nil || result.Quality == nil {
+		return
+	}
+	for _, violation := range result.Quality.Violations {
+		e.events.Publish(events.RuleViolated{Pipeline: pipeline, Index: i, Violation: violation})
+	}
+	e.events.Publish(events.QualityChecked{Pipeline: pipeline, Index: i, Report: *result.Quality})
+}
@@ -0,0 +1,64 @@
+package factory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab3/vfs"
+)
+
+func TestQualityStep(t *testing.T) {
+	files := vfs.NewMem(map[string][]byte{"orders.csv": []byte("id,status,amount\n1,open,5\n2,lost,7\n,open,1\n4,open,12\n")})
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).WithFileSystem(files)
+	var published []events.Event
+	executor.Events().Subscribe(events.ObserverFunc(func(event events.Event) {
+		switch event.(type) {
+		case events.RuleViolated, events.QualityChecked:
+			published = append(published, event)
+		}
+	}))
+
+	ten := 10.0
+	run := func(rules ...models.QualityRule) *models.PipelineResult {
+		pipeline, err := NewPipelineBuilder().
+			WithInputPath("orders.csv").
+			WithOutputPath("orders.ndjson").
+			AddConversionStep(models.FormatCSV, models.FormatNDJSON).
+			AddQuality(models.FormatNDJSON, models.Quality{Rules: rules}).
+			Build()
+		require.NoError(t, err)
+		return executor.Execute(pipeline)
+	}
+
+	result := run(
+		models.QualityRule{Field: "id", Check: "required", Action: "drop"},
+		models.QualityRule{Name: "known status", Field: "status", Check: "one_of", Values: []string{"open", "closed"}},
+		models.QualityRule{Field: "amount", Check: "range", Max: &ten, Action: "drop"},
+	)
+	require.NoError(t, result.Error)
+	data, err := vfs.ReadFile(files, "orders.ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, "{\"amount\":\"5\",\"id\":\"1\",\"status\":\"open\"}\n{\"amount\":\"7\",\"id\":\"2\",\"status\":\"lost\"}\n", string(data))
+
+	report := models.QualityReport{Checked: 4, Dropped: 2, Violations: []models.QualityViolation{
+		{Record: 2, Rule: "known status", Message: `status "lost" is not one of open, closed`, Action: "warn"},
+		{Record: 3, Rule: "required id", Message: "id is missing", Action: "drop"},
+		{Record: 4, Rule: "range amount", Message: "amount 12 is above 10", Action: "drop"},
+	}}
+	assert.Equal(t, report, *result.Results[1].Quality)
+	require.Len(t, published, 4)
+	assert.Equal(t, report.Violations[1], published[1].(events.RuleViolated).Violation)
+	assert.Equal(t, 1, published[3].(events.QualityChecked).Index)
+
+	result = run(models.QualityRule{Field: "amount", Check: "range", Max: &ten, Action: "fail"})
+	assert.EqualError(t, result.Error, "step 2 quality failed (ndjson): data quality check failed: record 4: range amount: amount 12 is above 10")
+
+	_, err = NewPipelineBuilder().AddQuality(models.FormatNDJSON, models.Quality{Rules: []models.QualityRule{{Field: "id", Check: "positive"}}}).Build()
+	assert.EqualError(t, err, `step 1: quality rule 1: unknown check "positive"; use required, pattern, range, one_of or unique`)
+	_, err = NewPipelineBuilder().AddQuality(models.FormatNDJSON, models.Quality{Rules: []models.QualityRule{{Field: "id", Check: "unique", Action: "skip"}}}).Build()
+	assert.EqualError(t, err, `step 1: quality rule 1: unknown action "skip"; use warn, drop or fail`)
+}
@@ -0,0 +1,58 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// checkStage rejects a step that sets more than one stage, since only the
+// first would run, and a record stage that changes the format or whose
+// format cannot be both parsed and rendered.
+func checkStage(step models.ConversionStep) error {
+	if stages := step.Stages(); len(stages) > 1 {
+		return fmt.Errorf("step sets %s; give each its own step", strings.Join(stages, ", "))
+	}
+	name, run := stageOf(step)
+	if run == nil {
+		return nil
+	}
+	if step.From != step.To {
+		return fmt.Errorf("%s keeps the format, but the step goes from %s to %s", name, step.From, step.To)
+	}
+	if _, ok := document.ParserFor(step.From); !ok {
+		return fmt.Errorf("%s needs a parser for %s", name, step.From)
+	}
+	if _, ok := document.RendererFor(step.From); !ok {
+		return fmt.Errorf("%s needs a renderer for %s", name, step.From)
+	}
+	return nil
+}
+
+// recordStage parses the input of a record stage, lets edit rewrite the
+// document, usually its records, and renders it back in the same format.
+func recordStage(step models.ConversionStep, input io.Reader, options models.ConversionOptions, edit func(doc *document.Document) error) (*models.ConversionResult, error) {
+	if err := checkStage(step); err != nil {
+		return nil, err
+	}
+
+	doc, err := document.Parse(input, step.From, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := edit(doc); err != nil {
+		return nil, err
+	}
+
+	data, err := document.Render(doc, step.To, options)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ConversionResult{Data: data, Format: step.To}, nil
+}
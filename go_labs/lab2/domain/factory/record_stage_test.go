@@ -0,0 +1,50 @@
+package factory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func buildSteps(steps ...models.ConversionStep) error {
+	_, err := NewPipelineBuilderFrom(&models.Pipeline{InputPath: "in.csv", OutputPath: "out.csv", Steps: steps}).Build()
+	return err
+}
+
+func TestBuildRejectsStepWithTwoStages(t *testing.T) {
+	err := buildSteps(models.ConversionStep{
+		From:   models.FormatCSV,
+		To:     models.FormatCSV,
+		Search: &models.Search{Query: "ann"},
+		Sample: &models.Sampling{Every: 2},
+	})
+	assert.EqualError(t, err, "step 1: step sets Sample, Search; give each its own step")
+}
+
+func TestBuildRejectsStageThatChangesFormat(t *testing.T) {
+	err := buildSteps(models.ConversionStep{
+		From:   models.FormatCSV,
+		To:     models.FormatJSON,
+		Search: &models.Search{Query: "ann"},
+	})
+	assert.EqualError(t, err, "step 1: search keeps the format, but the step goes from csv to json")
+}
+
+func TestBuildRejectsStageWithoutRenderer(t *testing.T) {
+	err := buildSteps(models.ConversionStep{
+		From:   models.FormatXLSX,
+		To:     models.FormatXLSX,
+		Sample: &models.Sampling{Every: 2},
+	})
+	assert.EqualError(t, err, "step 1: sample needs a renderer for xlsx")
+}
+
+func TestRecordStageRejectsFormatChangeAtRunTime(t *testing.T) {
+	step := models.ConversionStep{From: models.FormatCSV, To: models.FormatJSON, Search: &models.Search{Query: "ann"}}
+
+	_, err := applySearch(step, strings.NewReader("name\nann\n"), models.ConversionOptions{})
+	assert.EqualError(t, err, "search keeps the format, but the step goes from csv to json")
+}
@@ -0,0 +1,76 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/reshape"
+)
+
+func checkReshape(step models.ConversionStep) error {
+	spec := step.Reshape
+	switch spec.Mode {
+	case models.ReshapeUnpivot:
+	case models.ReshapePivot:
+		if len(spec.ID) == 0 {
+			return fmt.Errorf("pivot needs at least one id field")
+		}
+		if len(spec.Columns) > 0 || spec.DropEmpty {
+			return fmt.Errorf("pivot takes no columns or drop empty; they only apply to unpivot")
+		}
+	default:
+		return fmt.Errorf("unknown reshape mode %q; use %s or %s", spec.Mode, models.ReshapePivot, models.ReshapeUnpivot)
+	}
+	name, value := reshapeFields(spec)
+	if name == value {
+		return fmt.Errorf("%s name and value fields must differ", spec.Mode)
+	}
+	return nil
+}
+
+func reshapeFields(spec *models.Reshape) (name, value string) {
+	name, value = spec.Name, spec.Value
+	if name == "" {
+		name = reshape.DefaultNameField
+	}
+	if value == "" {
+		value = reshape.DefaultValueField
+	}
+	return name, value
+}
+
+// applyReshape parses the input, pivots or unpivots its records and
+// renders the new records in the same format.
+func applyReshape(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	spec := step.Reshape
+	if err := checkReshape(step); err != nil {
+		return nil, err
+	}
+
+	name, value := reshapeFields(spec)
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		rows := records.Find(doc.Root)
+		var reshaped []records.Record
+		if spec.Mode == models.ReshapePivot {
+			var err error
+			if reshaped, err = reshape.Pivot(rows, spec.ID, name, value); err != nil {
+				return err
+			}
+		} else {
+			reshaped = reshape.Unpivot(rows, spec.ID, spec.Columns, name, value, spec.DropEmpty)
+		}
+
+		root := make([]interface{}, len(reshaped))
+		for i, record := range reshaped {
+			root[i] = map[string]interface{}(record)
+		}
+		doc.Root = root
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestReshapeSteps(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "survey.csv")
+	require.NoError(t, os.WriteFile(input, []byte("respondent,q1,q2\n1,yes,no\n2,no,\n"), 0644))
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "answers.csv")).
+		AddUnpivot(models.FormatCSV, models.Reshape{ID: []string{"respondent"}, Name: "question", Value: "answer", DropEmpty: true}).
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(pipeline).Error)
+	long, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "answer,question,respondent\nyes,q1,1\nno,q2,1\nno,q1,2\n", string(long))
+
+	pipeline, err = NewPipelineBuilder().
+		WithInputPath(pipeline.OutputPath).
+		WithOutputPath(filepath.Join(dir, "survey.json")).
+		AddPivot(models.FormatCSV, models.Reshape{ID: []string{"respondent"}, Name: "question", Value: "answer"}).
+		AddCSVToJSON().
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(pipeline).Error)
+	wide, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"respondent":"1","q1":"yes","q2":"no"},{"respondent":"2","q1":"no","q2":""}]`, string(wide))
+}
+
+func TestBuildRejectsPivotWithoutIDs(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.csv").
+		AddPivot(models.FormatCSV, models.Reshape{}).
+		Build()
+	assert.EqualError(t, err, "step 1: pivot needs at least one id field")
+}
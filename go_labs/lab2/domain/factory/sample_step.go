@@ -0,0 +1,73 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/random"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func checkSample(step models.ConversionStep) error {
+	sampling := step.Sample
+	switch {
+	case sampling.Every < 0:
+		return fmt.Errorf("sample every must be positive")
+	case sampling.Every > 0 && sampling.Percent != 0:
+		return fmt.Errorf("sample takes a percent or every k-th record, not both")
+	case sampling.Every == 0 && (sampling.Percent <= 0 || sampling.Percent > 100):
+		return fmt.Errorf("sample percent must be above 0 and at most 100, got %v", sampling.Percent)
+	case sampling.Every > 0 && sampling.Seed != nil:
+		return fmt.Errorf("sample seed only applies to random samples")
+	}
+	return nil
+}
+
+// seedSample gives a random sample step without a seed of its own one
+// derived from the pipeline's seed and the step's number, so each sample
+// step of a seeded pipeline repeats its draws independently of the others.
+func seedSample(pipeline *models.Pipeline, i int, step models.ConversionStep) models.ConversionStep {
+	if step.Sample == nil || step.Sample.Seed != nil || step.Sample.Every > 0 || pipeline.Options.Seed == nil {
+		return step
+	}
+	sampling := *step.Sample
+	seed := random.Derive(*pipeline.Options.Seed, "sample", i+1)
+	sampling.Seed = &seed
+	step.Sample = &sampling
+	return step
+}
+
+// applySample parses the input, keeps a sample of its records and renders
+// them back in the same format, in their original order.
+func applySample(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	sampling := step.Sample
+	if err := checkSample(step); err != nil {
+		return nil, err
+	}
+
+	source := random.Default()
+	if sampling.Seed != nil {
+		source = random.New(*sampling.Seed)
+	}
+
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		kept := make([]interface{}, 0)
+		for i, record := range records.Find(doc.Root) {
+			if sampling.Every > 0 {
+				if i%sampling.Every != 0 {
+					continue
+				}
+			} else if source.Float64()*100 >= sampling.Percent {
+				continue
+			}
+			kept = append(kept, map[string]interface{}(record))
+		}
+		doc.Root = kept
+		return nil
+	})
+}
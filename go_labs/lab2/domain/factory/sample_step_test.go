@@ -0,0 +1,94 @@
+package factory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab3/vfs"
+)
+
+func TestSampleStep(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "numbers.ndjson")
+	var lines strings.Builder
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintf(&lines, "{\"n\":%d}\n", i)
+	}
+	require.NoError(t, os.WriteFile(input, []byte(lines.String()), 0644))
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	run := func(sampling models.Sampling) string {
+		pipeline, err := NewPipelineBuilder().
+			WithInputPath(input).
+			WithOutputPath(filepath.Join(dir, "sample.ndjson")).
+			AddSample(models.FormatNDJSON, sampling).
+			Build()
+		require.NoError(t, err)
+		require.NoError(t, executor.Execute(pipeline).Error)
+		data, err := os.ReadFile(pipeline.OutputPath)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	systematic := strings.Split(strings.TrimSpace(run(models.Sampling{Every: 250})), "\n")
+	assert.Equal(t, []string{`{"n":1}`, `{"n":251}`, `{"n":501}`, `{"n":751}`}, systematic)
+
+	seed := int64(42)
+	first := run(models.Sampling{Percent: 10, Seed: &seed})
+	assert.Equal(t, first, run(models.Sampling{Percent: 10, Seed: &seed}), "the same seed draws the same sample")
+	assert.InDelta(t, 100, strings.Count(first, "\n"), 40)
+}
+
+func TestBuildRejectsAmbiguousSamples(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.csv").
+		AddSample(models.FormatCSV, models.Sampling{Percent: 10, Every: 5}).
+		Build()
+	assert.EqualError(t, err, "step 1: sample takes a percent or every k-th record, not both")
+}
+
+func TestPipelineSeedRepeatsRuns(t *testing.T) {
+	var lines strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&lines, "{\"n\":%d}\n", i)
+	}
+	files := vfs.NewMem(map[string][]byte{"numbers.ndjson": []byte(lines.String())})
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).WithFileSystem(files)
+
+	run := func(seed *int64) (string, string, string) {
+		builder := NewPipelineBuilder().
+			WithInputPath("numbers.ndjson").
+			WithOutputPath("sample.ndjson").
+			AddSample(models.FormatNDJSON, models.Sampling{Percent: 50}).
+			AddSample(models.FormatNDJSON, models.Sampling{Percent: 50})
+		if seed != nil {
+			builder.WithSeed(*seed)
+		}
+		pipeline, err := builder.Build()
+		require.NoError(t, err)
+		result := executor.Execute(pipeline)
+		require.NoError(t, result.Error)
+		data, err := vfs.ReadFile(files, "sample.ndjson")
+		require.NoError(t, err)
+		return result.RunID, string(result.Results[0].Data), string(data)
+	}
+
+	seed := int64(7)
+	runID, firstStep, output := run(&seed)
+	againID, againFirstStep, againOutput := run(&seed)
+	assert.Equal(t, runID, againID)
+	assert.Equal(t, firstStep, againFirstStep)
+	assert.Equal(t, output, againOutput)
+
+	otherID, _, _ := run(nil)
+	assert.NotEqual(t, runID, otherID)
+	assert.Len(t, otherID, 16)
+}
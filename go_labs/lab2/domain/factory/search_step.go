@@ -0,0 +1,91 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab1/search"
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+func checkSearch(step models.ConversionStep) (search.Engine, error) {
+	name := step.Search.Engine
+	if name == "" {
+		name = "literal"
+	}
+	engine, ok := search.New(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown search engine %q; use %s", name, strings.Join(search.Names(), ", "))
+	}
+	if step.Search.Query == "" {
+		return nil, fmt.Errorf("search needs a query")
+	}
+	if name == "regex" {
+		if _, err := regexp.Compile(step.Search.Query); err != nil {
+			return nil, fmt.Errorf("invalid search pattern: %w", err)
+		}
+	}
+	return engine, nil
+}
+
+// applySearch parses the input, keeps the records with a field value that
+// matches the query and renders them back in the same format, in their
+// order.
+func applySearch(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	engine, err := checkSearch(step)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		kept := make([]interface{}, 0)
+		for _, record := range records.Find(doc.Root) {
+			if matchesField(engine, step.Search.Query, map[string]interface{}(record)) != step.Search.Invert {
+				kept = append(kept, map[string]interface{}(record))
+			}
+		}
+		doc.Root = kept
+		return nil
+	})
+}
+
+// matchesField reports whether any scalar in value, however deeply nested,
+// matches query. Strings are searched as they are, without the escaping of
+// a JSON encoding, and other scalars as text formats write them.
+func matchesField(engine search.Engine, query string, value interface{}) bool {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for _, child := range typed {
+			if matchesField(engine, query, child) {
+				return true
+			}
+		}
+		return false
+	case []interface{}:
+		for _, child := range typed {
+			if matchesField(engine, query, child) {
+				return true
+			}
+		}
+		return false
+	case nil:
+		return false
+	case string:
+		return engine.Search(typed, query)
+	case float64:
+		return engine.Search(strconv.FormatFloat(typed, 'f', -1, 64), query)
+	}
+	if text, ok := values.Text(value); ok {
+		return engine.Search(text, query)
+	}
+	return engine.Search(fmt.Sprint(value), query)
+}
@@ -0,0 +1,48 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestSearchStep(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	require.NoError(t, os.WriteFile(input, []byte("name,city\nAnn,Paris\nBob,Oslo\nCleo,Porto\n"), 0644))
+
+	run := func(search models.Search) string {
+		pipeline, err := NewPipelineBuilder().
+			WithInputPath(input).
+			WithOutputPath(filepath.Join(dir, "people.ndjson")).
+			AddConversionStep(models.FormatCSV, models.FormatNDJSON).
+			AddSearch(models.FormatNDJSON, search).
+			Build()
+		require.NoError(t, err)
+		require.NoError(t, NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).Execute(pipeline).Error)
+		data, err := os.ReadFile(pipeline.OutputPath)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "{\"city\":\"Oslo\",\"name\":\"Bob\"}\n", run(models.Search{Query: "Oslo"}))
+	assert.Equal(t, "{\"city\":\"Paris\",\"name\":\"Ann\"}\n{\"city\":\"Porto\",\"name\":\"Cleo\"}\n",
+		run(models.Search{Engine: "regex", Query: `^P`}))
+	assert.Empty(t, run(models.Search{Query: "city"}), "keys are not searched")
+	assert.Equal(t, "{\"city\":\"Paris\",\"name\":\"Ann\"}\n{\"city\":\"Oslo\",\"name\":\"Bob\"}\n",
+		run(models.Search{Engine: "fuzzy", Query: "porto", Invert: true}))
+
+	require.NoError(t, os.WriteFile(input, []byte("name,note\nAnn,\"say \"\"hi\"\"\"\nBob,R&D <lab>\nCleo,plain\n"), 0644))
+	assert.Equal(t, "{\"name\":\"Ann\",\"note\":\"say \\\"hi\\\"\"}\n", run(models.Search{Query: `say "hi"`}))
+	assert.Equal(t, "{\"name\":\"Bob\",\"note\":\"R\\u0026D \\u003clab\\u003e\"}\n", run(models.Search{Query: "R&D <lab>"}))
+
+	_, err := NewPipelineBuilder().AddSearch(models.FormatNDJSON, models.Search{Engine: "soundex", Query: "x"}).Build()
+	assert.EqualError(t, err, `step 1: unknown search engine "soundex"; use fuzzy, literal, regex`)
+	_, err = NewPipelineBuilder().AddSearch(models.FormatNDJSON, models.Search{Engine: "regex", Query: "["}).Build()
+	assert.ErrorContains(t, err, "step 1: invalid search pattern")
+}
@@ -0,0 +1,80 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+var ErrShuttingDown = errors.New("executor is shutting down")
+
+// admit registers a run and derives a context that is cancelled with
+// ErrShuttingDown if Shutdown's deadline passes before the run finishes.
+func (e *PipelineExecutor) admit(ctx context.Context) (context.Context, func(), error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closing {
+		return nil, nil, ErrShuttingDown
+	}
+	e.inFlight.Add(1)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(e.abort, func() { cancel(ErrShuttingDown) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+		e.inFlight.Done()
+	}, nil
+}
+
+// Shutdown stops accepting new runs and waits for in-flight ones. When ctx
+// expires first the remaining runs are aborted and fail with ErrShuttingDown;
+// Shutdown still waits for them to unwind so no output is written afterwards.
+func (e *PipelineExecutor) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	e.closing = true
+	e.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		e.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		e.abortRuns()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// writeFileAtomic writes through a temporary file and renames it into place,
+// so an aborted run never leaves a truncated output behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
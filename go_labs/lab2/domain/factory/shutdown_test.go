@@ -0,0 +1,50 @@
+package factory
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+type blockingConverter struct {
+	release chan struct{}
+}
+
+func (c *blockingConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	<-c.release
+	return &models.ConversionResult{}
+}
+
+func (c *blockingConverter) SupportsFormat(format models.FileFormat) bool {
+	return true
+}
+
+func TestShutdownAbortsRunsPastDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	RegisterConverter("blocking-json", func() models.Converter { return &blockingConverter{release: release} })
+	t.Cleanup(func() { UnregisterConverter("blocking-json") })
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	pipeline := &models.Pipeline{Steps: []models.ConversionStep{{From: "blocking", To: models.FormatJSON}}}
+
+	finished := make(chan *models.PipelineResult)
+	go func() {
+		_, result := executor.ConvertData(context.Background(), pipeline, nil)
+		finished <- result
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, executor.Shutdown(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, (<-finished).Error, ErrShuttingDown)
+
+	_, result := executor.ConvertData(context.Background(), pipeline, nil)
+	assert.ErrorIs(t, result.Error, ErrShuttingDown)
+}
@@ -0,0 +1,59 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"context"
+	"fmt"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// takeSnapshots captures the output of every step listed in the pipeline's
+// SnapshotSteps that completed successfully.
+func takeSnapshots(pipeline *models.Pipeline, results []*models.ConversionResult) []*models.Snapshot {
+	var snapshots []*models.Snapshot
+	for _, step := range pipeline.Options.SnapshotSteps {
+		if step < 1 || step > len(results) || results[step-1].Error != nil {
+			continue
+		}
+		snapshots = append(snapshots, models.NewSnapshot(step, pipeline.Steps[step-1].To, results[step-1].Data))
+	}
+	return snapshots
+}
+
+// Resume runs only the steps of the pipeline after the snapshot's step,
+// starting from the snapshot data. Input sanitizers are not applied again.
+// Step numbers in the result, including any new snapshots, stay relative to
+// the whole pipeline.
+func (e *PipelineExecutor) Resume(ctx context.Context, pipeline *models.Pipeline, snapshot *models.Snapshot) ([]byte, *models.PipelineResult) {
+	step := snapshot.Step()
+	if step < 1 || step > len(pipeline.Steps) {
+		return nil, &models.PipelineResult{Error: fmt.Errorf("snapshot step %d is outside the pipeline's %d steps", step, len(pipeline.Steps))}
+	}
+	if format := pipeline.Steps[step-1].To; format != snapshot.Format() {
+		return nil, &models.PipelineResult{Error: fmt.Errorf("snapshot holds %s but step %d produces %s", snapshot.Format(), step, format)}
+	}
+	if step == len(pipeline.Steps) {
+		return snapshot.Data(), &models.PipelineResult{Success: true}
+	}
+
+	remaining := &models.Pipeline{
+		Steps:   pipeline.Steps[step:],
+		Options: pipeline.Options,
+	}
+	remaining.Options.Sanitize = models.SanitizeOptions{}
+	remaining.Options.SnapshotSteps = nil
+	for _, n := range pipeline.Options.SnapshotSteps {
+		if n > step {
+			remaining.Options.SnapshotSteps = append(remaining.Options.SnapshotSteps, n-step)
+		}
+	}
+
+	output, result := e.ConvertData(ctx, remaining, snapshot.Data())
+	for i, taken := range result.Snapshots {
+		result.Snapshots[i] = models.NewSnapshot(taken.Step()+step, taken.Format(), taken.Data())
+	}
+	return output, result
+}
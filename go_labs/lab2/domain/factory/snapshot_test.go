@@ -0,0 +1,54 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestResumeFromEditedSnapshot(t *testing.T) {
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.yaml").
+		WithSnapshots(1).
+		AddCSVToJSON().
+		AddJSONToXML().
+		AddXMLToYAML().
+		Build()
+	assert.NoError(t, err)
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	_, result := executor.ConvertData(context.Background(), pipeline, []byte("name\nann\n"))
+	assert.NoError(t, result.Error)
+	assert.Len(t, result.Snapshots, 1)
+
+	snapshot := result.Snapshots[0]
+	assert.Equal(t, 1, snapshot.Step())
+	assert.Equal(t, models.FormatJSON, snapshot.Format())
+
+	doc, err := document.FromSnapshot(snapshot, pipeline.Options)
+	assert.NoError(t, err)
+	doc.Root.([]interface{})[0].(map[string]interface{})["name"] = "bob"
+	edited, err := document.ReplaceSnapshot(snapshot, doc, pipeline.Options)
+	assert.NoError(t, err)
+
+	output, resumed := executor.Resume(context.Background(), pipeline, edited)
+	assert.NoError(t, resumed.Error)
+	assert.Len(t, resumed.Results, 2)
+	assert.Contains(t, string(output), "name: bob")
+}
+
+func TestResumeRejectsMismatchedSnapshot(t *testing.T) {
+	pipeline := &models.Pipeline{Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}}}
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	_, result := executor.Resume(context.Background(), pipeline, models.NewSnapshot(1, models.FormatXML, nil))
+	assert.Error(t, result.Error)
+
+	_, result = executor.Resume(context.Background(), pipeline, models.NewSnapshot(2, models.FormatJSON, nil))
+	assert.Error(t, result.Error)
+}
@@ -0,0 +1,28 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/split"
+)
+
+func checkSplitOutput(pipeline *models.Pipeline) error {
+	options := pipeline.Options.Split
+	if options.MaxRows < 0 || options.MaxBytes < 0 {
+		return fmt.Errorf("split limits cannot be negative")
+	}
+	if IsArchive(pipeline.InputPath) {
+		return fmt.Errorf("split output is not supported for archive input")
+	}
+	if pipeline.Options.Encryption.EncryptOutput {
+		return fmt.Errorf("split output cannot be combined with encrypted output")
+	}
+	if format := pipeline.Steps[len(pipeline.Steps)-1].To; !split.Supported(format) {
+		return fmt.Errorf("split output needs csv or ndjson output, not %s", format)
+	}
+	return nil
+}
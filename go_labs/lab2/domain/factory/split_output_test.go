@@ -0,0 +1,73 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitOutputAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	output := filepath.Join(dir, "people.ndjson")
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(output).
+		AddConversionStep("csv", "ndjson").
+		WithSplit(2, 0).
+		Build()
+	require.NoError(t, err)
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	require.NoError(t, os.WriteFile(input, []byte("id\n1\n2\n3\n"), 0644))
+	result := executor.Execute(pipeline)
+	require.NoError(t, result.Error)
+	assert.Equal(t, []string{filepath.Join(dir, "people-0001.ndjson"), filepath.Join(dir, "people-0002.ndjson")}, result.Outputs)
+	data, err := os.ReadFile(result.Outputs[1])
+	require.NoError(t, err)
+	assert.Equal(t, "{\"id\":\"3\"}\n", string(data))
+	assert.NoFileExists(t, output)
+
+	require.NoError(t, os.WriteFile(input, []byte("id\n1\n"), 0644))
+	result = executor.Execute(pipeline)
+	require.NoError(t, result.Error)
+	assert.Len(t, result.Outputs, 1)
+	assert.NoFileExists(t, filepath.Join(dir, "people-0002.ndjson"), "parts of the previous run are removed")
+}
+
+func TestBuildRejectsSplitOfJSON(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.json").
+		AddConversionStep("csv", "json").
+		WithSplit(0, 1<<20).
+		Build()
+	assert.EqualError(t, err, "split output needs csv or ndjson output, not json")
+}
+
+func TestSplitOutputGetsAHeaderPerPart(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.json")
+	require.NoError(t, os.WriteFile(input, []byte(`[{"id":"1"},{"id":"2"},{"id":"3"}]`), 0644))
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "people.csv")).
+		AddConversionStep("json", "csv").
+		WithSplit(2, 0).
+		WithHeader("run {{.RunID}}, part {{.Part}} of {{.Parts}}").
+		WithCommentedInjection().
+		Build()
+	require.NoError(t, err)
+
+	result := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).Execute(pipeline)
+	require.NoError(t, result.Error)
+	require.Len(t, result.Outputs, 2)
+	data, err := os.ReadFile(result.Outputs[1])
+	require.NoError(t, err)
+	assert.Equal(t, "# run "+result.RunID+", part 2 of 2\nid\n3\n", string(data))
+}
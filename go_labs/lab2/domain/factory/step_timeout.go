@@ -0,0 +1,101 @@
+package factory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// ErrStepTimeout is the cause of steps cancelled for running past their
+// timeout.
+var ErrStepTimeout = errors.New("step timed out")
+
+// stepTimeout returns the timeout bounding step: its own, or the
+// pipeline's StepTimeout.
+func stepTimeout(pipeline *models.Pipeline, step models.ConversionStep) models.StepTimeout {
+	if step.Timeout != nil {
+		return *step.Timeout
+	}
+	return pipeline.Options.StepTimeout
+}
+
+func checkStepTimeout(step models.ConversionStep, timeout models.StepTimeout) error {
+	if timeout.After < 0 {
+		return fmt.Errorf("step timeout must not be negative")
+	}
+	if timeout.Retries < 0 {
+		return fmt.Errorf("timeout retries must not be negative")
+	}
+	switch timeout.Policy {
+	case "", models.TimeoutAbort, models.TimeoutRetry:
+	case models.TimeoutSkip:
+		if step.From != step.To {
+			return fmt.Errorf("a %s→%s step cannot be skipped on timeout, since it changes the format", step.From, step.To)
+		}
+	default:
+		return fmt.Errorf("unknown timeout policy %q", timeout.Policy)
+	}
+	return nil
+}
+
+// runTimedStep runs a step under its timeout, cancelling it through its
+// context when the timeout passes, and then skips, retries or fails it as
+// the timeout's policy says. Every timeout publishes a StepTimedOut event.
+func (e *PipelineExecutor) runTimedStep(ctx context.Context, pipeline *models.Pipeline, i int, step models.ConversionStep, input io.Reader, timeout models.StepTimeout) (*models.ConversionResult, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("step %d failed to read input: %w", i+1, err)
+	}
+
+	retries := 0
+	if timeout.Policy == models.TimeoutRetry {
+		retries = max(timeout.Retries, 1)
+	}
+	for attempt := 1; ; attempt++ {
+		result, err := e.attemptStep(ctx, pipeline, i, step, data, timeout)
+		if !errors.Is(err, ErrStepTimeout) {
+			return result, err
+		}
+
+		e.events.Publish(events.StepTimedOut{Pipeline: pipeline, Index: i, Step: step, After: timeout.After, Attempt: attempt, Policy: timeout.Policy})
+		switch {
+		case timeout.Policy == models.TimeoutSkip:
+			return &models.ConversionResult{Data: data, Format: step.To}, nil
+		case attempt <= retries:
+			continue
+		}
+		return nil, err
+	}
+}
+
+// attemptStep runs a step once with a context timing out after
+// timeout.After. A stage that does not watch the context keeps running in
+// the background, but the step returns as soon as the timeout passes; a
+// converter stuck that way is discarded from the pool, so a retry is not
+// left waiting for it.
+func (e *PipelineExecutor) attemptStep(ctx context.Context, pipeline *models.Pipeline, i int, step models.ConversionStep, data []byte, timeout models.StepTimeout) (*models.ConversionResult, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout.After, fmt.Errorf("%w after %s", ErrStepTimeout, timeout.After))
+	defer cancel()
+
+	type outcome struct {
+		result *models.ConversionResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := e.execStep(ctx, pipeline, i, step, bytes.NewReader(data))
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("step %d: %w", i+1, context.Cause(ctx))
+	}
+}
@@ -0,0 +1,163 @@
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab3/vfs"
+)
+
+// hangingConverter hangs on its first hangs calls until the test ends,
+// and tags its input as converted afterwards.
+type hangingConverter struct {
+	calls   *atomic.Int32
+	hangs   *atomic.Int32
+	release chan struct{}
+}
+
+func (c hangingConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if c.calls.Add(1) <= c.hangs.Load() {
+		<-c.release
+	}
+	data, err := io.ReadAll(input)
+	return &models.ConversionResult{Data: append(data, " converted"...), Format: to, Error: err}
+}
+
+func (c hangingConverter) SupportsFormat(format models.FileFormat) bool { return true }
+
+func TestStepTimeoutPolicies(t *testing.T) {
+	var calls, hangs atomic.Int32
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	RegisterConverter("slow-slow", func() models.Converter {
+		return hangingConverter{calls: &calls, hangs: &hangs, release: release}
+	})
+	t.Cleanup(func() { UnregisterConverter("slow-slow") })
+
+	files := vfs.NewMem(map[string][]byte{"in.slow": []byte("data")})
+	// Timed-out converters are discarded, so retries get a fresh one even
+	// from a pool of one.
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).WithFileSystem(files)
+	var timedOut []events.StepTimedOut
+	executor.Events().Subscribe(events.ObserverFunc(func(event events.Event) {
+		if e, ok := event.(events.StepTimedOut); ok {
+			timedOut = append(timedOut, e)
+		}
+	}))
+
+	run := func(hanging int32, timeout models.StepTimeout) (*models.PipelineResult, string) {
+		calls.Store(0)
+		hangs.Store(hanging)
+		timedOut = nil
+		timeout.After = 20 * time.Millisecond
+		pipeline, err := NewPipelineBuilder().
+			WithInputPath("in.slow").
+			WithOutputPath("out.slow").
+			AddConversionStep("slow", "slow").
+			WithLastStepTimeout(timeout).
+			Build()
+		require.NoError(t, err)
+		result := executor.Execute(pipeline)
+		data, _ := vfs.ReadFile(files, "out.slow")
+		return result, string(data)
+	}
+
+	result, _ := run(1, models.StepTimeout{})
+	assert.ErrorIs(t, result.Error, ErrStepTimeout)
+	assert.ErrorContains(t, result.Error, "step timed out after 20ms")
+	require.Len(t, timedOut, 1)
+	assert.Equal(t, 1, timedOut[0].Attempt)
+
+	result, output := run(1, models.StepTimeout{Policy: models.TimeoutSkip})
+	require.NoError(t, result.Error)
+	assert.Equal(t, "data", output)
+
+	result, output = run(2, models.StepTimeout{Policy: models.TimeoutRetry, Retries: 2})
+	require.NoError(t, result.Error)
+	assert.Equal(t, "data converted", output)
+	assert.Len(t, timedOut, 2)
+
+	result, _ = run(2, models.StepTimeout{Policy: models.TimeoutRetry})
+	assert.ErrorIs(t, result.Error, ErrStepTimeout)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+// contextConverter waits for its step's context to end, reports why and
+// closes returned.
+type contextConverter struct {
+	returned chan struct{}
+}
+
+func (contextConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	return &models.ConversionResult{Error: errors.New("Convert called instead of ConvertContext")}
+}
+
+func (c contextConverter) ConvertContext(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	defer close(c.returned)
+	<-ctx.Done()
+	return &models.ConversionResult{Error: context.Cause(ctx)}
+}
+
+func (contextConverter) SupportsFormat(format models.FileFormat) bool { return true }
+
+func TestStepTimeoutReachesContextConverters(t *testing.T) {
+	returned := make(chan struct{})
+	RegisterConverter("ctx-ctx", func() models.Converter { return contextConverter{returned: returned} })
+	t.Cleanup(func() { UnregisterConverter("ctx-ctx") })
+
+	files := vfs.NewMem(map[string][]byte{"in.ctx": []byte("data")})
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory())).WithFileSystem(files)
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.ctx").
+		WithOutputPath("out.ctx").
+		AddConversionStep("ctx", "ctx").
+		WithLastStepTimeout(models.StepTimeout{After: 20 * time.Millisecond}).
+		Build()
+	require.NoError(t, err)
+
+	result := executor.Execute(pipeline)
+	assert.ErrorIs(t, result.Error, ErrStepTimeout)
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the converter kept running after its step timed out")
+	}
+}
+
+func TestStepTimeoutIsChecked(t *testing.T) {
+	build := func(timeout models.StepTimeout) error {
+		_, err := NewPipelineBuilder().
+			WithInputPath("people.csv").
+			WithOutputPath("people.json").
+			AddCSVToJSON().
+			WithStepTimeout(timeout).
+			Build()
+		return err
+	}
+
+	assert.NoError(t, build(models.StepTimeout{After: time.Second, Policy: models.TimeoutRetry}))
+	assert.EqualError(t, build(models.StepTimeout{After: time.Second, Policy: models.TimeoutSkip}),
+		"step 1: a csv→json step cannot be skipped on timeout, since it changes the format")
+	assert.EqualError(t, build(models.StepTimeout{After: time.Second, Policy: "wait"}), `step 1: unknown timeout policy "wait"`)
+	assert.EqualError(t, build(models.StepTimeout{After: -time.Second}), "step 1: step timeout must not be negative")
+}
+
+func TestStepTimeoutJSON(t *testing.T) {
+	var step models.ConversionStep
+	require.NoError(t, json.Unmarshal([]byte(`{"From":"csv","To":"json","Timeout":{"After":"1m30s","Policy":"retry"}}`), &step))
+	assert.Equal(t, &models.StepTimeout{After: 90 * time.Second, Policy: models.TimeoutRetry}, step.Timeout)
+
+	data, err := json.Marshal(step.Timeout)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"After":"1m30s","Policy":"retry"}`, string(data))
+}
@@ -0,0 +1,180 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Executor runs built pipelines. The strategy recorded in the pipeline
+// options decides how steps and archive entries are scheduled.
+type Executor interface {
+	ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult
+	ConvertData(ctx context.Context, pipeline *models.Pipeline, input []byte) ([]byte, *models.PipelineResult)
+}
+
+var _ Executor = (*PipelineExecutor)(nil)
+
+func (e *PipelineExecutor) ExecuteSequential(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	return e.ExecuteContext(ctx, withStrategy(pipeline, models.StrategySequential))
+}
+
+func (e *PipelineExecutor) ExecuteConcurrent(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	return e.ExecuteContext(ctx, withStrategy(pipeline, models.StrategyConcurrent))
+}
+
+func (e *PipelineExecutor) ExecuteStreaming(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	return e.ExecuteContext(ctx, withStrategy(pipeline, models.StrategyStreaming))
+}
+
+func withStrategy(pipeline *models.Pipeline, strategy models.ExecutionStrategy) *models.Pipeline {
+	copied := *pipeline
+	copied.Options.Strategy = strategy
+	return &copied
+}
+
+// streamSteps starts every step at once and connects neighbours with
+// io.Pipe, so a step's output is handed to the next step as it is written
+// rather than held between steps. Steps whose converter implements
+// models.StreamConverter write into the pipe while they read, so a slow step
+// holds back the steps before it instead of output piling up in memory.
+//
+// The first failure cancels the run and is recorded before any pipe is
+// closed. Every step then closes its input pipe, so upstream writers never
+// block on a reader that has gone away, and closes its output pipe with its
+// error, so downstream readers stop with it instead of waiting for more.
+func (e *PipelineExecutor) streamSteps(ctx context.Context, pipeline *models.Pipeline, data []byte) ([]*models.ConversionResult, []byte, error) {
+	// Every step holds its converter until it has written all its output,
+	// so steps waiting on the pool for a converter held upstream would
+	// never be served.
+	for converterType, needed := range convertersHeld(pipeline.Steps) {
+		if limit := e.pool.Limit(converterType) + e.pool.maxOverflow; needed > limit {
+			return nil, nil, fmt.Errorf("streaming needs %d %s converters at once but the pool holds at most %d", needed, converterType, limit)
+		}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failure  error
+		output   []byte
+	)
+	fail := func(err error) {
+		failOnce.Do(func() {
+			failure = err
+			cancel(err)
+		})
+	}
+
+	stepResults := make([]*models.ConversionResult, len(pipeline.Steps))
+	var input io.Reader = bytes.NewReader(data)
+
+	for i, step := range pipeline.Steps {
+		var writer *io.PipeWriter
+		var next io.Reader
+		if i < len(pipeline.Steps)-1 {
+			next, writer = io.Pipe()
+		}
+
+		wg.Add(1)
+		go func(i int, step models.ConversionStep, input io.Reader, writer *io.PipeWriter) {
+			defer wg.Done()
+
+			e.events.Publish(events.StepStarted{Pipeline: pipeline, Index: i, Step: step})
+			start := time.Now()
+			// Only the last step and snapshotted steps keep their output.
+			var buffer bytes.Buffer
+			out := &countingWriter{w: &buffer}
+			if writer != nil && slices.Contains(pipeline.Options.SnapshotSteps, i+1) {
+				out.w = io.MultiWriter(writer, &buffer)
+			} else if writer != nil {
+				out.w = writer
+			}
+			result, err := e.streamStep(ctx, pipeline, i, step, input, out)
+			if result != nil && out.w != writer {
+				result.Data = buffer.Bytes()
+			}
+			stepResults[i] = result
+
+			// Record the failure before closing the pipes, so the error a
+			// neighbour sees from a closed pipe never wins over the cause.
+			if err != nil {
+				fail(err)
+			}
+			if reader, ok := input.(*io.PipeReader); ok {
+				reader.CloseWithError(io.ErrClosedPipe)
+			}
+			if writer != nil {
+				writer.CloseWithError(err)
+			}
+
+			if err != nil {
+				e.events.Publish(events.StepFailed{Pipeline: pipeline, Index: i, Step: step, Err: err})
+				return
+			}
+
+			if writer == nil {
+				output = result.Data
+			}
+			e.publishQuality(pipeline, i, result)
+			e.events.Publish(events.StepCompleted{
+				Pipeline:   pipeline,
+				Index:      i,
+				Step:       step,
+				Duration:   time.Since(start),
+				OutputSize: out.n,
+			})
+		}(i, step, input, writer)
+
+		input = next
+	}
+
+	wg.Wait()
+
+	results := make([]*models.ConversionResult, 0, len(stepResults))
+	for _, result := range stepResults {
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+
+	if failure != nil {
+		return results, nil, failure
+	}
+	return results, output, nil
+}
+
+// convertersHeld counts the converters of each type a streaming run holds at
+// once: one per converter step, and one per type for a sub-pipeline, whose
+// steps run one after another.
+func convertersHeld(steps []models.ConversionStep) map[string]int {
+	held := make(map[string]int)
+	for _, step := range steps {
+		switch {
+		case step.Pipeline != nil:
+			for converterType := range convertersHeld(step.Pipeline.Steps) {
+				held[converterType]++
+			}
+		case step.UsesConverter():
+			key, err := converterKey(step)
+			if err != nil {
+				key = string(step.From) + "-" + string(step.To)
+			}
+			held[key]++
+		}
+	}
+	return held
+}
@@ -0,0 +1,177 @@
+package factory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestStrategiesProduceTheSameOutput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.csv")
+	assert.NoError(t, os.WriteFile(input, []byte("name,city\nann,oslo\nbob,rome\n"), 0644))
+
+	executor := NewPipelineExecutor(NewConverterPool(2, NewConverterFactory()))
+	run := map[models.ExecutionStrategy]func(context.Context, *models.Pipeline) *models.PipelineResult{
+		models.StrategySequential: executor.ExecuteSequential,
+		models.StrategyConcurrent: executor.ExecuteConcurrent,
+		models.StrategyStreaming:  executor.ExecuteStreaming,
+	}
+
+	outputs := make(map[models.ExecutionStrategy]string)
+	for strategy, execute := range run {
+		pipeline, err := NewPipelineBuilder().
+			WithInputPath(input).
+			WithOutputPath(filepath.Join(dir, string(strategy)+".yaml")).
+			AddCSVToJSON().
+			AddJSONToXML().
+			AddXMLToYAML().
+			Build()
+		assert.NoError(t, err)
+
+		result := execute(context.Background(), pipeline)
+		assert.NoError(t, result.Error, strategy)
+		assert.Len(t, result.Results, 3, strategy)
+
+		data, err := os.ReadFile(pipeline.OutputPath)
+		assert.NoError(t, err)
+		outputs[strategy] = string(data)
+	}
+
+	assert.Equal(t, outputs[models.StrategySequential], outputs[models.StrategyStreaming])
+	assert.Equal(t, outputs[models.StrategySequential], outputs[models.StrategyConcurrent])
+}
+
+func TestNDJSONFollowsKeyOrder(t *testing.T) {
+	converter := bridge(models.FormatCSV, models.FormatNDJSON)
+	converter.Configure(models.ConversionOptions{KeyOrder: models.KeyOrder{First: []string{"name"}}})
+
+	var out bytes.Buffer
+	assert.NoError(t, converter.ConvertStream(strings.NewReader("age,name\n31,ann\n"), &out, models.FormatCSV, models.FormatNDJSON))
+	assert.Equal(t, `{"name":"ann","age":"31"}`+"\n", out.String())
+}
+
+func TestStreamingReportsTheFailingStep(t *testing.T) {
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.yaml").
+		WithStrategy(models.StrategyStreaming).
+		AddCSVToJSON().
+		AddConversionStep(models.FormatJSON, models.FormatMarkdown).
+		AddConversionStep(models.FormatMarkdown, models.FormatYAML).
+		Build()
+	assert.NoError(t, err)
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	_, result := executor.ConvertData(context.Background(), pipeline, []byte("name\nann\n"))
+	assert.Error(t, result.Error)
+	assert.Contains(t, result.Error.Error(), "step 3")
+}
+
+func TestBuildRejectsStreamingWithIntermediarySteps(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.json").
+		WithStrategy(models.StrategyStreaming).
+		WithSaveIntermediarySteps().
+		AddCSVToJSON().
+		Build()
+	assert.Error(t, err)
+}
+
+func TestStreamConvertersMatchConvert(t *testing.T) {
+	width := 0
+	tests := []struct {
+		name      string
+		converter models.StreamConverter
+		from, to  models.FileFormat
+		options   models.ConversionOptions
+		input     string
+	}{
+		{"csv to json", &CSVToJSONConverter{}, models.FormatCSV, models.FormatJSON, models.ConversionOptions{}, "name,city\nann,oslo\nbob,<rome>\n"},
+		{"csv to compact json", &CSVToJSONConverter{}, models.FormatCSV, models.FormatJSON, models.ConversionOptions{IndentWidth: &width}, "name,city\nann,oslo\nbob,rome\n"},
+		{"csv header only", &CSVToJSONConverter{}, models.FormatCSV, models.FormatJSON, models.ConversionOptions{}, "name,city\n"},
+		{"empty csv", &CSVToJSONConverter{}, models.FormatCSV, models.FormatJSON, models.ConversionOptions{}, ""},
+		{"csv to ndjson", bridge(models.FormatCSV, models.FormatNDJSON), models.FormatCSV, models.FormatNDJSON, models.ConversionOptions{}, "name,age\nann,31\n"},
+		{"ndjson to ndjson", bridge(models.FormatNDJSON, models.FormatNDJSON), models.FormatNDJSON, models.FormatNDJSON, models.ConversionOptions{}, "{\"b\":1,\"a\":\"x\"}\n\n{\"a\":2}\n"},
+		{"csv to ordered ndjson", bridge(models.FormatCSV, models.FormatNDJSON), models.FormatCSV, models.FormatNDJSON, models.ConversionOptions{KeyOrder: models.KeyOrder{First: []string{"name"}}}, "age,name\n31,ann\n"},
+		{"json to yaml", bridge(models.FormatJSON, models.FormatYAML), models.FormatJSON, models.FormatYAML, models.ConversionOptions{}, `[{"a":1}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.converter.(models.Configurable).Configure(tt.options)
+			expected := tt.converter.Convert(strings.NewReader(tt.input), tt.from, tt.to)
+			assert.NoError(t, expected.Error)
+
+			var out bytes.Buffer
+			assert.NoError(t, tt.converter.ConvertStream(strings.NewReader(tt.input), &out, tt.from, tt.to))
+			assert.Equal(t, string(expected.Data), out.String())
+		})
+	}
+}
+
+func TestStreamingKeepsSnapshotsOfStreamedSteps(t *testing.T) {
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.yaml").
+		WithStrategy(models.StrategyStreaming).
+		WithSnapshots(1).
+		AddCSVToJSON().
+		AddConversionStep(models.FormatJSON, models.FormatYAML).
+		Build()
+	assert.NoError(t, err)
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	_, result := executor.ConvertData(context.Background(), pipeline, []byte("name\nann\n"))
+	assert.NoError(t, result.Error)
+	assert.Len(t, result.Snapshots, 1)
+	assert.JSONEq(t, `[{"name":"ann"}]`, string(result.Snapshots[0].Data()))
+}
+
+func TestStreamingFailureDoesNotDeadlock(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("name,city\n")
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&input, "person%d,city%d\n", i, i)
+	}
+
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.yaml").
+		WithStrategy(models.StrategyStreaming).
+		AddCSVToJSON().
+		AddConversionStep(models.FormatJSON, models.FormatMarkdown).
+		AddConversionStep(models.FormatMarkdown, models.FormatYAML).
+		Build()
+	assert.NoError(t, err)
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	done := make(chan *models.PipelineResult, 1)
+	go func() {
+		_, result := executor.ConvertData(context.Background(), pipeline, []byte(input.String()))
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		assert.Error(t, result.Error)
+		assert.Contains(t, result.Error.Error(), "step 3")
+	case <-time.After(10 * time.Second):
+		t.Fatal("streaming pipeline did not return after a step failed")
+	}
+}
+
+func bridge(from, to models.FileFormat) *BridgeConverter {
+	converter, _ := newBridgeConverter(from, to)
+	return converter
+}
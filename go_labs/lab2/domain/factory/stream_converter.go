@@ -0,0 +1,236 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+var (
+	_ models.StreamConverter = (*CSVToJSONConverter)(nil)
+	_ models.StreamConverter = (*BridgeConverter)(nil)
+)
+
+// ConvertStream writes the JSON array one record at a time, producing the
+// same bytes as Convert.
+func (c *CSVToJSONConverter) ConvertStream(input io.Reader, output io.Writer, from, to models.FileFormat) error {
+	if from != models.FormatCSV || to != models.FormatJSON {
+		return fmt.Errorf("unsupported conversion: %s to %s", from, to)
+	}
+
+	reader := newCSVReader(input, c.options)
+	headers, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		_, err = io.WriteString(output, "[]")
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSV: %w", err)
+	}
+	headers = slices.Clone(headers)
+	reader.ReuseRecord = true
+
+	writer := newJSONArrayWriter(output, c.options)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		if err := writer.write(csvRow(headers, record)); err != nil {
+			return err
+		}
+	}
+	return writer.close()
+}
+
+// jsonArrayWriter writes array elements as they come, laid out exactly as
+// marshalJSON lays out the whole array.
+type jsonArrayWriter struct {
+	out    *bufio.Writer
+	indent string
+	order  models.KeyOrder
+	count  int
+}
+
+func newJSONArrayWriter(output io.Writer, options models.ConversionOptions) *jsonArrayWriter {
+	indent, _ := options.Indentation()
+	return &jsonArrayWriter{out: bufio.NewWriter(output), indent: indent, order: options.KeyOrder}
+}
+
+func (w *jsonArrayWriter) write(element interface{}) error {
+	element = document.Ordered(element, w.order)
+	var data []byte
+	var err error
+	if w.indent == "" {
+		data, err = json.Marshal(element)
+	} else {
+		data, err = json.MarshalIndent(element, w.indent, w.indent)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	separator := ","
+	if w.count == 0 {
+		separator = "["
+	}
+	w.count++
+	w.out.WriteString(separator)
+	if w.indent != "" {
+		w.out.WriteString("\n" + w.indent)
+	}
+	_, err = w.out.Write(data)
+	return err
+}
+
+func (w *jsonArrayWriter) close() error {
+	switch {
+	case w.count == 0:
+		w.out.WriteString("[]")
+	case w.indent != "":
+		w.out.WriteString("\n]")
+	default:
+		w.out.WriteString("]")
+	}
+	return w.out.Flush()
+}
+
+// ConvertStream streams record-oriented input (CSV, NDJSON) into NDJSON line
+// by line, ordering each record's keys like NDJSON rendering does. Other pairs need the whole document and are converted in memory
+// before the output is written.
+func (b *BridgeConverter) ConvertStream(input io.Reader, output io.Writer, from, to models.FileFormat) error {
+	if from != b.from || to != b.to {
+		return fmt.Errorf("unsupported conversion: %s to %s", from, to)
+	}
+
+	if to != models.FormatNDJSON || (from != models.FormatCSV && from != models.FormatNDJSON) {
+		result := b.Convert(input, from, to)
+		if result.Error != nil {
+			return result.Error
+		}
+		_, err := output.Write(result.Data)
+		return err
+	}
+
+	iterator, err := records.NewIterator(input, from, b.options)
+	if err != nil {
+		return err
+	}
+
+	buffered := bufio.NewWriter(output)
+	encoder := json.NewEncoder(buffered)
+	for {
+		record, err := iterator.Next()
+		if errors.Is(err, io.EOF) {
+			return buffered.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		if err := encoder.Encode(document.Ordered(map[string]interface{}(record), b.options.KeyOrder)); err != nil {
+			return err
+		}
+	}
+}
+
+// countingWriter counts the bytes a streamed step writes, since its result
+// carries no data to measure.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// streamStep runs one step of a streaming pipeline, writing its output to
+// output. Stream converters write while they read, so a slow consumer
+// blocks the producer on the pipe instead of output piling up in memory.
+// Other steps, and steps with a timeout, which may be retried or skipped,
+// run in memory and write their result in one go.
+func (e *PipelineExecutor) streamStep(ctx context.Context, pipeline *models.Pipeline, i int, step models.ConversionStep, input io.Reader, output io.Writer) (*models.ConversionResult, error) {
+	if step.UsesConverter() && stepTimeout(pipeline, step).After == 0 {
+		converterType, err := converterKey(step)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		converter, err := e.pool.GetContext(ctx, converterType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get converter from pool for step %d: %w", i+1, err)
+		}
+		if streamer, ok := converter.(models.StreamConverter); ok {
+			return e.convertStream(ctx, pipeline, i, step, converterType, streamer, input, output)
+		}
+		e.pool.Return(converterType, converter)
+	}
+
+	result, err := e.runStep(ctx, pipeline, i, step, input)
+	if err != nil {
+		return result, err
+	}
+	if _, err := output.Write(result.Data); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		return result, err
+	}
+	return result, nil
+}
+
+func (e *PipelineExecutor) convertStream(ctx context.Context, pipeline *models.Pipeline, i int, step models.ConversionStep, converterType string, converter models.StreamConverter, input io.Reader, output io.Writer) (*models.ConversionResult, error) {
+	ctx, unlabel := labelStep(ctx, i+1, step)
+	defer unlabel()
+	ctx, span := tracer.Start(ctx, "pipeline.step", trace.WithAttributes(stepAttributes(i+1, step)...))
+	span.SetAttributes(attribute.Bool("step.streamed", true))
+
+	if configurable, ok := converter.(models.Configurable); ok {
+		configurable.Configure(pipeline.Options)
+	}
+
+	counted := &countingWriter{w: output}
+	done := make(chan error, 1)
+	go func() {
+		err := converter.ConvertStream(input, counted, step.From, step.To)
+		e.pool.Return(converterType, converter)
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("step %d aborted (%s→%s): %w", i+1, step.From, step.To, context.Cause(ctx))
+		endSpan(span, err)
+		return nil, err
+	}
+
+	// A closed pipe means the next step stopped reading; if that was a
+	// failure it has already been recorded.
+	if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		err = fmt.Errorf("step %d failed (%s→%s): %w", i+1, step.From, step.To, err)
+		endSpan(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("output.size", counted.n))
+	endSpan(span, nil)
+	return &models.ConversionResult{Format: step.To}, nil
+}
@@ -0,0 +1,161 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+// TemplateData is the value a user template is executed against.
+type TemplateData struct {
+	Columns []string
+	Records []records.Record
+}
+
+type OutputTemplateConverter struct {
+	configured
+	text string
+	path string
+}
+
+var templateSources = []models.FileFormat{
+	models.FormatCSV,
+	models.FormatJSON,
+	models.FormatXML,
+	models.FormatYAML,
+}
+
+// MaxTemplateWidth bounds the width of pad and the count of repeat, so a
+// template cannot make a single call allocate without limit.
+const MaxTemplateWidth = 64 << 10
+
+func checkTemplateWidth(name string, n int) error {
+	if n < 0 || n > MaxTemplateWidth {
+		return fmt.Errorf("%s %d is outside 0..%d", name, n, MaxTemplateWidth)
+	}
+	return nil
+}
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+	"field": func(record records.Record, name string) string {
+		if value, ok := record[name]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+		return ""
+	},
+	"pad": func(width int, value interface{}) (string, error) {
+		if err := checkTemplateWidth("pad width", width); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%-*s", width, fmt.Sprint(value)), nil
+	},
+	"repeat": func(count int, s string) (string, error) {
+		if err := checkTemplateWidth("repeat count", count); err != nil {
+			return "", err
+		}
+		return strings.Repeat(s, count), nil
+	},
+}
+
+func init() {
+	for _, from := range templateSources {
+		RegisterConverter(string(from)+"-"+string(models.FormatTemplate), func() models.Converter {
+			return &OutputTemplateConverter{}
+		})
+	}
+}
+
+func (t *OutputTemplateConverter) Configure(options models.ConversionOptions) {
+	t.configured.Configure(options)
+	t.text = options.Template
+	t.path = options.TemplatePath
+}
+
+func (t *OutputTemplateConverter) Reset() {
+	t.configured.Reset()
+	t.text, t.path = "", ""
+}
+
+func (t *OutputTemplateConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from == models.FormatTemplate || !t.SupportsFormat(from) || to != models.FormatTemplate {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	text, err := t.templateText()
+	if err != nil {
+		return &models.ConversionResult{Error: err}
+	}
+
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return &models.ConversionResult{Error: err}
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to read %s: %w", from, err)}
+	}
+
+	rows, err := records.DecodeWithOptions(data, from, t.options)
+	if err != nil {
+		return &models.ConversionResult{Error: err}
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, TemplateData{Columns: records.Columns(rows), Records: rows}); err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to render template: %w", err)}
+	}
+
+	return &models.ConversionResult{
+		Data:   out.Bytes(),
+		Format: models.FormatTemplate,
+	}
+}
+
+// parseTemplate parses an output template with the template functions.
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func (t *OutputTemplateConverter) SupportsFormat(format models.FileFormat) bool {
+	if format == models.FormatTemplate {
+		return true
+	}
+	for _, source := range templateSources {
+		if format == source {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *OutputTemplateConverter) templateText() (string, error) {
+	if t.text != "" {
+		return t.text, nil
+	}
+	if t.path == "" {
+		return "", fmt.Errorf("no output template configured")
+	}
+
+	content, err := os.ReadFile(t.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,41 @@
+package factory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestOutputTemplateConverter(t *testing.T) {
+	converter, err := NewConverterFactory().CreateConverter("csv-template")
+	assert.NoError(t, err)
+
+	converter.(models.Configurable).Configure(models.ConversionOptions{
+		Template: "{{range .Records}}{{upper (field . \"name\")}}|{{pad 3 .age}}|\n{{end}}",
+	})
+
+	result := converter.Convert(strings.NewReader("name,age\nann,7\nbob,42\n"), models.FormatCSV, models.FormatTemplate)
+
+	assert.NoError(t, result.Error)
+	assert.Equal(t, "ANN|7  |\nBOB|42 |\n", string(result.Data))
+}
+
+func TestOutputTemplateConverterRequiresTemplate(t *testing.T) {
+	converter := &OutputTemplateConverter{}
+
+	result := converter.Convert(strings.NewReader("[]"), models.FormatJSON, models.FormatTemplate)
+
+	assert.Error(t, result.Error)
+}
+
+func TestOutputTemplateConverterBoundsPadAndRepeat(t *testing.T) {
+	for _, text := range []string{`{{repeat 1000000000 "x"}}`, `{{pad 1000000000 "x"}}`, `{{repeat -1 "x"}}`} {
+		converter := &OutputTemplateConverter{}
+		converter.Configure(models.ConversionOptions{Template: text})
+
+		result := converter.Convert(strings.NewReader("[]"), models.FormatJSON, models.FormatTemplate)
+		assert.ErrorContains(t, result.Error, "is outside 0..65536", text)
+	}
+}
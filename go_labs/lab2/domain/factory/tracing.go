@@ -0,0 +1,63 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const TracerName = "tmps-go-labs/lab2/factory"
+
+// Spans go to the global tracer provider, which is a no-op until the
+// application installs an SDK provider with otel.SetTracerProvider.
+var tracer = otel.Tracer(TracerName)
+
+func stepAttributes(index int, step models.ConversionStep) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("pipeline.step.index", index),
+		attribute.String("conversion.from", string(step.From)),
+		attribute.String("conversion.to", string(step.To)),
+	}
+}
+
+// labelStep tags the calling goroutine, and the goroutines it starts, with
+// the step it runs, so profiles can be narrowed to one step
+// (go tool pprof -tagfocus step=2). The returned function restores the
+// previous labels.
+func labelStep(ctx context.Context, index int, step models.ConversionStep) (context.Context, func()) {
+	labeled := pprof.WithLabels(ctx, pprof.Labels(
+		"step", strconv.Itoa(index),
+		"conversion", string(step.From)+"-"+string(step.To),
+	))
+	pprof.SetGoroutineLabels(labeled)
+	return labeled, func() { pprof.SetGoroutineLabels(ctx) }
+}
+
+func pipelineAttributes(pipeline *models.Pipeline) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{attribute.Int("pipeline.steps", len(pipeline.Steps))}
+	if len(pipeline.Steps) > 0 {
+		attributes = append(attributes,
+			attribute.String("conversion.from", string(pipeline.Steps[0].From)),
+			attribute.String("conversion.to", string(pipeline.Steps[len(pipeline.Steps)-1].To)),
+		)
+	}
+	return attributes
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,55 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestConvertDataEmitsSpanPerStep(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	// The package tracer keeps delegating to the first provider installed,
+	// so shutting it down is what stops the other tests' spans reaching it.
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	pipeline := &models.Pipeline{Steps: []models.ConversionStep{
+		{From: models.FormatCSV, To: models.FormatJSON},
+		{From: models.FormatJSON, To: models.FormatXML},
+	}}
+
+	executor := NewPipelineExecutor(NewConverterPool(2, NewConverterFactory()))
+	_, result := executor.ConvertData(context.Background(), pipeline, []byte("name\nann\n"))
+	assert.NoError(t, result.Error)
+
+	counts := make(map[string]int)
+	var root sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		counts[span.Name()]++
+		if span.Name() == "pipeline.convert_data" {
+			root = span
+		}
+	}
+
+	assert.Equal(t, 1, counts["pipeline.convert_data"])
+	assert.Equal(t, 2, counts["pipeline.step"])
+	assert.Equal(t, 2, counts["converter_pool.get"])
+	assert.Equal(t, 2, counts["converter.convert"])
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "pipeline.step" {
+			assert.Equal(t, root.SpanContext().SpanID(), span.Parent().SpanID())
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/expr"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+type compiledTransform struct {
+	filter expr.Expr
+	fields []string
+	set    []expr.Expr
+}
+
+func compileTransform(transform *models.Transform) (*compiledTransform, error) {
+	compiled := &compiledTransform{}
+
+	if transform.Filter != "" {
+		filter, err := expr.Parse(transform.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %w", transform.Filter, err)
+		}
+		compiled.filter = filter
+	}
+
+	for field := range transform.Set {
+		compiled.fields = append(compiled.fields, field)
+	}
+	sort.Strings(compiled.fields)
+	for _, field := range compiled.fields {
+		value, err := expr.Parse(transform.Set[field])
+		if err != nil {
+			return nil, fmt.Errorf("set %s = %q: %w", field, transform.Set[field], err)
+		}
+		compiled.set = append(compiled.set, value)
+	}
+
+	return compiled, nil
+}
+
+// applyTransform parses the input, filters and rewrites its records and
+// renders them back in the same format.
+func applyTransform(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	compiled, err := compileTransform(step.Transform)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		kept := make([]interface{}, 0)
+		for i, record := range records.Find(doc.Root) {
+			env := map[string]interface{}(record)
+
+			if compiled.filter != nil {
+				keep, err := compiled.filter.Eval(env)
+				if err != nil {
+					return fmt.Errorf("record %d: %w", i+1, err)
+				}
+				if !expr.Truthy(keep) {
+					continue
+				}
+			}
+
+			values := make([]interface{}, len(compiled.set))
+			for j, value := range compiled.set {
+				var err error
+				if values[j], err = value.Eval(env); err != nil {
+					return fmt.Errorf("record %d, field %s: %w", i+1, compiled.fields[j], err)
+				}
+			}
+			for j, field := range compiled.fields {
+				record[field] = values[j]
+			}
+
+			kept = append(kept, map[string]interface{}(record))
+		}
+		doc.Root = kept
+		return nil
+	})
+}
@@ -0,0 +1,38 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestFilterAndMapSteps(t *testing.T) {
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.json").
+		Apply(models.WithIndentWidth(0)).
+		AddCSVToJSON().
+		AddFilter(models.FormatJSON, `age > 30 && country == "MD"`).
+		AddMap(models.FormatJSON, map[string]string{"name": "upper(name)", "age": "number(age)"}).
+		Build()
+	assert.NoError(t, err)
+
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+	output, result := executor.ConvertData(context.Background(), pipeline,
+		[]byte("name,age,country\nann,28,MD\nbob,35,MD\ncid,40,RO\n"))
+	assert.NoError(t, result.Error)
+	assert.JSONEq(t, `[{"name":"BOB","age":35,"country":"MD"}]`, string(output))
+}
+
+func TestBuildRejectsInvalidExpressions(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.json").
+		AddCSVToJSON().
+		AddFilter(models.FormatJSON, `age >`).
+		Build()
+	assert.EqualError(t, err, `step 2: filter "age >": column 6: unexpected end of expression`)
+}
@@ -0,0 +1,80 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/units"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+func checkUnits(step models.ConversionStep) (*units.Converter, error) {
+	spec := step.Units
+	if len(spec.Fields) == 0 {
+		return nil, fmt.Errorf("unit conversion needs at least one field")
+	}
+	decimals := -1
+	if spec.Decimals != nil {
+		if *spec.Decimals < 0 {
+			return nil, fmt.Errorf("unit conversion decimals must not be negative")
+		}
+		decimals = *spec.Decimals
+	}
+	converter, err := units.New(spec.From, spec.To, spec.Rates, decimals, spec.Rounding)
+	if err != nil {
+		return nil, fmt.Errorf("unit conversion: %w", err)
+	}
+	return converter, nil
+}
+
+// applyUnits converts the fields of every record and renders the records
+// back in the same format. Numbers stay numbers; numeric strings, as CSV
+// holds them, stay strings, written with the rounded number of decimals.
+// Missing, null and empty fields are left alone.
+func applyUnits(step models.ConversionStep, input io.Reader, options models.ConversionOptions) (*models.ConversionResult, error) {
+	converter, err := checkUnits(step)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordStage(step, input, options, func(doc *document.Document) error {
+		for i, record := range records.Find(doc.Root) {
+			for _, field := range step.Units.Fields {
+				switch value := record[field].(type) {
+				case nil:
+				case float64:
+					record[field] = converter.Convert(value)
+				case int:
+					record[field] = converter.Convert(float64(value))
+				case int64:
+					record[field] = converter.Convert(float64(value))
+				case uint64:
+					record[field] = converter.Convert(float64(value))
+				case values.Decimal:
+					record[field] = converter.Convert(value.Float64())
+				case string:
+					text := strings.TrimSpace(value)
+					if text == "" {
+						continue
+					}
+					number, err := strconv.ParseFloat(text, 64)
+					if err != nil {
+						return fmt.Errorf("record %d, field %s: %q is not a number", i+1, field, value)
+					}
+					record[field] = strconv.FormatFloat(converter.Convert(number), 'f', converter.Decimals(), 64)
+				default:
+					return fmt.Errorf("record %d, field %s: %v is not a number", i+1, field, value)
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,45 @@
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestUnitConversionStep(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "readings.csv")
+	require.NoError(t, os.WriteFile(input, []byte("sensor,temp,size\na,21.5,1048576\nb,,2097152\n"), 0644))
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	one := 1
+	pipeline, err := NewPipelineBuilder().
+		WithInputPath(input).
+		WithOutputPath(filepath.Join(dir, "readings.json")).
+		AddUnitConversion(models.FormatCSV, models.UnitConversion{Fields: []string{"temp"}, From: "C", To: "F", Decimals: &one}).
+		AddUnitConversion(models.FormatCSV, models.UnitConversion{Fields: []string{"size"}, From: "B", To: "MiB"}).
+		AddCSVToJSON().
+		Build()
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(pipeline).Error)
+	data, err := os.ReadFile(pipeline.OutputPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"sensor":"a","temp":"70.7","size":"1"},{"sensor":"b","temp":"","size":"2"}]`, string(data))
+
+	require.NoError(t, os.WriteFile(input, []byte("sensor,temp,size\na,warm,1\n"), 0644))
+	assert.ErrorContains(t, executor.Execute(pipeline).Error, `step 1 unit conversion failed (csv): record 1, field temp: "warm" is not a number`)
+}
+
+func TestBuildRejectsUnknownUnits(t *testing.T) {
+	_, err := NewPipelineBuilder().
+		WithInputPath("in.csv").
+		WithOutputPath("out.csv").
+		AddUnitConversion(models.FormatCSV, models.UnitConversion{Fields: []string{"price"}, From: "EUR", To: "USD"}).
+		Build()
+	assert.EqualError(t, err, `step 1: unit conversion: unknown unit "EUR"`)
+}
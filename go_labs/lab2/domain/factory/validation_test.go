@@ -0,0 +1,76 @@
+package factory
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/validation"
+)
+
+func TestExecuteValidatesInputBeforeConverting(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"rules": [{"id": "email-required", "field": "email", "required": true}]}`), 0644))
+
+	inputPath := filepath.Join(dir, "people.csv")
+	outputPath := filepath.Join(dir, "people.json")
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	run := func(input string) error {
+		require.NoError(t, os.WriteFile(inputPath, []byte(input), 0644))
+		pipeline, err := NewPipelineBuilder().
+			WithInputPath(inputPath).
+			WithOutputPath(outputPath).
+			AddCSVToJSON().
+			WithValidation(schemaPath).
+			Build()
+		require.NoError(t, err)
+		return executor.Execute(pipeline).Error
+	}
+
+	err := run("name,email\nann,\n")
+	assert.ErrorIs(t, err, validation.ErrFailed)
+	assert.NoFileExists(t, outputPath)
+
+	var report validation.Report
+	data, readErr := os.ReadFile(validation.ReportPath(outputPath, validation.ReportJSON))
+	require.NoError(t, readErr)
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, "email-required", report.Issues[0].RuleID)
+
+	assert.NoError(t, run("name,email\nann,ann@example.com\n"))
+	assert.FileExists(t, outputPath)
+}
+
+func TestExecuteValidatesConfigRules(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "people.csv")
+	require.NoError(t, os.WriteFile(inputPath, []byte("name,country\nann,fr\nbob,xx\n"), 0644))
+	outputPath := filepath.Join(dir, "people.json")
+	executor := NewPipelineExecutor(NewConverterPool(1, NewConverterFactory()))
+
+	builder := NewPipelineBuilder().
+		WithInputPath(inputPath).
+		WithOutputPath(outputPath).
+		AddCSVToJSON().
+		WithValidationRules(models.ValidationRule{Field: "country", Enum: []string{"fr", "de"}, Severity: "warning"})
+	pipeline, err := builder.Build()
+	require.NoError(t, err)
+	assert.NoError(t, executor.Execute(pipeline).Error, "warnings pass by default")
+
+	pipeline, err = builder.WithValidationFailOn(string(validation.SeverityWarning)).Build()
+	require.NoError(t, err)
+	err = executor.Execute(pipeline).Error
+	assert.ErrorIs(t, err, validation.ErrFailed)
+	assert.ErrorContains(t, err, "0 errors and 1 warnings in 2 records")
+
+	_, err = builder.WithValidationRules(models.ValidationRule{Field: "age", Type: "date"}).Build()
+	assert.EqualError(t, err, `validation rules: rule rule-2: unknown type "date"`)
+}
@@ -0,0 +1,523 @@
+// Package factory implements creational design patterns for file format converters.
+// It provides Factory Method pattern for converter creation, Object Pool pattern
+// for converter reuse, and Builder pattern for pipeline construction.
+package factory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/buffers"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+// vCard (RFC 6350) and iCalendar (RFC 5545) share the same "content line"
+// syntax, so both are handled by one pair of converters. In JSON a component
+// is an object keyed by lowercased property names; a property is a plain
+// string, or {"value": ..., "params": {...}} when it carries parameters, and
+// becomes an array when repeated. Nested components (e.g. vevent) are arrays
+// of objects under their lowercased component name.
+
+type ContentLinesToJSONConverter struct {
+	configured
+}
+
+type JSONToContentLinesConverter struct{}
+
+var knownComponents = map[string]bool{
+	"VCARD":     true,
+	"VCALENDAR": true,
+	"VEVENT":    true,
+	"VTODO":     true,
+	"VJOURNAL":  true,
+	"VFREEBUSY": true,
+	"VTIMEZONE": true,
+	"VALARM":    true,
+	"STANDARD":  true,
+	"DAYLIGHT":  true,
+}
+
+// textProperties hold TEXT values, in which a backslash, comma, semicolon
+// or line break is escaped (RFC 6350 section 3.4, RFC 5545 section 3.3.11).
+// Structured and list values such as N, ADR or RRULE use ; and , as
+// separators, and URIs and dates need no escaping, so only line breaks are
+// escaped in those; a raw line break would end the content line.
+var textProperties = map[string]bool{
+	"FN":          true,
+	"NOTE":        true,
+	"TITLE":       true,
+	"ROLE":        true,
+	"LABEL":       true,
+	"PRODID":      true,
+	"SUMMARY":     true,
+	"DESCRIPTION": true,
+	"LOCATION":    true,
+	"COMMENT":     true,
+	"CONTACT":     true,
+	"TZNAME":      true,
+	"UID":         true,
+	"RELATED-TO":  true,
+}
+
+func isTextProperty(name string) bool {
+	return textProperties[name] || strings.HasPrefix(name, "X-")
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	lineEscaper   = strings.NewReplacer("\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+// escapeValue escapes a property value for a content line.
+func escapeValue(name, value string) string {
+	if isTextProperty(name) {
+		return textEscaper.Replace(value)
+	}
+	return lineEscaper.Replace(value)
+}
+
+// unescapeValue reverses escapeValue for the TEXT properties.
+func unescapeValue(name, value string) string {
+	if isTextProperty(name) {
+		return textUnescaper.Replace(value)
+	}
+	return value
+}
+
+type contentComponent struct {
+	name       string
+	properties []contentProperty
+	children   []*contentComponent
+}
+
+type contentProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func init() {
+	RegisterConverter("vcard-json", func() models.Converter {
+		return &ContentLinesToJSONConverter{}
+	})
+	RegisterConverter("ical-json", func() models.Converter {
+		return &ContentLinesToJSONConverter{}
+	})
+	RegisterConverter("json-vcard", func() models.Converter {
+		return &JSONToContentLinesConverter{}
+	})
+	RegisterConverter("json-ical", func() models.Converter {
+		return &JSONToContentLinesConverter{}
+	})
+}
+
+func (c *ContentLinesToJSONConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if (from != models.FormatVCard && from != models.FormatICal) || to != models.FormatJSON {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	components, err := parseContentLines(input)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to parse %s: %w", from, err)}
+	}
+
+	jsonData := make([]map[string]interface{}, 0, len(components))
+	for _, component := range components {
+		jsonData = append(jsonData, componentToMap(component))
+	}
+
+	data, err := marshalJSON(jsonData, c.options)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to marshal JSON: %w", err)}
+	}
+
+	return &models.ConversionResult{
+		Data:   data,
+		Format: models.FormatJSON,
+	}
+}
+
+func (c *ContentLinesToJSONConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == models.FormatVCard || format == models.FormatICal || format == models.FormatJSON
+}
+
+func (c *JSONToContentLinesConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from != models.FormatJSON || (to != models.FormatVCard && to != models.FormatICal) {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	jsonData, err := buffers.ReadAll(input)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to read JSON: %w", err)}
+	}
+	defer buffers.Put(jsonData)
+
+	decoded, err := values.UnmarshalJSON(jsonData.Bytes(), true)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("failed to parse JSON: %w", err)}
+	}
+	var items []map[string]interface{}
+	switch typed := decoded.(type) {
+	case map[string]interface{}:
+		items = []map[string]interface{}{typed}
+	case []interface{}:
+		for _, element := range typed {
+			item, ok := element.(map[string]interface{})
+			if !ok {
+				return &models.ConversionResult{Error: fmt.Errorf("failed to parse JSON: expected objects, got %T", element)}
+			}
+			items = append(items, item)
+		}
+	default:
+		return &models.ConversionResult{Error: fmt.Errorf("failed to parse JSON: expected an object or a list of objects")}
+	}
+
+	var components []*contentComponent
+	if to == models.FormatVCard {
+		for _, item := range items {
+			card, err := mapToComponent("VCARD", item)
+			if err != nil {
+				return &models.ConversionResult{Error: err}
+			}
+			ensureProperty(card, "VERSION", "4.0")
+			components = append(components, card)
+		}
+	} else {
+		components, err = calendarComponents(items)
+		if err != nil {
+			return &models.ConversionResult{Error: err}
+		}
+	}
+
+	var out bytes.Buffer
+	for _, component := range components {
+		writeComponent(&out, component)
+	}
+
+	return &models.ConversionResult{
+		Data:   out.Bytes(),
+		Format: to,
+	}
+}
+
+func (c *JSONToContentLinesConverter) SupportsFormat(format models.FileFormat) bool {
+	return format == models.FormatVCard || format == models.FormatICal || format == models.FormatJSON
+}
+
+// calendarComponents accepts either calendar objects (with a vevent list) or
+// flat event records, e.g. rows coming from a CSV export, which are wrapped
+// into a single calendar.
+func calendarComponents(items []map[string]interface{}) ([]*contentComponent, error) {
+	calendar := &contentComponent{name: "VCALENDAR"}
+	var calendars []*contentComponent
+
+	for _, item := range items {
+		if isCalendarObject(item) {
+			component, err := mapToComponent("VCALENDAR", item)
+			if err != nil {
+				return nil, err
+			}
+			calendars = append(calendars, component)
+			continue
+		}
+
+		event, err := mapToComponent("VEVENT", item)
+		if err != nil {
+			return nil, err
+		}
+		calendar.children = append(calendar.children, event)
+	}
+
+	if len(calendar.children) > 0 {
+		calendars = append(calendars, calendar)
+	}
+
+	for _, component := range calendars {
+		ensureProperty(component, "PRODID", "-//tmps-go-labs//lab2 converter//EN")
+		ensureProperty(component, "VERSION", "2.0")
+	}
+	return calendars, nil
+}
+
+func isCalendarObject(item map[string]interface{}) bool {
+	for key := range item {
+		upper := strings.ToUpper(key)
+		if knownComponents[upper] && upper != "VCARD" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseContentLines(input io.Reader) ([]*contentComponent, error) {
+	lines, err := unfoldContentLines(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []*contentComponent
+	var stack []*contentComponent
+
+	for i, line := range lines {
+		property, err := parseContentLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch property.name {
+		case "BEGIN":
+			component := &contentComponent{name: strings.ToUpper(property.value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, component)
+			} else {
+				roots = append(roots, component)
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != strings.ToUpper(property.value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, property.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property %s outside of a component", i+1, property.name)
+			}
+			current := stack[len(stack)-1]
+			current.properties = append(current.properties, property)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].name)
+	}
+	return roots, nil
+}
+
+// unfoldContentLines joins continuation lines, which start with a space or tab.
+func unfoldContentLines(input io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(input)
+	var lines []string
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func parseContentLine(line string) (contentProperty, error) {
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return contentProperty{}, fmt.Errorf("malformed content line %q", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	property := contentProperty{
+		name:  strings.ToUpper(parts[0]),
+		value: line[colon+1:],
+	}
+
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		if property.params == nil {
+			property.params = make(map[string]string)
+		}
+		property.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return property, nil
+}
+
+func componentToMap(component *contentComponent) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for _, property := range component.properties {
+		text := unescapeValue(property.name, property.value)
+		var value interface{} = text
+		if len(property.params) > 0 {
+			value = map[string]interface{}{"value": text, "params": property.params}
+		}
+		appendValue(result, strings.ToLower(property.name), value)
+	}
+
+	for _, child := range component.children {
+		key := strings.ToLower(child.name)
+		list, _ := result[key].([]interface{})
+		result[key] = append(list, componentToMap(child))
+	}
+	return result
+}
+
+func appendValue(target map[string]interface{}, key string, value interface{}) {
+	existing, exists := target[key]
+	switch {
+	case !exists:
+		target[key] = value
+	case isSlice(existing):
+		target[key] = append(existing.([]interface{}), value)
+	default:
+		target[key] = []interface{}{existing, value}
+	}
+}
+
+func isSlice(value interface{}) bool {
+	_, ok := value.([]interface{})
+	return ok
+}
+
+func mapToComponent(name string, item map[string]interface{}) (*contentComponent, error) {
+	component := &contentComponent{name: name}
+
+	keys := make([]string, 0, len(item))
+	for key := range item {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		upper := strings.ToUpper(key)
+		value := item[key]
+
+		if knownComponents[upper] {
+			children, ok := value.([]interface{})
+			if !ok {
+				children = []interface{}{value}
+			}
+			for _, child := range children {
+				childMap, ok := child.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("component %s must be an object", key)
+				}
+				nested, err := mapToComponent(upper, childMap)
+				if err != nil {
+					return nil, err
+				}
+				component.children = append(component.children, nested)
+			}
+			continue
+		}
+
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			property, err := valueToProperty(upper, v)
+			if err != nil {
+				return nil, err
+			}
+			if property.value != "" || len(property.params) > 0 {
+				component.properties = append(component.properties, property)
+			}
+		}
+	}
+	return component, nil
+}
+
+func valueToProperty(name string, value interface{}) (contentProperty, error) {
+	property := contentProperty{name: name}
+
+	switch v := value.(type) {
+	case nil:
+	case string:
+		property.value = v
+	case map[string]interface{}:
+		property.value = fmt.Sprint(v["value"])
+		if params, ok := v["params"].(map[string]interface{}); ok {
+			property.params = make(map[string]string)
+			for key, param := range params {
+				property.params[strings.ToUpper(key)] = fmt.Sprint(param)
+			}
+		}
+	case float64, bool:
+		property.value = fmt.Sprint(v)
+	default:
+		return property, fmt.Errorf("unsupported value for property %s", name)
+	}
+	return property, nil
+}
+
+func ensureProperty(component *contentComponent, name, value string) {
+	for _, property := range component.properties {
+		if property.name == name {
+			return
+		}
+	}
+	component.properties = append([]contentProperty{{name: name, value: value}}, component.properties...)
+}
+
+func writeComponent(out *bytes.Buffer, component *contentComponent) {
+	writeContentLine(out, "BEGIN:"+component.name)
+
+	for _, property := range component.properties {
+		var line strings.Builder
+		line.WriteString(property.name)
+
+		keys := make([]string, 0, len(property.params))
+		for key := range property.params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := property.params[key]
+			if strings.ContainsAny(value, ":;,") {
+				value = `"` + value + `"`
+			}
+			fmt.Fprintf(&line, ";%s=%s", key, value)
+		}
+
+		line.WriteString(":")
+		line.WriteString(escapeValue(property.name, property.value))
+		writeContentLine(out, line.String())
+	}
+
+	for _, child := range component.children {
+		writeComponent(out, child)
+	}
+
+	writeContentLine(out, "END:"+component.name)
+}
+
+// writeContentLine folds lines longer than 75 octets as both RFCs require,
+// taking care not to split multi-byte characters.
+func writeContentLine(out *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		out.WriteString(line[:cut])
+		out.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	out.WriteString(line)
+	out.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,61 @@
+package factory
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestVCardToJSON(t *testing.T) {
+	input := "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Alice Johnson\r\nEMAIL;TYPE=work:alice@\r\n example.com\r\nEMAIL:alice@home.org\r\nEND:VCARD\r\n"
+
+	result := (&ContentLinesToJSONConverter{}).Convert(strings.NewReader(input), models.FormatVCard, models.FormatJSON)
+
+	assert.NoError(t, result.Error)
+	assert.JSONEq(t, `[{
+		"version": "4.0",
+		"fn": "Alice Johnson",
+		"email": [{"value": "alice@example.com", "params": {"TYPE": "work"}}, "alice@home.org"]
+	}]`, string(result.Data))
+}
+
+func TestICalRoundTrip(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nDTSTART:20250101T090000Z\r\nSUMMARY:Kickoff\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	toJSON := (&ContentLinesToJSONConverter{}).Convert(strings.NewReader(input), models.FormatICal, models.FormatJSON)
+	assert.NoError(t, toJSON.Error)
+
+	back := (&JSONToContentLinesConverter{}).Convert(strings.NewReader(string(toJSON.Data)), models.FormatJSON, models.FormatICal)
+	assert.NoError(t, back.Error)
+	assert.Equal(t, input, string(back.Data))
+}
+
+func TestFlatRecordsBecomeEvents(t *testing.T) {
+	input := `[{"summary": "Standup", "dtstart": "20250102T100000Z"}]`
+
+	result := (&JSONToContentLinesConverter{}).Convert(strings.NewReader(input), models.FormatJSON, models.FormatICal)
+
+	assert.NoError(t, result.Error)
+	assert.Contains(t, string(result.Data), "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:")
+	assert.Contains(t, string(result.Data), "BEGIN:VEVENT\r\nDTSTART:20250102T100000Z\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n")
+}
+
+func TestContentLinesEscapeTextValues(t *testing.T) {
+	input := `{"fn": "Doe, Jane; \\ PhD", "n": "Doe;Jane;;;", "note": "line one\nline two ` + strings.Repeat("x", 80) + `"}`
+
+	result := (&JSONToContentLinesConverter{}).Convert(strings.NewReader(input), models.FormatJSON, models.FormatVCard)
+	assert.NoError(t, result.Error)
+	output := string(result.Data)
+	assert.Contains(t, output, "FN:Doe\\, Jane\\; \\\\ PhD\r\n")
+	assert.Contains(t, output, "N:Doe;Jane;;;\r\n")
+	assert.Contains(t, output, "NOTE:line one\\nline two ")
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+
+	back := (&ContentLinesToJSONConverter{}).Convert(strings.NewReader(output), models.FormatVCard, models.FormatJSON)
+	assert.NoError(t, back.Error)
+	assert.JSONEq(t, `[{"version": "4.0", "fn": "Doe, Jane; \\ PhD", "n": "Doe;Jane;;;", "note": "line one\nline two `+strings.Repeat("x", 80)+`"}]`, string(back.Data))
+}
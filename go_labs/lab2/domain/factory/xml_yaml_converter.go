@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/clbanning/mxj/v2"
-	"gopkg.in/yaml.v3"
+	"tmps-go-labs/lab2/domain/buffers"
 	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/xmlmap"
 )
 
-type XMLToYAMLConverter struct{}
+type XMLToYAMLConverter struct {
+	configured
+}
 
 func init() {
 	RegisterConverter("xml-yaml", func() models.Converter {
@@ -26,19 +28,20 @@ func (x *XMLToYAMLConverter) Convert(input io.Reader, from, to models.FileFormat
 	}
 
 	// Read XML data
-	xmlData, err := io.ReadAll(input)
+	xmlData, err := buffers.ReadAll(input)
 	if err != nil {
 		return &models.ConversionResult{Error: fmt.Errorf("failed to read XML: %w", err)}
 	}
+	defer buffers.Put(xmlData)
 
-	// Parse XML using mxj library
-	mv, err := mxj.NewMapXml(xmlData)
+	// Parse XML with mxj, or the xmlmap reader when XML options are set
+	m, err := xmlmap.Parse(xmlData.Bytes(), x.options.XML)
 	if err != nil {
 		return &models.ConversionResult{Error: fmt.Errorf("failed to parse XML: %w", err)}
 	}
 
 	// Convert map to YAML using gopkg.in/yaml.v3
-	yamlData, err := yaml.Marshal(mv.Old())
+	yamlData, err := marshalYAML(m, x.options)
 	if err != nil {
 		return &models.ConversionResult{Error: fmt.Errorf("failed to convert to YAML: %w", err)}
 	}
@@ -0,0 +1,56 @@
+// Package incremental skips pipelines whose input has not changed since
+// their last successful run. Fingerprints of inputs and pipeline definitions
+// are kept in a JSON state file shared by batch and scheduled runs.
+package incremental
+
+import (
+	"context"
+	"fmt"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+type Runner interface {
+	ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult
+}
+
+// Executor wraps a runner, skipping unchanged pipelines and recording each
+// successful run in the state, which is saved after every run so an
+// interrupted batch keeps its progress.
+type Executor struct {
+	runner Runner
+	state  *State
+}
+
+func NewExecutor(runner Runner, state *State) *Executor {
+	return &Executor{runner: runner, state: state}
+}
+
+func (e *Executor) ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	unchanged, err := e.state.Unchanged(pipeline)
+	if err != nil {
+		return &models.PipelineResult{Error: fmt.Errorf("failed to check input for changes: %w", err)}
+	}
+	if unchanged {
+		// A touched but identical input refreshed its modification time.
+		if err := e.state.Save(); err != nil {
+			return &models.PipelineResult{Error: err}
+		}
+		return &models.PipelineResult{Success: true, Skipped: true}
+	}
+
+	result := e.runner.ExecuteContext(ctx, pipeline)
+	if result.Error != nil || !result.Success {
+		return result
+	}
+
+	err = e.state.Record(pipeline)
+	if err == nil {
+		err = e.state.Save()
+	}
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Errorf("failed to record incremental state: %w", err)
+	}
+	return result
+}
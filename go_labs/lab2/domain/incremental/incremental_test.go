@@ -0,0 +1,88 @@
+package incremental
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab3/vfs"
+)
+
+type countingRunner struct {
+	runs     int
+	executor *factory.PipelineExecutor
+}
+
+func (r *countingRunner) ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	r.runs++
+	return r.executor.ExecuteContext(ctx, pipeline)
+}
+
+func TestExecutorSkipsUnchangedInputs(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "people.csv")
+	output := filepath.Join(dir, "people.json")
+	statePath := filepath.Join(dir, "state.json")
+	require.NoError(t, os.WriteFile(input, []byte("name\nann\n"), 0644))
+
+	pipeline, err := factory.NewPipelineBuilder().WithInputPath(input).WithOutputPath(output).AddCSVToJSON().Build()
+	require.NoError(t, err)
+
+	runner := &countingRunner{executor: factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory()))}
+	run := func() *models.PipelineResult {
+		state, err := LoadState(statePath)
+		require.NoError(t, err)
+		result := NewExecutor(runner, state).ExecuteContext(context.Background(), pipeline)
+		require.NoError(t, result.Error)
+		return result
+	}
+
+	assert.False(t, run().Skipped)
+	assert.True(t, run().Skipped)
+
+	// Touched without changes: the hash matches, so still skipped.
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(input, later, later))
+	assert.True(t, run().Skipped)
+	assert.Equal(t, 1, runner.runs)
+
+	require.NoError(t, os.WriteFile(input, []byte("name\nbob\n"), 0644))
+	assert.False(t, run().Skipped)
+
+	require.NoError(t, os.Remove(output))
+	assert.False(t, run().Skipped)
+
+	pipeline.Options.IndentWidth = new(int)
+	assert.False(t, run().Skipped)
+	assert.Equal(t, 4, runner.runs)
+}
+
+func TestExecutorAlwaysRunsURLInputs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("name\nann\n"))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	output := filepath.Join(dir, "people.json")
+	pipeline, err := factory.NewPipelineBuilder().WithInputPath(server.URL + "/people.csv").WithOutputPath(output).AddCSVToJSON().Build()
+	require.NoError(t, err)
+	runner := &countingRunner{executor: factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory())).WithFileSystem(vfs.Default())}
+	state, err := LoadState(filepath.Join(dir, "state.json"))
+	require.NoError(t, err)
+
+	for range 2 {
+		result := NewExecutor(runner, state).ExecuteContext(context.Background(), pipeline)
+		require.NoError(t, result.Error)
+		assert.False(t, result.Skipped)
+	}
+	assert.Equal(t, 2, runner.runs)
+}
@@ -0,0 +1,128 @@
+// Package inject adds user-provided content around a pipeline's output: a
+// license header, a generation notice or a comment block. The content is a
+// text/template over the run's metadata and can be written as comments of
+// the output format.
+package inject
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Metadata describes the run that wrote an output file. Part and Parts
+// number the file when the output is split, and are 1 otherwise.
+type Metadata struct {
+	RunID  string
+	Time   time.Time
+	Input  string
+	Output string
+	From   models.FileFormat
+	To     models.FileFormat
+	Part   int
+	Parts  int
+}
+
+// Check parses the templates and, when they are written as comments,
+// checks that format has comments.
+func Check(options models.InjectOptions, format models.FileFormat) error {
+	for _, text := range []string{options.Header, options.Footer} {
+		if _, err := parse(text); err != nil {
+			return err
+		}
+	}
+	if options.Comment {
+		if _, ok := comments[format]; !ok {
+			return fmt.Errorf("cannot write comments in %s output", format)
+		}
+	}
+	return nil
+}
+
+// Apply renders the header and footer for metadata and adds them around
+// data. In XML the header goes after the XML declaration.
+func Apply(data []byte, format models.FileFormat, options models.InjectOptions, metadata Metadata) ([]byte, error) {
+	header, err := render(options.Header, format, options.Comment, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render header: %w", err)
+	}
+	footer, err := render(options.Footer, format, options.Comment, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render footer: %w", err)
+	}
+
+	var prolog []byte
+	if format == models.FormatXML && bytes.HasPrefix(data, []byte("<?xml")) {
+		if end := bytes.Index(data, []byte("?>")); end >= 0 {
+			prolog, data = data[:end+2], bytes.TrimLeft(data[end+2:], "\r\n")
+			prolog = append(prolog[:len(prolog):len(prolog)], '\n')
+		}
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(prolog) + len(header) + len(data) + len(footer) + 1)
+	out.Write(prolog)
+	out.WriteString(header)
+	out.Write(data)
+	if footer != "" {
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			out.WriteByte('\n')
+		}
+		out.WriteString(footer)
+	}
+	return out.Bytes(), nil
+}
+
+// comment turns text into a comment of a format: either a prefix for every
+// line or a block around them.
+type comment struct {
+	line, open, close string
+}
+
+var comments = map[models.FileFormat]comment{
+	models.FormatCSV:      {line: "# "},
+	models.FormatYAML:     {line: "# "},
+	models.FormatXML:      {open: "<!--", close: "-->"},
+	models.FormatMarkdown: {open: "<!--", close: "-->"},
+}
+
+func parse(text string) (*template.Template, error) {
+	tmpl, err := template.New("inject").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func render(text string, format models.FileFormat, asComment bool, metadata Metadata) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, metadata); err != nil {
+		return "", err
+	}
+	rendered := strings.TrimRight(out.String(), "\n")
+	if !asComment {
+		return rendered + "\n", nil
+	}
+
+	c := comments[format]
+	if c.line == "" {
+		// "--" may not appear inside an XML comment.
+		return c.open + "\n" + strings.ReplaceAll(rendered, "--", "- -") + "\n" + c.close + "\n", nil
+	}
+	lines := strings.Split(rendered, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(c.line+line, " ")
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
@@ -0,0 +1,52 @@
+package inject
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+var metadata = Metadata{
+	RunID:  "0123abcd",
+	Time:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Input:  "people.csv",
+	Output: "people.yaml",
+	From:   models.FormatCSV,
+	To:     models.FormatYAML,
+	Part:   1,
+	Parts:  1,
+}
+
+func TestApplyWritesComments(t *testing.T) {
+	options := models.InjectOptions{
+		Header:  "Generated from {{.Input}} by run {{.RunID}}\n\nDo not edit.",
+		Footer:  "Written {{.Time.Format \"2006-01-02\"}}",
+		Comment: true,
+	}
+	out, err := Apply([]byte("- name: ann"), models.FormatYAML, options, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, "# Generated from people.csv by run 0123abcd\n#\n# Do not edit.\n- name: ann\n# Written 2024-05-01\n", string(out))
+
+	out, err = Apply([]byte("<?xml version=\"1.0\"?>\n<people/>\n"), models.FormatXML, models.InjectOptions{Header: "a -- b", Comment: true}, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, "<?xml version=\"1.0\"?>\n<!--\na - - b\n-->\n<people/>\n", string(out), "the header follows the XML declaration")
+}
+
+func TestApplyWritesRawContent(t *testing.T) {
+	out, err := Apply([]byte("{}"), models.FormatJSON, models.InjectOptions{Footer: "// part {{.Part}} of {{.Parts}}"}, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n// part 1 of 1\n", string(out))
+
+	_, err = Apply([]byte("{}"), models.FormatJSON, models.InjectOptions{Header: "{{.Missing}}"}, metadata)
+	assert.ErrorContains(t, err, "failed to render header")
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(models.InjectOptions{Header: "x", Comment: true}, models.FormatCSV))
+	assert.EqualError(t, Check(models.InjectOptions{Header: "x", Comment: true}, models.FormatJSON), "cannot write comments in json output")
+	assert.ErrorContains(t, Check(models.InjectOptions{Footer: "{{.RunID"}, models.FormatJSON), "failed to parse template")
+}
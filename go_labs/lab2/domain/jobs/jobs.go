@@ -0,0 +1,98 @@
+// Package jobs queues conversion requests so a pool of worker processes can
+// run them. The service enqueues a job and returns its ID; clients poll the
+// job status and fetch the output once a worker has finished it.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/random"
+	"tmps-go-labs/lab2/domain/runstate"
+)
+
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateDone      State = "done"
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+)
+
+var (
+	ErrNotFound        = errors.New("job not found")
+	ErrCancelled error = cancelledError{}
+	ErrQueueFull       = errors.New("job queue is full")
+)
+
+// cancelledError is ErrCancelled. It is also a context.Canceled, so a run
+// stopped with it as the cause ends cancelled rather than failed in code
+// that only knows about contexts, such as runstate.
+type cancelledError struct{}
+
+func (cancelledError) Error() string { return "job cancelled" }
+
+func (cancelledError) Is(target error) bool { return target == context.Canceled }
+
+type Job struct {
+	ID      string                   `json:"id"`
+	Tenant  string                   `json:"tenant"`
+	Owner   string                   `json:"owner"`
+	Steps   []models.ConversionStep  `json:"steps"`
+	Options models.ConversionOptions `json:"options"`
+	Timeout time.Duration            `json:"timeout"`
+	Input   []byte                   `json:"input"`
+}
+
+type Status struct {
+	ID        string             `json:"id"`
+	Tenant    string             `json:"tenant"`
+	Owner     string             `json:"owner"`
+	State     State              `json:"state"`
+	Error     string             `json:"error,omitempty"`
+	Output    []byte             `json:"output,omitempty"`
+	Run       *runstate.Snapshot `json:"run,omitempty"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// Queue is shared between the service and the workers. Enqueue fails with
+// ErrQueueFull instead of waiting for room. Dequeue blocks until a job is
+// available or ctx is done, and the worker Acks the job once it is finished.
+type Queue interface {
+	Enqueue(ctx context.Context, job *Job) error
+	Dequeue(ctx context.Context) (*Job, error)
+	Ack(ctx context.Context, job *Job) error
+	SetStatus(ctx context.Context, status *Status) error
+	Status(ctx context.Context, id string) (*Status, error)
+	// Cancel asks for the job to be stopped by the worker running it, or
+	// skipped by the one that dequeues it. Workers poll Cancelled, so the
+	// request can come from another process.
+	Cancel(ctx context.Context, id string) error
+	Cancelled(ctx context.Context, id string) (bool, error)
+	// Len returns the number of jobs waiting for a worker.
+	Len(ctx context.Context) (int, error)
+}
+
+// NewID returns a random job ID, drawn from random.Default so tests can
+// make it repeat. It panics when crypto/rand fails rather than hand out an
+// ID that may collide.
+func NewID() string {
+	return random.ID(random.Default(), 16)
+}
+
+func (j *Job) status(state State) *Status {
+	return &Status{ID: j.ID, Tenant: j.Tenant, Owner: j.Owner, State: state, UpdatedAt: time.Now().UTC()}
+}
+
+// finished reports whether the status is final.
+func (s *Status) finished() bool {
+	return s.State == StateDone || s.State == StateFailed || s.State == StateCancelled
+}
+
+func (j *Job) Pipeline() *models.Pipeline {
+	return &models.Pipeline{Steps: j.Steps, Options: j.Options}
+}
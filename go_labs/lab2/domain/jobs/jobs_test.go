@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/random"
+)
+
+func TestNewIDDrawsFromTheDefaultSource(t *testing.T) {
+	assert.Len(t, NewID(), 32)
+	assert.NotEqual(t, NewID(), NewID())
+
+	restore := random.SetDefault(random.New(3))
+	first := NewID()
+	restore()
+	defer random.SetDefault(random.New(3))()
+	assert.Equal(t, first, NewID())
+}
@@ -0,0 +1,137 @@
+// Package jobs queues conversion requests so a pool of worker processes can
+// run them. The service enqueues a job and returns its ID; clients poll the
+// job status and fetch the output once a worker has finished it.
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryQueue keeps jobs in process, for a single binary running both the
+// service and its workers. It holds at most capacity queued jobs. Like
+// RedisQueue, it forgets the statuses of finished jobs, output included,
+// StatusTTL after they finished.
+type MemoryQueue struct {
+	jobs      chan *Job
+	StatusTTL time.Duration
+
+	mu        sync.Mutex
+	statuses  map[string]*Status
+	cancelled map[string]bool
+	// finished lists the finished jobs in the order they finished, so the
+	// expired ones are found at its front.
+	finished []finishedJob
+}
+
+type finishedJob struct {
+	id string
+	at time.Time
+}
+
+func NewMemoryQueue(capacity int) *MemoryQueue {
+	return &MemoryQueue{
+		jobs:      make(chan *Job, capacity),
+		StatusTTL: DefaultStatusTTL,
+		statuses:  make(map[string]*Status),
+		cancelled: make(map[string]bool),
+	}
+}
+
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	if err := q.SetStatus(ctx, job.status(StateQueued)); err != nil {
+		return err
+	}
+
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		q.mu.Lock()
+		delete(q.statuses, job.ID)
+		q.mu.Unlock()
+		return ErrQueueFull
+	}
+}
+
+func (q *MemoryQueue) Dequeue(ctx context.Context) (*Job, error) {
+	select {
+	case job := <-q.jobs:
+		return job, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Ack does nothing: jobs in process are lost with the process anyway.
+func (q *MemoryQueue) Ack(ctx context.Context, job *Job) error {
+	return nil
+}
+
+// SetStatus records status. A final status also drops the job's
+// cancellation request, which the worker has acted on by then.
+func (q *MemoryQueue) SetStatus(ctx context.Context, status *Status) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire(time.Now())
+	q.statuses[status.ID] = status
+	if status.finished() {
+		delete(q.cancelled, status.ID)
+		q.finished = append(q.finished, finishedJob{id: status.ID, at: status.UpdatedAt})
+	}
+	return nil
+}
+
+func (q *MemoryQueue) Status(ctx context.Context, id string) (*Status, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire(time.Now())
+	status, ok := q.statuses[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return status, nil
+}
+
+// Cancel ignores jobs that are unknown or already finished, so that no
+// worker is left to drop the request.
+func (q *MemoryQueue) Cancel(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if status, ok := q.statuses[id]; ok && !status.finished() {
+		q.cancelled[id] = true
+	}
+	return nil
+}
+
+func (q *MemoryQueue) Cancelled(ctx context.Context, id string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.cancelled[id], nil
+}
+
+func (q *MemoryQueue) Len(ctx context.Context) (int, error) {
+	return len(q.jobs), nil
+}
+
+// expire forgets the jobs that finished StatusTTL or longer before now.
+// The caller holds q.mu.
+func (q *MemoryQueue) expire(now time.Time) {
+	expired := 0
+	for _, job := range q.finished {
+		if now.Sub(job.at) < q.StatusTTL {
+			break
+		}
+		// A job whose status was set again since is left alone.
+		if status, ok := q.statuses[job.id]; ok && status.UpdatedAt.Equal(job.at) {
+			delete(q.statuses, job.id)
+		}
+		expired++
+	}
+	if expired > 0 {
+		q.finished = append(q.finished[:0:0], q.finished[expired:]...)
+	}
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryQueueForgetsFinishedJobs(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue(4)
+	queue.StatusTTL = 20 * time.Millisecond
+
+	finished := &Job{ID: NewID()}
+	running := &Job{ID: NewID()}
+	assert.NoError(t, queue.SetStatus(ctx, running.status(StateRunning)))
+	assert.NoError(t, queue.Cancel(ctx, running.ID))
+	done := finished.status(StateDone)
+	done.Output = []byte("output")
+	assert.NoError(t, queue.SetStatus(ctx, done))
+
+	status, err := queue.Status(ctx, finished.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "output", string(status.Output))
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = queue.Status(ctx, finished.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	status, err = queue.Status(ctx, running.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StateRunning, status.State)
+	assert.Empty(t, queue.finished)
+}
+
+func TestMemoryQueueDropsCancellationOnceTheJobFinished(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue(4)
+	job := &Job{ID: NewID()}
+
+	assert.NoError(t, queue.Cancel(ctx, job.ID))
+	cancelled, _ := queue.Cancelled(ctx, job.ID)
+	assert.False(t, cancelled, "unknown jobs are not recorded")
+
+	assert.NoError(t, queue.Enqueue(ctx, job))
+	assert.NoError(t, queue.Cancel(ctx, job.ID))
+	cancelled, _ = queue.Cancelled(ctx, job.ID)
+	assert.True(t, cancelled)
+
+	assert.NoError(t, queue.SetStatus(ctx, job.status(StateCancelled)))
+	assert.Empty(t, queue.cancelled)
+	assert.NoError(t, queue.Cancel(ctx, job.ID))
+	assert.Empty(t, queue.cancelled)
+}
@@ -0,0 +1,162 @@
+// Package jobs queues conversion requests so a pool of worker processes can
+// run them. The service enqueues a job and returns its ID; clients poll the
+// job status and fetch the output once a worker has finished it.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	DefaultRedisPrefix = "tmps"
+	DefaultStatusTTL   = 24 * time.Hour
+)
+
+// RedisQueue pushes jobs onto a Redis list, so any number of worker
+// processes can share it. Dequeue moves a job onto a processing list with
+// BLMOVE, where it stays until it is acknowledged, so the jobs of a crashed
+// worker are not lost; Requeue puts them back. Statuses expire after
+// StatusTTL.
+type RedisQueue struct {
+	client    *redis.Client
+	prefix    string
+	StatusTTL time.Duration
+
+	mu sync.Mutex
+	// payloads holds the processing list entries of the jobs dequeued by
+	// this process, which Ack removes.
+	payloads map[string]string
+}
+
+func NewRedisQueue(client *redis.Client, prefix string) *RedisQueue {
+	if prefix == "" {
+		prefix = DefaultRedisPrefix
+	}
+	return &RedisQueue{client: client, prefix: prefix, StatusTTL: DefaultStatusTTL, payloads: make(map[string]string)}
+}
+
+func (q *RedisQueue) queueKey() string {
+	return q.prefix + ":jobs"
+}
+
+func (q *RedisQueue) processingKey() string {
+	return q.prefix + ":jobs:processing"
+}
+
+func (q *RedisQueue) statusKey(id string) string {
+	return q.prefix + ":job:" + id
+}
+
+func (q *RedisQueue) cancelKey(id string) string {
+	return q.prefix + ":job:" + id + ":cancel"
+}
+
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	if err := q.SetStatus(ctx, job.status(StateQueued)); err != nil {
+		return err
+	}
+	return q.client.LPush(ctx, q.queueKey(), data).Err()
+}
+
+func (q *RedisQueue) Dequeue(ctx context.Context) (*Job, error) {
+	for {
+		payload, err := q.client.BLMove(ctx, q.queueKey(), q.processingKey(), "RIGHT", "LEFT", 5*time.Second).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var job Job
+		if err := json.Unmarshal([]byte(payload), &job); err != nil {
+			// It can never be processed, so it is not kept either.
+			q.client.LRem(context.WithoutCancel(ctx), q.processingKey(), 1, payload)
+			return nil, fmt.Errorf("failed to decode job: %w", err)
+		}
+
+		q.mu.Lock()
+		q.payloads[job.ID] = payload
+		q.mu.Unlock()
+		return &job, nil
+	}
+}
+
+// Ack removes a job dequeued by this queue from the processing list.
+func (q *RedisQueue) Ack(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	payload, ok := q.payloads[job.ID]
+	delete(q.payloads, job.ID)
+	q.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return q.client.LRem(ctx, q.processingKey(), 1, payload).Err()
+}
+
+// Requeue moves every job left on the processing list back onto the queue
+// and returns how many there were. Jobs being processed are moved too, so
+// it is only safe while no worker is running.
+func (q *RedisQueue) Requeue(ctx context.Context) (int, error) {
+	moved := 0
+	for {
+		err := q.client.LMove(ctx, q.processingKey(), q.queueKey(), "RIGHT", "RIGHT").Err()
+		if errors.Is(err, redis.Nil) {
+			return moved, nil
+		}
+		if err != nil {
+			return moved, err
+		}
+		moved++
+	}
+}
+
+func (q *RedisQueue) SetStatus(ctx context.Context, status *Status) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to encode job status: %w", err)
+	}
+	return q.client.Set(ctx, q.statusKey(status.ID), data, q.StatusTTL).Err()
+}
+
+func (q *RedisQueue) Status(ctx context.Context, id string) (*Status, error) {
+	data, err := q.client.Get(ctx, q.statusKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to decode job status: %w", err)
+	}
+	return &status, nil
+}
+
+func (q *RedisQueue) Cancel(ctx context.Context, id string) error {
+	return q.client.Set(ctx, q.cancelKey(id), 1, q.StatusTTL).Err()
+}
+
+func (q *RedisQueue) Len(ctx context.Context) (int, error) {
+	length, err := q.client.LLen(ctx, q.queueKey()).Result()
+	return int(length), err
+}
+
+func (q *RedisQueue) Cancelled(ctx context.Context, id string) (bool, error) {
+	count, err := q.client.Exists(ctx, q.cancelKey(id)).Result()
+	return count > 0, err
+}
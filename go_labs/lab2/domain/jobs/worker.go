@@ -0,0 +1,201 @@
+// Package jobs queues conversion requests so a pool of worker processes can
+// run them. The service enqueues a job and returns its ID; clients poll the
+// job status and fetch the output once a worker has finished it.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/runstate"
+)
+
+const (
+	DefaultPollInterval = time.Second
+	DefaultDrainTimeout = 30 * time.Second
+)
+
+// ErrInterrupted reports a job stopped because its worker shut down. The
+// job is not acknowledged, so RedisQueue keeps it on the processing list
+// for Requeue.
+var ErrInterrupted = errors.New("job interrupted by worker shutdown")
+
+type Worker struct {
+	queue    Queue
+	executor *factory.PipelineExecutor
+	logger   *slog.Logger
+	// PollInterval is how often a running job publishes its progress and
+	// checks whether it was cancelled.
+	PollInterval time.Duration
+	// DrainTimeout is how long a running job may still take once the
+	// worker's context is done, before it is interrupted.
+	DrainTimeout time.Duration
+}
+
+func NewWorker(queue Queue, executor *factory.PipelineExecutor) *Worker {
+	return &Worker{
+		queue:        queue,
+		executor:     executor,
+		logger:       slog.Default(),
+		PollInterval: DefaultPollInterval,
+		DrainTimeout: DefaultDrainTimeout,
+	}
+}
+
+// WithLogger logs the worker's failures to logger instead of the default
+// slog logger.
+func (w *Worker) WithLogger(logger *slog.Logger) *Worker {
+	w.logger = logger
+	return w
+}
+
+// Run processes jobs until ctx is done. The job running then gets
+// DrainTimeout to finish; if it does not, it is left unacknowledged.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		job, err := w.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			w.logger.Error("dequeue failed", "error", err)
+			select {
+			case <-time.After(time.Second):
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		err = w.Process(ctx, job)
+		if errors.Is(err, ErrInterrupted) {
+			w.logger.Warn("job left for redelivery", "job", job.ID, "error", err)
+			return nil
+		}
+		if err != nil {
+			w.logger.Error("job bookkeeping failed", "job", job.ID, "error", err)
+		}
+		if err := w.queue.Ack(context.WithoutCancel(ctx), job); err != nil {
+			w.logger.Error("acknowledging job failed", "job", job.ID, "error", err)
+		}
+	}
+}
+
+// Process runs a single job and records its final status. The returned error
+// only reports status bookkeeping failures and ErrInterrupted; conversion
+// errors end up in the job status. Once ctx is done the job gets
+// DrainTimeout to finish; an interrupted job is put back to queued.
+func (w *Worker) Process(ctx context.Context, job *Job) error {
+	if ctx.Err() != nil {
+		return ErrInterrupted
+	}
+	// Bookkeeping and the run itself outlive ctx for the drain.
+	detached := context.WithoutCancel(ctx)
+	if cancelled, err := w.queue.Cancelled(detached, job.ID); err == nil && cancelled {
+		status := job.status(StateCancelled)
+		status.Error = ErrCancelled.Error()
+		return w.queue.SetStatus(detached, status)
+	}
+	if err := w.queue.SetStatus(detached, job.status(StateRunning)); err != nil {
+		return err
+	}
+
+	cancelCtx, cancelRun := context.WithCancelCause(detached)
+	defer cancelRun(nil)
+	stopDrain := context.AfterFunc(ctx, func() {
+		timer := time.NewTimer(w.DrainTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancelRun(ErrInterrupted)
+		case <-cancelCtx.Done():
+		}
+	})
+	defer stopDrain()
+
+	runCtx := cancelCtx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(cancelCtx, job.Timeout)
+		defer cancel()
+	}
+
+	pipeline := job.Pipeline()
+	run := runstate.New()
+	unfollow := run.Follow(w.executor.Events(), pipeline)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		w.monitor(cancelCtx, job, run, cancelRun, stop)
+	}()
+	output, result := w.executor.ConvertData(runCtx, pipeline, job.Input)
+	unfollow()
+	// The final status must not be overwritten by a late progress update.
+	close(stop)
+	<-stopped
+
+	snapshot := run.Snapshot()
+	status := job.status(StateDone)
+	status.Output = output
+	status.Run = &snapshot
+	if !result.Success {
+		status.State = StateFailed
+		status.Error = result.Error.Error()
+		if errors.Is(result.Error, context.DeadlineExceeded) {
+			status.Error = "job timed out: " + status.Error
+		}
+		if errors.Is(context.Cause(cancelCtx), ErrCancelled) {
+			status.State = StateCancelled
+			status.Error = ErrCancelled.Error()
+			status.Output = nil
+		}
+		if errors.Is(context.Cause(cancelCtx), ErrInterrupted) {
+			if err := w.queue.SetStatus(detached, job.status(StateQueued)); err != nil {
+				return err
+			}
+			return ErrInterrupted
+		}
+	}
+	return w.queue.SetStatus(detached, status)
+}
+
+// monitor publishes the running job's progress whenever a step has
+// finished or the steps have read more input since the last poll, so it
+// publishes at most once per PollInterval, and stops the run with
+// ErrCancelled once the job is cancelled. It returns when stop is closed.
+func (w *Worker) monitor(ctx context.Context, job *Job, run *runstate.Machine, cancel context.CancelCauseFunc, stop <-chan struct{}) {
+	interval := w.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	published := runstate.Progress{}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if cancelled, err := w.queue.Cancelled(ctx, job.ID); err == nil && cancelled {
+			cancel(ErrCancelled)
+		}
+
+		snapshot := run.Snapshot()
+		if snapshot.Progress == nil || (snapshot.Progress.StepsDone == published.StepsDone && snapshot.Progress.BytesRead == published.BytesRead) {
+			continue
+		}
+		published = *snapshot.Progress
+		status := job.status(StateRunning)
+		status.Run = &snapshot
+		if err := w.queue.SetStatus(context.WithoutCancel(ctx), status); err != nil {
+			w.logger.Error("publishing job progress failed", "job", job.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/runstate"
+)
+
+func TestWorkerProcessesQueuedJob(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue(4)
+	worker := NewWorker(queue, factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory())))
+
+	job := &Job{
+		ID:     NewID(),
+		Tenant: "analytics",
+		Steps:  []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}},
+		Input:  []byte("name\nann\n"),
+	}
+	assert.NoError(t, queue.Enqueue(ctx, job))
+
+	status, err := queue.Status(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StateQueued, status.State)
+
+	dequeued, err := queue.Dequeue(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, worker.Process(ctx, dequeued))
+
+	status, err = queue.Status(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StateDone, status.State)
+	assert.Equal(t, "analytics", status.Tenant)
+	assert.JSONEq(t, `[{"name":"ann"}]`, string(status.Output))
+	assert.Equal(t, runstate.StateSucceeded, status.Run.State)
+	assert.Len(t, status.Run.Transitions, 3)
+
+	_, err = queue.Status(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestWorkerSkipsCancelledJob(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue(4)
+	worker := NewWorker(queue, factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory())))
+
+	job := &Job{
+		ID:    NewID(),
+		Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}},
+		Input: []byte("name\nann\n"),
+	}
+	assert.NoError(t, queue.Enqueue(ctx, job))
+	assert.NoError(t, queue.Cancel(ctx, job.ID))
+
+	dequeued, err := queue.Dequeue(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, worker.Process(ctx, dequeued))
+
+	status, err := queue.Status(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StateCancelled, status.State)
+	assert.Empty(t, status.Output)
+}
+
+// blockingConverter holds its step until release is closed, keeping a job
+// running for as long as a test needs.
+type blockingConverter struct {
+	release chan struct{}
+}
+
+func (c *blockingConverter) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	io.Copy(io.Discard, input)
+	<-c.release
+	return &models.ConversionResult{Data: []byte("done"), Format: to}
+}
+
+func (c *blockingConverter) SupportsFormat(format models.FileFormat) bool { return true }
+
+func TestWorkerPublishesProgressAndCancelsRunningJob(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	factory.RegisterConverter("json-blocking", func() models.Converter { return &blockingConverter{release: release} })
+	defer factory.UnregisterConverter("json-blocking")
+
+	ctx := context.Background()
+	queue := NewMemoryQueue(4)
+	worker := NewWorker(queue, factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory())))
+	worker.PollInterval = 5 * time.Millisecond
+
+	job := &Job{
+		ID:    NewID(),
+		Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}, {From: models.FormatJSON, To: "blocking"}},
+		Input: []byte("name\nann\n"),
+	}
+	assert.NoError(t, queue.Enqueue(ctx, job))
+	dequeued, err := queue.Dequeue(ctx)
+	assert.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- worker.Process(ctx, dequeued) }()
+
+	assert.Eventually(t, func() bool {
+		status, err := queue.Status(ctx, job.ID)
+		return err == nil && status.Run != nil && status.Run.Progress.StepsDone == 1
+	}, 5*time.Second, 5*time.Millisecond)
+
+	status, _ := queue.Status(ctx, job.ID)
+	assert.Equal(t, StateRunning, status.State)
+	assert.Equal(t, float64(50), status.Run.Progress.Percent)
+	assert.Positive(t, status.Run.Progress.BytesProcessed)
+	assert.NotEmpty(t, status.Run.Progress.ETA)
+
+	assert.NoError(t, queue.Cancel(ctx, job.ID))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled job kept running")
+	}
+	status, _ = queue.Status(ctx, job.ID)
+	assert.Equal(t, StateCancelled, status.State)
+	assert.Equal(t, runstate.StateCancelled, status.Run.State)
+}
+
+func TestWorkerPublishesProgressWithinASingleStep(t *testing.T) {
+	release := make(chan struct{})
+	factory.RegisterConverter("csv-blocking", func() models.Converter { return &blockingConverter{release: release} })
+	defer factory.UnregisterConverter("csv-blocking")
+
+	ctx := context.Background()
+	queue := NewMemoryQueue(4)
+	worker := NewWorker(queue, factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory())))
+	worker.PollInterval = 5 * time.Millisecond
+
+	job := &Job{ID: NewID(), Steps: []models.ConversionStep{{From: models.FormatCSV, To: "blocking"}}, Input: []byte("name\nann\n")}
+	assert.NoError(t, queue.Enqueue(ctx, job))
+	dequeued, err := queue.Dequeue(ctx)
+	assert.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- worker.Process(ctx, dequeued) }()
+
+	assert.Eventually(t, func() bool {
+		status, err := queue.Status(ctx, job.ID)
+		return err == nil && status.Run != nil && status.Run.Progress.BytesRead == int64(len(job.Input))
+	}, 5*time.Second, 5*time.Millisecond)
+	status, _ := queue.Status(ctx, job.ID)
+	assert.Equal(t, StateRunning, status.State)
+	assert.Zero(t, status.Run.Progress.StepsDone)
+
+	close(release)
+	assert.NoError(t, <-done)
+}
+
+func TestWorkerFinishesRunningJobWithinDrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	factory.RegisterConverter("json-blocking", func() models.Converter { return &blockingConverter{release: release} })
+	defer factory.UnregisterConverter("json-blocking")
+
+	queue := NewMemoryQueue(4)
+	worker := NewWorker(queue, factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory())))
+	job := &Job{ID: NewID(), Steps: []models.ConversionStep{{From: models.FormatJSON, To: "blocking"}}, Input: []byte("{}")}
+	assert.NoError(t, queue.Enqueue(context.Background(), job))
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(ctx) }()
+	assert.Eventually(t, func() bool {
+		status, err := queue.Status(context.Background(), job.ID)
+		return err == nil && status.State == StateRunning
+	}, 5*time.Second, 5*time.Millisecond)
+
+	stop()
+	close(release)
+	assert.NoError(t, <-done)
+	status, err := queue.Status(context.Background(), job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StateDone, status.State)
+	assert.Equal(t, "done", string(status.Output))
+}
+
+func TestWorkerLeavesInterruptedJobQueued(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	factory.RegisterConverter("json-blocking", func() models.Converter { return &blockingConverter{release: release} })
+	defer factory.UnregisterConverter("json-blocking")
+
+	queue := NewMemoryQueue(4)
+	worker := NewWorker(queue, factory.NewPipelineExecutor(factory.NewConverterPool(1, factory.NewConverterFactory())))
+	worker.DrainTimeout = 10 * time.Millisecond
+	job := &Job{ID: NewID(), Steps: []models.ConversionStep{{From: models.FormatJSON, To: "blocking"}}, Input: []byte("{}")}
+	assert.NoError(t, queue.Enqueue(context.Background(), job))
+	dequeued, err := queue.Dequeue(context.Background())
+	assert.NoError(t, err)
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- worker.Process(ctx, dequeued) }()
+	assert.Eventually(t, func() bool {
+		status, err := queue.Status(context.Background(), job.ID)
+		return err == nil && status.State == StateRunning
+	}, 5*time.Second, 5*time.Millisecond)
+
+	stop()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrInterrupted)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job outlived the drain timeout")
+	}
+	status, err := queue.Status(context.Background(), job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StateQueued, status.State)
+}
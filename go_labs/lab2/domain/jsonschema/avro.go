@@ -0,0 +1,101 @@
+package jsonschema
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var avroInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Avro returns s as an Avro record schema named name, ready to marshal as
+// JSON. Integers map to long, numbers to double, date-time strings to
+// timestamp-millis longs and nested objects to records named after their
+// field. Nullable fields become unions with null that default to null.
+// Fields are sorted by name, and names are made valid Avro names.
+func Avro(s *Schema, name string) map[string]interface{} {
+	return avroRecord(s, avroName(name))
+}
+
+func avroRecord(s *Schema, name string) map[string]interface{} {
+	keys := make([]string, 0, len(s.Properties))
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		field := map[string]interface{}{"name": avroName(key)}
+		if field["name"] != key {
+			field["aliases"] = []string{key}
+		}
+		nullable := s.Properties[key].Type.Has(TypeNull) || !contains(s.Required, key)
+		field["type"] = avroType(s.Properties[key], name+"_"+avroName(key), nullable)
+		if nullable {
+			field["default"] = nil
+		}
+		fields = append(fields, field)
+	}
+	return map[string]interface{}{"type": "record", "name": name, "fields": fields}
+}
+
+// avroType returns the Avro type of values matching s. Schemas allowing
+// several types become unions, with null first when nullable.
+func avroType(s *Schema, name string, nullable bool) interface{} {
+	var union []interface{}
+	if nullable {
+		union = append(union, "null")
+	}
+	types := s.Type.NonNull()
+	if len(types) == 0 && !nullable {
+		types = Types{TypeString}
+	}
+	for _, t := range types {
+		switch t {
+		case TypeBoolean:
+			union = append(union, "boolean")
+		case TypeInteger:
+			union = append(union, "long")
+		case TypeNumber:
+			union = append(union, "double")
+		case TypeString:
+			if s.Format == FormatDateTime {
+				union = append(union, map[string]interface{}{"type": "long", "logicalType": "timestamp-millis"})
+			} else if s.Format == FormatDate {
+				union = append(union, map[string]interface{}{"type": "int", "logicalType": "date"})
+			} else {
+				union = append(union, "string")
+			}
+		case TypeObject:
+			union = append(union, avroRecord(s, name))
+		case TypeArray:
+			items := s.Items
+			if items == nil {
+				items = &Schema{}
+			}
+			union = append(union, map[string]interface{}{"type": "array", "items": avroType(items, name+"_item", items.Type.Has(TypeNull))})
+		}
+	}
+	if len(union) == 1 {
+		return union[0]
+	}
+	return union
+}
+
+func avroName(name string) string {
+	name = avroInvalid.ReplaceAllString(name, "_")
+	if name == "" || strings.ContainsAny(name[:1], "0123456789") {
+		name = "_" + name
+	}
+	return name
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
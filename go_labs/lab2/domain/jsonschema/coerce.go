@@ -0,0 +1,351 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tmps-go-labs/lab2/domain/values"
+)
+
+// ErrMismatch is wrapped by the error of values that do not match a schema.
+var ErrMismatch = errors.New("output does not match its JSON schema")
+
+// maxReported caps the violations an error lists.
+const maxReported = 5
+
+// Violation is a value that does not match its schema, at a path such as
+// "[2].address.zip".
+type Violation struct {
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Coerce makes v match s where it can and returns it with the violations
+// left. Scalars are converted to the first type of the schema they can
+// spell, such as "42" to 42 or 1 to true; a single value where a list is
+// expected becomes a list of one; missing required properties get their
+// default; and properties forbidden by additionalProperties are removed.
+// With validateOnly v is left as it is and every difference is a
+// violation. A list checked against an object schema has each of its
+// elements checked, so a schema of one record covers a list of them.
+func Coerce(v interface{}, s *Schema, validateOnly bool) (interface{}, []Violation) {
+	c := &coercer{validateOnly: validateOnly, patterns: make(map[string]*regexp.Regexp)}
+	if list, ok := v.([]interface{}); ok && !s.Type.Has(TypeArray) && s.Type.Has(TypeObject) {
+		for i, element := range list {
+			list[i] = c.value(element, s, fmt.Sprintf("[%d]", i))
+		}
+		return list, c.violations
+	}
+	return c.value(v, s, ""), c.violations
+}
+
+// Error returns an ErrMismatch error listing the first violations, or nil.
+func Error(violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	listed := make([]string, 0, maxReported)
+	for _, violation := range violations[:min(len(violations), maxReported)] {
+		listed = append(listed, violation.String())
+	}
+	more := ""
+	if len(violations) > maxReported {
+		more = fmt.Sprintf("; and %d more", len(violations)-maxReported)
+	}
+	return fmt.Errorf("%w: %s%s", ErrMismatch, strings.Join(listed, "; "), more)
+}
+
+type coercer struct {
+	validateOnly bool
+	violations   []Violation
+	patterns     map[string]*regexp.Regexp
+}
+
+func (c *coercer) fail(path, format string, args ...interface{}) {
+	c.violations = append(c.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *coercer) value(v interface{}, s *Schema, path string) interface{} {
+	if len(s.Type) > 0 && !matchesAny(v, s.Type) {
+		converted, ok := convert(v, s.Type)
+		if !ok || c.validateOnly {
+			c.fail(path, "expected %s, got %s", strings.Join(s.Type, " or "), describe(v))
+			return v
+		}
+		v = converted
+	}
+
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		c.object(typed, s, path)
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range typed {
+				typed[i] = c.value(item, s.Items, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	c.constraints(v, s, path)
+	return v
+}
+
+func (c *coercer) object(object map[string]interface{}, s *Schema, path string) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; ok {
+			continue
+		}
+		if property := s.Properties[name]; property != nil && property.Default != nil && !c.validateOnly {
+			object[name] = clone(property.Default)
+			continue
+		}
+		c.fail(join(path, name), "is required")
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		property, ok := s.Properties[key]
+		if ok {
+			object[key] = c.value(object[key], property, join(path, key))
+			continue
+		}
+		if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			if c.validateOnly {
+				c.fail(join(path, key), "is not allowed")
+				continue
+			}
+			delete(object, key)
+		}
+	}
+}
+
+func (c *coercer) constraints(v interface{}, s *Schema, path string) {
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if allowed == nil && v == nil || allowed != nil && v != nil && text(allowed) == text(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.fail(path, "%s is not one of the allowed values", describe(v))
+		}
+	}
+	if number, ok := values.Float(v); ok {
+		if s.Minimum != nil && number < *s.Minimum {
+			c.fail(path, "%s is below the minimum of %v", text(v), *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			c.fail(path, "%s is above the maximum of %v", text(v), *s.Maximum)
+		}
+	}
+	if str, ok := v.(string); ok && s.Pattern != "" {
+		pattern, ok := c.patterns[s.Pattern]
+		if !ok {
+			var err error
+			if pattern, err = regexp.Compile(s.Pattern); err != nil {
+				c.fail(path, "invalid pattern %q: %v", s.Pattern, err)
+			}
+			c.patterns[s.Pattern] = pattern
+		}
+		if pattern != nil && !pattern.MatchString(str) {
+			c.fail(path, "%q does not match %s", str, s.Pattern)
+		}
+	}
+}
+
+// clone copies the maps and lists of a default so records filled in with it
+// do not share them.
+func clone(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for key, child := range typed {
+			out[key] = clone(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, child := range typed {
+			out[i] = clone(child)
+		}
+		return out
+	}
+	return v
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func matchesAny(v interface{}, types Types) bool {
+	for _, name := range types {
+		if matches(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(v interface{}, name string) bool {
+	switch name {
+	case TypeNull:
+		return v == nil
+	case TypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case TypeString:
+		switch v.(type) {
+		case string, values.Timestamp, time.Time:
+			return true
+		}
+		return false
+	case TypeInteger:
+		number, ok := values.Float(v)
+		if decimal, isDecimal := v.(values.Decimal); isDecimal {
+			return !strings.ContainsAny(string(decimal), ".eE")
+		}
+		return ok && number == math.Trunc(number) && !math.IsInf(number, 0)
+	case TypeNumber:
+		_, ok := values.Float(v)
+		return ok
+	case TypeObject:
+		_, ok := v.(map[string]interface{})
+		return ok
+	case TypeArray:
+		_, ok := v.([]interface{})
+		return ok
+	}
+	return false
+}
+
+// convert returns v as the first of types it can be read as.
+func convert(v interface{}, types Types) (interface{}, bool) {
+	for _, name := range types {
+		if converted, ok := convertTo(v, name); ok {
+			return converted, true
+		}
+	}
+	return nil, false
+}
+
+func convertTo(v interface{}, name string) (interface{}, bool) {
+	str, isString := v.(string)
+	str = strings.TrimSpace(str)
+	switch name {
+	case TypeNull:
+		return nil, isString && str == ""
+	case TypeString:
+		if v == nil {
+			return nil, false
+		}
+		if _, ok := v.(map[string]interface{}); ok {
+			return nil, false
+		}
+		if _, ok := v.([]interface{}); ok {
+			return nil, false
+		}
+		return text(v), true
+	case TypeInteger:
+		if isString {
+			if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+				return i, true
+			}
+			if u, err := strconv.ParseUint(str, 10, 64); err == nil {
+				return u, true
+			}
+			if f, err := strconv.ParseFloat(str, 64); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+				return int64(f), true
+			}
+			return nil, false
+		}
+		if b, ok := v.(bool); ok {
+			return int64(boolInt(b)), true
+		}
+	case TypeNumber:
+		if isString {
+			if decimal, ok := values.ParseDecimal(str); ok {
+				if f, err := strconv.ParseFloat(string(decimal), 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == string(decimal) {
+					return f, true
+				}
+				return decimal, true
+			}
+			return nil, false
+		}
+		if b, ok := v.(bool); ok {
+			return float64(boolInt(b)), true
+		}
+	case TypeBoolean:
+		if isString {
+			b, err := strconv.ParseBool(str)
+			return b, err == nil
+		}
+		if number, ok := values.Float(v); ok && (number == 0 || number == 1) {
+			return number == 1, true
+		}
+	case TypeArray:
+		if _, ok := v.([]interface{}); !ok && v != nil {
+			return []interface{}{v}, true
+		}
+	}
+	return nil, false
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func text(v interface{}) string {
+	if str, ok := v.(string); ok {
+		return str
+	}
+	if str, ok := values.Text(v); ok {
+		return str
+	}
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := values.Float(v); ok {
+		return "number " + text(v)
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,75 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/values"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"required": ["id", "status", "tags"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"price": {"type": "number"},
+		"paid": {"type": "boolean"},
+		"note": {"type": ["string", "null"]},
+		"status": {"type": "string", "enum": ["open", "closed"], "default": "open"},
+		"tags": {"type": "array", "items": {"type": "string"}, "default": []}
+	}
+}`
+
+func TestCoerceConvertsFillsAndPrunes(t *testing.T) {
+	schema, err := Parse([]byte(orderSchema))
+	require.NoError(t, err)
+
+	out, violations := Coerce([]interface{}{
+		map[string]interface{}{"id": "7", "price": "9.50", "paid": "true", "note": nil, "tags": "a", "internal": "x"},
+		map[string]interface{}{"id": 8.0, "price": 3.0, "paid": 0.0, "note": 12.0, "status": "closed", "tags": []interface{}{1.0}},
+	}, schema, false)
+	assert.Empty(t, violations)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": int64(7), "price": values.Decimal("9.50"), "paid": true, "note": nil, "status": "open", "tags": []interface{}{"a"}},
+		map[string]interface{}{"id": 8.0, "price": 3.0, "paid": false, "note": "12", "status": "closed", "tags": []interface{}{"1"}},
+	}, out)
+}
+
+func TestCoerceReportsWhatItCannotFix(t *testing.T) {
+	schema, err := Parse([]byte(orderSchema))
+	require.NoError(t, err)
+
+	_, violations := Coerce(map[string]interface{}{
+		"id": "seven", "price": 0.0, "paid": "maybe", "status": "lost",
+	}, schema, false)
+	assert.Equal(t, []Violation{
+		{Path: "id", Message: `expected integer, got string "seven"`},
+		{Path: "paid", Message: `expected boolean, got string "maybe"`},
+		{Path: "status", Message: `string "lost" is not one of the allowed values`},
+	}, violations)
+
+	_, violations = Coerce(map[string]interface{}{"id": int64(0), "status": "open", "tags": []interface{}{}}, schema, false)
+	assert.Equal(t, []Violation{{Path: "id", Message: "0 is below the minimum of 1"}}, violations)
+}
+
+func TestCoerceValidateOnlyLeavesValues(t *testing.T) {
+	schema, err := Parse([]byte(orderSchema))
+	require.NoError(t, err)
+
+	record := map[string]interface{}{"id": "7", "tags": []interface{}{}, "extra": true}
+	out, violations := Coerce(record, schema, true)
+	assert.Equal(t, map[string]interface{}{"id": "7", "tags": []interface{}{}, "extra": true}, out)
+	assert.Equal(t, []Violation{
+		{Path: "status", Message: "is required"},
+		{Path: "extra", Message: "is not allowed"},
+		{Path: "id", Message: `expected integer, got string "7"`},
+	}, violations)
+
+	err = Error(violations)
+	assert.True(t, errors.Is(err, ErrMismatch))
+	assert.EqualError(t, err, `output does not match its JSON schema: status: is required; extra: is not allowed; id: expected integer, got string "7"`)
+}
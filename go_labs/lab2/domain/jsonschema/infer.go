@@ -0,0 +1,246 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+// InferOptions tune Infer. TypedStrings reads strings spelling numbers and
+// booleans as those types and empty strings as null, for CSV, XML and other
+// text input. Strings with at most EnumLimit distinct values, each seen more
+// than once, become an enum. Bounds records the lowest and highest number
+// seen, and Strict forbids properties the samples did not have.
+type InferOptions struct {
+	Title        string
+	TypedStrings bool
+	EnumLimit    int
+	Bounds       bool
+	Strict       bool
+}
+
+var (
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Infer returns the schema of an object that every one of rows matches.
+// A field is required when no row lacks it or leaves it null.
+func Infer(rows []records.Record, options InferOptions) *Schema {
+	root := newNode()
+	for _, row := range rows {
+		root.observe(map[string]interface{}(row), options)
+	}
+	schema := root.schema(options)
+	if schema.Type == nil {
+		schema.Type = Types{TypeObject}
+	}
+	schema.Schema = Draft
+	schema.Title = options.Title
+	return schema
+}
+
+// node gathers what the values seen at one place of the records have in
+// common.
+type node struct {
+	types    map[string]bool
+	objects  int
+	present  map[string]int
+	children map[string]*node
+	items    *node
+
+	min, max float64
+	strings  int
+	distinct map[string]int
+	formats  map[string]int
+}
+
+func newNode() *node {
+	return &node{types: make(map[string]bool), min: math.Inf(1), max: math.Inf(-1)}
+}
+
+func (n *node) observe(value interface{}, options InferOptions) {
+	if text, ok := value.(string); ok && options.TypedStrings {
+		value = typedString(text)
+	}
+	if number, ok := values.Float(value); ok {
+		n.number(number, isInteger(value, number))
+		return
+	}
+
+	switch typed := value.(type) {
+	case nil:
+		n.types[TypeNull] = true
+	case bool:
+		n.types[TypeBoolean] = true
+	case string:
+		n.text(typed, format(typed))
+	case values.Timestamp:
+		n.text(typed.String(), format(typed.String()))
+	case time.Time:
+		n.text(typed.Format(time.RFC3339Nano), FormatDateTime)
+	case map[string]interface{}:
+		n.types[TypeObject] = true
+		n.objects++
+		if n.children == nil {
+			n.children = make(map[string]*node)
+			n.present = make(map[string]int)
+		}
+		for key, child := range typed {
+			if n.children[key] == nil {
+				n.children[key] = newNode()
+			}
+			n.children[key].observe(child, options)
+			if child != nil && !(options.TypedStrings && child == "") {
+				n.present[key]++
+			}
+		}
+	case []interface{}:
+		n.types[TypeArray] = true
+		if n.items == nil {
+			n.items = newNode()
+		}
+		for _, item := range typed {
+			n.items.observe(item, options)
+		}
+	default:
+		n.text(stringOf(typed), "")
+	}
+}
+
+func (n *node) number(number float64, integer bool) {
+	if integer {
+		n.types[TypeInteger] = true
+	} else {
+		n.types[TypeNumber] = true
+	}
+	n.min = math.Min(n.min, number)
+	n.max = math.Max(n.max, number)
+}
+
+func (n *node) text(text, format string) {
+	n.types[TypeString] = true
+	n.strings++
+	if n.distinct == nil {
+		n.distinct = make(map[string]int)
+		n.formats = make(map[string]int)
+	}
+	// Past this many, no enum limit anyone would set is met.
+	if len(n.distinct) <= 1000 {
+		n.distinct[text]++
+	}
+	n.formats[format]++
+}
+
+func (n *node) schema(options InferOptions) *Schema {
+	schema := &Schema{}
+	if n.types[TypeInteger] && n.types[TypeNumber] {
+		delete(n.types, TypeInteger)
+	}
+	for _, name := range []string{TypeObject, TypeArray, TypeString, TypeInteger, TypeNumber, TypeBoolean, TypeNull} {
+		if n.types[name] {
+			schema.Type = append(schema.Type, name)
+		}
+	}
+
+	if n.children != nil {
+		schema.Properties = make(map[string]*Schema, len(n.children))
+		for key, child := range n.children {
+			schema.Properties[key] = child.schema(options)
+			if n.present[key] == n.objects {
+				schema.Required = append(schema.Required, key)
+			}
+		}
+		sort.Strings(schema.Required)
+		if options.Strict {
+			schema.AdditionalProperties = new(bool)
+		}
+	}
+	if n.items != nil {
+		schema.Items = n.items.schema(options)
+	}
+
+	if options.Bounds && (n.types[TypeInteger] || n.types[TypeNumber]) {
+		schema.Minimum, schema.Maximum = &n.min, &n.max
+	}
+	if n.strings > 0 {
+		for format, count := range n.formats {
+			if format != "" && count == n.strings {
+				schema.Format = format
+			}
+		}
+		if len(schema.Type.NonNull()) == 1 && options.EnumLimit > 0 && len(n.distinct) <= options.EnumLimit && len(n.distinct) < n.strings {
+			texts := make([]string, 0, len(n.distinct))
+			for text := range n.distinct {
+				texts = append(texts, text)
+			}
+			sort.Strings(texts)
+			for _, text := range texts {
+				schema.Enum = append(schema.Enum, text)
+			}
+			if n.types[TypeNull] {
+				schema.Enum = append(schema.Enum, nil)
+			}
+		}
+	}
+	return schema
+}
+
+// typedString reads text as the value it spells.
+func typedString(text string) interface{} {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return nil
+	}
+	if i, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(trimmed, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+	if b, err := strconv.ParseBool(trimmed); err == nil && len(trimmed) > 1 {
+		return b
+	}
+	return text
+}
+
+func isInteger(value interface{}, number float64) bool {
+	switch value.(type) {
+	case int, int64, uint64:
+		return true
+	case values.Decimal:
+		return false
+	}
+	return number == math.Trunc(number) && !math.IsInf(number, 0)
+}
+
+func format(text string) string {
+	if _, err := time.Parse(time.RFC3339Nano, text); err == nil {
+		return FormatDateTime
+	}
+	if _, err := time.Parse(time.DateOnly, text); err == nil {
+		return FormatDate
+	}
+	if uuidPattern.MatchString(text) {
+		return FormatUUID
+	}
+	if emailPattern.MatchString(text) {
+		return FormatEmail
+	}
+	return ""
+}
+
+func stringOf(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
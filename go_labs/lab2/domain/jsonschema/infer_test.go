@@ -0,0 +1,83 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func TestInferFromTypedRecords(t *testing.T) {
+	rows := []records.Record{
+		{"id": int64(1), "price": 9.5, "tags": []interface{}{"a"}, "owner": map[string]interface{}{"email": "ann@example.com"}},
+		{"id": int64(2), "price": int64(3), "tags": []interface{}{}, "owner": nil, "note": "x"},
+	}
+	schema := Infer(rows, InferOptions{Title: "orders", Strict: true})
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "orders",
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["id", "price", "tags"],
+		"properties": {
+			"id": {"type": "integer"},
+			"price": {"type": "number"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"note": {"type": "string"},
+			"owner": {
+				"type": ["object", "null"],
+				"additionalProperties": false,
+				"required": ["email"],
+				"properties": {"email": {"type": "string", "format": "email"}}
+			}
+		}
+	}`, string(data))
+}
+
+func TestInferFromTextRecords(t *testing.T) {
+	rows := []records.Record{
+		{"age": "31", "active": "true", "tier": "gold", "joined": "2024-01-02T10:00:00Z"},
+		{"age": "", "active": "false", "tier": "gold", "joined": "2024-02-03T10:00:00Z"},
+		{"age": "27.5", "active": "true", "tier": "silver", "joined": "2024-03-04T10:00:00Z"},
+	}
+	schema := Infer(rows, InferOptions{TypedStrings: true, EnumLimit: 2, Bounds: true})
+	assert.Equal(t, Types{TypeNumber, TypeNull}, schema.Properties["age"].Type)
+	assert.Equal(t, 27.5, *schema.Properties["age"].Minimum)
+	assert.Equal(t, 31.0, *schema.Properties["age"].Maximum)
+	assert.Equal(t, Types{TypeBoolean}, schema.Properties["active"].Type)
+	assert.Equal(t, []interface{}{"gold", "silver"}, schema.Properties["tier"].Enum)
+	assert.Equal(t, FormatDateTime, schema.Properties["joined"].Format)
+	assert.Nil(t, schema.Properties["joined"].Enum, "values seen once are not an enum")
+	assert.Equal(t, []string{"active", "joined", "tier"}, schema.Required)
+}
+
+func TestAvro(t *testing.T) {
+	schema := Infer([]records.Record{
+		{"id": int64(1), "first name": "ann", "at": "2024-01-02T10:00:00Z", "address": map[string]interface{}{"city": "x"}},
+		{"id": int64(2), "at": "2024-01-03T10:00:00Z", "address": map[string]interface{}{"city": "y"}},
+	}, InferOptions{})
+	data, err := json.Marshal(Avro(schema, "people-2024"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "record",
+		"name": "people_2024",
+		"fields": [
+			{"name": "address", "type": {"type": "record", "name": "people_2024_address", "fields": [{"name": "city", "type": "string"}]}},
+			{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "first_name", "aliases": ["first name"], "type": ["null", "string"], "default": null},
+			{"name": "id", "type": "long"}
+		]
+	}`, string(data))
+}
+
+func TestTypesAcceptNameOrList(t *testing.T) {
+	schema, err := Parse([]byte(`{"properties": {"a": {"type": "string"}, "b": {"type": ["integer", "null"]}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Types{TypeString}, schema.Properties["a"].Type)
+	assert.Equal(t, Types{TypeInteger}, schema.Properties["b"].Type.NonNull())
+}
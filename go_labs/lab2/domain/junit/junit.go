@@ -0,0 +1,82 @@
+// Package junit writes JUnit XML reports, the format CI servers read to show
+// test results. Validation reports and pipeline results are rendered as test
+// suites so a CI job can fail on them and show readable diagnostics.
+package junit
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     float64     `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Skipped   int        `xml:"skipped,attr"`
+	Time      float64    `xml:"time,attr"`
+	Cases     []TestCase `xml:"testcase"`
+	SystemOut string     `xml:"system-out,omitempty"`
+}
+
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      float64  `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// NewSuite totals the test, failure and skip counts of cases.
+func NewSuite(name string, cases []TestCase) TestSuite {
+	suite := TestSuite{Name: name, Tests: len(cases), Cases: cases}
+	for _, c := range cases {
+		suite.Time += c.Time
+		if c.Failure != nil {
+			suite.Failures++
+		}
+		if c.Skipped != nil {
+			suite.Skipped++
+		}
+	}
+	return suite
+}
+
+// Write renders suites as an indented JUnit document with totals.
+func Write(w io.Writer, suites ...TestSuite) error {
+	document := TestSuites{Suites: suites}
+	for _, suite := range suites {
+		document.Tests += suite.Tests
+		document.Failures += suite.Failures
+		document.Time += suite.Time
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
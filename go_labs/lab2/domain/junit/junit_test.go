@@ -0,0 +1,51 @@
+package junit
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestWritePipelineResult(t *testing.T) {
+	pipeline := &models.Pipeline{
+		InputPath: "people.csv",
+		Steps: []models.ConversionStep{
+			{From: models.FormatCSV, To: models.FormatJSON},
+			{From: models.FormatJSON, To: models.FormatXML},
+			{From: models.FormatXML, To: models.FormatYAML},
+		},
+	}
+	result := &models.PipelineResult{
+		Results:  []*models.ConversionResult{{Data: []byte("[]"), Format: models.FormatJSON}},
+		Error:    errors.New("step 2 aborted (json→xml): malformed JSON"),
+		Duration: 1500000000,
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WritePipelineResult(&out, pipeline, result))
+	xml := out.String()
+
+	assert.Contains(t, xml, `<testsuites tests="3" failures="1" time="1.5">`)
+	assert.Contains(t, xml, `<testsuite name="people.csv" tests="3" failures="1" skipped="1" time="1.5">`)
+	assert.Contains(t, xml, `<testcase name="step 1: csv to json" classname="people.csv" time="0">`+"\n"+`      <system-out>2 bytes of json</system-out>`)
+	assert.Contains(t, xml, `<failure message="step 2 aborted (json→xml): malformed JSON" type="error">`)
+	assert.Contains(t, xml, `<skipped message="not run after an earlier step failed"></skipped>`)
+}
+
+func TestWritePipelineResultReportsOutputFailure(t *testing.T) {
+	pipeline := &models.Pipeline{Steps: []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}}}
+	result := &models.PipelineResult{
+		Results: []*models.ConversionResult{{Data: []byte("[]"), Format: models.FormatJSON}},
+		Error:   errors.New("failed to write output file: permission denied"),
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WritePipelineResult(&out, pipeline, result))
+	assert.Contains(t, out.String(), `<testcase name="output" classname="pipeline" time="0">`)
+	assert.Contains(t, out.String(), `tests="2" failures="1"`)
+}
@@ -0,0 +1,75 @@
+// Package junit writes JUnit XML reports, the format CI servers read to show
+// test results. Validation reports and pipeline results are rendered as test
+// suites so a CI job can fail on them and show readable diagnostics.
+package junit
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// WritePipelineResult renders a run as one test case per step: steps that
+// produced output pass, the step the run stopped at fails with the run's
+// error, and later steps are skipped. A failure after the last step, such as
+// writing the output, becomes an extra "output" case. Archive runs get one
+// suite per entry.
+func WritePipelineResult(w io.Writer, pipeline *models.Pipeline, result *models.PipelineResult) error {
+	name := pipeline.InputPath
+	if name == "" {
+		name = "pipeline"
+	}
+
+	if len(result.Entries) == 0 {
+		suite := pipelineSuite(name, pipeline.Steps, result.Results, result.Error)
+		suite.Time = seconds(result.Duration)
+		return Write(w, suite)
+	}
+
+	suites := make([]TestSuite, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		suites = append(suites, pipelineSuite(name+"/"+entry.Name, pipeline.Steps, entry.Results, entry.Error))
+	}
+	return Write(w, suites...)
+}
+
+func pipelineSuite(name string, steps []models.ConversionStep, results []*models.ConversionResult, runErr error) TestSuite {
+	cases := make([]TestCase, 0, len(steps)+1)
+	failed := false
+	for i, step := range steps {
+		testCase := TestCase{Name: fmt.Sprintf("step %d: %s to %s", i+1, step.From, step.To), ClassName: name}
+
+		switch {
+		case i < len(results) && results[i].Error == nil:
+			testCase.SystemOut = fmt.Sprintf("%d bytes of %s", len(results[i].Data), results[i].Format)
+		case failed:
+			testCase.Skipped = &Skipped{Message: "not run after an earlier step failed"}
+		default:
+			failed = true
+			err := runErr
+			if i < len(results) {
+				err = results[i].Error
+			}
+			testCase.Failure = failure(err)
+		}
+		cases = append(cases, testCase)
+	}
+
+	if !failed && runErr != nil {
+		cases = append(cases, TestCase{Name: "output", ClassName: name, Failure: failure(runErr)})
+	}
+	return NewSuite(name, cases)
+}
+
+func failure(err error) *Failure {
+	if err == nil {
+		return &Failure{Message: "step did not complete"}
+	}
+	return &Failure{Message: err.Error(), Type: "error", Text: err.Error()}
+}
+
+func seconds(nanoseconds int64) float64 {
+	return time.Duration(nanoseconds).Seconds()
+}
@@ -0,0 +1,291 @@
+// Package limits guards against adversarial input: documents nested so
+// deeply that recursive parsers exhaust the stack, or holding so many
+// values that the document model exhausts memory. Check walks JSON and XML
+// with streaming tokenizers and YAML one document at a time, without
+// recursion, so it uses neither itself.
+package limits
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// ErrExceeded is wrapped by the errors of inputs over a limit.
+var ErrExceeded = errors.New("document limit exceeded")
+
+// Server bounds request bodies of the conversion service. Requests may
+// tighten it but not loosen it.
+var Server = models.DocumentLimits{MaxDepth: 512, MaxNodeCount: 1_000_000}
+
+// Tightest returns the lower of each limit, treating zero as no limit.
+func Tightest(a, b models.DocumentLimits) models.DocumentLimits {
+	return models.DocumentLimits{
+		MaxDepth:     lower(a.MaxDepth, b.MaxDepth),
+		MaxNodeCount: lower(a.MaxNodeCount, b.MaxNodeCount),
+	}
+}
+
+func lower(a, b int) int {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// Check reports whether the JSON, NDJSON, XML or YAML document in data
+// stays within limits. Values, elements, attributes and text count as
+// nodes. Other formats are flat and always pass.
+func Check(data []byte, format models.FileFormat, limits models.DocumentLimits) error {
+	if limits.MaxDepth <= 0 && limits.MaxNodeCount <= 0 {
+		return nil
+	}
+	c := &counter{data: data, limits: limits}
+	switch format {
+	case models.FormatJSON, models.FormatNDJSON, models.FormatGeoJSON:
+		return c.json()
+	case models.FormatXML:
+		return c.xml()
+	case models.FormatYAML:
+		return c.yaml()
+	}
+	return nil
+}
+
+type counter struct {
+	data   []byte
+	limits models.DocumentLimits
+	depth  int
+	nodes  int
+	// extents memoizes what the anchored YAML nodes expand to.
+	extents map[*yaml.Node]extent
+}
+
+// enter counts a node at the current depth, one level below its parent.
+func (c *counter) enter(offset int64) error {
+	c.nodes = saturatingAdd(c.nodes, 1)
+	if max := c.limits.MaxNodeCount; max > 0 && c.nodes > max {
+		return fmt.Errorf("%w: more than %d nodes (line %d)", ErrExceeded, max, c.line(offset))
+	}
+	if max := c.limits.MaxDepth; max > 0 && c.depth > max {
+		return fmt.Errorf("%w: nested deeper than %d levels (line %d)", ErrExceeded, max, c.line(offset))
+	}
+	return nil
+}
+
+func (c *counter) line(offset int64) int {
+	if offset > int64(len(c.data)) {
+		offset = int64(len(c.data))
+	}
+	return 1 + bytes.Count(c.data[:offset], []byte("\n"))
+}
+
+func (c *counter) json() error {
+	decoder := json.NewDecoder(bytes.NewReader(c.data))
+	// Object keys are tokens too; they belong to their value.
+	var inObject []bool
+	expectKey := false
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// Malformed input is left to the parser to report.
+			return nil
+		}
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			c.depth--
+			inObject = inObject[:len(inObject)-1]
+			expectKey = len(inObject) > 0 && inObject[len(inObject)-1]
+			continue
+		}
+		if expectKey {
+			expectKey = false
+			continue
+		}
+		c.depth++
+		if err := c.enter(offset); err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); ok {
+			inObject = append(inObject, delim == '{')
+			expectKey = delim == '{'
+			continue
+		}
+		c.depth--
+		expectKey = len(inObject) > 0 && inObject[len(inObject)-1]
+	}
+}
+
+func (c *counter) xml() error {
+	decoder := xml.NewDecoder(bytes.NewReader(c.data))
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			return nil
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			c.depth++
+			if err := c.enter(offset); err != nil {
+				return err
+			}
+			c.nodes += len(token.Attr)
+			if max := c.limits.MaxNodeCount; max > 0 && c.nodes > max {
+				return fmt.Errorf("%w: more than %d nodes (line %d)", ErrExceeded, max, c.line(offset))
+			}
+		case xml.EndElement:
+			c.depth--
+		case xml.CharData:
+			if len(bytes.TrimSpace(token)) > 0 {
+				c.depth++
+				err := c.enter(offset)
+				c.depth--
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// yaml decodes the stream one document at a time and walks each node tree
+// without recursion, so only one document is held at once. An alias counts
+// as the nodes it expands to, at the depth they end up at, which stops
+// billion laughs documents as well.
+func (c *counter) yaml() error {
+	c.extents = make(map[*yaml.Node]extent)
+	decoder := yaml.NewDecoder(bytes.NewReader(c.data))
+	for {
+		var root yaml.Node
+		if err := decoder.Decode(&root); err != nil {
+			// The end of the stream, or malformed input left to the
+			// parser to report.
+			return nil
+		}
+		if err := c.yamlDocument(&root); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *counter) yamlDocument(root *yaml.Node) error {
+	type entry struct {
+		node  *yaml.Node
+		depth int
+	}
+	stack := []entry{{root, 0}}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if top.node.Kind == yaml.DocumentNode {
+			for _, child := range top.node.Content {
+				stack = append(stack, entry{child, 1})
+			}
+			continue
+		}
+
+		c.depth = top.depth
+		if top.node.Kind == yaml.AliasNode && top.node.Alias != nil {
+			expanded := c.expansion(top.node.Alias)
+			c.depth += expanded.height - 1
+			c.nodes = saturatingAdd(c.nodes, expanded.nodes-1)
+		}
+		if err := c.enter(c.offset(top.node)); err != nil {
+			return err
+		}
+		for i := len(top.node.Content) - 1; i >= 0; i-- {
+			stack = append(stack, entry{top.node.Content[i], top.depth + 1})
+		}
+	}
+	return nil
+}
+
+// extent is the number of nodes in a YAML subtree with its aliases
+// expanded, and its height.
+type extent struct {
+	nodes, height int
+}
+
+// expansion measures the subtree an alias of node expands to, without
+// recursion. Extents are memoized, so nested aliases are measured once
+// however often they repeat. An alias inside the node it refers to counts
+// as a single node, as the parser either rejects it or turns it into null.
+func (c *counter) expansion(node *yaml.Node) extent {
+	if known, ok := c.extents[node]; ok {
+		return known
+	}
+
+	type frame struct {
+		node     *yaml.Node
+		next     int
+		children extent
+	}
+	add := func(f *frame, child extent) {
+		f.children.nodes = saturatingAdd(f.children.nodes, child.nodes)
+		f.children.height = max(f.children.height, child.height)
+	}
+
+	stack := []*frame{{node: node}}
+	open := map[*yaml.Node]bool{node: true}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if top.next < len(top.node.Content) {
+			child := top.node.Content[top.next]
+			top.next++
+			if child.Kind == yaml.AliasNode && child.Alias != nil {
+				child = child.Alias
+			}
+			if known, ok := c.extents[child]; ok {
+				add(top, known)
+			} else if open[child] {
+				add(top, extent{nodes: 1, height: 1})
+			} else {
+				open[child] = true
+				stack = append(stack, &frame{node: child})
+			}
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+		delete(open, top.node)
+		measured := extent{nodes: saturatingAdd(top.children.nodes, 1), height: top.children.height + 1}
+		c.extents[top.node] = measured
+		if len(stack) > 0 {
+			add(stack[len(stack)-1], measured)
+		}
+	}
+	return c.extents[node]
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// offset returns the offset of the start of node's line.
+func (c *counter) offset(node *yaml.Node) int64 {
+	line, offset := 1, 0
+	for offset < len(c.data) && line < node.Line {
+		next := bytes.IndexByte(c.data[offset:], '\n')
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+		line++
+	}
+	return int64(offset)
+}
@@ -0,0 +1,68 @@
+package limits
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestCheckDepth(t *testing.T) {
+	inputs := map[models.FileFormat]string{
+		models.FormatJSON: `{"a": [1, {"b": [true]}]}`,
+		models.FormatXML:  `<a x="1"><b><c>text</c></b></a>`,
+		models.FormatYAML: "a:\n  - 1\n  - b:\n      - true\n",
+	}
+	depths := map[models.FileFormat]int{models.FormatJSON: 5, models.FormatXML: 4, models.FormatYAML: 5}
+	for format, input := range inputs {
+		depth := depths[format]
+		assert.NoError(t, Check([]byte(input), format, models.DocumentLimits{MaxDepth: depth}), format)
+		err := Check([]byte(input), format, models.DocumentLimits{MaxDepth: depth - 1})
+		assert.ErrorIs(t, err, ErrExceeded, format)
+	}
+}
+
+func TestCheckNodeCount(t *testing.T) {
+	ndjson := `{"a": 1, "b": [2, 3]}` + "\n" + `{"a": 4}` + "\n"
+	assert.NoError(t, Check([]byte(ndjson), models.FormatNDJSON, models.DocumentLimits{MaxNodeCount: 7}))
+	err := Check([]byte(ndjson), models.FormatNDJSON, models.DocumentLimits{MaxNodeCount: 6})
+	require.ErrorIs(t, err, ErrExceeded)
+	assert.ErrorContains(t, err, "more than 6 nodes (line 2)")
+
+	xml := `<a x="1" y="2"><b>one</b><b>two</b></a>`
+	assert.NoError(t, Check([]byte(xml), models.FormatXML, models.DocumentLimits{MaxNodeCount: 7}))
+	assert.ErrorIs(t, Check([]byte(xml), models.FormatXML, models.DocumentLimits{MaxNodeCount: 6}), ErrExceeded)
+}
+
+func TestCheckYAMLExpandsAliasesInEveryDocument(t *testing.T) {
+	multi := "a: 1\n---\nb: [1, 2, 3]\n"
+	assert.NoError(t, Check([]byte(multi), models.FormatYAML, models.DocumentLimits{MaxNodeCount: 9}))
+	assert.ErrorIs(t, Check([]byte(multi), models.FormatYAML, models.DocumentLimits{MaxNodeCount: 8}), ErrExceeded)
+
+	var laughs strings.Builder
+	laughs.WriteString("a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol]\n")
+	for level := 'b'; level <= 'i'; level++ {
+		fmt.Fprintf(&laughs, "%c: &%c [*%c, *%c, *%c, *%c, *%c, *%c, *%c, *%c, *%c]\n", level, level,
+			level-1, level-1, level-1, level-1, level-1, level-1, level-1, level-1, level-1)
+	}
+	err := Check([]byte(laughs.String()), models.FormatYAML, models.DocumentLimits{MaxNodeCount: 1_000_000})
+	assert.ErrorContains(t, err, "more than 1000000 nodes")
+	err = Check([]byte(laughs.String()), models.FormatYAML, models.DocumentLimits{MaxDepth: 5})
+	assert.ErrorContains(t, err, "nested deeper than 5 levels")
+}
+
+func TestCheckDeepInputWithoutRecursion(t *testing.T) {
+	deep := strings.Repeat("[", 1_000_000) + strings.Repeat("]", 1_000_000)
+	err := Check([]byte(deep), models.FormatJSON, models.DocumentLimits{MaxDepth: 100})
+	assert.ErrorContains(t, err, "nested deeper than 100 levels")
+	assert.NoError(t, Check([]byte(deep), models.FormatCSV, models.DocumentLimits{MaxDepth: 100}))
+}
+
+func TestTightest(t *testing.T) {
+	got := Tightest(models.DocumentLimits{MaxDepth: 10}, models.DocumentLimits{MaxDepth: 20, MaxNodeCount: 5})
+	assert.Equal(t, models.DocumentLimits{MaxDepth: 10, MaxNodeCount: 5}, got)
+}
@@ -0,0 +1,93 @@
+// Package lookup joins records against a small table loaded into memory
+// from a CSV, JSON or other parseable file, adding the matching row's
+// columns to each record, e.g. a country name for a country code.
+package lookup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+// Table maps key values, compared as text, to their rows.
+type Table struct {
+	key  string
+	rows map[string]records.Record
+}
+
+// Format returns the format of the table file at path: format when set,
+// otherwise the one its extension implies.
+func Format(path string, format models.FileFormat) (models.FileFormat, error) {
+	if format != "" {
+		return format, nil
+	}
+	if detected, ok := models.FormatFromPath(path); ok {
+		return detected, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of lookup table %s; set it explicitly", path)
+}
+
+// Load reads the table at path and indexes its rows by the key column.
+// Keys must be present and unique, so every match is exact and single.
+func Load(path string, format models.FileFormat, key string, options models.ConversionOptions) (*Table, error) {
+	format, err := Format(path, format)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lookup table: %w", err)
+	}
+	doc, err := document.Parse(bytes.NewReader(data), format, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lookup table %s: %w", path, err)
+	}
+
+	table := &Table{key: key, rows: make(map[string]records.Record)}
+	for i, row := range records.Find(doc.Root) {
+		value, ok := row[key]
+		if !ok || value == nil {
+			return nil, fmt.Errorf("lookup table %s: row %d has no %s", path, i+1, key)
+		}
+		text := fmt.Sprint(value)
+		if _, duplicate := table.rows[text]; duplicate {
+			return nil, fmt.Errorf("lookup table %s: duplicate %s %q", path, key, text)
+		}
+		table.rows[text] = row
+	}
+	return table, nil
+}
+
+func (t *Table) Len() int {
+	return len(t.rows)
+}
+
+// Enrich adds to record the columns of the row whose key equals the
+// record's field, each named with prefix in front, and reports whether a
+// row matched. Without columns, every column but the key is added.
+func (t *Table) Enrich(record records.Record, field string, columns []string, prefix string) bool {
+	value, ok := record[field]
+	if !ok || value == nil {
+		return false
+	}
+	row, ok := t.rows[fmt.Sprint(value)]
+	if !ok {
+		return false
+	}
+	if len(columns) == 0 {
+		for column, value := range row {
+			if column != t.key {
+				record[prefix+column] = value
+			}
+		}
+		return true
+	}
+	for _, column := range columns {
+		record[prefix+column] = row[column]
+	}
+	return true
+}
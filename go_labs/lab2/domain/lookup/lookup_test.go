@@ -0,0 +1,46 @@
+package lookup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func TestEnrich(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "countries.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"code": "MD", "name": "Moldova", "currency": "MDL"},
+		{"code": "RO", "name": "Romania", "currency": "RON"}
+	]`), 0644))
+	table, err := Load(path, "", "code", models.ConversionOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, table.Len())
+
+	record := records.Record{"country": "MD"}
+	assert.True(t, table.Enrich(record, "country", []string{"name"}, "country_"))
+	assert.Equal(t, records.Record{"country": "MD", "country_name": "Moldova"}, record)
+
+	record = records.Record{"code": "RO"}
+	assert.True(t, table.Enrich(record, "code", nil, ""))
+	assert.Equal(t, records.Record{"code": "RO", "name": "Romania", "currency": "RON"}, record)
+
+	record = records.Record{"country": "FR"}
+	assert.False(t, table.Enrich(record, "country", nil, ""))
+	assert.Equal(t, records.Record{"country": "FR"}, record)
+}
+
+func TestLoadRejectsDuplicateKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "countries.csv")
+	require.NoError(t, os.WriteFile(path, []byte("code,name\nMD,Moldova\nMD,Moldavia\n"), 0644))
+	_, err := Load(path, "", "code", models.ConversionOptions{})
+	assert.EqualError(t, err, `lookup table `+path+`: duplicate code "MD"`)
+
+	_, err = Load(filepath.Join(t.TempDir(), "countries"), "", "code", models.ConversionOptions{})
+	assert.ErrorContains(t, err, "cannot tell the format")
+}
@@ -0,0 +1,124 @@
+// Package manifest records what a pipeline run consumed and produced, and
+// optionally signs that record with ed25519 so downstream systems can verify
+// the integrity and origin of converted data.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const Version = 1
+
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Step records one pipeline step. ConverterVersion is the version of the
+// converter that ran it, empty for steps that run no converter.
+type Step struct {
+	From             models.FileFormat `json:"from"`
+	To               models.FileFormat `json:"to"`
+	ConverterVersion string            `json:"converter_version,omitempty"`
+}
+
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Input     File      `json:"input"`
+	Outputs   []File    `json:"outputs"`
+	Steps     []Step    `json:"steps"`
+}
+
+func New(pipeline *models.Pipeline, outputs []string) (*Manifest, error) {
+	input, err := Describe(pipeline.InputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Input:     input,
+		Outputs:   make([]File, 0, len(outputs)),
+		Steps:     make([]Step, 0, len(pipeline.Steps)),
+	}
+
+	for _, output := range outputs {
+		file, err := Describe(output)
+		if err != nil {
+			return nil, err
+		}
+		m.Outputs = append(m.Outputs, file)
+	}
+
+	for _, step := range pipeline.Steps {
+		m.Steps = append(m.Steps, Step{From: step.From, To: step.To})
+	}
+
+	return m, nil
+}
+
+func Describe(path string) (File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+
+	return File{Path: path, SHA256: hex.EncodeToString(hash.Sum(nil)), Size: size}, nil
+}
+
+func (m *Manifest) Marshal() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+func Read(path string) (*Manifest, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &m, data, nil
+}
+
+// CheckOutputs re-hashes every output and reports the first mismatch.
+func (m *Manifest) CheckOutputs() error {
+	for _, expected := range m.Outputs {
+		actual, err := Describe(expected.Path)
+		if err != nil {
+			return err
+		}
+		if actual.SHA256 != expected.SHA256 {
+			return fmt.Errorf("output %s does not match manifest hash", expected.Path)
+		}
+	}
+	return nil
+}
+
+func ManifestPath(outputPath string) string {
+	return outputPath + ".manifest.json"
+}
+
+func SignaturePath(outputPath string) string {
+	return outputPath + ".sig"
+}
@@ -0,0 +1,121 @@
+// Package manifest records what a pipeline run consumed and produced, and
+// optionally signs that record with ed25519 so downstream systems can verify
+// the integrity and origin of converted data.
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/encryption"
+)
+
+// The signature covers the manifest bytes, and the manifest pins the SHA-256
+// of every output, so one detached signature protects both.
+
+func Sign(privateKey ed25519.PrivateKey, manifestData []byte) []byte {
+	signature := ed25519.Sign(privateKey, manifestData)
+	return []byte(base64.StdEncoding.EncodeToString(signature) + "\n")
+}
+
+// Verify checks the signature of the manifest written for outputPath and
+// that the outputs on disk still match the hashes it records.
+func Verify(publicKey ed25519.PublicKey, outputPath string) (*Manifest, error) {
+	m, data, err := Read(ManifestPath(outputPath))
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := os.ReadFile(SignaturePath(outputPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	if !ed25519.Verify(publicKey, data, signature) {
+		return nil, fmt.Errorf("manifest signature is invalid")
+	}
+
+	if err := m.CheckOutputs(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadPrivateKey accepts a PKCS#8 PEM block, a 32-byte seed or a 64-byte
+// private key, the latter two raw, hex or base64 encoded.
+func LoadPrivateKey(env, file string) (ed25519.PrivateKey, error) {
+	material, err := encryption.ReadKeyMaterial(env, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(material); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is not ed25519")
+		}
+		return privateKey, nil
+	}
+
+	raw := decodeKey(material)
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, fmt.Errorf("ed25519 private key must be a %d-byte seed or %d-byte key", ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+// LoadPublicKey accepts a PKIX PEM block or 32 raw, hex or base64 bytes.
+func LoadPublicKey(env, file string) (ed25519.PublicKey, error) {
+	material, err := encryption.ReadKeyMaterial(env, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(material); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is not ed25519")
+		}
+		return publicKey, nil
+	}
+
+	raw := decodeKey(material)
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("ed25519 public key must be %d bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+func decodeKey(material []byte) []byte {
+	text := strings.TrimSpace(string(material))
+	if raw, err := hex.DecodeString(text); err == nil {
+		return raw
+	}
+	if raw, err := base64.StdEncoding.DecodeString(text); err == nil {
+		return raw
+	}
+	return material
+}
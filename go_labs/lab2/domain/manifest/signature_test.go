@@ -0,0 +1,53 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	output := filepath.Join(dir, "out.json")
+	assert.NoError(t, os.WriteFile(input, []byte("a\n1\n"), 0644))
+	assert.NoError(t, os.WriteFile(output, []byte(`[{"a":"1"}]`), 0644))
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	pipeline := &models.Pipeline{
+		InputPath:  input,
+		OutputPath: output,
+		Steps:      []models.ConversionStep{{From: models.FormatCSV, To: models.FormatJSON}},
+	}
+	m, err := New(pipeline, []string{output})
+	assert.NoError(t, err)
+	data, err := m.Marshal()
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(ManifestPath(output), data, 0644))
+	assert.NoError(t, os.WriteFile(SignaturePath(output), Sign(privateKey, data), 0644))
+
+	verified, err := Verify(publicKey, output)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Outputs[0].SHA256, verified.Outputs[0].SHA256)
+
+	assert.NoError(t, os.WriteFile(output, []byte(`[{"a":"2"}]`), 0644))
+	_, err = Verify(publicKey, output)
+	assert.ErrorContains(t, err, "does not match")
+}
+
+func TestLoadPrivateKeyFromSeed(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	t.Setenv("TEST_SIGNING_KEY", hex.EncodeToString(seed))
+
+	key, err := LoadPrivateKey("TEST_SIGNING_KEY", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, ed25519.NewKeyFromSeed(seed), key)
+}
@@ -0,0 +1,132 @@
+// Package migrate moves records from one schema version to another with
+// declarative field renames, computed fields, defaults for new fields and
+// drops of retired ones, so data migrations run as a pipeline step.
+package migrate
+
+import (
+	"fmt"
+	"sort"
+
+	"tmps-go-labs/lab2/domain/expr"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+// Migration is a compiled models.Migration.
+type Migration struct {
+	spec     models.Migration
+	renamed  []string
+	computed []string
+	set      []expr.Expr
+	defaults []string
+}
+
+// Compile checks spec and parses its expressions.
+func Compile(spec models.Migration) (*Migration, error) {
+	if spec.VersionField != "" && (spec.From == "" || spec.To == "") {
+		return nil, fmt.Errorf("migrating %s needs both a from and a to version", spec.VersionField)
+	}
+	if spec.VersionField != "" && spec.From == spec.To {
+		return nil, fmt.Errorf("from and to versions are both %q", spec.From)
+	}
+	if spec.VersionField == "" && (spec.From != "" || spec.To != "") {
+		return nil, fmt.Errorf("from and to versions need a version field")
+	}
+
+	m := &Migration{spec: spec}
+	targets := make(map[string]string)
+	for from, to := range spec.Rename {
+		if to == "" {
+			return nil, fmt.Errorf("rename of %s has no new name", from)
+		}
+		if other, taken := targets[to]; taken {
+			first, second := sortPair(from, other)
+			return nil, fmt.Errorf("%s and %s are both renamed to %s", first, second, to)
+		}
+		targets[to] = from
+		m.renamed = append(m.renamed, from)
+	}
+	sort.Strings(m.renamed)
+
+	for _, field := range spec.Drop {
+		if from, ok := targets[field]; ok {
+			return nil, fmt.Errorf("%s is renamed to %s and also dropped", from, field)
+		}
+		if _, ok := spec.Set[field]; ok {
+			return nil, fmt.Errorf("%s is both set and dropped", field)
+		}
+	}
+	for field := range spec.Set {
+		m.computed = append(m.computed, field)
+	}
+	sort.Strings(m.computed)
+	for _, field := range m.computed {
+		value, err := expr.Parse(spec.Set[field])
+		if err != nil {
+			return nil, fmt.Errorf("set %s = %q: %w", field, spec.Set[field], err)
+		}
+		m.set = append(m.set, value)
+	}
+	for field := range spec.Defaults {
+		m.defaults = append(m.defaults, field)
+	}
+	sort.Strings(m.defaults)
+	return m, nil
+}
+
+func sortPair(a, b string) (string, string) {
+	if b < a {
+		return b, a
+	}
+	return a, b
+}
+
+// Apply migrates record in place and reports whether it was migrated,
+// which is false for records already at the target version.
+func (m *Migration) Apply(record records.Record) (bool, error) {
+	if field := m.spec.VersionField; field != "" {
+		version, ok := record[field]
+		switch {
+		case ok && version != nil && fmt.Sprint(version) == m.spec.To:
+			return false, nil
+		case ok && version != nil && fmt.Sprint(version) != m.spec.From:
+			return false, fmt.Errorf("version %v is neither %s nor %s", version, m.spec.From, m.spec.To)
+		}
+	}
+
+	moved := make(map[string]interface{}, len(m.renamed))
+	for _, from := range m.renamed {
+		if value, ok := record[from]; ok {
+			moved[m.spec.Rename[from]] = value
+			delete(record, from)
+		}
+	}
+	for to, value := range moved {
+		record[to] = value
+	}
+
+	env := map[string]interface{}(record)
+	values := make([]interface{}, len(m.set))
+	for i, value := range m.set {
+		var err error
+		if values[i], err = value.Eval(env); err != nil {
+			return false, fmt.Errorf("field %s: %w", m.computed[i], err)
+		}
+	}
+	for i, field := range m.computed {
+		record[field] = values[i]
+	}
+
+	for _, field := range m.defaults {
+		if record[field] == nil {
+			record[field] = m.spec.Defaults[field]
+		}
+	}
+	for _, field := range m.spec.Drop {
+		delete(record, field)
+	}
+	if m.spec.VersionField != "" {
+		record[m.spec.VersionField] = m.spec.To
+	}
+	return true, nil
+}
@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func TestApply(t *testing.T) {
+	migration, err := Compile(models.Migration{
+		VersionField: "v",
+		From:         "1",
+		To:           "2",
+		Rename:       map[string]string{"fname": "first_name", "first_name": "given"},
+		Set:          map[string]string{"full_name": `given + " " + lname`},
+		Defaults:     map[string]interface{}{"country": "MD", "given": "?"},
+		Drop:         []string{"lname", "legacy"},
+	})
+	require.NoError(t, err)
+
+	record := records.Record{"v": 1.0, "fname": "ann", "first_name": "Ann", "lname": "Lee", "legacy": true, "country": nil}
+	migrated, err := migration.Apply(record)
+	require.NoError(t, err)
+	assert.True(t, migrated)
+	assert.Equal(t, records.Record{"v": "2", "first_name": "ann", "given": "Ann", "full_name": "Ann Lee", "country": "MD"}, record, "renames read the record before writing")
+
+	current := records.Record{"v": "2", "given": "Bob"}
+	migrated, err = migration.Apply(current)
+	require.NoError(t, err)
+	assert.False(t, migrated)
+	assert.Equal(t, records.Record{"v": "2", "given": "Bob"}, current)
+
+	unversioned := records.Record{"first_name": "Cid", "lname": "Ng"}
+	_, err = migration.Apply(unversioned)
+	require.NoError(t, err)
+	assert.Equal(t, records.Record{"v": "2", "given": "Cid", "full_name": "Cid Ng", "country": "MD"}, unversioned)
+
+	_, err = migration.Apply(records.Record{"v": "3"})
+	assert.EqualError(t, err, "version 3 is neither 1 nor 2")
+}
+
+func TestCompileRejectsConflicts(t *testing.T) {
+	for spec, message := range map[*models.Migration]string{
+		{Rename: map[string]string{"a": "x", "b": "x"}}:            "a and b are both renamed to x",
+		{Rename: map[string]string{"a": "x"}, Drop: []string{"x"}}: "a is renamed to x and also dropped",
+		{Set: map[string]string{"x": "1"}, Drop: []string{"x"}}:    "x is both set and dropped",
+		{VersionField: "v", From: "1"}:                             "migrating v needs both a from and a to version",
+		{From: "1", To: "2"}:                                       "from and to versions need a version field",
+		{Set: map[string]string{"x": "1 +"}}:                       `set x = "1 +"`,
+	} {
+		_, err := Compile(*spec)
+		assert.ErrorContains(t, err, message)
+	}
+}
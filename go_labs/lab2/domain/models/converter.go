@@ -3,21 +3,35 @@
 // design patterns implemented in the factory package.
 package models
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 type FileFormat string
 
 const (
-	FormatCSV  FileFormat = "csv"
-	FormatJSON FileFormat = "json"
-	FormatXML  FileFormat = "xml"
-	FormatYAML FileFormat = "yaml"
+	FormatCSV        FileFormat = "csv"
+	FormatJSON       FileFormat = "json"
+	FormatXML        FileFormat = "xml"
+	FormatYAML       FileFormat = "yaml"
+	FormatMarkdown   FileFormat = "markdown"
+	FormatTemplate   FileFormat = "template"
+	FormatFixedWidth FileFormat = "fixedwidth"
+	FormatVCard      FileFormat = "vcard"
+	FormatICal       FileFormat = "ical"
+	FormatGeoJSON    FileFormat = "geojson"
+	FormatNDJSON     FileFormat = "ndjson"
+	FormatXLSX       FileFormat = "xlsx"
 )
 
+// ConversionResult is the output of a step. Quality is set by quality
+// steps.
 type ConversionResult struct {
-	Data   []byte
-	Format FileFormat
-	Error  error
+	Data    []byte
+	Format  FileFormat
+	Error   error
+	Quality *QualityReport
 }
 
 type Converter interface {
@@ -25,9 +39,286 @@ type Converter interface {
 	SupportsFormat(format FileFormat) bool
 }
 
+// StreamConverter converts while it reads, writing output as it is
+// produced. The streaming strategy chains such converters through pipes so
+// no step's whole output is held in memory.
+type StreamConverter interface {
+	Converter
+	ConvertStream(input io.Reader, output io.Writer, from, to FileFormat) error
+}
+
+// ContextConverter converters are given the step's context, so pipeline
+// cancellation, step timeouts and the trace reach the work they do, such as
+// a request to another service. The executor prefers ConvertContext to
+// Convert.
+type ContextConverter interface {
+	Converter
+	ConvertContext(ctx context.Context, input io.Reader, from, to FileFormat) *ConversionResult
+}
+
+// Configurable converters receive the pipeline options before each conversion.
+type Configurable interface {
+	Configure(options ConversionOptions)
+}
+
+// Resettable converters drop per-use state, such as the options of the last
+// pipeline, when they are returned to the pool, so nothing carries over to
+// the next pipeline that uses them.
+type Resettable interface {
+	Reset()
+}
+
 type ConversionOptions struct {
-	Indent                bool
+	// Deprecated: set IndentWidth. Indent is still read from existing
+	// configs and means DefaultIndentWidth.
+	Indent bool
+	// Deprecated: set IndentWidth. PrettyPrint is still read from existing
+	// configs and means DefaultIndentWidth.
 	PrettyPrint           bool
+	IndentWidth           *int
+	CSVDelimiter          rune
+	CSVParser             CSVParser
+	XMLRoot               string
 	Headers               []string
 	SaveIntermediarySteps bool
+	Profile               bool
+	Provenance            bool
+	PreserveComments      bool
+	LosslessTypes         bool
+	FloatNumbers          bool
+	YAML                  YAMLOptions
+	XML                   XMLOptions
+	Limits                DocumentLimits
+	Template              string
+	TemplatePath          string
+	FixedWidthColumns     []FixedWidthColumn
+	Geo                   GeoOptions
+	ArchiveEntryGlob      string
+	Encryption            EncryptionOptions
+	Manifest              ManifestOptions
+	Strategy              ExecutionStrategy
+	Sanitize              SanitizeOptions
+	SnapshotSteps         []int
+	PostProcess           []string
+	Validation            ValidationOptions
+	OutputSchema          OutputSchemaOptions
+	Delta                 DeltaOptions
+	Split                 SplitOptions
+	Inject                InjectOptions
+	KeyOrder              KeyOrder
+	Seed                  *int64 `json:",omitempty"`
+	StepTimeout           StepTimeout
+}
+
+// ExecutionStrategy trades throughput against memory when running a
+// pipeline. The zero value behaves as StrategySequential.
+type ExecutionStrategy string
+
+const (
+	// StrategySequential runs steps one after another, keeping each step's
+	// full output in memory.
+	StrategySequential ExecutionStrategy = "sequential"
+	// StrategyConcurrent additionally converts archive entries in parallel,
+	// bounded by the converter pool size.
+	StrategyConcurrent ExecutionStrategy = "concurrent"
+	// StrategyStreaming runs all steps at once, piping each step's output
+	// into the next instead of holding it between steps.
+	StrategyStreaming ExecutionStrategy = "streaming"
+)
+
+// YAMLOptions control anchors and aliases. Aliases and merge keys of YAML
+// input are always expanded; a cyclic alias fails the parse unless
+// BreakCycles replaces it with null, and MaxAliasDepth limits how many
+// aliases may be followed inside one another, 32 when unset. Anchors writes
+// repeated objects and lists of YAML output once, with an anchor, and
+// aliases them after that.
+type YAMLOptions struct {
+	BreakCycles   bool `json:",omitempty"`
+	MaxAliasDepth int  `json:",omitempty"`
+	Anchors       bool `json:",omitempty"`
+}
+
+// KeyCollation compares the keys of JSON and YAML objects. The zero value
+// behaves as CollationLexical.
+type KeyCollation string
+
+const (
+	// CollationLexical compares keys byte by byte, like encoding/json.
+	CollationLexical KeyCollation = "lexical"
+	// CollationNatural compares runs of digits by value, so "item2" comes
+	// before "item10".
+	CollationNatural KeyCollation = "natural"
+)
+
+// KeyOrder sorts the keys of JSON, NDJSON and YAML output objects and the
+// columns of CSV output: First keys in their listed order, then the other
+// keys by Collation, then Last keys in their listed order, e.g.
+// First: ["id"], Last: ["metadata"].
+type KeyOrder struct {
+	Collation KeyCollation `json:",omitempty"`
+	First     []string     `json:",omitempty"`
+	Last      []string     `json:",omitempty"`
+}
+
+// IsZero reports whether the default lexical order applies.
+func (o KeyOrder) IsZero() bool {
+	return (o.Collation == "" || o.Collation == CollationLexical) && len(o.First) == 0 && len(o.Last) == 0
+}
+
+// CSVParser selects how whole CSV tables are read. The zero value behaves
+// as CSVParserStandard.
+type CSVParser string
+
+const (
+	// CSVParserStandard reads with encoding/csv.
+	CSVParserStandard CSVParser = "standard"
+	// CSVParserParallel splits large inputs at record boundaries and parses
+	// the chunks in parallel (see package csvparse). It reads the whole
+	// input first and returns the same records as CSVParserStandard.
+	CSVParserParallel CSVParser = "parallel"
+)
+
+// FixedWidthColumn describes one field of a fixed-width record. Start is the
+// 1-based position of the first character, as in most record layouts.
+type FixedWidthColumn struct {
+	Name   string
+	Start  int
+	Length int
+	Type   string
+}
+
+// Handling of CDATA sections and mixed content in XML input.
+const (
+	XMLText        = "text"
+	XMLConcatenate = "concatenate"
+	XMLPreserve    = "preserve"
+	XMLError       = "error"
+)
+
+// XMLOptions control how XML input text is read. CDATA is XMLText to read
+// CDATA sections as text, XMLPreserve to keep them under "#cdata" apart
+// from other text, or XMLError to reject them. MixedContent, for elements
+// holding both text and child elements, is XMLConcatenate to join every
+// text run into "#text", XMLPreserve to also list the runs and children in
+// order under "#content", or XMLError to reject such elements. Setting
+// either reads XML with a parser that keeps every text run, where the
+// default mxj parser keeps only some. Documents with a DTD are rejected
+// unless AllowDTD is set; external DTDs and entities always are.
+type XMLOptions struct {
+	CDATA        string `json:",omitempty"`
+	MixedContent string `json:",omitempty"`
+	AllowDTD     bool   `json:",omitempty"`
+}
+
+// DocumentLimits bound the nesting depth and the number of values,
+// elements, attributes and text runs of JSON, XML and YAML input, which
+// is checked before it is parsed. Zero means no limit.
+type DocumentLimits struct {
+	MaxDepth     int `json:",omitempty"`
+	MaxNodeCount int `json:",omitempty"`
+}
+
+// GeoOptions control how point coordinates map to CSV columns. Empty column
+// names fall back to common spellings (lat/latitude, lon/lng/longitude).
+type GeoOptions struct {
+	LatColumn   string
+	LonColumn   string
+	Validate    bool
+	SkipInvalid bool
+}
+
+// SanitizeOptions enable the input sanitizer chain (encoding fix, BOM strip,
+// control-character removal, whitespace normalization). Skip names handlers
+// to leave out.
+type SanitizeOptions struct {
+	Enabled           bool
+	Skip              []string
+	TrimTrailingSpace bool
+}
+
+// ValidationOptions check the input records against the rules in SchemaPath
+// and Rules before conversion and write a report next to the output, as
+// JSON (the default) or as JUnit XML when ReportFormat is "junit". FailOn
+// is the lowest severity that fails the run: "error" (the default),
+// "warning", or "none" to only write the report.
+type ValidationOptions struct {
+	SchemaPath   string
+	Rules        []ValidationRule `json:",omitempty"`
+	ReportFormat string
+	FailOn       string `json:",omitempty"`
+}
+
+// OutputSchemaOptions make the final output match the JSON Schema at Path
+// before post-processing: values are converted to the types it declares,
+// missing required properties get their defaults and properties it does
+// not allow are removed. Whatever still does not match fails the run.
+// ValidateOnly fails on any difference instead of changing the output.
+type OutputSchemaOptions struct {
+	Path         string
+	ValidateOnly bool `json:",omitempty"`
+}
+
+// Enabled reports whether there are any rules to check.
+func (o ValidationOptions) Enabled() bool {
+	return o.SchemaPath != "" || len(o.Rules) > 0
+}
+
+// ValidationRule constrains one field of every record, or the whole record
+// when Check is used without Field, in pipeline configs and schema files
+// alike (validation.Rule). Min and Max bound numbers, Enum lists the
+// allowed values, and Severity is "error" (the default) or "warning".
+type ValidationRule struct {
+	ID       string   `json:"id,omitempty"`
+	Field    string   `json:"field,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+	Check    string   `json:"check,omitempty"`
+	Message  string   `json:"message,omitempty"`
+}
+
+// DeltaOptions make file runs write only the records that changed since
+// the previous run, matched by the Key field, with a full snapshot every
+// FullEvery runs (never, when zero) and whenever there is no previous run.
+type DeltaOptions struct {
+	Key       string
+	FullEvery int
+}
+
+// SplitOptions cut CSV and NDJSON file output into numbered parts of at
+// most MaxRows records and MaxBytes bytes each. Zero means no limit.
+type SplitOptions struct {
+	MaxRows  int
+	MaxBytes int64
+}
+
+// InjectOptions add Header before file output and Footer after it, such as
+// a license or a generation notice. Both are text/template templates over
+// the run's metadata (see inject.Metadata). With Comment every line becomes
+// a comment of the output format.
+type InjectOptions struct {
+	Header  string
+	Footer  string
+	Comment bool
+}
+
+// EncryptionOptions enable AES-GCM decryption of the input and encryption of
+// the output. The key is loaded from KeyEnv, or from KeyFile when unset.
+type EncryptionOptions struct {
+	DecryptInput  bool
+	EncryptOutput bool
+	KeyEnv        string
+	KeyFile       string
+}
+
+// ManifestOptions write a run manifest next to the output, signed with the
+// ed25519 key from SigningKeyEnv or SigningKeyFile when one is configured.
+type ManifestOptions struct {
+	Enabled        bool
+	SigningKeyEnv  string
+	SigningKeyFile string
 }
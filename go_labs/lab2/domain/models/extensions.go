@@ -0,0 +1,52 @@
+// Package models defines the core interfaces and data structures for file format
+// conversion operations. It provides the foundation types used by the creational
+// design patterns implemented in the factory package.
+package models
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+var extensions = map[string]FileFormat{
+	".csv":      FormatCSV,
+	".json":     FormatJSON,
+	".xml":      FormatXML,
+	".yaml":     FormatYAML,
+	".yml":      FormatYAML,
+	".md":       FormatMarkdown,
+	".markdown": FormatMarkdown,
+	".vcf":      FormatVCard,
+	".ics":      FormatICal,
+	".geojson":  FormatGeoJSON,
+	".ndjson":   FormatNDJSON,
+	".jsonl":    FormatNDJSON,
+	".xlsx":     FormatXLSX,
+}
+
+// FormatFromPath infers a file's format from its extension.
+func FormatFromPath(path string) (FileFormat, bool) {
+	format, ok := extensions[strings.ToLower(filepath.Ext(path))]
+	return format, ok
+}
+
+// canonicalExtensions names the extension written for formats whose name
+// is not their usual extension.
+var canonicalExtensions = map[FileFormat]string{
+	FormatMarkdown: ".md",
+	FormatVCard:    ".vcf",
+	FormatICal:     ".ics",
+}
+
+// Extension returns the usual file extension of a format, such as ".yaml",
+// and false for formats without one.
+func Extension(format FileFormat) (string, bool) {
+	if extension, ok := canonicalExtensions[format]; ok {
+		return extension, true
+	}
+	extension := "." + string(format)
+	if extensions[extension] != format {
+		return "", false
+	}
+	return extension, true
+}
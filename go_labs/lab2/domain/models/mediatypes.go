@@ -0,0 +1,60 @@
+// Package models defines the core interfaces and data structures for file format
+// conversion operations. It provides the foundation types used by the creational
+// design patterns implemented in the factory package.
+package models
+
+import (
+	"mime"
+	"strings"
+)
+
+var mediaTypes = map[FileFormat]string{
+	FormatCSV:      "text/csv",
+	FormatJSON:     "application/json",
+	FormatXML:      "application/xml",
+	FormatYAML:     "application/yaml",
+	FormatMarkdown: "text/markdown",
+	FormatGeoJSON:  "application/geo+json",
+	FormatVCard:    "text/vcard",
+	FormatICal:     "text/calendar",
+	FormatNDJSON:   "application/x-ndjson",
+	FormatXLSX:     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Aliases seen in the wild for the formats above.
+var mediaTypeAliases = map[string]FileFormat{
+	"text/xml":              FormatXML,
+	"text/yaml":             FormatYAML,
+	"application/x-yaml":    FormatYAML,
+	"text/x-yaml":           FormatYAML,
+	"text/x-markdown":       FormatMarkdown,
+	"text/x-vcard":          FormatVCard,
+	"application/jsonl":     FormatNDJSON,
+	"application/x-jsonl":   FormatNDJSON,
+	"application/ndjson":    FormatNDJSON,
+	"application/jsonlines": FormatNDJSON,
+}
+
+// MediaType returns the media type of a format, without parameters, or
+// false for formats such as template output that have none of their own.
+func MediaType(format FileFormat) (string, bool) {
+	mediaType, ok := mediaTypes[format]
+	return mediaType, ok
+}
+
+// FormatFromMediaType maps a media type such as "text/csv; charset=utf-8"
+// to its format. Parameters and case are ignored.
+func FormatFromMediaType(mediaType string) (FileFormat, bool) {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = parsed
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	for format, candidate := range mediaTypes {
+		if candidate == mediaType {
+			return format, true
+		}
+	}
+	format, ok := mediaTypeAliases[mediaType]
+	return format, ok
+}
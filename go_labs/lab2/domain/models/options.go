@@ -0,0 +1,200 @@
+// Package models defines the core interfaces and data structures for file format
+// conversion operations. It provides the foundation types used by the creational
+// design patterns implemented in the factory package.
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultIndentWidth  = 2
+	MaxIndentWidth      = 16
+	DefaultCSVDelimiter = ','
+	DefaultXMLRoot      = "root"
+)
+
+// Option sets one conversion option. New options are added as new Option
+// constructors, so callers never depend on the shape of ConversionOptions.
+type Option func(*ConversionOptions)
+
+func NewOptions(options ...Option) ConversionOptions {
+	var o ConversionOptions
+	o.Apply(options...)
+	return o
+}
+
+func (o *ConversionOptions) Apply(options ...Option) {
+	for _, option := range options {
+		option(o)
+	}
+}
+
+// WithIndentWidth sets the indentation of JSON, XML and YAML output. Zero
+// produces compact output.
+func WithIndentWidth(width int) Option {
+	return func(o *ConversionOptions) {
+		o.IndentWidth = &width
+	}
+}
+
+func WithCSVDelimiter(delimiter rune) Option {
+	return func(o *ConversionOptions) {
+		o.CSVDelimiter = delimiter
+	}
+}
+
+// WithCSVParser selects the parser used to read whole CSV tables.
+func WithCSVParser(parser CSVParser) Option {
+	return func(o *ConversionOptions) {
+		o.CSVParser = parser
+	}
+}
+
+// WithKeyOrder sorts the keys of JSON, NDJSON and YAML output objects and
+// the columns of CSV output.
+func WithKeyOrder(order KeyOrder) Option {
+	return func(o *ConversionOptions) {
+		o.KeyOrder = order
+	}
+}
+
+// WithYAML sets how YAML aliases are read and written.
+func WithYAML(yaml YAMLOptions) Option {
+	return func(o *ConversionOptions) {
+		o.YAML = yaml
+	}
+}
+
+// WithXML sets how CDATA sections and mixed content of XML input are read.
+func WithXML(xml XMLOptions) Option {
+	return func(o *ConversionOptions) {
+		o.XML = xml
+	}
+}
+
+// WithLimits bounds the nesting depth and node count of input documents.
+func WithLimits(limits DocumentLimits) Option {
+	return func(o *ConversionOptions) {
+		o.Limits = limits
+	}
+}
+
+// WithLosslessTypes reads JSON and YAML numbers as int64, uint64 or exact
+// decimals and YAML timestamps with their text, instead of as float64 and
+// time.Time. This is the default; the option overrides WithFloatNumbers.
+func WithLosslessTypes() Option {
+	return func(o *ConversionOptions) {
+		o.LosslessTypes = true
+	}
+}
+
+// WithFloatNumbers reads JSON and YAML numbers as float64 and YAML
+// timestamps as time.Time, as encoding/json and yaml.v3 do. Integers past
+// 2^53 lose precision.
+func WithFloatNumbers() Option {
+	return func(o *ConversionOptions) {
+		o.FloatNumbers = true
+	}
+}
+
+// Lossless reports whether numbers and timestamps are read exactly: unless
+// FloatNumbers asks otherwise, they are.
+func (o ConversionOptions) Lossless() bool {
+	return o.LosslessTypes || !o.FloatNumbers
+}
+
+// WithOutputSchema makes the final output match a JSON Schema.
+func WithOutputSchema(schema OutputSchemaOptions) Option {
+	return func(o *ConversionOptions) {
+		o.OutputSchema = schema
+	}
+}
+
+// WithSeed makes the random draws of a run, its run ID and the records of
+// sample steps without their own seed, repeat for the same seed.
+func WithSeed(seed int64) Option {
+	return func(o *ConversionOptions) {
+		o.Seed = &seed
+	}
+}
+
+// WithStepTimeout bounds how long every step of a run may take.
+func WithStepTimeout(timeout StepTimeout) Option {
+	return func(o *ConversionOptions) {
+		o.StepTimeout = timeout
+	}
+}
+
+func WithXMLRoot(name string) Option {
+	return func(o *ConversionOptions) {
+		o.XMLRoot = name
+	}
+}
+
+func WithHeaders(headers ...string) Option {
+	return func(o *ConversionOptions) {
+		o.Headers = headers
+	}
+}
+
+func WithTemplate(text string) Option {
+	return func(o *ConversionOptions) {
+		o.Template = text
+	}
+}
+
+func WithFixedWidthColumns(columns ...FixedWidthColumn) Option {
+	return func(o *ConversionOptions) {
+		o.FixedWidthColumns = columns
+	}
+}
+
+// WithPostProcessors names the post-processors (see package postprocess)
+// applied in order to the final output.
+func WithPostProcessors(names ...string) Option {
+	return func(o *ConversionOptions) {
+		o.PostProcess = names
+	}
+}
+
+func WithGeoColumns(latColumn, lonColumn string) Option {
+	return func(o *ConversionOptions) {
+		o.Geo.LatColumn = latColumn
+		o.Geo.LonColumn = lonColumn
+	}
+}
+
+// Indentation returns the indent unit for structured output, and false when
+// the caller did not choose one so converters keep their format's default.
+// The deprecated Indent and PrettyPrint choose DefaultIndentWidth. Widths outside
+// 0..MaxIndentWidth, which CheckIndentWidth rejects, are clamped.
+func (o ConversionOptions) Indentation() (string, bool) {
+	if o.IndentWidth == nil {
+		return strings.Repeat(" ", DefaultIndentWidth), o.Indent || o.PrettyPrint
+	}
+	return strings.Repeat(" ", min(max(*o.IndentWidth, 0), MaxIndentWidth)), true
+}
+
+// CheckIndentWidth fails for an IndentWidth outside 0..MaxIndentWidth.
+func (o ConversionOptions) CheckIndentWidth() error {
+	if o.IndentWidth != nil && (*o.IndentWidth < 0 || *o.IndentWidth > MaxIndentWidth) {
+		return fmt.Errorf("indent width %d is outside 0..%d", *o.IndentWidth, MaxIndentWidth)
+	}
+	return nil
+}
+
+func (o ConversionOptions) Delimiter() rune {
+	if o.CSVDelimiter == 0 {
+		return DefaultCSVDelimiter
+	}
+	return o.CSVDelimiter
+}
+
+func (o ConversionOptions) RootElement() string {
+	if o.XMLRoot == "" {
+		return DefaultXMLRoot
+	}
+	return o.XMLRoot
+}
@@ -3,6 +3,12 @@
 // design patterns implemented in the factory package.
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type Pipeline struct {
 	Steps      []ConversionStep
 	Options    ConversionOptions
@@ -10,14 +16,326 @@ type Pipeline struct {
 	OutputPath string
 }
 
+// ConversionStep converts From to To with a single converter or, when
+// Pipeline is set, by running that sub-pipeline with its own options. A
+// step with a Transform, Patch, Encrypt, Lookup, Extract, Units, Reshape,
+// Sample, Migrate, Search or Quality keeps the format and rewrites the records or
+// the document instead. Converter narrows which registered implementation
+// of the From-To converter runs, and Timeout replaces the pipeline's
+// StepTimeout for this step.
 type ConversionStep struct {
-	From FileFormat
-	To   FileFormat
+	From      FileFormat
+	To        FileFormat
+	Pipeline  *Pipeline             `json:",omitempty"`
+	Transform *Transform            `json:",omitempty"`
+	Patch     *Patch                `json:",omitempty"`
+	Encrypt   *FieldEncryption      `json:",omitempty"`
+	Lookup    *Lookup               `json:",omitempty"`
+	Extract   *Extract              `json:",omitempty"`
+	Units     *UnitConversion       `json:",omitempty"`
+	Reshape   *Reshape              `json:",omitempty"`
+	Sample    *Sampling             `json:",omitempty"`
+	Migrate   *Migration            `json:",omitempty"`
+	Search    *Search               `json:",omitempty"`
+	Quality   *Quality              `json:",omitempty"`
+	Converter *ConverterRequirement `json:",omitempty"`
+	Timeout   *StepTimeout          `json:",omitempty"`
+}
+
+// UsesConverter reports whether the step runs a From-To converter rather
+// than a sub-pipeline or a record stage.
+func (s ConversionStep) UsesConverter() bool {
+	return len(s.Stages()) == 0
+}
+
+// Stages names the fields set on the step that replace its converter: the
+// sub-pipeline and the record stages. A valid step sets at most one.
+func (s ConversionStep) Stages() []string {
+	fields := []struct {
+		name string
+		set  bool
+	}{
+		{"Pipeline", s.Pipeline != nil},
+		{"Transform", s.Transform != nil},
+		{"Patch", s.Patch != nil},
+		{"Encrypt", s.Encrypt != nil},
+		{"Lookup", s.Lookup != nil},
+		{"Extract", s.Extract != nil},
+		{"Units", s.Units != nil},
+		{"Reshape", s.Reshape != nil},
+		{"Sample", s.Sample != nil},
+		{"Migrate", s.Migrate != nil},
+		{"Search", s.Search != nil},
+		{"Quality", s.Quality != nil},
+	}
+	var stages []string
+	for _, field := range fields {
+		if field.set {
+			stages = append(stages, field.name)
+		}
+	}
+	return stages
+}
+
+// ConverterRequirement asks for a converter whose version starts with
+// Version, e.g. "2" or "2.1", and that offers every one of Capabilities.
+// The newest converter meeting it runs; an empty field accepts any.
+type ConverterRequirement struct {
+	Version      string   `json:",omitempty"`
+	Capabilities []string `json:",omitempty"`
+}
+
+// TimeoutPolicy decides what happens to a step that runs past its timeout.
+type TimeoutPolicy string
+
+const (
+	// TimeoutAbort fails the run. It is the default.
+	TimeoutAbort TimeoutPolicy = "abort"
+	// TimeoutSkip passes the step's input on unchanged, so it only suits
+	// steps that keep the format.
+	TimeoutSkip TimeoutPolicy = "skip"
+	// TimeoutRetry runs the step again on the same input, Retries times,
+	// before failing the run.
+	TimeoutRetry TimeoutPolicy = "retry"
+)
+
+// StepTimeout bounds how long a step may run. A step still running After
+// has passed is cancelled through its context and handled by Policy;
+// Retries defaults to 1 for TimeoutRetry. A zero After sets no bound. In
+// JSON, After is a duration string such as "30s".
+type StepTimeout struct {
+	After   time.Duration
+	Policy  TimeoutPolicy `json:",omitempty"`
+	Retries int           `json:",omitempty"`
+}
+
+type stepTimeoutJSON struct {
+	After   string        `json:",omitempty"`
+	Policy  TimeoutPolicy `json:",omitempty"`
+	Retries int           `json:",omitempty"`
+}
+
+func (t StepTimeout) MarshalJSON() ([]byte, error) {
+	encoded := stepTimeoutJSON{Policy: t.Policy, Retries: t.Retries}
+	if t.After != 0 {
+		encoded.After = t.After.String()
+	}
+	return json.Marshal(encoded)
+}
+
+func (t *StepTimeout) UnmarshalJSON(data []byte) error {
+	var decoded stepTimeoutJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*t = StepTimeout{Policy: decoded.Policy, Retries: decoded.Retries}
+	if decoded.After != "" {
+		after, err := time.ParseDuration(decoded.After)
+		if err != nil {
+			return fmt.Errorf("step timeout: %w", err)
+		}
+		t.After = after
+	}
+	return nil
+}
+
+// FieldEncryption encrypts the values of Fields in every record, or
+// decrypts them when Decrypt is set, leaving the other fields readable.
+// Deterministic encryption turns equal values into equal ciphertexts, so
+// the fields can still be matched and grouped on. The key is loaded from
+// KeyEnv, or from KeyFile when unset, like EncryptionOptions.
+type FieldEncryption struct {
+	Fields        []string
+	Deterministic bool `json:",omitempty"`
+	Decrypt       bool `json:",omitempty"`
+	KeyEnv        string
+	KeyFile       string
+}
+
+// Lookup enriches every record with the columns of the row of the table
+// at Path whose Key column equals the record's Field (Key when empty),
+// compared as text. Columns picks the columns to add, all but the key when
+// empty, and Prefix goes in front of their names. Records without a match
+// are kept as they are, or fail the step when Required is set. Format is
+// the table's format when its extension does not tell.
+type Lookup struct {
+	Path     string
+	Format   FileFormat `json:",omitempty"`
+	Key      string
+	Field    string   `json:",omitempty"`
+	Columns  []string `json:",omitempty"`
+	Prefix   string   `json:",omitempty"`
+	Required bool     `json:",omitempty"`
+}
+
+// Extract matches Pattern, a regular expression with named groups such as
+// `(?P<user>\w+)@(?P<domain>.+)`, against Field and sets a field per named
+// group, its name with Prefix in front, to the text the group matched.
+// Records whose field does not match are kept as they are, or fail the
+// step when Required is set. Remove drops Field from matched records.
+type Extract struct {
+	Field    string
+	Pattern  string
+	Prefix   string `json:",omitempty"`
+	Required bool   `json:",omitempty"`
+	Remove   bool   `json:",omitempty"`
+}
+
+// UnitConversion converts the numbers in Fields from one unit to another,
+// e.g. "MB" to "GiB" or "C" to "F". Currency codes are converted through
+// Rates, the amount of each currency worth one unit of a common base.
+// Decimals rounds the results, with Rounding one of "half-up" (the
+// default), "half-even", "down" or "up"; nil leaves them unrounded.
+type UnitConversion struct {
+	Fields   []string
+	From     string
+	To       string
+	Rates    map[string]float64 `json:",omitempty"`
+	Decimals *int               `json:",omitempty"`
+	Rounding string             `json:",omitempty"`
 }
 
+// Sampling passes a sample of the records through: each record with a
+// chance of Percent percent, or every Every-th record starting with the
+// first, when set instead. Seed makes random samples repeatable; without it
+// the pipeline's seed, when set, seeds the step, and otherwise every run
+// draws a different sample.
+type Sampling struct {
+	Percent float64 `json:",omitempty"`
+	Every   int     `json:",omitempty"`
+	Seed    *int64  `json:",omitempty"`
+}
+
+// Migration maps records from one schema version to another. Rename moves
+// fields to new names, all read before any is written. Set then computes
+// fields with expressions, as in Transform, over the renamed record.
+// Defaults fills the new fields that are missing or null, and Drop removes
+// retired ones. With VersionField set, only records at version From (or
+// without the field) are migrated and marked To; records already at To
+// pass unchanged and other versions fail the step. Versions compare as
+// text.
+type Migration struct {
+	VersionField string                 `json:",omitempty"`
+	From         string                 `json:",omitempty"`
+	To           string                 `json:",omitempty"`
+	Rename       map[string]string      `json:",omitempty"`
+	Set          map[string]string      `json:",omitempty"`
+	Defaults     map[string]interface{} `json:",omitempty"`
+	Drop         []string               `json:",omitempty"`
+}
+
+// Search keeps the records with a field value, at any depth, that matches
+// Query with the search tool's Engine: "literal" (the default), "regex" or
+// "fuzzy". Keys are not searched. Invert keeps the records that do not
+// match instead.
+type Search struct {
+	Engine string `json:",omitempty"`
+	Query  string
+	Invert bool `json:",omitempty"`
+}
+
+// Quality checks every record against Rules, in order, with the lab4
+// data-quality rules engine.
+type Quality struct {
+	Rules []QualityRule
+}
+
+// QualityRule checks Field with Check: "required", "pattern" (Pattern
+// matching the whole value), "range" (a number between Min and Max),
+// "one_of" (one of Values) or "unique". A violated rule's Action is "warn"
+// (the default) to report the record and keep it, "drop" to remove it or
+// "fail" to stop the run. Name defaults to the check and the field.
+type QualityRule struct {
+	Name    string `json:",omitempty"`
+	Field   string
+	Check   string
+	Pattern string   `json:",omitempty"`
+	Min     *float64 `json:",omitempty"`
+	Max     *float64 `json:",omitempty"`
+	Values  []string `json:",omitempty"`
+	Action  string   `json:",omitempty"`
+}
+
+// QualityReport counts the records a quality step checked and dropped and
+// lists the violations it found.
+type QualityReport struct {
+	Checked    int
+	Dropped    int
+	Violations []QualityViolation
+}
+
+// QualityViolation is a record, counted from 1, failing a quality rule.
+type QualityViolation struct {
+	Record  int
+	Rule    string
+	Message string
+	Action  string
+}
+
+// ReshapeMode selects the direction of a Reshape.
+type ReshapeMode string
+
+const (
+	// ReshapeUnpivot turns wide records into long ones: a record per
+	// column, holding the ids, the column's name and its value.
+	ReshapeUnpivot ReshapeMode = "unpivot"
+	// ReshapePivot turns long records back into wide ones: a record per
+	// distinct set of ids, with a field per name.
+	ReshapePivot ReshapeMode = "pivot"
+)
+
+// Reshape pivots or unpivots records around their ID fields. Name and
+// Value are the fields of long records holding a column's name and value,
+// "variable" and "value" when empty. Columns picks the columns to
+// unpivot, all but the ids when empty, and DropEmpty skips empty values
+// when unpivoting.
+type Reshape struct {
+	Mode      ReshapeMode
+	ID        []string
+	Columns   []string `json:",omitempty"`
+	Name      string   `json:",omitempty"`
+	Value     string   `json:",omitempty"`
+	DropEmpty bool     `json:",omitempty"`
+}
+
+// Transform filters and reshapes records with expressions. Filter keeps
+// the records for which it is true; Set assigns each field the value of its
+// expression, all evaluated against the record before any assignment.
+type Transform struct {
+	Filter string            `json:",omitempty"`
+	Set    map[string]string `json:",omitempty"`
+}
+
+// Patch overrides parts of the document: Merge is an RFC 7386 merge patch
+// and JSONPatch an RFC 6902 operation list. When both are set the merge
+// patch is applied first.
+type Patch struct {
+	Merge     json.RawMessage `json:",omitempty"`
+	JSONPatch json.RawMessage `json:",omitempty"`
+}
+
+// PipelineResult describes a run. Skipped is set when an incremental run
+// found the input unchanged and did not convert it again.
 type PipelineResult struct {
-	Success  bool
-	Results  []*ConversionResult
+	// RunID identifies the run, e.g. in injected headers and provenance
+	// fields.
+	RunID     string
+	Success   bool
+	Skipped   bool
+	Results   []*ConversionResult
+	Entries   []*EntryResult
+	Snapshots []*Snapshot
+	// Outputs lists the files written for a file input: the output path, or
+	// its numbered parts when the output is split.
+	Outputs  []string
 	Error    error
 	Duration int64
 }
+
+// EntryResult describes one archive entry processed by the pipeline.
+type EntryResult struct {
+	Name    string
+	Output  string
+	Results []*ConversionResult
+	Error   error
+}
@@ -0,0 +1,30 @@
+// Package models defines the core interfaces and data structures for file format
+// conversion operations. It provides the foundation types used by the creational
+// design patterns implemented in the factory package.
+package models
+
+// Snapshot is a memento of the data produced by a pipeline step. Only the
+// executor reads it back, to resume the pipeline after that step.
+type Snapshot struct {
+	step   int
+	format FileFormat
+	data   []byte
+}
+
+// NewSnapshot captures data as the output of the 1-based step. The data is
+// copied so later changes to the caller's slice do not leak in.
+func NewSnapshot(step int, format FileFormat, data []byte) *Snapshot {
+	return &Snapshot{step: step, format: format, data: append([]byte(nil), data...)}
+}
+
+func (s *Snapshot) Step() int {
+	return s.step
+}
+
+func (s *Snapshot) Format() FileFormat {
+	return s.format
+}
+
+func (s *Snapshot) Data() []byte {
+	return append([]byte(nil), s.data...)
+}
@@ -0,0 +1,330 @@
+// Package patch edits documents in place of a converter: RFC 7386 merge
+// patches overlay a partial document, and RFC 6902 JSON Patches apply a list
+// of add, remove, replace, move, copy and test operations.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/values"
+)
+
+// Operation is one entry of an RFC 6902 JSON Patch.
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+
+	hasValue bool
+}
+
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	type plain Operation
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*plain)(o)); err != nil {
+		return err
+	}
+	value, hasValue := raw["value"]
+	o.hasValue = hasValue
+	if hasValue {
+		// Read again so large integers in values stay exact.
+		var err error
+		if o.Value, err = values.UnmarshalJSON(value, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseJSONPatch decodes and checks a JSON Patch document without applying
+// it, so a bad patch fails when the pipeline is built.
+func ParseJSONPatch(data []byte) ([]Operation, error) {
+	var operations []Operation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+	}
+
+	for i, operation := range operations {
+		if err := operation.check(); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return operations, nil
+}
+
+func (o Operation) check() error {
+	switch o.Op {
+	case "add", "replace", "test":
+		if !o.hasValue {
+			return fmt.Errorf("%s needs a value", o.Op)
+		}
+	case "move", "copy":
+		if _, err := parsePointer(o.From); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unknown op %q", o.Op)
+	}
+
+	_, err := parsePointer(o.Path)
+	return err
+}
+
+// ApplyJSONPatch applies the operations in order to a copy of doc. As the
+// RFC requires, the patch is atomic: doc is never modified, and on error
+// no document is returned.
+func ApplyJSONPatch(doc interface{}, operations []Operation) (interface{}, error) {
+	doc = deepCopy(doc)
+	var err error
+	for i, operation := range operations {
+		if doc, err = operation.apply(doc); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func (o Operation) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add":
+		return add(doc, path, deepCopy(o.Value))
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "replace":
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(o.Value))
+	case "move":
+		from, _ := parsePointer(o.From)
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("cannot move %s into its own child", o.From)
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "copy":
+		from, _ := parsePointer(o.From)
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+	default:
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(normalize(value), normalize(o.Value)) {
+			return nil, fmt.Errorf("test failed: value is %v", value)
+		}
+		return doc, nil
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON Pointer %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for i, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", pointerString(path[:i+1]))
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pointerString(path[:i+1]), err)
+			}
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("%s does not exist", pointerString(path[:i+1]))
+		}
+	}
+	return doc, nil
+}
+
+// add sets the value at path, inserting into arrays, and returns the
+// possibly new document root.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[token] = value
+		return doc, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(container), true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pointerString(path), err)
+		}
+		grown := append(container, nil)
+		copy(grown[index+1:], grown[index:])
+		grown[index] = value
+		return replaceChild(doc, path[:len(path)-1], grown)
+	default:
+		return nil, fmt.Errorf("%s is not an object or array", pointerString(path[:len(path)-1]))
+	}
+}
+
+// remove deletes the value at path and returns the new root and the value.
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	token := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		value, ok := container[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s does not exist", pointerString(path))
+		}
+		delete(container, token)
+		return doc, value, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", pointerString(path), err)
+		}
+		value := container[index]
+		shrunk := append(container[:index:index], container[index+1:]...)
+		doc, err = replaceChild(doc, path[:len(path)-1], shrunk)
+		return doc, value, err
+	default:
+		return nil, nil, fmt.Errorf("%s does not exist", pointerString(path))
+	}
+}
+
+// replaceChild stores a resized array back at path, since growing or
+// shrinking a slice may reallocate it.
+func replaceChild(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[token] = value
+	case []interface{}:
+		index, _ := strconv.Atoi(token)
+		container[index] = value
+	}
+	return doc, nil
+}
+
+// arrayIndex resolves an array token; "-" (one past the end) is only valid
+// when adding. RFC 6901 allows only decimal digits without leading zeros,
+// so signs such as "+1" and "-0" are rejected.
+func arrayIndex(token string, length int, adding bool) (int, error) {
+	if token == "-" && adding {
+		return length, nil
+	}
+
+	if token == "" || strings.TrimLeft(token, "0123456789") != "" || (token != "0" && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > length || (!adding && index == length) {
+		return 0, fmt.Errorf("array index %d out of range", index)
+	}
+	return index, nil
+}
+
+func pointerString(path []string) string {
+	var b strings.Builder
+	for _, token := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// deepCopy keeps values inserted from the patch independent of the patch
+// and of other places they are copied to.
+func deepCopy(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for key, child := range typed {
+			out[key] = deepCopy(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, child := range typed {
+			out[i] = deepCopy(child)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// normalize lets test compare values decoded by different parsers, such as
+// an int from YAML against a float64 from the patch.
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	out, err := values.UnmarshalJSON(data, true)
+	if err != nil {
+		return value
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+// Package patch edits documents in place of a converter: RFC 7386 merge
+// patches overlay a partial document, and RFC 6902 JSON Patches apply a list
+// of add, remove, replace, move, copy and test operations.
+package patch
+
+// MergePatch applies an RFC 7386 merge patch to target: objects are merged
+// key by key, null removes a key, and any other value replaces the target.
+// target may be modified; use the returned value.
+func MergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = MergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,100 @@
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, text string) interface{} {
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(text), &value))
+	return value
+}
+
+func TestMergePatch(t *testing.T) {
+	// The example from RFC 7386 section 3.
+	target := decode(t, `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`)
+	patch := decode(t, `{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`)
+
+	assert.Equal(t, decode(t, `{"title":"Hello!","author":{"givenName":"John"},"tags":["example"],"content":"This will be unchanged","phoneNumber":"+01-123-456-7890"}`),
+		MergePatch(target, patch))
+	assert.Equal(t, decode(t, `{"a":{"b":"c"}}`), MergePatch(decode(t, `["x"]`), decode(t, `{"a":{"b":"c"}}`)))
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	operations, err := ParseJSONPatch([]byte(`[
+		{"op": "test", "path": "/env", "value": "dev"},
+		{"op": "replace", "path": "/env", "value": "prod"},
+		{"op": "add", "path": "/hosts/1", "value": "b.example"},
+		{"op": "add", "path": "/hosts/-", "value": "d.example"},
+		{"op": "remove", "path": "/hosts/0"},
+		{"op": "copy", "from": "/hosts/0", "path": "/primary"},
+		{"op": "move", "from": "/a~1b", "path": "/limits/max"},
+		{"op": "add", "path": "/flags", "value": null}
+	]`))
+	require.NoError(t, err)
+
+	doc, err := ApplyJSONPatch(decode(t, `{"env":"dev","hosts":["a.example","c.example"],"a/b":5,"limits":{}}`), operations)
+	require.NoError(t, err)
+	assert.Equal(t, decode(t, `{"env":"prod","hosts":["b.example","c.example","d.example"],"primary":"b.example","limits":{"max":5},"flags":null}`), doc)
+}
+
+func TestJSONPatchKeepsLargeIntegers(t *testing.T) {
+	operations, err := ParseJSONPatch([]byte(`[
+		{"op": "test", "path": "/id", "value": 9007199254740993},
+		{"op": "add", "path": "/next", "value": 9007199254740995}
+	]`))
+	require.NoError(t, err)
+
+	doc, err := ApplyJSONPatch(map[string]interface{}{"id": int64(9007199254740993)}, operations)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": int64(9007199254740993), "next": int64(9007199254740995)}, doc)
+
+	_, err = ApplyJSONPatch(map[string]interface{}{"id": int64(9007199254740992)}, operations[:1])
+	assert.Error(t, err, "the test op tells neighbouring integers apart")
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	_, err := ParseJSONPatch([]byte(`[{"op": "add", "path": "/x"}]`))
+	assert.EqualError(t, err, "operation 0: add needs a value")
+
+	_, err = ParseJSONPatch([]byte(`[{"op": "rename", "path": "/x"}]`))
+	assert.EqualError(t, err, `operation 0: unknown op "rename"`)
+
+	operations, err := ParseJSONPatch([]byte(`[{"op": "test", "path": "/n", "value": 2}]`))
+	require.NoError(t, err)
+	_, err = ApplyJSONPatch(decode(t, `{"n": 1}`), operations)
+	assert.EqualError(t, err, "operation 0 (test /n): test failed: value is 1")
+
+	operations, err = ParseJSONPatch([]byte(`[{"op": "remove", "path": "/items/3"}]`))
+	require.NoError(t, err)
+	_, err = ApplyJSONPatch(decode(t, `{"items": [1]}`), operations)
+	assert.EqualError(t, err, "operation 0 (remove /items/3): /items/3: array index 3 out of range")
+}
+
+func TestJSONPatchRejectsSignedIndexes(t *testing.T) {
+	for _, index := range []string{"+1", "-0", "-1", "01", ""} {
+		operations, err := ParseJSONPatch([]byte(`[{"op": "remove", "path": "/items/` + index + `"}]`))
+		require.NoError(t, err)
+		_, err = ApplyJSONPatch(decode(t, `{"items": [1, 2]}`), operations)
+		assert.EqualError(t, err, `operation 0 (remove /items/`+index+`): /items/`+index+`: invalid array index "`+index+`"`)
+	}
+}
+
+func TestJSONPatchLeavesDocumentUnchangedOnError(t *testing.T) {
+	operations, err := ParseJSONPatch([]byte(`[
+		{"op": "add", "path": "/items/-", "value": 3},
+		{"op": "replace", "path": "/name", "value": "b"},
+		{"op": "test", "path": "/name", "value": "c"}
+	]`))
+	require.NoError(t, err)
+
+	doc := decode(t, `{"name": "a", "items": [1, 2]}`)
+	patched, err := ApplyJSONPatch(doc, operations)
+	assert.Error(t, err)
+	assert.Nil(t, patched)
+	assert.Equal(t, decode(t, `{"name": "a", "items": [1, 2]}`), doc)
+}
@@ -0,0 +1,90 @@
+// Package postprocess reshapes a pipeline's final output to match a house
+// style: minified JSON, sorted keys, no empty fields. Processors are looked
+// up by name so pipelines and service requests can select them in options.
+package postprocess
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const (
+	Minify    = "minify"
+	SortKeys  = "sort-keys"
+	DropEmpty = "drop-empty"
+)
+
+type Processor interface {
+	Supports(format models.FileFormat) bool
+	Process(data []byte, format models.FileFormat, options models.ConversionOptions) ([]byte, error)
+}
+
+var (
+	processors = map[string]Processor{
+		Minify:    MinifyJSON{},
+		SortKeys:  KeySorter{},
+		DropEmpty: EmptyFieldDropper{},
+	}
+	registryMu sync.RWMutex
+)
+
+// Register adds a processor, replacing any registered under the same name.
+func Register(name string, processor Processor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	processors[name] = processor
+}
+
+func Lookup(name string) (Processor, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	processor, ok := processors[name]
+	return processor, ok
+}
+
+// Names lists the registered processors in sorted order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(processors))
+	for name := range processors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that every named processor exists and handles format.
+func Validate(names []string, format models.FileFormat) error {
+	for _, name := range names {
+		processor, ok := Lookup(name)
+		if !ok {
+			return fmt.Errorf("unknown post-processor %q", name)
+		}
+		if !processor.Supports(format) {
+			return fmt.Errorf("post-processor %q does not support %s output", name, format)
+		}
+	}
+	return nil
+}
+
+// Apply runs the named processors over data in order.
+func Apply(data []byte, format models.FileFormat, names []string, options models.ConversionOptions) ([]byte, error) {
+	if err := Validate(names, format); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		processor, _ := Lookup(name)
+		processed, err := processor.Process(data, format, options)
+		if err != nil {
+			return nil, fmt.Errorf("post-processor %q: %w", name, err)
+		}
+		data = processed
+	}
+	return data, nil
+}
@@ -0,0 +1,51 @@
+package postprocess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestMinifyJSON(t *testing.T) {
+	out, err := Apply([]byte("{\n  \"price\": 1.50,\n  \"tags\": [ \"a\" ]\n}\n"), models.FormatJSON, []string{Minify}, models.ConversionOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"price":1.50,"tags":["a"]}`, string(out))
+
+	out, err = Apply([]byte("{ \"a\": 1 }\n\n{ \"b\": 2 }\n"), models.FormatNDJSON, []string{Minify}, models.ConversionOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "{\"a\":1}\n{\"b\":2}\n", string(out))
+}
+
+func TestSortKeysKeepsYAMLComments(t *testing.T) {
+	input := "zeta: 1 # last\nalpha:\n  b: 2\n  a: 1\n"
+	out, err := Apply([]byte(input), models.FormatYAML, []string{SortKeys}, models.ConversionOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "alpha:\n    a: 1\n    b: 2\nzeta: 1 # last\n", string(out))
+}
+
+func TestDropEmpty(t *testing.T) {
+	input := `{"name":"ann","email":"","phone":null,"tags":[],"address":{"city":""},"scores":[1,null]}`
+	options := models.NewOptions(models.WithIndentWidth(0))
+
+	out, err := Apply([]byte(input), models.FormatJSON, []string{DropEmpty}, options)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"ann","scores":[1,null]}`, string(out))
+}
+
+func TestPostProcessorsKeepLargeIntegers(t *testing.T) {
+	input := `{"id":9007199254740993,"empty":"","price":19.990}`
+	options := models.NewOptions(models.WithIndentWidth(0), models.WithFloatNumbers())
+
+	out, err := Apply([]byte(input), models.FormatJSON, []string{SortKeys, DropEmpty}, options)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":9007199254740993,"price":19.990}`, string(out))
+}
+
+func TestValidateRejectsUnknownAndUnsupported(t *testing.T) {
+	assert.EqualError(t, Validate([]string{"shout"}, models.FormatJSON), `unknown post-processor "shout"`)
+	assert.EqualError(t, Validate([]string{Minify}, models.FormatCSV), `post-processor "minify" does not support csv output`)
+	assert.NoError(t, Validate([]string{SortKeys, DropEmpty}, models.FormatYAML))
+}
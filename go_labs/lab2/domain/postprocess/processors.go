@@ -0,0 +1,167 @@
+// Package postprocess reshapes a pipeline's final output to match a house
+// style: minified JSON, sorted keys, no empty fields. Processors are looked
+// up by name so pipelines and service requests can select them in options.
+package postprocess
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// MinifyJSON strips insignificant whitespace from JSON and NDJSON output
+// without reparsing values, so numbers keep their exact spelling.
+type MinifyJSON struct{}
+
+func (MinifyJSON) Supports(format models.FileFormat) bool {
+	return format == models.FormatJSON || format == models.FormatNDJSON
+}
+
+func (MinifyJSON) Process(data []byte, format models.FileFormat, _ models.ConversionOptions) ([]byte, error) {
+	if format == models.FormatJSON {
+		var out bytes.Buffer
+		if err := json.Compact(&out, data); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	}
+
+	var out bytes.Buffer
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := json.Compact(&out, []byte(line)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// KeySorter orders mapping keys at every level, alphabetically or by the
+// pipeline's KeyOrder. YAML is sorted on the node tree so comments, anchors
+// and scalar styles survive.
+type KeySorter struct{}
+
+func (KeySorter) Supports(format models.FileFormat) bool {
+	return format == models.FormatYAML || format == models.FormatJSON
+}
+
+func (KeySorter) Process(data []byte, format models.FileFormat, options models.ConversionOptions) ([]byte, error) {
+	if format == models.FormatJSON {
+		// Rendering writes map keys in sorted order, or by KeyOrder.
+		return roundTrip(data, format, options, func(root interface{}) interface{} { return root })
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if root.Kind == 0 {
+		return data, nil
+	}
+	sortNode(&root, document.KeyLess(options.KeyOrder))
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	if indent, set := options.Indentation(); set {
+		encoder.SetIndent(max(len(indent), 2))
+	}
+	if err := encoder.Encode(&root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func sortNode(node *yaml.Node, less func(a, b string) bool) {
+	for _, child := range node.Content {
+		sortNode(child, less)
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return less(pairs[i][0].Value, pairs[j][0].Value)
+	})
+	for i, pair := range pairs {
+		node.Content[2*i], node.Content[2*i+1] = pair[0], pair[1]
+	}
+}
+
+// EmptyFieldDropper removes null values, empty strings and fields left
+// holding an empty object or array. Array elements are kept so positions
+// stay meaningful.
+type EmptyFieldDropper struct{}
+
+func (EmptyFieldDropper) Supports(format models.FileFormat) bool {
+	return format == models.FormatJSON || format == models.FormatYAML || format == models.FormatNDJSON
+}
+
+func (EmptyFieldDropper) Process(data []byte, format models.FileFormat, options models.ConversionOptions) ([]byte, error) {
+	return roundTrip(data, format, options, dropEmpty)
+}
+
+func dropEmpty(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			child = dropEmpty(child)
+			if empty(child) {
+				delete(typed, key)
+			} else {
+				typed[key] = child
+			}
+		}
+		return typed
+	case []interface{}:
+		for i, child := range typed {
+			typed[i] = dropEmpty(child)
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+func empty(value interface{}) bool {
+	switch typed := value.(type) {
+	case nil:
+		return true
+	case string:
+		return typed == ""
+	case map[string]interface{}:
+		return len(typed) == 0
+	case []interface{}:
+		return len(typed) == 0
+	default:
+		return false
+	}
+}
+
+// roundTrip re-parses rendered output, always losslessly: numbers the
+// pipeline already wrote must come out as written.
+func roundTrip(data []byte, format models.FileFormat, options models.ConversionOptions, rewrite func(interface{}) interface{}) ([]byte, error) {
+	options.LosslessTypes = true
+	doc, err := document.Parse(bytes.NewReader(data), format, options)
+	if err != nil {
+		return nil, err
+	}
+	doc.Root = rewrite(doc.Root)
+	return document.Render(doc, format, options)
+}
@@ -0,0 +1,215 @@
+// Package profiling computes column statistics over tabular data flowing through
+// a conversion pipeline. It decodes any supported format into flat records and
+// summarizes each column so unfamiliar datasets can be understood at a glance.
+package profiling
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+const (
+	TypeNull    = "null"
+	TypeInteger = "integer"
+	TypeFloat   = "float"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+	TypeString  = "string"
+	TypeMixed   = "mixed"
+)
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05", "01/02/2006"}
+
+type ColumnProfile struct {
+	Name         string  `json:"name"`
+	Count        int     `json:"count"`
+	Distinct     int     `json:"distinct"`
+	Nulls        int     `json:"nulls"`
+	NullRatio    float64 `json:"null_ratio"`
+	Min          string  `json:"min,omitempty"`
+	Max          string  `json:"max,omitempty"`
+	InferredType string  `json:"inferred_type"`
+}
+
+type Report struct {
+	Source    string               `json:"source"`
+	Format    models.FileFormat    `json:"format"`
+	Records   int                  `json:"records"`
+	Columns   []ColumnProfile      `json:"columns"`
+	Interning *records.InternStats `json:"interning,omitempty"`
+}
+
+type Profiler struct{}
+
+func NewProfiler() *Profiler {
+	return &Profiler{}
+}
+
+// profiled are the formats records.Decode reads.
+var profiled = map[models.FileFormat]bool{
+	models.FormatCSV:    true,
+	models.FormatJSON:   true,
+	models.FormatYAML:   true,
+	models.FormatXML:    true,
+	models.FormatNDJSON: true,
+	models.FormatXLSX:   true,
+}
+
+// Check fails for formats the profiler cannot read records from.
+func Check(format models.FileFormat) error {
+	if !profiled[format] {
+		return fmt.Errorf("profiling not supported for format: %s", format)
+	}
+	return nil
+}
+
+func (p *Profiler) Profile(data []byte, format models.FileFormat) (*Report, error) {
+	return p.ProfileWithOptions(data, format, models.ConversionOptions{})
+}
+
+// ProfileWithOptions honors format options that affect parsing, such as
+// the CSV delimiter.
+func (p *Profiler) ProfileWithOptions(data []byte, format models.FileFormat, options models.ConversionOptions) (*Report, error) {
+	rows, err := records.DecodeWithOptions(data, format, options)
+	if err != nil {
+		return nil, err
+	}
+
+	interner := records.NewInterner(records.DefaultInternLimit)
+	interner.InternRecords(rows)
+	stats := interner.Stats()
+
+	report := &Report{
+		Format:    format,
+		Records:   len(rows),
+		Columns:   make([]ColumnProfile, 0),
+		Interning: &stats,
+	}
+
+	for _, name := range records.Columns(rows) {
+		report.Columns = append(report.Columns, profileColumn(name, rows))
+	}
+
+	return report, nil
+}
+
+func profileColumn(name string, rows []records.Record) ColumnProfile {
+	column := ColumnProfile{Name: name, Count: len(rows)}
+	distinct := make(map[string]bool)
+	types := make(map[string]bool)
+	var values []string
+
+	for _, record := range rows {
+		value, present := record[name]
+		text := stringify(value)
+		if !present || value == nil || strings.TrimSpace(text) == "" {
+			column.Nulls++
+			continue
+		}
+
+		distinct[text] = true
+		types[inferType(value, text)] = true
+		values = append(values, text)
+	}
+
+	column.Distinct = len(distinct)
+	column.InferredType = mergeTypes(types)
+	column.Min, column.Max = bounds(values, column.InferredType)
+	if column.Count > 0 {
+		column.NullRatio = float64(column.Nulls) / float64(column.Count)
+	}
+	return column
+}
+
+// bounds compares numerically for numeric columns and lexically otherwise.
+func bounds(values []string, columnType string) (string, string) {
+	if len(values) == 0 {
+		return "", ""
+	}
+
+	numeric := columnType == TypeInteger || columnType == TypeFloat
+	minValue, maxValue := values[0], values[0]
+	for _, value := range values[1:] {
+		if less(value, minValue, numeric) {
+			minValue = value
+		}
+		if less(maxValue, value, numeric) {
+			maxValue = value
+		}
+	}
+	return minValue, maxValue
+}
+
+func less(a, b string, numeric bool) bool {
+	if numeric {
+		x, _ := strconv.ParseFloat(strings.TrimSpace(a), 64)
+		y, _ := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		return x < y
+	}
+	return a < b
+}
+
+func inferType(value interface{}, text string) string {
+	switch v := value.(type) {
+	case bool:
+		return TypeBoolean
+	case int, int64, uint64:
+		return TypeInteger
+	case float64:
+		if v == float64(int64(v)) {
+			return TypeInteger
+		}
+		return TypeFloat
+	case time.Time:
+		return TypeDate
+	}
+
+	text = strings.TrimSpace(text)
+	if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return TypeInteger
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return TypeFloat
+	}
+	if strings.EqualFold(text, "true") || strings.EqualFold(text, "false") {
+		return TypeBoolean
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, text); err == nil {
+			return TypeDate
+		}
+	}
+	return TypeString
+}
+
+func mergeTypes(types map[string]bool) string {
+	switch {
+	case len(types) == 0:
+		return TypeNull
+	case len(types) == 1:
+		for t := range types {
+			return t
+		}
+	case len(types) == 2 && types[TypeInteger] && types[TypeFloat]:
+		return TypeFloat
+	}
+	return TypeMixed
+}
+
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,62 @@
+package profiling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestProfileCSV(t *testing.T) {
+	input := "name,age,score\nAlice,30,1.5\nBob,25,\nCarol,41,2\n"
+
+	report, err := NewProfiler().Profile([]byte(input), models.FormatCSV)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Records)
+	assert.Len(t, report.Columns, 3)
+
+	age := report.Columns[0]
+	assert.Equal(t, "age", age.Name)
+	assert.Equal(t, TypeInteger, age.InferredType)
+	assert.Equal(t, "25", age.Min)
+	assert.Equal(t, "41", age.Max)
+
+	score := report.Columns[2]
+	assert.Equal(t, TypeFloat, score.InferredType)
+	assert.Equal(t, 1, score.Nulls)
+	assert.InDelta(t, 1.0/3.0, score.NullRatio, 0.001)
+}
+
+func TestProfileReportsInterningSavings(t *testing.T) {
+	input := "name,country\nAlice,Moldova\nBob,Moldova\nCarol,Moldova\n"
+
+	report, err := NewProfiler().Profile([]byte(input), models.FormatCSV)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, report.Interning.Values)
+	assert.Equal(t, 2, report.Interning.Interned)
+	assert.Equal(t, int64(14), report.Interning.SavedBytes)
+}
+
+func TestProfileNestedXML(t *testing.T) {
+	input := "<doc><root><city>Oslo</city></root><root><city>Bergen</city></root></doc>"
+
+	report, err := NewProfiler().Profile([]byte(input), models.FormatXML)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Records)
+	assert.Equal(t, 2, report.Columns[0].Distinct)
+	assert.Equal(t, "Bergen", report.Columns[0].Min)
+	assert.Equal(t, TypeString, report.Columns[0].InferredType)
+}
+
+func TestProfileWithOptionsUsesDelimiter(t *testing.T) {
+	input := "name;age\nAlice;30\nBob;25\n"
+
+	report, err := NewProfiler().ProfileWithOptions([]byte(input), models.FormatCSV, models.ConversionOptions{CSVDelimiter: ';'})
+
+	assert.NoError(t, err)
+	assert.Len(t, report.Columns, 2)
+	assert.Equal(t, TypeInteger, report.Columns[0].InferredType)
+}
@@ -0,0 +1,143 @@
+// Package provenance tags every input record with where it came from: the
+// source file, the line it starts on and the run that converted it, so
+// records stay traceable after many inputs are merged into one output.
+package provenance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"tmps-go-labs/lab2/domain/document"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+// Fields added to every record. LineField is only added for CSV, NDJSON
+// and JSON array input, whose records have a line to point at.
+const (
+	SourceField = "_source"
+	LineField   = "_line"
+	RunField    = "_run_id"
+)
+
+// Trailing returns order with the provenance fields appended to Last, so
+// they follow the data fields in every output. Fields order already
+// places are left where they are.
+func Trailing(order models.KeyOrder) models.KeyOrder {
+	placed := make(map[string]bool)
+	for _, key := range append(order.First, order.Last...) {
+		placed[key] = true
+	}
+	last := slices.Clone(order.Last)
+	for _, field := range []string{SourceField, LineField, RunField} {
+		if !placed[field] {
+			last = append(last, field)
+		}
+	}
+	order.Last = last
+	return order
+}
+
+// Check reports whether records of format can be annotated, which takes
+// both a parser and a renderer.
+func Check(format models.FileFormat) error {
+	if _, ok := document.ParserFor(format); !ok {
+		return fmt.Errorf("provenance needs a parser for %s input", format)
+	}
+	if _, ok := document.RendererFor(format); !ok {
+		return fmt.Errorf("provenance needs a renderer for %s input", format)
+	}
+	return nil
+}
+
+// Annotate adds the provenance fields to every record of data and renders
+// it back in the same format.
+func Annotate(data []byte, format models.FileFormat, options models.ConversionOptions, source, runID string) ([]byte, error) {
+	doc, err := document.Parse(bytes.NewReader(data), format, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input for provenance: %w", err)
+	}
+
+	rows := records.Find(doc.Root)
+	lines := Lines(data, format)
+	if len(lines) != len(rows) {
+		lines = nil
+	}
+	for i, row := range rows {
+		row[SourceField] = source
+		row[RunField] = runID
+		if lines != nil {
+			row[LineField] = lines[i]
+		}
+	}
+
+	output, err := document.Render(doc, format, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render input with provenance: %w", err)
+	}
+	return output, nil
+}
+
+// Lines returns the 1-based line each record of data starts on, or nil for
+// formats without line-oriented records. CSV records spanning lines inside
+// quotes count from their first line; blank lines hold no record.
+func Lines(data []byte, format models.FileFormat) []int {
+	switch format {
+	case models.FormatCSV:
+		lines := recordLines(data, true)
+		if len(lines) > 0 {
+			lines = lines[1:]
+		}
+		return lines
+	case models.FormatNDJSON:
+		return recordLines(data, false)
+	case models.FormatJSON:
+		return elementLines(data)
+	}
+	return nil
+}
+
+func recordLines(data []byte, quotes bool) []int {
+	var lines []int
+	line, quoted, started := 1, false, false
+	for _, b := range data {
+		switch {
+		case b == '\n' && !quoted:
+			line++
+			started = false
+			continue
+		case b == '\n':
+			line++
+		case b == '"' && quotes:
+			quoted = !quoted
+		}
+		if !started && b != '\r' && b != ' ' && b != '\t' {
+			lines = append(lines, line)
+			started = true
+		}
+	}
+	return lines
+}
+
+// elementLines returns the lines of the elements of a top-level JSON array.
+func elementLines(data []byte) []int {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil
+	}
+	var lines []int
+	for decoder.More() {
+		start := int(decoder.InputOffset())
+		for start < len(data) && bytes.IndexByte([]byte(" \t\r\n,"), data[start]) >= 0 {
+			start++
+		}
+		lines = append(lines, 1+bytes.Count(data[:start], []byte("\n")))
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return nil
+		}
+	}
+	return lines
+}
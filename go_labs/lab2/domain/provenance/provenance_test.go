@@ -0,0 +1,39 @@
+package provenance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestLines(t *testing.T) {
+	assert.Equal(t, []int{2, 4, 6}, Lines([]byte("id,note\n1,a\n\n2,\"two\nlines\"\n3,c\n"), models.FormatCSV))
+	assert.Equal(t, []int{1, 3}, Lines([]byte("{\"id\":1}\n\n{\"id\":2}"), models.FormatNDJSON))
+	assert.Equal(t, []int{2, 3, 3}, Lines([]byte("[\n  {\"id\": 1},\n  {\"id\": 2}, {\"id\": 3}\n]"), models.FormatJSON))
+	assert.Nil(t, Lines([]byte("people: []"), models.FormatYAML))
+}
+
+func TestAnnotate(t *testing.T) {
+	out, err := Annotate([]byte("id\n1\n2\n"), models.FormatCSV, models.ConversionOptions{}, "people.csv", "run1")
+	require.NoError(t, err)
+	assert.Equal(t, "_line,_run_id,_source,id\n2,run1,people.csv,1\n3,run1,people.csv,2\n", string(out))
+
+	out, err = Annotate([]byte("people:\n  - id: 1\n"), models.FormatYAML, models.ConversionOptions{}, "people.yaml", "run1")
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), LineField, "formats without record lines get no line field")
+	assert.Contains(t, string(out), "_source: people.yaml")
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(models.FormatNDJSON))
+	assert.EqualError(t, Check(models.FormatXLSX), "provenance needs a renderer for xlsx input")
+}
+
+func TestTrailing(t *testing.T) {
+	order := Trailing(models.KeyOrder{First: []string{"id"}, Last: []string{"_run_id", "notes"}})
+	assert.Equal(t, []string{"_run_id", "notes", "_source", "_line"}, order.Last)
+	assert.Equal(t, order, Trailing(order))
+}
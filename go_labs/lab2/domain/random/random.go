@@ -0,0 +1,114 @@
+// Package random is the one place the converters draw randomness from:
+// record samples, run IDs and job IDs. By default it is unpredictable;
+// a seed makes a pipeline run repeat the same draws, for debugging and
+// tests. Encryption nonces are not drawn from here and stay random.
+package random
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"sync"
+)
+
+// Source is a stream of random numbers.
+type Source interface {
+	Uint64() uint64
+	Float64() float64
+}
+
+// New returns a Source repeating the same sequence for the same seed. It
+// is not safe for concurrent use.
+func New(seed int64) Source {
+	return rand.New(rand.NewSource(seed))
+}
+
+// Derive returns a seed for one use of seed, named by labels, such as a
+// step number, so the draws of different uses are independent of each
+// other but repeat with seed.
+func Derive(seed int64, labels ...interface{}) int64 {
+	hash := fnv.New64a()
+	fmt.Fprint(hash, seed)
+	for _, label := range labels {
+		fmt.Fprintf(hash, "/%v", label)
+	}
+	return int64(hash.Sum64())
+}
+
+// ID returns n random bytes of source as hex.
+func ID(source Source, n int) string {
+	id := make([]byte, 0, n+7)
+	for len(id) < n {
+		id = binary.LittleEndian.AppendUint64(id, source.Uint64())
+	}
+	return hex.EncodeToString(id[:n])
+}
+
+var (
+	mu      sync.Mutex
+	current Source = cryptoSource{}
+)
+
+// Default returns the source of the draws no seed applies to. It is safe
+// for concurrent use and, unless replaced, reads crypto/rand.
+func Default() Source {
+	mu.Lock()
+	defer mu.Unlock()
+	return current
+}
+
+// SetDefault makes source the default, guarded for concurrent use, until
+// the returned function restores the previous one. Tests use it to make
+// job IDs repeat:
+//
+//	defer random.SetDefault(random.New(1))()
+func SetDefault(source Source) (restore func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	previous := current
+	current = &locked{source: source}
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		current = previous
+	}
+}
+
+// locked serializes the draws of a source that is not safe for
+// concurrent use.
+type locked struct {
+	mu     sync.Mutex
+	source Source
+}
+
+func (l *locked) Uint64() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.source.Uint64()
+}
+
+func (l *locked) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.source.Float64()
+}
+
+// cryptoSource reads crypto/rand. A failed read panics: the draws would
+// otherwise all be zero, and job and run IDs would collide.
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := io.ReadFull(crand.Reader, b[:]); err != nil {
+		panic(fmt.Errorf("random: reading crypto/rand: %w", err))
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (s cryptoSource) Float64() float64 {
+	return float64(s.Uint64()>>11) / (1 << 53)
+}
@@ -0,0 +1,60 @@
+package random
+
+import (
+	crand "crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeededSourcesRepeat(t *testing.T) {
+	a, b := New(42), New(42)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, a.Uint64(), b.Uint64())
+		assert.Equal(t, a.Float64(), b.Float64())
+	}
+	assert.Equal(t, ID(New(7), 8), ID(New(7), 8))
+	assert.Len(t, ID(New(7), 12), 24)
+	assert.NotEqual(t, ID(New(7), 8), ID(New(8), 8))
+}
+
+func TestDerive(t *testing.T) {
+	assert.Equal(t, Derive(42, "sample", 2), Derive(42, "sample", 2))
+	assert.NotEqual(t, Derive(42, "sample", 2), Derive(42, "sample", 3))
+	assert.NotEqual(t, Derive(42, "run"), Derive(43, "run"))
+}
+
+func TestDefault(t *testing.T) {
+	assert.NotEqual(t, ID(Default(), 16), ID(Default(), 16))
+	f := Default().Float64()
+	assert.True(t, f >= 0 && f < 1)
+
+	restore := SetDefault(New(1))
+	first := ID(Default(), 16)
+	restore()
+	restore = SetDefault(New(1))
+	assert.Equal(t, first, ID(Default(), 16))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Default().Uint64()
+		}()
+	}
+	wg.Wait()
+	restore()
+	assert.Equal(t, cryptoSource{}, Default())
+}
+
+func TestCryptoSourcePanicsWhenCryptoRandFails(t *testing.T) {
+	reader := crand.Reader
+	crand.Reader = iotest.ErrReader(errors.New("no entropy"))
+	t.Cleanup(func() { crand.Reader = reader })
+
+	assert.PanicsWithError(t, "random: reading crypto/rand: no entropy", func() { cryptoSource{}.Uint64() })
+}
@@ -0,0 +1,86 @@
+// Package records decodes documents of any supported format into flat records
+// (one map per row). It gives record-oriented features such as profiling and
+// templating a single, format-agnostic view of the data.
+package records
+
+import "strings"
+
+// DefaultInternLimit is the number of distinct values a column may have
+// before the Interner treats it as high-cardinality and stops interning it.
+const DefaultInternLimit = 1024
+
+// InternStats reports how much an Interner saved. SavedBytes counts the
+// bytes of the repeated values that were answered with a shared copy.
+type InternStats struct {
+	Values     int   `json:"values"`
+	Interned   int   `json:"interned"`
+	Distinct   int   `json:"distinct"`
+	SavedBytes int64 `json:"saved_bytes"`
+}
+
+// Interner shares one copy of every repeated value per column (flyweight),
+// so low-cardinality columns such as country or status cost one string per
+// distinct value instead of one per row. Readers such as encoding/csv slice
+// all fields of a row out of one string, which a single kept field would
+// keep alive; Intern therefore returns a copy of every value it does not
+// share, so no result pins the row it came from.
+type Interner struct {
+	limit   int
+	columns map[string]map[string]string
+	skipped map[string]bool
+	stats   InternStats
+}
+
+func NewInterner(limit int) *Interner {
+	return &Interner{
+		limit:   limit,
+		columns: make(map[string]map[string]string),
+		skipped: make(map[string]bool),
+	}
+}
+
+func (i *Interner) Intern(column, value string) string {
+	i.stats.Values++
+	if i.skipped[column] {
+		return strings.Clone(value)
+	}
+
+	table, ok := i.columns[column]
+	if !ok {
+		table = make(map[string]string)
+		i.columns[column] = table
+	}
+
+	if shared, ok := table[value]; ok {
+		i.stats.Interned++
+		i.stats.SavedBytes += int64(len(value))
+		return shared
+	}
+
+	if len(table) >= i.limit {
+		i.skipped[column] = true
+		i.stats.Distinct -= len(table)
+		delete(i.columns, column)
+		return strings.Clone(value)
+	}
+
+	value = strings.Clone(value)
+	table[value] = value
+	i.stats.Distinct++
+	return value
+}
+
+// InternRecords replaces the string values of rows in place.
+func (i *Interner) InternRecords(rows []Record) {
+	for _, row := range rows {
+		for column, value := range row {
+			if text, ok := value.(string); ok {
+				row[column] = i.Intern(column, text)
+			}
+		}
+	}
+}
+
+func (i *Interner) Stats() InternStats {
+	return i.stats
+}
@@ -0,0 +1,36 @@
+package records
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternerSharesRepeatedValues(t *testing.T) {
+	interner := NewInterner(2)
+
+	first := interner.Intern("country", string([]byte("MD")))
+	second := interner.Intern("country", string([]byte("MD")))
+	assert.Equal(t, unsafe.StringData(first), unsafe.StringData(second))
+
+	interner.Intern("id", "1")
+	interner.Intern("id", "2")
+	interner.Intern("id", "3")
+	interner.Intern("id", "3")
+
+	assert.Equal(t, InternStats{Values: 6, Interned: 1, Distinct: 1, SavedBytes: 2}, interner.Stats())
+}
+
+func TestInternerDoesNotPinTheRow(t *testing.T) {
+	interner := NewInterner(1)
+	row := string([]byte("MD,1,2"))
+
+	country := interner.Intern("country", row[:2])
+	assert.NotSame(t, unsafe.StringData(row), unsafe.StringData(country))
+
+	interner.Intern("id", row[3:4])
+	id := interner.Intern("id", row[5:6])
+	assert.Equal(t, "2", id)
+	assert.NotSame(t, unsafe.StringData(row[5:]), unsafe.StringData(id))
+}
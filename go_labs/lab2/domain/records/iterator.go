@@ -0,0 +1,257 @@
+// Package records decodes documents of any supported format into flat records
+// (one map per row). It gives record-oriented features such as profiling and
+// templating a single, format-agnostic view of the data.
+package records
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+// RecordIterator pulls records one at a time. Next returns io.EOF once the
+// input is exhausted, so a stage can stop early without reading the rest.
+type RecordIterator interface {
+	Next() (Record, error)
+}
+
+// NewIterator picks the iterator for format. XLSX needs random access, so
+// its input is buffered in memory first.
+func NewIterator(input io.Reader, format models.FileFormat, options models.ConversionOptions) (RecordIterator, error) {
+	switch format {
+	case models.FormatCSV:
+		return NewCSVIterator(input, options.Delimiter()), nil
+	case models.FormatNDJSON:
+		it := NewNDJSONIterator(input).(*ndjsonIterator)
+		it.lossless = options.Lossless()
+		return it, nil
+	case models.FormatJSON:
+		decoder := json.NewDecoder(input)
+		if options.Lossless() {
+			decoder.UseNumber()
+		}
+		return &jsonArrayIterator{decoder: decoder}, nil
+	case models.FormatXLSX:
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return NewXLSXIterator(bytes.NewReader(data), int64(len(data)))
+	default:
+		return nil, fmt.Errorf("no record iterator for format: %s", format)
+	}
+}
+
+type csvIterator struct {
+	reader   *csv.Reader
+	header   []string
+	interner *Interner
+}
+
+// NewCSVIterator reads records keyed by the header row. Short rows leave
+// the missing columns empty, and repeated values are interned per column.
+func NewCSVIterator(input io.Reader, delimiter rune) RecordIterator {
+	reader := csv.NewReader(input)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return &csvIterator{reader: reader, interner: NewInterner(DefaultInternLimit)}
+}
+
+func (it *csvIterator) Next() (Record, error) {
+	if it.header == nil {
+		header, err := it.reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		// The reader reuses its record slice, so the header is kept as a copy.
+		it.header = slices.Clone(header)
+	}
+
+	row, err := it.reader.Read()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			err = fmt.Errorf("failed to read CSV: %w", err)
+		}
+		return nil, err
+	}
+
+	record := make(Record, len(it.header))
+	for i, name := range it.header {
+		if i < len(row) {
+			record[name] = it.interner.Intern(name, row[i])
+		} else {
+			record[name] = ""
+		}
+	}
+	return record, nil
+}
+
+type ndjsonIterator struct {
+	scanner  *bufio.Scanner
+	line     int
+	lossless bool
+}
+
+// NewNDJSONIterator reads one JSON object per line, skipping blank lines.
+// Numbers are read losslessly, as values.Number does.
+func NewNDJSONIterator(input io.Reader) RecordIterator {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	return &ndjsonIterator{scanner: scanner, lossless: true}
+}
+
+func (it *ndjsonIterator) Next() (Record, error) {
+	for it.scanner.Scan() {
+		it.line++
+		line := bytes.TrimSpace(it.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var record Record
+		if it.lossless {
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber()
+			if err := decoder.Decode(&record); err != nil || record == nil || decoder.More() {
+				return nil, fmt.Errorf("line %d is not a JSON object", it.line)
+			}
+			values.Numbers(map[string]interface{}(record))
+			return record, nil
+		}
+		if err := json.Unmarshal(line, &record); err != nil || record == nil {
+			return nil, fmt.Errorf("line %d is not a JSON object", it.line)
+		}
+		return record, nil
+	}
+
+	if err := it.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+type jsonArrayIterator struct {
+	decoder *json.Decoder
+	started bool
+	index   int
+}
+
+// NewJSONArrayIterator decodes the elements of a top-level JSON array one
+// by one instead of unmarshalling the whole array. Numbers are read
+// losslessly, as values.Number does.
+func NewJSONArrayIterator(input io.Reader) RecordIterator {
+	decoder := json.NewDecoder(input)
+	decoder.UseNumber()
+	return &jsonArrayIterator{decoder: decoder}
+}
+
+func (it *jsonArrayIterator) Next() (Record, error) {
+	if !it.started {
+		token, err := it.decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return nil, fmt.Errorf("expected a JSON array of objects")
+		}
+		it.started = true
+	}
+
+	if !it.decoder.More() {
+		return nil, io.EOF
+	}
+
+	it.index++
+	var record Record
+	if err := it.decoder.Decode(&record); err != nil || record == nil {
+		return nil, fmt.Errorf("array element %d is not a JSON object", it.index)
+	}
+	values.Numbers(map[string]interface{}(record))
+	return record, nil
+}
+
+type sliceIterator struct {
+	records []Record
+}
+
+// FromSlice iterates over records already in memory.
+func FromSlice(records []Record) RecordIterator {
+	return &sliceIterator{records: records}
+}
+
+func (it *sliceIterator) Next() (Record, error) {
+	if len(it.records) == 0 {
+		return nil, io.EOF
+	}
+	record := it.records[0]
+	it.records = it.records[1:]
+	return record, nil
+}
+
+type filterIterator struct {
+	source RecordIterator
+	keep   func(Record) (bool, error)
+}
+
+// Filter lazily yields the records of source for which keep is true.
+func Filter(source RecordIterator, keep func(Record) (bool, error)) RecordIterator {
+	return &filterIterator{source: source, keep: keep}
+}
+
+func (it *filterIterator) Next() (Record, error) {
+	for {
+		record, err := it.source.Next()
+		if err != nil {
+			return nil, err
+		}
+		keep, err := it.keep(record)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			return record, nil
+		}
+	}
+}
+
+type mapIterator struct {
+	source    RecordIterator
+	transform func(Record) (Record, error)
+}
+
+// Map lazily applies transform to every record of source.
+func Map(source RecordIterator, transform func(Record) (Record, error)) RecordIterator {
+	return &mapIterator{source: source, transform: transform}
+}
+
+func (it *mapIterator) Next() (Record, error) {
+	record, err := it.source.Next()
+	if err != nil {
+		return nil, err
+	}
+	return it.transform(record)
+}
+
+// Collect drains the iterator into a slice.
+func Collect(it RecordIterator) ([]Record, error) {
+	var records []Record
+	for {
+		record, err := it.Next()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,120 @@
+package records
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestIteratorsYieldTheSameRecords(t *testing.T) {
+	expected := []Record{{"name": "ann", "city": "oslo"}, {"name": "bob", "city": "rome"}}
+
+	inputs := map[models.FileFormat]string{
+		models.FormatCSV:    "name,city\nann,oslo\nbob,rome\n",
+		models.FormatNDJSON: "{\"name\":\"ann\",\"city\":\"oslo\"}\n\n{\"name\":\"bob\",\"city\":\"rome\"}\n",
+		models.FormatJSON:   `[{"name":"ann","city":"oslo"},{"name":"bob","city":"rome"}]`,
+	}
+	for format, input := range inputs {
+		iterator, err := NewIterator(strings.NewReader(input), format, models.ConversionOptions{})
+		assert.NoError(t, err)
+		records, err := Collect(iterator)
+		assert.NoError(t, err, format)
+		assert.Equal(t, expected, records, format)
+	}
+}
+
+func TestXLSXIterator(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>name</t></si><si><t>age</t></si><si><r><t>an</t></r><r><t>n</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+			<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>28</v></c></row>
+			<row r="3"><c r="B3"><v>35</v></c></row>
+		</sheetData></worksheet>`,
+	} {
+		w, _ := archive.Create(name)
+		w.Write([]byte(content))
+	}
+	assert.NoError(t, archive.Close())
+
+	iterator, err := NewXLSXIterator(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	records, err := Collect(iterator)
+	assert.NoError(t, err)
+	assert.Equal(t, []Record{{"name": "ann", "age": 28.0}, {"name": "", "age": 35.0}}, records)
+}
+
+func xlsxArchive(t *testing.T, parts map[string]string) *bytes.Reader {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, _ := archive.Create(name)
+		w.Write([]byte(content))
+	}
+	assert.NoError(t, archive.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestXLSXIteratorFollowsWorkbookRelationships(t *testing.T) {
+	input := xlsxArchive(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Data" sheetId="7" r:id="rId3"/><sheet name="Other" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+			<Relationship Id="rId3" Target="/xl/worksheets/data.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row><c t="inlineStr"><is><t>wrong</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/data.xml": `<worksheet><sheetData>
+			<row><c t="inlineStr"><is><t>n</t></is></c></row><row><c><v>1</v></c></row></sheetData></worksheet>`,
+	})
+
+	iterator, err := NewXLSXIterator(input, input.Size())
+	assert.NoError(t, err)
+	records, err := Collect(iterator)
+	assert.NoError(t, err)
+	assert.Equal(t, []Record{{"n": 1.0}}, records)
+}
+
+func TestXLSXIteratorRejectsColumnsPastXFD(t *testing.T) {
+	input := xlsxArchive(t, map[string]string{
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row><c r="ZZZZZZZZZZZZ1"><v>1</v></c></row></sheetData></worksheet>`,
+	})
+
+	iterator, err := NewXLSXIterator(input, input.Size())
+	assert.NoError(t, err)
+	_, err = iterator.Next()
+	assert.EqualError(t, err, `XLSX cell "ZZZZZZZZZZZZ1" is past the last column XFD`)
+
+	column, err := columnIndex("XFD1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, maxXLSXColumns-1, column)
+}
+
+func TestLazyComposition(t *testing.T) {
+	pulled := 0
+	source := Map(NewCSVIterator(strings.NewReader("n\n1\n2\n3\n4\n"), ','), func(record Record) (Record, error) {
+		pulled++
+		return record, nil
+	})
+	even := Filter(source, func(record Record) (bool, error) {
+		return record["n"] == "2", nil
+	})
+
+	record, err := even.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, Record{"n": "2"}, record)
+	assert.Equal(t, 2, pulled)
+
+	_, err = NewJSONArrayIterator(strings.NewReader(`{"a":1}`)).Next()
+	assert.Error(t, err)
+
+	_, err = FromSlice(nil).Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
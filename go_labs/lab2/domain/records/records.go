@@ -0,0 +1,152 @@
+// Package records decodes documents of any supported format into flat records
+// (one map per row). It gives record-oriented features such as profiling and
+// templating a single, format-agnostic view of the data.
+package records
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"tmps-go-labs/lab2/domain/csvparse"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/values"
+	"tmps-go-labs/lab2/domain/xmlmap"
+	"tmps-go-labs/lab2/domain/yamlalias"
+)
+
+type Record map[string]interface{}
+
+func Decode(data []byte, format models.FileFormat) ([]Record, error) {
+	return DecodeWithOptions(data, format, models.ConversionOptions{})
+}
+
+// DecodeWithOptions honors format options that affect parsing, such as the
+// CSV delimiter.
+func DecodeWithOptions(data []byte, format models.FileFormat, options models.ConversionOptions) ([]Record, error) {
+	switch format {
+	case models.FormatCSV:
+		return decodeCSV(data, options)
+	case models.FormatJSON:
+		doc, err := values.UnmarshalJSON(data, options.Lossless())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return findRecords(doc), nil
+	case models.FormatYAML:
+		decode := yamlalias.Decode
+		if options.Lossless() {
+			decode = yamlalias.DecodeLossless
+		}
+		doc, err := decode(bytes.NewReader(data), options.YAML)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		return findRecords(doc), nil
+	case models.FormatXML:
+		doc, err := xmlmap.Parse(data, options.XML)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+		return findRecords(doc), nil
+	case models.FormatNDJSON, models.FormatXLSX:
+		iterator, err := NewIterator(bytes.NewReader(data), format, options)
+		if err != nil {
+			return nil, err
+		}
+		return Collect(iterator)
+	default:
+		return nil, fmt.Errorf("profiling not supported for format: %s", format)
+	}
+}
+
+func decodeCSV(data []byte, options models.ConversionOptions) ([]Record, error) {
+	records, err := csvparse.ReadAll(bytes.NewReader(data), options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	headers := records[0]
+	rows := make([]Record, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(Record)
+		for i, value := range record {
+			if i < len(headers) {
+				row[headers[i]] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Find extracts the record set from an already decoded document.
+func Find(doc interface{}) []Record {
+	return findRecords(doc)
+}
+
+// findRecords walks wrapper objects (e.g. <doc><root>...) until it reaches
+// the first list of objects, which is treated as the record set.
+func findRecords(doc interface{}) []Record {
+	switch v := doc.(type) {
+	case []interface{}:
+		rows := make([]Record, 0, len(v))
+		for _, item := range v {
+			if m, ok := asMap(item); ok {
+				rows = append(rows, m)
+			}
+		}
+		return rows
+	case map[string]interface{}, map[interface{}]interface{}:
+		m, _ := asMap(v)
+		if len(m) == 1 {
+			for _, child := range m {
+				if _, isMap := asMap(child); isMap || isList(child) {
+					return findRecords(child)
+				}
+			}
+		}
+		return []Record{m}
+	default:
+		return nil
+	}
+}
+
+func asMap(v interface{}) (Record, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return Record(m), true
+	case map[interface{}]interface{}:
+		out := make(Record, len(m))
+		for k, val := range m {
+			out[fmt.Sprint(k)] = val
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+func isList(v interface{}) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
+
+func Columns(records []Record) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, record := range records {
+		for name := range record {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,54 @@
+// Package records decodes documents of any supported format into flat records
+// (one map per row). It gives record-oriented features such as profiling and
+// templating a single, format-agnostic view of the data.
+package records
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Sample cuts data down to its first n records: the header and n rows of
+// CSV, n lines of NDJSON, or n elements of a JSON array. Other inputs are
+// returned whole.
+func Sample(data []byte, format models.FileFormat, n int) []byte {
+	switch format {
+	case models.FormatCSV:
+		return firstLines(data, n+1)
+	case models.FormatNDJSON:
+		return firstLines(data, n)
+	case models.FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+			return data
+		}
+		var elements []json.RawMessage
+		for len(elements) < n && decoder.More() {
+			var element json.RawMessage
+			if err := decoder.Decode(&element); err != nil {
+				return data
+			}
+			elements = append(elements, element)
+		}
+		sampled, err := json.Marshal(elements)
+		if err != nil {
+			return data
+		}
+		return sampled
+	}
+	return data
+}
+
+func firstLines(data []byte, n int) []byte {
+	end := 0
+	for i := 0; i < n && end < len(data); i++ {
+		next := bytes.IndexByte(data[end:], '\n')
+		if next < 0 {
+			return data
+		}
+		end += next + 1
+	}
+	return data[:end]
+}
@@ -0,0 +1,260 @@
+// Package records decodes documents of any supported format into flat records
+// (one map per row). It gives record-oriented features such as profiling and
+// templating a single, format-agnostic view of the data.
+package records
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXColumns is the number of columns a worksheet can have, A to XFD.
+const maxXLSXColumns = 16384
+
+// xlsxIterator streams the rows of the first worksheet of an Excel
+// workbook. The first row is the header; numeric cells become float64.
+type xlsxIterator struct {
+	decoder *xml.Decoder
+	closer  io.Closer
+	strings []string
+	header  []string
+}
+
+func NewXLSXIterator(input io.ReaderAt, size int64) (RecordIterator, error) {
+	archive, err := zip.NewReader(input, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+
+	shared, err := readSharedStrings(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := firstSheetPath(archive)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := archive.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("XLSX has no first worksheet: %w", err)
+	}
+	return &xlsxIterator{decoder: xml.NewDecoder(sheet), closer: sheet, strings: shared}, nil
+}
+
+// firstSheetPath resolves the first worksheet listed in xl/workbook.xml
+// through the workbook relationships. Archives without a workbook part
+// fall back to the conventional xl/worksheets/sheet1.xml.
+func firstSheetPath(archive *zip.Reader) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if found, err := decodePart(archive, "xl/workbook.xml", &workbook); err != nil || !found {
+		return fallback, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("XLSX workbook lists no worksheets")
+	}
+
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if found, err := decodePart(archive, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	} else if !found {
+		return fallback, nil
+	}
+
+	for _, rel := range rels.Items {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("XLSX workbook has no relationship %q for its first worksheet", workbook.Sheets[0].ID)
+}
+
+// decodePart decodes the named archive part into v, reporting whether the
+// part exists.
+func decodePart(archive *zip.Reader, name string, v interface{}) (bool, error) {
+	file, err := archive.Open(name)
+	if err != nil {
+		return false, nil
+	}
+	defer file.Close()
+
+	if err := xml.NewDecoder(file).Decode(v); err != nil {
+		return true, fmt.Errorf("failed to read XLSX %s: %w", name, err)
+	}
+	return true, nil
+}
+
+func readSharedStrings(archive *zip.Reader) ([]string, error) {
+	file, err := archive.Open("xl/sharedStrings.xml")
+	if err != nil {
+		// Workbooks without text cells have no shared string table.
+		return nil, nil
+	}
+	defer file.Close()
+
+	var table struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xml.NewDecoder(file).Decode(&table); err != nil {
+		return nil, fmt.Errorf("failed to read XLSX shared strings: %w", err)
+	}
+
+	shared := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		shared[i] = item.Text
+		for _, run := range item.Runs {
+			shared[i] += run.Text
+		}
+	}
+	return shared, nil
+}
+
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+func (it *xlsxIterator) Next() (Record, error) {
+	for {
+		cells, err := it.nextRow()
+		if err != nil {
+			return nil, err
+		}
+
+		if it.header == nil {
+			for _, cell := range cells {
+				it.header = append(it.header, fmt.Sprint(cell))
+			}
+			continue
+		}
+
+		record := make(Record, len(it.header))
+		for i, name := range it.header {
+			if i < len(cells) && cells[i] != nil {
+				record[name] = cells[i]
+			} else {
+				record[name] = ""
+			}
+		}
+		return record, nil
+	}
+}
+
+// nextRow decodes the next <row> element into cell values indexed by
+// column, leaving nil for columns the row skips.
+func (it *xlsxIterator) nextRow() ([]interface{}, error) {
+	for {
+		token, err := it.decoder.Token()
+		if err != nil {
+			it.closer.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read XLSX worksheet: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row struct {
+			Cells []xlsxCell `xml:"c"`
+		}
+		if err := it.decoder.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("failed to read XLSX row: %w", err)
+		}
+
+		var cells []interface{}
+		for i, cell := range row.Cells {
+			column, err := columnIndex(cell.Ref, i)
+			if err != nil {
+				return nil, err
+			}
+			for len(cells) <= column {
+				cells = append(cells, nil)
+			}
+			cells[column] = it.cellValue(cell)
+		}
+		return cells, nil
+	}
+}
+
+func (it *xlsxIterator) cellValue(cell xlsxCell) interface{} {
+	switch cell.Type {
+	case "s":
+		index, err := strconv.Atoi(cell.Value)
+		if err != nil || index < 0 || index >= len(it.strings) {
+			return cell.Value
+		}
+		return it.strings[index]
+	case "inlineStr":
+		return cell.Inline
+	case "b":
+		return cell.Value == "1"
+	case "str", "e":
+		return cell.Value
+	default:
+		if number, err := strconv.ParseFloat(cell.Value, 64); err == nil {
+			return number
+		}
+		return cell.Value
+	}
+}
+
+// columnIndex turns the letters of a cell reference such as "C7" into a
+// 0-based column, falling back to the cell's position when absent. Columns
+// past XFD are rejected rather than allocated.
+func columnIndex(ref string, fallback int) (int, error) {
+	letters := strings.TrimRightFunc(ref, func(r rune) bool { return r >= '0' && r <= '9' })
+	if letters == "" {
+		return checkColumn(ref, fallback)
+	}
+
+	index := 0
+	for _, r := range strings.ToUpper(letters) {
+		if r < 'A' || r > 'Z' {
+			return checkColumn(ref, fallback)
+		}
+		index = index*26 + int(r-'A'+1)
+		if index > maxXLSXColumns {
+			return 0, fmt.Errorf("XLSX cell %q is past the last column XFD", ref)
+		}
+	}
+	return index - 1, nil
+}
+
+func checkColumn(ref string, column int) (int, error) {
+	if column >= maxXLSXColumns {
+		return 0, fmt.Errorf("XLSX cell %q is past the last column XFD", ref)
+	}
+	return column, nil
+}
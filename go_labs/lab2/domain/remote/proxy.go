@@ -0,0 +1,146 @@
+// Package remote provides a proxy converter that hands conversions to a
+// remote conversion service over HTTP. Registered under a normal converter
+// key, it lets heavy conversions be offloaded without changing callers.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/service"
+)
+
+const (
+	DefaultTimeout          = 60 * time.Second
+	DefaultMaxResponseBytes = 64 << 20
+)
+
+// Remote describes a conversion served by a convertd instance at URL.
+// Responses larger than MaxResponseBytes, DefaultMaxResponseBytes when
+// zero, fail the conversion.
+type Remote struct {
+	URL              string
+	APIKey           string
+	From             models.FileFormat
+	To               models.FileFormat
+	Client           *http.Client
+	MaxResponseBytes int64
+}
+
+// Proxy stands in for a local converter. Nothing is contacted until Convert
+// is called, so creating and pooling proxies is free.
+type Proxy struct {
+	remote  Remote
+	options models.ConversionOptions
+}
+
+func NewProxy(remote Remote) *Proxy {
+	if remote.Client == nil {
+		remote.Client = &http.Client{Timeout: DefaultTimeout}
+	}
+	if remote.MaxResponseBytes <= 0 {
+		remote.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	return &Proxy{remote: remote}
+}
+
+// Register makes the remote conversion available as the From-To converter,
+// replacing any local converter for that pair.
+func Register(remote Remote) {
+	factory.RegisterConverter(string(remote.From)+"-"+string(remote.To), func() models.Converter {
+		return NewProxy(remote)
+	})
+}
+
+func (p *Proxy) Configure(options models.ConversionOptions) {
+	p.options = options
+}
+
+func (p *Proxy) Reset() {
+	p.options = models.ConversionOptions{}
+}
+
+func (p *Proxy) Convert(input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	return p.ConvertContext(context.Background(), input, from, to)
+}
+
+// ConvertContext sends the conversion under ctx, so cancelling the step
+// aborts the request and the service joins the step's trace.
+func (p *Proxy) ConvertContext(ctx context.Context, input io.Reader, from, to models.FileFormat) *models.ConversionResult {
+	if from != p.remote.From || to != p.remote.To {
+		return &models.ConversionResult{Error: fmt.Errorf("unsupported conversion: %s to %s", from, to)}
+	}
+
+	data, err := p.post(ctx, input)
+	if err != nil {
+		return &models.ConversionResult{Error: fmt.Errorf("remote %s-%s conversion: %w", from, to, err)}
+	}
+	return &models.ConversionResult{Data: data, Format: to}
+}
+
+func (p *Proxy) post(ctx context.Context, input io.Reader) ([]byte, error) {
+	options, err := json.Marshal(p.options)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{"from": {string(p.remote.From)}, "to": {string(p.remote.To)}}
+	endpoint := strings.TrimSuffix(p.remote.URL, "/") + "/convert?" + query.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, input)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set(service.APIKeyHeader, p.remote.APIKey)
+	request.Header.Set(service.OptionsHeader, string(options))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+	response, err := p.remote.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, p.remote.MaxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > p.remote.MaxResponseBytes {
+		return nil, fmt.Errorf("response exceeds %d bytes", p.remote.MaxResponseBytes)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, remoteError(response.Status, body)
+	}
+	return body, nil
+}
+
+// remoteError prefers the service's JSON error message over the raw body.
+func remoteError(status string, body []byte) error {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		return fmt.Errorf("%s: %s", status, payload.Error)
+	}
+	if message := strings.TrimSpace(string(bytes.TrimSpace(body))); message != "" {
+		return fmt.Errorf("%s: %s", status, message)
+	}
+	return errors.New(status)
+}
+
+func (p *Proxy) SupportsFormat(format models.FileFormat) bool {
+	return format == p.remote.From || format == p.remote.To
+}
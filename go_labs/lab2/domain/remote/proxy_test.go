@@ -0,0 +1,79 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/service"
+)
+
+func TestProxyConvertsRemotely(t *testing.T) {
+	pool := factory.NewConverterPool(1, factory.NewConverterFactory())
+	server := httptest.NewServer(service.NewServer(factory.NewPipelineExecutor(pool), []service.Tenant{
+		{Name: "offload", APIKey: "secret"},
+	}))
+	defer server.Close()
+
+	proxy := NewProxy(Remote{URL: server.URL, APIKey: "secret", From: models.FormatCSV, To: models.FormatJSON})
+	proxy.Configure(models.NewOptions(models.WithIndentWidth(0)))
+
+	result := proxy.Convert(strings.NewReader("name\nann\n"), models.FormatCSV, models.FormatJSON)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, `[{"name":"ann"}]`, strings.TrimSpace(string(result.Data)))
+
+	denied := NewProxy(Remote{URL: server.URL, APIKey: "wrong", From: models.FormatCSV, To: models.FormatJSON})
+	result = denied.Convert(strings.NewReader("name\nann\n"), models.FormatCSV, models.FormatJSON)
+	assert.ErrorContains(t, result.Error, "401 Unauthorized: missing or unknown API key")
+}
+
+func TestProxyUsesTheStepContext(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	proxy := NewProxy(Remote{URL: server.URL, From: models.FormatCSV, To: models.FormatJSON})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan *models.ConversionResult, 1)
+	go func() {
+		done <- proxy.ConvertContext(ctx, strings.NewReader("name\nann\n"), models.FormatCSV, models.FormatJSON)
+	}()
+	select {
+	case result := <-done:
+		assert.ErrorIs(t, result.Error, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling the context did not abort the request")
+	}
+}
+
+func TestProxyBoundsTheResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 32)))
+	}))
+	defer server.Close()
+
+	proxy := NewProxy(Remote{URL: server.URL, From: models.FormatCSV, To: models.FormatJSON, MaxResponseBytes: 16})
+	result := proxy.Convert(strings.NewReader("name\nann\n"), models.FormatCSV, models.FormatJSON)
+	assert.EqualError(t, result.Error, "remote csv-json conversion: response exceeds 16 bytes")
+
+	proxy = NewProxy(Remote{URL: server.URL, From: models.FormatCSV, To: models.FormatJSON, MaxResponseBytes: 32})
+	result = proxy.Convert(strings.NewReader("name\nann\n"), models.FormatCSV, models.FormatJSON)
+	assert.NoError(t, result.Error)
+}
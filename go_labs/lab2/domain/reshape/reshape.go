@@ -0,0 +1,104 @@
+// Package reshape turns tables between wide and long form: Unpivot melts a
+// column per question into one record per answer, and Pivot gathers those
+// records back into one row per identity with a column per name.
+package reshape
+
+import (
+	"fmt"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/records"
+)
+
+// Default names of the fields holding the unpivoted column name and value.
+const (
+	DefaultNameField  = "variable"
+	DefaultValueField = "value"
+)
+
+// Unpivot returns a record per row and column of columns, holding the id
+// fields of its row, the column's name in nameField and its value in
+// valueField. Without columns, every field but the ids is unpivoted, in
+// name order. dropEmpty skips missing, null and empty values.
+func Unpivot(rows []records.Record, ids, columns []string, nameField, valueField string, dropEmpty bool) []records.Record {
+	if len(columns) == 0 {
+		for _, column := range records.Columns(rows) {
+			if !isID(column, ids) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	long := make([]records.Record, 0, len(rows)*len(columns))
+	for _, row := range rows {
+		for _, column := range columns {
+			value := row[column]
+			if dropEmpty && (value == nil || value == "") {
+				continue
+			}
+			record := make(records.Record, len(ids)+2)
+			for _, id := range ids {
+				record[id] = row[id]
+			}
+			record[nameField] = column
+			record[valueField] = value
+			long = append(long, record)
+		}
+	}
+	return long
+}
+
+// Pivot groups rows by their id fields, in the order each group first
+// appears, and returns a record per group holding the ids and a field per
+// distinct nameField value set to the valueField of its row. Other fields
+// are dropped. Two rows of a group with the same name are an error, since
+// only one of their values could be kept.
+func Pivot(rows []records.Record, ids []string, nameField, valueField string) ([]records.Record, error) {
+	var wide []records.Record
+	groups := make(map[string]records.Record)
+	for i, row := range rows {
+		name, ok := row[nameField]
+		if !ok || name == nil || name == "" {
+			return nil, fmt.Errorf("record %d has no %s", i+1, nameField)
+		}
+		column := fmt.Sprint(name)
+		if isID(column, ids) {
+			return nil, fmt.Errorf("record %d: %s %q is also an id field", i+1, nameField, column)
+		}
+
+		key := groupKey(row, ids)
+		group, ok := groups[key]
+		if !ok {
+			group = make(records.Record, len(ids))
+			for _, id := range ids {
+				group[id] = row[id]
+			}
+			groups[key] = group
+			wide = append(wide, group)
+		}
+		if _, duplicate := group[column]; duplicate {
+			return nil, fmt.Errorf("record %d: %s %q appears twice for the same %s", i+1, nameField, column, strings.Join(ids, ", "))
+		}
+		group[column] = row[valueField]
+	}
+	return wide, nil
+}
+
+func isID(field string, ids []string) bool {
+	for _, id := range ids {
+		if id == field {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKey joins the id values of row with a separator that does not occur
+// in text, so different id tuples never share a key.
+func groupKey(row records.Record, ids []string) string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = fmt.Sprint(row[id])
+	}
+	return strings.Join(values, "\x00")
+}
@@ -0,0 +1,45 @@
+package reshape
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/records"
+)
+
+func TestUnpivotAndPivotRoundTrip(t *testing.T) {
+	wide := []records.Record{
+		{"respondent": "1", "q1": "yes", "q2": "no"},
+		{"respondent": "2", "q1": "no", "q2": ""},
+	}
+
+	long := Unpivot(wide, []string{"respondent"}, nil, "question", "answer", false)
+	assert.Equal(t, []records.Record{
+		{"respondent": "1", "question": "q1", "answer": "yes"},
+		{"respondent": "1", "question": "q2", "answer": "no"},
+		{"respondent": "2", "question": "q1", "answer": "no"},
+		{"respondent": "2", "question": "q2", "answer": ""},
+	}, long)
+
+	back, err := Pivot(long, []string{"respondent"}, "question", "answer")
+	require.NoError(t, err)
+	assert.Equal(t, wide, back)
+
+	assert.Len(t, Unpivot(wide, []string{"respondent"}, []string{"q2"}, "question", "answer", true), 1, "empty answers are dropped")
+}
+
+func TestPivotRejectsAmbiguousRecords(t *testing.T) {
+	_, err := Pivot([]records.Record{
+		{"id": "1", "variable": "q1", "value": "yes"},
+		{"id": "1", "variable": "q1", "value": "no"},
+	}, []string{"id"}, "variable", "value")
+	assert.EqualError(t, err, `record 2: variable "q1" appears twice for the same id`)
+
+	_, err = Pivot([]records.Record{{"id": "1", "value": "yes"}}, []string{"id"}, "variable", "value")
+	assert.EqualError(t, err, "record 1 has no variable")
+
+	_, err = Pivot([]records.Record{{"id": "1", "variable": "id", "value": "yes"}}, []string{"id"}, "variable", "value")
+	assert.EqualError(t, err, `record 1: variable "id" is also an id field`)
+}
@@ -0,0 +1,259 @@
+// Package runstate tracks the status of a single pipeline run as an explicit
+// state machine, Pending → Running → Step N → Succeeded, Failed or Cancelled,
+// recording every transition so status APIs can report a run's history.
+package runstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+type State string
+
+const (
+	StatePending   State = "pending"
+	StateRunning   State = "running"
+	StateStep      State = "step"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+)
+
+var ErrInvalidTransition = errors.New("invalid run state transition")
+
+// Transition records one state change. Step is the 1-based step number for
+// StateStep and zero otherwise.
+type Transition struct {
+	From State     `json:"from"`
+	To   State     `json:"to"`
+	Step int       `json:"step,omitempty"`
+	At   time.Time `json:"at"`
+}
+
+type Snapshot struct {
+	State       State        `json:"state"`
+	Step        int          `json:"step,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Progress    *Progress    `json:"progress,omitempty"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// Progress measures a run in finished steps. BytesProcessed is the output
+// the finished steps produced, and BytesRead the input the steps have read
+// so far, which moves while a long step runs. ETA extrapolates the average
+// step duration to the remaining steps, so it is only set once a step has
+// finished.
+type Progress struct {
+	Steps          int     `json:"steps"`
+	StepsDone      int     `json:"steps_done"`
+	Percent        float64 `json:"percent"`
+	BytesProcessed int64   `json:"bytes_processed"`
+	BytesRead      int64   `json:"bytes_read"`
+	ETA            string  `json:"eta,omitempty"`
+}
+
+type Machine struct {
+	mu          sync.Mutex
+	state       State
+	step        int
+	err         string
+	transitions []Transition
+	now         func() time.Time
+	started     time.Time
+	steps       int
+	stepsDone   int
+	bytes       int64
+	read        map[int]int64
+}
+
+func New() *Machine {
+	return &Machine{state: StatePending, now: time.Now}
+}
+
+func (m *Machine) State() (State, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state, m.step
+}
+
+func (m *Machine) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return Snapshot{
+		State:       m.state,
+		Step:        m.step,
+		Error:       m.err,
+		Progress:    m.progress(),
+		Transitions: append([]Transition(nil), m.transitions...),
+	}
+}
+
+// Plan sets the number of steps the run has, which enables progress
+// reporting.
+func (m *Machine) Plan(steps int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.steps = steps
+}
+
+// CompleteStep records a finished step and the bytes it produced. Steps may
+// finish out of order under the concurrent strategies.
+func (m *Machine) CompleteStep(outputBytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stepsDone++
+	m.bytes += int64(outputBytes)
+}
+
+// ReadInput records that the step at index has read bytes of its input so
+// far.
+func (m *Machine) ReadInput(index int, bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.read == nil {
+		m.read = make(map[int]int64)
+	}
+	m.read[index] = bytes
+}
+
+func (m *Machine) progress() *Progress {
+	if m.steps == 0 {
+		return nil
+	}
+
+	progress := &Progress{
+		Steps:          m.steps,
+		StepsDone:      m.stepsDone,
+		Percent:        float64(m.stepsDone) * 100 / float64(m.steps),
+		BytesProcessed: m.bytes,
+	}
+	for _, bytes := range m.read {
+		progress.BytesRead += bytes
+	}
+	if m.state == StateSucceeded {
+		progress.Percent = 100
+	}
+	if !terminal(m.state) && !m.started.IsZero() && m.stepsDone > 0 {
+		perStep := m.now().Sub(m.started) / time.Duration(m.stepsDone)
+		progress.ETA = (perStep * time.Duration(m.steps-m.stepsDone)).Round(time.Millisecond).String()
+	}
+	return progress
+}
+
+func (m *Machine) Start() error {
+	return m.transition(StateRunning, 0, "")
+}
+
+// EnterStep moves the run to step n; steps only move forward.
+func (m *Machine) EnterStep(n int) error {
+	return m.transition(StateStep, n, "")
+}
+
+func (m *Machine) Succeed() error {
+	return m.transition(StateSucceeded, 0, "")
+}
+
+func (m *Machine) Fail(err error) error {
+	return m.transition(StateFailed, 0, err.Error())
+}
+
+func (m *Machine) Cancel() error {
+	return m.transition(StateCancelled, 0, "")
+}
+
+// Finish moves the run to its terminal state for a pipeline result:
+// Cancelled when the run was cancelled, Failed on any other error.
+func (m *Machine) Finish(result *models.PipelineResult) error {
+	switch {
+	case result.Success:
+		return m.Succeed()
+	case errors.Is(result.Error, context.Canceled):
+		return m.Cancel()
+	default:
+		return m.Fail(result.Error)
+	}
+}
+
+func (m *Machine) transition(to State, step int, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !allowed(m.state, m.step, to, step) {
+		if to == StateStep {
+			return fmt.Errorf("%w: %s to step %d", ErrInvalidTransition, m.describe(), step)
+		}
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, m.describe(), to)
+	}
+
+	m.transitions = append(m.transitions, Transition{From: m.state, To: to, Step: step, At: m.now().UTC()})
+	m.state = to
+	if to == StateRunning {
+		m.started = m.now()
+	}
+	if to == StateStep {
+		m.step = step
+	}
+	m.err = message
+	return nil
+}
+
+func (m *Machine) describe() string {
+	if m.state == StateStep {
+		return fmt.Sprintf("step %d", m.step)
+	}
+	return string(m.state)
+}
+
+func allowed(from State, fromStep int, to State, toStep int) bool {
+	switch from {
+	case StatePending:
+		return to == StateRunning || to == StateCancelled
+	case StateRunning:
+		return (to == StateStep && toStep > 0) || terminal(to)
+	case StateStep:
+		return (to == StateStep && toStep > fromStep) || terminal(to)
+	default:
+		return false
+	}
+}
+
+func terminal(state State) bool {
+	return state == StateSucceeded || state == StateFailed || state == StateCancelled
+}
+
+// Follow drives the machine from the executor's lifecycle events for one
+// pipeline run. It returns a function that stops following.
+func (m *Machine) Follow(bus *events.Bus, pipeline *models.Pipeline) func() {
+	return bus.Subscribe(events.ObserverFunc(func(event events.Event) {
+		switch event := event.(type) {
+		case events.PipelineStarted:
+			if event.Pipeline == pipeline {
+				m.Plan(len(pipeline.Steps))
+				m.Start()
+			}
+		case events.StepStarted:
+			if event.Pipeline == pipeline {
+				m.EnterStep(event.Index + 1)
+			}
+		case events.StepProgress:
+			if event.Pipeline == pipeline {
+				m.ReadInput(event.Index, event.BytesRead)
+			}
+		case events.StepCompleted:
+			if event.Pipeline == pipeline {
+				m.CompleteStep(event.OutputSize)
+			}
+		case events.PipelineFinished:
+			if event.Pipeline == pipeline {
+				m.Finish(event.Result)
+			}
+		}
+	}))
+}
@@ -0,0 +1,71 @@
+package runstate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/events"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestMachineRecordsTransitions(t *testing.T) {
+	machine := New()
+	assert.NoError(t, machine.Start())
+	assert.NoError(t, machine.EnterStep(1))
+	assert.NoError(t, machine.EnterStep(2))
+	assert.ErrorIs(t, machine.EnterStep(2), ErrInvalidTransition)
+	assert.NoError(t, machine.Fail(errors.New("bad xml")))
+	assert.ErrorIs(t, machine.Succeed(), ErrInvalidTransition)
+
+	snapshot := machine.Snapshot()
+	assert.Equal(t, StateFailed, snapshot.State)
+	assert.Equal(t, 2, snapshot.Step)
+	assert.Equal(t, "bad xml", snapshot.Error)
+	assert.Len(t, snapshot.Transitions, 4)
+	assert.Equal(t, Transition{From: StateRunning, To: StateStep, Step: 1, At: snapshot.Transitions[1].At}, snapshot.Transitions[1])
+}
+
+func TestMachineFollowsPipelineEvents(t *testing.T) {
+	bus := events.NewBus()
+	pipeline := &models.Pipeline{}
+	other := &models.Pipeline{}
+
+	machine := New()
+	defer machine.Follow(bus, pipeline)()
+
+	bus.Publish(events.PipelineStarted{Pipeline: pipeline})
+	bus.Publish(events.StepStarted{Pipeline: pipeline, Index: 0})
+	bus.Publish(events.StepStarted{Pipeline: other, Index: 4})
+	state, step := machine.State()
+	assert.Equal(t, StateStep, state)
+	assert.Equal(t, 1, step)
+
+	bus.Publish(events.PipelineFinished{Pipeline: pipeline, Result: &models.PipelineResult{Error: context.Canceled}})
+	state, _ = machine.State()
+	assert.Equal(t, StateCancelled, state)
+}
+
+func TestMachineReportsProgress(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	machine := New()
+	machine.now = func() time.Time { return now }
+	assert.Nil(t, machine.Snapshot().Progress)
+
+	machine.Plan(4)
+	assert.NoError(t, machine.Start())
+	assert.NoError(t, machine.EnterStep(1))
+	now = now.Add(2 * time.Second)
+	machine.CompleteStep(100)
+
+	progress := machine.Snapshot().Progress
+	assert.Equal(t, &Progress{Steps: 4, StepsDone: 1, Percent: 25, BytesProcessed: 100, ETA: "6s"}, progress)
+
+	assert.NoError(t, machine.Succeed())
+	progress = machine.Snapshot().Progress
+	assert.Equal(t, float64(100), progress.Percent)
+	assert.Empty(t, progress.ETA)
+}
@@ -0,0 +1,106 @@
+// Package sanitize cleans raw input before the first converter sees it. Each
+// cleanup is a Handler in a chain of responsibility, so steps can be
+// reordered, configured or skipped without the others knowing.
+package sanitize
+
+import (
+	"fmt"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const (
+	HandlerEncoding   = "encoding"
+	HandlerBOM        = "bom"
+	HandlerControl    = "control"
+	HandlerWhitespace = "whitespace"
+)
+
+// DefaultOrder is the order handlers run in when the chain is enabled.
+// Encoding runs first so a UTF-16 input is UTF-8 before the others look at it.
+var DefaultOrder = []string{HandlerEncoding, HandlerBOM, HandlerControl, HandlerWhitespace}
+
+type Handler interface {
+	SetNext(next Handler) Handler
+	Handle(data []byte) ([]byte, error)
+}
+
+// link is embedded by every handler to forward to the rest of the chain.
+type link struct {
+	next Handler
+}
+
+func (l *link) SetNext(next Handler) Handler {
+	l.next = next
+	return next
+}
+
+func (l *link) forward(data []byte) ([]byte, error) {
+	if l.next == nil {
+		return data, nil
+	}
+	return l.next.Handle(data)
+}
+
+// New builds the chain described by options for input in format, or returns
+// nil when sanitizing is disabled or every handler is skipped.
+func New(options models.SanitizeOptions, format models.FileFormat) (Handler, error) {
+	if !options.Enabled {
+		return nil, nil
+	}
+
+	skip := make(map[string]bool, len(options.Skip))
+	for _, name := range options.Skip {
+		if !known(name) {
+			return nil, fmt.Errorf("unknown sanitizer %q", name)
+		}
+		skip[name] = true
+	}
+
+	var first, last Handler
+	for _, name := range DefaultOrder {
+		if skip[name] {
+			continue
+		}
+
+		handler := newHandler(name, options, format)
+		if first == nil {
+			first = handler
+		} else {
+			last.SetNext(handler)
+		}
+		last = handler
+	}
+	return first, nil
+}
+
+// Apply passes data in format through the chain configured by options.
+func Apply(data []byte, options models.SanitizeOptions, format models.FileFormat) ([]byte, error) {
+	chain, err := New(options, format)
+	if err != nil || chain == nil {
+		return data, err
+	}
+	return chain.Handle(data)
+}
+
+func newHandler(name string, options models.SanitizeOptions, format models.FileFormat) Handler {
+	switch name {
+	case HandlerEncoding:
+		return &EncodingFixer{}
+	case HandlerBOM:
+		return &BOMStripper{}
+	case HandlerControl:
+		return &ControlCharRemover{}
+	default:
+		return &WhitespaceNormalizer{TrimTrailing: options.TrimTrailingSpace, CSV: format == models.FormatCSV}
+	}
+}
+
+func known(name string) bool {
+	for _, candidate := range DefaultOrder {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestChainCleansInput(t *testing.T) {
+	input := append([]byte{0xEF, 0xBB, 0xBF}, "name,city\r\nann\x00,café \r\n"...)
+
+	output, err := Apply(input, models.SanitizeOptions{Enabled: true, TrimTrailingSpace: true}, models.FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, "name,city\nann,café\n", string(output))
+}
+
+func TestChainSkipsHandlers(t *testing.T) {
+	output, err := Apply([]byte("a\x07\r\nb"), models.SanitizeOptions{Enabled: true, Skip: []string{HandlerControl}}, models.FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\x07\nb", string(output))
+
+	_, err = New(models.SanitizeOptions{Enabled: true, Skip: []string{"spelling"}}, models.FormatCSV)
+	assert.Error(t, err)
+}
+
+func TestEncodingFixer(t *testing.T) {
+	output, err := (&EncodingFixer{}).Handle([]byte{'c', 'a', 'f', 0xE9, ' ', 0x80})
+	assert.NoError(t, err)
+	assert.Equal(t, "café €", string(output))
+
+	output, err = (&EncodingFixer{}).Handle(append([]byte("naïve "), 0x93, 'q', 0x94))
+	assert.NoError(t, err)
+	assert.Equal(t, "naïve “q”", string(output), "valid UTF-8 is kept")
+
+	output, err = (&EncodingFixer{}).Handle([]byte{0xFF, 0xFE, 'h', 0, 'i', 0})
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", string(output))
+}
+
+func TestDisabledChainLeavesInputAlone(t *testing.T) {
+	output, err := Apply([]byte("a\r\n"), models.SanitizeOptions{}, models.FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\r\n", string(output))
+}
+
+func TestWhitespaceNormalizerKeepsQuotedCSVFields(t *testing.T) {
+	input := "name,note \r\nann,\"two\r\nlines \"\"ok\"\"  \"  \rbob,x\r"
+
+	output, err := Apply([]byte(input), models.SanitizeOptions{Enabled: true, TrimTrailingSpace: true}, models.FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, "name,note\nann,\"two\r\nlines \"\"ok\"\"  \"\nbob,x\n", string(output))
+
+	output, err = Apply([]byte("a \r\n\"b\r\"\r"), models.SanitizeOptions{Enabled: true, TrimTrailingSpace: true}, models.FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\n\"b\n\"\n", string(output))
+}
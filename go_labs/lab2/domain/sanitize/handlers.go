@@ -0,0 +1,175 @@
+// Package sanitize cleans raw input before the first converter sees it. Each
+// cleanup is a Handler in a chain of responsibility, so steps can be
+// reordered, configured or skipped without the others knowing.
+package sanitize
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// BOMStripper removes a leading UTF-8 byte order mark, which otherwise ends
+// up in the first CSV header or breaks JSON parsing.
+type BOMStripper struct {
+	link
+}
+
+func (h *BOMStripper) Handle(data []byte) ([]byte, error) {
+	return h.forward(bytes.TrimPrefix(data, utf8BOM))
+}
+
+// EncodingFixer converts UTF-16 input with a byte order mark to UTF-8 and
+// reinterprets the bytes of any other input that are not valid UTF-8 as
+// Windows-1252, the usual encoding of spreadsheet exports. Valid UTF-8
+// sequences are kept as they are.
+type EncodingFixer struct {
+	link
+}
+
+func (h *EncodingFixer) Handle(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		decoded, err := decodeUTF16(data[2:], false)
+		if err != nil {
+			return nil, err
+		}
+		return h.forward(decoded)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		decoded, err := decodeUTF16(data[2:], true)
+		if err != nil {
+			return nil, err
+		}
+		return h.forward(decoded)
+	case !utf8.Valid(data):
+		return h.forward(decodeWindows1252(data))
+	default:
+		return h.forward(data)
+	}
+}
+
+func decodeUTF16(data []byte, bigEndian bool) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("UTF-16 input has an odd number of bytes")
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+// windows1252 maps the bytes 0x80-0x9F, where Windows-1252 differs from
+// Latin-1; unassigned positions keep their Latin-1 control character.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// decodeWindows1252 replaces each byte that does not start a valid UTF-8
+// sequence with its Windows-1252 character.
+func decodeWindows1252(data []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(data) + len(data)/4)
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		switch b := data[0]; {
+		case r != utf8.RuneError || size > 1:
+			buf.Write(data[:size])
+		case b < 0xA0:
+			buf.WriteRune(windows1252[b-0x80])
+		default:
+			buf.WriteRune(rune(b))
+		}
+		data = data[size:]
+	}
+	return buf.Bytes()
+}
+
+// ControlCharRemover drops control characters other than tab, newline and
+// carriage return, which are never meaningful in text formats.
+type ControlCharRemover struct {
+	link
+}
+
+func (h *ControlCharRemover) Handle(data []byte) ([]byte, error) {
+	cleaned := bytes.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, data)
+	return h.forward(cleaned)
+}
+
+// WhitespaceNormalizer converts CRLF and CR line endings to LF and
+// non-breaking spaces to plain spaces. With TrimTrailing it also strips
+// trailing spaces and tabs from every line. With CSV it leaves the line
+// breaks and trailing blanks inside quoted fields alone, since they are
+// part of the value.
+type WhitespaceNormalizer struct {
+	link
+	TrimTrailing bool
+	CSV          bool
+}
+
+func (h *WhitespaceNormalizer) Handle(data []byte) ([]byte, error) {
+	data = bytes.ReplaceAll(data, []byte(" "), []byte(" "))
+	if h.CSV {
+		return h.forward(normalizeCSVLines(data, h.TrimTrailing))
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+
+	if h.TrimTrailing {
+		lines := bytes.Split(data, []byte("\n"))
+		for i, line := range lines {
+			lines[i] = bytes.TrimRight(line, " \t")
+		}
+		data = bytes.Join(lines, []byte("\n"))
+	}
+	return h.forward(data)
+}
+
+// normalizeCSVLines ends the lines of CSV data with LF, trimming trailing
+// blanks when trim is set, outside quoted fields only. A doubled quote
+// inside a field flips the state twice, so it needs no special case.
+func normalizeCSVLines(data []byte, trim bool) []byte {
+	out := make([]byte, 0, len(data))
+	quoted := false
+	lineStart := 0
+	endLine := func() {
+		if trim {
+			out = out[:lineStart+len(bytes.TrimRight(out[lineStart:], " \t"))]
+		}
+		out = append(out, '\n')
+		lineStart = len(out)
+	}
+	for i := 0; i < len(data); i++ {
+		switch b := data[i]; {
+		case b == '"':
+			quoted = !quoted
+			out = append(out, b)
+		case quoted || (b != '\r' && b != '\n'):
+			out = append(out, b)
+		case b == '\r' && i+1 < len(data) && data[i+1] == '\n':
+			i++
+			endLine()
+		default:
+			endLine()
+		}
+	}
+	if trim {
+		out = out[:lineStart+len(bytes.TrimRight(out[lineStart:], " \t"))]
+	}
+	return out
+}
@@ -0,0 +1,161 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/jobs"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const AdminKeyHeader = "X-Admin-Key"
+
+// TenantReport is a tenant's current quotas and load, without its API key.
+type TenantReport struct {
+	Name           string              `json:"name"`
+	MaxInputBytes  int64               `json:"max_input_bytes"`
+	Timeout        Duration            `json:"timeout"`
+	MaxConcurrent  int                 `json:"max_concurrent"`
+	InFlight       int                 `json:"in_flight"`
+	AllowedFormats []models.FileFormat `json:"allowed_formats,omitempty"`
+}
+
+// EnablePresets lets /convert and /jobs run the pipelines presets names.
+func (s *Server) EnablePresets(presets Presets) {
+	s.presets = presets
+}
+
+// EnableAdmin adds the operator endpoints, which require key in the
+// X-Admin-Key header:
+//
+//	GET   /admin/health             the full HealthReport
+//	GET   /admin/converters         registered conversions
+//	GET   /admin/pool               converter pool statistics
+//	POST  /admin/jobs/{id}/cancel   cancel a queued or running job
+//	GET   /admin/tenants            quotas and load per tenant
+//	PATCH /admin/tenants/{name}     change a tenant's quotas (TenantLimits)
+//	GET   /admin/usage              requests and bytes per principal key
+//	POST  /admin/reload             call reload, when it is not nil
+func (s *Server) EnableAdmin(key string, reload func() error) {
+	s.handle("GET /admin/health", s.admin(key, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.Health(r.Context()))
+	}))
+	s.handle("GET /admin/converters", s.admin(key, s.handleAdminConverters))
+	s.handle("GET /admin/pool", s.admin(key, s.handleAdminPool))
+	s.handle("POST /admin/jobs/{id}/cancel", s.admin(key, s.handleAdminCancelJob))
+	s.handle("GET /admin/tenants", s.admin(key, s.handleAdminTenants))
+	s.handle("PATCH /admin/tenants/{name}", s.admin(key, s.handleAdminSetLimits))
+	s.handle("GET /admin/usage", s.admin(key, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.Usage())
+	}))
+	if reload != nil {
+		s.handle("POST /admin/reload", s.admin(key, func(w http.ResponseWriter, r *http.Request) {
+			if err := reload(); err != nil {
+				s.health.recordError(err)
+				writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("reload failed: %w", err))
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "reloaded"})
+		}))
+	}
+}
+
+func (s *Server) admin(key string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		given := r.Header.Get(AdminKeyHeader)
+		if key == "" || subtle.ConstantTimeCompare([]byte(given), []byte(key)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or unknown admin key"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) handleAdminConverters(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]string{"conversions": factory.RegisteredConversions()})
+}
+
+func (s *Server) handleAdminPool(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.executor.Pool().Stats())
+}
+
+// handleAdminCancelJob cancels a job of any tenant. Cancellation is
+// asynchronous: the worker stops the job at its next check.
+func (s *Server) handleAdminCancelJob(w http.ResponseWriter, r *http.Request) {
+	if s.jobs == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("the job queue is not enabled"))
+		return
+	}
+
+	status, err := s.jobs.Status(r.Context(), r.PathValue("id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if status.State != jobs.StateQueued && status.State != jobs.StateRunning {
+		writeError(w, http.StatusConflict, fmt.Errorf("job is %s", status.State))
+		return
+	}
+
+	if err := s.jobs.Cancel(r.Context(), status.ID); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, jobResponse{ID: status.ID, State: status.State})
+}
+
+func (s *Server) handleAdminTenants(w http.ResponseWriter, r *http.Request) {
+	tenants := s.tenantStates()
+	reports := make([]TenantReport, 0, len(tenants))
+	for _, tenant := range tenants {
+		reports = append(reports, tenant.report())
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Name < reports[j].Name })
+	writeJSON(w, http.StatusOK, reports)
+}
+
+func (s *Server) handleAdminSetLimits(w http.ResponseWriter, r *http.Request) {
+	var limits TenantLimits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limits: %w", err))
+		return
+	}
+	if limits.MaxInputBytes < 0 || limits.Timeout.Duration < 0 || limits.MaxConcurrent < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("limits must not be negative"))
+		return
+	}
+
+	for _, tenant := range s.tenantStates() {
+		if tenant.Name == r.PathValue("name") {
+			tenant.setLimits(limits)
+			writeJSON(w, http.StatusOK, tenant.report())
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("unknown tenant %q", r.PathValue("name")))
+}
+
+func (t *tenantState) report() TenantReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return TenantReport{
+		Name:           t.Name,
+		MaxInputBytes:  t.MaxInputBytes,
+		Timeout:        t.Timeout,
+		MaxConcurrent:  t.MaxConcurrent,
+		InFlight:       t.inFlight,
+		AllowedFormats: t.AllowedFormats,
+	}
+}
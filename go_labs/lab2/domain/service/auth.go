@@ -0,0 +1,165 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Principal is who a request acts for: the tenant whose quotas apply and
+// the key its usage is accounted under. Key never contains a secret.
+type Principal struct {
+	Tenant string
+	Key    string
+}
+
+// Authenticator identifies the caller of a request. Servers try their
+// authenticators in order and use the first that accepts the request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, bool)
+}
+
+type AuthenticatorFunc func(r *http.Request) (Principal, bool)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (Principal, bool) {
+	return f(r)
+}
+
+// APIKeys accepts the tenants' API keys in the X-API-Key header or as a
+// bearer token. This is how servers authenticate unless told otherwise.
+func APIKeys(tenants []Tenant) Authenticator {
+	principals := make(map[string]Principal, len(tenants))
+	for _, tenant := range tenants {
+		principals[tenant.APIKey] = Principal{Tenant: tenant.Name, Key: "api-key:" + tenant.Name}
+	}
+
+	return AuthenticatorFunc(func(r *http.Request) (Principal, bool) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			key = bearerToken(r)
+		}
+		principal, ok := principals[key]
+		return principal, ok && key != ""
+	})
+}
+
+// JWT accepts HS256 bearer tokens signed with secret. The token's
+// tenantClaim ("tenant" when empty) names the tenant, and usage is
+// accounted per subject. Tokens without an exp claim, expired tokens and
+// tokens not yet valid are refused.
+func JWT(secret []byte, tenantClaim string) Authenticator {
+	if tenantClaim == "" {
+		tenantClaim = "tenant"
+	}
+
+	return AuthenticatorFunc(func(r *http.Request) (Principal, bool) {
+		claims, ok := verifyJWT(bearerToken(r), secret, time.Now())
+		if !ok {
+			return Principal{}, false
+		}
+		tenant, _ := claims[tenantClaim].(string)
+		if tenant == "" {
+			return Principal{}, false
+		}
+		subject, _ := claims["sub"].(string)
+		if subject == "" {
+			subject = tenant
+		}
+		return Principal{Tenant: tenant, Key: "jwt:" + subject}, true
+	})
+}
+
+func verifyJWT(token string, secret []byte, now time.Time) (map[string]interface{}, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if !decodeSegment(parts[0], &header) || header.Alg != "HS256" {
+		return nil, false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, false
+	}
+
+	var claims map[string]interface{}
+	if !decodeSegment(parts[1], &claims) {
+		return nil, false
+	}
+	if exp, ok := claims["exp"].(float64); !ok || now.Unix() >= int64(exp) {
+		return nil, false
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return nil, false
+	}
+	return claims, true
+}
+
+func decodeSegment(segment string, target interface{}) bool {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	return err == nil && json.Unmarshal(data, target) == nil
+}
+
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token
+}
+
+// SetAuthenticators replaces how callers are identified, for example with
+// APIKeys(tenants) followed by JWT(secret, ""). Principals must name one
+// of the server's tenants. In open mode every request is anonymous.
+func (s *Server) SetAuthenticators(authenticators ...Authenticator) {
+	s.authenticators = authenticators
+}
+
+type principalKey struct{}
+
+// principal identifies the caller, once per request.
+func (s *Server) principal(r *http.Request) (Principal, bool) {
+	if principal, ok := r.Context().Value(principalKey{}).(Principal); ok {
+		return principal, true
+	}
+	if s.open != nil {
+		return Principal{Tenant: s.open.Name, Key: s.open.Name}, true
+	}
+	for _, authenticator := range s.authenticators {
+		if principal, ok := authenticator.Authenticate(r); ok {
+			return principal, true
+		}
+	}
+	return Principal{}, false
+}
+
+func (s *Server) authenticate(r *http.Request) (*tenantState, bool) {
+	principal, ok := s.principal(r)
+	if !ok {
+		return nil, false
+	}
+	if s.open != nil {
+		return s.open, true
+	}
+	tenant, ok := s.tenants[principal.Tenant]
+	return tenant, ok
+}
+
+func withPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
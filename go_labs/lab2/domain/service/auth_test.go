@@ -0,0 +1,109 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signJWT(secret string, claims map[string]interface{}) string {
+	encode := func(value interface{}) string {
+		data, _ := json.Marshal(value)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := encode(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encode(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTAuthentication(t *testing.T) {
+	server := newTestServer()
+	server.SetAuthenticators(APIKeys([]Tenant{{Name: "big", APIKey: "big-key"}}), JWT([]byte("jwt-secret"), ""))
+
+	bearer := func(token string) int {
+		request := httptest.NewRequest(http.MethodPost, "/convert?from=csv&to=json", strings.NewReader("a\n1\n"))
+		request.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	hour := time.Now().Add(time.Hour).Unix()
+	assert.Equal(t, http.StatusOK, bearer("big-key"))
+	assert.Equal(t, http.StatusOK, bearer(signJWT("jwt-secret", map[string]interface{}{"tenant": "small", "sub": "etl", "exp": hour})))
+	assert.Equal(t, http.StatusUnauthorized, bearer(signJWT("wrong-secret", map[string]interface{}{"tenant": "small"})))
+	assert.Equal(t, http.StatusUnauthorized, bearer(signJWT("jwt-secret", map[string]interface{}{"tenant": "small", "exp": time.Now().Add(-time.Minute).Unix()})))
+	assert.Equal(t, http.StatusUnauthorized, bearer(signJWT("jwt-secret", map[string]interface{}{"tenant": "small"})))
+	assert.Equal(t, http.StatusUnauthorized, bearer(signJWT("jwt-secret", map[string]interface{}{"tenant": "unknown", "exp": hour})))
+	assert.Equal(t, http.StatusUnauthorized, bearer(signJWT("jwt-secret", map[string]interface{}{"sub": "etl", "exp": hour})))
+}
+
+func TestNewServerRefusesDuplicateTenantNames(t *testing.T) {
+	assert.Panics(t, func() {
+		NewServer(nil, []Tenant{{Name: "big", APIKey: "one"}, {Name: "big", APIKey: "two"}})
+	})
+}
+
+func TestLoadTenantsExpandsKeys(t *testing.T) {
+	t.Setenv("TEST_TENANT_KEY", "from$$env")
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"tenants": [
+		{"name": "literal", "api_key": "pa$$word"},
+		{"name": "dollar", "api_key": "cost$5"},
+		{"name": "referenced", "api_key": "${TEST_TENANT_KEY}"}
+	]}`), 0o644))
+
+	tenants, err := LoadTenants(path)
+	assert.NoError(t, err)
+	keys := make(map[string]string)
+	for _, tenant := range tenants {
+		keys[tenant.Name] = tenant.APIKey
+	}
+	assert.Equal(t, map[string]string{"literal": "pa$word", "dollar": "cost$5", "referenced": "from$$env"}, keys)
+}
+
+func TestAccountingKeepsFlushing(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	response := &countingResponse{ResponseWriter: recorder, status: http.StatusOK}
+
+	assert.NoError(t, http.NewResponseController(response).Flush())
+	assert.True(t, recorder.Flushed)
+}
+
+func TestUsageIsAccountedPerKey(t *testing.T) {
+	server := newTestServer()
+	server.EnableAdmin("admin-secret", nil)
+
+	convert(server, "big-key", "from=csv&to=json", "a\n1\n")
+	convert(server, "big-key", "from=csv&to=nope", "a\n1\n")
+	convert(server, "small-key", "from=csv&to=json", "a\n2\n")
+	convert(server, "unknown-key", "from=csv&to=json", "a\n3\n")
+	adminRequest(server, "admin-secret", http.MethodGet, "/admin/pool", "")
+
+	response := adminRequest(server, "admin-secret", http.MethodGet, "/admin/usage", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	var usage []Usage
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &usage))
+	assert.Len(t, usage, 2)
+
+	big := usage[0]
+	assert.Equal(t, "api-key:big", big.Key)
+	assert.Equal(t, "big", big.Tenant)
+	assert.Equal(t, int64(2), big.Requests)
+	assert.Equal(t, int64(1), big.Errors)
+	assert.Equal(t, int64(4), big.BytesIn)
+	assert.Positive(t, big.BytesOut)
+	assert.Equal(t, "api-key:small", usage[1].Key)
+	assert.NotContains(t, response.Body.String(), "big-key")
+}
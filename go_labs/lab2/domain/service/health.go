@@ -0,0 +1,134 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"tmps-go-labs/lab2/domain/factory"
+)
+
+type TenantLoad struct {
+	InFlight      int `json:"in_flight"`
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+type ErrorReport struct {
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// ProbeReport is what the unauthenticated /healthz and /readyz answer: the
+// status alone, so probes reveal nothing about tenants or failures.
+type ProbeReport struct {
+	Status string `json:"status"`
+}
+
+// HealthReport is the full report served on /admin/health.
+type HealthReport struct {
+	Status     string                `json:"status"`
+	Uptime     string                `json:"uptime"`
+	Pool       factory.PoolStats     `json:"pool"`
+	InFlight   int                   `json:"in_flight"`
+	QueueDepth int                   `json:"queue_depth"`
+	QueueError string                `json:"queue_error,omitempty"`
+	Tenants    map[string]TenantLoad `json:"tenants"`
+	LastError  *ErrorReport          `json:"last_error,omitempty"`
+}
+
+type health struct {
+	started   time.Time
+	mu        sync.Mutex
+	lastError *ErrorReport
+	draining  bool
+}
+
+func (h *health) recordError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastError = &ErrorReport{Message: err.Error(), At: time.Now().UTC()}
+}
+
+// Drain marks the server as not ready so load balancers stop routing new
+// conversions to it while in-flight ones finish.
+func (s *Server) Drain() {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	s.health.draining = true
+}
+
+// Shutdown drains the server and waits for in-flight conversions until ctx
+// expires, after which they are aborted and answered with 503.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Drain()
+	return s.executor.Shutdown(ctx)
+}
+
+func (s *Server) status() string {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	if s.health.draining {
+		return "draining"
+	}
+	return "ok"
+}
+
+// Health reports the server's state. InFlight counts the conversions
+// running for all tenants; QueueDepth the /jobs waiting for a worker.
+func (s *Server) Health(ctx context.Context) HealthReport {
+	s.health.mu.Lock()
+	lastError := s.health.lastError
+	s.health.mu.Unlock()
+
+	report := HealthReport{
+		Status:    s.status(),
+		Uptime:    time.Since(s.health.started).Round(time.Second).String(),
+		Pool:      s.executor.Pool().Stats(),
+		Tenants:   make(map[string]TenantLoad),
+		LastError: lastError,
+	}
+
+	for _, tenant := range s.tenantStates() {
+		load := tenant.load()
+		report.InFlight += load.InFlight
+		report.Tenants[tenant.Name] = load
+	}
+	if s.jobs != nil {
+		depth, err := s.jobs.Len(ctx)
+		if err != nil {
+			report.QueueError = err.Error()
+		}
+		report.QueueDepth = depth
+	}
+	return report
+}
+
+func (s *Server) tenantStates() []*tenantState {
+	if s.open != nil {
+		return []*tenantState{s.open}
+	}
+	states := make([]*tenantState, 0, len(s.tenants))
+	for _, tenant := range s.tenants {
+		states = append(states, tenant)
+	}
+	return states
+}
+
+// handleHealthz reports liveness: the process is up and serving requests.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ProbeReport{Status: s.status()})
+}
+
+// handleReadyz fails while the server drains so no new work is routed to it.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := ProbeReport{Status: s.status()}
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
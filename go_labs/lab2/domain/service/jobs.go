@@ -0,0 +1,118 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"tmps-go-labs/lab2/domain/jobs"
+	"tmps-go-labs/lab2/domain/runstate"
+)
+
+type jobResponse struct {
+	ID    string             `json:"id"`
+	State jobs.State         `json:"state"`
+	Error string             `json:"error,omitempty"`
+	Run   *runstate.Snapshot `json:"run,omitempty"`
+}
+
+// EnableJobs adds the asynchronous job endpoints. Conversions posted to
+// /jobs are queued for workers instead of running in the request.
+func (s *Server) EnableJobs(queue jobs.Queue) {
+	s.jobs = queue
+	s.handle("POST /jobs", s.handleEnqueue)
+	s.handle("GET /jobs/{id}", s.handleJobStatus)
+	s.handle("GET /jobs/{id}/output", s.handleJobOutput)
+}
+
+func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
+	// The Accept header of /jobs is about the job response, not the output.
+	req, ok := s.parseRequest(w, r, "")
+	if !ok {
+		return
+	}
+
+	// Queueing reads the whole input, so it counts against the same
+	// concurrency quota as a conversion.
+	if !req.tenant.tryAcquire() {
+		writeError(w, http.StatusTooManyRequests, fmt.Errorf("too many concurrent conversions for this API key"))
+		return
+	}
+	defer req.tenant.release()
+
+	if !s.readInput(w, r, req) {
+		return
+	}
+
+	principal, _ := s.principal(r)
+	job := &jobs.Job{
+		ID:      jobs.NewID(),
+		Tenant:  req.tenant.Name,
+		Owner:   principal.Key,
+		Steps:   req.pipeline.Steps,
+		Options: req.pipeline.Options,
+		Timeout: req.tenant.limits().Timeout.Duration,
+		Input:   req.input,
+	}
+	err := s.jobs.Enqueue(r.Context(), job)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		writeError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	if err != nil {
+		s.health.recordError(err)
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("failed to enqueue job: %w", err))
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, jobResponse{ID: job.ID, State: jobs.StateQueued})
+}
+
+func (s *Server) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	status, ok := s.lookupJob(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, jobResponse{ID: status.ID, State: status.State, Error: status.Error, Run: status.Run})
+}
+
+func (s *Server) handleJobOutput(w http.ResponseWriter, r *http.Request) {
+	status, ok := s.lookupJob(w, r)
+	if !ok {
+		return
+	}
+
+	switch status.State {
+	case jobs.StateDone:
+		writeBody(w, r, "application/octet-stream", status.Output)
+	case jobs.StateFailed:
+		writeError(w, http.StatusUnprocessableEntity, errors.New(status.Error))
+	default:
+		writeError(w, http.StatusConflict, fmt.Errorf("job is %s", status.State))
+	}
+}
+
+// lookupJob only reveals jobs queued by the caller: the same API key, or
+// tokens with the same subject.
+func (s *Server) lookupJob(w http.ResponseWriter, r *http.Request) (*jobs.Status, bool) {
+	if _, ok := s.authenticate(r); !ok {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or unknown API key"))
+		return nil, false
+	}
+	principal, _ := s.principal(r)
+
+	status, err := s.jobs.Status(r.Context(), r.PathValue("id"))
+	if errors.Is(err, jobs.ErrNotFound) || (err == nil && status.Owner != principal.Key) {
+		writeError(w, http.StatusNotFound, jobs.ErrNotFound)
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return nil, false
+	}
+	return status, true
+}
@@ -0,0 +1,124 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"compress/gzip"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// acceptRange is one entry of an Accept or Accept-Encoding header.
+type acceptRange struct {
+	value string
+	q     float64
+}
+
+// parseAccept returns the header's entries by preference, highest quality
+// first and in header order among equals. Entries with q=0 are refused and
+// kept so they can override a wildcard.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, number, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(name, "q") {
+				if parsed, err := strconv.ParseFloat(number, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{value: value, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// matches reports whether an Accept range such as "text/*" covers
+// mediaType.
+func (a acceptRange) matches(mediaType string) bool {
+	if a.value == "*/*" || a.value == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(a.value, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// accepts reports whether the Accept header allows format. The most
+// specific matching range decides, so "*/*, text/csv;q=0" refuses CSV.
+// Formats without a media type are always acceptable.
+func accepts(header string, format models.FileFormat) bool {
+	mediaType, ok := models.MediaType(format)
+	if header == "" || !ok {
+		return true
+	}
+
+	best, q := -1, 0.0
+	for _, r := range parseAccept(header) {
+		if !r.matches(mediaType) {
+			continue
+		}
+		specificity := strings.Count(r.value, "*")
+		if best == -1 || specificity < best {
+			best, q = specificity, r.q
+		}
+	}
+	return best != -1 && q > 0
+}
+
+// negotiateFormat picks the preferred format named in the Accept header
+// that usable allows. Wildcards say nothing about which format to produce,
+// so they never select one.
+func negotiateFormat(header string, usable func(models.FileFormat) bool) (models.FileFormat, bool) {
+	for _, r := range parseAccept(header) {
+		if r.q <= 0 {
+			continue
+		}
+		if format, ok := models.FormatFromMediaType(r.value); ok && usable(format) {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// acceptsGzip reports whether Accept-Encoding allows a gzip response.
+func acceptsGzip(header string) bool {
+	allowed := false
+	for _, r := range parseAccept(header) {
+		switch r.value {
+		case "gzip", "x-gzip":
+			return r.q > 0
+		case "*":
+			allowed = r.q > 0
+		}
+	}
+	return allowed
+}
+
+// writeBody writes a successful conversion, gzipped when the client
+// accepts it.
+func writeBody(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept, Accept-Encoding")
+	if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		w.Write(body)
+		return
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	compressed := gzip.NewWriter(w)
+	compressed.Write(body)
+	compressed.Close()
+}
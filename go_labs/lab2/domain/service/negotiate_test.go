@@ -0,0 +1,61 @@
+package service
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestAccepts(t *testing.T) {
+	assert.True(t, accepts("", models.FormatCSV))
+	assert.True(t, accepts("text/*", models.FormatCSV))
+	assert.True(t, accepts("application/json, */*;q=0.1", models.FormatCSV))
+	assert.False(t, accepts("*/*, text/csv;q=0", models.FormatCSV))
+	assert.False(t, accepts("application/json", models.FormatCSV))
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	assert.True(t, acceptsGzip("gzip, deflate"))
+	assert.True(t, acceptsGzip("*"))
+	assert.False(t, acceptsGzip("gzip;q=0, *"))
+	assert.False(t, acceptsGzip(""))
+}
+
+func TestConvertNegotiatesFormatAndEncoding(t *testing.T) {
+	server := newTestServer()
+	request := func(query string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/convert?"+query, strings.NewReader("a\n1\n"))
+		request.Header.Set(APIKeyHeader, "big-key")
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	response := request("", map[string]string{"Content-Type": "text/csv", "Accept": "image/png, application/yaml;q=0.9, */*;q=0.1"})
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/yaml", response.Header().Get("Content-Type"))
+	assert.Contains(t, response.Header().Get("Vary"), "Accept")
+
+	assert.Equal(t, http.StatusNotAcceptable, request("from=csv", map[string]string{"Accept": "image/png"}).Code)
+	assert.Equal(t, http.StatusNotAcceptable, request("from=csv&to=xml", map[string]string{"Accept": "application/json"}).Code)
+	assert.Equal(t, http.StatusBadRequest, request("from=csv", nil).Code)
+
+	response = request("from=csv&to=json", map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "gzip", response.Header().Get("Content-Encoding"))
+	reader, err := gzip.NewReader(response.Body)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"a":"1"}]`, string(body))
+}
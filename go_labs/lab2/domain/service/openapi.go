@@ -0,0 +1,42 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI is the OpenAPI 3 description of the service, served at
+// /openapi.yaml and, converted, at /openapi.json.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+var openAPIJSON = sync.OnceValues(func() ([]byte, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(OpenAPI, &document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+})
+
+func (s *Server) handleOpenAPIYAML(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(OpenAPI)
+}
+
+func (s *Server) handleOpenAPIJSON(w http.ResponseWriter, r *http.Request) {
+	document, err := openAPIJSON()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(document)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/jobs"
+)
+
+func TestOpenAPIDescribesRoutedEndpoints(t *testing.T) {
+	server := newTestServer()
+	server.EnableJobs(jobs.NewMemoryQueue(1))
+	assert.NoError(t, server.EnableUploads(t.TempDir()))
+	server.EnableAdmin("admin-secret", func() error { return nil })
+
+	var document struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	assert.NoError(t, yaml.Unmarshal(OpenAPI, &document))
+	assert.NotEmpty(t, document.Paths)
+
+	for path, operations := range document.Paths {
+		for method := range operations {
+			request := httptest.NewRequest(strings.ToUpper(method), strings.NewReplacer("{id}", "x", "{name}", "x").Replace(path), nil)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			// Unrouted paths get the mux's plain-text 404 or a 405.
+			assert.NotEqual(t, http.StatusMethodNotAllowed, recorder.Code, method, path)
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"), method, path)
+		}
+	}
+
+	// And every route is described, except the document itself.
+	for _, route := range server.routes {
+		method, path, _ := strings.Cut(route, " ")
+		if strings.HasPrefix(path, "/openapi.") {
+			continue
+		}
+		assert.Contains(t, document.Paths[path], strings.ToLower(method), "%s is missing from openapi.yaml", route)
+	}
+}
+
+func TestOpenAPIJSON(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestServer().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var document map[string]interface{}
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	assert.Equal(t, "3.0.3", document["openapi"])
+}
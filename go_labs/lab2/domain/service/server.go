@@ -0,0 +1,288 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/jobs"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const (
+	APIKeyHeader  = "X-API-Key"
+	OptionsHeader = "X-Conversion-Options"
+)
+
+type Server struct {
+	executor       *factory.PipelineExecutor
+	tenants        map[string]*tenantState
+	open           *tenantState
+	authenticators []Authenticator
+	mux            *http.ServeMux
+	routes         []string
+	health         health
+	jobs           jobs.Queue
+	presets        Presets
+	uploads        *uploadStore
+	usage          usageLedger
+}
+
+// Presets resolves the named pipelines requested with /convert?preset=name.
+type Presets interface {
+	Preset(name string) (*models.Pipeline, bool)
+}
+
+// NewServer serves the given tenants. Without tenants the service runs in
+// open mode: no API key is required and the default quotas apply to all.
+// It panics on tenants that CheckTenants rejects, since one tenant would
+// silently replace the other.
+func NewServer(executor *factory.PipelineExecutor, tenants []Tenant) *Server {
+	if err := CheckTenants(tenants); err != nil {
+		panic("service: " + err.Error())
+	}
+	s := &Server{
+		executor: executor,
+		tenants:  make(map[string]*tenantState),
+		mux:      http.NewServeMux(),
+		health:   health{started: time.Now()},
+	}
+
+	for _, tenant := range tenants {
+		s.tenants[tenant.Name] = newTenantState(tenant)
+	}
+	s.authenticators = []Authenticator{APIKeys(tenants)}
+	if len(tenants) == 0 {
+		s.open = newTenantState(Tenant{Name: "anonymous"})
+	}
+
+	s.handle("POST /convert", s.handleConvert)
+	s.handle("GET /healthz", s.handleHealthz)
+	s.handle("GET /readyz", s.handleReadyz)
+	s.handle("GET /openapi.yaml", s.handleOpenAPIYAML)
+	s.handle("GET /openapi.json", s.handleOpenAPIJSON)
+	return s
+}
+
+// handle routes pattern, such as "GET /jobs/{id}", to handler and records
+// it so the OpenAPI document can be checked against every route.
+func (s *Server) handle(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, handler)
+	s.routes = append(s.routes, pattern)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if accounted(r.URL.Path) {
+		s.account(w, r)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+type conversionRequest struct {
+	tenant   *tenantState
+	pipeline *models.Pipeline
+	input    []byte
+}
+
+// parseRequest authenticates the caller and validates the conversion it asks
+// for, negotiating the output format with accept, the Accept header of a
+// request answered with the output. On failure the error response has
+// already been written.
+func (s *Server) parseRequest(w http.ResponseWriter, r *http.Request, accept string) (*conversionRequest, bool) {
+	tenant, ok := s.authenticate(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or unknown API key"))
+		return nil, false
+	}
+
+	if name := r.URL.Query().Get("preset"); name != "" {
+		return s.parsePresetRequest(w, r, tenant, name, accept)
+	}
+
+	// The query wins; otherwise the input format comes from Content-Type and
+	// the output format from Accept.
+	from := models.FileFormat(r.URL.Query().Get("from"))
+	if format, ok := models.FormatFromMediaType(r.Header.Get("Content-Type")); from == "" && ok {
+		from = format
+	}
+	to := models.FileFormat(r.URL.Query().Get("to"))
+	if to == "" && from != "" && accept != "" {
+		format, ok := negotiateFormat(accept, func(format models.FileFormat) bool {
+			return format != from && tenant.allows(format) && factory.IsRegistered(string(from)+"-"+string(format))
+		})
+		if !ok {
+			writeError(w, http.StatusNotAcceptable, fmt.Errorf("no format in the Accept header can be produced from %s", from))
+			return nil, false
+		}
+		to = format
+	}
+	if from == "" || to == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("from and to query parameters are required"))
+		return nil, false
+	}
+	if !accepts(accept, to) {
+		writeError(w, http.StatusNotAcceptable, fmt.Errorf("%s output is not acceptable per the Accept header", to))
+		return nil, false
+	}
+	if !tenant.allows(from) || !tenant.allows(to) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("conversion %s to %s is not allowed for this API key", from, to))
+		return nil, false
+	}
+	if !factory.IsRegistered(string(from) + "-" + string(to)) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported conversion: %s to %s", from, to))
+		return nil, false
+	}
+
+	options, err := parseOptions(r.Header.Get(OptionsHeader))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	// The header gets the checks of a pipeline file, so invalid options
+	// fail here instead of in the middle of the run.
+	pipeline, err := factory.NewPipelineBuilderFrom(&models.Pipeline{
+		Steps:   []models.ConversionStep{{From: from, To: to}},
+		Options: options,
+	}).BuildSubPipeline()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s header: %w", OptionsHeader, err))
+		return nil, false
+	}
+
+	return &conversionRequest{tenant: tenant, pipeline: pipeline}, true
+}
+
+// parsePresetRequest runs a server-defined pipeline. Its options are
+// trusted as they are, so the caller cannot add options of their own.
+func (s *Server) parsePresetRequest(w http.ResponseWriter, r *http.Request, tenant *tenantState, name, accept string) (*conversionRequest, bool) {
+	var pipeline *models.Pipeline
+	ok := false
+	if s.presets != nil {
+		pipeline, ok = s.presets.Preset(name)
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown preset %q", name))
+		return nil, false
+	}
+	if r.Header.Get(OptionsHeader) != "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%s cannot be combined with a preset", OptionsHeader))
+		return nil, false
+	}
+	if to := pipeline.Steps[len(pipeline.Steps)-1].To; !accepts(accept, to) {
+		writeError(w, http.StatusNotAcceptable, fmt.Errorf("preset %q produces %s, which is not acceptable per the Accept header", name, to))
+		return nil, false
+	}
+	for _, step := range pipeline.Steps {
+		if !tenant.allows(step.From) || !tenant.allows(step.To) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("preset %q converts %s to %s, which is not allowed for this API key", name, step.From, step.To))
+			return nil, false
+		}
+	}
+	return &conversionRequest{tenant: tenant, pipeline: pipeline}, true
+}
+
+func (s *Server) readInput(w http.ResponseWriter, r *http.Request, req *conversionRequest) bool {
+	if id := r.URL.Query().Get("upload"); id != "" {
+		return s.readUpload(w, req, id)
+	}
+
+	maxBytes := req.tenant.limits().MaxInputBytes
+	input, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("input exceeds %d bytes", maxBytes))
+			return false
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read input: %w", err))
+		return false
+	}
+	req.input = input
+	return true
+}
+
+func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.parseRequest(w, r, r.Header.Get("Accept"))
+	if !ok {
+		return
+	}
+
+	if !req.tenant.tryAcquire() {
+		writeError(w, http.StatusTooManyRequests, fmt.Errorf("too many concurrent conversions for this API key"))
+		return
+	}
+	// A timed-out converter keeps running after the response, so the slot
+	// is only freed once it returns.
+	ctx, settled := factory.TrackConverters(r.Context())
+	defer settled(req.tenant.release)
+
+	if !s.readInput(w, r, req) {
+		return
+	}
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+	ctx, cancel := context.WithTimeout(ctx, req.tenant.limits().Timeout.Duration)
+	defer cancel()
+
+	output, result := s.executor.ConvertData(ctx, req.pipeline, req.input)
+	if !result.Success {
+		status := http.StatusUnprocessableEntity
+		switch {
+		case errors.Is(result.Error, context.DeadlineExceeded):
+			status = http.StatusGatewayTimeout
+		case errors.Is(result.Error, factory.ErrShuttingDown), errors.Is(result.Error, factory.ErrPoolExhausted):
+			status = http.StatusServiceUnavailable
+		}
+		s.health.recordError(result.Error)
+		writeError(w, status, result.Error)
+		return
+	}
+
+	to := req.pipeline.Steps[len(req.pipeline.Steps)-1].To
+	w.Header().Set("X-Conversion-Duration", time.Duration(result.Duration).String())
+	writeBody(w, r, contentType(to), output)
+}
+
+func contentType(format models.FileFormat) string {
+	if mediaType, ok := models.MediaType(format); ok {
+		return mediaType
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func parseOptions(header string) (models.ConversionOptions, error) {
+	var options models.ConversionOptions
+	if header == "" {
+		return sandboxOptions(options)
+	}
+	if err := json.Unmarshal([]byte(header), &options); err != nil {
+		return options, fmt.Errorf("invalid %s header: %w", OptionsHeader, err)
+	}
+	sandboxed, err := sandboxOptions(options)
+	if err != nil {
+		return options, fmt.Errorf("invalid %s header: %w", OptionsHeader, err)
+	}
+	return sandboxed, nil
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
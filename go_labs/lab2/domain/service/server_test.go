@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/jobs"
+	"tmps-go-labs/lab2/domain/limits"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func newTestServer() *Server {
+	pool := factory.NewConverterPool(2, factory.NewConverterFactory())
+	return NewServer(factory.NewPipelineExecutor(pool), []Tenant{
+		{Name: "small", APIKey: "small-key", MaxInputBytes: 16, AllowedFormats: []models.FileFormat{"csv", "json"}},
+		{Name: "big", APIKey: "big-key"},
+	})
+}
+
+func convert(server *Server, key, query, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/convert?"+query, strings.NewReader(body))
+	if key != "" {
+		request.Header.Set(APIKeyHeader, key)
+	}
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestConvertEnforcesTenantQuotas(t *testing.T) {
+	server := newTestServer()
+
+	assert.Equal(t, http.StatusUnauthorized, convert(server, "", "from=csv&to=json", "a\n1\n").Code)
+	assert.Equal(t, http.StatusForbidden, convert(server, "small-key", "from=json&to=xml", "[]").Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, convert(server, "small-key", "from=csv&to=json", strings.Repeat("a", 64)).Code)
+
+	response := convert(server, "small-key", "from=csv&to=json", "a\n1\n")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `[{"a":"1"}]`, response.Body.String())
+
+	response = convert(server, "big-key", "from=json&to=xml", `[{"a":"1"}]`)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/xml", response.Header().Get("Content-Type"))
+}
+
+func TestSandboxDropsServerSideOptions(t *testing.T) {
+	options, err := parseOptions(`{"TemplatePath": "/etc/passwd", "Template": "{{len .Records}}", "Profile": true}`)
+
+	assert.NoError(t, err)
+	assert.Empty(t, options.TemplatePath)
+	assert.False(t, options.Profile)
+	assert.Equal(t, "{{len .Records}}", options.Template)
+
+	options, err = parseOptions(`{"XML": {"AllowDTD": true, "CDATA": "preserve"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, models.XMLOptions{CDATA: models.XMLPreserve}, options.XML, "request bodies never get DTDs")
+
+	options, err = parseOptions(`{"Limits": {"MaxDepth": 10000, "MaxNodeCount": 50}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DocumentLimits{MaxDepth: limits.Server.MaxDepth, MaxNodeCount: 50}, options.Limits, "requests only tighten limits")
+
+	options, err = parseOptions(`{"YAML": {"MaxAliasDepth": 1000}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, serverMaxAliasDepth, options.YAML.MaxAliasDepth)
+
+	_, err = parseOptions(`{"IndentWidth": -1}`)
+	assert.EqualError(t, err, "invalid X-Conversion-Options header: indent width -1 is outside 0..16")
+}
+
+func TestConvertRejectsInvalidHeaderOptions(t *testing.T) {
+	server := newTestServer()
+	for header, message := range map[string]string{
+		`{"CSVParser": "parallel"}`:    "",
+		`{"PostProcess": ["shout"]}`:   `unknown post-processor \"shout\"`,
+		`{"CSVParser": "fastest"}`:     `unknown CSV parser \"fastest\"`,
+		`{"Template": "{{.Records"}`:   "failed to parse template",
+		`{"Strategy": "eventually"}`:   `unknown execution strategy \"eventually\"`,
+		`{"Limits": {"MaxDepth": -1}}`: "document limits must not be negative",
+		`{"XML": {"CDATA": "keep"}}`:   `unknown XML CDATA handling \"keep\"`,
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/convert?from=csv&to=json", strings.NewReader("a\n1\n"))
+		request.Header.Set(APIKeyHeader, "big-key")
+		request.Header.Set(OptionsHeader, header)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		if message == "" {
+			assert.Equal(t, http.StatusOK, recorder.Code, header)
+			continue
+		}
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, header)
+		assert.Contains(t, recorder.Body.String(), message, header)
+	}
+}
+
+func TestConvertBoundsFixedWidthColumns(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/convert?from=csv&to=fixedwidth", strings.NewReader("a\n1\n"))
+	request.Header.Set(APIKeyHeader, "big-key")
+	request.Header.Set(OptionsHeader, `{"FixedWidthColumns": [{"Name": "a", "Start": 1, "Length": 1000000000}]}`)
+	recorder := httptest.NewRecorder()
+	newTestServer().ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "ends past position 65536")
+}
+
+func TestConvertRejectsDeeplyNestedInput(t *testing.T) {
+	deep := strings.Repeat("[", limits.Server.MaxDepth+1) + strings.Repeat("]", limits.Server.MaxDepth+1)
+	response := convert(newTestServer(), "big-key", "from=json&to=yaml", deep)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	assert.Contains(t, response.Body.String(), "nested deeper than")
+}
+
+func TestConvertRejectsXMLWithDTD(t *testing.T) {
+	response := convert(newTestServer(), "big-key", "from=xml&to=json", `<?xml version="1.0"?>
+<!DOCTYPE r [<!ENTITY x SYSTEM "file:///etc/passwd">]>
+<r>&x;</r>`)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	assert.Contains(t, response.Body.String(), "external entities are not allowed")
+}
+
+func TestReadyzFailsWhileDraining(t *testing.T) {
+	server := newTestServer()
+	convert(server, "big-key", "from=json&to=xml", "not json")
+
+	request := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+
+	server.Drain()
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.JSONEq(t, `{"status":"draining"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestAdminHealthReportsDetails(t *testing.T) {
+	server := newTestServer()
+	server.EnableAdmin("admin-secret", nil)
+	convert(server, "big-key", "from=json&to=xml", "not json")
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/health", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	request := httptest.NewRequest(http.MethodGet, "/admin/health", nil)
+	request.Header.Set(AdminKeyHeader, "admin-secret")
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	var report HealthReport
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	assert.Equal(t, 0, report.InFlight)
+	assert.Equal(t, 0, report.QueueDepth)
+	assert.Equal(t, 1, report.Pool.Created["json-xml"])
+	assert.NotNil(t, report.LastError)
+	assert.Contains(t, report.Tenants, "small")
+}
+
+func TestJobsAreScopedToTenant(t *testing.T) {
+	server := newTestServer()
+	queue := jobs.NewMemoryQueue(4)
+	server.EnableJobs(queue)
+
+	request := httptest.NewRequest(http.MethodPost, "/jobs?from=csv&to=json", strings.NewReader("a\n1\n"))
+	request.Header.Set(APIKeyHeader, "small-key")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+
+	job, err := queue.Dequeue(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, jobs.NewWorker(queue, server.executor).Process(context.Background(), job))
+
+	get := func(key, path string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set(APIKeyHeader, key)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("big-key", "/jobs/"+job.ID).Code)
+	assert.Contains(t, get("small-key", "/jobs/"+job.ID).Body.String(), `"state":"done"`)
+	assert.JSONEq(t, `[{"a":"1"}]`, get("small-key", "/jobs/"+job.ID+"/output").Body.String())
+}
+
+func TestEnqueueAnswers429WhenTheQueueIsFull(t *testing.T) {
+	server := newTestServer()
+	server.EnableJobs(jobs.NewMemoryQueue(1))
+
+	enqueue := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/jobs?from=csv&to=json", strings.NewReader("a\n1\n"))
+		request.Header.Set(APIKeyHeader, "small-key")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusAccepted, enqueue().Code)
+	assert.Equal(t, http.StatusTooManyRequests, enqueue().Code)
+	assert.Equal(t, 1, server.Health(context.Background()).QueueDepth)
+}
+
+type presetMap map[string]*models.Pipeline
+
+func (p presetMap) Preset(name string) (*models.Pipeline, bool) {
+	pipeline, ok := p[name]
+	return pipeline, ok
+}
+
+func TestConvertRunsPresets(t *testing.T) {
+	server := newTestServer()
+	server.EnablePresets(presetMap{
+		"to-xml": {Steps: []models.ConversionStep{{From: "csv", To: "json"}, {From: "json", To: "xml"}}},
+	})
+
+	response := convert(server, "big-key", "preset=to-xml", "a\n1\n")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/xml", response.Header().Get("Content-Type"))
+
+	assert.Equal(t, http.StatusNotFound, convert(server, "big-key", "preset=missing", "a\n1\n").Code)
+	assert.Equal(t, http.StatusForbidden, convert(server, "small-key", "preset=to-xml", "a\n1\n").Code)
+}
+
+func adminRequest(server *Server, key, method, path, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set(AdminKeyHeader, key)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAdminReloadRequiresKey(t *testing.T) {
+	server := newTestServer()
+	reloads := 0
+	server.EnableAdmin("admin-secret", func() error {
+		reloads++
+		return nil
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(server, "big-key", http.MethodPost, "/admin/reload", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(server, "", http.MethodGet, "/admin/pool", "").Code)
+	assert.Equal(t, http.StatusOK, adminRequest(server, "admin-secret", http.MethodPost, "/admin/reload", "").Code)
+	assert.Equal(t, 1, reloads)
+}
+
+func TestAdminAdjustsTenantLimits(t *testing.T) {
+	server := newTestServer()
+	server.EnableAdmin("admin-secret", nil)
+
+	response := adminRequest(server, "admin-secret", http.MethodGet, "/admin/converters", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `"csv-json"`)
+
+	response = adminRequest(server, "admin-secret", http.MethodPatch, "/admin/tenants/small", `{"max_input_bytes": 1024, "max_concurrent": 1}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+	var report TenantReport
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &report))
+	assert.Equal(t, int64(1024), report.MaxInputBytes)
+	assert.Equal(t, 1, report.MaxConcurrent)
+	assert.Equal(t, DefaultTimeout, report.Timeout.Duration)
+	assert.Equal(t, int64(DefaultMaxUploads*1024), server.tenants["small"].limits().MaxUploadBytes, "the upload quota follows max_input_bytes")
+
+	assert.Equal(t, http.StatusOK, convert(server, "small-key", "from=csv&to=json", strings.Repeat("a", 64)).Code)
+	assert.Equal(t, http.StatusNotFound, adminRequest(server, "admin-secret", http.MethodPatch, "/admin/tenants/missing", `{}`).Code)
+
+	response = adminRequest(server, "admin-secret", http.MethodGet, "/admin/tenants", "")
+	assert.NotContains(t, response.Body.String(), "small-key")
+}
+
+func TestSetLimitsKeepsConfiguredUploadBytes(t *testing.T) {
+	tenant := newTenantState(Tenant{Name: "small", MaxInputBytes: 16, MaxUploadBytes: 40})
+	tenant.setLimits(TenantLimits{MaxInputBytes: 1024})
+
+	assert.Equal(t, int64(1024), tenant.limits().MaxInputBytes)
+	assert.Equal(t, int64(40), tenant.limits().MaxUploadBytes)
+}
+
+func TestAdminCancelsQueuedJob(t *testing.T) {
+	server := newTestServer()
+	queue := jobs.NewMemoryQueue(4)
+	server.EnableJobs(queue)
+	server.EnableAdmin("admin-secret", nil)
+
+	request := httptest.NewRequest(http.MethodPost, "/jobs?from=csv&to=json", strings.NewReader("a\n1\n"))
+	request.Header.Set(APIKeyHeader, "big-key")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	var enqueued jobResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &enqueued))
+
+	assert.Equal(t, http.StatusAccepted, adminRequest(server, "admin-secret", http.MethodPost, "/admin/jobs/"+enqueued.ID+"/cancel", "").Code)
+	cancelled, err := queue.Cancelled(context.Background(), enqueued.ID)
+	assert.NoError(t, err)
+	assert.True(t, cancelled)
+	assert.Equal(t, http.StatusNotFound, adminRequest(server, "admin-secret", http.MethodPost, "/admin/jobs/missing/cancel", "").Code)
+}
+
+func TestResumableUpload(t *testing.T) {
+	server := newTestServer()
+	assert.NoError(t, server.EnableUploads(t.TempDir()))
+
+	send := func(method, path, key string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set(APIKeyHeader, key)
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	input := "name,city\nann,oslo\nbob,rome"
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(http.MethodPost, "/uploads", "small-key", map[string]string{UploadLengthHeader: "64"}, "").Code)
+	created := send(http.MethodPost, "/uploads", "big-key", map[string]string{UploadLengthHeader: fmt.Sprint(len(input))}, "")
+	assert.Equal(t, http.StatusCreated, created.Code)
+	location := created.Header().Get("Location")
+
+	// The first chunk is cut short, as by a dropped connection; the bytes
+	// that arrived are kept.
+	response := send(http.MethodPut, location, "big-key", map[string]string{"Content-Range": "bytes 0-9/27"}, input[:6])
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, "6", response.Header().Get(UploadOffsetHeader))
+
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, location, "small-key", nil, "").Code)
+	assert.Equal(t, http.StatusConflict, send(http.MethodPost, "/convert?from=csv&to=json&upload="+strings.TrimPrefix(location, "/uploads/"), "big-key", nil, "").Code)
+	assert.Equal(t, http.StatusConflict, send(http.MethodPut, location, "big-key", map[string]string{"Content-Range": "bytes 0-5/27"}, input[:6]).Code)
+
+	response = send(http.MethodPut, location, "big-key", map[string]string{"Content-Range": "bytes 6-26/27"}, input[6:])
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"id":"`+strings.TrimPrefix(location, "/uploads/")+`","length":27,"offset":27,"complete":true}`, response.Body.String())
+
+	response = send(http.MethodPost, "/convert?from=csv&to=json&upload="+strings.TrimPrefix(location, "/uploads/"), "big-key", nil, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `[{"name":"ann","city":"oslo"},{"name":"bob","city":"rome"}]`, response.Body.String())
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, location, "big-key", nil, "").Code, "a converted upload is consumed")
+
+	created = send(http.MethodPost, "/uploads", "big-key", map[string]string{UploadLengthHeader: "1"}, "")
+	assert.Equal(t, http.StatusCreated, created.Code)
+	location = created.Header().Get("Location")
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, location, "big-key", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, location, "big-key", nil, "").Code)
+}
+
+func TestUploadsAreLimitedPerTenant(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "upload-left-by-an-earlier-run")
+	assert.NoError(t, os.WriteFile(stale, []byte("a,b"), 0600))
+	unrelated := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, os.WriteFile(unrelated, []byte("keep"), 0600))
+
+	pool := factory.NewConverterPool(1, factory.NewConverterFactory())
+	server := NewServer(factory.NewPipelineExecutor(pool), []Tenant{
+		{Name: "small", APIKey: "small-key", MaxInputBytes: 16, MaxUploads: 3, MaxUploadBytes: 40},
+		{Name: "big", APIKey: "big-key"},
+	})
+	assert.NoError(t, server.EnableUploads(dir))
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, unrelated)
+
+	create := func(key string, length int) int {
+		request := httptest.NewRequest(http.MethodPost, "/uploads", nil)
+		request.Header.Set(APIKeyHeader, key)
+		request.Header.Set(UploadLengthHeader, fmt.Sprint(length))
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, create("small-key", 16))
+	assert.Equal(t, http.StatusCreated, create("small-key", 16))
+	assert.Equal(t, http.StatusTooManyRequests, create("small-key", 16), "40 bytes in total")
+	assert.Equal(t, http.StatusCreated, create("small-key", 8))
+	assert.Equal(t, http.StatusTooManyRequests, create("small-key", 1), "3 uploads at most")
+	assert.Equal(t, http.StatusCreated, create("big-key", 1), "other tenants are not affected")
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, end, total, err := parseContentRange("bytes 10-19/*")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{10, 19, -1}, []int64{start, end, total})
+
+	for _, header := range []string{"", "bytes 5-1/10", "items 0-1/2", "bytes 0-1"} {
+		_, _, _, err := parseContentRange(header)
+		assert.Error(t, err, header)
+	}
+}
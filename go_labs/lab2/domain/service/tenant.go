@@ -0,0 +1,251 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"tmps-go-labs/lab2/domain/config"
+	"tmps-go-labs/lab2/domain/limits"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const (
+	DefaultMaxInputBytes = 10 << 20
+	DefaultTimeout       = 30 * time.Second
+	DefaultMaxConcurrent = 4
+	DefaultMaxUploads    = 4
+)
+
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+type Tenant struct {
+	Name           string              `json:"name"`
+	APIKey         string              `json:"api_key"`
+	MaxInputBytes  int64               `json:"max_input_bytes"`
+	Timeout        Duration            `json:"timeout"`
+	AllowedFormats []models.FileFormat `json:"allowed_formats"`
+	MaxConcurrent  int                 `json:"max_concurrent"`
+	// MaxUploads and MaxUploadBytes bound the resumable uploads a tenant
+	// keeps on the server at once, by count and by their summed
+	// Upload-Length.
+	MaxUploads     int   `json:"max_uploads"`
+	MaxUploadBytes int64 `json:"max_upload_bytes"`
+}
+
+type tenantConfig struct {
+	Tenants []Tenant `json:"tenants"`
+}
+
+// LoadTenants reads the tenants file, expanding references such as
+// "${ANALYTICS_API_KEY}" so API keys can stay out of the file. Every
+// string goes through config.Expand, so a key written literally in the
+// file must spell each $ as $$; values read from a reference are used
+// as they are.
+func LoadTenants(path string) ([]Tenant, error) {
+	var tenants tenantConfig
+	if err := config.Load(path, &tenants); err != nil {
+		return nil, fmt.Errorf("failed to load tenants file: %w", err)
+	}
+
+	for _, tenant := range tenants.Tenants {
+		if tenant.APIKey == "" {
+			return nil, fmt.Errorf("tenant %q has no api_key", tenant.Name)
+		}
+	}
+	if err := CheckTenants(tenants.Tenants); err != nil {
+		return nil, err
+	}
+	return tenants.Tenants, nil
+}
+
+// CheckTenants reports tenants that share a name or an API key, which
+// NewServer refuses.
+func CheckTenants(tenants []Tenant) error {
+	keys := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, tenant := range tenants {
+		if tenant.APIKey != "" && keys[tenant.APIKey] {
+			return fmt.Errorf("tenant %q reuses an api_key", tenant.Name)
+		}
+		if names[tenant.Name] {
+			return fmt.Errorf("tenant name %q is used twice", tenant.Name)
+		}
+		keys[tenant.APIKey] = true
+		names[tenant.Name] = true
+	}
+	return nil
+}
+
+// withDefaults fills unset quotas so a tenant entry only needs the limits it
+// wants to tighten or relax.
+func (t Tenant) withDefaults() Tenant {
+	if t.MaxInputBytes <= 0 {
+		t.MaxInputBytes = DefaultMaxInputBytes
+	}
+	if t.Timeout.Duration <= 0 {
+		t.Timeout.Duration = DefaultTimeout
+	}
+	if t.MaxConcurrent <= 0 {
+		t.MaxConcurrent = DefaultMaxConcurrent
+	}
+	if t.MaxUploads <= 0 {
+		t.MaxUploads = DefaultMaxUploads
+	}
+	if t.MaxUploadBytes <= 0 {
+		t.MaxUploadBytes = int64(t.MaxUploads) * t.MaxInputBytes
+	}
+	return t
+}
+
+func (t Tenant) allows(format models.FileFormat) bool {
+	if len(t.AllowedFormats) == 0 {
+		return true
+	}
+	for _, allowed := range t.AllowedFormats {
+		if allowed == format {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantLimits changes a tenant's quotas at runtime. Unset fields keep
+// their current value.
+type TenantLimits struct {
+	MaxInputBytes int64    `json:"max_input_bytes,omitempty"`
+	Timeout       Duration `json:"timeout"`
+	MaxConcurrent int      `json:"max_concurrent,omitempty"`
+}
+
+// tenantState tracks a tenant's conversions in flight. The quotas can be
+// changed by an admin while requests run, so they are read through limits.
+// Tenant holds the quotas in force: configured with the defaults filled in.
+type tenantState struct {
+	Tenant
+	configured Tenant
+	mu         sync.Mutex
+	inFlight   int
+}
+
+func newTenantState(tenant Tenant) *tenantState {
+	return &tenantState{Tenant: tenant.withDefaults(), configured: tenant}
+}
+
+func (t *tenantState) limits() Tenant {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Tenant
+}
+
+// setLimits applies limits to the configured quotas and derives the
+// defaults again, so a MaxUploadBytes left unset follows a new
+// MaxInputBytes. Lowering MaxConcurrent does not abort running
+// conversions; new ones are refused until enough have finished.
+func (t *tenantState) setLimits(limits TenantLimits) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if limits.MaxInputBytes > 0 {
+		t.configured.MaxInputBytes = limits.MaxInputBytes
+	}
+	if limits.Timeout.Duration > 0 {
+		t.configured.Timeout = limits.Timeout
+	}
+	if limits.MaxConcurrent > 0 {
+		t.configured.MaxConcurrent = limits.MaxConcurrent
+	}
+	t.Tenant = t.configured.withDefaults()
+}
+
+func (t *tenantState) load() TenantLoad {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return TenantLoad{InFlight: t.inFlight, MaxConcurrent: t.MaxConcurrent}
+}
+
+func (t *tenantState) tryAcquire() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.inFlight >= t.MaxConcurrent {
+		return false
+	}
+	t.inFlight++
+	return true
+}
+
+func (t *tenantState) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inFlight--
+}
+
+// serverMaxAliasDepth bounds YAML alias nesting for requests, whatever
+// their options ask for.
+const serverMaxAliasDepth = 8
+
+func sandboxYAML(options models.YAMLOptions) models.YAMLOptions {
+	if options.MaxAliasDepth == 0 || options.MaxAliasDepth > serverMaxAliasDepth {
+		options.MaxAliasDepth = serverMaxAliasDepth
+	}
+	return options
+}
+
+// sandboxOptions keeps only options that act on the request payload. Anything
+// that reads or writes server-side files or secrets is dropped, and XML
+// with a DTD stays rejected, YAML aliases nest at most serverMaxAliasDepth
+// deep and documents stay within limits.Server, as request bodies are
+// untrusted. Options that would break a conversion, such as a negative
+// indent width or limit, are rejected; the rest are checked with the
+// pipeline they belong to.
+func sandboxOptions(options models.ConversionOptions) (models.ConversionOptions, error) {
+	if err := options.CheckIndentWidth(); err != nil {
+		return models.ConversionOptions{}, err
+	}
+	if options.Limits.MaxDepth < 0 || options.Limits.MaxNodeCount < 0 {
+		return models.ConversionOptions{}, fmt.Errorf("document limits must not be negative")
+	}
+	xml := options.XML
+	xml.AllowDTD = false
+	return models.ConversionOptions{
+		Indent:            options.Indent,
+		PrettyPrint:       options.PrettyPrint,
+		IndentWidth:       options.IndentWidth,
+		CSVDelimiter:      options.CSVDelimiter,
+		CSVParser:         options.CSVParser,
+		Strategy:          options.Strategy,
+		XMLRoot:           options.XMLRoot,
+		XML:               xml,
+		YAML:              sandboxYAML(options.YAML),
+		Limits:            limits.Tightest(options.Limits, limits.Server),
+		Headers:           options.Headers,
+		Template:          options.Template,
+		FixedWidthColumns: options.FixedWidthColumns,
+		Geo:               options.Geo,
+		Sanitize:          options.Sanitize,
+		PostProcess:       options.PostProcess,
+	}, nil
+}
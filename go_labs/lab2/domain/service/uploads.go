@@ -0,0 +1,381 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tmps-go-labs/lab2/domain/jobs"
+)
+
+const (
+	UploadLengthHeader = "Upload-Length"
+	UploadOffsetHeader = "Upload-Offset"
+	DefaultUploadTTL   = 24 * time.Hour
+
+	// uploadFilePrefix starts the name of every upload file, so sweeping
+	// the directory leaves other files alone.
+	uploadFilePrefix = "upload-"
+)
+
+type uploadResponse struct {
+	ID       string `json:"id"`
+	Length   int64  `json:"length"`
+	Offset   int64  `json:"offset"`
+	Complete bool   `json:"complete"`
+}
+
+// upload is an input assembled from chunks in a file under the upload
+// directory. Its offset is the number of bytes received so far. Everything
+// that touches the file holds mu; removed marks an upload deleted while a
+// request waited for it.
+type upload struct {
+	mu      sync.Mutex
+	id      string
+	tenant  string
+	length  int64
+	offset  int64
+	path    string
+	updated time.Time
+	removed bool
+}
+
+func (u *upload) response() uploadResponse {
+	return uploadResponse{ID: u.id, Length: u.length, Offset: u.offset, Complete: u.offset == u.length}
+}
+
+type uploadStore struct {
+	dir     string
+	ttl     time.Duration
+	mu      sync.Mutex
+	uploads map[string]*upload
+}
+
+// EnableUploads adds resumable uploads for inputs too large to send in one
+// request. Chunks are written to upload files in dir: the ones an earlier
+// run left there cannot be resumed and are removed, while other files are
+// left alone. Uploads untouched for DefaultUploadTTL are removed too, and
+// each tenant keeps at most MaxUploads uploads of MaxUploadBytes in total.
+//
+//	POST   /uploads        start an upload of Upload-Length bytes
+//	PUT    /uploads/{id}   send the chunk given by Content-Range
+//	GET    /uploads/{id}   report the offset to resume from
+//	DELETE /uploads/{id}   discard the upload
+//
+// A complete upload is converted with /convert?upload={id} or
+// /jobs?upload={id} instead of a request body, which consumes it: it is
+// removed once read, so it no longer counts against the tenant's limits.
+func (s *Server) EnableUploads(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	if err := sweepUploads(dir); err != nil {
+		return err
+	}
+	s.uploads = &uploadStore{dir: dir, ttl: DefaultUploadTTL, uploads: make(map[string]*upload)}
+	s.handle("POST /uploads", s.handleCreateUpload)
+	s.handle("PUT /uploads/{id}", s.handleUploadChunk)
+	s.handle("GET /uploads/{id}", s.handleUploadStatus)
+	s.handle("DELETE /uploads/{id}", s.handleDeleteUpload)
+	return nil
+}
+
+func (s *Server) handleCreateUpload(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := s.authenticate(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or unknown API key"))
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get(UploadLengthHeader), 10, 64)
+	if err != nil || length <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%s must be a positive number of bytes", UploadLengthHeader))
+		return
+	}
+	if maxBytes := tenant.limits().MaxInputBytes; length > maxBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("input exceeds %d bytes", maxBytes))
+		return
+	}
+
+	s.uploads.expire()
+	upload := &upload{id: jobs.NewID(), tenant: tenant.Name, length: length, updated: time.Now()}
+	upload.path = filepath.Join(s.uploads.dir, uploadFilePrefix+upload.id)
+	if err := s.uploads.add(upload, tenant.limits()); err != nil {
+		writeError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	file, err := os.OpenFile(upload.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		s.uploads.remove(upload)
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to create upload: %w", err))
+		return
+	}
+	file.Close()
+
+	w.Header().Set("Location", "/uploads/"+upload.id)
+	w.Header().Set(UploadOffsetHeader, "0")
+	writeJSON(w, http.StatusCreated, upload.response())
+}
+
+// handleUploadChunk appends the chunk at the upload's offset. A chunk cut
+// off by a dropped connection keeps the bytes that arrived, and the client
+// resumes from the offset GET reports.
+func (s *Server) handleUploadChunk(w http.ResponseWriter, r *http.Request) {
+	upload, ok := s.lookupUpload(w, r)
+	if !ok {
+		return
+	}
+
+	start, end, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !lockUpload(w, upload) {
+		return
+	}
+	defer upload.mu.Unlock()
+
+	w.Header().Set(UploadOffsetHeader, strconv.FormatInt(upload.offset, 10))
+	if total >= 0 && total != upload.length {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("Content-Range total %d does not match the upload length %d", total, upload.length))
+		return
+	}
+	if start != upload.offset {
+		writeError(w, http.StatusConflict, fmt.Errorf("chunk starts at byte %d, expected %d", start, upload.offset))
+		return
+	}
+	if end >= upload.length {
+		writeError(w, http.StatusRequestedRangeNotSatisfiable, fmt.Errorf("chunk ends past the upload length %d", upload.length))
+		return
+	}
+
+	file, err := os.OpenFile(upload.path, os.O_WRONLY, 0600)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to open upload: %w", err))
+		return
+	}
+	defer file.Close()
+
+	want := end - start + 1
+	written, err := io.Copy(io.NewOffsetWriter(file, start), io.LimitReader(r.Body, want))
+	upload.offset += written
+	upload.updated = time.Now()
+	w.Header().Set(UploadOffsetHeader, strconv.FormatInt(upload.offset, 10))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("chunk interrupted after %d bytes: %w", written, err))
+		return
+	}
+	if written < want {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("chunk has %d bytes, Content-Range announced %d", written, want))
+		return
+	}
+	writeJSON(w, http.StatusOK, upload.response())
+}
+
+func (s *Server) handleUploadStatus(w http.ResponseWriter, r *http.Request) {
+	upload, ok := s.lookupUpload(w, r)
+	if !ok || !lockUpload(w, upload) {
+		return
+	}
+	defer upload.mu.Unlock()
+	w.Header().Set(UploadOffsetHeader, strconv.FormatInt(upload.offset, 10))
+	writeJSON(w, http.StatusOK, upload.response())
+}
+
+func (s *Server) handleDeleteUpload(w http.ResponseWriter, r *http.Request) {
+	upload, ok := s.lookupUpload(w, r)
+	if !ok || !lockUpload(w, upload) {
+		return
+	}
+	s.uploads.remove(upload)
+	upload.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lockUpload locks an upload found by id, failing with 404 when it was
+// removed while the request waited for the lock.
+func lockUpload(w http.ResponseWriter, upload *upload) bool {
+	upload.mu.Lock()
+	if upload.removed {
+		upload.mu.Unlock()
+		writeError(w, http.StatusNotFound, fmt.Errorf("upload not found"))
+		return false
+	}
+	return true
+}
+
+// lookupUpload only reveals uploads that belong to the caller's tenant.
+func (s *Server) lookupUpload(w http.ResponseWriter, r *http.Request) (*upload, bool) {
+	tenant, ok := s.authenticate(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or unknown API key"))
+		return nil, false
+	}
+	return s.findUpload(w, tenant, r.PathValue("id"))
+}
+
+func (s *Server) findUpload(w http.ResponseWriter, tenant *tenantState, id string) (*upload, bool) {
+	if s.uploads == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("uploads are not enabled"))
+		return nil, false
+	}
+	s.uploads.mu.Lock()
+	upload, ok := s.uploads.uploads[id]
+	s.uploads.mu.Unlock()
+	if !ok || upload.tenant != tenant.Name {
+		writeError(w, http.StatusNotFound, fmt.Errorf("upload not found"))
+		return nil, false
+	}
+	return upload, true
+}
+
+// readUpload loads a complete upload as the request input, reading no more
+// than the upload's length from its file, and removes the upload.
+func (s *Server) readUpload(w http.ResponseWriter, req *conversionRequest, id string) bool {
+	upload, ok := s.findUpload(w, req.tenant, id)
+	if !ok || !lockUpload(w, upload) {
+		return false
+	}
+	defer upload.mu.Unlock()
+	if upload.offset != upload.length {
+		writeError(w, http.StatusConflict, fmt.Errorf("upload is incomplete: %d of %d bytes received", upload.offset, upload.length))
+		return false
+	}
+	if maxBytes := req.tenant.limits().MaxInputBytes; upload.length > maxBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("input exceeds %d bytes", maxBytes))
+		return false
+	}
+
+	file, err := os.Open(upload.path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to read upload: %w", err))
+		return false
+	}
+	defer file.Close()
+	input := make([]byte, upload.length)
+	if _, err := io.ReadFull(file, input); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to read upload: %w", err))
+		return false
+	}
+	s.uploads.remove(upload)
+	req.input = input
+	return true
+}
+
+// add registers an upload unless the tenant already keeps as many uploads,
+// or as many bytes of them, as its limits allow.
+func (u *uploadStore) add(upload *upload, limits Tenant) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	count, total := 0, upload.length
+	for _, existing := range u.uploads {
+		if existing.tenant == upload.tenant {
+			count++
+			total += existing.length
+		}
+	}
+	if count >= limits.MaxUploads {
+		return fmt.Errorf("too many uploads for this API key: at most %d are kept", limits.MaxUploads)
+	}
+	if total > limits.MaxUploadBytes {
+		return fmt.Errorf("uploads for this API key would exceed %d bytes", limits.MaxUploadBytes)
+	}
+	u.uploads[upload.id] = upload
+	return nil
+}
+
+// remove deletes an upload and its file. The caller holds upload.mu, or
+// knows no request can reach the upload yet.
+func (u *uploadStore) remove(upload *upload) {
+	u.mu.Lock()
+	delete(u.uploads, upload.id)
+	u.mu.Unlock()
+	upload.removed = true
+	os.Remove(upload.path)
+}
+
+// expire removes uploads that have not been touched within the TTL.
+// Uploads busy with a request are left for the next call.
+func (u *uploadStore) expire() {
+	u.mu.Lock()
+	var stale []*upload
+	for _, upload := range u.uploads {
+		if upload.mu.TryLock() {
+			if time.Since(upload.updated) > u.ttl {
+				stale = append(stale, upload)
+				continue
+			}
+			upload.mu.Unlock()
+		}
+	}
+	u.mu.Unlock()
+
+	for _, upload := range stale {
+		u.remove(upload)
+		upload.mu.Unlock()
+	}
+}
+
+// sweepUploads removes the upload files an earlier run left in the upload
+// directory. Their offsets were kept in memory, so they cannot be resumed.
+func sweepUploads(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read upload directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), uploadFilePrefix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove stale upload: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseContentRange reads "bytes start-end/total", where total may be "*".
+// A total of -1 means it was not given.
+func parseContentRange(header string) (start, end, total int64, err error) {
+	invalid := fmt.Errorf("Content-Range must be \"bytes start-end/total\", got %q", header)
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+	span, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+	first, last, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, 0, invalid
+	}
+
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, 0, invalid
+	}
+	end, err = strconv.ParseInt(last, 10, 64)
+	if err != nil || start < 0 || end < start {
+		return 0, 0, 0, invalid
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, 0, invalid
+		}
+	}
+	return start, end, total, nil
+}
@@ -0,0 +1,133 @@
+// Package service exposes the conversion pipeline over HTTP. Every request
+// runs in its own option sandbox under the quotas of the tenant identified by
+// its API key, so one tenant's oversized input cannot starve the others.
+package service
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage is what one principal key has used since the server started.
+// Bytes are counted as sent on the wire, so gzipped responses count
+// compressed.
+type Usage struct {
+	Key      string    `json:"key"`
+	Tenant   string    `json:"tenant"`
+	Requests int64     `json:"requests"`
+	Errors   int64     `json:"errors"`
+	BytesIn  int64     `json:"bytes_in"`
+	BytesOut int64     `json:"bytes_out"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+type usageLedger struct {
+	mu   sync.Mutex
+	keys map[string]*Usage
+}
+
+func (l *usageLedger) record(principal Principal, bytesIn, bytesOut int64, status int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.keys == nil {
+		l.keys = make(map[string]*Usage)
+	}
+	usage, ok := l.keys[principal.Key]
+	if !ok {
+		usage = &Usage{Key: principal.Key, Tenant: principal.Tenant}
+		l.keys[principal.Key] = usage
+	}
+	usage.Requests++
+	if status >= 400 {
+		usage.Errors++
+	}
+	usage.BytesIn += bytesIn
+	usage.BytesOut += bytesOut
+	usage.LastUsed = time.Now().UTC()
+}
+
+// Usage returns the usage of every key, ordered by key.
+func (s *Server) Usage() []Usage {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+
+	usages := make([]Usage, 0, len(s.usage.keys))
+	for _, usage := range s.usage.keys {
+		usages = append(usages, *usage)
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Key < usages[j].Key })
+	return usages
+}
+
+// accounted reports whether requests to path are tenant work. Health,
+// API description and admin requests are not.
+func accounted(path string) bool {
+	for _, prefix := range []string{"/convert", "/jobs", "/uploads"} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// account serves an authenticated tenant request and records its usage.
+// Unauthenticated requests are left to the handlers, which refuse them.
+func (s *Server) account(w http.ResponseWriter, r *http.Request) {
+	principal, ok := s.principal(r)
+	if !ok {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+
+	r = r.WithContext(withPrincipal(r.Context(), principal))
+	body := &countingBody{ReadCloser: r.Body}
+	r.Body = body
+	response := &countingResponse{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(response, r)
+	s.usage.record(principal, body.n, response.n, response.status)
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+type countingResponse struct {
+	http.ResponseWriter
+	status int
+	n      int64
+}
+
+func (w *countingResponse) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *countingResponse) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Flush keeps streamed responses streaming through the accounting.
+func (w *countingResponse) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *countingResponse) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,243 @@
+// Package soak runs a pipeline over and over for a fixed time while
+// watching the process for leaks: live heap that keeps growing, goroutines
+// that outlive their runs and pooled converters that are never returned.
+// It is meant for validating a deployment before production traffic, not
+// for measuring throughput.
+package soak
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const (
+	DefaultInterval      = 10 * time.Second
+	DefaultMaxHeapGrowth = 0.5
+	// heapNoise is the live heap growth always tolerated, so small heaps
+	// do not fail on allocator noise.
+	heapNoise = 4 << 20
+	// settleTime bounds how long the final check waits for goroutines of
+	// the last runs to exit.
+	settleTime = time.Second
+)
+
+// Options configure a soak run. Zero values take the defaults.
+type Options struct {
+	Duration    time.Duration
+	Concurrency int
+	// Interval is the time between samples. The first sample, taken once
+	// pools and caches have warmed up, is the baseline for growth, which is
+	// measured at the last sample taken before the time was up.
+	Interval time.Duration
+	// MaxHeapGrowth is the tolerated growth of the live heap between the
+	// baseline and the end, as a fraction of the baseline.
+	MaxHeapGrowth float64
+	// OnSample, when set, is called with every sample as it is taken.
+	OnSample func(Sample)
+}
+
+// Sample is the state of the process at one point of the run. The heap is
+// measured right after a garbage collection, so it is the live heap.
+type Sample struct {
+	Elapsed    time.Duration `json:"elapsed"`
+	Runs       int64         `json:"runs"`
+	Failures   int64         `json:"failures"`
+	HeapAlloc  uint64        `json:"heap_alloc_bytes"`
+	Goroutines int           `json:"goroutines"`
+	CheckedOut int           `json:"converters_checked_out"`
+}
+
+// Report is the outcome of a soak run. Problems lists what failed the run;
+// it is empty when the run passed.
+type Report struct {
+	Samples    []Sample       `json:"samples"`
+	Runs       int64          `json:"runs"`
+	Failures   int64          `json:"failures"`
+	FirstError string         `json:"first_error,omitempty"`
+	HeapGrowth float64        `json:"heap_growth"`
+	Leaked     map[string]int `json:"leaked_converters,omitempty"`
+	Goroutines int            `json:"leaked_goroutines"`
+	Problems   []string       `json:"problems,omitempty"`
+}
+
+func (r *Report) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// Run converts input with pipeline on Concurrency goroutines until Duration
+// has passed or ctx is done, sampling every Interval, and then checks for
+// leaks. Runs in flight when the time is up are finished, not cancelled, so
+// cancellation does not show up as failures.
+func Run(ctx context.Context, executor factory.Executor, pool *factory.ConverterPool, pipeline *models.Pipeline, input []byte, options Options) (*Report, error) {
+	if options.Duration <= 0 {
+		return nil, fmt.Errorf("soak duration must be positive")
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	if options.MaxHeapGrowth <= 0 {
+		options.MaxHeapGrowth = DefaultMaxHeapGrowth
+	}
+
+	startGoroutines := runtime.NumGoroutine()
+	startCheckedOut := checkedOut(pool.Stats())
+
+	var (
+		runs, failures atomic.Int64
+		errOnce        sync.Once
+		firstError     string
+		wg             sync.WaitGroup
+	)
+	stop, cancel := context.WithTimeout(ctx, options.Duration)
+	defer cancel()
+	start := time.Now()
+
+	for i := 0; i < options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for stop.Err() == nil {
+				_, result := executor.ConvertData(ctx, pipeline, input)
+				runs.Add(1)
+				if result.Error != nil && ctx.Err() == nil {
+					failures.Add(1)
+					errOnce.Do(func() { firstError = result.Error.Error() })
+				}
+			}
+		}()
+	}
+
+	report := &Report{}
+	sample := func() Sample {
+		s := Sample{
+			Elapsed:    time.Since(start),
+			Runs:       runs.Load(),
+			Failures:   failures.Load(),
+			HeapAlloc:  liveHeap(),
+			Goroutines: runtime.NumGoroutine(),
+			CheckedOut: total(checkedOut(pool.Stats())),
+		}
+		report.Samples = append(report.Samples, s)
+		if options.OnSample != nil {
+			options.OnSample(s)
+		}
+		return s
+	}
+
+	ticker := time.NewTicker(options.Interval)
+sampling:
+	for {
+		select {
+		case <-stop.Done():
+			break sampling
+		case <-ticker.C:
+			sample()
+		}
+	}
+	ticker.Stop()
+	wg.Wait()
+
+	goroutines := settledGoroutines(startGoroutines)
+	final := sample()
+	report.Runs, report.Failures, report.FirstError = final.Runs, final.Failures, firstError
+	report.Goroutines = max(0, goroutines-startGoroutines)
+	report.Leaked = leaked(pool.Stats(), startCheckedOut)
+
+	// Growth compares samples taken while runs were in flight, so data held
+	// by the runs themselves does not count; a leak also shows in them.
+	baseline, last := report.Samples[0], report.Samples[max(0, len(report.Samples)-2)]
+	if baseline.HeapAlloc > 0 {
+		report.HeapGrowth = float64(last.HeapAlloc)/float64(baseline.HeapAlloc) - 1
+	}
+
+	if report.Failures > 0 {
+		report.Problems = append(report.Problems, fmt.Sprintf("%d of %d runs failed, first with: %s", report.Failures, report.Runs, report.FirstError))
+	}
+	if report.HeapGrowth > options.MaxHeapGrowth && last.HeapAlloc > baseline.HeapAlloc+heapNoise {
+		report.Problems = append(report.Problems, fmt.Sprintf("live heap grew %.0f%% (from %d to %d bytes), more than the %.0f%% allowed",
+			report.HeapGrowth*100, baseline.HeapAlloc, last.HeapAlloc, options.MaxHeapGrowth*100))
+	}
+	if report.Goroutines > 0 {
+		report.Problems = append(report.Problems, fmt.Sprintf("%d goroutines still running after the last run", report.Goroutines))
+	}
+	types := make([]string, 0, len(report.Leaked))
+	for converterType := range report.Leaked {
+		types = append(types, converterType)
+	}
+	sort.Strings(types)
+	for _, converterType := range types {
+		report.Problems = append(report.Problems, fmt.Sprintf("%d %s converters were never returned to the pool", report.Leaked[converterType], converterType))
+	}
+	return report, nil
+}
+
+func liveHeap() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// settledGoroutines waits up to settleTime for the goroutine count to drop
+// back to want and returns the last count.
+func settledGoroutines(want int) int {
+	deadline := time.Now().Add(settleTime)
+	for {
+		count := runtime.NumGoroutine()
+		if count <= want || time.Now().After(deadline) {
+			return count
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// checkedOut counts the converters of each type taken from the pool and
+// not yet returned.
+func checkedOut(stats factory.PoolStats) map[string]int {
+	out := make(map[string]int)
+	for converterType, created := range stats.Created {
+		if n := created - stats.Idle[converterType] + stats.Overflow[converterType]; n > 0 {
+			out[converterType] = n
+		}
+	}
+	for converterType, overflow := range stats.Overflow {
+		if _, counted := stats.Created[converterType]; !counted && overflow > 0 {
+			out[converterType] = overflow
+		}
+	}
+	return out
+}
+
+func total(counts map[string]int) int {
+	n := 0
+	for _, count := range counts {
+		n += count
+	}
+	return n
+}
+
+// leaked returns the converters checked out now beyond those checked out
+// before the run.
+func leaked(stats factory.PoolStats, before map[string]int) map[string]int {
+	var out map[string]int
+	for converterType, n := range checkedOut(stats) {
+		if n -= before[converterType]; n > 0 {
+			if out == nil {
+				out = make(map[string]int)
+			}
+			out[converterType] = n
+		}
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package soak
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func csvToJSON(t *testing.T) *models.Pipeline {
+	pipeline, err := factory.NewPipelineBuilder().
+		WithInputPath("people.csv").
+		WithOutputPath("people.json").
+		AddConversionStep(models.FormatCSV, models.FormatJSON).
+		Build()
+	require.NoError(t, err)
+	return pipeline
+}
+
+func TestSoakPassesAHealthyPipeline(t *testing.T) {
+	pool := factory.NewConverterPool(2, factory.NewConverterFactory())
+	executor := factory.NewPipelineExecutor(pool)
+
+	var samples []Sample
+	report, err := Run(context.Background(), executor, pool, csvToJSON(t), []byte("name,age\nAda,36\nAlan,41\n"), Options{
+		Duration:    200 * time.Millisecond,
+		Concurrency: 2,
+		Interval:    50 * time.Millisecond,
+		OnSample:    func(s Sample) { samples = append(samples, s) },
+	})
+	require.NoError(t, err)
+
+	assert.True(t, report.Passed(), report.Problems)
+	assert.Positive(t, report.Runs)
+	assert.Zero(t, report.Failures)
+	assert.Empty(t, report.Leaked)
+	assert.GreaterOrEqual(t, len(report.Samples), 2)
+	assert.Equal(t, report.Samples, samples)
+	assert.Equal(t, report.Runs, report.Samples[len(report.Samples)-1].Runs)
+}
+
+// leakyExecutor takes a converter for every run and never returns it, and
+// fails every run.
+type leakyExecutor struct {
+	pool *factory.ConverterPool
+}
+
+func (e *leakyExecutor) ExecuteContext(ctx context.Context, pipeline *models.Pipeline) *models.PipelineResult {
+	return &models.PipelineResult{}
+}
+
+func (e *leakyExecutor) ConvertData(ctx context.Context, pipeline *models.Pipeline, input []byte) ([]byte, *models.PipelineResult) {
+	if _, err := e.pool.Get("csv-json"); err != nil {
+		return nil, &models.PipelineResult{Error: err}
+	}
+	return nil, &models.PipelineResult{Error: assert.AnError}
+}
+
+func TestSoakReportsLeaksAndFailures(t *testing.T) {
+	pool := factory.NewConverterPool(3, factory.NewConverterFactory(), factory.FailWhenExhausted())
+
+	report, err := Run(context.Background(), &leakyExecutor{pool: pool}, pool, csvToJSON(t), nil, Options{
+		Duration: 50 * time.Millisecond,
+		Interval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, report.Passed())
+	assert.Equal(t, map[string]int{"csv-json": 3}, report.Leaked)
+	assert.Equal(t, report.Runs, report.Failures)
+	assert.Contains(t, report.Problems, "3 csv-json converters were never returned to the pool")
+	assert.Contains(t, report.Problems[0], "runs failed, first with: "+assert.AnError.Error())
+
+	_, err = Run(context.Background(), &leakyExecutor{pool: pool}, pool, csvToJSON(t), nil, Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+// Package split cuts record-oriented output into numbered parts of at most
+// a number of rows or bytes, for downstream systems that cap file sizes.
+// Every CSV part repeats the header row, so each part is a complete table.
+package split
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Supported reports whether output in format can be split.
+func Supported(format models.FileFormat) bool {
+	return format == models.FormatCSV || format == models.FormatNDJSON
+}
+
+// Split cuts data into parts of at most options.MaxRows records and
+// options.MaxBytes bytes, header included. A record larger than MaxBytes on
+// its own gets a part to itself rather than being cut. Data without records
+// gives a single part, holding only the CSV header.
+func Split(data []byte, format models.FileFormat, options models.SplitOptions) ([][]byte, error) {
+	if !Supported(format) {
+		return nil, fmt.Errorf("cannot split %s output; only csv and ndjson can be split", format)
+	}
+
+	rows := records(data, format)
+	var header []byte
+	if format == models.FormatCSV && len(rows) > 0 {
+		header, rows = rows[0], rows[1:]
+	}
+
+	var parts [][]byte
+	part, count := append([]byte(nil), header...), 0
+	for _, row := range rows {
+		full := (options.MaxRows > 0 && count >= options.MaxRows) ||
+			(options.MaxBytes > 0 && int64(len(part)+len(row)) > options.MaxBytes)
+		if count > 0 && full {
+			parts = append(parts, part)
+			part, count = append([]byte(nil), header...), 0
+		}
+		part = append(part, row...)
+		count++
+	}
+	return append(parts, part), nil
+}
+
+// records cuts data after each newline that ends a record. Newlines inside
+// quoted CSV fields do not end one; blank NDJSON lines are dropped.
+func records(data []byte, format models.FileFormat) [][]byte {
+	var out [][]byte
+	start, quoted := 0, false
+	for i, b := range data {
+		switch {
+		case b == '"' && format == models.FormatCSV:
+			quoted = !quoted
+		case b == '\n' && !quoted:
+			out = appendRecord(out, data[start:i+1], format)
+			start = i + 1
+		}
+	}
+	return appendRecord(out, data[start:], format)
+}
+
+func appendRecord(out [][]byte, record []byte, format models.FileFormat) [][]byte {
+	if len(record) == 0 || (format == models.FormatNDJSON && strings.TrimSpace(string(record)) == "") {
+		return out
+	}
+	if record[len(record)-1] != '\n' {
+		record = append(record[:len(record):len(record)], '\n')
+	}
+	return append(out, record)
+}
+
+// PartPath numbers outputPath for the 1-based part index, e.g. people.csv
+// -> people-0002.csv.
+func PartPath(outputPath string, index int) string {
+	ext := filepath.Ext(outputPath)
+	return fmt.Sprintf("%s-%04d%s", strings.TrimSuffix(outputPath, ext), index, ext)
+}
@@ -0,0 +1,52 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+func TestSplitRepeatsTheCSVHeader(t *testing.T) {
+	data := []byte("id,note\n1,a\n2,\"two\nlines\"\n3,c\n4,d\n5,e")
+	parts, err := Split(data, models.FormatCSV, models.SplitOptions{MaxRows: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"id,note\n1,a\n2,\"two\nlines\"\n",
+		"id,note\n3,c\n4,d\n",
+		"id,note\n5,e\n",
+	}, texts(parts))
+}
+
+func TestSplitByBytes(t *testing.T) {
+	data := []byte("{\"id\":1}\n\n{\"id\":2}\n{\"id\":3,\"note\":\"longer than the limit\"}\n{\"id\":4}\n")
+	parts, err := Split(data, models.FormatNDJSON, models.SplitOptions{MaxBytes: 20})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"{\"id\":1}\n{\"id\":2}\n",
+		"{\"id\":3,\"note\":\"longer than the limit\"}\n",
+		"{\"id\":4}\n",
+	}, texts(parts), "a record over the limit gets a part of its own")
+
+	parts, err = Split([]byte("id\n"), models.FormatCSV, models.SplitOptions{MaxRows: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id\n"}, texts(parts))
+
+	_, err = Split(data, models.FormatJSON, models.SplitOptions{MaxRows: 1})
+	assert.EqualError(t, err, "cannot split json output; only csv and ndjson can be split")
+}
+
+func TestPartPath(t *testing.T) {
+	assert.Equal(t, "out/people-0002.csv", PartPath("out/people.csv", 2))
+	assert.Equal(t, "people-0010", PartPath("people", 10))
+}
+
+func texts(parts [][]byte) []string {
+	out := make([]string, len(parts))
+	for i, part := range parts {
+		out[i] = string(part)
+	}
+	return out
+}
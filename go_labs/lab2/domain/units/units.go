@@ -0,0 +1,152 @@
+// Package units converts numeric values between units of the same kind:
+// data sizes, temperatures, lengths, masses, durations, and currencies
+// through a static rate table. Results can be rounded to a number of
+// decimals with a choice of rounding mode.
+package units
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rounding modes.
+const (
+	HalfUp   = "half-up"
+	HalfEven = "half-even"
+	Down     = "down"
+	Up       = "up"
+)
+
+// unit maps a value onto its kind's base unit as value*scale + offset.
+type unit struct {
+	kind   string
+	scale  float64
+	offset float64
+}
+
+var units = map[string]unit{
+	"B":   {kind: "data", scale: 1},
+	"KB":  {kind: "data", scale: 1e3},
+	"MB":  {kind: "data", scale: 1e6},
+	"GB":  {kind: "data", scale: 1e9},
+	"TB":  {kind: "data", scale: 1e12},
+	"KiB": {kind: "data", scale: 1 << 10},
+	"MiB": {kind: "data", scale: 1 << 20},
+	"GiB": {kind: "data", scale: 1 << 30},
+	"TiB": {kind: "data", scale: 1 << 40},
+
+	"C": {kind: "temperature", scale: 1, offset: 273.15},
+	"F": {kind: "temperature", scale: 5.0 / 9, offset: 273.15 - 32*5.0/9},
+	"K": {kind: "temperature", scale: 1},
+
+	"mm": {kind: "length", scale: 0.001},
+	"cm": {kind: "length", scale: 0.01},
+	"m":  {kind: "length", scale: 1},
+	"km": {kind: "length", scale: 1000},
+	"in": {kind: "length", scale: 0.0254},
+	"ft": {kind: "length", scale: 0.3048},
+	"mi": {kind: "length", scale: 1609.344},
+
+	"g":  {kind: "mass", scale: 1},
+	"kg": {kind: "mass", scale: 1000},
+	"oz": {kind: "mass", scale: 28.349523125},
+	"lb": {kind: "mass", scale: 453.59237},
+
+	"ms":  {kind: "time", scale: 0.001},
+	"s":   {kind: "time", scale: 1},
+	"min": {kind: "time", scale: 60},
+	"h":   {kind: "time", scale: 3600},
+	"d":   {kind: "time", scale: 86400},
+}
+
+// Units lists the unit names Converter accepts besides currency codes.
+func Units() []string {
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Converter converts values from one unit to another and rounds them.
+type Converter struct {
+	from, to unit
+	decimals int
+	round    func(float64) float64
+}
+
+// New returns a converter from one unit to another. Currency codes are
+// looked up in rates, which give the amount of each currency worth one
+// unit of a common base, e.g. {"EUR": 1, "USD": 1.08}. A negative decimals
+// leaves results unrounded.
+func New(from, to string, rates map[string]float64, decimals int, rounding string) (*Converter, error) {
+	fromUnit, err := lookup(from, rates)
+	if err != nil {
+		return nil, err
+	}
+	toUnit, err := lookup(to, rates)
+	if err != nil {
+		return nil, err
+	}
+	if fromUnit.kind != toUnit.kind {
+		return nil, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, fromUnit.kind, to, toUnit.kind)
+	}
+
+	c := &Converter{from: fromUnit, to: toUnit, decimals: decimals}
+	switch rounding {
+	case "", HalfUp:
+		c.round = math.Round
+	case HalfEven:
+		c.round = math.RoundToEven
+	case Down:
+		c.round = math.Trunc
+	case Up:
+		c.round = func(x float64) float64 {
+			if x < 0 {
+				return math.Floor(x)
+			}
+			return math.Ceil(x)
+		}
+	default:
+		return nil, fmt.Errorf("unknown rounding mode %q; use %s", rounding, strings.Join([]string{HalfUp, HalfEven, Down, Up}, ", "))
+	}
+	return c, nil
+}
+
+func lookup(name string, rates map[string]float64) (unit, error) {
+	if u, ok := units[name]; ok {
+		return u, nil
+	}
+	if rate, ok := rates[name]; ok {
+		if rate <= 0 {
+			return unit{}, fmt.Errorf("rate of %s must be positive", name)
+		}
+		return unit{kind: "currency", scale: 1 / rate}, nil
+	}
+	return unit{}, fmt.Errorf("unknown unit %q", name)
+}
+
+// Convert converts value and rounds the result. Rounding modes other than
+// half-even round halves away from zero; down and up round toward and away
+// from zero.
+func (c *Converter) Convert(value float64) float64 {
+	base := value*c.from.scale + c.from.offset
+	result := (base - c.to.offset) / c.to.scale
+	if c.decimals < 0 {
+		return result
+	}
+	shift := math.Pow(10, float64(c.decimals))
+	// Dropping the binary error beyond 15 significant digits first keeps
+	// values such as 2.675 (stored as 2.67499999...) rounding as written.
+	scaled, _ := strconv.ParseFloat(strconv.FormatFloat(result*shift, 'g', 15, 64), 64)
+	return c.round(scaled) / shift
+}
+
+// Decimals is the number of decimals results are rounded to, or -1.
+func (c *Converter) Decimals() int {
+	return c.decimals
+}
@@ -0,0 +1,47 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	rates := map[string]float64{"EUR": 1, "USD": 1.08}
+	tests := []struct {
+		from, to string
+		decimals int
+		rounding string
+		value    float64
+		want     float64
+	}{
+		{"B", "MB", 2, "", 1_234_567, 1.23},
+		{"MiB", "KiB", -1, "", 1.5, 1536},
+		{"C", "F", 1, "", 36.6, 97.9},
+		{"F", "C", 0, "", -40, -40},
+		{"K", "C", 2, "", 0, -273.15},
+		{"EUR", "USD", 2, "", 10, 10.8},
+		{"USD", "EUR", 2, Down, 10, 9.25},
+		{"m", "m", 2, "", 2.675, 2.68},
+		{"m", "m", 2, HalfEven, 2.665, 2.66},
+		{"m", "m", 0, Up, -1.2, -2},
+		{"m", "m", 0, Down, -1.8, -1},
+	}
+	for _, test := range tests {
+		converter, err := New(test.from, test.to, rates, test.decimals, test.rounding)
+		require.NoError(t, err)
+		assert.InDelta(t, test.want, converter.Convert(test.value), 1e-9, "%v %s to %s", test.value, test.from, test.to)
+	}
+}
+
+func TestNewRejectsBadConversions(t *testing.T) {
+	_, err := New("MB", "C", nil, 2, "")
+	assert.EqualError(t, err, "cannot convert MB (data) to C (temperature)")
+	_, err = New("EUR", "GBP", map[string]float64{"EUR": 1}, 2, "")
+	assert.EqualError(t, err, `unknown unit "GBP"`)
+	_, err = New("EUR", "USD", map[string]float64{"EUR": 1, "USD": 0}, 2, "")
+	assert.EqualError(t, err, "rate of USD must be positive")
+	_, err = New("B", "KB", nil, 2, "banker")
+	assert.ErrorContains(t, err, `unknown rounding mode "banker"`)
+}
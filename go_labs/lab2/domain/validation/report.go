@@ -0,0 +1,90 @@
+// Package validation checks records against a schema of rules before they
+// are converted and reports every violation with its record, rule ID and
+// severity, as JSON or JUnit XML, so CI jobs can fail on bad data.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/junit"
+)
+
+const (
+	ReportJSON  = "json"
+	ReportJUnit = "junit"
+)
+
+// ReportPath is where the report for outputPath is written, e.g.
+// people.yaml -> people.validation.json or people.validation.xml.
+func ReportPath(outputPath, format string) string {
+	extension := ".validation.json"
+	if format == ReportJUnit {
+		extension = ".validation.xml"
+	}
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + extension
+}
+
+// Write renders the report as JSON (the default) or JUnit XML.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "", ReportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case ReportJUnit:
+		return junit.Write(w, r.suite())
+	default:
+		return fmt.Errorf("unknown validation report format %q", format)
+	}
+}
+
+// suite turns every rule into a test case that fails when an error-severity
+// rule was violated. Warnings are listed in the case's output only.
+func (r *Report) suite() junit.TestSuite {
+	byRule := make(map[string][]Issue)
+	for _, issue := range r.Issues {
+		byRule[issue.RuleID] = append(byRule[issue.RuleID], issue)
+	}
+
+	name := "validation"
+	if r.Source != "" {
+		name += " " + r.Source
+	}
+
+	cases := make([]junit.TestCase, 0, len(r.Rules))
+	for _, rule := range r.Rules {
+		testCase := junit.TestCase{Name: rule.ID, ClassName: name}
+		if rule.Field != "" {
+			testCase.Name += " (" + rule.Field + ")"
+		}
+
+		issues := byRule[rule.ID]
+		if len(issues) > 0 {
+			var lines []string
+			for _, issue := range issues {
+				line := fmt.Sprintf("record %d", issue.Record)
+				if issue.Field != "" {
+					line += " " + issue.Field
+				}
+				lines = append(lines, line+": "+issue.Message)
+			}
+			text := strings.Join(lines, "\n")
+
+			if Severity(rule.Severity) == SeverityWarning {
+				testCase.SystemOut = text
+			} else {
+				testCase.Failure = &junit.Failure{
+					Message: fmt.Sprintf("%d of %d records violate %s", len(issues), r.Records, rule.ID),
+					Type:    rule.Severity,
+					Text:    text,
+				}
+			}
+		}
+		cases = append(cases, testCase)
+	}
+	return junit.NewSuite(name, cases)
+}
@@ -0,0 +1,198 @@
+// Package validation checks records against a schema of rules before they
+// are converted and reports every violation with its record, rule ID and
+// severity, as JSON or JUnit XML, so CI jobs can fail on bad data.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+
+	"tmps-go-labs/lab2/domain/expr"
+	"tmps-go-labs/lab2/domain/jsonschema"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+)
+
+// FailNever is the FailOn severity that only reports violations.
+const FailNever Severity = "none"
+
+// Rule is the rule of schema files and pipeline configs alike; see
+// models.ValidationRule. Constraints other than Required are skipped for
+// empty values, Min and Max are inclusive and Enum values are compared as
+// text. Severity defaults to error and ID to "rule-N".
+type Rule = models.ValidationRule
+
+type Schema struct {
+	Rules []Rule `json:"rules"`
+
+	// compiled holds Rules with their patterns and checks compiled.
+	compiled []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	pattern *regexp.Regexp
+	check   expr.Expr
+}
+
+// NewSchema compiles rules declared in a pipeline config.
+func NewSchema(rules []Rule) (*Schema, error) {
+	schema := &Schema{Rules: slices.Clone(rules)}
+	if err := schema.Compile(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// FromJSONSchema returns rules checking the top-level properties of s:
+// required fields, scalar types, bounds, patterns and enums. Nested
+// properties are not checked.
+func FromJSONSchema(s *jsonschema.Schema) *Schema {
+	fields := make([]string, 0, len(s.Properties))
+	for field := range s.Properties {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	schema := &Schema{}
+	for _, field := range fields {
+		property := s.Properties[field]
+		rule := Rule{
+			ID:       field,
+			Field:    field,
+			Required: slices.Contains(s.Required, field),
+			Min:      property.Minimum,
+			Max:      property.Maximum,
+			Pattern:  property.Pattern,
+		}
+		if types := property.Type.NonNull(); len(types) == 1 {
+			switch types[0] {
+			case TypeString, TypeNumber, TypeInteger, TypeBoolean:
+				rule.Type = types[0]
+			}
+		}
+		for _, value := range property.Enum {
+			if value != nil {
+				rule.Enum = append(rule.Enum, fmt.Sprint(value))
+			}
+		}
+		if rule.Required || rule.Type != "" || rule.Min != nil || rule.Max != nil || rule.Pattern != "" || len(rule.Enum) > 0 {
+			schema.Rules = append(schema.Rules, rule)
+		}
+	}
+	return schema
+}
+
+// FailOn parses the lowest severity that fails a run, SeverityError when
+// empty.
+func FailOn(severity string) (Severity, error) {
+	switch Severity(severity) {
+	case "":
+		return SeverityError, nil
+	case SeverityError, SeverityWarning, FailNever:
+		return Severity(severity), nil
+	}
+	return "", fmt.Errorf("unknown validation fail-on severity %q; use %s, %s or %s", severity, SeverityError, SeverityWarning, FailNever)
+}
+
+// LoadSchema reads and compiles a schema file of rules, or a JSON Schema,
+// told apart by its $schema or properties keyword.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validation schema: %w", err)
+	}
+
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return nil, fmt.Errorf("failed to parse validation schema %s: %w", path, err)
+	}
+	var schema Schema
+	if _, ok := keywords["rules"]; !ok && (keywords["$schema"] != nil || keywords["properties"] != nil) {
+		source, err := jsonschema.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("validation schema %s: %w", path, err)
+		}
+		schema = *FromJSONSchema(source)
+	} else if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse validation schema %s: %w", path, err)
+	}
+	if err := schema.Compile(); err != nil {
+		return nil, fmt.Errorf("validation schema %s: %w", path, err)
+	}
+	return &schema, nil
+}
+
+// Compile fills in defaults and compiles patterns and checks. Schemas built
+// in code must be compiled before use; LoadSchema does it already.
+func (s *Schema) Compile() error {
+	s.compiled = nil
+	compiled := make([]compiledRule, len(s.Rules))
+	for i := range s.Rules {
+		rule := &s.Rules[i]
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("rule-%d", i+1)
+		}
+		if rule.Severity == "" {
+			rule.Severity = string(SeverityError)
+		}
+
+		switch Severity(rule.Severity) {
+		case SeverityError, SeverityWarning:
+		default:
+			return fmt.Errorf("rule %s: unknown severity %q", rule.ID, rule.Severity)
+		}
+
+		switch rule.Type {
+		case "", TypeString, TypeNumber, TypeInteger, TypeBoolean:
+		default:
+			return fmt.Errorf("rule %s: unknown type %q", rule.ID, rule.Type)
+		}
+
+		if rule.Field == "" && rule.Check == "" {
+			return fmt.Errorf("rule %s: needs a field or a check", rule.ID)
+		}
+		if rule.Field == "" && (rule.Required || rule.Type != "" || rule.Min != nil || rule.Max != nil || rule.Pattern != "" || len(rule.Enum) > 0) {
+			return fmt.Errorf("rule %s: field constraints need a field", rule.ID)
+		}
+		if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+			return fmt.Errorf("rule %s: min %v is above max %v", rule.ID, *rule.Min, *rule.Max)
+		}
+
+		compiled[i].Rule = *rule
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("rule %s: invalid pattern: %w", rule.ID, err)
+			}
+			compiled[i].pattern = pattern
+		}
+
+		if rule.Check != "" {
+			check, err := expr.Parse(rule.Check)
+			if err != nil {
+				return fmt.Errorf("rule %s: invalid check: %w", rule.ID, err)
+			}
+			compiled[i].check = check
+		}
+	}
+	s.compiled = compiled
+	return nil
+}
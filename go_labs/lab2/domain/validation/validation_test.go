@@ -0,0 +1,154 @@
+package validation
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/records"
+)
+
+const people = "name,email,age\nann,ann@example.com,34\n,bob-at-example,thirty\ncid,,200\n"
+
+func loadTestSchema(t *testing.T) *Schema {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [
+		{"id": "name-required", "field": "name", "required": true},
+		{"id": "email-format", "field": "email", "pattern": "^[^@]+@[^@]+$"},
+		{"id": "age-integer", "field": "age", "type": "integer"},
+		{"id": "age-plausible", "check": "age < 130", "severity": "warning", "message": "age looks implausible"}
+	]}`), 0644))
+
+	schema, err := LoadSchema(path)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestValidateReportsIssuesPerRecord(t *testing.T) {
+	report, err := Validate(records.NewCSVIterator(strings.NewReader(people), ','), loadTestSchema(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Records)
+	assert.Equal(t, 3, report.Errors)
+	assert.Equal(t, 2, report.Warnings)
+	assert.True(t, report.Failed())
+	assert.Equal(t, []Issue{
+		{Record: 2, RuleID: "name-required", Field: "name", Severity: SeverityError, Message: "is required"},
+		{Record: 2, RuleID: "email-format", Field: "email", Severity: SeverityError, Message: `"bob-at-example" does not match ^[^@]+@[^@]+$`},
+		{Record: 2, RuleID: "age-integer", Field: "age", Severity: SeverityError, Message: `"thirty" is not of type integer`},
+		{Record: 2, RuleID: "age-plausible", Severity: SeverityWarning, Message: "age looks implausible"},
+		{Record: 3, RuleID: "age-plausible", Severity: SeverityWarning, Message: "age looks implausible"},
+	}, report.Issues)
+}
+
+func TestReportAsJUnit(t *testing.T) {
+	report, err := Validate(records.NewCSVIterator(strings.NewReader(people), ','), loadTestSchema(t))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out, ReportJUnit))
+	xml := out.String()
+
+	assert.Contains(t, xml, `<testsuite name="validation" tests="4" failures="3" skipped="0" time="0">`)
+	assert.Contains(t, xml, `<failure message="1 of 3 records violate name-required" type="error">record 2 name: is required</failure>`)
+	assert.Contains(t, xml, "<system-out>record 2: age looks implausible&#xA;record 3: age looks implausible</system-out>")
+}
+
+func TestCompileRejectsBadRules(t *testing.T) {
+	schema := &Schema{Rules: []Rule{{Field: "age", Type: "date"}}}
+	assert.EqualError(t, schema.Compile(), `rule rule-1: unknown type "date"`)
+
+	schema = &Schema{Rules: []Rule{{ID: "x", Check: "age >"}}}
+	assert.ErrorContains(t, schema.Compile(), "rule x: invalid check")
+}
+
+func TestRangeAndEnumRules(t *testing.T) {
+	low, high := 0.0, 130.0
+	schema, err := NewSchema([]models.ValidationRule{
+		{ID: "age-range", Field: "age", Min: &low, Max: &high},
+		{ID: "status-known", Field: "status", Enum: []string{"active", "inactive"}, Severity: "warning"},
+	})
+	require.NoError(t, err)
+
+	input := "age,status\n34,active\n200,retired\nold,\n"
+	report, err := Validate(records.NewCSVIterator(strings.NewReader(input), ','), schema)
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{
+		{Record: 2, RuleID: "age-range", Field: "age", Severity: SeverityError, Message: "200 is above the maximum of 130"},
+		{Record: 2, RuleID: "status-known", Field: "status", Severity: SeverityWarning, Message: `"retired" is not one of active, inactive`},
+		{Record: 3, RuleID: "age-range", Field: "age", Severity: SeverityError, Message: `"old" is not a number`},
+	}, report.Issues)
+
+	assert.True(t, report.FailedAt(SeverityError))
+	assert.False(t, report.FailedAt(FailNever))
+	report.Errors = 0
+	assert.False(t, report.FailedAt(SeverityError))
+	assert.True(t, report.FailedAt(SeverityWarning), "warnings fail the run when failing on warnings")
+
+	_, err = NewSchema([]models.ValidationRule{{ID: "x", Field: "age", Min: &high, Max: &low}})
+	assert.EqualError(t, err, "rule x: min 130 is above max 0")
+	_, err = FailOn("fatal")
+	assert.ErrorContains(t, err, `unknown validation fail-on severity "fatal"`)
+}
+
+func TestBoundsRejectNaNAndInfinity(t *testing.T) {
+	low, high := 0.0, 130.0
+	schema, err := NewSchema([]Rule{{ID: "age-range", Field: "age", Min: &low, Max: &high}})
+	require.NoError(t, err)
+
+	input := "age\nNaN\n+Inf\n34\n"
+	report, err := Validate(records.NewCSVIterator(strings.NewReader(input), ','), schema)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Errors)
+	assert.Equal(t, `"NaN" is not a number`, report.Issues[0].Message)
+}
+
+func TestCheckTreatsLosslessZeroAsFalse(t *testing.T) {
+	schema, err := NewSchema([]Rule{{ID: "count-set", Check: "count"}})
+	require.NoError(t, err)
+
+	input := `{"count": 0}` + "\n" + `{"count": 0.0}` + "\n" + `{"count": 2}` + "\n"
+	iterator, err := records.NewIterator(strings.NewReader(input), models.FormatNDJSON, models.NewOptions())
+	require.NoError(t, err)
+	report, err := Validate(iterator, schema)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Errors)
+}
+
+func TestValidateNeedsACompiledSchema(t *testing.T) {
+	schema := &Schema{Rules: []Rule{{Field: "age", Required: true}}}
+	_, err := Validate(records.NewCSVIterator(strings.NewReader("age\n1\n"), ','), schema)
+	assert.Error(t, err)
+}
+
+func TestLoadJSONSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "people.schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string"},
+			"email": {"type": ["string", "null"], "pattern": "^[^@]+@[^@]+$"},
+			"age": {"type": "integer", "maximum": 150},
+			"address": {"type": "object"}
+		}
+	}`), 0644))
+
+	schema, err := LoadSchema(path)
+	require.NoError(t, err)
+	report, err := Validate(records.NewCSVIterator(strings.NewReader(people), ','), schema)
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{
+		{Record: 2, RuleID: "age", Field: "age", Severity: SeverityError, Message: `"thirty" is not of type integer`},
+		{Record: 2, RuleID: "email", Field: "email", Severity: SeverityError, Message: `"bob-at-example" does not match ^[^@]+@[^@]+$`},
+		{Record: 2, RuleID: "name", Field: "name", Severity: SeverityError, Message: "is required"},
+		{Record: 3, RuleID: "age", Field: "age", Severity: SeverityError, Message: "200 is above the maximum of 150"},
+	}, report.Issues)
+}
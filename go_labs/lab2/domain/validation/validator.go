@@ -0,0 +1,185 @@
+// Package validation checks records against a schema of rules before they
+// are converted and reports every violation with its record, rule ID and
+// severity, as JSON or JUnit XML, so CI jobs can fail on bad data.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/expr"
+	"tmps-go-labs/lab2/domain/records"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+// ErrFailed is wrapped by pipeline errors when the input broke an
+// error-severity rule.
+var ErrFailed = errors.New("validation failed")
+
+var errNotCompiled = errors.New("validation schema is not compiled")
+
+// Issue is one rule violated by one record. Record is 1-based.
+type Issue struct {
+	Record   int      `json:"record"`
+	RuleID   string   `json:"rule_id"`
+	Field    string   `json:"field,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+type Report struct {
+	Source   string  `json:"source,omitempty"`
+	Records  int     `json:"records"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	Rules    []Rule  `json:"rules"`
+	Issues   []Issue `json:"issues"`
+}
+
+// Failed reports whether any error-severity rule was violated.
+func (r *Report) Failed() bool {
+	return r.Errors > 0
+}
+
+// FailedAt reports whether any rule of severity or above was violated;
+// never for FailNever.
+func (r *Report) FailedAt(severity Severity) bool {
+	switch severity {
+	case FailNever:
+		return false
+	case SeverityWarning:
+		return r.Errors+r.Warnings > 0
+	}
+	return r.Failed()
+}
+
+// Validate checks every record of it against the compiled schema.
+func Validate(it records.RecordIterator, schema *Schema) (*Report, error) {
+	report := &Report{Rules: schema.Rules, Issues: []Issue{}}
+	if len(schema.compiled) != len(schema.Rules) {
+		return report, errNotCompiled
+	}
+	for {
+		record, err := it.Next()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+
+		report.Records++
+		for _, rule := range schema.compiled {
+			if message := rule.violation(record); message != "" {
+				if rule.Message != "" {
+					message = rule.Message
+				}
+				report.add(Issue{
+					Record:   report.Records,
+					RuleID:   rule.ID,
+					Field:    rule.Field,
+					Severity: Severity(rule.Severity),
+					Message:  message,
+				})
+			}
+		}
+	}
+}
+
+func (r *Report) add(issue Issue) {
+	r.Issues = append(r.Issues, issue)
+	if issue.Severity == SeverityWarning {
+		r.Warnings++
+	} else {
+		r.Errors++
+	}
+}
+
+// violation describes why record breaks the rule, or returns "".
+func (rule compiledRule) violation(record records.Record) string {
+	if rule.Field != "" {
+		value, present := record[rule.Field]
+		if !present || value == nil || value == "" {
+			if rule.Required {
+				return "is required"
+			}
+			return ""
+		}
+
+		if rule.Type != "" && !hasType(value, rule.Type) {
+			return fmt.Sprintf("%q is not of type %s", fmt.Sprint(value), rule.Type)
+		}
+		if rule.Min != nil || rule.Max != nil {
+			number, ok := toNumber(value)
+			switch {
+			case !ok:
+				return fmt.Sprintf("%q is not a number", fmt.Sprint(value))
+			case rule.Min != nil && number < *rule.Min:
+				return fmt.Sprintf("%v is below the minimum of %v", number, *rule.Min)
+			case rule.Max != nil && number > *rule.Max:
+				return fmt.Sprintf("%v is above the maximum of %v", number, *rule.Max)
+			}
+		}
+		if rule.pattern != nil && !rule.pattern.MatchString(fmt.Sprint(value)) {
+			return fmt.Sprintf("%q does not match %s", fmt.Sprint(value), rule.Pattern)
+		}
+		if len(rule.Enum) > 0 && !slices.Contains(rule.Enum, fmt.Sprint(value)) {
+			return fmt.Sprintf("%q is not one of %s", fmt.Sprint(value), strings.Join(rule.Enum, ", "))
+		}
+	}
+
+	if rule.check != nil {
+		result, err := rule.check.Eval(record)
+		if err != nil {
+			return fmt.Sprintf("check %s: %v", rule.Check, err)
+		}
+		if !expr.Truthy(result) {
+			return fmt.Sprintf("check %s failed", rule.Check)
+		}
+	}
+	return ""
+}
+
+// hasType accepts strings spelling the type, since CSV and XML fields
+// arrive as strings.
+func hasType(value interface{}, kind string) bool {
+	switch kind {
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeBoolean:
+		switch typed := value.(type) {
+		case bool:
+			return true
+		case string:
+			_, err := strconv.ParseBool(strings.TrimSpace(typed))
+			return err == nil
+		}
+		return false
+	default:
+		number, ok := toNumber(value)
+		if !ok {
+			return false
+		}
+		return kind == TypeNumber || number == math.Trunc(number)
+	}
+}
+
+// toNumber rejects NaN and infinities, which compare false with any bound
+// and would slip past Min and Max.
+func toNumber(value interface{}) (float64, bool) {
+	number, ok := values.Float(value)
+	if typed, isString := value.(string); !ok && isString {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		number, ok = parsed, err == nil
+	}
+	if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
@@ -0,0 +1,231 @@
+// Package values defines the scalars of the document model beyond the
+// float64, string and bool of encoding/json: exact decimals, timestamps
+// that keep the text they were written as, and the int64 and uint64 that
+// JSON and YAML numbers read as, unless FloatNumbers is set. Each encodes
+// as the closest native type of every text output format; binary formats
+// such as Avro, Parquet and protobuf are not supported.
+package values
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decimal is a number kept as its decimal literal, so values such as
+// 0.1000000000000000055 or 1e400 that a float64 would round or overflow
+// survive a round trip. JSON and YAML write it as a bare number.
+type Decimal string
+
+var decimalPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// ParseDecimal returns text as a Decimal in JSON number syntax. It also
+// accepts YAML spellings: a leading +, digit-group underscores, and a
+// missing digit before or after the point.
+func ParseDecimal(text string) (Decimal, bool) {
+	text = strings.ReplaceAll(strings.TrimPrefix(text, "+"), "_", "")
+	sign := ""
+	if rest, ok := strings.CutPrefix(text, "-"); ok {
+		sign, text = "-", rest
+	}
+	if strings.HasPrefix(text, ".") {
+		text = "0" + text
+	}
+	if mantissa, exponent, _ := strings.Cut(text, "e"); strings.HasSuffix(mantissa, ".") {
+		text = mantissa + "0"
+		if exponent != "" {
+			text += "e" + exponent
+		}
+	}
+	for len(text) > 1 && text[0] == '0' && text[1] != '.' && text[1] != 'e' && text[1] != 'E' {
+		text = text[1:]
+	}
+	if !decimalPattern.MatchString(text) {
+		return "", false
+	}
+	return Decimal(sign + text), true
+}
+
+func (d Decimal) String() string {
+	return string(d)
+}
+
+// Float64 returns d rounded to the nearest float64.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(string(d), 64)
+	return f
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d), nil
+}
+
+func (d Decimal) MarshalYAML() (interface{}, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: string(d)}, nil
+}
+
+// Timestamp is a point in time together with the text it was read from,
+// which it is written back as. JSON has no timestamp type and writes it as
+// a string; YAML tags it !!timestamp.
+type Timestamp struct {
+	Time time.Time
+	Text string
+}
+
+func (t Timestamp) String() string {
+	if t.Text != "" {
+		return t.Text
+	}
+	return t.Time.Format(time.RFC3339Nano)
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t Timestamp) MarshalYAML() (interface{}, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: t.String()}, nil
+}
+
+// Number returns n as an int64 when it is an integer that fits, then as a
+// uint64, and otherwise as a Decimal.
+func Number(n json.Number) interface{} {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u
+	}
+	return Decimal(n)
+}
+
+// Numbers replaces every json.Number in v, as decoded with UseNumber, by
+// its Number, in place.
+func Numbers(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case json.Number:
+		return Number(typed)
+	case map[string]interface{}:
+		for key, child := range typed {
+			typed[key] = Numbers(child)
+		}
+	case []interface{}:
+		for i, child := range typed {
+			typed[i] = Numbers(child)
+		}
+	}
+	return v
+}
+
+// UnmarshalJSON is json.Unmarshal into an interface{}, reading numbers
+// as their Number when lossless is set.
+func UnmarshalJSON(data []byte, lossless bool) (interface{}, error) {
+	var v interface{}
+	if !lossless {
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid data after top-level value")
+	}
+	return Numbers(v), nil
+}
+
+// Float returns the value of a number of any of the model's types.
+func Float(v interface{}) (float64, bool) {
+	switch typed := v.(type) {
+	case float64:
+		return typed, true
+	case int:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case uint64:
+		return float64(typed), true
+	case Decimal:
+		return typed.Float64(), true
+	}
+	return 0, false
+}
+
+// Text returns how text formats such as CSV and XML write the scalars of
+// this package and time.Time: integers in full, decimals as written, and
+// timestamps as written or else in RFC 3339. It reports false for other
+// values.
+func Text(v interface{}) (string, bool) {
+	switch typed := v.(type) {
+	case int64:
+		return strconv.FormatInt(typed, 10), true
+	case uint64:
+		return strconv.FormatUint(typed, 10), true
+	case Decimal:
+		return string(typed), true
+	case Timestamp:
+		return typed.String(), true
+	case time.Time:
+		return typed.Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+// Textual returns v with every value Text formats replaced by its text,
+// copying only the maps and lists that change.
+func Textual(v interface{}) interface{} {
+	out, _ := textual(v)
+	return out
+}
+
+func textual(v interface{}) (interface{}, bool) {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for key, child := range typed {
+			converted, changed := textual(child)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]interface{}, len(typed))
+				for k, c := range typed {
+					out[k] = c
+				}
+			}
+			out[key] = converted
+		}
+		if out != nil {
+			return out, true
+		}
+	case []interface{}:
+		var out []interface{}
+		for i, child := range typed {
+			converted, changed := textual(child)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]interface{}(nil), typed...)
+			}
+			out[i] = converted
+		}
+		if out != nil {
+			return out, true
+		}
+	default:
+		if text, ok := Text(v); ok {
+			return text, true
+		}
+	}
+	return v, false
+}
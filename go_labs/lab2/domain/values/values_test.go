@@ -0,0 +1,64 @@
+package values
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for text, want := range map[string]Decimal{
+		"1.50":      "1.50",
+		"+1_000.25": "1000.25",
+		"-.5":       "-0.5",
+		"2.":        "2.0",
+		"007":       "7",
+		"6.02e23":   "6.02e23",
+	} {
+		got, ok := ParseDecimal(text)
+		assert.True(t, ok, text)
+		assert.Equal(t, want, got, text)
+	}
+	for _, text := range []string{".inf", ".nan", "1.2.3", "0x1F", ""} {
+		_, ok := ParseDecimal(text)
+		assert.False(t, ok, text)
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	v, err := UnmarshalJSON([]byte(`{"id": 9007199254740993, "max": 18446744073709551615, "price": 0.10000000000000000001, "n": [1]}`), true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":    int64(9007199254740993),
+		"max":   uint64(18446744073709551615),
+		"price": Decimal("0.10000000000000000001"),
+		"n":     []interface{}{int64(1)},
+	}, v)
+
+	_, err = UnmarshalJSON([]byte(`{} {}`), true)
+	assert.Error(t, err)
+}
+
+func TestEncodings(t *testing.T) {
+	stamp := Timestamp{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Text: "2024-01-02"}
+	v := map[string]interface{}{"price": Decimal("1.50"), "day": stamp}
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"day": "2024-01-02", "price": 1.50}`, string(data))
+	assert.Contains(t, string(data), "1.50")
+
+	data, err = yaml.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, "day: 2024-01-02\nprice: 1.50\n", string(data))
+
+	assert.Equal(t, map[string]interface{}{"day": "2024-01-02", "price": "1.50"}, Textual(v))
+	assert.Equal(t, stamp, v["day"], "Textual copies changed maps")
+
+	plain := map[string]interface{}{"a": []interface{}{"x", 1.5}}
+	assert.Equal(t, plain, Textual(plain))
+}
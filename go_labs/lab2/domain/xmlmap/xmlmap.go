@@ -0,0 +1,265 @@
+// Package xmlmap reads XML documents into maps the way mxj does: attributes
+// become "-name" keys, an element's text "#text", and repeated elements
+// lists. With XMLOptions set it uses its own reader, which keeps every text
+// run of mixed content and can tell CDATA sections from other text.
+package xmlmap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/clbanning/mxj/v2"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Keys of the values that are not child elements.
+const (
+	TextKey    = "#text"
+	CDATAKey   = "#cdata"
+	ContentKey = "#content"
+	AttrPrefix = "-"
+)
+
+var (
+	// ErrDTD is returned for documents with a document type declaration
+	// when options.AllowDTD is not set.
+	ErrDTD = errors.New("XML document type declarations are not allowed")
+	// ErrExternalEntity is returned for documents referring to an external
+	// DTD or declaring an external entity, allowed or not.
+	ErrExternalEntity = errors.New("XML external entities are not allowed")
+)
+
+var (
+	externalDTD    = regexp.MustCompile(`^DOCTYPE\s+\S+\s+(SYSTEM|PUBLIC)\b`)
+	externalEntity = regexp.MustCompile(`<!ENTITY\s+(%\s+)?\S+\s+(SYSTEM|PUBLIC)\b`)
+)
+
+// Parse reads the XML document in data into a map holding its root element.
+// Documents with a DTD are rejected unless options.AllowDTD is set, and
+// external DTDs and entities always are. Internal entities are never
+// expanded either way.
+func Parse(data []byte, options models.XMLOptions) (map[string]interface{}, error) {
+	if err := checkProlog(data, options.AllowDTD); err != nil {
+		return nil, err
+	}
+	if options.CDATA == "" && options.MixedContent == "" {
+		mv, err := mxj.NewMapXml(data)
+		if err != nil {
+			return nil, err
+		}
+		return mv.Old(), nil
+	}
+
+	p := &parser{decoder: xml.NewDecoder(bytes.NewReader(data)), data: data, options: options}
+	for {
+		offset := p.decoder.InputOffset()
+		token, err := p.decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("no root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := p.element(start, offset)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{name(start.Name): value}, nil
+		}
+	}
+}
+
+// checkProlog looks for document type declarations before the root
+// element, where XML allows them.
+func checkProlog(data []byte, allowDTD bool) error {
+	if !bytes.Contains(data, []byte("<!")) {
+		return nil
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			// Syntax errors are left to the parser, which reports them the
+			// same way with or without a DTD.
+			return nil
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			return nil
+		case xml.Directive:
+			directive := bytes.TrimSpace(token)
+			if !bytes.HasPrefix(directive, []byte("DOCTYPE")) {
+				continue
+			}
+			line := 1 + bytes.Count(data[:offset], []byte("\n"))
+			if externalDTD.Match(directive) || externalEntity.Match(directive) {
+				return fmt.Errorf("line %d: %w", line, ErrExternalEntity)
+			}
+			if !allowDTD {
+				return fmt.Errorf("line %d: %w", line, ErrDTD)
+			}
+		}
+	}
+}
+
+type parser struct {
+	decoder *xml.Decoder
+	data    []byte
+	options models.XMLOptions
+}
+
+// run is a stretch of text between child elements; CDATA sections and
+// other text are kept apart when CDATA is preserved.
+type run struct {
+	text  strings.Builder
+	cdata bool
+}
+
+// element reads the content of start up to its end element.
+func (p *parser) element(start xml.StartElement, offset int64) (interface{}, error) {
+	fields := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		fields[AttrPrefix+name(attr.Name)] = attr.Value
+	}
+
+	var content []interface{} // text runs and {name: value} children, in order
+	var current *run
+	children := 0
+	flush := func() {
+		if current != nil {
+			if text := strings.TrimSpace(current.text.String()); text != "" {
+				if current.cdata {
+					content = append(content, cdata(text))
+				} else {
+					content = append(content, text)
+				}
+			}
+			current = nil
+		}
+	}
+
+	for {
+		tokenOffset := p.decoder.InputOffset()
+		token, err := p.decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("line %d: <%s> is not closed", p.line(offset), name(start.Name))
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			flush()
+			value, err := p.element(token, tokenOffset)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, map[string]interface{}{name(token.Name): value})
+			children++
+		case xml.CharData:
+			isCDATA := bytes.HasPrefix(p.data[tokenOffset:], []byte("<![CDATA["))
+			if isCDATA && p.options.CDATA == models.XMLError {
+				return nil, fmt.Errorf("line %d: <%s> holds a CDATA section", p.line(tokenOffset), name(start.Name))
+			}
+			separate := isCDATA && p.options.CDATA == models.XMLPreserve
+			if current != nil && current.cdata != separate {
+				flush()
+			}
+			if current == nil {
+				current = &run{cdata: separate}
+			}
+			current.text.Write(token)
+		case xml.EndElement:
+			if name(token.Name) != name(start.Name) {
+				return nil, fmt.Errorf("line %d: </%s> closes <%s>", p.line(tokenOffset), name(token.Name), name(start.Name))
+			}
+			flush()
+			return p.value(start, offset, fields, content, children)
+		}
+	}
+}
+
+// cdata is a text run read from CDATA sections.
+type cdata string
+
+// value builds the value of an element from its attributes and content.
+func (p *parser) value(start xml.StartElement, offset int64, fields map[string]interface{}, content []interface{}, children int) (interface{}, error) {
+	texts := len(content) - children
+	if texts > 0 && children > 0 {
+		switch p.options.MixedContent {
+		case models.XMLError:
+			return nil, fmt.Errorf("line %d: <%s> mixes text and elements", p.line(offset), name(start.Name))
+		case models.XMLPreserve:
+			ordered := make([]interface{}, len(content))
+			for i, item := range content {
+				if text, ok := item.(cdata); ok {
+					ordered[i] = map[string]interface{}{CDATAKey: string(text)}
+				} else {
+					ordered[i] = item
+				}
+			}
+			fields[ContentKey] = ordered
+		}
+	}
+
+	var text, cdataText []string
+	for _, item := range content {
+		switch item := item.(type) {
+		case string:
+			text = append(text, item)
+		case cdata:
+			cdataText = append(cdataText, string(item))
+		case map[string]interface{}:
+			for key, value := range item {
+				add(fields, key, value)
+			}
+		}
+	}
+	if len(text) > 0 {
+		fields[TextKey] = strings.Join(text, " ")
+	}
+	if len(cdataText) > 0 {
+		fields[CDATAKey] = strings.Join(cdataText, " ")
+	}
+
+	if len(fields) == 0 {
+		return "", nil
+	}
+	if len(fields) == 1 && len(text) == 1 {
+		return fields[TextKey], nil
+	}
+	return fields, nil
+}
+
+// add sets key, turning it into a list when it repeats.
+func add(fields map[string]interface{}, key string, value interface{}) {
+	existing, ok := fields[key]
+	if !ok {
+		fields[key] = value
+		return
+	}
+	if list, ok := existing.([]interface{}); ok {
+		fields[key] = append(list, value)
+		return
+	}
+	fields[key] = []interface{}{existing, value}
+}
+
+func (p *parser) line(offset int64) int {
+	return 1 + bytes.Count(p.data[:offset], []byte("\n"))
+}
+
+func name(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
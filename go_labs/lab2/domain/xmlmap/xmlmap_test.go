@@ -0,0 +1,71 @@
+package xmlmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+const article = `<article id="7">
+  <title><![CDATA[Tips & <tricks>]]></title>
+  <p>Read <b>this</b> twice<!-- really -->, then <i>act</i>.</p>
+  <tag>a</tag>
+  <tag>b</tag>
+</article>`
+
+func TestParseMatchesMXJShape(t *testing.T) {
+	value, err := Parse([]byte(article), models.XMLOptions{MixedContent: models.XMLConcatenate})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"article": map[string]interface{}{
+		"-id":   "7",
+		"title": "Tips & <tricks>",
+		"p":     map[string]interface{}{"#text": "Read twice, then .", "b": "this", "i": "act"},
+		"tag":   []interface{}{"a", "b"},
+	}}, value)
+}
+
+func TestParsePreservesCDATAAndMixedContent(t *testing.T) {
+	value, err := Parse([]byte(article), models.XMLOptions{CDATA: models.XMLPreserve, MixedContent: models.XMLPreserve})
+	require.NoError(t, err)
+	root := value["article"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"#cdata": "Tips & <tricks>"}, root["title"])
+	assert.Equal(t, []interface{}{
+		"Read",
+		map[string]interface{}{"b": "this"},
+		"twice, then",
+		map[string]interface{}{"i": "act"},
+		".",
+	}, root["p"].(map[string]interface{})["#content"])
+}
+
+func TestParseRejects(t *testing.T) {
+	_, err := Parse([]byte(article), models.XMLOptions{MixedContent: models.XMLError})
+	assert.EqualError(t, err, "line 3: <p> mixes text and elements")
+	_, err = Parse([]byte(article), models.XMLOptions{CDATA: models.XMLError})
+	assert.EqualError(t, err, "line 2: <title> holds a CDATA section")
+	_, err = Parse([]byte("<a><b></a>"), models.XMLOptions{CDATA: models.XMLText})
+	assert.EqualError(t, err, "line 1: </a> closes <b>")
+}
+
+func TestParseRejectsDTDs(t *testing.T) {
+	internal := "<?xml version=\"1.0\"?>\n<!DOCTYPE r [<!ENTITY who \"world\">]>\n<r>hello</r>"
+	_, err := Parse([]byte(internal), models.XMLOptions{})
+	assert.ErrorIs(t, err, ErrDTD)
+	assert.EqualError(t, err, "line 2: XML document type declarations are not allowed")
+
+	value, err := Parse([]byte(internal), models.XMLOptions{AllowDTD: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"r": "hello"}, value)
+
+	for _, external := range []string{
+		`<!DOCTYPE r SYSTEM "http://example.com/r.dtd"><r/>`,
+		`<!DOCTYPE r [<!ENTITY % remote SYSTEM "http://example.com/x">]><r/>`,
+		`<!DOCTYPE r [<!ENTITY secret PUBLIC "-//X//EN" "file:///etc/passwd">]><r/>`,
+	} {
+		_, err := Parse([]byte(external), models.XMLOptions{AllowDTD: true})
+		assert.ErrorIs(t, err, ErrExternalEntity, external)
+	}
+}
@@ -0,0 +1,258 @@
+// Package yamlalias expands the anchors, aliases and merge keys of YAML
+// input under limits suited to untrusted documents, and turns repeated
+// objects and lists of YAML output back into anchors and aliases.
+package yamlalias
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+const mergeTag = "!!merge"
+
+// DefaultMaxAliasDepth limits how deep aliases may nest when
+// models.YAMLOptions sets no MaxAliasDepth.
+const DefaultMaxAliasDepth = 32
+
+// ErrExcessiveAliasing is returned for documents whose aliases expand into
+// far more nodes than the document holds, such as a billion laughs attack.
+var ErrExcessiveAliasing = errors.New("document contains excessive aliasing")
+
+// Decode reads one YAML document into plain values, expanding every alias
+// and merge key. A cyclic alias fails unless options.BreakCycles is set,
+// which makes it null. Like yaml.v3, it fails with ErrExcessiveAliasing
+// when nodes reached through aliases outnumber the others too far. Maps are string-keyed when the document has aliases;
+// without any, it decodes as yaml.v3 does.
+func Decode(r io.Reader, options models.YAMLOptions) (interface{}, error) {
+	return decode(r, options, false)
+}
+
+// DecodeLossless is Decode keeping the exact types of scalars: integers
+// come out as int64 or uint64, decimal floats as values.Decimal and
+// timestamps as values.Timestamp.
+func DecodeLossless(r io.Reader, options models.YAMLOptions) (interface{}, error) {
+	return decode(r, options, true)
+}
+
+func decode(r io.Reader, options models.YAMLOptions, lossless bool) (interface{}, error) {
+	var root yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&root); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if root.Kind == 0 {
+		return nil, nil
+	}
+	if !lossless && !hasAliases(&root) {
+		var value interface{}
+		err := root.Decode(&value)
+		return value, err
+	}
+	d := &decoder{options: options, lossless: lossless, expanding: make(map[*yaml.Node]bool)}
+	return d.value(&root, 0)
+}
+
+func hasAliases(node *yaml.Node) bool {
+	if node.Kind == yaml.AliasNode {
+		return true
+	}
+	for _, child := range node.Content {
+		if hasAliases(child) {
+			return true
+		}
+	}
+	return false
+}
+
+type decoder struct {
+	options   models.YAMLOptions
+	lossless  bool
+	expanding map[*yaml.Node]bool
+
+	// decoded counts the nodes decoded and aliased those of them reached
+	// through an alias.
+	decoded, aliased int
+}
+
+// allowedAliasRatio is yaml.v3's bound on the share of decoded nodes that
+// may come from aliases: 99% of small documents, falling to 10% of
+// documents of four million nodes and more.
+func allowedAliasRatio(decoded int) float64 {
+	switch {
+	case decoded <= 400_000:
+		return 0.99
+	case decoded >= 4_000_000:
+		return 0.10
+	}
+	return 0.99 - 0.89*(float64(decoded-400_000)/3_600_000)
+}
+
+// value decodes node; depth counts the aliases node was reached through.
+func (d *decoder) value(node *yaml.Node, depth int) (interface{}, error) {
+	d.decoded++
+	if depth > 0 {
+		d.aliased++
+	}
+	if d.aliased > 100 && d.decoded > 1000 && float64(d.aliased)/float64(d.decoded) > allowedAliasRatio(d.decoded) {
+		return nil, ErrExcessiveAliasing
+	}
+
+	if node.Anchor != "" {
+		d.expanding[node] = true
+		defer delete(d.expanding, node)
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return d.value(node.Content[0], depth)
+	case yaml.AliasNode:
+		if d.expanding[node.Alias] {
+			if d.options.BreakCycles {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("line %d: alias *%s refers to a node containing it", node.Line, node.Value)
+		}
+		limit := d.options.MaxAliasDepth
+		if limit == 0 {
+			limit = DefaultMaxAliasDepth
+		}
+		if depth+1 > limit {
+			return nil, fmt.Errorf("line %d: alias *%s is nested more than %d aliases deep", node.Line, node.Value, limit)
+		}
+		return d.value(node.Alias, depth+1)
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(node.Content))
+		for i, child := range node.Content {
+			item, err := d.value(child, depth)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case yaml.MappingNode:
+		return d.mapping(node, depth)
+	}
+
+	if d.lossless {
+		switch node.ShortTag() {
+		case "!!float":
+			if decimal, ok := values.ParseDecimal(node.Value); ok {
+				return decimal, nil
+			}
+		case "!!timestamp":
+			var t time.Time
+			if err := node.Decode(&t); err != nil {
+				return nil, err
+			}
+			return values.Timestamp{Time: t, Text: node.Value}, nil
+		}
+	}
+
+	var scalar interface{}
+	if err := node.Decode(&scalar); err != nil {
+		return nil, err
+	}
+	if i, ok := scalar.(int); ok && d.lossless {
+		return int64(i), nil
+	}
+	return scalar, nil
+}
+
+// mapping decodes a mapping. Keys written in it override merged keys, and
+// of several merged mappings the first one listed wins.
+func (d *decoder) mapping(node *yaml.Node, depth int) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.ShortTag() != mergeTag {
+			continue
+		}
+		merged, err := d.value(value, depth)
+		if err != nil {
+			return nil, err
+		}
+		sources, ok := merged.([]interface{})
+		if !ok {
+			sources = []interface{}{merged}
+		}
+		for j := len(sources) - 1; j >= 0; j-- {
+			source, ok := sources[j].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("line %d: merge key needs a mapping or a list of mappings", key.Line)
+			}
+			for name, child := range source {
+				out[name] = child
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.ShortTag() == mergeTag {
+			continue
+		}
+		name, err := d.value(key, depth)
+		if err != nil {
+			return nil, err
+		}
+		child, err := d.value(value, depth)
+		if err != nil {
+			return nil, err
+		}
+		out[fmt.Sprint(name)] = child
+	}
+	return out, nil
+}
+
+// AddAnchors replaces every repeat of a non-empty mapping or sequence
+// below root with an alias of its first occurrence, which gets an anchor.
+func AddAnchors(root *yaml.Node) {
+	hashes := make(map[*yaml.Node][sha256.Size]byte)
+	hash(root, hashes)
+
+	first := make(map[[sha256.Size]byte]*yaml.Node)
+	anchors := 0
+	var visit func(node *yaml.Node)
+	visit = func(node *yaml.Node) {
+		if (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && len(node.Content) > 0 {
+			sum := hashes[node]
+			if original, seen := first[sum]; seen {
+				if original.Anchor == "" {
+					anchors++
+					original.Anchor = fmt.Sprintf("ref%d", anchors)
+				}
+				*node = yaml.Node{Kind: yaml.AliasNode, Value: original.Anchor, Alias: original}
+				return
+			}
+			first[sum] = node
+		}
+		for _, child := range node.Content {
+			visit(child)
+		}
+	}
+	visit(root)
+}
+
+func hash(node *yaml.Node, hashes map[*yaml.Node][sha256.Size]byte) [sha256.Size]byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00", node.Kind, node.ShortTag(), node.Value)
+	for _, child := range node.Content {
+		sum := hash(child, hashes)
+		h.Write(sum[:])
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	hashes[node] = sum
+	return sum
+}
@@ -0,0 +1,119 @@
+package yamlalias
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/values"
+)
+
+func TestDecodeExpandsAliasesAndMergeKeys(t *testing.T) {
+	input := `
+defaults: &defaults
+  retries: 3
+  timeout: 10
+extra: &extra
+  timeout: 99
+  verbose: true
+services:
+  - name: api
+    <<: *defaults
+    timeout: 30
+  - name: worker
+    <<: [*defaults, *extra]
+  - *defaults
+`
+	value, err := Decode(strings.NewReader(input), models.YAMLOptions{})
+	require.NoError(t, err)
+	services := value.(map[string]interface{})["services"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "api", "retries": 3, "timeout": 30}, services[0], "keys written in the mapping override merged ones")
+	assert.Equal(t, map[string]interface{}{"name": "worker", "retries": 3, "timeout": 10, "verbose": true}, services[1], "the first merged mapping wins")
+	assert.Equal(t, map[string]interface{}{"retries": 3, "timeout": 10}, services[2])
+}
+
+func TestDecodeLimitsAliases(t *testing.T) {
+	cyclic := "node: &node\n  name: root\n  child: *node\n"
+	_, err := Decode(strings.NewReader(cyclic), models.YAMLOptions{})
+	assert.EqualError(t, err, "line 3: alias *node refers to a node containing it")
+
+	value, err := Decode(strings.NewReader(cyclic), models.YAMLOptions{BreakCycles: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"node": map[string]interface{}{"name": "root", "child": nil}}, value)
+
+	nested := "a: &a [1]\nb: &b [*a, *a]\nc: &c [*b, *b]\n"
+	_, err = Decode(strings.NewReader(nested), models.YAMLOptions{MaxAliasDepth: 2})
+	assert.NoError(t, err)
+	_, err = Decode(strings.NewReader(nested+"d: [*c]\n"), models.YAMLOptions{MaxAliasDepth: 2})
+	assert.EqualError(t, err, "line 2: alias *a is nested more than 2 aliases deep")
+}
+
+// billionLaughs nests levels lists of nine aliases of the level below,
+// expanding into 9^levels strings.
+func billionLaughs(levels int) string {
+	var b strings.Builder
+	b.WriteString(`a0: &a0 ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]` + "\n")
+	for i := 1; i <= levels; i++ {
+		fmt.Fprintf(&b, "a%d: &a%d [", i, i)
+		for j := range 9 {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, "*a%d", i-1)
+		}
+		b.WriteString("]\n")
+	}
+	return b.String()
+}
+
+func TestDecodeRejectsAliasBombs(t *testing.T) {
+	bomb := billionLaughs(8)
+	assert.Less(t, len(bomb), 500)
+
+	start := time.Now()
+	_, err := Decode(strings.NewReader(bomb), models.YAMLOptions{})
+	assert.ErrorIs(t, err, ErrExcessiveAliasing)
+	_, err = DecodeLossless(strings.NewReader(bomb), models.YAMLOptions{MaxAliasDepth: 100})
+	assert.ErrorIs(t, err, ErrExcessiveAliasing)
+	assert.Less(t, time.Since(start), time.Second)
+
+	_, err = Decode(strings.NewReader(billionLaughs(2)), models.YAMLOptions{})
+	assert.NoError(t, err, "modest reuse stays allowed")
+}
+
+func TestAddAnchors(t *testing.T) {
+	address := map[string]interface{}{"city": "Paris", "zip": "75001"}
+	var node yaml.Node
+	require.NoError(t, node.Encode(map[string]interface{}{
+		"billing":  address,
+		"shipping": map[string]interface{}{"city": "Paris", "zip": "75001"},
+		"tags":     []interface{}{},
+		"other":    []interface{}{},
+	}))
+	AddAnchors(&node)
+	data, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, "billing: &ref1\n    city: Paris\n    zip: \"75001\"\nother: []\nshipping: *ref1\ntags: []\n", string(data))
+
+	value, err := Decode(strings.NewReader(string(data)), models.YAMLOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, address, value.(map[string]interface{})["shipping"])
+}
+
+func TestDecodeLosslessKeepsScalarTypes(t *testing.T) {
+	input := "id: 0x10\nbig: 18446744073709551615\nprice: 0.10000000000000000001\nratio: .inf\nday: 2024-01-02\n"
+	value, err := DecodeLossless(strings.NewReader(input), models.YAMLOptions{})
+	require.NoError(t, err)
+	m := value.(map[string]interface{})
+	assert.Equal(t, int64(16), m["id"])
+	assert.Equal(t, uint64(18446744073709551615), m["big"])
+	assert.Equal(t, values.Decimal("0.10000000000000000001"), m["price"])
+	assert.IsType(t, float64(0), m["ratio"])
+	assert.Equal(t, values.Timestamp{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Text: "2024-01-02"}, m["day"])
+}
@@ -0,0 +1,132 @@
+// Package yamlcomments carries the comments of a YAML document across
+// conversions that drop them. Extract records every comment under the path
+// of the node it belongs to, and Apply puts them back on the nodes at the
+// same paths of another document, such as the YAML rendered after a round
+// trip through JSON.
+package yamlcomments
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Comment holds the comments around one node.
+type Comment struct {
+	Head string
+	Line string
+	Foot string
+}
+
+func commentOf(node *yaml.Node) Comment {
+	return Comment{Head: node.HeadComment, Line: node.LineComment, Foot: node.FootComment}
+}
+
+func (c Comment) empty() bool {
+	return c == Comment{}
+}
+
+func (c Comment) applyTo(node *yaml.Node) {
+	if c.Head != "" {
+		node.HeadComment = c.Head
+	}
+	if c.Line != "" {
+		node.LineComment = c.Line
+	}
+	if c.Foot != "" {
+		node.FootComment = c.Foot
+	}
+}
+
+// Comments are the comments of one document, by node path. Mapping keys
+// and their values are kept apart, as YAML attaches comments to either.
+type Comments struct {
+	document Comment
+	values   map[string]Comment
+	keys     map[string]Comment
+}
+
+// Len is the number of nodes with comments.
+func (c *Comments) Len() int {
+	n := len(c.values) + len(c.keys)
+	if !c.document.empty() {
+		n++
+	}
+	return n
+}
+
+// Extract records the comments of the YAML document in data.
+func Extract(data []byte) (*Comments, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML comments: %w", err)
+	}
+	comments := &Comments{values: make(map[string]Comment), keys: make(map[string]Comment)}
+	if root.Kind == 0 {
+		return comments, nil
+	}
+	comments.document = commentOf(&root)
+	if len(root.Content) > 0 {
+		walk(nil, root.Content[0], "", func(path string, key, value *yaml.Node) {
+			if key != nil {
+				if comment := commentOf(key); !comment.empty() {
+					comments.keys[path] = comment
+				}
+			}
+			if comment := commentOf(value); !comment.empty() {
+				comments.values[path] = comment
+			}
+		})
+	}
+	return comments, nil
+}
+
+// Apply returns the YAML document in data with the recorded comments put
+// back on the nodes at the same paths, indented by indent spaces. Comments
+// of paths data no longer has are dropped.
+func Apply(data []byte, comments *Comments, indent int) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML output: %w", err)
+	}
+	if root.Kind == 0 || comments.Len() == 0 {
+		return data, nil
+	}
+	comments.document.applyTo(&root)
+	if len(root.Content) > 0 {
+		walk(nil, root.Content[0], "", func(path string, key, value *yaml.Node) {
+			if key != nil {
+				comments.keys[path].applyTo(key)
+			}
+			comments.values[path].applyTo(value)
+		})
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// walk calls visit for node, whose mapping key is key or nil, and every
+// node below it with its path.
+func walk(key, node *yaml.Node, path string, visit func(path string, key, value *yaml.Node)) {
+	visit(path, key, node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walk(node.Content[i], node.Content[i+1], fmt.Sprintf("%s/%q", path, node.Content[i].Value), visit)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			walk(nil, item, fmt.Sprintf("%s[%d]", path, i), visit)
+		}
+	}
+}
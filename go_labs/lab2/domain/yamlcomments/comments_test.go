@@ -0,0 +1,59 @@
+package yamlcomments
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const config = `# Service configuration
+
+name: api # public name
+# Listeners, in order of preference
+ports:
+  - 80 # plain
+  - 443
+limits:
+  # requests per second
+  rate: 10
+`
+
+func TestCommentsSurviveAJSONRoundTrip(t *testing.T) {
+	comments, err := Extract([]byte(config))
+	require.NoError(t, err)
+
+	var value map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(config), &value))
+	viaJSON, err := json.Marshal(value)
+	require.NoError(t, err)
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal(viaJSON, &decoded))
+	stripped, err := yaml.Marshal(decoded)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stripped), "#")
+
+	restored, err := Apply(stripped, comments, 2)
+	require.NoError(t, err)
+	assert.Equal(t, `# Service configuration
+
+limits:
+  # requests per second
+  rate: 10
+name: api # public name
+# Listeners, in order of preference
+ports:
+  - 80 # plain
+  - 443
+`, string(restored))
+}
+
+func TestApplyDropsCommentsOfMissingPaths(t *testing.T) {
+	comments, err := Extract([]byte("a: 1 # one\nb: 2 # two\n"))
+	require.NoError(t, err)
+	restored, err := Apply([]byte("b: 3\nc: 4\n"), comments, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "b: 3 # two\nc: 4\n", string(restored))
+}
@@ -0,0 +1,348 @@
+// Package client is a typed Go client for the conversion service, covering
+// the API described by its OpenAPI document (GET /openapi.yaml):
+//
+//	c := client.New("http://localhost:8080", os.Getenv("CONVERT_API_KEY"))
+//	yaml, err := c.Convert(ctx, convert.CSV, convert.YAML, file)
+//
+// Failed requests return an *Error carrying the status code and the
+// service's message.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/runstate"
+	"tmps-go-labs/lab2/domain/service"
+	"tmps-go-labs/lab2/pkg/convert"
+)
+
+const DefaultTimeout = 60 * time.Second
+
+type (
+	HealthReport = service.HealthReport
+	TenantReport = service.TenantReport
+	TenantLimits = service.TenantLimits
+	PoolStats    = factory.PoolStats
+	Usage        = service.Usage
+	Run          = runstate.Snapshot
+	Progress     = runstate.Progress
+)
+
+// Job is a queued conversion. State is queued, running, done, failed or
+// cancelled.
+type Job struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+	Run   *Run   `json:"run,omitempty"`
+}
+
+// Upload is a resumable upload; Offset is the number of bytes received.
+type Upload struct {
+	ID       string `json:"id"`
+	Length   int64  `json:"length"`
+	Offset   int64  `json:"offset"`
+	Complete bool   `json:"complete"`
+}
+
+// Error is an answer of the service outside the 2xx range.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+type Client struct {
+	BaseURL string
+	APIKey  string
+	// AdminKey authenticates the admin methods.
+	AdminKey   string
+	HTTPClient *http.Client
+}
+
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Convert converts input from one format to another. Options the service
+// treats as server-side are dropped by it.
+func (c *Client) Convert(ctx context.Context, from, to convert.FileFormat, input io.Reader, options ...convert.Option) ([]byte, error) {
+	request, err := c.conversion(ctx, "/convert", url.Values{"from": {string(from)}, "to": {string(to)}}, input, options)
+	if err != nil {
+		return nil, err
+	}
+	return c.read(request)
+}
+
+// ConvertPreset runs the named server-defined pipeline on input.
+func (c *Client) ConvertPreset(ctx context.Context, preset string, input io.Reader) ([]byte, error) {
+	request, err := c.conversion(ctx, "/convert", url.Values{"preset": {preset}}, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.read(request)
+}
+
+// ConvertUpload converts a complete upload, which the service removes once
+// it has read it.
+func (c *Client) ConvertUpload(ctx context.Context, uploadID string, from, to convert.FileFormat, options ...convert.Option) ([]byte, error) {
+	request, err := c.conversion(ctx, "/convert", url.Values{"from": {string(from)}, "to": {string(to)}, "upload": {uploadID}}, nil, options)
+	if err != nil {
+		return nil, err
+	}
+	return c.read(request)
+}
+
+// Enqueue queues a conversion for the service's workers.
+func (c *Client) Enqueue(ctx context.Context, from, to convert.FileFormat, input io.Reader, options ...convert.Option) (*Job, error) {
+	request, err := c.conversion(ctx, "/jobs", url.Values{"from": {string(from)}, "to": {string(to)}}, input, options)
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	return &job, c.decode(request, &job)
+}
+
+func (c *Client) Job(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	return &job, c.getJSON(ctx, "/jobs/"+url.PathEscape(id), &job)
+}
+
+// JobOutput returns the output of a finished job.
+func (c *Client) JobOutput(ctx context.Context, id string) ([]byte, error) {
+	request, err := c.newRequest(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id)+"/output", nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.read(request)
+}
+
+// CreateUpload starts a resumable upload of length bytes.
+func (c *Client) CreateUpload(ctx context.Context, length int64) (*Upload, error) {
+	request, err := c.newRequest(ctx, http.MethodPost, "/uploads", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set(service.UploadLengthHeader, strconv.FormatInt(length, 10))
+	var upload Upload
+	return &upload, c.decode(request, &upload)
+}
+
+// UploadChunk sends chunk as the bytes starting at offset.
+func (c *Client) UploadChunk(ctx context.Context, upload *Upload, offset int64, chunk []byte) (*Upload, error) {
+	request, err := c.newRequest(ctx, http.MethodPut, "/uploads/"+url.PathEscape(upload.ID), bytes.NewReader(chunk))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(chunk))-1, upload.Length))
+	var updated Upload
+	return &updated, c.decode(request, &updated)
+}
+
+// UploadStatus reports the offset an interrupted upload resumes from.
+func (c *Client) UploadStatus(ctx context.Context, id string) (*Upload, error) {
+	var upload Upload
+	return &upload, c.getJSON(ctx, "/uploads/"+url.PathEscape(id), &upload)
+}
+
+func (c *Client) DeleteUpload(ctx context.Context, id string) error {
+	request, err := c.newRequest(ctx, http.MethodDelete, "/uploads/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.read(request)
+	return err
+}
+
+// Upload sends size bytes of input in chunks of chunkSize. A failed chunk
+// is retried up to retries times from the offset the service reports, so
+// only the missing bytes are sent again.
+func (c *Client) Upload(ctx context.Context, input io.ReaderAt, size, chunkSize int64, retries int) (*Upload, error) {
+	upload, err := c.CreateUpload(ctx, size)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := 0
+	for upload.Offset < size {
+		chunk := make([]byte, min(chunkSize, size-upload.Offset))
+		if _, err := input.ReadAt(chunk, upload.Offset); err != nil && !errors.Is(err, io.EOF) {
+			return upload, err
+		}
+
+		updated, err := c.UploadChunk(ctx, upload, upload.Offset, chunk)
+		if err == nil {
+			upload = updated
+			continue
+		}
+		if failures++; failures > retries || ctx.Err() != nil {
+			return upload, err
+		}
+		if status, statusErr := c.UploadStatus(ctx, upload.ID); statusErr == nil {
+			upload = status
+		}
+	}
+	return upload, nil
+}
+
+// Health returns the service's full health report, an admin method.
+func (c *Client) Health(ctx context.Context) (*HealthReport, error) {
+	var report HealthReport
+	return &report, c.admin(ctx, http.MethodGet, "/admin/health", nil, &report)
+}
+
+// Converters lists the conversions the service has registered.
+func (c *Client) Converters(ctx context.Context) ([]string, error) {
+	var body struct {
+		Conversions []string `json:"conversions"`
+	}
+	err := c.admin(ctx, http.MethodGet, "/admin/converters", nil, &body)
+	return body.Conversions, err
+}
+
+func (c *Client) PoolStats(ctx context.Context) (*PoolStats, error) {
+	var stats PoolStats
+	return &stats, c.admin(ctx, http.MethodGet, "/admin/pool", nil, &stats)
+}
+
+func (c *Client) CancelJob(ctx context.Context, id string) error {
+	return c.admin(ctx, http.MethodPost, "/admin/jobs/"+url.PathEscape(id)+"/cancel", nil, nil)
+}
+
+func (c *Client) Tenants(ctx context.Context) ([]TenantReport, error) {
+	var tenants []TenantReport
+	err := c.admin(ctx, http.MethodGet, "/admin/tenants", nil, &tenants)
+	return tenants, err
+}
+
+func (c *Client) SetTenantLimits(ctx context.Context, name string, limits TenantLimits) (*TenantReport, error) {
+	var report TenantReport
+	return &report, c.admin(ctx, http.MethodPatch, "/admin/tenants/"+url.PathEscape(name), limits, &report)
+}
+
+// Usage returns the requests and bytes accounted to each API key or token
+// subject since the service started.
+func (c *Client) Usage(ctx context.Context) ([]Usage, error) {
+	var usage []Usage
+	err := c.admin(ctx, http.MethodGet, "/admin/usage", nil, &usage)
+	return usage, err
+}
+
+// Reload makes the service reload its converter plugins and presets.
+func (c *Client) Reload(ctx context.Context) error {
+	return c.admin(ctx, http.MethodPost, "/admin/reload", nil, nil)
+}
+
+func (c *Client) conversion(ctx context.Context, path string, query url.Values, input io.Reader, options []convert.Option) (*http.Request, error) {
+	if input == nil {
+		input = http.NoBody
+	}
+	request, err := c.newRequest(ctx, http.MethodPost, path+"?"+query.Encode(), input)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) > 0 {
+		encoded, err := json.Marshal(convert.NewOptions(options...))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set(service.OptionsHeader, string(encoded))
+	}
+	return request, nil
+}
+
+func (c *Client) admin(ctx context.Context, method, path string, body, target interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(encoded)
+	}
+	request, err := c.newRequest(ctx, method, path, payload)
+	if err != nil {
+		return err
+	}
+	request.Header.Del(service.APIKeyHeader)
+	request.Header.Set(service.AdminKeyHeader, c.AdminKey)
+	if target == nil {
+		_, err = c.read(request)
+		return err
+	}
+	return c.decode(request, target)
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, target interface{}) error {
+	request, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	return c.decode(request, target)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.APIKey != "" {
+		request.Header.Set(service.APIKeyHeader, c.APIKey)
+	}
+	return request, nil
+}
+
+func (c *Client) decode(request *http.Request, target interface{}) error {
+	body, err := c.read(request)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, target)
+}
+
+// read performs the request and returns the body of a 2xx response.
+func (c *Client) read(request *http.Request) ([]byte, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		var payload struct {
+			Error string `json:"error"`
+		}
+		message := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+			message = payload.Error
+		}
+		return nil, &Error{StatusCode: response.StatusCode, Message: message}
+	}
+	return body, nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/service"
+	"tmps-go-labs/lab2/pkg/convert"
+)
+
+func newTestService(t *testing.T, wrap func(http.Handler) http.Handler) *Client {
+	pool := factory.NewConverterPool(2, factory.NewConverterFactory())
+	server := service.NewServer(factory.NewPipelineExecutor(pool), []service.Tenant{{Name: "analytics", APIKey: "secret"}})
+	assert.NoError(t, server.EnableUploads(t.TempDir()))
+	server.EnableAdmin("admin-secret", nil)
+
+	var handler http.Handler = server
+	if wrap != nil {
+		handler = wrap(server)
+	}
+	httpServer := httptest.NewServer(handler)
+	t.Cleanup(httpServer.Close)
+
+	c := New(httpServer.URL, "secret")
+	c.AdminKey = "admin-secret"
+	return c
+}
+
+func TestConvert(t *testing.T) {
+	c := newTestService(t, nil)
+	ctx := context.Background()
+
+	output, err := c.Convert(ctx, convert.CSV, convert.JSON, strings.NewReader("name\nann\n"), convert.WithIndentWidth(0))
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name":"ann"}]`, string(output))
+
+	c.APIKey = "wrong"
+	_, err = c.Convert(ctx, convert.CSV, convert.JSON, strings.NewReader("name\nann\n"))
+	var serviceErr *Error
+	assert.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, http.StatusUnauthorized, serviceErr.StatusCode)
+	assert.Equal(t, "missing or unknown API key", serviceErr.Message)
+
+	conversions, err := c.Converters(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, conversions, "csv-json")
+}
+
+func TestUploadResumesInterruptedChunk(t *testing.T) {
+	interrupted := false
+	c := newTestService(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The first chunk loses its tail on the way, like a dropped
+			// connection.
+			if r.Method == http.MethodPut && !interrupted {
+				interrupted = true
+				r.Body = io.NopCloser(io.LimitReader(r.Body, 3))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	ctx := context.Background()
+
+	input := []byte("name,city\nann,oslo\nbob,rome\n")
+	upload, err := c.Upload(ctx, bytes.NewReader(input), int64(len(input)), 8, 1)
+	assert.NoError(t, err)
+	assert.True(t, upload.Complete)
+	assert.True(t, interrupted)
+
+	output, err := c.ConvertUpload(ctx, upload.ID, convert.CSV, convert.JSON)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"name":"ann","city":"oslo"},{"name":"bob","city":"rome"}]`, string(output))
+	assert.ErrorContains(t, c.DeleteUpload(ctx, upload.ID), "404", "converting consumes the upload")
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"tmps-go-labs/lab2/domain/service"
+	"tmps-go-labs/lab2/pkg/convert"
+)
+
+// unexposed are the operations of the OpenAPI document that the client
+// leaves to load balancers and orchestrators.
+var unexposed = map[string]bool{
+	"GET /healthz": true,
+	"GET /readyz":  true,
+}
+
+func TestClientMatchesOpenAPI(t *testing.T) {
+	var document struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	assert.NoError(t, yaml.Unmarshal(service.OpenAPI, &document))
+
+	// Each operation becomes a pattern matching its requests, e.g.
+	// "GET /jobs/{id}" matches GET /jobs/abc.
+	operations := make(map[string]*regexp.Regexp)
+	for path, methods := range document.Paths {
+		pattern := regexp.MustCompile(`\\{[^}]+\\}`).ReplaceAllString(regexp.QuoteMeta(path), `[^/]+`)
+		for method := range methods {
+			operations[strings.ToUpper(method)+" "+path] = regexp.MustCompile("^" + pattern + "$")
+		}
+	}
+
+	var mu sync.Mutex
+	called := make(map[string]bool)
+	c := newTestService(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			matched := false
+			for operation, pattern := range operations {
+				method, _, _ := strings.Cut(operation, " ")
+				if method == r.Method && pattern.MatchString(r.URL.Path) {
+					mu.Lock()
+					called[operation] = true
+					mu.Unlock()
+					matched = true
+				}
+			}
+			assert.True(t, matched, "%s %s is not in openapi.yaml", r.Method, r.URL.Path)
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	// Only the requests matter here, not whether they succeed.
+	ctx := context.Background()
+	input := strings.NewReader("name\nann\n")
+	c.Convert(ctx, convert.CSV, convert.JSON, input)
+	c.ConvertPreset(ctx, "daily", input)
+	c.ConvertUpload(ctx, "x", convert.CSV, convert.JSON)
+	c.Enqueue(ctx, convert.CSV, convert.JSON, input)
+	c.Job(ctx, "x")
+	c.JobOutput(ctx, "x")
+	upload, _ := c.CreateUpload(ctx, 4)
+	c.UploadChunk(ctx, upload, 0, []byte("name"))
+	c.UploadStatus(ctx, "x")
+	c.DeleteUpload(ctx, "x")
+	c.Health(ctx)
+	c.Converters(ctx)
+	c.PoolStats(ctx)
+	c.CancelJob(ctx, "x")
+	c.Tenants(ctx)
+	c.SetTenantLimits(ctx, "analytics", TenantLimits{})
+	c.Usage(ctx)
+	c.Reload(ctx)
+
+	for operation := range operations {
+		assert.True(t, called[operation] || unexposed[operation], "the client has no method for %s", operation)
+	}
+}
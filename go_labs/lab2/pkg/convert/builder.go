@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"context"
+	"log/slog"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Builder assembles and validates a Pipeline. Its methods chain; errors
+// surface from Build.
+type Builder struct {
+	builder *factory.PipelineBuilder
+}
+
+// NewBuilder starts an empty pipeline.
+func NewBuilder() *Builder {
+	return &Builder{builder: factory.NewPipelineBuilder()}
+}
+
+// WithInputPath sets the file or URL the pipeline reads.
+func (b *Builder) WithInputPath(path string) *Builder {
+	b.builder.WithInputPath(path)
+	return b
+}
+
+// WithOutputPath sets the file the pipeline writes.
+func (b *Builder) WithOutputPath(path string) *Builder {
+	b.builder.WithOutputPath(path)
+	return b
+}
+
+// Apply sets options such as WithCSVDelimiter(';') on top of any set so
+// far.
+func (b *Builder) Apply(options ...Option) *Builder {
+	b.builder.Apply(options...)
+	return b
+}
+
+// WithStrategy selects how the Executor runs the pipeline; the default is
+// Sequential.
+func (b *Builder) WithStrategy(strategy Strategy) *Builder {
+	b.builder.WithStrategy(strategy)
+	return b
+}
+
+// WithSaveIntermediarySteps writes the output of every step next to the
+// output file.
+func (b *Builder) WithSaveIntermediarySteps() *Builder {
+	b.builder.WithSaveIntermediarySteps()
+	return b
+}
+
+// WithProfiling writes a profile of the output to ProfilePath(output).
+func (b *Builder) WithProfiling() *Builder {
+	b.builder.WithProfiling()
+	return b
+}
+
+// AddConversionStep converts from one format to another with the newest
+// registered converter.
+func (b *Builder) AddConversionStep(from, to FileFormat) *Builder {
+	b.builder.AddConversionStep(from, to)
+	return b
+}
+
+// AddPinnedConversion converts from to to with the newest registered
+// converter meeting requirement, e.g. a major version or a capability.
+func (b *Builder) AddPinnedConversion(from, to FileFormat, requirement ConverterRequirement) *Builder {
+	b.builder.AddPinnedConversion(from, to, requirement)
+	return b
+}
+
+// AddFilter keeps only the records of format for which expression, such as
+// `age > 30 && country == "MD"`, is true.
+func (b *Builder) AddFilter(format FileFormat, expression string) *Builder {
+	b.builder.AddFilter(format, expression)
+	return b
+}
+
+// Search keeps the records with a field value, at any depth, that matches
+// Query with Engine: "literal" (the default), "regex", "fuzzy" or one
+// registered by the caller. Invert keeps the records that do not match.
+type Search struct {
+	Engine string
+	Query  string
+	Invert bool
+}
+
+// AddSearch keeps the records of format that search matches.
+func (b *Builder) AddSearch(format FileFormat, search Search) *Builder {
+	b.builder.AddSearch(format, models.Search{Engine: search.Engine, Query: search.Query, Invert: search.Invert})
+	return b
+}
+
+// Build validates the pipeline and returns it.
+func (b *Builder) Build() (*Pipeline, error) {
+	return b.builder.Build()
+}
+
+// Executor runs pipelines with converters drawn from a shared pool. It is
+// safe for concurrent use.
+type Executor struct {
+	executor *factory.PipelineExecutor
+}
+
+// NewExecutor creates an executor that keeps up to poolSize converters of
+// each conversion type for reuse, each wrapped in the given middleware.
+func NewExecutor(poolSize int, middleware ...Middleware) *Executor {
+	converterFactory := factory.NewConverterFactory()
+	if len(middleware) > 0 {
+		converterFactory = factory.WithMiddleware(converterFactory, middleware...)
+	}
+	return &Executor{executor: factory.NewPipelineExecutor(factory.NewConverterPool(poolSize, converterFactory))}
+}
+
+// WithLogger logs runs and steps to logger.
+func (e *Executor) WithLogger(logger *slog.Logger) *Executor {
+	e.executor.WithLogger(logger)
+	return e
+}
+
+// WithFileSystem makes the executor read inputs from and write outputs to
+// files instead of the local disk.
+func (e *Executor) WithFileSystem(files FileSystem) *Executor {
+	e.executor.WithFileSystem(files)
+	return e
+}
+
+// Subscribe delivers the executor's events to observer until the returned
+// function is called.
+func (e *Executor) Subscribe(observer Observer) (unsubscribe func()) {
+	return e.executor.Events().Subscribe(observer)
+}
+
+// Execute runs pipeline.
+func (e *Executor) Execute(pipeline *Pipeline) *Result {
+	return e.executor.Execute(pipeline)
+}
+
+// ExecuteContext runs pipeline until ctx is done.
+func (e *Executor) ExecuteContext(ctx context.Context, pipeline *Pipeline) *Result {
+	return e.executor.ExecuteContext(ctx, pipeline)
+}
+
+// Shutdown stops accepting new runs and waits for in-flight ones until ctx
+// is done, after which they are aborted.
+func (e *Executor) Shutdown(ctx context.Context) error {
+	return e.executor.Shutdown(ctx)
+}
@@ -0,0 +1,273 @@
+package convert
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/junit"
+	"tmps-go-labs/lab2/domain/models"
+	"tmps-go-labs/lab2/domain/postprocess"
+	"tmps-go-labs/lab3/vfs"
+)
+
+// FileFormat names a data format, such as "csv" or "json".
+type FileFormat = models.FileFormat
+
+const (
+	CSV        = models.FormatCSV
+	JSON       = models.FormatJSON
+	XML        = models.FormatXML
+	YAML       = models.FormatYAML
+	Markdown   = models.FormatMarkdown
+	Template   = models.FormatTemplate
+	FixedWidth = models.FormatFixedWidth
+	VCard      = models.FormatVCard
+	ICal       = models.FormatICal
+	GeoJSON    = models.FormatGeoJSON
+	NDJSON     = models.FormatNDJSON
+	XLSX       = models.FormatXLSX
+)
+
+// Converter transforms data between two formats. Convert reports failures
+// through ConversionResult.Error rather than panicking.
+type Converter = models.Converter
+
+// Configurable converters receive the pipeline Options before each
+// conversion.
+type Configurable = models.Configurable
+
+// ConversionResult is the output of a single Converter call.
+type ConversionResult = models.ConversionResult
+
+// Options tunes how converters and the executor behave.
+type Options = models.ConversionOptions
+
+// Strategy selects how an Executor schedules a pipeline; pass one to
+// Builder.WithStrategy.
+type Strategy = models.ExecutionStrategy
+
+const (
+	Sequential = models.StrategySequential
+	Concurrent = models.StrategyConcurrent
+	Streaming  = models.StrategyStreaming
+)
+
+// Option sets one conversion option; pass options to Builder.Apply or
+// NewOptions.
+type Option = models.Option
+
+// NewOptions builds Options from functional options.
+func NewOptions(options ...Option) Options {
+	return models.NewOptions(options...)
+}
+
+// WithIndentWidth sets the indentation of JSON, XML and YAML output; zero
+// produces compact output.
+func WithIndentWidth(width int) Option { return models.WithIndentWidth(width) }
+
+// WithCSVDelimiter sets the field separator used to read and write CSV.
+func WithCSVDelimiter(delimiter rune) Option { return models.WithCSVDelimiter(delimiter) }
+
+// WithCSVParser selects the parser that reads CSV input, such as
+// ParallelCSV for large files.
+func WithCSVParser(parser CSVParser) Option { return models.WithCSVParser(parser) }
+
+// CSVParser names a CSV parsing backend.
+type CSVParser = models.CSVParser
+
+// CSV parsing backends.
+const (
+	StandardCSV = models.CSVParserStandard
+	ParallelCSV = models.CSVParserParallel
+)
+
+// WithXMLRoot names the root element of XML output.
+func WithXMLRoot(name string) Option { return models.WithXMLRoot(name) }
+
+// WithKeyOrder sorts the keys of JSON, NDJSON and YAML output objects and
+// the columns of CSV output, e.g.
+// KeyOrder{First: []string{"id"}, Last: []string{"metadata"}}.
+func WithKeyOrder(order KeyOrder) Option { return models.WithKeyOrder(order) }
+
+// KeyOrder sorts object keys: First keys, the others by Collation, then
+// Last keys.
+type KeyOrder = models.KeyOrder
+
+// Key collations.
+const (
+	LexicalKeys = models.CollationLexical
+	NaturalKeys = models.CollationNatural
+)
+
+// WithYAML limits alias expansion in YAML input and makes YAML output use
+// anchors for repeated objects and lists.
+func WithYAML(yaml YAMLOptions) Option { return models.WithYAML(yaml) }
+
+// YAMLOptions control YAML anchors and aliases.
+type YAMLOptions = models.YAMLOptions
+
+// WithXML sets how CDATA sections and mixed content of XML input are read.
+func WithXML(xml XMLOptions) Option { return models.WithXML(xml) }
+
+// XMLOptions control CDATA sections and mixed content of XML input.
+type XMLOptions = models.XMLOptions
+
+// WithLimits rejects JSON, XML and YAML input nested deeper or holding
+// more nodes than limits allow, before it is parsed.
+func WithLimits(limits DocumentLimits) Option { return models.WithLimits(limits) }
+
+// DocumentLimits bound the nesting depth and node count of input.
+type DocumentLimits = models.DocumentLimits
+
+// WithLosslessTypes keeps JSON and YAML integers, decimals and timestamps
+// exact instead of reading numbers as float64. This is the default; it
+// only overrides WithFloatNumbers.
+func WithLosslessTypes() Option { return models.WithLosslessTypes() }
+
+// WithFloatNumbers reads JSON and YAML numbers as float64, losing the
+// precision of integers past 2^53.
+func WithFloatNumbers() Option { return models.WithFloatNumbers() }
+
+// WithOutputSchema converts, fills in and prunes the final output to match
+// a JSON Schema, failing on what it cannot fix.
+func WithOutputSchema(schema OutputSchemaOptions) Option { return models.WithOutputSchema(schema) }
+
+// OutputSchemaOptions name the JSON Schema output must match.
+type OutputSchemaOptions = models.OutputSchemaOptions
+
+// WithSeed makes runs reproducible: the run ID and the records of sample
+// steps without their own seed repeat for the same seed.
+func WithSeed(seed int64) Option { return models.WithSeed(seed) }
+
+// StepTimeout bounds how long a step may run and what happens after.
+type StepTimeout = models.StepTimeout
+
+// TimeoutPolicy decides whether a step running past its timeout fails the
+// run, is skipped or is retried.
+type TimeoutPolicy = models.TimeoutPolicy
+
+const (
+	AbortOnTimeout = models.TimeoutAbort
+	SkipOnTimeout  = models.TimeoutSkip
+	RetryOnTimeout = models.TimeoutRetry
+)
+
+// WithStepTimeout bounds how long every step of a run may take.
+func WithStepTimeout(timeout StepTimeout) Option { return models.WithStepTimeout(timeout) }
+
+// ErrStepTimeout is the cause of steps failing for running past their
+// timeout.
+var ErrStepTimeout = factory.ErrStepTimeout
+
+// WithHeaders sets the column headers used by converters that need them.
+func WithHeaders(headers ...string) Option { return models.WithHeaders(headers...) }
+
+// WithTemplate sets the text/template used for Template output.
+func WithTemplate(text string) Option { return models.WithTemplate(text) }
+
+// WithPostProcessors reshapes the final output with the named
+// post-processors, such as MinifyJSON, SortKeys and DropEmpty, in order.
+func WithPostProcessors(names ...string) Option { return models.WithPostProcessors(names...) }
+
+// Names of the built-in post-processors.
+const (
+	MinifyJSON = postprocess.Minify
+	SortKeys   = postprocess.SortKeys
+	DropEmpty  = postprocess.DropEmpty
+)
+
+// Pipeline is an ordered list of conversion steps with their input, output
+// and options. Build one with NewBuilder.
+type Pipeline = models.Pipeline
+
+// Step is one conversion in a Pipeline.
+type Step = models.ConversionStep
+
+// Result reports the outcome of executing a Pipeline.
+type Result = models.PipelineResult
+
+// FileSystem is where an Executor reads pipeline inputs and writes their
+// outputs; pass one to Executor.WithFileSystem. The default is the local
+// disk.
+type FileSystem = vfs.FileSystem
+
+// Creator returns a new Converter instance for the pool.
+type Creator = factory.ConverterCreator
+
+// ConverterInfo is the version and capabilities of a registered converter.
+type ConverterInfo = factory.ConverterInfo
+
+// ConverterRequirement asks a step for a converter version or capability.
+type ConverterRequirement = models.ConverterRequirement
+
+// Middleware wraps every converter an executor creates, for cross-cutting
+// concerns such as logging, size limits or caching.
+type Middleware = factory.Middleware
+
+// Logging logs every conversion with its duration and sizes.
+func Logging(logger *slog.Logger) Middleware { return factory.Logging(logger) }
+
+// Timing reports the duration and error of every conversion to record.
+func Timing(record func(key string, duration time.Duration, err error)) Middleware {
+	return factory.Timing(record)
+}
+
+// SizeLimit rejects inputs larger than maxBytes with ErrInputTooLarge.
+func SizeLimit(maxBytes int64) Middleware { return factory.SizeLimit(maxBytes) }
+
+// ValidateOutput fails conversions whose JSON, XML or YAML output is
+// malformed.
+func ValidateOutput() Middleware { return factory.ValidateOutput() }
+
+// Cache reuses up to size results for identical conversion, options and
+// input.
+func Cache(size int) Middleware { return factory.Cache(size) }
+
+// ErrInputTooLarge is returned by SizeLimit.
+var ErrInputTooLarge = factory.ErrInputTooLarge
+
+// Register makes creator available for conversions from one format to
+// another, replacing any converter registered for the same pair.
+func Register(from, to FileFormat, creator Creator) {
+	factory.RegisterConverter(key(from, to), creator)
+}
+
+// RegisterVersion makes creator available as the info.Version converter
+// from one format to another, next to the other versions of the pair.
+// Pipelines run the newest version unless a step asks for another.
+func RegisterVersion(from, to FileFormat, info ConverterInfo, creator Creator) {
+	factory.RegisterConverterVersion(key(from, to), info, creator)
+}
+
+// Versions lists the converters registered from one format to another,
+// newest first.
+func Versions(from, to FileFormat) []ConverterInfo {
+	return factory.ConverterVersions(key(from, to))
+}
+
+// Supports reports whether a converter is registered for from to to.
+func Supports(from, to FileFormat) bool {
+	return factory.IsRegistered(key(from, to))
+}
+
+// Conversions lists every registered conversion as "from-to".
+func Conversions() []string {
+	return factory.RegisteredConversions()
+}
+
+func key(from, to FileFormat) string {
+	return string(from) + "-" + string(to)
+}
+
+// ProfilePath is where Options.Profile writes the report for outputPath.
+func ProfilePath(outputPath string) string {
+	return factory.ProfilePath(outputPath)
+}
+
+// WriteJUnit renders the result of running pipeline as JUnit XML, one test
+// case per step, for CI dashboards that already understand JUnit.
+func WriteJUnit(w io.Writer, pipeline *Pipeline, result *Result) error {
+	return junit.WritePipelineResult(w, pipeline, result)
+}
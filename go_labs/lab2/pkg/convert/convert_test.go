@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"tmps-go-labs/lab2/domain/factory"
+)
+
+type upperConverter struct{}
+
+func (upperConverter) Convert(input io.Reader, from, to FileFormat) *ConversionResult {
+	data, err := io.ReadAll(input)
+	return &ConversionResult{Data: []byte(strings.ToUpper(string(data))), Format: to, Error: err}
+}
+
+func (upperConverter) SupportsFormat(format FileFormat) bool {
+	return true
+}
+
+func TestPublicPipelineWithCustomConverter(t *testing.T) {
+	Register(JSON, "shout", func() Converter { return upperConverter{} })
+	t.Cleanup(func() { factory.UnregisterConverter("json-shout") })
+	assert.True(t, Supports(JSON, "shout"))
+	assert.Contains(t, Conversions(), "csv-json")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	output := filepath.Join(dir, "out.txt")
+	assert.NoError(t, os.WriteFile(input, []byte("name\nann\n"), 0644))
+
+	pipeline, err := NewBuilder().
+		WithInputPath(input).
+		WithOutputPath(output).
+		AddConversionStep(CSV, JSON).
+		AddConversionStep(JSON, "shout").
+		Build()
+	assert.NoError(t, err)
+
+	result := NewExecutor(1).Execute(pipeline)
+	assert.NoError(t, result.Error)
+
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"NAME": "ANN"`)
+}
@@ -0,0 +1,83 @@
+// Package converttest provides test doubles for code built on package
+// convert: a converter replaying scripted results, a search engine with
+// scripted matches and in-memory inputs and outputs for executors, so
+// integrations are unit-tested without real converters or files:
+//
+//	fake := &converttest.FakeConverter{Results: []converttest.FakeResult{{Output: "ok"}}}
+//	convert.Register(convert.CSV, "report", fake.Creator())
+//
+//	sink := &converttest.Sink{}
+//	executor := convert.NewExecutor(1).WithFileSystem(converttest.Files(converttest.Source{"in.csv": "id\n1\n"}, sink))
+//	result := executor.Execute(pipeline)
+//	report, ok := sink.File("out.report")
+//
+// Every double is safe for concurrent use and records how it was called.
+package converttest
+
+import (
+	"io"
+	"sync"
+
+	"tmps-go-labs/lab2/pkg/convert"
+)
+
+// FakeResult is one scripted outcome of a FakeConverter.
+type FakeResult struct {
+	Output string
+	Err    error
+}
+
+// ConvertCall is one call of a FakeConverter.
+type ConvertCall struct {
+	From  convert.FileFormat
+	To    convert.FileFormat
+	Input string
+}
+
+// FakeConverter converts by replaying Results in order, repeating the
+// last one once they run out. Without Results it returns its input
+// unchanged. It supports every format.
+type FakeConverter struct {
+	Results []FakeResult
+
+	mu    sync.Mutex
+	calls []ConvertCall
+}
+
+func (f *FakeConverter) Convert(input io.Reader, from, to convert.FileFormat) *convert.ConversionResult {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return &convert.ConversionResult{Format: to, Error: err}
+	}
+
+	f.mu.Lock()
+	n := len(f.calls)
+	f.calls = append(f.calls, ConvertCall{From: from, To: to, Input: string(data)})
+	f.mu.Unlock()
+
+	if len(f.Results) == 0 {
+		return &convert.ConversionResult{Data: data, Format: to}
+	}
+	result := f.Results[min(n, len(f.Results)-1)]
+	if result.Err != nil {
+		return &convert.ConversionResult{Format: to, Error: result.Err}
+	}
+	return &convert.ConversionResult{Data: []byte(result.Output), Format: to}
+}
+
+func (f *FakeConverter) SupportsFormat(format convert.FileFormat) bool {
+	return true
+}
+
+// Calls returns the calls made so far, in order.
+func (f *FakeConverter) Calls() []ConvertCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ConvertCall(nil), f.calls...)
+}
+
+// Creator returns a creator handing out f itself, for convert.Register,
+// so every pooled converter of the conversion shares its script and calls.
+func (f *FakeConverter) Creator() convert.Creator {
+	return func() convert.Converter { return f }
+}
@@ -0,0 +1,87 @@
+package converttest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/pkg/convert"
+)
+
+func TestFakeConverterInAPipeline(t *testing.T) {
+	fake := &FakeConverter{Results: []FakeResult{{Output: "first"}, {Err: errors.New("disk full")}}}
+	convert.Register(convert.CSV, "fake-report", fake.Creator())
+	t.Cleanup(func() { factory.UnregisterConverter("csv-fake-report") })
+
+	source := Source{"people.csv": "id\n1\n"}
+	sink := &Sink{}
+	executor := convert.NewExecutor(1).WithFileSystem(Files(source, sink))
+	pipeline, err := convert.NewBuilder().
+		WithInputPath("people.csv").
+		WithOutputPath("people.report").
+		AddConversionStep(convert.CSV, "fake-report").
+		Build()
+	require.NoError(t, err)
+
+	require.NoError(t, executor.Execute(pipeline).Error)
+	output, ok := sink.File("people.report")
+	assert.True(t, ok)
+	assert.Equal(t, "first", output)
+	assert.Equal(t, []string{"people.report"}, sink.Names())
+
+	assert.ErrorContains(t, executor.Execute(pipeline).Error, "disk full")
+	assert.ErrorContains(t, executor.Execute(pipeline).Error, "disk full", "the last result repeats")
+	assert.Equal(t, []ConvertCall{{From: convert.CSV, To: "fake-report", Input: "id\n1\n"}}, fake.Calls()[:1])
+	assert.Len(t, fake.Calls(), 3)
+	assert.Equal(t, Source{"people.csv": "id\n1\n"}, source)
+}
+
+func TestFakeConverterWithoutResultsEchoes(t *testing.T) {
+	fake := &FakeConverter{}
+	result := fake.Convert(strings.NewReader("a,b\n"), convert.CSV, convert.JSON)
+	require.NoError(t, result.Error)
+	assert.Equal(t, "a,b\n", string(result.Data))
+	assert.Equal(t, convert.JSON, result.Format)
+}
+
+func TestFakeSearchEngineInASearchStep(t *testing.T) {
+	fake := &FakeSearchEngine{Matches: map[string]bool{"2": true}}
+	fake.Register("fake")
+
+	sink := &Sink{}
+	executor := convert.NewExecutor(1).WithFileSystem(Files(Source{"ids.csv": "id\n1\n2\n"}, sink))
+	pipeline, err := convert.NewBuilder().
+		WithInputPath("ids.csv").
+		WithOutputPath("ids.ndjson").
+		AddConversionStep(convert.CSV, convert.NDJSON).
+		AddSearch(convert.NDJSON, convert.Search{Engine: "fake", Query: "anything"}).
+		Build()
+	require.NoError(t, err)
+
+	require.NoError(t, executor.Execute(pipeline).Error)
+	output, _ := sink.File("ids.ndjson")
+	assert.Equal(t, "{\"id\":\"2\"}\n", output)
+	assert.Equal(t, []SearchCall{{Text: "1", Query: "anything"}, {Text: "2", Query: "anything"}}, fake.Calls())
+}
+
+func TestFiles(t *testing.T) {
+	sink := &Sink{}
+	files := Files(Source{"in.txt": "source"}, sink)
+
+	_, err := files.Open("missing.txt")
+	assert.ErrorContains(t, err, "open missing.txt: file does not exist")
+	require.NoError(t, files.WriteFile("in.txt", []byte("written")))
+	file, err := files.Open("in.txt")
+	require.NoError(t, err)
+	data := make([]byte, 16)
+	n, _ := file.Read(data)
+	assert.Equal(t, "written", string(data[:n]), "written files shadow the source")
+
+	require.NoError(t, files.Remove("in.txt"))
+	assert.Error(t, files.Remove("in.txt"))
+	assert.Empty(t, sink.Names())
+}
@@ -0,0 +1,83 @@
+package converttest
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"sort"
+	"sync"
+
+	"tmps-go-labs/lab2/pkg/convert"
+)
+
+// Source holds the input files of a test, keyed by name.
+type Source map[string]string
+
+// Sink collects the files written to it. The zero value is ready to use.
+type Sink struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// File returns the contents of the file called name and whether it was
+// written.
+func (s *Sink) File(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[name]
+	return string(data), ok
+}
+
+// Names returns the names of the files written, in order.
+func (s *Sink) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Files returns a file system for convert.Executor.WithFileSystem reading
+// the files of sink, then those of source, and writing to sink. Source is
+// never changed.
+func Files(source Source, sink *Sink) convert.FileSystem {
+	return &files{source: source, sink: sink}
+}
+
+type files struct {
+	source Source
+	sink   *Sink
+}
+
+func (f *files) Open(name string) (io.ReadCloser, error) {
+	if data, ok := f.sink.File(name); ok {
+		return io.NopCloser(bytes.NewReader([]byte(data))), nil
+	}
+	if data, ok := f.source[name]; ok {
+		return io.NopCloser(bytes.NewReader([]byte(data))), nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (f *files) WriteFile(name string, data []byte) error {
+	f.sink.mu.Lock()
+	defer f.sink.mu.Unlock()
+	if f.sink.files == nil {
+		f.sink.files = make(map[string][]byte)
+	}
+	f.sink.files[name] = bytes.Clone(data)
+	return nil
+}
+
+func (f *files) Remove(name string) error {
+	f.sink.mu.Lock()
+	defer f.sink.mu.Unlock()
+	if _, ok := f.sink.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(f.sink.files, name)
+	return nil
+}
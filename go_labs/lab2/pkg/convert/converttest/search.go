@@ -0,0 +1,42 @@
+package converttest
+
+import (
+	"sync"
+
+	"tmps-go-labs/lab1/search"
+)
+
+// SearchCall is one call of a FakeSearchEngine.
+type SearchCall struct {
+	Text  string
+	Query string
+}
+
+// FakeSearchEngine matches the texts set in Matches, whatever the query.
+// Register it to use it in a pipeline's search stage.
+type FakeSearchEngine struct {
+	Matches map[string]bool
+
+	mu    sync.Mutex
+	calls []SearchCall
+}
+
+func (f *FakeSearchEngine) Search(text, query string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, SearchCall{Text: text, Query: query})
+	return f.Matches[text]
+}
+
+// Calls returns the calls made so far, in order.
+func (f *FakeSearchEngine) Calls() []SearchCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SearchCall(nil), f.calls...)
+}
+
+// Register makes f the search engine called name, for search steps with
+// that Engine.
+func (f *FakeSearchEngine) Register(name string) {
+	search.Register(name, func() search.Engine { return f })
+}
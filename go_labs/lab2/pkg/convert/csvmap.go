@@ -0,0 +1,269 @@
+package convert
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// CSVUnmarshaler is implemented by field types that parse their own CSV
+// cell. It takes precedence over encoding.TextUnmarshaler.
+type CSVUnmarshaler interface {
+	UnmarshalCSV(value string) error
+}
+
+// CSVMarshaler is implemented by field types that format their own CSV cell.
+type CSVMarshaler interface {
+	MarshalCSV() (string, error)
+}
+
+// csvField maps one struct field to a CSV column. The column name comes from
+// the csv tag, falling back to the json tag and then the field name; the
+// ",required" flag rejects missing columns and empty cells.
+type csvField struct {
+	index    []int
+	column   string
+	required bool
+}
+
+var (
+	csvUnmarshalerType  = reflect.TypeOf((*CSVUnmarshaler)(nil)).Elem()
+	csvMarshalerType    = reflect.TypeOf((*CSVMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// UnmarshalCSV reads CSV rows into structs of type T using csv struct tags:
+//
+//	type Person struct {
+//		Name string    `csv:"name,required"`
+//		Age  int       `csv:"age"`
+//		Born time.Time `csv:"born"`
+//	}
+func UnmarshalCSV[T any](input io.Reader, options ...Option) ([]T, error) {
+	var rows []T
+	if err := unmarshalCSV(input, reflect.ValueOf(&rows).Elem(), options); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// MarshalCSV writes structs as CSV with one column per mapped field, in
+// field order.
+func MarshalCSV[T any](rows []T, options ...Option) ([]byte, error) {
+	return marshalCSV(reflect.ValueOf(rows), options)
+}
+
+// isStructSlice reports whether t is a slice of structs, the shape that
+// ConvertInto and ConvertFrom map through csv tags.
+func isStructSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct
+}
+
+func unmarshalCSV(input io.Reader, rows reflect.Value, options []Option) error {
+	structType := rows.Type().Elem()
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("CSV rows must be structs, got %s", structType)
+	}
+
+	reader := csv.NewReader(input)
+	reader.Comma = models.NewOptions(options...).Delimiter()
+	table, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(table) == 0 {
+		return nil
+	}
+
+	fields := csvFields(structType)
+	columns := make(map[string]int, len(table[0]))
+	for i, name := range table[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, field := range fields {
+		if _, ok := columns[field.column]; !ok && field.required {
+			return fmt.Errorf("required column %q is missing", field.column)
+		}
+	}
+
+	result := reflect.MakeSlice(rows.Type(), 0, len(table)-1)
+	for r, record := range table[1:] {
+		row := reflect.New(structType).Elem()
+		for _, field := range fields {
+			i, ok := columns[field.column]
+			if !ok {
+				continue
+			}
+			cell := ""
+			if i < len(record) {
+				cell = record[i]
+			}
+			if cell == "" && field.required {
+				return fmt.Errorf("row %d, column %s: value is required", r+2, field.column)
+			}
+			if err := setCSVValue(row.FieldByIndex(field.index), cell); err != nil {
+				return fmt.Errorf("row %d, column %s: %w", r+2, field.column, err)
+			}
+		}
+		result = reflect.Append(result, row)
+	}
+	rows.Set(result)
+	return nil
+}
+
+func marshalCSV(rows reflect.Value, options []Option) ([]byte, error) {
+	structType := rows.Type().Elem()
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("CSV rows must be structs, got %s", structType)
+	}
+
+	fields := csvFields(structType)
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.column
+	}
+
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	writer.Comma = models.NewOptions(options...).Delimiter()
+	writer.Write(header)
+
+	for r := 0; r < rows.Len(); r++ {
+		record := make([]string, len(fields))
+		for i, field := range fields {
+			cell, err := formatCSVValue(rows.Index(r).FieldByIndex(field.index))
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %s: %w", r+1, field.column, err)
+			}
+			record[i] = cell
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+	return out.Bytes(), writer.Error()
+}
+
+func csvFields(structType reflect.Type) []csvField {
+	var fields []csvField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, hasTag := field.Tag.Lookup("csv")
+		if !hasTag {
+			tag = field.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, csvField{
+			index:    field.Index,
+			column:   name,
+			required: hasTag && strings.Contains(","+flags+",", ",required,"),
+		})
+	}
+	return fields
+}
+
+func setCSVValue(field reflect.Value, cell string) error {
+	if field.Kind() == reflect.Pointer {
+		if cell == "" {
+			return nil
+		}
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	if field.Addr().Type().Implements(csvUnmarshalerType) {
+		return field.Addr().Interface().(CSVUnmarshaler).UnmarshalCSV(cell)
+	}
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		if cell == "" {
+			return nil
+		}
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(cell))
+	}
+	if cell == "" {
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(cell, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func formatCSVValue(field reflect.Value) (string, error) {
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return "", nil
+		}
+		field = field.Elem()
+	}
+
+	if field.Type().Implements(csvMarshalerType) {
+		return field.Interface().(CSVMarshaler).MarshalCSV()
+	}
+	if field.Type().Implements(textMarshalerType) {
+		text, err := field.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, field.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported field type %s", field.Type())
+	}
+}
@@ -0,0 +1,56 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type grade string
+
+func (g *grade) UnmarshalCSV(value string) error {
+	if value != "A" && value != "B" {
+		return fmt.Errorf("unknown grade %q", value)
+	}
+	*g = grade(value)
+	return nil
+}
+
+type student struct {
+	Name   string    `csv:"name,required"`
+	Age    int       `csv:"age"`
+	Joined time.Time `csv:"joined"`
+	Grade  grade     `csv:"grade"`
+	Note   *string   `csv:"note"`
+	Secret string    `csv:"-"`
+}
+
+func TestUnmarshalCSVWithTags(t *testing.T) {
+	input := "name;age;joined;grade;note\nAnn;31;2024-03-01T00:00:00Z;A;\nBob;28;2023-01-15T00:00:00Z;B;late\n"
+
+	students, err := ConvertInto[[]student](strings.NewReader(input), CSV, WithCSVDelimiter(';'))
+	assert.NoError(t, err)
+	assert.Len(t, students, 2)
+	assert.Equal(t, 31, students[0].Age)
+	assert.Equal(t, 2024, students[0].Joined.Year())
+	assert.Nil(t, students[0].Note)
+	assert.Equal(t, "late", *students[1].Note)
+
+	data, err := MarshalCSV(students[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, "name,age,joined,grade,note\nAnn,31,2024-03-01T00:00:00Z,A,\n", string(data))
+}
+
+func TestUnmarshalCSVReportsInvalidRows(t *testing.T) {
+	_, err := UnmarshalCSV[student](strings.NewReader("age\n3\n"))
+	assert.ErrorContains(t, err, `required column "name" is missing`)
+
+	_, err = UnmarshalCSV[student](strings.NewReader("name,age\n,3\n"))
+	assert.ErrorContains(t, err, "row 2, column name: value is required")
+
+	_, err = UnmarshalCSV[student](strings.NewReader("name,grade\nAnn,C\n"))
+	assert.ErrorContains(t, err, `row 2, column grade: unknown grade "C"`)
+}
@@ -0,0 +1,40 @@
+// Package convert is the public, importable API of the lab2 file converters.
+//
+// External projects should depend on this package only. Most callers need
+// just the one-call helpers, which plan the conversion themselves:
+//
+//	err := convert.File("people.csv", "people.yaml")
+//	json, err := convert.Bytes(data, convert.XML, convert.JSON)
+//
+// For full pipelines it offers a Builder and an Executor, wrapping the
+// internal ones with a method set of their own:
+//
+//	executor := convert.NewExecutor(5)
+//	pipeline, err := convert.NewBuilder().
+//		WithInputPath("people.csv").
+//		WithOutputPath("people.yaml").
+//		AddConversionStep(convert.CSV, convert.JSON).
+//		AddConversionStep(convert.JSON, convert.YAML).
+//		Build()
+//	if err != nil {
+//		return err
+//	}
+//	result := executor.Execute(pipeline)
+//
+// Custom converters plug in through Register:
+//
+//	convert.Register(convert.CSV, "tsv", func() convert.Converter { return &TSVConverter{} })
+//
+// # Stability
+//
+// The identifiers exported here follow semantic versioning: within a major
+// version they are neither removed nor changed incompatibly, and new
+// options are added as fields with zero values that keep today's behavior.
+// The domain packages behind them (models, factory, service, ...) are
+// implementation details and may change in any release. Builder and
+// Executor are types of this package, so methods added to the internal
+// builder and executor do not become API. The plain data types, such as
+// FileFormat, Options, Pipeline and Result, and the Converter contract are
+// aliases of the domain types; their exported fields and methods are
+// covered by the same promise.
+package convert
@@ -0,0 +1,21 @@
+package convert
+
+import "tmps-go-labs/lab2/domain/events"
+
+// Event is published by an Executor to its subscribers while a pipeline runs.
+type Event = events.Event
+
+// Lifecycle events, in the order an Executor publishes them.
+type (
+	PipelineStarted  = events.PipelineStarted
+	StepStarted      = events.StepStarted
+	StepCompleted    = events.StepCompleted
+	StepFailed       = events.StepFailed
+	PipelineFinished = events.PipelineFinished
+)
+
+// Observer receives events from Executor.Subscribe.
+type Observer = events.Observer
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc = events.ObserverFunc
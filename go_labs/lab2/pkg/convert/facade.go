@@ -0,0 +1,107 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+// Bytes converts data between two formats along the shortest chain of
+// registered conversions, using a shared executor. Data already in the
+// target format is returned unchanged.
+//
+//	yaml, err := convert.Bytes(csvData, convert.CSV, convert.YAML)
+func Bytes(data []byte, from, to FileFormat, options ...Option) ([]byte, error) {
+	if from == to {
+		return data, nil
+	}
+	return runPlan(data, from, to, options)
+}
+
+// File converts the file at in and writes the result to out, inferring both
+// formats from the file extensions (.csv, .json, .xml, .yaml, .md, .ndjson,
+// .xlsx, ...). The output is replaced atomically. As with Bytes, an input
+// already in the output's format is copied unchanged.
+//
+//	err := convert.File("people.csv", "people.yaml")
+func File(in, out string, options ...Option) error {
+	from, ok := FormatFromPath(in)
+	if !ok {
+		return fmt.Errorf("cannot infer the format of %s from its extension", in)
+	}
+	to, ok := FormatFromPath(out)
+	if !ok {
+		return fmt.Errorf("cannot infer the format of %s from its extension", out)
+	}
+
+	if from == to {
+		return copyFile(in, out)
+	}
+
+	steps, err := factory.PlanConversion(from, to)
+	if err != nil {
+		return err
+	}
+
+	builder := NewBuilder().WithInputPath(in).WithOutputPath(out).Apply(options...)
+	for _, step := range steps {
+		builder.AddConversionStep(step.From, step.To)
+	}
+	pipeline, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	result := defaultExecutor().Execute(pipeline)
+	if !result.Success {
+		return result.Error
+	}
+	return nil
+}
+
+// copyFile replaces out with the contents of in through a temporary file
+// in the same directory, so readers never see a partial copy.
+func copyFile(in, out string) error {
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(out), "."+filepath.Base(out)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Chmod(0644); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), out)
+}
+
+// FormatFromPath infers a file's format from its extension. It is the
+// extension table the convert command and lookup steps use.
+func FormatFromPath(path string) (FileFormat, bool) {
+	return models.FormatFromPath(path)
+}
+
+// MediaType returns the media type of a format, such as "text/csv".
+func MediaType(format FileFormat) (string, bool) {
+	return models.MediaType(format)
+}
+
+// FormatFromMediaType maps a media type such as a Content-Type or Accept
+// value to its format, ignoring parameters.
+func FormatFromMediaType(mediaType string) (FileFormat, bool) {
+	return models.FormatFromMediaType(mediaType)
+}
@@ -0,0 +1,65 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytes(t *testing.T) {
+	output, err := Bytes([]byte("name\nann\n"), CSV, JSON, WithIndentWidth(0))
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name":"ann"}]`, string(output))
+
+	_, err = Bytes([]byte("x"), CSV, "pdf")
+	assert.Error(t, err)
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "people.csv")
+	out := filepath.Join(dir, "people.yml")
+	assert.NoError(t, os.WriteFile(in, []byte("name\nann\n"), 0644))
+
+	assert.NoError(t, File(in, out))
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "name: ann")
+
+	assert.Error(t, File(in, filepath.Join(dir, "people.unknown")))
+}
+
+func TestSameFormatIsCopied(t *testing.T) {
+	output, err := Bytes([]byte("name\nann\n"), CSV, CSV)
+	assert.NoError(t, err)
+	assert.Equal(t, "name\nann\n", string(output))
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "people.yaml")
+	out := filepath.Join(dir, "copy.yml")
+	assert.NoError(t, os.WriteFile(in, []byte("name: ann\n"), 0644))
+
+	assert.NoError(t, File(in, out))
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "name: ann\n", string(data))
+}
+
+func TestMediaTypes(t *testing.T) {
+	format, ok := FormatFromMediaType("Text/CSV; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, CSV, format)
+
+	format, ok = FormatFromMediaType("application/x-yaml")
+	assert.True(t, ok)
+	assert.Equal(t, YAML, format)
+
+	mediaType, ok := MediaType(JSON)
+	assert.True(t, ok)
+	assert.Equal(t, "application/json", mediaType)
+
+	_, ok = FormatFromMediaType("image/png")
+	assert.False(t, ok)
+}
@@ -0,0 +1,94 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"tmps-go-labs/lab2/domain/factory"
+	"tmps-go-labs/lab2/domain/models"
+)
+
+var defaultExecutor = sync.OnceValue(func() *Executor {
+	return NewExecutor(4)
+})
+
+// ConvertInto decodes input of the given format into a value of type T. The
+// input is first converted to JSON with the registered converters, then
+// unmarshaled with encoding/json, so T uses ordinary json struct tags. CSV
+// into a slice of structs is mapped directly through csv tags instead, see
+// UnmarshalCSV.
+func ConvertInto[T any](input io.Reader, from FileFormat, options ...Option) (T, error) {
+	var value T
+
+	if from == CSV && isStructSlice(reflect.TypeOf((*T)(nil)).Elem()) {
+		err := unmarshalCSV(input, reflect.ValueOf(&value).Elem(), options)
+		return value, err
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return value, fmt.Errorf("failed to read %s: %w", from, err)
+	}
+
+	data, err = runPlan(data, from, JSON, options)
+	if err != nil {
+		return value, err
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, fmt.Errorf("failed to decode %s into %T: %w", from, value, err)
+	}
+	return value, nil
+}
+
+// ConvertFrom encodes v as JSON and converts it to the given format with the
+// registered converters. A slice of structs converted to CSV is written
+// through csv tags, see MarshalCSV.
+func ConvertFrom[T any](v T, to FileFormat, options ...Option) ([]byte, error) {
+	if to == CSV && isStructSlice(reflect.TypeOf((*T)(nil)).Elem()) {
+		return marshalCSV(reflect.ValueOf(v), options)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %T: %w", v, err)
+	}
+	if to == JSON {
+		return marshalJSON(data, options)
+	}
+	return runPlan(data, JSON, to, options)
+}
+
+func runPlan(data []byte, from, to FileFormat, options []Option) ([]byte, error) {
+	steps, err := factory.PlanConversion(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return data, nil
+	}
+
+	pipeline := &Pipeline{Steps: steps, Options: models.NewOptions(options...)}
+	output, result := defaultExecutor().executor.ConvertData(context.Background(), pipeline, data)
+	if !result.Success {
+		return nil, result.Error
+	}
+	return output, nil
+}
+
+// marshalJSON re-indents JSON output the way the JSON converters would.
+func marshalJSON(data []byte, options []Option) ([]byte, error) {
+	indent, _ := models.NewOptions(options...).Indentation()
+	if indent == "" {
+		return data, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(value, "", indent)
+}
@@ -0,0 +1,30 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type person struct {
+	Name string `json:"name"`
+	City string `json:"city"`
+}
+
+func TestConvertIntoAndFrom(t *testing.T) {
+	people, err := ConvertInto[[]person](strings.NewReader("name,city\nAnn,Oslo\nBob,Rome\n"), CSV)
+	assert.NoError(t, err)
+	assert.Equal(t, []person{{"Ann", "Oslo"}, {"Bob", "Rome"}}, people)
+
+	data, err := ConvertFrom(people, YAML)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "name: Ann")
+
+	data, err = ConvertFrom(people[0], JSON, WithIndentWidth(0))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Ann","city":"Oslo"}`, string(data))
+
+	_, err = ConvertInto[[]person](strings.NewReader(""), "nope")
+	assert.Error(t, err)
+}
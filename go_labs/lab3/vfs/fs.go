@@ -0,0 +1,52 @@
+package vfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// FS adapts an io/fs file system, such as an embed.FS or fstest.MapFS,
+// read-only.
+type FS struct {
+	FS fs.FS
+}
+
+func (f FS) Open(name string) (io.ReadCloser, error) {
+	return f.FS.Open(name)
+}
+
+func (f FS) WriteFile(name string, data []byte) error {
+	return &fs.PathError{Op: "write", Path: name, Err: ErrReadOnly}
+}
+
+func (f FS) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
+
+// NewZip returns the entries of the zip archive read from r, of size
+// bytes, as a read-only file system. Entries are named by their slash
+// separated paths in the archive.
+func NewZip(r io.ReaderAt, size int64) (FS, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return FS{}, err
+	}
+	return FS{FS: archive}, nil
+}
+
+// ReadZip reads the zip archive called name from fsys, which may itself
+// be remote or in memory, and returns its entries as NewZip does.
+func ReadZip(fsys FileSystem, name string) (FS, error) {
+	data, err := ReadFile(fsys, name)
+	if err != nil {
+		return FS{}, err
+	}
+	archive, err := NewZip(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return FS{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return archive, nil
+}
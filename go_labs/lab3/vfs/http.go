@@ -0,0 +1,94 @@
+package vfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultHTTPTimeout bounds a whole request, body included, when HTTP
+	// has no Client of its own.
+	DefaultHTTPTimeout = time.Minute
+	// DefaultMaxHTTPBytes is the largest response body HTTP reads when
+	// MaxBytes is zero.
+	DefaultMaxHTTPBytes = 256 << 20
+)
+
+var defaultHTTPClient = &http.Client{Timeout: DefaultHTTPTimeout}
+
+// HTTP adapts an HTTP server, read-only: opening a file fetches Base
+// followed by its name with a GET request. With an empty Base names are
+// full URLs. A 404 response is reported as fs.ErrNotExist and any other
+// status outside 2xx as an error naming it. Reading a body longer than
+// MaxBytes fails, so a huge or endless response cannot exhaust memory.
+type HTTP struct {
+	// Client sends the requests; nil means a client that gives up after
+	// DefaultHTTPTimeout.
+	Client *http.Client
+	Base   string
+	// MaxBytes is the largest body read; zero means DefaultMaxHTTPBytes.
+	MaxBytes int64
+}
+
+func (h *HTTP) Open(name string) (io.ReadCloser, error) {
+	client := h.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	limit := h.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxHTTPBytes
+	}
+	response, err := client.Get(h.Base + name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		response.Body.Close()
+		err := errors.New(response.Status)
+		if response.StatusCode == http.StatusNotFound {
+			err = fs.ErrNotExist
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	tooLarge := &fs.PathError{Op: "read", Path: name, Err: fmt.Errorf("response body exceeds %d bytes", limit)}
+	if response.ContentLength > limit {
+		response.Body.Close()
+		return nil, tooLarge
+	}
+	return &limitedBody{ReadCloser: response.Body, left: limit, err: tooLarge}, nil
+}
+
+// limitedBody fails with err once more than left bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+	err  error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.left < 0 {
+		return 0, b.err
+	}
+	if int64(len(p)) > b.left+1 {
+		p = p[:b.left+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.left -= int64(n)
+	if b.left < 0 {
+		return n + int(b.left), b.err
+	}
+	return n, err
+}
+
+func (h *HTTP) WriteFile(name string, data []byte) error {
+	return &fs.PathError{Op: "write", Path: name, Err: ErrReadOnly}
+}
+
+func (h *HTTP) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
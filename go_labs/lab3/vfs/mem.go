@@ -0,0 +1,67 @@
+package vfs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"sort"
+	"sync"
+)
+
+// Mem keeps files in memory, for tests and for pipelines whose input and
+// output never reach the disk. It is safe for concurrent use.
+type Mem struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMem returns a memory file system holding files, keyed by name.
+func NewMem(files map[string][]byte) *Mem {
+	m := &Mem{files: make(map[string][]byte, len(files))}
+	for name, data := range files {
+		m.files[name] = bytes.Clone(data)
+	}
+	return m
+}
+
+func (m *Mem) Open(name string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *Mem) WriteFile(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[string][]byte)
+	}
+	m.files[name] = bytes.Clone(data)
+	return nil
+}
+
+func (m *Mem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+// Names returns the names of the files held, in order.
+func (m *Mem) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,59 @@
+package vfs
+
+import (
+	"io"
+	"strings"
+)
+
+// Mux is a file system composed of others: each name goes, unchanged, to
+// the file system handling the longest prefix of it, or to the fallback.
+type Mux struct {
+	fallback FileSystem
+	routes   []route
+}
+
+type route struct {
+	prefix string
+	fsys   FileSystem
+}
+
+// NewMux returns a Mux sending every name to fallback until prefixes are
+// handled.
+func NewMux(fallback FileSystem) *Mux {
+	return &Mux{fallback: fallback}
+}
+
+// Handle sends the names starting with prefix to fsys, replacing any file
+// system handling the same prefix.
+func (m *Mux) Handle(prefix string, fsys FileSystem) *Mux {
+	for i, r := range m.routes {
+		if r.prefix == prefix {
+			m.routes[i].fsys = fsys
+			return m
+		}
+	}
+	m.routes = append(m.routes, route{prefix: prefix, fsys: fsys})
+	return m
+}
+
+func (m *Mux) Open(name string) (io.ReadCloser, error) {
+	return m.route(name).Open(name)
+}
+
+func (m *Mux) WriteFile(name string, data []byte) error {
+	return m.route(name).WriteFile(name, data)
+}
+
+func (m *Mux) Remove(name string) error {
+	return m.route(name).Remove(name)
+}
+
+func (m *Mux) route(name string) FileSystem {
+	fsys, longest := m.fallback, -1
+	for _, r := range m.routes {
+		if strings.HasPrefix(name, r.prefix) && len(r.prefix) > longest {
+			fsys, longest = r.fsys, len(r.prefix)
+		}
+	}
+	return fsys
+}
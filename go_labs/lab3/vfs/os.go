@@ -0,0 +1,41 @@
+package vfs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// OS adapts the local disk. Files are written through a temporary file
+// renamed into place, so an interrupted write never leaves a truncated
+// file behind.
+type OS struct{}
+
+func (OS) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+func (OS) WriteFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (OS) Remove(name string) error {
+	return os.Remove(name)
+}
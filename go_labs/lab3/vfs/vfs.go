@@ -0,0 +1,42 @@
+// Package vfs is a virtual file system: one FileSystem interface adapting
+// the local disk, memory, zip archives, HTTP servers and any io/fs file
+// system, so the search tool and the converter read and write files the
+// same way wherever they live, and tests run without touching the disk.
+package vfs
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrReadOnly is returned when writing to or removing from a file system
+// that can only be read.
+var ErrReadOnly = errors.New("read-only file system")
+
+// FileSystem is the file access the tools depend on. Errors for missing
+// files match fs.ErrNotExist.
+type FileSystem interface {
+	// Open opens the file called name for reading.
+	Open(name string) (io.ReadCloser, error)
+	// WriteFile replaces the file called name with data.
+	WriteFile(name string, data []byte) error
+	// Remove removes the file called name.
+	Remove(name string) error
+}
+
+// ReadFile reads the whole file called name from fsys.
+func ReadFile(fsys FileSystem, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Default returns the file system of the command line tools: the local
+// disk, with http:// and https:// URLs fetched over HTTP.
+func Default() *Mux {
+	remote := &HTTP{}
+	return NewMux(OS{}).Handle("http://", remote).Handle("https://", remote)
+}
@@ -0,0 +1,137 @@
+package vfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOSWritesAndRemovesFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	fsys := OS{}
+
+	require.NoError(t, fsys.WriteFile(path, []byte("first")))
+	require.NoError(t, fsys.WriteFile(path, []byte("second")))
+	data, err := ReadFile(fsys, path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+
+	require.NoError(t, fsys.Remove(path))
+	_, err = ReadFile(fsys, path)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMem(t *testing.T) {
+	fsys := NewMem(map[string][]byte{"in.csv": []byte("a,b\n")})
+
+	data, err := ReadFile(fsys, "in.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n", string(data))
+
+	require.NoError(t, fsys.WriteFile("out/result.json", []byte("[]")))
+	assert.Equal(t, []string{"in.csv", "out/result.json"}, fsys.Names())
+
+	require.NoError(t, fsys.Remove("in.csv"))
+	assert.ErrorIs(t, fsys.Remove("in.csv"), fs.ErrNotExist)
+	_, err = fsys.Open("in.csv")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestZipIsReadOnly(t *testing.T) {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	entry, err := writer.Create("data/people.csv")
+	require.NoError(t, err)
+	entry.Write([]byte("name\nAnn\n"))
+	require.NoError(t, writer.Close())
+
+	fsys, err := ReadZip(NewMem(map[string][]byte{"people.zip": archive.Bytes()}), "people.zip")
+	require.NoError(t, err)
+	data, err := ReadFile(fsys, "data/people.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "name\nAnn\n", string(data))
+
+	_, err = fsys.Open("data/missing.csv")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorIs(t, fsys.WriteFile("data/people.csv", nil), ErrReadOnly)
+	assert.ErrorIs(t, fsys.Remove("data/people.csv"), ErrReadOnly)
+
+	_, err = ReadZip(NewMem(map[string][]byte{"bad.zip": []byte("not a zip")}), "bad.zip")
+	assert.ErrorContains(t, err, "bad.zip: zip: not a valid zip file")
+}
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/in.txt":
+			w.Write([]byte("hello"))
+		case "/files/secret.txt":
+			w.WriteHeader(http.StatusForbidden)
+		case "/files/endless.txt":
+			w.(http.Flusher).Flush()
+			for range 64 {
+				w.Write([]byte("0123456789"))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fsys := &HTTP{Base: server.URL + "/files/"}
+	data, err := ReadFile(fsys, "in.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = fsys.Open("missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = fsys.Open("secret.txt")
+	assert.EqualError(t, err, "open secret.txt: 403 Forbidden")
+	assert.ErrorIs(t, fsys.WriteFile("in.txt", nil), ErrReadOnly)
+
+	limited := &HTTP{Base: server.URL + "/files/", MaxBytes: 100}
+	_, err = ReadFile(limited, "endless.txt")
+	assert.EqualError(t, err, "read endless.txt: response body exceeds 100 bytes")
+	data, err = ReadFile(&HTTP{Base: server.URL + "/files/", MaxBytes: 5}, "in.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	_, err = ReadFile(&HTTP{Base: server.URL + "/files/", MaxBytes: 4}, "in.txt")
+	assert.EqualError(t, err, "read in.txt: response body exceeds 4 bytes")
+
+	data, err = ReadFile(Default(), server.URL+"/files/in.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestMuxRoutesByLongestPrefix(t *testing.T) {
+	disk := NewMem(nil)
+	cache := NewMem(map[string][]byte{"cache/a": []byte("cached")})
+	fixtures := FS{FS: fstest.MapFS{"fixtures/hot/a": {Data: []byte("hot")}}}
+	mux := NewMux(disk).Handle("cache/", cache).Handle("fixtures/", NewMem(nil)).Handle("fixtures/hot/", fixtures)
+
+	data, err := ReadFile(mux, "cache/a")
+	require.NoError(t, err)
+	assert.Equal(t, "cached", string(data))
+	data, err = ReadFile(mux, "fixtures/hot/a")
+	require.NoError(t, err)
+	assert.Equal(t, "hot", string(data))
+
+	require.NoError(t, mux.WriteFile("out.txt", []byte("x")))
+	assert.Equal(t, []string{"out.txt"}, disk.Names())
+	assert.ErrorIs(t, mux.WriteFile("fixtures/hot/b", nil), ErrReadOnly)
+
+	mux.Handle("cache/", disk)
+	_, err = mux.Open("cache/a")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
@@ -0,0 +1,182 @@
+package rules
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Action is what a violated rule does to its record.
+type Action string
+
+const (
+	// Warn reports the violation and passes the record on.
+	Warn Action = "warn"
+	// Drop reports the violation and removes the record.
+	Drop Action = "drop"
+	// Fail reports the violation and stops the run.
+	Fail Action = "fail"
+)
+
+// ParseAction returns the action called name; "" means Warn.
+func ParseAction(name string) (Action, error) {
+	switch action := Action(name); action {
+	case "":
+		return Warn, nil
+	case Warn, Drop, Fail:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown action %q; use warn, drop or fail", name)
+}
+
+// Violation is a record failing a rule. Record counts from 1.
+type Violation struct {
+	Record  int
+	Rule    string
+	Message string
+	Action  Action
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("record %d: %s: %s", v.Record, v.Rule, v.Message)
+}
+
+// Observer is notified of every violation as the engine finds it.
+type Observer interface {
+	Violated(violation Violation)
+}
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc func(violation Violation)
+
+func (f ObserverFunc) Violated(violation Violation) {
+	f(violation)
+}
+
+// Verdict is what the chain decided for a record.
+type Verdict int
+
+const (
+	Kept Verdict = iota
+	Dropped
+	Failed
+)
+
+// handler is a link of the chain: it checks its rule and passes the
+// record on to the next link unless the rule stops it.
+type handler struct {
+	name   string
+	rule   Rule
+	action Action
+	next   *handler
+}
+
+func (h *handler) handle(index int, record Record, notify func(Violation)) (Verdict, *Violation) {
+	if h == nil {
+		return Kept, nil
+	}
+	message := h.rule.Check(record)
+	if message == "" {
+		return h.next.handle(index, record, notify)
+	}
+
+	violation := Violation{Record: index, Rule: h.name, Message: message, Action: h.action}
+	notify(violation)
+	switch h.action {
+	case Drop:
+		return Dropped, &violation
+	case Fail:
+		return Failed, &violation
+	}
+	return h.next.handle(index, record, notify)
+}
+
+// Engine runs records through a chain of rules. Observers may subscribe
+// while records are being checked.
+type Engine struct {
+	first, last *handler
+
+	mu        sync.RWMutex
+	observers []Observer
+}
+
+func New() *Engine {
+	return &Engine{}
+}
+
+// Add appends rule, called name, to the end of the chain.
+func (e *Engine) Add(name string, rule Rule, action Action) *Engine {
+	link := &handler{name: name, rule: rule, action: action}
+	if e.last == nil {
+		e.first = link
+	} else {
+		e.last.next = link
+	}
+	e.last = link
+	return e
+}
+
+// Subscribe notifies observer of every violation from now on.
+func (e *Engine) Subscribe(observer Observer) *Engine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.observers = append(e.observers, observer)
+	return e
+}
+
+func (e *Engine) notify(violation Violation) {
+	e.mu.RLock()
+	observers := e.observers
+	e.mu.RUnlock()
+	for _, observer := range observers {
+		observer.Violated(violation)
+	}
+}
+
+// Check runs the record numbered index through the chain. The violation
+// returned is the one that dropped or failed the record.
+func (e *Engine) Check(index int, record Record) (Verdict, *Violation) {
+	return e.first.handle(index, record, e.notify)
+}
+
+// Report counts the records of a run.
+type Report struct {
+	Checked    int
+	Dropped    int
+	Violations int
+}
+
+// FailedError stops a run at a violated rule whose action is Fail.
+type FailedError struct {
+	Violation Violation
+}
+
+func (e *FailedError) Error() string {
+	return "data quality check failed: " + e.Violation.String()
+}
+
+// Run checks records in order and returns those kept. It stops at the
+// first record failing a rule with the Fail action, with a *FailedError.
+func (e *Engine) Run(records []Record) ([]Record, Report, error) {
+	var report Report
+	// The run counts its violations itself rather than subscribing, so
+	// runs do not see each other's counts.
+	notify := func(violation Violation) {
+		report.Violations++
+		e.notify(violation)
+	}
+
+	kept := make([]Record, 0, len(records))
+	for i, record := range records {
+		report.Checked++
+		verdict, violation := e.first.handle(i+1, record, notify)
+		switch verdict {
+		case Kept:
+			kept = append(kept, record)
+		case Dropped:
+			report.Dropped++
+		case Failed:
+			return nil, report, &FailedError{Violation: *violation}
+		}
+	}
+	return kept, report, nil
+}
@@ -0,0 +1,166 @@
+// Package rules is a data-quality rules engine. Rules are interchangeable
+// checks of one record (strategies), chained so each record passes every
+// rule in turn unless one stops it (chain of responsibility), and every
+// violation is reported to the subscribed observers as it is found.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Record is one decoded record, keyed by field name.
+type Record = map[string]interface{}
+
+// Rule checks one record and returns why it fails, or "" when it passes.
+type Rule interface {
+	Check(record Record) string
+}
+
+// RuleFunc adapts a function to a Rule.
+type RuleFunc func(record Record) string
+
+func (f RuleFunc) Check(record Record) string {
+	return f(record)
+}
+
+// Required fails records missing field or holding an empty value in it.
+func Required(field string) Rule {
+	return RuleFunc(func(record Record) string {
+		if value, ok := Lookup(record, field); !ok || value == nil || value == "" {
+			return fmt.Sprintf("%s is missing", field)
+		}
+		return ""
+	})
+}
+
+// Pattern fails records whose field, when present, does not match pattern
+// as a whole. This differs on purpose from the pattern rules of lab2's
+// validation package, which match anywhere in the value as JSON Schema
+// does: "[0-9]+" there accepts "a1", here only digits.
+func Pattern(field, pattern string) (Rule, error) {
+	compiled, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern for %s: %w", field, err)
+	}
+	return RuleFunc(func(record Record) string {
+		value, ok := Lookup(record, field)
+		if !ok || value == nil {
+			return ""
+		}
+		if text := fmt.Sprint(value); !compiled.MatchString(text) {
+			return fmt.Sprintf("%s %q does not match %s", field, text, pattern)
+		}
+		return ""
+	}), nil
+}
+
+// Range fails records whose field, when present, is not a number or lies
+// outside the bounds set, inclusive.
+func Range(field string, min, max *float64) Rule {
+	return RuleFunc(func(record Record) string {
+		value, ok := Lookup(record, field)
+		if !ok || value == nil {
+			return ""
+		}
+		n, ok := number(value)
+		switch {
+		case !ok:
+			return fmt.Sprintf("%s %q is not a number", field, fmt.Sprint(value))
+		case min != nil && n < *min:
+			return fmt.Sprintf("%s %v is below %v", field, n, *min)
+		case max != nil && n > *max:
+			return fmt.Sprintf("%s %v is above %v", field, n, *max)
+		}
+		return ""
+	})
+}
+
+// OneOf fails records whose field, when present, holds none of values.
+func OneOf(field string, values ...string) Rule {
+	allowed := make(map[string]bool, len(values))
+	for _, value := range values {
+		allowed[value] = true
+	}
+	return RuleFunc(func(record Record) string {
+		value, ok := Lookup(record, field)
+		if !ok || value == nil {
+			return ""
+		}
+		if text := fmt.Sprint(value); !allowed[text] {
+			return fmt.Sprintf("%s %q is not one of %s", field, text, strings.Join(values, ", "))
+		}
+		return ""
+	})
+}
+
+// Unique fails records repeating a value of field seen in an earlier
+// record. It remembers the values it checked, so each run needs its own.
+func Unique(field string) Rule {
+	seen := make(map[string]bool)
+	return RuleFunc(func(record Record) string {
+		value, ok := Lookup(record, field)
+		if !ok || value == nil {
+			return ""
+		}
+		text := fmt.Sprint(value)
+		if seen[text] {
+			return fmt.Sprintf("%s %q is a duplicate", field, text)
+		}
+		seen[text] = true
+		return ""
+	})
+}
+
+// Lookup returns the value of field in record, following dots into
+// nested records.
+func Lookup(record Record, field string) (interface{}, bool) {
+	var value interface{} = record
+	for _, name := range strings.Split(field, ".") {
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = nested[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// number reads value as a number, whether it was decoded as one or as
+// text, such as a CSV field or an exact decimal. NaN and infinities are
+// not numbers here: they compare false with any bound and would pass Range.
+func number(value interface{}) (float64, bool) {
+	var n float64
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case json.Number:
+		parsed, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		n = parsed
+	case bool:
+		return 0, false
+	default:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(value)), 64)
+		if err != nil {
+			return 0, false
+		}
+		n = parsed
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	return n, true
+}
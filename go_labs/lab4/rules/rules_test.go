@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	ten := 10.0
+	email, err := Pattern("email", `[^@]+@[^@]+`)
+	require.NoError(t, err)
+	_, err = Pattern("email", "(")
+	assert.ErrorContains(t, err, "invalid pattern for email")
+
+	assert.Empty(t, Required("name").Check(Record{"name": "Ann"}))
+	assert.Equal(t, "name is missing", Required("name").Check(Record{"name": ""}))
+	assert.Equal(t, "address.city is missing", Required("address.city").Check(Record{"address": Record{}}))
+	assert.Empty(t, Required("address.city").Check(Record{"address": Record{"city": "Oslo"}}))
+
+	assert.Empty(t, email.Check(Record{"email": "ann@example.com"}))
+	assert.Empty(t, email.Check(Record{}), "missing fields are left to Required")
+	assert.Equal(t, `email "ann" does not match [^@]+@[^@]+`, email.Check(Record{"email": "ann"}))
+
+	amount := Range("amount", nil, &ten)
+	assert.Empty(t, amount.Check(Record{"amount": "9.5"}))
+	assert.Empty(t, amount.Check(Record{"amount": json.Number("10")}))
+	assert.Equal(t, "amount 12 is above 10", amount.Check(Record{"amount": 12.0}))
+	assert.Equal(t, `amount "ten" is not a number`, amount.Check(Record{"amount": "ten"}))
+	assert.Equal(t, `amount "NaN" is not a number`, amount.Check(Record{"amount": "NaN"}))
+	assert.Equal(t, `amount "+Inf" is not a number`, amount.Check(Record{"amount": math.Inf(1)}))
+	assert.Equal(t, "amount -1 is below 0", Range("amount", new(float64), nil).Check(Record{"amount": -1}))
+
+	status := OneOf("status", "open", "closed")
+	assert.Empty(t, status.Check(Record{"status": "open"}))
+	assert.Equal(t, `status "lost" is not one of open, closed`, status.Check(Record{"status": "lost"}))
+
+	id := Unique("id")
+	assert.Empty(t, id.Check(Record{"id": "1"}))
+	assert.Empty(t, id.Check(Record{"id": "2"}))
+	assert.Equal(t, `id "1" is a duplicate`, id.Check(Record{"id": 1}))
+}
+
+func TestEngineChainsRules(t *testing.T) {
+	var seen []string
+	engine := New().
+		Add("id required", Required("id"), Drop).
+		Add("unique id", Unique("id"), Fail).
+		Add("known status", OneOf("status", "open"), Warn).
+		Subscribe(ObserverFunc(func(v Violation) { seen = append(seen, v.String()) }))
+
+	kept, report, err := engine.Run([]Record{
+		{"id": "1", "status": "open"},
+		{"status": "open"},
+		{"id": "2", "status": "lost"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Record{{"id": "1", "status": "open"}, {"id": "2", "status": "lost"}}, kept)
+	assert.Equal(t, Report{Checked: 3, Dropped: 1, Violations: 2}, report)
+	assert.Equal(t, []string{
+		"record 2: id required: id is missing",
+		`record 3: known status: status "lost" is not one of open`,
+	}, seen)
+
+	_, report, err = engine.Run([]Record{{"id": "1"}, {"id": "4"}})
+	var failed *FailedError
+	require.ErrorAs(t, err, &failed)
+	assert.Equal(t, Violation{Record: 1, Rule: "unique id", Message: `id "1" is a duplicate`, Action: Fail}, failed.Violation)
+	assert.EqualError(t, err, `data quality check failed: record 1: unique id: id "1" is a duplicate`)
+	assert.Equal(t, 1, report.Checked)
+	assert.Len(t, engine.observers, 1, "runs do not subscribe")
+}
+
+func TestParseAction(t *testing.T) {
+	action, err := ParseAction("")
+	require.NoError(t, err)
+	assert.Equal(t, Warn, action)
+	action, err = ParseAction("drop")
+	require.NoError(t, err)
+	assert.Equal(t, Drop, action)
+	_, err = ParseAction("ignore")
+	assert.EqualError(t, err, `unknown action "ignore"; use warn, drop or fail`)
+}